@@ -0,0 +1,138 @@
+// Package api exposes dockswap's deployment operations over HTTP, modeled
+// on the route surface of the Docker Engine API (GET/POST under a
+// versioned /v1/ prefix, one resource per app). It lets dockswap run as a
+// controllable blue/green deployment service - triggering and observing
+// deployments from a dashboard or CI pipeline - instead of only ever being
+// driven from its own CLI.
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+
+	"dockswap/internal/config"
+	"dockswap/internal/docker"
+	"dockswap/internal/events"
+	"dockswap/internal/logger"
+	"dockswap/internal/state"
+)
+
+// Server binds addr and serves the /v1/apps route surface against a
+// running DeploymentOrchestrator. It holds no state of its own beyond the
+// http.Server - configs, orchestrator and db are all owned by whoever
+// constructs it (handleServe) and shared by reference, the same way
+// DeploymentOrchestrator shares its configs map with the CLI.
+type Server struct {
+	addr         string
+	configs      map[string]*config.AppConfig
+	orchestrator *docker.DeploymentOrchestrator
+	db           *sql.DB
+	bus          *events.Bus
+	ring         *events.RingBuffer
+	log          logger.Logger
+
+	httpServer *http.Server
+}
+
+// NewServer builds a Server listening on addr. bus and ring back the
+// per-app event stream GET /v1/apps/{name}/events serves; ring may be nil,
+// in which case that endpoint only streams events published after the
+// client connects.
+func NewServer(addr string, configs map[string]*config.AppConfig, orchestrator *docker.DeploymentOrchestrator, db *sql.DB, bus *events.Bus, ring *events.RingBuffer, log logger.Logger) *Server {
+	s := &Server{
+		addr:         addr,
+		configs:      configs,
+		orchestrator: orchestrator,
+		db:           db,
+		bus:          bus,
+		ring:         ring,
+		log:          log,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apps", s.handleApps)
+	mux.HandleFunc("/v1/apps/", s.handleAppByName)
+	s.httpServer = &http.Server{Addr: addr, Handler: mux}
+	return s
+}
+
+// Start begins serving in the background and returns once the listener is
+// up, so a caller logging "API listening on %s" right after Start knows the
+// address is actually bound. Serve errors other than the ones Stop causes
+// (http.ErrServerClosed) are logged rather than returned, since by the time
+// they happen the caller has moved on to its own select loop.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+
+	go func() {
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			s.log.Error("api: server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop gracefully shuts the HTTP server down, waiting for in-flight
+// requests (including any open event stream) to finish or ctx to expire.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// appSummary is the JSON shape returned by GET /v1/apps and GET
+// /v1/apps/{name}: the currently persisted state plus whether this
+// process's orchestrator has a deployment in progress right now, which
+// current_state alone can't tell you since a crash mid-deployment leaves
+// status stuck at "deploying" until the next reconcile.
+type appSummary struct {
+	Name        string `json:"name"`
+	ActiveColor string `json:"active_color,omitempty"`
+	Image       string `json:"image,omitempty"`
+	Status      string `json:"status,omitempty"`
+	InProgress  bool   `json:"in_progress"`
+}
+
+func (s *Server) appSummaries() ([]appSummary, error) {
+	states, err := state.GetAllCurrentStates(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current states: %w", err)
+	}
+	byName := make(map[string]state.CurrentState, len(states))
+	for _, cs := range states {
+		byName[cs.AppName] = cs
+	}
+
+	out := make([]appSummary, 0, len(s.configs))
+	for name := range s.configs {
+		summary := appSummary{Name: name, InProgress: s.orchestrator.InProgress(name)}
+		if cs, ok := byName[name]; ok {
+			summary.ActiveColor = cs.ActiveColor
+			summary.Image = cs.Image
+			summary.Status = cs.Status
+		}
+		out = append(out, summary)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// handleApps serves GET /v1/apps.
+func (s *Server) handleApps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, methodNotAllowed(r.Method))
+		return
+	}
+
+	summaries, err := s.appSummaries()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
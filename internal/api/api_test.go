@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"dockswap/internal/config"
+	"dockswap/internal/docker"
+	"dockswap/internal/events"
+	"dockswap/internal/logger"
+	"dockswap/internal/state"
+)
+
+func newTestServer(t *testing.T, configs map[string]*config.AppConfig) *Server {
+	t.Helper()
+	db, err := state.OpenAndMigrate(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	orchestrator := docker.NewDeploymentOrchestrator(nil, nil, configs)
+	orchestrator.SetDB(db)
+
+	bus := events.NewBus()
+	ring := events.NewRingBuffer(0)
+	bus.Subscribe(ring)
+
+	return NewServer("", configs, orchestrator, db, bus, ring, logger.New(logger.LevelError))
+}
+
+func TestHandleAppsListsConfiguredApps(t *testing.T) {
+	configs := map[string]*config.AppConfig{"app1": {Name: "app1"}}
+	s := newTestServer(t, configs)
+	require.NoError(t, state.UpsertCurrentState(s.db, "app1", 1, "blue", "nginx:1.21", "ready"))
+
+	rr := httptest.NewRecorder()
+	s.handleApps(rr, httptest.NewRequest(http.MethodGet, "/v1/apps", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var got []appSummary
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Len(t, got, 1)
+	require.Equal(t, "app1", got[0].Name)
+	require.Equal(t, "blue", got[0].ActiveColor)
+}
+
+func TestHandleAppByNameUnknownAppIsNotFound(t *testing.T) {
+	s := newTestServer(t, map[string]*config.AppConfig{})
+
+	rr := httptest.NewRecorder()
+	s.handleAppByName(rr, httptest.NewRequest(http.MethodGet, "/v1/apps/missing", nil))
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	var got errorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Contains(t, got.Message, "missing")
+}
+
+func TestHandleDeployRejectsMissingImage(t *testing.T) {
+	configs := map[string]*config.AppConfig{"app1": {Name: "app1"}}
+	s := newTestServer(t, configs)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/apps/app1/deploy", strings.NewReader(`{}`))
+	s.handleAppByName(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandleDeployAcceptsAndReturns202(t *testing.T) {
+	configs := map[string]*config.AppConfig{"app1": {Name: "app1"}}
+	s := newTestServer(t, configs)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/apps/app1/deploy", strings.NewReader(`{"image":"nginx:1.22"}`))
+	s.handleAppByName(rr, req)
+
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	var got deployResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, "nginx:1.22", got.Image)
+	require.Equal(t, "accepted", got.Status)
+}
+
+func TestHandleRollbackRequiresPriorDeployment(t *testing.T) {
+	configs := map[string]*config.AppConfig{"app1": {Name: "app1"}}
+	s := newTestServer(t, configs)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/apps/app1/rollback", nil)
+	s.handleAppByName(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestHandleRollbackRedeploysPreviousImage(t *testing.T) {
+	configs := map[string]*config.AppConfig{"app1": {Name: "app1"}}
+	s := newTestServer(t, configs)
+
+	_, err := state.InsertDeployment(s.db, "app1", 0, "nginx:1.20", "stable", "blue", nil)
+	require.NoError(t, err)
+	_, err = state.InsertDeployment(s.db, "app1", 0, "nginx:1.21", "stable", "green", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/apps/app1/rollback", nil)
+	s.handleAppByName(rr, req)
+
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	var got deployResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, "nginx:1.20", got.Image)
+}
+
+func TestMethodNotAllowedMapsTo405(t *testing.T) {
+	s := newTestServer(t, map[string]*config.AppConfig{})
+
+	rr := httptest.NewRecorder()
+	s.handleApps(rr, httptest.NewRequest(http.MethodPost, "/v1/apps", nil))
+
+	require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
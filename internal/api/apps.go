@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"dockswap/internal/deployment"
+	"dockswap/internal/docker/errdefs"
+	"dockswap/internal/state"
+)
+
+// handleAppByName serves everything under /v1/apps/{name}, dispatching on
+// the path suffix the same way fakeserver.Server.handleContainerByID splits
+// /containers/{id}/{action}.
+func (s *Server) handleAppByName(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/v1/apps/")
+	parts := strings.SplitN(rest, "/", 2)
+	appName := parts[0]
+	if appName == "" {
+		writeError(w, errdefs.NewInvalidParameter(fmt.Errorf("app name is required")))
+		return
+	}
+
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	if _, exists := s.configs[appName]; !exists {
+		writeError(w, errdefs.NewNotFound(fmt.Errorf("no configuration found for app %s", appName)))
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		s.handleGetApp(w, r, appName)
+	case action == "deploy" && r.Method == http.MethodPost:
+		s.handleDeploy(w, r, appName)
+	case action == "rollback" && r.Method == http.MethodPost:
+		s.handleRollback(w, r, appName)
+	case action == "cancel" && r.Method == http.MethodPost:
+		s.handleCancel(w, r, appName)
+	case action == "pause" && r.Method == http.MethodPost:
+		s.handlePause(w, r, appName)
+	case action == "force-rollback" && r.Method == http.MethodPost:
+		s.handleForceRollback(w, r, appName)
+	case action == "events" && r.Method == http.MethodGet:
+		s.handleAppEvents(w, r, appName)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleGetApp serves GET /v1/apps/{name}: the same appSummary GET
+// /v1/apps returns for every app, narrowed to one.
+func (s *Server) handleGetApp(w http.ResponseWriter, r *http.Request, appName string) {
+	summaries, err := s.appSummaries()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, summary := range summaries {
+		if summary.Name == appName {
+			writeJSON(w, http.StatusOK, summary)
+			return
+		}
+	}
+	writeError(w, errdefs.NewNotFound(fmt.Errorf("no state recorded for app %s", appName)))
+}
+
+// deployRequest is the JSON body POST /v1/apps/{name}/deploy expects.
+type deployRequest struct {
+	Image string `json:"image"`
+}
+
+// deployResponse is returned immediately once the deployment has been
+// handed to the orchestrator's worker pool; it does not wait for the
+// deployment to settle; GET /v1/apps/{name} or the events stream reports
+// the outcome.
+type deployResponse struct {
+	AppName string `json:"app_name"`
+	Image   string `json:"image"`
+	Status  string `json:"status"`
+}
+
+// handleDeploy serves POST /v1/apps/{name}/deploy, starting a deployment
+// via DeployAsync - the same non-blocking path DeployAll uses for
+// fleet-wide rollouts - so a slow health check can't hold an HTTP
+// connection open for the 10-minute deployment timeout.
+func (s *Server) handleDeploy(w http.ResponseWriter, r *http.Request, appName string) {
+	var req deployRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errdefs.NewInvalidParameter(fmt.Errorf("invalid request body: %w", err)))
+		return
+	}
+	if req.Image == "" {
+		writeError(w, errdefs.NewInvalidParameter(fmt.Errorf("image is required")))
+		return
+	}
+
+	handle := s.orchestrator.DeployAsync(appName, req.Image)
+	go func() {
+		if err := handle.Wait(); err != nil {
+			s.log.Error("api: deploy of %s to %s failed: %v", appName, req.Image, err)
+		}
+	}()
+
+	writeJSON(w, http.StatusAccepted, deployResponse{AppName: appName, Image: req.Image, Status: "accepted"})
+}
+
+// handleRollback serves POST /v1/apps/{name}/rollback: it looks up the
+// image from appName's deployment before its current one and re-deploys
+// it via DeployAsync, the same way an operator would re-run `dockswap
+// deploy <app> <previous-image>` by hand.
+func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request, appName string) {
+	history, err := state.GetDeploymentHistory(s.db, appName)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to get deployment history: %w", err))
+		return
+	}
+	if len(history) < 2 {
+		writeError(w, errdefs.NewConflict(fmt.Errorf("app %s has no prior deployment to roll back to", appName)))
+		return
+	}
+
+	previous := history[1]
+	handle := s.orchestrator.DeployAsync(appName, previous.Image)
+	go func() {
+		if err := handle.Wait(); err != nil {
+			s.log.Error("api: rollback of %s to %s failed: %v", appName, previous.Image, err)
+		}
+	}()
+
+	writeJSON(w, http.StatusAccepted, deployResponse{AppName: appName, Image: previous.Image, Status: "accepted"})
+}
+
+// transitionResponse is returned by the cancel/pause/force-rollback
+// endpoints once the DesiredTransition has been registered; like
+// deployResponse, it doesn't wait for the deployment to actually settle.
+type transitionResponse struct {
+	AppName string `json:"app_name"`
+	Status  string `json:"status"`
+}
+
+// withDesiredTransition applies mutate to appName's current DesiredTransition
+// (so setting one field, e.g. Cancel, doesn't clobber another already set,
+// e.g. Pause) and registers the result with the orchestrator.
+func (s *Server) withDesiredTransition(appName string, mutate func(*deployment.DesiredTransition)) error {
+	dt, err := s.orchestrator.GetDesiredTransition(appName)
+	if err != nil {
+		return err
+	}
+	mutate(&dt)
+	return s.orchestrator.SetDesiredTransition(appName, dt)
+}
+
+// handleCancel serves POST /v1/apps/{name}/cancel, setting DesiredTransition
+// .Cancel so the app's state machine aborts on its next ProcessEvent call if
+// it's still early enough to back out cleanly (StateStarting/
+// StateHealthCheck); it's a no-op once the cutover has already landed.
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request, appName string) {
+	cancel := true
+	if err := s.withDesiredTransition(appName, func(dt *deployment.DesiredTransition) { dt.Cancel = &cancel }); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, transitionResponse{AppName: appName, Status: "cancel_requested"})
+}
+
+// pauseRequest is the JSON body POST /v1/apps/{name}/pause expects; Pause
+// defaults to false (a missing/empty body resumes a previously paused
+// deployment) so the same endpoint toggles both directions.
+type pauseRequest struct {
+	Pause bool `json:"pause"`
+}
+
+// handlePause serves POST /v1/apps/{name}/pause, setting DesiredTransition
+// .Pause so the app's runDeploymentLoop holds at its current state instead
+// of polling the next health-check/canary-step tick, without touching
+// whatever action is already in flight.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request, appName string) {
+	var req pauseRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
+			writeError(w, errdefs.NewInvalidParameter(fmt.Errorf("invalid request body: %w", err)))
+			return
+		}
+	}
+
+	if err := s.withDesiredTransition(appName, func(dt *deployment.DesiredTransition) { dt.Pause = &req.Pause }); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status := "resumed"
+	if req.Pause {
+		status = "paused"
+	}
+	writeJSON(w, http.StatusAccepted, transitionResponse{AppName: appName, Status: status})
+}
+
+// handleForceRollback serves POST /v1/apps/{name}/force-rollback, setting
+// DesiredTransition.ForceRollback so the app's state machine reverses a
+// cutover that has already landed (StateDraining) - restoring traffic to
+// the previous color and draining the new one - on its next ProcessEvent
+// call.
+func (s *Server) handleForceRollback(w http.ResponseWriter, r *http.Request, appName string) {
+	forceRollback := true
+	if err := s.withDesiredTransition(appName, func(dt *deployment.DesiredTransition) { dt.ForceRollback = &forceRollback }); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, transitionResponse{AppName: appName, Status: "force_rollback_requested"})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"dockswap/internal/docker/errdefs"
+)
+
+// methodNotAllowed wraps an unsupported HTTP method as a
+// *methodNotAllowedError, which writeError maps to 405 Method Not Allowed,
+// so it goes through the same writeError path as every other handler error
+// rather than a one-off http.Error call.
+func methodNotAllowed(method string) error {
+	return &methodNotAllowedError{method: method}
+}
+
+type methodNotAllowedError struct{ method string }
+
+func (e *methodNotAllowedError) Error() string {
+	return fmt.Sprintf("method %s not allowed", e.method)
+}
+
+// errorResponse is the JSON body every non-2xx response carries, modeled on
+// the moby API's {"message": "..."} error shape.
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// writeError classifies err through errdefs (falling back to 500 for
+// anything unclassified, e.g. a raw database/sql error) and writes it as a
+// JSON error body, rather than string-matching err.Error() to pick a status
+// code.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errdefs.IsNotFound(err):
+		status = http.StatusNotFound
+	case errdefs.IsConflict(err):
+		status = http.StatusConflict
+	case errdefs.IsInvalidParameter(err):
+		status = http.StatusBadRequest
+	case errdefs.IsForbidden(err):
+		status = http.StatusForbidden
+	case errdefs.IsUnavailable(err):
+		status = http.StatusServiceUnavailable
+	case isMethodNotAllowed(err):
+		status = http.StatusMethodNotAllowed
+	}
+	writeJSON(w, status, errorResponse{Message: err.Error()})
+}
+
+func isMethodNotAllowed(err error) bool {
+	_, ok := err.(*methodNotAllowedError)
+	return ok
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
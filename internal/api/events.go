@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"dockswap/internal/docker/errdefs"
+	"dockswap/internal/events"
+)
+
+// eventPayload is the JSON shape written for every SSE frame, matching the
+// field set events.WebhookSubscriber already POSTs elsewhere so a client
+// watching both sees the same vocabulary.
+type eventPayload struct {
+	AppName   string    `json:"app_name"`
+	Color     string    `json:"color"`
+	FromState string    `json:"from_state"`
+	ToState   string    `json:"to_state"`
+	Image     string    `json:"image"`
+	Timestamp time.Time `json:"timestamp"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func toPayload(e events.Event) eventPayload {
+	p := eventPayload{
+		AppName:   e.AppName,
+		Color:     e.Color,
+		FromState: e.FromState,
+		ToState:   e.ToState,
+		Image:     e.Image,
+		Timestamp: e.Timestamp,
+	}
+	if e.Error != nil {
+		p.Error = e.Error.Error()
+	}
+	return p
+}
+
+// chanSubscriber is an events.Subscriber that forwards every Event for one
+// app onto a buffered channel, for handleAppEvents to drain into an SSE
+// stream. Notify drops an event rather than blocking the publisher
+// (whatever deployment triggered it) if the client has fallen behind and
+// the buffer is full.
+type chanSubscriber struct {
+	appName string
+	ch      chan events.Event
+}
+
+func (c *chanSubscriber) Notify(e events.Event) {
+	if e.AppName != c.appName {
+		return
+	}
+	select {
+	case c.ch <- e:
+	default:
+	}
+}
+
+// handleAppEvents serves GET /v1/apps/{name}/events as a Server-Sent
+// Events stream: the ring buffer's backlog for appName first (if any),
+// then every future lifecycle event for it, one JSON object per `data:`
+// line. The connection stays open until the client disconnects or the
+// server shuts down.
+func (s *Server) handleAppEvents(w http.ResponseWriter, r *http.Request, appName string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errdefs.NewSystem(fmt.Errorf("streaming unsupported by this response writer")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if s.ring != nil {
+		for _, e := range s.ring.Filter(func(e events.Event) bool { return e.AppName == appName }) {
+			writeSSE(w, toPayload(e))
+		}
+		flusher.Flush()
+	}
+
+	if s.bus == nil {
+		return
+	}
+
+	// events.Bus has no Unsubscribe (same as its webhook/file subscribers),
+	// so this subscriber outlives the request - a disconnected client's
+	// Notify calls just hit the buffered channel's default case forever.
+	sub := &chanSubscriber{appName: appName, ch: make(chan events.Event, 32)}
+	s.bus.Subscribe(sub)
+
+	for {
+		select {
+		case e := <-sub.ch:
+			writeSSE(w, toPayload(e))
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, payload eventPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", body)
+}
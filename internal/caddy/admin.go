@@ -0,0 +1,88 @@
+package caddy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AdminConfigFilename is the optional file alongside a CaddyManager's
+// ConfigPath/TemplatePath (typically "<config-dir>/caddy/admin.yaml") that
+// points ReloadCaddy/ValidateRunning/SurgicalSwapUpstream at Caddy's admin
+// API over a Unix socket instead of the default TCP AdminURL - the socket
+// Caddy listens on when its own config sets "admin": {"listen":
+// "unix//..."}. Its absence is the common case: every caller keeps using
+// AdminURL exactly as before AdminConfigFilename existed.
+const AdminConfigFilename = "admin.yaml"
+
+// AdminConfig selects the transport CaddyManager talks to Caddy's admin API
+// over, read from AdminConfigFilename.
+type AdminConfig struct {
+	// Socket is a filesystem path to Caddy's admin API Unix socket. Empty
+	// (the file absent, or Socket unset within it) means "keep using
+	// AdminURL over TCP".
+	Socket string `yaml:"socket"`
+}
+
+// LoadAdminConfig reads AdminConfigFilename from caddyDir (the directory
+// holding caddy.json/template.json), defaulting to an empty AdminConfig -
+// TCP AdminURL, unchanged - when the file is absent.
+func LoadAdminConfig(caddyDir string) (AdminConfig, error) {
+	path := filepath.Join(caddyDir, AdminConfigFilename)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return AdminConfig{}, nil
+	}
+	if err != nil {
+		return AdminConfig{}, fmt.Errorf("failed to read %s: %w", AdminConfigFilename, err)
+	}
+
+	var cfg AdminConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return AdminConfig{}, fmt.Errorf("failed to parse %s: %w", AdminConfigFilename, err)
+	}
+	return cfg, nil
+}
+
+// SetAdminSocket points cm at Caddy's admin API over a Unix socket instead
+// of AdminURL's TCP address - ReloadCaddy, ValidateRunning, and
+// SurgicalSwapUpstream all dial it once set.
+func (cm *CaddyManager) SetAdminSocket(socket string) {
+	cm.AdminSocket = socket
+}
+
+// adminClient returns the http.Client ReloadCaddy/ValidateRunning/
+// SurgicalSwapUpstream should issue requests with: cm.client unchanged when
+// AdminSocket is unset, else a client whose transport dials AdminSocket
+// instead of resolving the request URL's host.
+func (cm *CaddyManager) adminClient() *http.Client {
+	if cm.AdminSocket == "" {
+		return cm.client
+	}
+	socket := cm.AdminSocket
+	return &http.Client{
+		Timeout: cm.client.Timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// adminBaseURL returns the base URL admin API requests are built against:
+// cm.AdminURL unchanged when AdminSocket is unset, else a placeholder host
+// adminClient's DialContext ignores in favor of the Unix socket.
+func (cm *CaddyManager) adminBaseURL() string {
+	if cm.AdminSocket == "" {
+		return cm.AdminURL
+	}
+	return "http://unix"
+}
@@ -0,0 +1,197 @@
+package caddy
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dockswap/internal/config"
+	"dockswap/internal/state"
+)
+
+func TestLoadAdminConfig(t *testing.T) {
+	t.Run("absent file defaults to empty socket", func(t *testing.T) {
+		cfg, err := LoadAdminConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadAdminConfig() failed: %v", err)
+		}
+		if cfg.Socket != "" {
+			t.Errorf("Socket = %q, want empty", cfg.Socket)
+		}
+	})
+
+	t.Run("reads socket from admin.yaml", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "socket: /var/run/caddy-admin.sock\n"
+		if err := os.WriteFile(filepath.Join(dir, AdminConfigFilename), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write admin.yaml: %v", err)
+		}
+
+		cfg, err := LoadAdminConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadAdminConfig() failed: %v", err)
+		}
+		if cfg.Socket != "/var/run/caddy-admin.sock" {
+			t.Errorf("Socket = %q, want /var/run/caddy-admin.sock", cfg.Socket)
+		}
+	})
+}
+
+// serveUnixSocket starts an http.Server listening on a Unix socket under
+// t.TempDir() (avoiding the 104-char path limit real XDG/tmp paths can hit)
+// and returns its path, shutting the listener down on test cleanup.
+func serveUnixSocket(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "admin.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen on unix socket: %v", err)
+	}
+
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return socketPath
+}
+
+func TestReloadCaddyOverUnixSocket(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"apps":{}}`), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	var gotPath, gotMethod string
+	socket := serveUnixSocket(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotMethod = r.URL.Path, r.Method
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cm := New(configPath, "/test/template.json")
+	cm.SetAdminSocket(socket)
+
+	if err := cm.ReloadCaddy(); err != nil {
+		t.Fatalf("ReloadCaddy() over unix socket failed: %v", err)
+	}
+	if gotMethod != "POST" || gotPath != "/load" {
+		t.Errorf("expected POST /load, got %s %s", gotMethod, gotPath)
+	}
+}
+
+func TestSurgicalSwapUpstream(t *testing.T) {
+	t.Run("successful swap", func(t *testing.T) {
+		var gotPath, gotMethod, gotBody string
+		socket := serveUnixSocket(t, func(w http.ResponseWriter, r *http.Request) {
+			gotPath, gotMethod = r.URL.Path, r.Method
+			buf := make([]byte, 1024)
+			n, _ := r.Body.Read(buf)
+			gotBody = string(buf[:n])
+			w.WriteHeader(http.StatusOK)
+		})
+
+		cm := New("/test/config.json", "/test/template.json")
+		cm.SetAdminSocket(socket)
+
+		if err := cm.SurgicalSwapUpstream("test-app", 0, "localhost:8082"); err != nil {
+			t.Fatalf("SurgicalSwapUpstream() failed: %v", err)
+		}
+		if gotMethod != http.MethodPatch || gotPath != "/config/apps/http/servers/test-app/routes/0/handle/0/upstreams" {
+			t.Errorf("unexpected request: %s %s", gotMethod, gotPath)
+		}
+		if !strings.Contains(gotBody, `"dial":"localhost:8082"`) {
+			t.Errorf("unexpected request body: %s", gotBody)
+		}
+	})
+
+	t.Run("patch failure", func(t *testing.T) {
+		socket := serveUnixSocket(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		})
+
+		cm := New("/test/config.json", "/test/template.json")
+		cm.SetAdminSocket(socket)
+
+		if err := cm.SurgicalSwapUpstream("test-app", 0, "localhost:8082"); err == nil {
+			t.Errorf("SurgicalSwapUpstream() should fail when caddy returns an error status")
+		}
+	})
+}
+
+func TestUpdateAppRoutingPrefersSurgicalSwap(t *testing.T) {
+	var loadCalled, patchCalled bool
+	socket := serveUnixSocket(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/load":
+			loadCalled = true
+		default:
+			patchCalled = true
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tempDir := t.TempDir()
+	cm := New(filepath.Join(tempDir, "config.json"), filepath.Join(tempDir, "template.json"))
+	cm.SetAdminSocket(socket)
+
+	configs := map[string]*config.AppConfig{
+		"test-app": {Name: "test-app", Proxy: config.Proxy{ListenPort: 80}, Ports: config.Ports{Blue: 8081, Green: 8082}},
+	}
+	states := map[string]*state.AppState{
+		"test-app": {Name: "test-app", ActiveColor: "blue"},
+	}
+
+	if err := cm.UpdateAppRouting("test-app", configs, states); err != nil {
+		t.Fatalf("UpdateAppRouting() failed: %v", err)
+	}
+	if loadCalled {
+		t.Errorf("expected UpdateAppRouting() to use the surgical swap, but it POSTed /load")
+	}
+	if !patchCalled {
+		t.Errorf("expected UpdateAppRouting() to PATCH the upstream")
+	}
+}
+
+func TestUpdateAppRoutingFallsBackForWeightedSlots(t *testing.T) {
+	var loadCalled bool
+	socket := serveUnixSocket(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/load" {
+			loadCalled = true
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "template.json")
+	if err := os.WriteFile(templatePath, []byte(`{"apps":{}}`), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	cm := New(filepath.Join(tempDir, "config.json"), templatePath)
+	cm.SetAdminSocket(socket)
+
+	configs := map[string]*config.AppConfig{
+		"test-app": {
+			Name:  "test-app",
+			Proxy: config.Proxy{ListenPort: 80},
+			Slots: map[string]config.SlotConfig{
+				"blue":   {Port: 8081, Weight: 70},
+				"canary": {Port: 8083, Weight: 30},
+			},
+		},
+	}
+	states := map[string]*state.AppState{
+		"test-app": {Name: "test-app", ActiveColor: "blue"},
+	}
+
+	if err := cm.UpdateAppRouting("test-app", configs, states); err != nil {
+		t.Fatalf("UpdateAppRouting() failed: %v", err)
+	}
+	if !loadCalled {
+		t.Errorf("expected UpdateAppRouting() to fall back to a full /load for weighted slots")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"text/template"
 	"time"
 
@@ -15,18 +17,77 @@ import (
 	"dockswap/internal/state"
 )
 
+// ProxyManager abstracts the reverse-proxy backend that publishes dockswap's
+// blue/green routing - which upstream port each app's active color (or
+// weighted slot) currently points at - so a backend other than Caddy (Nginx,
+// Traefik, ...) can be swapped in without touching deployment orchestration.
+// This mirrors how Docker's daemon abstracts execdrivers/graphdrivers behind
+// interfaces so alternate implementations plug in unchanged.
+type ProxyManager interface {
+	ValidateRunning() error
+	UpdateAppRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error
+	RollbackRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error
+
+	// UpdateWeightedRouting re-renders appName's routing split across
+	// weights (slot/color name -> traffic percent, summing to 100) instead
+	// of sending it all to one active color, for a canary rollout's
+	// stepped weight stages. Every other app's routing, taken from
+	// configs/states, is unaffected.
+	UpdateWeightedRouting(appName string, weights map[string]int, configs map[string]*config.AppConfig, states map[string]*state.AppState) error
+}
+
+// NewProxyManager constructs the ProxyManager backend named by backend.
+// "caddy" (and the empty string, preserving existing behavior) selects
+// CaddyManager; "nginx" selects NginxManager.
+func NewProxyManager(backend, configPath, templatePath string) (ProxyManager, error) {
+	switch backend {
+	case "", "caddy":
+		return New(configPath, templatePath), nil
+	case "nginx":
+		return NewNginxManager(configPath, templatePath), nil
+	default:
+		return nil, fmt.Errorf("unknown proxy backend %q", backend)
+	}
+}
+
 type CaddyManager struct {
 	AdminURL     string
 	ConfigPath   string
 	TemplatePath string
-	client       *http.Client
+	// AdminSocket, if set via SetAdminSocket/LoadAdminConfig, is a Unix
+	// socket path ReloadCaddy/ValidateRunning/SurgicalSwapUpstream dial
+	// instead of AdminURL's TCP address. See adminClient/adminBaseURL.
+	AdminSocket string
+	client      *http.Client
+
+	// mu serializes GenerateConfig and ReloadCaddy against one another so
+	// two apps flipping colors at once can't interleave writes to
+	// ConfigPath or race a write against a reload of the file it just
+	// replaced.
+	mu sync.Mutex
 }
 
 type AppTemplateData struct {
 	Name       string
 	Proxy      config.Proxy
 	ActivePort int
-	IsLast     bool
+
+	// Slots lists every declared config.SlotConfig (blue/green, or
+	// additional canary slots), sorted by name, for templates that render
+	// weighted upstreams instead of (or alongside) the single ActivePort.
+	// Empty for configs with no Slots declared.
+	Slots []SlotTemplateData
+
+	IsLast bool
+}
+
+// SlotTemplateData is one deployment slot's port and traffic weight, for
+// templates doing weighted reverse-proxy routing across more than just the
+// single ActivePort.
+type SlotTemplateData struct {
+	Name   string
+	Port   int
+	Weight int
 }
 
 type TemplateData struct {
@@ -50,8 +111,9 @@ func (cm *CaddyManager) SetAdminURL(url string) {
 	cm.AdminURL = url
 }
 
-func (cm *CaddyManager) ValidateCaddyRunning() error {
-	resp, err := cm.client.Get(cm.AdminURL + "/")
+// ValidateRunning satisfies ProxyManager by checking Caddy's admin API.
+func (cm *CaddyManager) ValidateRunning() error {
+	resp, err := cm.adminClient().Get(cm.adminBaseURL() + "/")
 	if err != nil {
 		return fmt.Errorf("caddy admin API not accessible at %s: %w", cm.AdminURL, err)
 	}
@@ -65,6 +127,9 @@ func (cm *CaddyManager) ValidateCaddyRunning() error {
 }
 
 func (cm *CaddyManager) GenerateConfig(configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	templateContent, err := os.ReadFile(cm.TemplatePath)
 	if err != nil {
 		return fmt.Errorf("failed to read template file %s: %w", cm.TemplatePath, err)
@@ -75,7 +140,7 @@ func (cm *CaddyManager) GenerateConfig(configs map[string]*config.AppConfig, sta
 		return fmt.Errorf("failed to parse template: %w", err)
 	}
 
-	templateData, err := cm.buildTemplateData(configs, states)
+	templateData, err := buildTemplateData(configs, states)
 	if err != nil {
 		return fmt.Errorf("failed to build template data: %w", err)
 	}
@@ -102,19 +167,22 @@ func (cm *CaddyManager) GenerateConfig(configs map[string]*config.AppConfig, sta
 }
 
 func (cm *CaddyManager) ReloadCaddy() error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	configContent, err := os.ReadFile(cm.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to read config file %s: %w", cm.ConfigPath, err)
 	}
 
-	req, err := http.NewRequest("POST", cm.AdminURL+"/load", bytes.NewReader(configContent))
+	req, err := http.NewRequest("POST", cm.adminBaseURL()+"/load", bytes.NewReader(configContent))
 	if err != nil {
 		return fmt.Errorf("failed to create reload request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := cm.client.Do(req)
+	resp, err := cm.adminClient().Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send reload request to caddy: %w", err)
 	}
@@ -128,7 +196,20 @@ func (cm *CaddyManager) ReloadCaddy() error {
 	return nil
 }
 
+// UpdateAppRouting satisfies ProxyManager. When AdminSocket is configured,
+// it first tries surgicalUpdateAppRouting - a single PATCH to appName's
+// route instead of the full GenerateConfig+ReloadCaddy below, avoiding the
+// brief connection-draining window a full /load causes across every other
+// app's routes, not just the one cutting over. Any failure there (socket
+// unreachable, a weighted-Slots config the surgical path doesn't support,
+// ...) falls back to the full path rather than failing the cutover outright.
 func (cm *CaddyManager) UpdateAppRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	if cm.AdminSocket != "" {
+		if err := cm.surgicalUpdateAppRouting(appName, configs, states); err == nil {
+			return nil
+		}
+	}
+
 	if err := cm.GenerateConfig(configs, states); err != nil {
 		return fmt.Errorf("failed to generate config for app %s: %w", appName, err)
 	}
@@ -140,7 +221,123 @@ func (cm *CaddyManager) UpdateAppRouting(appName string, configs map[string]*con
 	return nil
 }
 
-func (cm *CaddyManager) buildTemplateData(configs map[string]*config.AppConfig, states map[string]*state.AppState) (*TemplateData, error) {
+// surgicalUpdateAppRouting swaps appName's single upstream via
+// SurgicalSwapUpstream instead of a full reload. It only applies to the
+// plain blue/green case - no weighted Slots, where "the" upstream isn't
+// well-defined - and returns an error for anything else so
+// UpdateAppRouting falls back to GenerateConfig+ReloadCaddy.
+func (cm *CaddyManager) surgicalUpdateAppRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	appConfig, ok := configs[appName]
+	if !ok {
+		return fmt.Errorf("no configuration found for app %s", appName)
+	}
+	if len(appConfig.Slots) > 1 {
+		return fmt.Errorf("surgical swap does not support weighted slots")
+	}
+
+	appState, ok := states[appName]
+	if !ok {
+		return fmt.Errorf("no state found for app %s", appName)
+	}
+
+	activePort, err := getActivePort(appConfig, appState)
+	if err != nil {
+		return err
+	}
+
+	return cm.SurgicalSwapUpstream(appName, 0, fmt.Sprintf("localhost:%d", activePort))
+}
+
+// SurgicalSwapUpstream PATCHes serverName's routeIndex'th route to dial
+// instead of its current upstream, the "PATCH
+// /config/apps/http/servers/.../routes/N/handle/0/upstreams" Caddy admin
+// API call - a single-upstream swap rather than the full config ReloadCaddy
+// replaces wholesale. serverName/routeIndex must match how GenerateConfig's
+// template laid the server out: CreateDefaultTemplate's default keys each
+// server by app name with exactly one route, so serverName is the app name
+// and routeIndex is 0.
+func (cm *CaddyManager) SurgicalSwapUpstream(serverName string, routeIndex int, dial string) error {
+	body, err := json.Marshal([]map[string]string{{"dial": dial}})
+	if err != nil {
+		return fmt.Errorf("failed to encode upstream patch: %w", err)
+	}
+
+	path := fmt.Sprintf("/config/apps/http/servers/%s/routes/%d/handle/0/upstreams", serverName, routeIndex)
+	req, err := http.NewRequest(http.MethodPatch, cm.adminBaseURL()+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create upstream patch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cm.adminClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send upstream patch to caddy: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("caddy upstream patch failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// RollbackRouting satisfies ProxyManager. Caddy has no separate "undo"
+// operation: re-publishing whatever states the caller now considers correct
+// is the same generate-then-reload flow as UpdateAppRouting.
+func (cm *CaddyManager) RollbackRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	return cm.UpdateAppRouting(appName, configs, states)
+}
+
+// UpdateWeightedRouting satisfies ProxyManager by rendering appName's Slots
+// with weights substituted in for the configured ones, then generating and
+// reloading exactly like UpdateAppRouting.
+func (cm *CaddyManager) UpdateWeightedRouting(appName string, weights map[string]int, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	weightedConfigs, err := weightedAppConfigs(appName, weights, configs)
+	if err != nil {
+		return err
+	}
+
+	if err := cm.GenerateConfig(weightedConfigs, states); err != nil {
+		return fmt.Errorf("failed to generate weighted config for app %s: %w", appName, err)
+	}
+
+	return cm.ReloadCaddy()
+}
+
+// weightedAppConfigs returns a shallow copy of configs with appName's Slots
+// overridden by weights (slot name -> traffic percent), for
+// UpdateWeightedRouting/NginxManager.UpdateWeightedRouting to render a
+// partial-traffic split instead of the statically configured weights. Every
+// other app's config is passed through unchanged.
+func weightedAppConfigs(appName string, weights map[string]int, configs map[string]*config.AppConfig) (map[string]*config.AppConfig, error) {
+	base, ok := configs[appName]
+	if !ok {
+		return nil, fmt.Errorf("no configuration found for app %s", appName)
+	}
+
+	weighted := *base
+	weighted.Slots = make(map[string]config.SlotConfig, len(base.Slots))
+	for name, slot := range base.Slots {
+		weighted.Slots[name] = config.SlotConfig{Port: slot.Port, Weight: weights[name]}
+	}
+
+	weightedConfigs := make(map[string]*config.AppConfig, len(configs))
+	for name, c := range configs {
+		weightedConfigs[name] = c
+	}
+	weightedConfigs[appName] = &weighted
+
+	return weightedConfigs, nil
+}
+
+// buildTemplateData assembles the TemplateData shared by every
+// ProxyManager backend: each app's currently active port (or weighted slot
+// ports) to render into that backend's own config template. Shared by
+// CaddyManager and NginxManager so the two backends agree on what "routing
+// state" means.
+func buildTemplateData(configs map[string]*config.AppConfig, states map[string]*state.AppState) (*TemplateData, error) {
 	var apps []AppTemplateData
 
 	for appName, appConfig := range configs {
@@ -149,7 +346,7 @@ func (cm *CaddyManager) buildTemplateData(configs map[string]*config.AppConfig,
 			return nil, fmt.Errorf("no state found for app %s", appName)
 		}
 
-		activePort, err := cm.getActivePort(appConfig, appState)
+		activePort, err := getActivePort(appConfig, appState)
 		if err != nil {
 			return nil, fmt.Errorf("failed to determine active port for app %s: %w", appName, err)
 		}
@@ -158,6 +355,7 @@ func (cm *CaddyManager) buildTemplateData(configs map[string]*config.AppConfig,
 			Name:       appName,
 			Proxy:      appConfig.Proxy,
 			ActivePort: activePort,
+			Slots:      buildSlotTemplateData(appConfig),
 			IsLast:     false, // Will be set correctly below
 		})
 	}
@@ -169,7 +367,15 @@ func (cm *CaddyManager) buildTemplateData(configs map[string]*config.AppConfig,
 	return &TemplateData{Apps: apps}, nil
 }
 
-func (cm *CaddyManager) getActivePort(appConfig *config.AppConfig, appState *state.AppState) (int, error) {
+func getActivePort(appConfig *config.AppConfig, appState *state.AppState) (int, error) {
+	if len(appConfig.Slots) > 0 {
+		slot, ok := appConfig.Slots[appState.ActiveColor]
+		if !ok {
+			return 0, fmt.Errorf("invalid active color: %s", appState.ActiveColor)
+		}
+		return slot.Port, nil
+	}
+
 	switch appState.ActiveColor {
 	case "blue":
 		return appConfig.Ports.Blue, nil
@@ -180,6 +386,22 @@ func (cm *CaddyManager) getActivePort(appConfig *config.AppConfig, appState *sta
 	}
 }
 
+// buildSlotTemplateData returns appConfig.Slots as a name-sorted slice for
+// deterministic template rendering; nil when no Slots are declared.
+func buildSlotTemplateData(appConfig *config.AppConfig) []SlotTemplateData {
+	if len(appConfig.Slots) == 0 {
+		return nil
+	}
+
+	slots := make([]SlotTemplateData, 0, len(appConfig.Slots))
+	for name, slot := range appConfig.Slots {
+		slots = append(slots, SlotTemplateData{Name: name, Port: slot.Port, Weight: slot.Weight})
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Name < slots[j].Name })
+
+	return slots
+}
+
 func (cm *CaddyManager) validateGeneratedConfig(configJSON []byte) error {
 	var config map[string]interface{}
 	return json.Unmarshal(configJSON, &config)
@@ -199,7 +421,33 @@ func (cm *CaddyManager) HasTemplate() bool {
 }
 
 func (cm *CaddyManager) CreateDefaultTemplate() error {
-	defaultTemplate := `{
+	defaultTemplate := `{{define "handle"}}
+"handle": [
+  {
+    "handler": "reverse_proxy",
+    {{if gt (len .Slots) 1}}
+    "upstreams": [
+      {{range $i, $s := .Slots}}{{if $i}},{{end}}
+      {"dial": "localhost:{{$s.Port}}"}
+      {{end}}
+    ],
+    "load_balancing": {
+      "selection_policy": {
+        "policy": "weighted_round_robin",
+        "weights": [{{range $i, $s := .Slots}}{{if $i}},{{end}}{{$s.Weight}}{{end}}]
+      }
+    }
+    {{else}}
+    "upstreams": [
+      {
+        "dial": "localhost:{{.ActivePort}}"
+      }
+    ]
+    {{end}}
+  }
+]
+{{end}}{{/* "handle" */}}
+{
   "apps": {
     "http": {
       "servers": {
@@ -214,31 +462,13 @@ func (cm *CaddyManager) CreateDefaultTemplate() error {
                   "host": ["{{.Proxy.Host}}"]
                 }
               ],
-              "handle": [
-                {
-                  "handler": "reverse_proxy",
-                  "upstreams": [
-                    {
-                      "dial": "localhost:{{.ActivePort}}"
-                    }
-                  ]
-                }
-              ]
+              {{template "handle" .}}
             }
           ]
           {{else}}
           "routes": [
             {
-              "handle": [
-                {
-                  "handler": "reverse_proxy",
-                  "upstreams": [
-                    {
-                      "dial": "localhost:{{.ActivePort}}"
-                    }
-                  ]
-                }
-              ]
+              {{template "handle" .}}
             }
           ]
           {{end}}
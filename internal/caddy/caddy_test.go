@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"dockswap/internal/config"
@@ -40,7 +41,7 @@ func TestSetAdminURL(t *testing.T) {
 	}
 }
 
-func TestValidateCaddyRunning(t *testing.T) {
+func TestValidateRunning(t *testing.T) {
 	t.Run("caddy running", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusOK)
@@ -50,9 +51,9 @@ func TestValidateCaddyRunning(t *testing.T) {
 		cm := New("/test/config.json", "/test/template.json")
 		cm.SetAdminURL(server.URL)
 
-		err := cm.ValidateCaddyRunning()
+		err := cm.ValidateRunning()
 		if err != nil {
-			t.Errorf("ValidateCaddyRunning() should succeed when caddy is running: %v", err)
+			t.Errorf("ValidateRunning() should succeed when caddy is running: %v", err)
 		}
 	})
 
@@ -60,9 +61,9 @@ func TestValidateCaddyRunning(t *testing.T) {
 		cm := New("/test/config.json", "/test/template.json")
 		cm.SetAdminURL("http://localhost:99999")
 
-		err := cm.ValidateCaddyRunning()
+		err := cm.ValidateRunning()
 		if err == nil {
-			t.Errorf("ValidateCaddyRunning() should fail when caddy is not running")
+			t.Errorf("ValidateRunning() should fail when caddy is not running")
 		}
 	})
 
@@ -75,9 +76,9 @@ func TestValidateCaddyRunning(t *testing.T) {
 		cm := New("/test/config.json", "/test/template.json")
 		cm.SetAdminURL(server.URL)
 
-		err := cm.ValidateCaddyRunning()
+		err := cm.ValidateRunning()
 		if err == nil {
-			t.Errorf("ValidateCaddyRunning() should fail when caddy returns error")
+			t.Errorf("ValidateRunning() should fail when caddy returns error")
 		}
 	})
 }
@@ -246,8 +247,6 @@ func TestReloadCaddy(t *testing.T) {
 }
 
 func TestBuildTemplateData(t *testing.T) {
-	cm := New("/test/config.json", "/test/template.json")
-
 	configs := map[string]*config.AppConfig{
 		"app1": {
 			Name: "app1",
@@ -284,7 +283,7 @@ func TestBuildTemplateData(t *testing.T) {
 		},
 	}
 
-	templateData, err := cm.buildTemplateData(configs, states)
+	templateData, err := buildTemplateData(configs, states)
 	if err != nil {
 		t.Fatalf("buildTemplateData() failed: %v", err)
 	}
@@ -330,8 +329,6 @@ func TestBuildTemplateData(t *testing.T) {
 }
 
 func TestGetActivePort(t *testing.T) {
-	cm := New("/test/config.json", "/test/template.json")
-
 	appConfig := &config.AppConfig{
 		Ports: config.Ports{
 			Blue:  8081,
@@ -344,7 +341,7 @@ func TestGetActivePort(t *testing.T) {
 			ActiveColor: "blue",
 		}
 
-		port, err := cm.getActivePort(appConfig, appState)
+		port, err := getActivePort(appConfig, appState)
 		if err != nil {
 			t.Errorf("getActivePort() failed: %v", err)
 		}
@@ -358,7 +355,7 @@ func TestGetActivePort(t *testing.T) {
 			ActiveColor: "green",
 		}
 
-		port, err := cm.getActivePort(appConfig, appState)
+		port, err := getActivePort(appConfig, appState)
 		if err != nil {
 			t.Errorf("getActivePort() failed: %v", err)
 		}
@@ -372,11 +369,62 @@ func TestGetActivePort(t *testing.T) {
 			ActiveColor: "red",
 		}
 
-		_, err := cm.getActivePort(appConfig, appState)
+		_, err := getActivePort(appConfig, appState)
 		if err == nil {
 			t.Errorf("getActivePort() should fail with invalid color")
 		}
 	})
+
+	t.Run("canary slot active", func(t *testing.T) {
+		slottedConfig := &config.AppConfig{
+			Ports: config.Ports{Blue: 8081, Green: 8082},
+			Slots: map[string]config.SlotConfig{
+				"blue":   {Port: 8081, Weight: 90},
+				"green":  {Port: 8082, Weight: 0},
+				"canary": {Port: 8083, Weight: 10},
+			},
+		}
+		appState := &state.AppState{ActiveColor: "canary"}
+
+		port, err := getActivePort(slottedConfig, appState)
+		if err != nil {
+			t.Errorf("getActivePort() failed: %v", err)
+		}
+		if port != 8083 {
+			t.Errorf("getActivePort() = %d, want 8083", port)
+		}
+	})
+}
+
+func TestBuildSlotTemplateData(t *testing.T) {
+	t.Run("no slots declared", func(t *testing.T) {
+		appConfig := &config.AppConfig{Ports: config.Ports{Blue: 8081, Green: 8082}}
+
+		if got := buildSlotTemplateData(appConfig); got != nil {
+			t.Errorf("buildSlotTemplateData() = %+v, want nil", got)
+		}
+	})
+
+	t.Run("sorted by name", func(t *testing.T) {
+		appConfig := &config.AppConfig{
+			Slots: map[string]config.SlotConfig{
+				"green":  {Port: 8082, Weight: 0},
+				"blue":   {Port: 8081, Weight: 90},
+				"canary": {Port: 8083, Weight: 10},
+			},
+		}
+
+		slots := buildSlotTemplateData(appConfig)
+		if len(slots) != 3 {
+			t.Fatalf("buildSlotTemplateData() returned %d slots, want 3", len(slots))
+		}
+		wantOrder := []string{"blue", "canary", "green"}
+		for i, name := range wantOrder {
+			if slots[i].Name != name {
+				t.Errorf("slots[%d].Name = %s, want %s", i, slots[i].Name, name)
+			}
+		}
+	})
 }
 
 func TestHasTemplate(t *testing.T) {
@@ -456,3 +504,157 @@ func TestCreateDefaultTemplate(t *testing.T) {
 		t.Errorf("CreateDefaultTemplate() template cannot generate valid config: %v", err)
 	}
 }
+
+func TestCreateDefaultTemplateWeightedSlots(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "template.json")
+	configPath := filepath.Join(tempDir, "config.json")
+
+	cm := New(configPath, templatePath)
+
+	if err := cm.CreateDefaultTemplate(); err != nil {
+		t.Fatalf("CreateDefaultTemplate() failed: %v", err)
+	}
+
+	testConfigs := map[string]*config.AppConfig{
+		"test-app": {
+			Name: "test-app",
+			Proxy: config.Proxy{
+				ListenPort: 80,
+				Host:       "test.example.com",
+			},
+			Ports: config.Ports{Blue: 8081, Green: 8082},
+			Slots: map[string]config.SlotConfig{
+				"blue":   {Port: 8081, Weight: 90},
+				"canary": {Port: 8083, Weight: 10},
+			},
+		},
+	}
+	testStates := map[string]*state.AppState{
+		"test-app": {Name: "test-app", ActiveColor: "blue"},
+	}
+
+	if err := cm.GenerateConfig(testConfigs, testStates); err != nil {
+		t.Fatalf("GenerateConfig() with weighted slots failed: %v", err)
+	}
+
+	configContent, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated config: %v", err)
+	}
+
+	var configJSON map[string]interface{}
+	if err := json.Unmarshal(configContent, &configJSON); err != nil {
+		t.Fatalf("Generated weighted config is not valid JSON: %v", err)
+	}
+}
+
+func TestWeightedAppConfigs(t *testing.T) {
+	configs := map[string]*config.AppConfig{
+		"test-app": {
+			Name: "test-app",
+			Slots: map[string]config.SlotConfig{
+				"blue":   {Port: 8081, Weight: 100},
+				"canary": {Port: 8083, Weight: 0},
+			},
+		},
+		"other-app": {Name: "other-app"},
+	}
+
+	t.Run("overrides weights for the named app only", func(t *testing.T) {
+		weighted, err := weightedAppConfigs("test-app", map[string]int{"blue": 80, "canary": 20}, configs)
+		if err != nil {
+			t.Fatalf("weightedAppConfigs() failed: %v", err)
+		}
+
+		if got := weighted["test-app"].Slots["blue"]; got.Port != 8081 || got.Weight != 80 {
+			t.Errorf("blue slot = %+v, want port 8081 weight 80", got)
+		}
+		if got := weighted["test-app"].Slots["canary"]; got.Port != 8083 || got.Weight != 20 {
+			t.Errorf("canary slot = %+v, want port 8083 weight 20", got)
+		}
+		if weighted["other-app"] != configs["other-app"] {
+			t.Errorf("weightedAppConfigs() should pass through other-app unchanged")
+		}
+		if configs["test-app"].Slots["blue"].Weight != 100 {
+			t.Errorf("weightedAppConfigs() must not mutate the original config")
+		}
+	})
+
+	t.Run("unknown app", func(t *testing.T) {
+		_, err := weightedAppConfigs("missing-app", map[string]int{"blue": 50}, configs)
+		if err == nil {
+			t.Errorf("weightedAppConfigs() should fail for an app with no configuration")
+		}
+	})
+}
+
+func TestUpdateWeightedRouting(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+	templatePath := filepath.Join(tempDir, "template.json")
+
+	template := `{
+  "apps": {
+    "http": {
+      "servers": {
+        {{range .Apps}}
+        "{{.Name}}": {
+          "listen": [":{{.Proxy.ListenPort}}"],
+          "slots": [{{range $i, $s := .Slots}}{{if $i}},{{end}}{"name": "{{$s.Name}}", "weight": {{$s.Weight}}}{{end}}]
+        }{{if not .IsLast}},{{end}}
+        {{end}}
+      }
+    }
+  }
+}`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template file: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cm := New(configPath, templatePath)
+	cm.SetAdminURL(server.URL)
+
+	configs := map[string]*config.AppConfig{
+		"test-app": {
+			Name:  "test-app",
+			Proxy: config.Proxy{ListenPort: 80},
+			Slots: map[string]config.SlotConfig{
+				"blue":   {Port: 8081, Weight: 100},
+				"canary": {Port: 8083, Weight: 0},
+			},
+		},
+	}
+	states := map[string]*state.AppState{
+		"test-app": {Name: "test-app", ActiveColor: "blue"},
+	}
+
+	err := cm.UpdateWeightedRouting("test-app", map[string]int{"blue": 70, "canary": 30}, configs, states)
+	if err != nil {
+		t.Fatalf("UpdateWeightedRouting() failed: %v", err)
+	}
+
+	configContent, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated config: %v", err)
+	}
+	if !strings.Contains(string(configContent), `"weight": 70`) || !strings.Contains(string(configContent), `"weight": 30`) {
+		t.Errorf("generated config %s does not reflect overridden weights", configContent)
+	}
+
+	if configs["test-app"].Slots["blue"].Weight != 100 {
+		t.Errorf("UpdateWeightedRouting() must not mutate the caller's config")
+	}
+
+	t.Run("unknown app", func(t *testing.T) {
+		err := cm.UpdateWeightedRouting("missing-app", map[string]int{"blue": 50}, configs, states)
+		if err == nil {
+			t.Errorf("UpdateWeightedRouting() should fail for an app with no configuration")
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package caddy
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Fetch5xxRate performs a best-effort read of Caddy's Prometheus-format
+// /metrics admin endpoint (served alongside the admin API whenever Caddy's
+// metrics app is enabled) and returns the fraction of caddy_http_requests_total
+// samples whose status code label is 5xx, for a canary rollout's
+// --abort-on-5xx-rate gate. ok is false when metrics are unreachable or the
+// expected counter isn't exposed (e.g. the metrics app is disabled) -
+// callers should treat that as "no signal" and fall back to health checks
+// rather than treat it as an abort condition.
+func (cm *CaddyManager) Fetch5xxRate() (rate float64, ok bool, err error) {
+	resp, err := cm.adminClient().Get(cm.adminBaseURL() + "/metrics")
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to fetch caddy metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("caddy metrics returned status %d", resp.StatusCode)
+	}
+
+	var total, errorTotal float64
+	found := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "caddy_http_requests_total{") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		found = true
+		total += value
+		if strings.Contains(line, `code="5`) {
+			errorTotal += value
+		}
+	}
+	if !found || total == 0 {
+		return 0, false, nil
+	}
+	return errorTotal / total, true, nil
+}
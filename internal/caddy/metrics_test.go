@@ -0,0 +1,65 @@
+package caddy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetch5xxRate(t *testing.T) {
+	t.Run("computes rate from requests_total counters", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `# HELP caddy_http_requests_total Counter of HTTP requests.`)
+			fmt.Fprintln(w, `caddy_http_requests_total{code="200",handler="reverse_proxy"} 95`)
+			fmt.Fprintln(w, `caddy_http_requests_total{code="502",handler="reverse_proxy"} 5`)
+		}))
+		defer server.Close()
+
+		cm := New("", "")
+		cm.SetAdminURL(server.URL)
+
+		rate, ok, err := cm.Fetch5xxRate()
+		if err != nil {
+			t.Fatalf("Fetch5xxRate() failed: %v", err)
+		}
+		if !ok {
+			t.Fatal("Fetch5xxRate() ok = false, want true")
+		}
+		if rate != 0.05 {
+			t.Errorf("rate = %v, want 0.05", rate)
+		}
+	})
+
+	t.Run("ok=false when the counter is absent", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `# no caddy_http_requests_total samples here`)
+		}))
+		defer server.Close()
+
+		cm := New("", "")
+		cm.SetAdminURL(server.URL)
+
+		_, ok, err := cm.Fetch5xxRate()
+		if err != nil {
+			t.Fatalf("Fetch5xxRate() failed: %v", err)
+		}
+		if ok {
+			t.Fatal("Fetch5xxRate() ok = true, want false")
+		}
+	})
+
+	t.Run("error on non-200 response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		cm := New("", "")
+		cm.SetAdminURL(server.URL)
+
+		if _, _, err := cm.Fetch5xxRate(); err == nil {
+			t.Fatal("Fetch5xxRate() expected an error for a 404 response")
+		}
+	})
+}
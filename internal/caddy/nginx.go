@@ -0,0 +1,146 @@
+package caddy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"text/template"
+
+	"dockswap/internal/config"
+	"dockswap/internal/state"
+)
+
+// NginxManager is a ProxyManager backed by nginx's own reload cycle: it
+// renders an nginx config from TemplatePath to ConfigPath and asks the
+// running nginx master process to pick it up via `nginx -s reload`, the same
+// generate-then-publish flow CaddyManager uses against Caddy's admin API
+// instead of a CLI signal.
+type NginxManager struct {
+	ConfigPath   string
+	TemplatePath string
+
+	// BinaryPath is the nginx executable to invoke; defaults to "nginx" on
+	// PATH when empty.
+	BinaryPath string
+
+	// mu serializes GenerateConfig and Reload against one another, same
+	// reason as CaddyManager.mu: two apps flipping colors at once must not
+	// interleave writes to ConfigPath or race a write against a reload of
+	// the file it just replaced.
+	mu sync.Mutex
+}
+
+func NewNginxManager(configPath, templatePath string) *NginxManager {
+	return &NginxManager{
+		ConfigPath:   configPath,
+		TemplatePath: templatePath,
+	}
+}
+
+func (nm *NginxManager) binary() string {
+	if nm.BinaryPath != "" {
+		return nm.BinaryPath
+	}
+	return "nginx"
+}
+
+// ValidateRunning satisfies ProxyManager. nginx has no persistent admin API
+// the way Caddy does, so `-t` against the live config file is the closest
+// equivalent check that a master process is reachable and happy with what's
+// published.
+func (nm *NginxManager) ValidateRunning() error {
+	cmd := exec.Command(nm.binary(), "-t")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("nginx config test failed: %w: %s", err, string(output))
+	}
+	return nil
+}
+
+// GenerateConfig renders TemplatePath against configs/states and writes the
+// result to ConfigPath, same contract as CaddyManager.GenerateConfig.
+func (nm *NginxManager) GenerateConfig(configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	templateContent, err := os.ReadFile(nm.TemplatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template file %s: %w", nm.TemplatePath, err)
+	}
+
+	tmpl, err := template.New("nginx").Parse(string(templateContent))
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	templateData, err := buildTemplateData(configs, states)
+	if err != nil {
+		return fmt.Errorf("failed to build template data: %w", err)
+	}
+
+	var configBuffer bytes.Buffer
+	if err := tmpl.Execute(&configBuffer, templateData); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	configDir := filepath.Dir(nm.ConfigPath)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory %s: %w", configDir, err)
+	}
+
+	if err := os.WriteFile(nm.ConfigPath, configBuffer.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", nm.ConfigPath, err)
+	}
+
+	return nil
+}
+
+// Reload asks the running nginx master process to pick up ConfigPath.
+func (nm *NginxManager) Reload() error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	cmd := exec.Command(nm.binary(), "-s", "reload")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("nginx reload failed: %w: %s", err, string(output))
+	}
+	return nil
+}
+
+func (nm *NginxManager) UpdateAppRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	if err := nm.GenerateConfig(configs, states); err != nil {
+		return fmt.Errorf("failed to generate config for app %s: %w", appName, err)
+	}
+
+	if err := nm.Reload(); err != nil {
+		return fmt.Errorf("failed to reload nginx for app %s: %w", appName, err)
+	}
+
+	return nil
+}
+
+// RollbackRouting satisfies ProxyManager. Like CaddyManager, nginx has no
+// separate "undo": re-publishing whatever states the caller now considers
+// correct is the same generate-then-reload flow as UpdateAppRouting.
+func (nm *NginxManager) RollbackRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	return nm.UpdateAppRouting(appName, configs, states)
+}
+
+// UpdateWeightedRouting satisfies ProxyManager by rendering appName's Slots
+// with weights substituted in for the configured ones - an operator-supplied
+// nginx template can render those as `server ... weight=N;` upstream
+// entries - then generating and reloading exactly like UpdateAppRouting.
+func (nm *NginxManager) UpdateWeightedRouting(appName string, weights map[string]int, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	weightedConfigs, err := weightedAppConfigs(appName, weights, configs)
+	if err != nil {
+		return err
+	}
+
+	if err := nm.GenerateConfig(weightedConfigs, states); err != nil {
+		return fmt.Errorf("failed to generate weighted config for app %s: %w", appName, err)
+	}
+
+	return nm.Reload()
+}
@@ -1,13 +1,19 @@
 package cli
 
 import (
+	"context"
 	"database/sql"
 	"dockswap/internal/caddy"
 	"dockswap/internal/config"
+	"dockswap/internal/events"
 	"dockswap/internal/logger"
+	"dockswap/internal/runtime"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var (
@@ -17,44 +23,218 @@ var (
 )
 
 type GlobalFlags struct {
-	Config   string
-	LogLevel int
+	Config       string
+	LogLevel     int
+	ProxyBackend string // "" (default) or "caddy" picks Caddy; "nginx" picks the nginx backend
+
+	// Workspace, if set, names an entry in the workspace.Workspaces registry
+	// (workspace.ResolveWorkspaceRoot) whose root main.go resolves in place
+	// of the usual --config search, for hosts running several named
+	// workspaces (e.g. staging + prod) side by side.
+	Workspace string
+
+	// EventWebhook, if set, is subscribed to the event bus by `serve`/`watch`
+	// as an events.WebhookSubscriber, POSTing every lifecycle event to it.
+	EventWebhook string
+	// EventLog, if set, is subscribed to the event bus by `serve`/`watch` as
+	// an events.FileSubscriber appending one line per event to that path.
+	EventLog string
+
+	// Listen, if set, is the address `serve` binds the internal/api HTTP
+	// server to (e.g. ":8080"), turning dockswap into a controllable
+	// deployment service alongside its CLI. Unset disables the API server.
+	Listen string
 }
 
 type CLI struct {
-	flags    GlobalFlags
+	flags GlobalFlags
+	// ctx is the root context main.go derives from signal.NotifyContext, so
+	// a Ctrl+C during a one-shot command's blocking Docker calls (health
+	// check polling, a blue/green cutover) cancels them instead of running
+	// to their own timeout. Set once by New; Run refreshes it for each
+	// invocation so tests can exercise multiple Run calls with distinct
+	// contexts. `serve` layers its own shutdown.Coordinator on top of this
+	// for the double-signal force-exit behavior a long-lived daemon needs.
+	ctx      context.Context
 	DB       *sql.DB // Add DB handle for inspection commands
 	logger   logger.Logger
 	configs  map[string]*config.AppConfig
-	caddyMgr *caddy.CaddyManager
+	// configsMu guards replacing entries in configs once `serve` is running:
+	// reloadConfigs (SIGHUP) and the fsnotify-driven config.Watcher can both
+	// trigger a reload, and do so on different goroutines than whatever is
+	// mid-deployment and reading the orchestrator's own reference to the
+	// same map.
+	configsMu sync.Mutex
+	caddyMgr  *caddy.CaddyManager // always Caddy-specific; backs the `caddy ...` admin subcommands
+	// proxyMgr is the caddy.ProxyManager backend deployments route through -
+	// flags.ProxyBackend, chosen at LoadConfigs time. Equal to caddyMgr when
+	// the backend is (the default) Caddy.
+	proxyMgr  caddy.ProxyManager
+	configDir string // set by LoadConfigs; lets `serve` re-run it on SIGHUP
+
+	// bus fans deployment lifecycle events out to ring (always) and, once
+	// wireEventSubscribers runs, any webhook/file subscriber the global
+	// flags configured. serve/watch hand it to the orchestrator so every
+	// app's state machine and action provider publish to it.
+	bus *events.Bus
+	// ring retains this process's recent events as the backlog the API's
+	// SSE event stream replays to a new client. It's process-local, so it
+	// only has anything in it once a long-lived `serve`/`watch` invocation
+	// has been running for a while; the `events` command reads the DB instead.
+	ring *events.RingBuffer
+
+	// runtimePool caches a runtime.Client per (runtime, docker_host) pair so
+	// apps that share an endpoint (including the common case of all of them
+	// leaving docker_host unset) reuse one connection instead of dialing it
+	// per app. See dockerEndpoint for how a command resolves an app's
+	// runtime.Endpoint before calling runtimePool.Get.
+	runtimePool *runtime.Pool
 }
 
-// New creates a CLI with a DB handle and logger.
-func New(db *sql.DB, log logger.Logger) *CLI {
+// New creates a CLI with a DB handle and logger. ctx is the root context
+// every command derives its Docker calls from; pass the context
+// signal.NotifyContext returns in main.go so Ctrl+C propagates, or
+// context.Background() for callers (tests, embedding) that don't need
+// cancellation.
+func New(ctx context.Context, db *sql.DB, log logger.Logger) *CLI {
+	bus := events.NewBus()
+	ring := events.NewRingBuffer(0)
+	bus.Subscribe(ring)
 	return &CLI{
-		DB:       db,
-		logger:   log,
-		configs:  make(map[string]*config.AppConfig),
-		caddyMgr: nil, // Will be initialized when configs are loaded
+		ctx:         ctx,
+		DB:          db,
+		logger:      log,
+		configs:     make(map[string]*config.AppConfig),
+		caddyMgr:    nil, // Will be initialized when configs are loaded
+		bus:         bus,
+		ring:        ring,
+		runtimePool: runtime.NewPool(),
+	}
+}
+
+// ctxOrBackground returns c.ctx if set, else context.Background(), the same
+// fallback DeploymentOrchestrator.ctxOrBackground gives callers that don't
+// want to nil-check it themselves.
+func (c *CLI) ctxOrBackground() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+// dockerEndpoint resolves the runtime.Endpoint a command should connect
+// appConfig through: appConfig.Docker.Host if set, plus the config dir's
+// TLS directory (tlsDirIfPresent) if it actually holds material - so a
+// docker_host left on the local default socket, or one with no TLS material
+// on disk, sees no change from the pre-docker_host behavior.
+func (c *CLI) dockerEndpoint(appConfig *config.AppConfig) runtime.Endpoint {
+	endpoint := runtime.Endpoint{Host: appConfig.Docker.Host}
+	if endpoint.Host == "" {
+		return endpoint
 	}
+	endpoint.TLSDir = c.tlsDirIfPresent()
+	return endpoint
 }
 
-// LoadConfigs loads all app configurations from the specified directory
+// tlsDirIfPresent returns config.TLSDir(c.configDir) if that directory
+// actually holds cert.pem, else "" - so constructing a runtime.Endpoint for
+// a config dir with no TLS material never passes a TLSDir that would make
+// client.WithTLSClientConfig fail trying to read files that don't exist.
+func (c *CLI) tlsDirIfPresent() string {
+	if c.configDir == "" {
+		return ""
+	}
+	tlsDir := config.TLSDir(c.configDir)
+	if _, err := os.Stat(filepath.Join(tlsDir, "cert.pem")); err != nil {
+		return ""
+	}
+	return tlsDir
+}
+
+// LoadConfigs loads all app configurations from configDir, which is either
+// a plain directory (the historical behavior: apps live under
+// "<configDir>/apps") or a config.ConfigSource DSN such as
+// "file:///etc/dockswap/apps" or "boltdb:///var/lib/dockswap/state.db?prefix=apps/"
+// for a pluggable backend. Caddy's config/template paths are only derived
+// from configDir in the plain-directory case, since a non-file DSN has no
+// filesystem layout to derive them from.
 func (c *CLI) LoadConfigs(configDir string) error {
-	appsDir := configDir + "/apps"
-	configs, err := config.LoadAllConfigs(appsDir)
+	dsn := configDir
+	if !strings.Contains(dsn, "://") {
+		dsn = "file://" + configDir + "/apps"
+	}
+
+	source, err := config.NewConfigSource(dsn)
+	if err != nil {
+		return fmt.Errorf("failed to construct config source %s: %w", dsn, err)
+	}
+
+	configs, err := config.LoadAllConfigsFromSource(c.ctxOrBackground(), source)
 	if err != nil {
 		return fmt.Errorf("failed to load app configs: %w", err)
 	}
 	c.configs = configs
+	c.configDir = configDir
 
 	// Initialize Caddy manager if we have configs
-	if len(configs) > 0 {
+	if len(configs) > 0 && !strings.Contains(configDir, "://") {
 		caddyConfigPath := configDir + "/caddy/caddy.json"
 		caddyTemplatePath := configDir + "/caddy/template.json"
 		c.caddyMgr = caddy.New(caddyConfigPath, caddyTemplatePath)
+
+		switch c.flags.ProxyBackend {
+		case "", "caddy":
+			c.proxyMgr = c.caddyMgr
+		default:
+			backendDir := configDir + "/" + c.flags.ProxyBackend
+			proxyMgr, err := caddy.NewProxyManager(c.flags.ProxyBackend, backendDir+"/config", backendDir+"/template")
+			if err != nil {
+				return fmt.Errorf("failed to construct proxy backend: %w", err)
+			}
+			c.proxyMgr = proxyMgr
+		}
+	}
+
+	return nil
+}
+
+// ReplaceConfigs swaps the currently loaded app configs for newConfigs under
+// configsMu: apps missing from newConfigs are dropped, apps present in it are
+// added or overwritten. It mutates the existing map in place rather than
+// pointing c.configs at a new one, since the orchestrator `serve` builds
+// holds that same map by reference and must see the update too. Callers
+// needing per-app add/remove/changed bookkeeping (logging, re-initializing
+// the orchestrator) should diff old vs. new themselves before calling this.
+func (c *CLI) ReplaceConfigs(newConfigs map[string]*config.AppConfig) {
+	c.configsMu.Lock()
+	defer c.configsMu.Unlock()
+
+	for appName := range c.configs {
+		if _, ok := newConfigs[appName]; !ok {
+			delete(c.configs, appName)
+		}
+	}
+	for appName, cfg := range newConfigs {
+		c.configs[appName] = cfg
 	}
+}
 
+// wireEventSubscribers subscribes the --event-webhook/--event-log global
+// flags to c.bus, in addition to the always-on ring buffer New already
+// subscribed. Called once by serve/watch before they start initializing
+// apps, since those are the only commands long-lived enough for the
+// subscribers to matter.
+func (c *CLI) wireEventSubscribers() error {
+	if c.flags.EventWebhook != "" {
+		c.bus.Subscribe(events.NewWebhookSubscriber(c.flags.EventWebhook))
+	}
+	if c.flags.EventLog != "" {
+		f, err := os.OpenFile(c.flags.EventLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open --event-log %s: %w", c.flags.EventLog, err)
+		}
+		c.bus.Subscribe(events.NewFileSubscriber(f))
+	}
 	return nil
 }
 
@@ -72,17 +252,42 @@ func (c *CLI) parseGlobalFlags(args []string) ([]string, error) {
 		} else if strings.HasPrefix(arg, "--log-level=") {
 			levelStr := strings.TrimPrefix(arg, "--log-level=")
 			level, err := strconv.Atoi(levelStr)
-			if err != nil || level < 1 || level > 3 {
-				return nil, fmt.Errorf("invalid log level: %s (must be 1, 2, or 3)", levelStr)
+			if err != nil || level < 1 || level > 5 {
+				return nil, fmt.Errorf("invalid log level: %s (must be 1-5: error, warn, info, debug, trace)", levelStr)
 			}
 			c.flags.LogLevel = level
 		} else if arg == "--log-level" && i+1 < len(args) {
 			i++
 			level, err := strconv.Atoi(args[i])
-			if err != nil || level < 1 || level > 3 {
-				return nil, fmt.Errorf("invalid log level: %s (must be 1, 2, or 3)", args[i])
+			if err != nil || level < 1 || level > 5 {
+				return nil, fmt.Errorf("invalid log level: %s (must be 1-5: error, warn, info, debug, trace)", args[i])
 			}
 			c.flags.LogLevel = level
+		} else if strings.HasPrefix(arg, "--proxy-backend=") {
+			c.flags.ProxyBackend = strings.TrimPrefix(arg, "--proxy-backend=")
+		} else if arg == "--proxy-backend" && i+1 < len(args) {
+			i++
+			c.flags.ProxyBackend = args[i]
+		} else if strings.HasPrefix(arg, "--event-webhook=") {
+			c.flags.EventWebhook = strings.TrimPrefix(arg, "--event-webhook=")
+		} else if arg == "--event-webhook" && i+1 < len(args) {
+			i++
+			c.flags.EventWebhook = args[i]
+		} else if strings.HasPrefix(arg, "--event-log=") {
+			c.flags.EventLog = strings.TrimPrefix(arg, "--event-log=")
+		} else if arg == "--event-log" && i+1 < len(args) {
+			i++
+			c.flags.EventLog = args[i]
+		} else if strings.HasPrefix(arg, "--workspace=") {
+			c.flags.Workspace = strings.TrimPrefix(arg, "--workspace=")
+		} else if arg == "--workspace" && i+1 < len(args) {
+			i++
+			c.flags.Workspace = args[i]
+		} else if strings.HasPrefix(arg, "--listen=") {
+			c.flags.Listen = strings.TrimPrefix(arg, "--listen=")
+		} else if arg == "--listen" && i+1 < len(args) {
+			i++
+			c.flags.Listen = args[i]
 		} else {
 			filteredArgs = append(filteredArgs, arg)
 		}
@@ -91,7 +296,14 @@ func (c *CLI) parseGlobalFlags(args []string) ([]string, error) {
 	return filteredArgs, nil
 }
 
-func (c *CLI) Run(args []string) error {
+// Run dispatches args to the matching command, deriving every Docker call
+// it makes from ctx - typically the signal.NotifyContext root from main.go,
+// so an interrupt cancels a blocking health check or cutover instead of
+// letting it run to its own timeout.
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	c.ctx = ctx
+	defer c.runtimePool.Close()
+
 	if len(args) == 0 {
 		c.printHelp()
 		return nil
@@ -121,8 +333,14 @@ func (c *CLI) Run(args []string) error {
 		return c.handleEvents(commandArgs)
 	case "health":
 		return c.handleHealth(commandArgs)
+	case "watch":
+		return c.handleWatch(commandArgs)
+	case "serve":
+		return c.handleServe(commandArgs)
 	case "switch":
 		return c.handleSwitch(commandArgs)
+	case "deploy-group":
+		return c.handleDeployGroup(commandArgs)
 	case "logs":
 		return c.handleLogs(commandArgs)
 	case "config":
@@ -131,6 +349,8 @@ func (c *CLI) Run(args []string) error {
 		return c.handleCaddy(commandArgs)
 	case "dbg-cmd":
 		return c.handleDbgCmd(commandArgs)
+	case "migrate":
+		return c.handleMigrate(commandArgs)
 	case "version":
 		return c.handleVersion(commandArgs)
 	case "help", "-h", "--help":
@@ -149,28 +369,53 @@ Usage:
 
 Commands:
   status [app-name]               Show deployment status for all apps or specific app
-  deploy <app-name> <image>       Deploy new image for application
+  deploy <app-name> <image> [opts] Deploy new image for application; pulls and resolves its digest before
+                                    creating a container, aborting on a deployment.pinned_digest mismatch.
+                                    --platform os/arch, --require-new-digest (abort if unchanged from active)
   history <app-name> [--limit N]  Show deployment history for application
-  health <app-name>               Check health status of application
-  switch <app-name> <color>       Switch traffic to blue or green deployment
-  logs <app-name> [--follow]      Show logs for application
+  events <deployment-id>          Show the persisted DB event log for one deployment
+  events [opts]                    Tail the cross-deployment event log; --since D, --follow/-f,
+                                    --filter type=<glob>,app=<glob>, --format text|json (default text)
+  health <app-name> [--format F]   Docker health + out-of-band HTTP probe for both colors (--format text|json, default text)
+  watch [app-name]                 Poll registries for new images and auto-deploy (watch.enabled apps)
+  serve                            Run as a daemon: watch loop plus SIGHUP/fsnotify config reload, SIGINT/SIGTERM shutdown, and --listen for the HTTP API
+  switch <app-name> <color> [opts] Switch traffic to blue or green deployment; --strategy instant|canary (default instant),
+                                    --steps 10,25,50,100, --step-interval 30s, --abort-on-5xx-rate 0.02
+  deploy-group --file release.yaml [--max-parallel N]
+                                    Deploy and switch several apps in depends_on topological order, apps at the
+                                    same level in parallel (bounded by --max-parallel, default 1); a failed level
+                                    halts the release and rolls already-switched apps back to their prior color
+  logs <app-name> [opts]          Tail container logs; --color blue|green|both (default both), --follow/-f, --since D, --tail N, --timestamps
   config reload [app-name]        Reload configuration for all apps or specific app
+  config history <app-name>       List the content-addressed config history for an app
+  config diff <old-id> <new-id>   Show a unified diff between two stored configs
+  config show <app-name> [--id N] Print the stored YAML for an app's latest (or --id'd) config
   caddy status                    Show Caddy proxy status
   caddy reload                    Reload Caddy configuration
   caddy config create             Create default Caddy template
   caddy config show               Show Caddy configuration paths
   dbg-cmd <app-name> [--color]    Show equivalent docker run command for debugging
+  migrate up                      Apply any pending schema migrations
+  migrate down [N]                Roll back the last N applied migrations (default 1)
+  migrate redo                    Roll back and re-apply the last migration
+  migrate status                  Show every migration and whether it's applied
   version                         Show version information
   help                           Show this help message
 
 Global Flags:
   --config <path>                Configuration file path
-  --log-level <level>            Log level (1=error, 2=info, 3=debug)
+  --log-level <level>            Log level (1=error, 2=warn, 3=info, 4=debug, 5=trace)
+  --proxy-backend <name>          Reverse-proxy backend deployments route through: caddy (default) or nginx
+  --event-webhook <url>           POST every deployment lifecycle event to url (serve/watch only)
+  --event-log <path>              Append one line per deployment lifecycle event to path (serve/watch only)
+  --listen <addr>                 Serve the HTTP API on addr, e.g. :8080 (serve only)
+  --workspace <name>               Target the named workspace from the workspaces registry instead of --config
 
 Examples:
   dockswap status                 # Show all app statuses
   dockswap deploy myapp nginx:1.21
   dockswap switch myapp blue
+  dockswap serve                  # Run as a supervisable daemon
   dockswap logs myapp --follow
   dockswap caddy status           # Check Caddy proxy status
   dockswap caddy reload           # Reload Caddy configuration
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"dockswap/internal/config"
+	"testing"
+)
+
+func TestReplaceConfigs(t *testing.T) {
+	c := &CLI{
+		configs: map[string]*config.AppConfig{
+			"keep":   {Name: "keep"},
+			"remove": {Name: "remove"},
+		},
+	}
+
+	changed := &config.AppConfig{Name: "keep", Description: "v2"}
+	c.ReplaceConfigs(map[string]*config.AppConfig{
+		"keep": changed,
+		"add":  {Name: "add"},
+	})
+
+	if len(c.configs) != 2 {
+		t.Fatalf("configs = %v, want 2 entries", c.configs)
+	}
+	if c.configs["keep"] != changed {
+		t.Errorf("configs[keep] should be replaced with the new pointer")
+	}
+	if _, ok := c.configs["add"]; !ok {
+		t.Errorf("configs should contain the newly added app")
+	}
+	if _, ok := c.configs["remove"]; ok {
+		t.Errorf("configs should no longer contain the removed app")
+	}
+}
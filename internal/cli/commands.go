@@ -1,16 +1,44 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"dockswap/internal/config"
+	"dockswap/internal/depgraph"
 	"dockswap/internal/docker"
+	"dockswap/internal/events"
+	"dockswap/internal/logger"
+	"dockswap/internal/runtime"
+	"dockswap/internal/shutdown"
 	"dockswap/internal/state"
+	"dockswap/internal/watcher"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"gopkg.in/yaml.v3"
 )
 
+// capitalize upper-cases just the first byte of s - good enough for the
+// "blue"/"green" color names handleHealth prints, without strings.Title's
+// deprecated Unicode-casing-rules baggage.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
 func (c *CLI) handleStatus(args []string) error {
 	if c.DB == nil {
 		return fmt.Errorf("DB not initialized")
@@ -44,6 +72,50 @@ func (c *CLI) handleStatus(args []string) error {
 	return nil
 }
 
+// pullProgressWriter adapts an io.Writer to log each JSON-per-line Docker
+// pull progress message as it arrives, so handleDeploy's pre-flight pull
+// shows up in the deploy log instead of pulling blind. Writes aren't
+// guaranteed to land on line boundaries, so partial lines are buffered
+// across calls.
+type pullProgressWriter struct {
+	log logger.Logger
+	buf bytes.Buffer
+}
+
+func newPullProgressWriter(log logger.Logger) *pullProgressWriter {
+	return &pullProgressWriter{log: log}
+}
+
+func (w *pullProgressWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			break
+		}
+		w.logLine(strings.TrimSpace(line))
+	}
+	return len(p), nil
+}
+
+func (w *pullProgressWriter) logLine(line string) {
+	var msg struct {
+		Status   string `json:"status"`
+		Progress string `json:"progress"`
+		ID       string `json:"id"`
+	}
+	if line == "" || json.Unmarshal([]byte(line), &msg) != nil || msg.Status == "" {
+		return
+	}
+	if msg.ID != "" {
+		w.log.Debug("%s: %s %s", msg.ID, msg.Status, msg.Progress)
+	} else {
+		w.log.Debug("%s %s", msg.Status, msg.Progress)
+	}
+}
+
 func (c *CLI) handleDeploy(args []string) error {
 	if len(args) < 2 {
 		return fmt.Errorf("deploy requires <app-name> and <image> arguments")
@@ -52,27 +124,55 @@ func (c *CLI) handleDeploy(args []string) error {
 	appName := args[0]
 	image := args[1]
 
+	var platform string
+	requireNewDigest := false
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--platform="):
+			platform = strings.TrimPrefix(arg, "--platform=")
+		case arg == "--platform" && i+1 < len(args):
+			i++
+			platform = args[i]
+		case arg == "--require-new-digest":
+			requireNewDigest = true
+		default:
+			return fmt.Errorf("unknown deploy argument: %s", arg)
+		}
+	}
+
+	_, err := c.deployOne(appName, image, platform, requireNewDigest)
+	return err
+}
+
+// deployOne runs a single app's blue/green deploy: pre-flight pull and
+// digest verification, container start, health check, and (for a first
+// deployment) the initial Caddy config. It returns the color the new image
+// landed on, so a caller that also wants to switch traffic (like
+// handleDeployGroup) doesn't have to re-derive it. handleDeploy calls this
+// after parsing its flags; handleDeployGroup calls it directly per app so a
+// multi-app release goes through the exact same path as `dockswap deploy`.
+func (c *CLI) deployOne(appName, image, platform string, requireNewDigest bool) (string, error) {
 	// Check if app config exists
 	appConfig, exists := c.configs[appName]
 	if !exists {
-		return fmt.Errorf("no configuration found for app %s", appName)
+		return "", fmt.Errorf("no configuration found for app %s", appName)
 	}
 
 	c.logger.Info("Deploying %s with image %s...", appName, image)
 
-	// Create Docker client
-	dockerClient, err := docker.NewDockerClient()
+	// Create runtime client
+	dockerClient, err := c.runtimePool.Get(runtime.Kind(appConfig.Docker.Runtime), c.dockerEndpoint(appConfig))
 	if err != nil {
-		return fmt.Errorf("failed to create Docker client: %w", err)
+		return "", fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer dockerClient.Close()
 
-	dockerManager := docker.NewDockerManager(dockerClient)
+	dockerManager := docker.NewRuntimeManager(dockerClient)
 
 	// Test Docker connection
-	ctx := context.Background()
+	ctx := c.ctxOrBackground()
 	if err := dockerManager.ValidateConnection(ctx); err != nil {
-		return fmt.Errorf("Docker not available: %w", err)
+		return "", fmt.Errorf("Docker not available: %w", err)
 	}
 
 	// Get current active color (default to blue if first deployment)
@@ -90,24 +190,64 @@ func (c *CLI) handleDeploy(args []string) error {
 
 	c.logger.Info("Current active: %s, deploying to: %s", activeColor, targetColor)
 
+	c.bus.Publish(events.Event{Kind: events.KindDeploymentStarted, AppName: appName, Color: targetColor, Image: image, Timestamp: time.Now()})
+
+	// Pre-flight pull and digest verification: resolve what image actually
+	// refers to right now, before anything gets created from it.
+	c.logger.Info("✓ Pulling %s...", image)
+	pullErr := dockerManager.PullImageWithOptions(ctx, image, appConfig, docker.PullOptions{
+		Platform: platform,
+		Progress: newPullProgressWriter(c.logger.With("app", appName)),
+	})
+	if pullErr != nil {
+		c.bus.Publish(events.Event{Kind: events.KindDeploymentFailed, AppName: appName, Color: targetColor, Image: image, Timestamp: time.Now(), Error: pullErr})
+		return "", fmt.Errorf("failed to pull %s: %w", image, pullErr)
+	}
+
+	digest, err := dockerManager.InspectImageDigest(ctx, image)
+	if err != nil {
+		c.bus.Publish(events.Event{Kind: events.KindDeploymentFailed, AppName: appName, Color: targetColor, Image: image, Timestamp: time.Now(), Error: err})
+		return "", fmt.Errorf("failed to resolve digest for %s: %w", image, err)
+	}
+	c.logger.Info("✓ Resolved %s to %s", image, digest)
+
+	if pinned := appConfig.Deployment.PinnedDigest; pinned != "" && digest != pinned {
+		err := fmt.Errorf("image %s resolved to %s, expected pinned digest %s", image, digest, pinned)
+		c.bus.Publish(events.Event{Kind: events.KindDeploymentFailed, AppName: appName, Color: targetColor, Image: image, Timestamp: time.Now(), Error: err})
+		return "", err
+	}
+
+	if requireNewDigest && cs != nil {
+		previousDigest, err := dockerManager.InspectImageDigest(ctx, cs.Image)
+		if err == nil && previousDigest == digest {
+			err := fmt.Errorf("image %s resolved to %s, same digest already active on %s - refusing to deploy with --require-new-digest", image, digest, activeColor)
+			c.bus.Publish(events.Event{Kind: events.KindDeploymentFailed, AppName: appName, Color: targetColor, Image: image, Timestamp: time.Now(), Error: err})
+			return "", err
+		}
+	}
+
 	// Create action provider
 	actionProvider := docker.NewDockerActionProvider(dockerManager, nil, c.configs)
 	actionProvider.SetContext(ctx)
+	actionProvider.SetLogger(c.logger.With("app", appName))
 
 	// Start container
 	c.logger.Info("✓ Starting container...")
 	if err := actionProvider.StartContainer(appName, targetColor, image); err != nil {
-		return fmt.Errorf("failed to start container: %w", err)
+		c.bus.Publish(events.Event{Kind: events.KindDeploymentFailed, AppName: appName, Color: targetColor, Image: image, Timestamp: time.Now(), Error: err})
+		return "", fmt.Errorf("failed to start container: %w", err)
 	}
 
 	// Wait for health check
 	c.logger.Info("✓ Waiting for health check...")
 	timeout := time.Duration(appConfig.HealthCheck.Retries) * appConfig.HealthCheck.Interval * 2
 	if err := dockerManager.WaitForHealthy(ctx, appName, targetColor, appConfig, timeout); err != nil {
-		return fmt.Errorf("health check failed: %w", err)
+		c.bus.Publish(events.Event{Kind: events.KindDeploymentFailed, AppName: appName, Color: targetColor, Image: image, Timestamp: time.Now(), Error: err})
+		return "", fmt.Errorf("health check failed: %w", err)
 	}
 
 	c.logger.Info("✓ Container healthy and ready")
+	c.bus.Publish(events.Event{Kind: events.KindDeploymentSucceeded, AppName: appName, Color: targetColor, Image: image, Timestamp: time.Now()})
 
 	// Update Caddy configuration if this is the first deployment
 	if cs == nil && c.caddyMgr != nil {
@@ -119,6 +259,7 @@ func (c *CLI) handleDeploy(args []string) error {
 				c.logger.Error("Warning: failed to reload Caddy: %v", err)
 			} else {
 				c.logger.Info("✓ Caddy configuration updated")
+				c.bus.Publish(events.Event{Kind: events.KindCaddyReloaded, AppName: appName, Timestamp: time.Now()})
 			}
 		}
 	}
@@ -145,9 +286,13 @@ func (c *CLI) handleDeploy(args []string) error {
 			dbActiveColor = targetColor
 		}
 		state.UpsertCurrentState(c.DB, appName, depID, dbActiveColor, image, "ready")
+
+		if _, err := state.InsertDeploymentEvent(c.DB, depID, appName, string(events.KindDeploymentSucceeded), image, nil); err != nil {
+			c.logger.Error("Warning: failed to persist deployment event: %v", err)
+		}
 	}
 
-	return nil
+	return targetColor, nil
 }
 
 func (c *CLI) handleHistory(args []string) error {
@@ -202,28 +347,41 @@ func (c *CLI) handleHistory(args []string) error {
 	return nil
 }
 
-// (Optional) Show all events for a deployment
+// handleEvents shows deployment lifecycle events. Given a numeric
+// <deployment-id> it prints that deployment's persisted DB event log (the
+// original behavior, unchanged). Otherwise it shows a cross-deployment view
+// of the persisted deployment_events log, most recent last, optionally
+// narrowed with --since <duration> and/or --filter type=<glob>,app=<glob>,
+// and --follow'd live (polling for newly inserted rows) the way `docker
+// events` tails the daemon's event stream. --format json prints one JSON
+// object per line instead of a human-readable summary. Unlike the
+// in-process events.RingBuffer (the backlog behind the API's SSE stream),
+// this reads the DB, so it shows events
+// persisted by any process - including a `serve` running in another
+// terminal - not just this one.
 func (c *CLI) handleEvents(args []string) error {
+	if len(args) > 0 {
+		if depID, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+			return c.handleDeploymentEvents(depID)
+		}
+	}
+	return c.handleRingEvents(args)
+}
+
+func (c *CLI) handleDeploymentEvents(depID int64) error {
 	if c.DB == nil {
 		return fmt.Errorf("DB not initialized")
 	}
-	if len(args) == 0 {
-		return fmt.Errorf("events requires <deployment-id> argument")
-	}
-	depID, err := strconv.ParseInt(args[0], 10, 64)
-	if err != nil {
-		return fmt.Errorf("invalid deployment id: %s", args[0])
-	}
-	events, err := state.GetDeploymentEvents(c.DB, depID)
+	dbEvents, err := state.GetDeploymentEvents(c.DB, depID)
 	if err != nil {
 		return fmt.Errorf("failed to get events: %w", err)
 	}
-	if len(events) == 0 {
+	if len(dbEvents) == 0 {
 		c.logger.Info("No events found for deployment %d", depID)
 		return nil
 	}
 	c.logger.Info("Events for deployment %d:", depID)
-	for _, e := range events {
+	for _, e := range dbEvents {
 		errStr := ""
 		if e.Error.Valid {
 			errStr = e.Error.String
@@ -234,21 +392,462 @@ func (c *CLI) handleEvents(args []string) error {
 	return nil
 }
 
+// eventFilter narrows the cross-deployment `dockswap events` view by event
+// type and/or app name, each optionally a glob (`*`). An empty pattern
+// matches everything for that field.
+type eventFilter struct {
+	typePattern string
+	appPattern  string
+}
+
+func (f eventFilter) matches(eventType, appName string) bool {
+	if f.typePattern != "" && !globMatch(f.typePattern, eventType) {
+		return false
+	}
+	if f.appPattern != "" && !globMatch(f.appPattern, appName) {
+		return false
+	}
+	return true
+}
+
+// globMatch reports whether s matches pattern, where `*` matches any run of
+// characters. Used instead of path.Match/filepath.Match because those treat
+// `/` specially, and app names may contain `/` (the workspace/app naming
+// convention).
+func globMatch(pattern, s string) bool {
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	re, err := regexp.Compile("^" + quoted + "$")
+	if err != nil {
+		return pattern == s
+	}
+	return re.MatchString(s)
+}
+
+// parseEventFilter parses the --filter flag's `type=X,app=Y` syntax (either
+// key optional) into an eventFilter.
+func parseEventFilter(raw string) eventFilter {
+	var f eventFilter
+	for _, part := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(k) {
+		case "type":
+			f.typePattern = strings.TrimSpace(v)
+		case "app":
+			f.appPattern = strings.TrimSpace(v)
+		}
+	}
+	return f
+}
+
+// deploymentEventPayload is the JSON shape printed for --format json, a
+// DB-backed counterpart to internal/api's eventPayload - sql.NullString
+// doesn't marshal to a plain string on its own, hence the wrapper rather
+// than marshaling state.DeploymentEvent directly.
+type deploymentEventPayload struct {
+	ID           int64     `json:"id"`
+	DeploymentID int64     `json:"deployment_id"`
+	AppName      string    `json:"app_name"`
+	EventType    string    `json:"event_type"`
+	Payload      string    `json:"payload"`
+	Timestamp    time.Time `json:"timestamp"`
+	Error        string    `json:"error,omitempty"`
+}
+
+func toDeploymentEventPayload(e state.DeploymentEvent) deploymentEventPayload {
+	p := deploymentEventPayload{
+		ID:           e.ID,
+		DeploymentID: e.DeploymentID,
+		AppName:      e.AppName,
+		EventType:    e.EventType,
+		Payload:      e.Payload,
+		Timestamp:    e.CreatedAt,
+	}
+	if e.Error.Valid {
+		p.Error = e.Error.String
+	}
+	return p
+}
+
+func (c *CLI) printDeploymentEvent(e state.DeploymentEvent, format string) {
+	if format == "json" {
+		body, err := json.Marshal(toDeploymentEventPayload(e))
+		if err != nil {
+			c.logger.Error("failed to marshal event: %v", err)
+			return
+		}
+		fmt.Println(string(body))
+		return
+	}
+	errStr := ""
+	if e.Error.Valid {
+		errStr = e.Error.String
+	}
+	c.logger.Info("  %s  #%d  app=%s  %s  payload=%s  error: %s",
+		e.CreatedAt.Format("2006-01-02 15:04:05"), e.ID, e.AppName, e.EventType, e.Payload, errStr)
+}
+
+// handleRingEvents is the no-deployment-id form of `dockswap events`: a
+// cross-deployment tail of the persisted deployment_events log, optionally
+// narrowed with --since <duration> and --filter type=<glob>,app=<glob>, and
+// kept open with --follow to poll for newly inserted rows (one process can
+// watch events another process - e.g. a `serve` - is persisting).
+func (c *CLI) handleRingEvents(args []string) error {
+	if c.DB == nil {
+		return fmt.Errorf("DB not initialized")
+	}
+
+	var since time.Duration
+	var filterRaw string
+	var follow bool
+	format := "text"
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--follow" || arg == "-f":
+			follow = true
+		case strings.HasPrefix(arg, "--since="):
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "--since="))
+			if err != nil {
+				return fmt.Errorf("invalid --since duration: %w", err)
+			}
+			since = d
+		case arg == "--since" && i+1 < len(args):
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil {
+				return fmt.Errorf("invalid --since duration: %w", err)
+			}
+			since = d
+		case strings.HasPrefix(arg, "--filter="):
+			filterRaw = strings.TrimPrefix(arg, "--filter=")
+		case arg == "--filter" && i+1 < len(args):
+			i++
+			filterRaw = args[i]
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case arg == "--format" && i+1 < len(args):
+			i++
+			format = args[i]
+		default:
+			return fmt.Errorf("unknown events argument: %s (expected a numeric deployment id, or --since/--filter/--follow/--format)", arg)
+		}
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid --format %q: want text or json", format)
+	}
+	filter := parseEventFilter(filterRaw)
+
+	sinceTime := time.Time{}
+	if since > 0 {
+		sinceTime = time.Now().Add(-since)
+	}
+	dbEvents, err := state.GetEventsSince(c.DB, sinceTime)
+	if err != nil {
+		return fmt.Errorf("failed to get events: %w", err)
+	}
+
+	var lastID int64
+	printed := false
+	for _, e := range dbEvents {
+		if e.ID > lastID {
+			lastID = e.ID
+		}
+		if !filter.matches(e.EventType, e.AppName) {
+			continue
+		}
+		c.printDeploymentEvent(e, format)
+		printed = true
+	}
+	if !printed && !follow {
+		c.logger.Info("No events found")
+	}
+
+	if !follow {
+		return nil
+	}
+
+	coord := shutdown.New()
+	stopTrap := coord.Trap(func(sig os.Signal) {
+		c.logger.Info("events: %s received, stopping...", sig)
+	})
+	defer stopTrap()
+	ctx := coord.Context()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			newEvents, err := state.GetEventsAfter(c.DB, lastID)
+			if err != nil {
+				c.logger.Error("failed to poll events: %v", err)
+				continue
+			}
+			for _, e := range newEvents {
+				if e.ID > lastID {
+					lastID = e.ID
+				}
+				if !filter.matches(e.EventType, e.AppName) {
+					continue
+				}
+				c.printDeploymentEvent(e, format)
+			}
+		}
+	}
+}
+
+// colorHealthReport is one color's section of `dockswap health` output:
+// Docker's own view of the container (from GetContainerInfo) plus an
+// out-of-band probe of appConfig.HealthCheck's endpoint performed directly
+// from this process, independent of whatever WaitForHealthy decided during
+// the last deploy/switch.
+type colorHealthReport struct {
+	Color         string        `json:"color"`
+	Routed        bool          `json:"routed"`
+	Found         bool          `json:"found"`
+	Error         string        `json:"error,omitempty"`
+	ContainerID   string        `json:"container_id,omitempty"`
+	Image         string        `json:"image,omitempty"`
+	Uptime        time.Duration `json:"uptime_seconds,omitempty"`
+	DockerHealth  string        `json:"docker_health,omitempty"`
+	FailingStreak int           `json:"failing_streak,omitempty"`
+	ProbeStatus   string        `json:"probe_status,omitempty"`
+	ProbeCode     int           `json:"probe_status_code,omitempty"`
+	ProbeLatency  time.Duration `json:"probe_latency_ms,omitempty"`
+	ProbeError    string        `json:"probe_error,omitempty"`
+}
+
+// MarshalJSON reports Uptime in whole seconds and ProbeLatency in whole
+// milliseconds, matching their JSON field names.
+func (r colorHealthReport) MarshalJSON() ([]byte, error) {
+	type alias colorHealthReport
+	return json.Marshal(struct {
+		alias
+		Uptime       int64 `json:"uptime_seconds,omitempty"`
+		ProbeLatency int64 `json:"probe_latency_ms,omitempty"`
+	}{alias(r), int64(r.Uptime / time.Second), r.ProbeLatency.Milliseconds()})
+}
+
+// handleHealth reports, per color, what Docker itself knows about the
+// container (GetContainerInfo's Status/Health/FailingStreak) alongside an
+// out-of-band HTTP probe of appConfig.HealthCheck run from this process, so
+// an operator can tell "Docker thinks it's healthy" apart from "I can
+// actually reach it" during on-call diagnosis.
 func (c *CLI) handleHealth(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("health requires <app-name> argument")
 	}
 
 	appName := args[0]
+	format := "text"
+
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--format" && i+1 < len(args):
+			i++
+			format = args[i]
+		case strings.HasPrefix(args[i], "--format="):
+			format = strings.TrimPrefix(args[i], "--format=")
+		default:
+			return fmt.Errorf("unknown health flag: %s", args[i])
+		}
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid --format %q: want text or json", format)
+	}
+
+	appConfig, exists := c.configs[appName]
+	if !exists {
+		return fmt.Errorf("no configuration found for app %s", appName)
+	}
+
+	dockerClient, err := c.runtimePool.Get(runtime.Kind(appConfig.Docker.Runtime), c.dockerEndpoint(appConfig))
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	dockerManager := docker.NewRuntimeManager(dockerClient)
+
+	var routedColor string
+	if cs, err := state.GetCurrentState(c.DB, appName); err == nil && cs != nil {
+		routedColor = cs.ActiveColor
+	}
+
+	ctx := c.ctxOrBackground()
+	reports := make([]colorHealthReport, 0, 2)
+	for _, color := range []string{"blue", "green"} {
+		reports = append(reports, c.checkColorHealth(ctx, dockerManager, appName, color, appConfig, color == routedColor))
+	}
+
+	if format == "json" {
+		out, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode health report: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
 
 	c.logger.Info("Health check for %s:", appName)
-	c.logger.Info("  Blue:  ✓ healthy (2/2 containers)")
-	c.logger.Info("  Green: ✓ healthy (2/2 containers)")
-	c.logger.Info("  Load Balancer: ✓ healthy")
+	for _, r := range reports {
+		routedMark := ""
+		if r.Routed {
+			routedMark = " (routed)"
+		}
+		if !r.Found {
+			c.logger.Info("  %s%s: not found", capitalize(r.Color), routedMark)
+			continue
+		}
+		c.logger.Info("  %s%s: container=%s image=%s uptime=%s docker_health=%s failing_streak=%d",
+			capitalize(r.Color), routedMark, r.ContainerID[:12], r.Image, r.Uptime.Round(time.Second), r.DockerHealth, r.FailingStreak)
+		if r.ProbeStatus == "" {
+			c.logger.Info("    probe: no HTTP health_check configured")
+		} else if r.ProbeError != "" {
+			c.logger.Info("    probe: %s (%v) - %s", r.ProbeStatus, r.ProbeLatency.Round(time.Millisecond), r.ProbeError)
+		} else {
+			c.logger.Info("    probe: %s status=%d latency=%v", r.ProbeStatus, r.ProbeCode, r.ProbeLatency.Round(time.Millisecond))
+		}
+	}
 
 	return nil
 }
 
+// checkColorHealth resolves appName-color's container info via Docker and,
+// when appConfig.HealthCheck is an HTTP check, performs one additional
+// out-of-band GET/POST against its endpoint - separate from the
+// retrying/threshold-gated probes CheckContainerHealth runs during a
+// deploy - timing it for on-call diagnosis.
+func (c *CLI) checkColorHealth(ctx context.Context, dockerManager *docker.RuntimeManager, appName, color string, appConfig *config.AppConfig, routed bool) colorHealthReport {
+	report := colorHealthReport{Color: color, Routed: routed}
+
+	info, err := dockerManager.GetContainerInfo(ctx, appName, color)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	report.Found = true
+	report.ContainerID = info.ID
+	report.Image = info.Image
+	report.Uptime = time.Since(info.Created).Round(time.Second)
+	report.DockerHealth = info.Health
+	report.FailingStreak = info.FailingStreak
+
+	if appConfig.HealthCheck.Type != config.HealthCheckTypeHTTP && appConfig.HealthCheck.Type != "" {
+		return report
+	}
+	if appConfig.HealthCheck.Endpoint == "" && appConfig.HealthCheck.Type == "" {
+		return report
+	}
+
+	port := appConfig.Ports.Blue
+	if color == "green" {
+		port = appConfig.Ports.Green
+	}
+	method := appConfig.HealthCheck.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	url := fmt.Sprintf("http://localhost:%d%s", port, appConfig.HealthCheck.Endpoint)
+
+	timeout := appConfig.HealthCheck.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(probeCtx, method, url, nil)
+	if err != nil {
+		report.ProbeStatus = "error"
+		report.ProbeError = err.Error()
+		return report
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	report.ProbeLatency = time.Since(start)
+	if err != nil {
+		report.ProbeStatus = "error"
+		report.ProbeError = err.Error()
+		return report
+	}
+	defer resp.Body.Close()
+
+	report.ProbeCode = resp.StatusCode
+	expected := appConfig.HealthCheck.ExpectedStatus
+	if expected == 0 {
+		expected = http.StatusOK
+	}
+	if resp.StatusCode == expected {
+		report.ProbeStatus = "ok"
+	} else {
+		report.ProbeStatus = "unexpected_status"
+		report.ProbeError = fmt.Sprintf("want %d", expected)
+	}
+
+	return report
+}
+
+// isSwitchableSlot reports whether color names one of appConfig's declared
+// slots (blue/green, plus any canary slots), or the legacy blue/green pair
+// for configs with no Slots of their own.
+func isSwitchableSlot(appConfig *config.AppConfig, color string) bool {
+	if len(appConfig.Slots) > 0 {
+		_, ok := appConfig.Slots[color]
+		return ok
+	}
+	return color == "blue" || color == "green"
+}
+
+// declaredSlotNames lists appConfig's valid switch targets, sorted, for
+// error messages - the declared Slots if any, else the legacy blue/green pair.
+func declaredSlotNames(appConfig *config.AppConfig) string {
+	if len(appConfig.Slots) == 0 {
+		return "'blue', 'green'"
+	}
+
+	names := make([]string, 0, len(appConfig.Slots))
+	for name := range appConfig.Slots {
+		names = append(names, "'"+name+"'")
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+// parseCanarySteps parses a --steps flag's comma-separated weight list
+// (e.g. "10,25,50,100") the same way config.go validates deployment.canary.steps:
+// each between 1 and 100, strictly increasing, ending at 100.
+func parseCanarySteps(raw string) ([]int, error) {
+	parts := strings.Split(raw, ",")
+	steps := make([]int, 0, len(parts))
+	previous := 0
+	for _, part := range parts {
+		step, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --steps value %q: %w", part, err)
+		}
+		if step < 1 || step > 100 {
+			return nil, fmt.Errorf("--steps value %d must be between 1 and 100", step)
+		}
+		if step <= previous {
+			return nil, fmt.Errorf("--steps must strictly increase, got %d after %d", step, previous)
+		}
+		previous = step
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 || steps[len(steps)-1] != 100 {
+		return nil, fmt.Errorf("--steps must end at 100, got %v", steps)
+	}
+	return steps, nil
+}
+
 func (c *CLI) handleSwitch(args []string) error {
 	if len(args) < 2 {
 		return fmt.Errorf("switch requires <app-name> and <color> arguments")
@@ -257,25 +856,93 @@ func (c *CLI) handleSwitch(args []string) error {
 	appName := args[0]
 	color := args[1]
 
-	if color != "blue" && color != "green" {
-		return fmt.Errorf("color must be 'blue' or 'green', got: %s", color)
+	strategy := "instant"
+	canarySteps := []int{10, 25, 50, 100}
+	stepInterval := 30 * time.Second
+	abortOn5xxRate := 0.02
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--strategy="):
+			strategy = strings.TrimPrefix(arg, "--strategy=")
+		case arg == "--strategy" && i+1 < len(args):
+			i++
+			strategy = args[i]
+		case strings.HasPrefix(arg, "--steps="):
+			steps, err := parseCanarySteps(strings.TrimPrefix(arg, "--steps="))
+			if err != nil {
+				return err
+			}
+			canarySteps = steps
+		case arg == "--steps" && i+1 < len(args):
+			i++
+			steps, err := parseCanarySteps(args[i])
+			if err != nil {
+				return err
+			}
+			canarySteps = steps
+		case strings.HasPrefix(arg, "--step-interval="):
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "--step-interval="))
+			if err != nil {
+				return fmt.Errorf("invalid --step-interval duration: %w", err)
+			}
+			stepInterval = d
+		case arg == "--step-interval" && i+1 < len(args):
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil {
+				return fmt.Errorf("invalid --step-interval duration: %w", err)
+			}
+			stepInterval = d
+		case strings.HasPrefix(arg, "--abort-on-5xx-rate="):
+			v, err := strconv.ParseFloat(strings.TrimPrefix(arg, "--abort-on-5xx-rate="), 64)
+			if err != nil {
+				return fmt.Errorf("invalid --abort-on-5xx-rate: %w", err)
+			}
+			abortOn5xxRate = v
+		case arg == "--abort-on-5xx-rate" && i+1 < len(args):
+			i++
+			v, err := strconv.ParseFloat(args[i], 64)
+			if err != nil {
+				return fmt.Errorf("invalid --abort-on-5xx-rate: %w", err)
+			}
+			abortOn5xxRate = v
+		default:
+			return fmt.Errorf("unknown switch argument: %s", arg)
+		}
+	}
+	if strategy != "instant" && strategy != "canary" {
+		return fmt.Errorf("invalid --strategy %q: want instant or canary", strategy)
 	}
 
+	return c.switchOne(appName, color, strategy, canarySteps, stepInterval, abortOn5xxRate)
+}
+
+// switchOne runs a single app's traffic cutover to color - instant or
+// canary, per strategy - once the caller has already decided the target
+// color and validated any canary parameters. handleSwitch calls this after
+// parsing its flags; handleDeployGroup calls it directly per app once that
+// app's new container is healthy, so a multi-app release switches traffic
+// through the exact same path as `dockswap switch`.
+func (c *CLI) switchOne(appName, color, strategy string, canarySteps []int, stepInterval time.Duration, abortOn5xxRate float64) error {
 	// Check if app config exists
 	appConfig, exists := c.configs[appName]
 	if !exists {
 		return fmt.Errorf("no configuration found for app %s", appName)
 	}
 
-	// Create Docker client
-	dockerClient, err := docker.NewDockerClient()
+	if !isSwitchableSlot(appConfig, color) {
+		return fmt.Errorf("color must be one of %s, got: %s", declaredSlotNames(appConfig), color)
+	}
+
+	// Create runtime client
+	dockerClient, err := c.runtimePool.Get(runtime.Kind(appConfig.Docker.Runtime), c.dockerEndpoint(appConfig))
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer dockerClient.Close()
 
-	dockerManager := docker.NewDockerManager(dockerClient)
-	ctx := context.Background()
+	dockerManager := docker.NewRuntimeManager(dockerClient)
+	ctx := c.ctxOrBackground()
 
 	// Check if target container exists and is healthy
 	exists, err = dockerManager.ContainerExists(ctx, appName, color)
@@ -313,15 +980,27 @@ func (c *CLI) handleSwitch(args []string) error {
 		return nil
 	}
 
-	// Update database state to switch active color
-	c.logger.Info("✓ Updating traffic routing...")
+	if strategy == "canary" {
+		return c.runCanarySwitch(ctx, appName, appConfig, dockerManager, cs, oldColor, color, canarySteps, stepInterval, abortOn5xxRate)
+	}
+
+	return c.finalizeSwitch(ctx, appName, appConfig, dockerManager, cs, oldColor, color)
+}
+
+// finalizeSwitch performs the actual cutover once color has been decided on:
+// flips ActiveColor in the DB, regenerates and reloads the full Caddy
+// config (not a weighted split - any in-progress canary weights are
+// superseded), optionally stops oldColor's container, and records the
+// traffic_switched event. Both the instant and canary strategies end here.
+func (c *CLI) finalizeSwitch(ctx context.Context, appName string, appConfig *config.AppConfig, dockerManager *docker.RuntimeManager, cs *state.CurrentState, oldColor, color string) error {
 	// Use the image from the current state (cs) if available
 	image := ""
 	if cs != nil {
 		image = cs.Image
 	}
-	err = state.UpsertCurrentState(c.DB, appName, 0, color, image, "active")
-	if err != nil {
+
+	c.logger.Info("✓ Updating traffic routing...")
+	if err := state.UpsertCurrentState(c.DB, appName, 0, color, image, "active"); err != nil {
 		return fmt.Errorf("failed to update state: %w", err)
 	}
 
@@ -335,6 +1014,7 @@ func (c *CLI) handleSwitch(args []string) error {
 				c.logger.Error("Warning: failed to reload Caddy: %v", err)
 			} else {
 				c.logger.Info("✓ Caddy configuration updated")
+				c.bus.Publish(events.Event{Kind: events.KindCaddyReloaded, AppName: appName, Timestamp: time.Now()})
 			}
 		}
 	} else {
@@ -344,60 +1024,592 @@ func (c *CLI) handleSwitch(args []string) error {
 	// Optionally stop old container if configured
 	if appConfig.Deployment.AutoRollback {
 		c.logger.Info("✓ Stopping old %s container...", oldColor)
-		err = dockerManager.StopContainer(ctx, appName, oldColor, 30*time.Second)
-		if err != nil {
+		if err := dockerManager.StopContainer(ctx, appName, oldColor, 30*time.Second); err != nil {
 			c.logger.Error("Warning: failed to stop old container: %v", err)
-		} else {
-			err = dockerManager.RemoveContainer(ctx, appName, oldColor, false)
+		} else if err := dockerManager.RemoveContainer(ctx, appName, oldColor, false); err != nil {
+			c.logger.Error("Warning: failed to remove old container: %v", err)
+		}
+	}
+
+	c.logger.Info("✓ Traffic switched to %s deployment", color)
+	c.bus.Publish(events.Event{Kind: events.KindTrafficSwitched, AppName: appName, Color: color, FromState: oldColor, ToState: color, Image: image, Timestamp: time.Now()})
+	if cs != nil {
+		if _, err := state.InsertDeploymentEvent(c.DB, cs.DeploymentID, appName, string(events.KindTrafficSwitched), image, nil); err != nil {
+			c.logger.Error("Warning: failed to persist switch event: %v", err)
+		}
+	}
+	return nil
+}
+
+// applyCanaryWeights re-renders appName's Caddy routing across weights
+// (color name -> traffic percent) and reloads Caddy, the per-step operation
+// runCanarySwitch and abortCanarySwitch both drive.
+func (c *CLI) applyCanaryWeights(appName string, weights map[string]int) error {
+	configs, states, err := c.currentConfigsAndStates()
+	if err != nil {
+		return err
+	}
+	if err := c.caddyMgr.UpdateWeightedRouting(appName, weights, configs, states); err != nil {
+		return err
+	}
+	if err := c.caddyMgr.ReloadCaddy(); err != nil {
+		return err
+	}
+	c.bus.Publish(events.Event{Kind: events.KindCaddyReloaded, AppName: appName, Timestamp: time.Now()})
+	return nil
+}
+
+// abortCanarySwitch reverts traffic to 100% oldColor, clears the persisted
+// rollout record, and records a rollback event - mirroring how
+// deployment.DeploymentStateMachine's EventCanaryStepFailed restores full
+// traffic to the stable color on a failed orchestrator-driven canary step.
+func (c *CLI) abortCanarySwitch(appName string, oldColor string, depID int64, image string, cause error) error {
+	c.logger.Error("Canary rollout for %s aborting: %v", appName, cause)
+	if err := c.applyCanaryWeights(appName, map[string]int{oldColor: 100}); err != nil {
+		c.logger.Error("Warning: failed to revert traffic to %s: %v", oldColor, err)
+	}
+	if err := state.DeleteCanaryRollout(c.DB, appName); err != nil {
+		c.logger.Error("Warning: failed to clear canary rollout record: %v", err)
+	}
+	c.bus.Publish(events.Event{Kind: events.KindDeploymentRolledBack, AppName: appName, Color: oldColor, FromState: "canary", ToState: oldColor, Image: image, Timestamp: time.Now(), Error: cause})
+	if depID != 0 {
+		errMsg := cause.Error()
+		if _, err := state.InsertDeploymentEvent(c.DB, depID, appName, string(events.KindDeploymentRolledBack), image, &errMsg); err != nil {
+			c.logger.Error("Warning: failed to persist rollback event: %v", err)
+		}
+	}
+	return fmt.Errorf("canary switch for %s aborted: %w", appName, cause)
+}
+
+// runCanarySwitch drives `switch --strategy=canary` through steps' weight
+// stages instead of cutting traffic over in one shot: each step shifts
+// oldColor/color's Caddy weights, waits stepInterval for the split to soak,
+// then checks color's health (and, best-effort, Caddy's /metrics 5xx rate)
+// before advancing. A failing step aborts back to oldColor. Progress is
+// persisted to canary_rollouts after every step (state.UpsertCanaryRollout)
+// so a crash mid-rollout leaves a resumable record behind rather than
+// stranding traffic on a partial split with nothing recorded; a SIGINT
+// instead pauses cleanly at the current step. A later run with the same
+// colors and steps resumes from the recorded step; any other recorded
+// rollout is overwritten and the run starts from the first step.
+func (c *CLI) runCanarySwitch(ctx context.Context, appName string, appConfig *config.AppConfig, dockerManager *docker.RuntimeManager, cs *state.CurrentState, oldColor, color string, steps []int, stepInterval time.Duration, abortOn5xxRate float64) error {
+	if c.caddyMgr == nil {
+		return fmt.Errorf("switch --strategy=canary requires Caddy to be configured")
+	}
+
+	image := ""
+	var depID int64
+	if cs != nil {
+		image = cs.Image
+		depID = cs.DeploymentID
+	}
+
+	now := time.Now()
+	rollout := state.CanaryRollout{
+		AppName:        appName,
+		FromColor:      oldColor,
+		ToColor:        color,
+		Steps:          steps,
+		StepInterval:   stepInterval,
+		AbortOn5xxRate: abortOn5xxRate,
+		StartedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	coord := shutdown.New()
+	stopTrap := coord.Trap(func(sig os.Signal) {
+		c.logger.Info("switch: %s received, pausing canary rollout for %s (re-run switch to resume)", sig, appName)
+	})
+	defer stopTrap()
+	stepCtx := coord.Context()
+
+	startIdx := 0
+	if prev, err := state.GetCanaryRollout(c.DB, appName); err != nil {
+		c.logger.Error("Warning: failed to read canary rollout record: %v", err)
+	} else if prev != nil && prev.FromColor == oldColor && prev.ToColor == color &&
+		fmt.Sprint(prev.Steps) == fmt.Sprint(steps) && prev.StepIdx < len(steps) {
+		startIdx = prev.StepIdx
+		rollout.StartedAt = prev.StartedAt
+		c.logger.Info("Resuming canary rollout for %s at step %d", appName, startIdx)
+	}
+
+	for idx := startIdx; idx < len(steps); idx++ {
+		weight := steps[idx]
+		rollout.StepIdx = idx
+		rollout.UpdatedAt = time.Now()
+		if err := state.UpsertCanaryRollout(c.DB, rollout); err != nil {
+			c.logger.Error("Warning: failed to persist canary rollout progress: %v", err)
+		}
+
+		c.logger.Info("✓ Shifting %s to %d%% on %s (%d%% on %s)...", appName, weight, color, 100-weight, oldColor)
+		if err := c.applyCanaryWeights(appName, map[string]int{oldColor: 100 - weight, color: weight}); err != nil {
+			return c.abortCanarySwitch(appName, oldColor, depID, image, fmt.Errorf("failed to shift traffic: %w", err))
+		}
+
+		select {
+		case <-stepCtx.Done():
+			c.logger.Info("canary rollout for %s paused at step %d (%d%% on %s)", appName, idx, weight, color)
+			return nil
+		case <-time.After(stepInterval):
+		}
+
+		healthReport := c.checkColorHealth(ctx, dockerManager, appName, color, appConfig, true)
+		if !healthReport.Found || healthReport.DockerHealth == "unhealthy" || (healthReport.ProbeStatus != "" && healthReport.ProbeStatus != "ok") {
+			return c.abortCanarySwitch(appName, oldColor, depID, image,
+				fmt.Errorf("%s failed health check at %d%% traffic: docker_health=%s probe=%s", color, weight, healthReport.DockerHealth, healthReport.ProbeStatus))
+		}
+
+		if rate, ok, err := c.caddyMgr.Fetch5xxRate(); err != nil {
+			c.logger.Info("  (could not read Caddy metrics for 5xx-rate check: %v)", err)
+		} else if ok && rate > abortOn5xxRate {
+			return c.abortCanarySwitch(appName, oldColor, depID, image,
+				fmt.Errorf("5xx rate %.4f exceeded --abort-on-5xx-rate %.4f at %d%% traffic", rate, abortOn5xxRate, weight))
+		}
+	}
+
+	if err := state.DeleteCanaryRollout(c.DB, appName); err != nil {
+		c.logger.Error("Warning: failed to clear canary rollout record: %v", err)
+	}
+
+	c.logger.Info("✓ Canary rollout complete, finalizing cutover to %s...", color)
+	return c.finalizeSwitch(ctx, appName, appConfig, dockerManager, cs, oldColor, color)
+}
+
+// handleDeployGroup reads --file (app name -> image tag) plus each named
+// app's config.AppConfig.DependsOn, deploys and switches them in
+// depends_on-topological order via depgraph.Build, and halts the whole
+// release the moment any app in a level fails - rolling every
+// already-switched app in that release back to its pre-release color
+// first. Apps within a level run in parallel, bounded by --max-parallel.
+func (c *CLI) handleDeployGroup(args []string) error {
+	var filePath string
+	maxParallel := 1
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--file="):
+			filePath = strings.TrimPrefix(arg, "--file=")
+		case arg == "--file" && i+1 < len(args):
+			i++
+			filePath = args[i]
+		case strings.HasPrefix(arg, "--max-parallel="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--max-parallel="))
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --max-parallel value: %q", arg)
+			}
+			maxParallel = n
+		case arg == "--max-parallel" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --max-parallel value: %q", args[i])
+			}
+			maxParallel = n
+		default:
+			return fmt.Errorf("unknown deploy-group argument: %s", arg)
+		}
+	}
+	if filePath == "" {
+		return fmt.Errorf("deploy-group requires --file <release.yaml>")
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read release file %s: %w", filePath, err)
+	}
+	var images map[string]string
+	if err := yaml.Unmarshal(data, &images); err != nil {
+		return fmt.Errorf("failed to parse release file %s: %w", filePath, err)
+	}
+	if len(images) == 0 {
+		return fmt.Errorf("release file %s declares no apps", filePath)
+	}
+
+	deps := make(map[string][]string, len(images))
+	for appName := range images {
+		appConfig, exists := c.configs[appName]
+		if !exists {
+			return fmt.Errorf("no configuration found for app %s", appName)
+		}
+		deps[appName] = appConfig.DependsOn
+	}
+
+	graph, err := depgraph.Build(deps)
+	if err != nil {
+		return fmt.Errorf("invalid depends_on graph: %w", err)
+	}
+
+	releaseID := fmt.Sprintf("release-%d", time.Now().UnixNano())
+	c.logger.Info("Starting release %s: %d app(s) across %d level(s)", releaseID, len(images), len(graph.Levels))
+
+	var switchedRuns []state.ReleaseRun
+	for levelIdx, level := range graph.Levels {
+		c.logger.Info("Level %d: %s", levelIdx, strings.Join(level, ", "))
+
+		runs := make([]state.ReleaseRun, len(level))
+		errs := make([]error, len(level))
+		sem := make(chan struct{}, maxParallel)
+		var wg sync.WaitGroup
+		for i, appName := range level {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, appName string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				runs[i], errs[i] = c.deployAndSwitchOne(releaseID, appName, images[appName])
+			}(i, appName)
+		}
+		wg.Wait()
+
+		var failed []string
+		for i, err := range errs {
 			if err != nil {
-				c.logger.Error("Warning: failed to remove old container: %v", err)
+				c.logger.Error("deploy-group: %s failed: %v", level[i], err)
+				failed = append(failed, level[i])
+				continue
 			}
+			switchedRuns = append(switchedRuns, runs[i])
+		}
+
+		if len(failed) > 0 {
+			c.logger.Error("✗ Release %s failed at level %d (%s); rolling back %d already-switched app(s)", releaseID, levelIdx, strings.Join(failed, ", "), len(switchedRuns))
+			c.rollbackReleaseRuns(switchedRuns)
+			return fmt.Errorf("deploy-group release %s failed: %s did not deploy cleanly", releaseID, strings.Join(failed, ", "))
 		}
 	}
 
-	c.logger.Info("✓ Traffic switched to %s deployment", color)
+	c.logger.Info("✓ Release %s complete", releaseID)
 	return nil
 }
 
+// deployAndSwitchOne snapshots appName's pre-release CurrentState into a
+// new release_runs row, then runs deployOne followed by an instant
+// switchOne to the newly deployed color. The returned ReleaseRun is only
+// meaningful when err is nil; handleDeployGroup uses it to roll this app
+// back if a later level of the same release fails.
+func (c *CLI) deployAndSwitchOne(releaseID, appName, image string) (state.ReleaseRun, error) {
+	previousColor := "blue"
+	previousImage := ""
+	if cs, err := state.GetCurrentState(c.DB, appName); err == nil && cs != nil {
+		previousColor = cs.ActiveColor
+		previousImage = cs.Image
+	}
+
+	runID, err := state.InsertReleaseRun(c.DB, releaseID, appName, previousColor, previousImage)
+	if err != nil {
+		c.logger.Error("Warning: failed to record release run for %s: %v", appName, err)
+	}
+	run := state.ReleaseRun{ID: runID, ReleaseID: releaseID, AppName: appName, PreviousColor: previousColor, PreviousImage: previousImage}
+
+	targetColor, err := c.deployOne(appName, image, "", false)
+	if err != nil {
+		return run, fmt.Errorf("deploy: %w", err)
+	}
+
+	if err := c.switchOne(appName, targetColor, "instant", nil, 0, 0); err != nil {
+		return run, fmt.Errorf("switch: %w", err)
+	}
+
+	if runID != 0 {
+		if err := state.UpdateReleaseRunStatus(c.DB, runID, state.ReleaseRunSwitched); err != nil {
+			c.logger.Error("Warning: failed to update release run status for %s: %v", appName, err)
+		}
+	}
+	return run, nil
+}
+
+// rollbackReleaseRuns switches every already-switched app back to its
+// PreviousColor, in reverse order, so the apps later in topological order
+// (which depend on the ones before them) roll back before their
+// dependencies do.
+func (c *CLI) rollbackReleaseRuns(runs []state.ReleaseRun) {
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		c.logger.Info("✓ Rolling back %s to %s...", run.AppName, run.PreviousColor)
+		if err := c.switchOne(run.AppName, run.PreviousColor, "instant", nil, 0, 0); err != nil {
+			c.logger.Error("Warning: failed to roll back %s to %s: %v", run.AppName, run.PreviousColor, err)
+			continue
+		}
+		if run.ID != 0 {
+			if err := state.UpdateReleaseRunStatus(c.DB, run.ID, state.ReleaseRunRolledBack); err != nil {
+				c.logger.Error("Warning: failed to update release run status for %s: %v", run.AppName, err)
+			}
+		}
+	}
+}
+
+// initializeOrchestratorApp initializes orchestrator's state machine for
+// appName from its last known active color in the DB, defaulting to blue
+// for an app that's never been deployed. If the persisted current_state
+// marks this app "interrupted" - a previous process's shutdown.Coordinator
+// cut a deployment off mid-flight - the freshly initialized machine is
+// immediately flagged failed via MarkInterrupted instead of treated as
+// settled, so it needs an operator's RecoverApp before it deploys again.
+// Shared by `watch` and `serve`.
+func (c *CLI) initializeOrchestratorApp(orchestrator *docker.DeploymentOrchestrator, appName string) error {
+	activeColor := "blue"
+	interrupted := false
+	if cs, err := state.GetCurrentState(c.DB, appName); err == nil && cs != nil {
+		activeColor = cs.ActiveColor
+		interrupted = cs.Status == "interrupted"
+	}
+	if err := orchestrator.InitializeApp(appName, activeColor); err != nil {
+		return err
+	}
+	if interrupted {
+		c.logger.Error("%s: previous process was interrupted mid-deployment; marking failed pending manual recovery", appName)
+		orchestrator.MarkInterrupted(appName)
+	}
+	return nil
+}
+
+// startEventReconciliation subscribes to dockerClient's event stream for the
+// duration of ctx and reacts to it two ways: orchestrator.HandleContainerEvent
+// notifies an active color's state machine of an unexpected exit, and a
+// StateReconciler records every event dockswap cares about into
+// deployment_events and keeps current_state.status in sync, without either
+// waiting for runDeploymentLoop's next poll. The reconciler also resyncs
+// current_state against ListAppContainers on every (re)connect, healing
+// drift accumulated while the stream was down. Called by serve/watch, which
+// are the only commands long-lived enough for an event stream to matter.
+func (c *CLI) startEventReconciliation(ctx context.Context, dockerClient runtime.Client, dockerManager *docker.RuntimeManager, orchestrator *docker.DeploymentOrchestrator, configs map[string]*config.AppConfig) {
+	watcher := docker.NewEventWatcher(dockerClient)
+
+	reconciler := docker.NewStateReconciler(c.DB, dockerManager, configs)
+	reconciler.SetLogger(c.logger)
+	watcher.SetResync(reconciler.Resync)
+
+	go watcher.Run(ctx)
+	go docker.DispatchEvents(watcher, orchestrator.HandleContainerEvent, reconciler.Handle)
+}
+
+// handleWatch runs a Watchtower-style poll loop for every app configured
+// with watch.enabled (or, if app-name is given, just that app), blocking
+// until interrupted. Each digest change triggers a blue/green rollout
+// through the same DeploymentOrchestrator the deploy command uses. A
+// shutdown.Coordinator traps SIGINT/SIGTERM, canceling the context the
+// watch loop and any in-flight deployment run under before c.shutdown waits
+// for that deployment to reach a safe checkpoint; a second signal forces an
+// immediate exit.
+func (c *CLI) handleWatch(args []string) error {
+	if c.DB == nil {
+		return fmt.Errorf("DB not initialized")
+	}
+
+	var only string
+	if len(args) > 0 {
+		only = args[0]
+	}
+
+	configs := make(map[string]*config.AppConfig)
+	for appName, appConfig := range c.configs {
+		if only != "" && appName != only {
+			continue
+		}
+		if !appConfig.Watch.Enabled {
+			continue
+		}
+		configs[appName] = appConfig
+	}
+	if len(configs) == 0 {
+		return fmt.Errorf("no apps configured with watch.enabled")
+	}
+
+	dockerClient, err := c.runtimePool.Get(runtime.Docker, runtime.Endpoint{})
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+
+	dockerManager := docker.NewRuntimeManager(dockerClient)
+	if err := dockerManager.ValidateConnection(c.ctxOrBackground()); err != nil {
+		return fmt.Errorf("Docker not available: %w", err)
+	}
+
+	if err := c.wireEventSubscribers(); err != nil {
+		return err
+	}
+
+	coord := shutdown.New()
+
+	orchestrator := docker.NewDeploymentOrchestrator(dockerManager, c.proxyMgr, c.configs)
+	orchestrator.SetDB(c.DB)
+	orchestrator.SetLogger(c.logger)
+	orchestrator.SetEventBus(c.bus)
+	orchestrator.SetContext(coord.Context())
+	orchestrator.SetRuntimePool(c.runtimePool, c.tlsDirIfPresent())
+	for appName := range configs {
+		if err := c.initializeOrchestratorApp(orchestrator, appName); err != nil {
+			return fmt.Errorf("failed to initialize app %s: %w", appName, err)
+		}
+	}
+
+	c.startEventReconciliation(coord.Context(), dockerClient, dockerManager, orchestrator, configs)
+
+	store := watcher.NewDBStateStore(c.DB)
+	w := watcher.New(configs, orchestrator, dockerManager, store, 10*time.Second, c.logger)
+
+	stopTrap := coord.Trap(func(sig os.Signal) {
+		c.logger.Info("watch: %s received, shutting down...", sig)
+	})
+	defer stopTrap()
+
+	c.logger.Info("Watching %d app(s) for new images (Ctrl+C to stop)...", len(configs))
+	w.Run(coord.Context())
+	c.shutdown(orchestrator)
+
+	return nil
+}
+
+// handleLogs streams container logs for an app, by default tailing both
+// blue and green concurrently (useful for watching traffic drain during a
+// switch) with each line prefixed by its color and container short ID so
+// the two streams stay distinguishable once interleaved. A shutdown.Coordinator
+// traps SIGINT so --follow can be interrupted cleanly, closing the
+// underlying HTTP log stream(s) rather than leaving them open.
 func (c *CLI) handleLogs(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("logs requires <app-name> argument")
 	}
 
 	appName := args[0]
-	follow := false
+	opts := docker.StreamLogsOptions{Tail: "all"}
+	color := "both"
 
 	for i := 1; i < len(args); i++ {
-		if args[i] == "--follow" || args[i] == "-f" {
-			follow = true
+		switch {
+		case args[i] == "--follow" || args[i] == "-f":
+			opts.Follow = true
+		case args[i] == "--timestamps":
+			opts.Timestamps = true
+		case args[i] == "--since" && i+1 < len(args):
+			i++
+			opts.Since = args[i]
+		case strings.HasPrefix(args[i], "--since="):
+			opts.Since = strings.TrimPrefix(args[i], "--since=")
+		case args[i] == "--tail" && i+1 < len(args):
+			i++
+			opts.Tail = args[i]
+		case strings.HasPrefix(args[i], "--tail="):
+			opts.Tail = strings.TrimPrefix(args[i], "--tail=")
+		case args[i] == "--color" && i+1 < len(args):
+			i++
+			color = args[i]
+		case strings.HasPrefix(args[i], "--color="):
+			color = strings.TrimPrefix(args[i], "--color=")
+		default:
+			return fmt.Errorf("unknown logs flag: %s", args[i])
 		}
 	}
 
-	if follow {
-		c.logger.Info("Logs for %s (following):", appName)
-	} else {
-		c.logger.Info("Logs for %s:", appName)
+	var colors []string
+	switch color {
+	case "both":
+		colors = []string{"blue", "green"}
+	case "blue", "green":
+		colors = []string{color}
+	default:
+		return fmt.Errorf("invalid --color %q: want blue, green, or both", color)
 	}
 
-	c.logger.Info("2024-01-15 14:30:25 [INFO] Application started")
-	c.logger.Info("2024-01-15 14:30:26 [INFO] Listening on port 8080")
-	c.logger.Info("2024-01-15 14:30:27 [INFO] Health check endpoint ready")
+	appConfig, exists := c.configs[appName]
+	if !exists {
+		return fmt.Errorf("no configuration found for app %s", appName)
+	}
+
+	dockerClient, err := c.runtimePool.Get(runtime.Kind(appConfig.Docker.Runtime), c.dockerEndpoint(appConfig))
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	dockerManager := docker.NewRuntimeManager(dockerClient)
+
+	coord := shutdown.New()
+	stopTrap := coord.Trap(func(sig os.Signal) {
+		c.logger.Info("logs: %s received, stopping...", sig)
+	})
+	defer stopTrap()
+	ctx := coord.Context()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(colors))
+	for _, col := range colors {
+		logs, containerID, err := dockerManager.StreamContainerLogs(ctx, appName, col, opts)
+		if err != nil {
+			if len(colors) > 1 {
+				c.logger.Info("%s: %v", col, err)
+				continue
+			}
+			return fmt.Errorf("failed to stream logs: %w", err)
+		}
 
-	if follow {
-		c.logger.Info("^C to stop following logs")
+		prefix := fmt.Sprintf("[%s %s] ", col, containerID[:12])
+		wg.Add(1)
+		go func(logs io.ReadCloser) {
+			defer wg.Done()
+			defer logs.Close()
+			if err := demuxPrefixedLogs(os.Stdout, logs, prefix); err != nil && err != io.EOF {
+				errs <- fmt.Errorf("%s: %w", prefix, err)
+			}
+		}(logs)
 	}
 
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		return err
+	}
 	return nil
 }
 
+// demuxPrefixedLogs demultiplexes a Docker stdout/stderr log stream (the
+// same 8-byte stream-type/length framing stdcopy.StdCopy understands) and
+// writes every line to out with prefix prepended, so concurrently tailed
+// colors stay distinguishable once interleaved.
+func demuxPrefixedLogs(out io.Writer, logs io.Reader, prefix string) error {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, logs)
+		pw.CloseWithError(err)
+	}()
+
+	go func() {
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			fmt.Fprintf(out, "%s%s\n", prefix, scanner.Text())
+		}
+		done <- scanner.Err()
+	}()
+
+	return <-done
+}
+
 func (c *CLI) handleConfig(args []string) error {
-	if len(args) == 0 || args[0] != "reload" {
-		return fmt.Errorf("config subcommand must be 'reload'")
+	if len(args) == 0 {
+		return fmt.Errorf("config subcommand required. Use 'reload', 'history', 'diff', or 'show'")
+	}
+
+	subcommand := args[0]
+	subArgs := args[1:]
+
+	switch subcommand {
+	case "reload":
+		return c.handleConfigReload(subArgs)
+	case "history":
+		return c.handleConfigHistory(subArgs)
+	case "diff":
+		return c.handleConfigDiff(subArgs)
+	case "show":
+		return c.handleConfigShow(subArgs)
+	default:
+		return fmt.Errorf("unknown config subcommand: %s. Use 'reload', 'history', 'diff', or 'show'", subcommand)
 	}
+}
 
+func (c *CLI) handleConfigReload(args []string) error {
 	var appName string
-	if len(args) > 1 {
-		appName = args[1]
+	if len(args) > 0 {
+		appName = args[0]
 	}
 
 	if appName != "" {
@@ -411,6 +1623,115 @@ func (c *CLI) handleConfig(args []string) error {
 	return nil
 }
 
+// handleConfigHistory lists the content-addressed app_configs rows retained
+// for an app - each one a distinct config_sha, not one per deploy, since
+// InsertAppConfig dedupes byte-identical YAML.
+func (c *CLI) handleConfigHistory(args []string) error {
+	if c.DB == nil {
+		return fmt.Errorf("DB not initialized")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("config history requires <app-name> argument")
+	}
+	appName := args[0]
+
+	hist, err := state.GetAppConfigHistory(c.DB, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get config history: %w", err)
+	}
+	if len(hist) == 0 {
+		c.logger.Info("No configs recorded for %s", appName)
+		return nil
+	}
+	c.logger.Info("Config history for %s:", appName)
+	for _, cfg := range hist {
+		c.logger.Info("  #%d  %s  sha=%s", cfg.ID, cfg.CreatedAt.Format("2006-01-02 15:04:05"), cfg.ConfigSHA)
+	}
+	return nil
+}
+
+// handleConfigDiff prints a unified diff between two app_configs rows,
+// `dockswap config diff <old-id> <new-id>`.
+func (c *CLI) handleConfigDiff(args []string) error {
+	if c.DB == nil {
+		return fmt.Errorf("DB not initialized")
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("config diff requires <old-id> and <new-id> arguments")
+	}
+	oldID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid old-id %q: %w", args[0], err)
+	}
+	newID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid new-id %q: %w", args[1], err)
+	}
+
+	diff, err := state.DiffAppConfigs(c.DB, oldID, newID)
+	if err != nil {
+		return fmt.Errorf("failed to diff configs: %w", err)
+	}
+	if diff == "" {
+		c.logger.Info("config #%d and #%d are identical", oldID, newID)
+		return nil
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+// handleConfigShow prints the raw stored YAML for one app_configs row,
+// `dockswap config show <app-name>` for the latest or `--id <id>` for a
+// specific one.
+func (c *CLI) handleConfigShow(args []string) error {
+	if c.DB == nil {
+		return fmt.Errorf("DB not initialized")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("config show requires <app-name> argument")
+	}
+	appName := args[0]
+
+	var id int64
+	for i := 1; i < len(args); i++ {
+		if args[i] == "--id" && i+1 < len(args) {
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid --id value: %s", args[i+1])
+			}
+			id = n
+			i++
+		}
+	}
+
+	var cfg *state.AppConfig
+	var err error
+	if id != 0 {
+		hist, histErr := state.GetAppConfigHistory(c.DB, appName)
+		if histErr != nil {
+			return fmt.Errorf("failed to get config history: %w", histErr)
+		}
+		for i := range hist {
+			if hist[i].ID == id {
+				cfg = &hist[i]
+				break
+			}
+		}
+		if cfg == nil {
+			return fmt.Errorf("no config #%d found for %s", id, appName)
+		}
+	} else {
+		cfg, err = state.GetLatestAppConfig(c.DB, appName)
+		if err != nil {
+			return fmt.Errorf("failed to get latest config: %w", err)
+		}
+	}
+
+	c.logger.Info("Config #%d for %s (sha=%s, recorded %s):", cfg.ID, appName, cfg.ConfigSHA, cfg.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Println(cfg.ConfigYAML)
+	return nil
+}
+
 func (c *CLI) handleVersion(args []string) error {
 	showFull := false
 
@@ -469,7 +1790,7 @@ func (c *CLI) handleCaddyStatus(args []string) error {
 	}
 
 	// Check if Caddy is running
-	err := c.caddyMgr.ValidateCaddyRunning()
+	err := c.caddyMgr.ValidateRunning()
 	if err != nil {
 		c.logger.Info("  Status: %s", "❌ Not running")
 		c.logger.Error("  Error: %v", err)
@@ -491,7 +1812,7 @@ func (c *CLI) handleCaddyReload(args []string) error {
 	c.logger.Info("Reloading Caddy configuration...")
 
 	// Check if Caddy is running
-	if err := c.caddyMgr.ValidateCaddyRunning(); err != nil {
+	if err := c.caddyMgr.ValidateRunning(); err != nil {
 		return fmt.Errorf("caddy is not running: %w", err)
 	}
 
@@ -561,6 +1882,74 @@ func (c *CLI) handleCaddyConfigShow(args []string) error {
 	return nil
 }
 
+// handleMigrate dispatches the `migrate` subcommands over c.DB's Migrator.
+// Note main.go already runs state.OpenAndMigrate (i.e. `migrate up`) before
+// any command gets to run, so `up` here is mostly for scripting a fresh DB
+// without starting dockswap; `down`, `redo`, and `status` are the ones that
+// add something `OpenAndMigrate` alone doesn't give an operator.
+func (c *CLI) handleMigrate(args []string) error {
+	if c.DB == nil {
+		return fmt.Errorf("DB not initialized")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("migrate subcommand required. Use 'up', 'down', 'redo', or 'status'")
+	}
+
+	ctx := c.ctxOrBackground()
+	migrator := state.NewDefaultMigrator(c.DB)
+	subcommand, subArgs := args[0], args[1:]
+
+	switch subcommand {
+	case "up":
+		if err := migrator.Up(ctx); err != nil {
+			return fmt.Errorf("migrate up: %w", err)
+		}
+		c.logger.Info("migrate: up to date")
+		return nil
+
+	case "down":
+		steps := 1
+		if len(subArgs) > 0 {
+			n, err := strconv.Atoi(subArgs[0])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("migrate down: N must be a positive integer, got %q", subArgs[0])
+			}
+			steps = n
+		}
+		if err := migrator.Down(ctx, steps); err != nil {
+			return fmt.Errorf("migrate down: %w", err)
+		}
+		c.logger.Info("migrate: rolled back %d migration(s)", steps)
+		return nil
+
+	case "redo":
+		if err := migrator.Redo(ctx); err != nil {
+			return fmt.Errorf("migrate redo: %w", err)
+		}
+		c.logger.Info("migrate: redone")
+		return nil
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate status: %w", err)
+		}
+		c.logger.Info("Schema migrations:")
+		for _, s := range statuses {
+			if s.Applied {
+				c.logger.Info("  [x] %d  %-30s applied %s (%dms)",
+					s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"), s.DurationMS)
+			} else {
+				c.logger.Info("  [ ] %d  %-30s pending", s.Version, s.Name)
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate subcommand: %s. Use 'up', 'down', 'redo', or 'status'", subcommand)
+	}
+}
+
 func (c *CLI) handleDbgCmd(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("dbg-cmd requires <app-name> argument")
@@ -590,15 +1979,14 @@ func (c *CLI) handleDbgCmd(args []string) error {
 		return fmt.Errorf("no configuration found for app %s", appName)
 	}
 
-	// Create Docker client
-	dockerClient, err := docker.NewDockerClient()
+	// Create runtime client
+	dockerClient, err := c.runtimePool.Get(runtime.Kind(appConfig.Docker.Runtime), c.dockerEndpoint(appConfig))
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer dockerClient.Close()
 
-	dockerManager := docker.NewDockerManager(dockerClient)
-	ctx := context.Background()
+	dockerManager := docker.NewRuntimeManager(dockerClient)
+	ctx := c.ctxOrBackground()
 
 	// If no color specified, try to determine active color from state
 	if color == "" {
@@ -646,12 +2034,12 @@ func (c *CLI) handleDbgCmd(args []string) error {
 	return nil
 }
 
-func (c *CLI) generateCaddyConfig() error {
-	if c.caddyMgr == nil {
-		return fmt.Errorf("caddy manager not initialized")
-	}
-
-	// Get current states for all apps
+// currentConfigsAndStates returns every app with a DB current_state row,
+// paired with its config.AppConfig - the configs/states maps
+// caddy.CaddyManager.GenerateConfig/UpdateWeightedRouting render from, shared
+// by generateCaddyConfig's full regen and a canary rollout's per-step weight
+// updates.
+func (c *CLI) currentConfigsAndStates() (map[string]*config.AppConfig, map[string]*state.AppState, error) {
 	states := make(map[string]*state.AppState)
 	validConfigs := make(map[string]*config.AppConfig)
 
@@ -676,7 +2064,19 @@ func (c *CLI) generateCaddyConfig() error {
 
 	// Only generate config if we have valid states
 	if len(states) == 0 {
-		return fmt.Errorf("no apps with valid state found")
+		return nil, nil, fmt.Errorf("no apps with valid state found")
+	}
+	return validConfigs, states, nil
+}
+
+func (c *CLI) generateCaddyConfig() error {
+	if c.caddyMgr == nil {
+		return fmt.Errorf("caddy manager not initialized")
+	}
+
+	validConfigs, states, err := c.currentConfigsAndStates()
+	if err != nil {
+		return err
 	}
 
 	// Generate config
@@ -2,8 +2,11 @@ package cli
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+
+	"dockswap/internal/workspace"
 )
 
 type StatFunc func(string) (os.FileInfo, error)
@@ -23,21 +26,30 @@ func FindConfigDir(flags GlobalFlags, statFunc StatFunc, homeDirFunc HomeDirFunc
 		getwdFunc = os.Getwd
 	}
 
-	// 1. --config arg
+	// 1. --workspace arg, resolved against the workspaces registry
+	if flags.Workspace != "" {
+		root, err := workspace.ResolveWorkspaceRoot(flags.Workspace)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve --workspace=%s: %w", flags.Workspace, err)
+		}
+		return root, nil
+	}
+
+	// 2. --config arg
 	if flags.Config != "" {
 		if info, err := statFunc(flags.Config); err == nil && info.IsDir() {
 			return flags.Config, nil
 		}
 	}
 
-	// 2. ./dockswap-cfg
+	// 3. ./dockswap-cfg
 	cwd, _ := getwdFunc()
 	local := filepath.Join(cwd, "dockswap-cfg")
 	if info, err := statFunc(local); err == nil && info.IsDir() {
 		return local, nil
 	}
 
-	// 3. $HOME/.config/dockswap-cfg
+	// 4. $HOME/.config/dockswap-cfg
 	home, err := homeDirFunc()
 	if err == nil {
 		homeCfg := filepath.Join(home, ".config", "dockswap-cfg")
@@ -46,11 +58,11 @@ func FindConfigDir(flags GlobalFlags, statFunc StatFunc, homeDirFunc HomeDirFunc
 		}
 	}
 
-	// 4. /etc/dockswap-cfg/
+	// 5. /etc/dockswap-cfg/
 	etc := "/etc/dockswap-cfg/"
 	if info, err := statFunc(etc); err == nil && info.IsDir() {
 		return etc, nil
 	}
 
-	return "", errors.New("no config directory found (tried --config, ./dockswap-cfg, $HOME/.config/dockswap-cfg, /etc/dockswap-cfg/)")
+	return "", errors.New("no config directory found (tried --workspace, --config, ./dockswap-cfg, $HOME/.config/dockswap-cfg, /etc/dockswap-cfg/)")
 }
@@ -0,0 +1,366 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"dockswap/internal/api"
+	"dockswap/internal/config"
+	"dockswap/internal/docker"
+	"dockswap/internal/reconciler"
+	"dockswap/internal/runtime"
+	"dockswap/internal/shutdown"
+	"dockswap/internal/state"
+	"dockswap/internal/watcher"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// handleServe runs dockswap as a long-lived daemon: it starts the same
+// watch-and-deploy loop as `watch` for every watch.enabled app, then blocks
+// handling signals. SIGINT/SIGTERM are trapped by a shutdown.Coordinator,
+// which cancels the context every app's DockerActionProvider and
+// DeploymentOrchestrator.runDeploymentLoop run under before c.shutdown waits
+// for any in-flight deployment to reach a safe checkpoint; a second
+// SIGINT/SIGTERM forces an immediate exit. SIGHUP re-runs LoadConfigs and
+// applies the diff against the running apps - added, removed, and changed -
+// without dropping traffic; a config.Watcher does the same automatically
+// whenever a *.yaml/*.yml file under configDir changes, so editing a config
+// on disk reloads it without needing to send a signal at all. If --listen
+// is set, an internal/api HTTP server runs alongside the watch loop so the
+// same operations can be driven remotely instead of only from this CLI. An
+// internal/reconciler.Reconciler also runs alongside, periodically healing
+// drift between current_state and the containers actually running.
+func (c *CLI) handleServe(args []string) error {
+	if c.DB == nil {
+		return fmt.Errorf("DB not initialized")
+	}
+	if c.configDir == "" {
+		return fmt.Errorf("no config directory loaded; cannot watch for reloads")
+	}
+
+	dockerClient, err := c.runtimePool.Get(runtime.Docker, runtime.Endpoint{})
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+
+	dockerManager := docker.NewRuntimeManager(dockerClient)
+	if err := dockerManager.ValidateConnection(c.ctxOrBackground()); err != nil {
+		return fmt.Errorf("Docker not available: %w", err)
+	}
+
+	if err := c.wireEventSubscribers(); err != nil {
+		return err
+	}
+
+	coord := shutdown.New()
+
+	orchestrator := docker.NewDeploymentOrchestrator(dockerManager, c.proxyMgr, c.configs)
+	orchestrator.SetDB(c.DB)
+	orchestrator.SetLogger(c.logger)
+	orchestrator.SetEventBus(c.bus)
+	orchestrator.SetContext(coord.Context())
+	orchestrator.SetRuntimePool(c.runtimePool, c.tlsDirIfPresent())
+	for appName := range c.configs {
+		if err := c.initializeOrchestratorApp(orchestrator, appName); err != nil {
+			return fmt.Errorf("failed to initialize app %s: %w", appName, err)
+		}
+	}
+
+	c.startEventReconciliation(coord.Context(), dockerClient, dockerManager, orchestrator, c.configs)
+
+	watchCtx, stopWatch := context.WithCancel(coord.Context())
+	c.startWatcher(watchCtx, orchestrator, dockerManager)
+	c.startReconciler(watchCtx, dockerManager)
+
+	var apiServer *api.Server
+	if c.flags.Listen != "" {
+		apiServer = api.NewServer(c.flags.Listen, c.configs, orchestrator, c.DB, c.bus, c.ring, c.logger)
+		if err := apiServer.Start(); err != nil {
+			return fmt.Errorf("failed to start API server: %w", err)
+		}
+		c.logger.Info("serve: API listening on %s", c.flags.Listen)
+	}
+
+	done := make(chan struct{})
+	stopTrap := coord.Trap(func(sig os.Signal) {
+		c.logger.Info("serve: %s received, shutting down...", sig)
+		stopWatch()
+		if apiServer != nil {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := apiServer.Stop(shutdownCtx); err != nil {
+				c.logger.Error("serve: API server shutdown: %v", err)
+			}
+			cancel()
+		}
+		c.shutdown(orchestrator)
+		c.logger.Info("serve: shutdown complete")
+		close(done)
+	})
+	defer stopTrap()
+
+	sighupCh := make(chan os.Signal, 1)
+	signal.Notify(sighupCh, syscall.SIGHUP)
+	defer signal.Stop(sighupCh)
+
+	fsReloadCh := c.startConfigWatcher(coord.Context())
+
+	c.logger.Info("serve: running as a daemon (pid %d) for %d app(s); SIGHUP or editing a config under %s reloads it, SIGINT/SIGTERM shut down", os.Getpid(), len(c.configs), c.configDir)
+
+	for {
+		select {
+		case <-sighupCh:
+			c.logger.Info("serve: SIGHUP received, reloading configuration from %s", c.configDir)
+			stopWatch()
+			if err := c.reloadConfigs(orchestrator); err != nil {
+				c.logger.Error("serve: config reload failed: %v", err)
+			}
+			watchCtx, stopWatch = context.WithCancel(coord.Context())
+			c.startWatcher(watchCtx, orchestrator, dockerManager)
+
+		case reload, ok := <-fsReloadCh:
+			if !ok {
+				fsReloadCh = nil
+				continue
+			}
+			if reload.err != nil {
+				c.logger.Error("serve: config watcher reload failed: %v", reload.err)
+				continue
+			}
+			c.logger.Info("serve: config change detected under %s, reloading", c.configDir)
+			stopWatch()
+			c.applyConfigReload(orchestrator, reload.configs)
+			watchCtx, stopWatch = context.WithCancel(coord.Context())
+			c.startWatcher(watchCtx, orchestrator, dockerManager)
+
+		case <-done:
+			stopWatch()
+			return nil
+		}
+	}
+}
+
+// startWatcher spawns a Watcher over the currently watch.enabled apps in a
+// goroutine bound to ctx, the same way handleWatch does for the one-shot
+// `watch` command. It's a no-op if no app has watch.enabled, and is called
+// again after every config reload since the set of watch.enabled apps may
+// have changed.
+func (c *CLI) startWatcher(ctx context.Context, orchestrator *docker.DeploymentOrchestrator, dockerManager *docker.RuntimeManager) {
+	configs := make(map[string]*config.AppConfig)
+	for appName, appConfig := range c.configs {
+		if appConfig.Watch.Enabled {
+			configs[appName] = appConfig
+		}
+	}
+	if len(configs) == 0 {
+		return
+	}
+
+	store := watcher.NewDBStateStore(c.DB)
+	w := watcher.New(configs, orchestrator, dockerManager, store, 10*time.Second, c.logger)
+	go w.Run(ctx)
+}
+
+// reconcileInterval is how often startReconciler compares current_state
+// against actual runtime state for every app, looking for and repairing
+// drift (a stopped managed container, one disconnected from its network).
+const reconcileInterval = 30 * time.Second
+
+// startReconciler runs an internal/reconciler.Reconciler in the background
+// for the life of ctx, alongside startWatcher's registry-polling loop.
+func (c *CLI) startReconciler(ctx context.Context, dockerManager *docker.RuntimeManager) {
+	rec := reconciler.New(c.DB, dockerManager, c.configs, reconcileInterval, c.logger)
+	go rec.Run(ctx)
+}
+
+// configReload is one config.Watcher reload attempt, carried over
+// fsReloadCh so it's applied on handleServe's own select loop rather than
+// racing SIGHUP-triggered reloads from the watcher's own goroutine.
+type configReload struct {
+	configs map[string]*config.AppConfig
+	err     error
+}
+
+// startConfigWatcher builds the ConfigSource c.configDir resolves to and, if
+// it supports fsnotify-style change events (FileConfigSource always does),
+// starts a config.Watcher over it in the background. Every debounced reload
+// it produces is sent on the returned channel for handleServe to apply;
+// the channel is closed (and nil'd out by the caller) once ctx is done.
+func (c *CLI) startConfigWatcher(ctx context.Context) <-chan configReload {
+	dsn := c.configDir
+	if !strings.Contains(dsn, "://") {
+		dsn = "file://" + c.configDir + "/apps"
+	}
+
+	source, err := config.NewConfigSource(dsn)
+	if err != nil {
+		c.logger.Error("serve: config watcher disabled, failed to construct config source %s: %v", dsn, err)
+		ch := make(chan configReload)
+		close(ch)
+		return ch
+	}
+
+	ch := make(chan configReload)
+	w := config.NewWatcher(source)
+	go func() {
+		defer close(ch)
+		if err := w.Run(ctx, func(configs map[string]*config.AppConfig, err error) {
+			select {
+			case ch <- configReload{configs: configs, err: err}:
+			case <-ctx.Done():
+			}
+		}); err != nil {
+			c.logger.Error("serve: config watcher stopped: %v", err)
+		}
+	}()
+	return ch
+}
+
+// reloadConfigs re-runs LoadConfigs's source-loading step against
+// c.configDir and applies the result against the currently running
+// c.configs in place (so the orchestrator, which shares that same map,
+// sees the update too): removed apps are dropped, added apps are
+// initialized, and changed apps get their config re-applied unless a
+// deployment is already under way for them, in which case the new config
+// takes effect the next time that app deploys rather than yanking state
+// out from under an in-flight rollout.
+func (c *CLI) reloadConfigs(orchestrator *docker.DeploymentOrchestrator) error {
+	dsn := c.configDir
+	if !strings.Contains(dsn, "://") {
+		dsn = "file://" + c.configDir + "/apps"
+	}
+
+	source, err := config.NewConfigSource(dsn)
+	if err != nil {
+		return fmt.Errorf("failed to construct config source %s: %w", dsn, err)
+	}
+
+	newConfigs, err := config.LoadAllConfigsFromSource(c.ctxOrBackground(), source)
+	if err != nil {
+		return fmt.Errorf("failed to load app configs: %w", err)
+	}
+
+	c.applyConfigReload(orchestrator, newConfigs)
+	return nil
+}
+
+// applyConfigReload diffs newConfigs against the currently running
+// c.configs, applies the result via c.ReplaceConfigs (added, removed, and
+// changed), and records each changed app's new YAML in app_configs via
+// state.InsertAppConfig so `dockswap config history/diff` sees it - the
+// same content-addressed dedup InsertAppConfig already gives deploys. A
+// deployment already under way for a changed app is left alone; its new
+// config takes effect the next time that app deploys rather than yanking
+// state out from under an in-flight rollout. Shared by reloadConfigs
+// (SIGHUP) and the fsnotify-driven config.Watcher started in handleServe.
+func (c *CLI) applyConfigReload(orchestrator *docker.DeploymentOrchestrator, newConfigs map[string]*config.AppConfig) {
+	var added, removed, changed []string
+	for appName := range c.configs {
+		if _, ok := newConfigs[appName]; !ok {
+			removed = append(removed, appName)
+		}
+	}
+	for appName, newCfg := range newConfigs {
+		oldCfg, ok := c.configs[appName]
+		if !ok {
+			added = append(added, appName)
+		} else if !reflect.DeepEqual(oldCfg, newCfg) {
+			changed = append(changed, appName)
+		}
+	}
+
+	c.ReplaceConfigs(newConfigs)
+
+	for _, appName := range removed {
+		c.logger.Info("config reload: %s removed", appName)
+	}
+	for _, appName := range added {
+		c.recordConfigVersion(appName, newConfigs[appName])
+		if err := c.initializeOrchestratorApp(orchestrator, appName); err != nil {
+			c.logger.Error("config reload: failed to initialize new app %s: %v", appName, err)
+			continue
+		}
+		c.logger.Info("config reload: %s added", appName)
+	}
+	for _, appName := range changed {
+		c.recordConfigVersion(appName, newConfigs[appName])
+		if orchestrator.InProgress(appName) {
+			c.logger.Info("config reload: %s changed, deferring until its in-flight deployment settles", appName)
+			continue
+		}
+		if err := c.initializeOrchestratorApp(orchestrator, appName); err != nil {
+			c.logger.Error("config reload: failed to re-initialize changed app %s: %v", appName, err)
+			continue
+		}
+		c.logger.Info("config reload: %s changed", appName)
+	}
+
+	if len(added)+len(removed)+len(changed) == 0 {
+		c.logger.Info("config reload: no changes")
+		return
+	}
+
+	if c.caddyMgr != nil {
+		if err := c.generateCaddyConfig(); err != nil {
+			c.logger.Error("config reload: failed to regenerate caddy config: %v", err)
+		} else if err := c.caddyMgr.ReloadCaddy(); err != nil {
+			c.logger.Error("config reload: failed to reload caddy: %v", err)
+		}
+	}
+}
+
+// recordConfigVersion re-marshals appConfig and inserts it into app_configs,
+// content-addressed on its sha256 the same way InsertAppConfig already
+// dedupes deploy-time config writes. A failure here doesn't block the
+// reload - the in-memory config driving the orchestrator already applied -
+// it just means this version is missing from `config history`.
+func (c *CLI) recordConfigVersion(appName string, appConfig *config.AppConfig) {
+	if c.DB == nil {
+		return
+	}
+
+	yamlBytes, err := yaml.Marshal(appConfig)
+	if err != nil {
+		c.logger.Error("config reload: failed to marshal %s for history: %v", appName, err)
+		return
+	}
+
+	sum := sha256.Sum256(yamlBytes)
+	sha := hex.EncodeToString(sum[:])
+	if _, err := state.InsertAppConfig(c.DB, appName, string(yamlBytes), sha); err != nil {
+		c.logger.Error("config reload: failed to record config version for %s: %v", appName, err)
+	}
+}
+
+// shutdown performs serve's ordered exit: give any app with a deployment in
+// progress up to its configured DrainTimeout+StopTimeout to reach a settled
+// state before returning control to main, which closes the DB via its own
+// deferred db.Close(). There's no local buffering to flush - every
+// state.* write already happens synchronously inside the deployment state
+// machine - and Caddy itself is an externally-run process dockswap only
+// talks to over its admin API, so there's nothing to stop there either.
+func (c *CLI) shutdown(orchestrator *docker.DeploymentOrchestrator) {
+	for appName, appConfig := range c.configs {
+		if !orchestrator.InProgress(appName) {
+			continue
+		}
+
+		timeout := appConfig.Deployment.DrainTimeout + appConfig.Deployment.StopTimeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+
+		c.logger.Info("serve: waiting up to %s for %s's in-flight deployment to settle...", timeout, appName)
+		settled := shutdown.WaitSafe(func() bool { return !orchestrator.InProgress(appName) }, timeout)
+		if !settled {
+			c.logger.Error("serve: %s still mid-deployment after %s, leaving it for manual recovery on next start", appName, timeout)
+		}
+	}
+}
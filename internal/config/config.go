@@ -1,48 +1,410 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type AppConfig struct {
-	Name        string      `yaml:"name"`
-	Description string      `yaml:"description"`
-	Docker      Docker      `yaml:"docker"`
-	Ports       Ports       `yaml:"ports"`
-	HealthCheck HealthCheck `yaml:"health_check"`
-	Deployment  Deployment  `yaml:"deployment"`
-	Proxy       Proxy       `yaml:"proxy"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Docker      Docker `yaml:"docker"`
+	Ports       Ports  `yaml:"ports"`
+
+	// Slots generalizes Ports to an arbitrary number of named deployment
+	// slots, each with its own port and traffic weight, e.g. a "canary" slot
+	// alongside "blue"/"green" for weighted canary rollouts. When absent,
+	// LoadAppConfig migrates Ports.Blue/Green into Slots so every config
+	// behaves as a two-slot, blue/green-weighted deployment; see
+	// migrateLegacySlots.
+	Slots map[string]SlotConfig `yaml:"slots"`
+
+	HealthCheck HealthCheck     `yaml:"health_check"`
+	Deployment  Deployment      `yaml:"deployment"`
+	Proxy       Proxy           `yaml:"proxy"`
+	Services    []ServiceConfig `yaml:"services"`
+
+	// AdditionalChecks lets HealthCheck be chained with further probes of
+	// different types, e.g. a TCP-readiness check plus a gRPC-serving check
+	// on top of the primary HTTP HealthCheck, all gating the same cutover.
+	AdditionalChecks []HealthCheck `yaml:"additional_checks"`
+
+	// Watch configures Watchtower-style registry polling: when enabled, the
+	// `dockswap watch` subcommand periodically checks whether the currently
+	// deployed tag's digest has changed and, if so, triggers an automatic
+	// blue/green rollout of the new image.
+	Watch Watch `yaml:"watch"`
+
+	// Hooks declares the lifecycle commands deployment.DeploymentStateMachine
+	// runs around a rollout - DB migrations, cache warms, Slack
+	// notifications - without patching dockswap itself, OCI runtime hooks
+	// style.
+	Hooks HooksConfig `yaml:"hooks"`
+
+	// DependsOn names other apps (by AppConfig.Name) that must already be
+	// switched and healthy before `dockswap deploy-group` deploys this one -
+	// e.g. a "web" app depending on "api" so the backend is up first. Only
+	// read by deploy-group; the single-app handleDeploy/handleSwitch ignore
+	// it. See internal/depgraph.
+	DependsOn []string `yaml:"depends_on"`
+}
+
+// HookMode selects whether a HookCommand blocks the transition it's
+// attached to until it finishes ("blocking", the default, and the only mode
+// that can veto the transition on a non-zero exit) or fires and is left to
+// run on its own ("background").
+type HookMode string
+
+const (
+	HookModeBlocking   HookMode = "blocking"
+	HookModeBackground HookMode = "background"
+)
+
+// HookCommand is one lifecycle hook: either a host command or a webhook URL
+// (exactly one of Command or URL must be set), its arguments, an optional
+// timeout and extra environment, and the mode controlling whether it can
+// veto the transition it's attached to.
+type HookCommand struct {
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args"`
+	URL     string            `yaml:"url"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Env     map[string]string `yaml:"env"`
+	Mode    HookMode          `yaml:"mode"`
+}
+
+// HooksConfig groups the lifecycle phases a deployment can hook into:
+// PreDeploy runs before the new color's container starts, PostHealth after
+// its health check passes, PreSwitch before traffic is cut over, PostSwitch
+// after, PreDrain right before the outgoing color's connections are drained,
+// OnFailure once a deployment has settled into its failed state, and
+// OnRollback right before a rollback is attempted. Each phase's hooks run in
+// the order declared.
+type HooksConfig struct {
+	PreDeploy  []HookCommand `yaml:"pre_deploy"`
+	PostHealth []HookCommand `yaml:"post_health"`
+	PreSwitch  []HookCommand `yaml:"pre_switch"`
+	PostSwitch []HookCommand `yaml:"post_switch"`
+	PreDrain   []HookCommand `yaml:"pre_drain"`
+	OnFailure  []HookCommand `yaml:"on_failure"`
+	OnRollback []HookCommand `yaml:"on_rollback"`
+
+	// PreStart/PostStart, PreStop/PostStop, and PreSwap/PostSwap hook
+	// directly into DockerActionProvider.StartContainer, StopContainer, and
+	// UpdateCaddy respectively - one level below PreDeploy/PreSwitch/etc,
+	// which hook into DeploymentStateMachine's transitions instead. Unlike
+	// HookCommand, each HookStep can also run inside the container itself
+	// (ContainerExec) rather than only on the host.
+	PreStart  []HookStep `yaml:"pre_start"`
+	PostStart []HookStep `yaml:"post_start"`
+	PreStop   []HookStep `yaml:"pre_stop"`
+	PostStop  []HookStep `yaml:"post_stop"`
+	PreSwap   []HookStep `yaml:"pre_swap"`
+	PostSwap  []HookStep `yaml:"post_swap"`
+}
+
+// HookStep is one step of a PreStart/PostStart/PreStop/PostStop/PreSwap/
+// PostSwap hook. Exactly one of Exec or ContainerExec must be set: Exec runs
+// on the host (like HookCommand); ContainerExec runs inside the color's
+// container via Docker's exec API, for steps that need to reach something
+// only visible from in there (e.g. an in-container migration binary).
+// ContinueOnError downgrades a non-zero exit from fatal (the default - it
+// fails the action, which DeploymentStateMachine treats as a failed
+// transition and rolls back) to merely logged.
+type HookStep struct {
+	Exec            *ExecStep          `yaml:"exec,omitempty"`
+	ContainerExec   *ContainerExecStep `yaml:"container_exec,omitempty"`
+	Timeout         time.Duration      `yaml:"timeout"`
+	ContinueOnError bool               `yaml:"continue_on_error"`
+}
+
+// ExecStep is a HookStep that runs command/args as a host process.
+type ExecStep struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+}
+
+// ContainerExecStep is a HookStep that runs command inside the container via
+// ContainerExecCreate/ContainerExecStart.
+type ContainerExecStep struct {
+	Command []string `yaml:"command"`
+}
+
+// Watch mirrors the handful of Watchtower settings relevant to dockswap:
+// whether to poll at all, how often, optional registry credentials for
+// private images, and whether a failed manifest HEAD request is fatal or
+// just a warning.
+type Watch struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+
+	// PollRegistryAuth is a base64-encoded "user:password" pair sent as an
+	// HTTP Basic Authorization header when polling a private registry,
+	// analogous to Watchtower's REPO_USER/REPO_PASS.
+	PollRegistryAuth string `yaml:"poll_registry_auth"`
+
+	// WarnOnHeadFailure logs and skips this poll on a failed manifest HEAD
+	// request instead of treating it as an error; useful for registries
+	// with flaky uptime.
+	WarnOnHeadFailure bool `yaml:"warn_on_head_failure"`
+}
+
+// ServiceConfig describes a sidecar container (db proxy, log shipper, cache,
+// ...) deployed alongside the app's main container, compose-service style.
+// Each service gets its own container per color, named
+// "<app>-<color>-<service>", and joins the same dockswap-managed network as
+// the main container.
+type ServiceConfig struct {
+	Name        string            `yaml:"name"`
+	Image       string            `yaml:"image"`
+	Environment map[string]string `yaml:"environment"`
+	Volumes     []string          `yaml:"volumes"`
+	// DependsOn lists sidecar names (by ServiceConfig.Name) that must be
+	// started, in order, before this one. The main container always starts
+	// last and stops first, regardless of DependsOn.
+	DependsOn []string `yaml:"depends_on"`
+}
+
+// Ulimit sets one resource limit ("nofile", "nproc", ...) inside the
+// container, mirroring `docker run --ulimit NAME=SOFT:HARD`.
+type Ulimit struct {
+	Name string `yaml:"name"`
+	Soft int64  `yaml:"soft"`
+	Hard int64  `yaml:"hard"`
 }
 
 type Docker struct {
-	RestartPolicy string            `yaml:"restart_policy"`
-	PullPolicy    string            `yaml:"pull_policy"`
-	MemoryLimit   string            `yaml:"memory_limit"`
-	CPULimit      string            `yaml:"cpu_limit"`
-	Environment   map[string]string `yaml:"environment"`
-	Volumes       []string          `yaml:"volumes"`
-	ExposePort    int               `yaml:"expose_port"`
-	Network       string            `yaml:"network"`
+	// Runtime selects the container engine RuntimeManager talks to:
+	// "docker" (the default), "podman", or "containerd". See
+	// runtime.NewClient for what each one requires to be reachable.
+	Runtime string `yaml:"runtime"`
+
+	// Host, if set, is the endpoint RuntimeManager dials for this app instead
+	// of the local default socket: "unix:///var/run/docker.sock",
+	// "tcp://host:2376", or "ssh://user@host". A "tcp://" host is only
+	// TLS-verified if cert/key material is present in the config dir's
+	// tls/ subdirectory (see config.TLSDir) - it's otherwise dialed
+	// unencrypted, the same as the Docker CLI's own DOCKER_HOST behavior.
+	Host              string            `yaml:"docker_host"`
+	RestartPolicy     string            `yaml:"restart_policy"`
+	PullPolicy        string            `yaml:"pull_policy"`
+	MemoryLimit       string            `yaml:"memory_limit"`
+	MemorySwap        string            `yaml:"memory_swap"`
+	MemoryReservation string            `yaml:"memory_reservation"`
+	CPULimit          string            `yaml:"cpu_limit"`
+	CPUPeriod         int64             `yaml:"cpu_period"`
+	CPUShares         int64             `yaml:"cpu_shares"`
+	CPUSetCPUs        string            `yaml:"cpuset_cpus"`
+	PidsLimit         int64             `yaml:"pids_limit"`
+	BlkioWeight       uint16            `yaml:"blkio_weight"`
+	Ulimits           []Ulimit          `yaml:"ulimits"`
+	Environment       map[string]string `yaml:"environment"`
+	Volumes           []string          `yaml:"volumes"`
+	// ExposePort may be left zero/absent in the app YAML; see Ports.
+	ExposePort int    `yaml:"expose_port"`
+	Network    string `yaml:"network"`
+
+	// PortMappings lists port specs in Docker's native -p syntax
+	// ("8080:80/tcp", "53:53/udp", "127.0.0.1:9000:9000", "3000-3005:3000-3005"),
+	// parsed with nat.ParsePortSpec. When set, it supersedes ExposePort/Ports
+	// for this app - each spec's host-side port(s) are shifted by
+	// ColorPortOffset (or this color's entry in ColorPortOffsets, if present)
+	// so blue and green can publish all the same container ports at once
+	// without colliding. ExposePort/Ports remain the single-port shorthand
+	// and are still honored when PortMappings is empty.
+	PortMappings []string `yaml:"port_mappings"`
+
+	// ColorPortOffset is added to every PortMappings host port for any color
+	// other than "blue", which is always the zero-offset anchor. Ignored for
+	// a color with an entry in ColorPortOffsets.
+	ColorPortOffset int `yaml:"color_port_offset"`
+
+	// ColorPortOffsets overrides ColorPortOffset for specific colors, e.g.
+	// {"green": 1000, "canary": 2000}.
+	ColorPortOffsets map[string]int `yaml:"color_port_offsets"`
+
+	// MemoryBytes, MemorySwapBytes, MemoryReservationBytes, and CPUs are
+	// MemoryLimit/MemorySwap/MemoryReservation/CPULimit parsed into
+	// machine-usable units (see ParseMemoryLimit, ParseMemorySwapLimit,
+	// ParseCPULimit) and range-checked by validateConfig, so callers hand
+	// Docker real numbers instead of re-parsing (and potentially
+	// mis-parsing) the free-form strings themselves. CPUPeriod, CPUShares,
+	// CPUSetCPUs, PidsLimit, BlkioWeight, and Ulimits need no such parsing
+	// and are applied to the container as configured.
+	MemoryBytes            int64   `yaml:"-"`
+	MemorySwapBytes        int64   `yaml:"-"`
+	MemoryReservationBytes int64   `yaml:"-"`
+	CPUs                   float64 `yaml:"-"`
+
+	// EnvironmentOverrides layers extra or replacement environment variables
+	// onto Environment for one specific color, keyed "blue"/"green"; see
+	// GetEnvironmentForColor. Useful for a color-pinned PORT during a
+	// migration, or anything else that must differ across the cutover.
+	EnvironmentOverrides map[string]map[string]string `yaml:"environment_overrides"`
+
+	// secretKeys records which resolved Environment/EnvironmentOverrides
+	// entries came from a secret:// URI, keyed the same way
+	// resolveEnvironment scopes them (e.g. "environment.DB_PASSWORD",
+	// "environment_overrides.blue.API_KEY"), so RedactedEnvironment can mask
+	// them in logs and diagnostics instead of printing the resolved value.
+	secretKeys map[string]bool `yaml:"-"`
+
+	// Networks attaches the container to more than one network with
+	// per-network aliases and an optional pinned address, superseding
+	// Network for apps that need it; Network remains the single-network
+	// shorthand and is still honored when Networks is empty.
+	Networks []NetworkAttachment `yaml:"networks"`
+
+	// Registry overrides registry credential resolution for this app's
+	// image pulls instead of relying on ~/.docker/config.json; see
+	// docker.DockerConfigAuthResolver for how the two are combined.
+	Registry RegistryAuth `yaml:"registry"`
+}
+
+// GetEnvironmentForColor merges Environment with EnvironmentOverrides[color]
+// layered on top (override keys win), returning the full environment for a
+// blue or green container. An unrecognized or empty color returns a copy of
+// the base Environment unchanged.
+func (d Docker) GetEnvironmentForColor(color string) map[string]string {
+	merged := make(map[string]string, len(d.Environment))
+	for k, v := range d.Environment {
+		merged[k] = v
+	}
+	for k, v := range d.EnvironmentOverrides[color] {
+		merged[k] = v
+	}
+	return merged
+}
+
+// RedactedEnvironment returns env (the result of GetEnvironmentForColor, or
+// d.Environment itself) with every value that was resolved from a secret://
+// URI replaced by "[REDACTED]", for logging and diagnostics that must not
+// leak secret values.
+func (d Docker) RedactedEnvironment(color string, env map[string]string) map[string]string {
+	scope := "environment"
+	if color != "" {
+		scope = fmt.Sprintf("environment_overrides.%s", color)
+	}
+
+	redacted := make(map[string]string, len(env))
+	for k, v := range env {
+		if d.secretKeys[scope+"."+k] {
+			redacted[k] = "[REDACTED]"
+		} else {
+			redacted[k] = v
+		}
+	}
+	return redacted
+}
+
+// NetworkAttachment describes one Docker network a container joins: the
+// network name, the DNS aliases it should answer to on that network (in
+// addition to the stable per-app alias docker.RuntimeManager.CreateContainer
+// always attaches), and an optional pinned IPv4 address.
+type NetworkAttachment struct {
+	Name        string   `yaml:"name"`
+	Aliases     []string `yaml:"aliases"`
+	IPv4Address string   `yaml:"ipv4_address"`
+}
+
+// RegistryAuth lets an app pin its own registry credentials, e.g. for a
+// private Harbor instance or an ECR token refreshed out-of-band into
+// IdentityToken, rather than relying on the host's ~/.docker/config.json.
+type RegistryAuth struct {
+	Username         string `yaml:"username"`
+	Password         string `yaml:"password"`
+	CredentialHelper string `yaml:"credential_helper"`
+	IdentityToken    string `yaml:"identity_token"`
 }
 
+// Ports may be left zero/absent in the app YAML: workspace.Workspace.AllocatePorts
+// fills in both colors (and Docker.ExposePort, Proxy.ListenPort) from its
+// reserved ranges and persists the result back to the file before
+// validateConfig ever sees it, so Blue/Green always arrive here positive.
 type Ports struct {
 	Blue  int `yaml:"blue"`
 	Green int `yaml:"green"`
 }
 
+// SlotConfig is one named deployment slot: the host port its container is
+// published on, and the traffic weight a proxy (see caddy.CaddyManager)
+// should give it relative to the app's other slots. A slot with Weight 0 is
+// valid (a standby or not-yet-promoted canary) as long as its Port is set.
+type SlotConfig struct {
+	Port   int `yaml:"port"`
+	Weight int `yaml:"weight"`
+}
+
+// migrateLegacySlots populates Slots from the legacy Ports.Blue/Green
+// fields when a config declares no slots of its own, so existing two-color
+// configs keep behaving exactly as before under the generalized schema:
+// blue carries full weight, green is the standby slot.
+func migrateLegacySlots(config *AppConfig) {
+	if len(config.Slots) > 0 {
+		return
+	}
+	if config.Ports.Blue <= 0 && config.Ports.Green <= 0 {
+		return
+	}
+
+	config.Slots = map[string]SlotConfig{
+		"blue":  {Port: config.Ports.Blue, Weight: 100},
+		"green": {Port: config.Ports.Green, Weight: 0},
+	}
+}
+
+// HealthCheckType selects which protocol-specific docker.HealthProbe backs
+// an app's health check, alongside the container's own Docker-native
+// HEALTHCHECK. An empty Type means HealthCheckTypeHTTP, preserving existing
+// configs that only ever set Endpoint/Method/ExpectedStatus.
+type HealthCheckType string
+
+const (
+	HealthCheckTypeHTTP HealthCheckType = "http"
+	HealthCheckTypeTCP  HealthCheckType = "tcp"
+	HealthCheckTypeGRPC HealthCheckType = "grpc"
+	HealthCheckTypeExec HealthCheckType = "exec"
+)
+
 type HealthCheck struct {
-	Endpoint         string        `yaml:"endpoint"`
-	Method           string        `yaml:"method"`
-	Timeout          time.Duration `yaml:"timeout"`
-	Interval         time.Duration `yaml:"interval"`
-	Retries          int           `yaml:"retries"`
-	SuccessThreshold int           `yaml:"success_threshold"`
-	ExpectedStatus   int           `yaml:"expected_status"`
+	Type             HealthCheckType `yaml:"type"`
+	Endpoint         string          `yaml:"endpoint"`
+	Method           string          `yaml:"method"`
+	Timeout          time.Duration   `yaml:"timeout"`
+	Interval         time.Duration   `yaml:"interval"`
+	Retries          int             `yaml:"retries"`
+	SuccessThreshold int             `yaml:"success_threshold"`
+	ExpectedStatus   int             `yaml:"expected_status"`
+
+	// GRPCService is passed as the Service field of a grpc.health.v1.Health/Check
+	// request when Type is "grpc"; the empty string checks overall server health.
+	GRPCService string `yaml:"grpc_service"`
+
+	// ExecCommand is run inside the target container when Type is "exec",
+	// mirroring Docker's own HEALTHCHECK CMD; a non-zero exit code is unhealthy.
+	ExecCommand []string `yaml:"exec_command"`
+
+	// FailureThreshold is how many consecutive failing checks
+	// docker.WaitForHealthy tolerates before giving up early instead of
+	// grinding out the rest of its timeout. Zero (the default) preserves the
+	// original behavior of waiting out the full timeout regardless of how
+	// many checks fail along the way.
+	FailureThreshold int `yaml:"failure_threshold"`
+
+	// InitialDelay is how long docker.WaitForHealthy waits before its first
+	// check, giving a slow-starting process a head start instead of
+	// immediately counting a cold-start failure against FailureThreshold.
+	InitialDelay time.Duration `yaml:"initial_delay"`
+
+	// StartPeriod is passed through to the container's own Docker-native
+	// HEALTHCHECK (container.HealthConfig.StartPeriod) when Type is "exec";
+	// failures during this window don't count toward Retries there.
+	StartPeriod time.Duration `yaml:"start_period"`
 }
 
 type Deployment struct {
@@ -50,8 +412,91 @@ type Deployment struct {
 	DrainTimeout time.Duration `yaml:"drain_timeout"`
 	StopTimeout  time.Duration `yaml:"stop_timeout"`
 	AutoRollback bool          `yaml:"auto_rollback"`
+
+	// Strategy selects the state graph deployment.DeploymentStateMachine
+	// drives a rollout through: DeploymentStrategyBlueGreen (the default,
+	// for empty) cuts straight over once HealthCheck passes,
+	// DeploymentStrategyCanary steps through Canary.Steps first, and
+	// DeploymentStrategyRolling is reserved for a future per-replica
+	// rolling update.
+	Strategy DeploymentStrategy `yaml:"strategy"`
+
+	// Canary configures the weight stages a canary rollout steps through;
+	// only read when Strategy is DeploymentStrategyCanary.
+	Canary CanaryPlan `yaml:"canary"`
+
+	// PinnedDigest, if set, is the sha256 digest (e.g.
+	// "sha256:abc123...") handleDeploy must resolve the newly pulled image
+	// to before creating a container - a mismatch aborts the deploy rather
+	// than running whatever the mutable tag happened to resolve to at pull
+	// time. Leave empty to trust the tag as-is.
+	PinnedDigest string `yaml:"pinned_digest"`
+
+	// RestartPolicy configures whether and how many times
+	// deployment.DeploymentStateMachine retries a failed container start,
+	// health check, or Caddy update before giving up and landing in
+	// StateFailed. The zero value (RestartPolicyKind "") behaves like
+	// RestartPolicyNever: the original fail-straight-to-StateFailed
+	// behavior, unchanged for any app that doesn't configure this.
+	RestartPolicy RestartPolicy `yaml:"restart_policy"`
+}
+
+// RestartPolicyKind mirrors deployment.RestartPolicyKind's values so app
+// YAML can select a policy without this package importing deployment - the
+// same parallel-enum pattern DeploymentStrategy already uses for
+// deployment.DeploymentStateMachine's state graph.
+type RestartPolicyKind string
+
+const (
+	RestartPolicyAlways    RestartPolicyKind = "always"
+	RestartPolicyOnFailure RestartPolicyKind = "on_failure"
+	RestartPolicyNever     RestartPolicyKind = "never"
+)
+
+// RestartPolicy configures retries of a failed container start, health
+// check, or Caddy update, borrowing its shape from Kubernetes' pod restart
+// policies (Always/OnFailure/Never) and applying it to a deployment attempt
+// instead of a running container. Each retry waits BackoffInitial *
+// BackoffMultiplier^(attempt-1), capped at BackoffMax, before the failed
+// step is re-issued; MaxRetries bounds how many times that happens before
+// the deployment gives up and lands in StateFailed.
+type RestartPolicy struct {
+	Policy            RestartPolicyKind `yaml:"policy"`
+	MaxRetries        int               `yaml:"max_retries"`
+	BackoffInitial    time.Duration     `yaml:"backoff_initial"`
+	BackoffMax        time.Duration     `yaml:"backoff_max"`
+	BackoffMultiplier float64           `yaml:"backoff_multiplier"`
+}
+
+// DeploymentStrategy selects how a deployment rolls traffic over to the new
+// color: all at once (DeploymentStrategyBlueGreen) or in weighted stages
+// (DeploymentStrategyCanary). DeploymentStrategyRolling passes validation
+// but deploys the same way as DeploymentStrategyBlueGreen; no per-replica
+// rolling update is performed.
+type DeploymentStrategy string
+
+const (
+	DeploymentStrategyBlueGreen DeploymentStrategy = "blue_green"
+	DeploymentStrategyCanary    DeploymentStrategy = "canary"
+	DeploymentStrategyRolling   DeploymentStrategy = "rolling"
+)
+
+// CanaryPlan is the sequence of traffic-weight stages a canary rollout steps
+// through, e.g. Steps: []int{5, 25, 50, 100}. Each step shifts the target
+// color's weight to that percentage and waits out StepDuration (a soak
+// window for real traffic to exercise it) before
+// deployment.DeploymentStateMachine checks health again and either advances
+// to the next step or, on a failed check, rolls back.
+type CanaryPlan struct {
+	Steps        []int         `yaml:"steps"`
+	StepDuration time.Duration `yaml:"step_duration"`
 }
 
+// ListenPort may be omitted from the app YAML if a proxy section is
+// otherwise present: workspace.Workspace.AllocatePorts assigns one from its
+// proxy port range. Unlike Ports.Blue/Green, an explicit 0 here is left
+// alone - it means the in-process proxy is deliberately disabled for this
+// app (see proxy.New) rather than unset.
 type Proxy struct {
 	ListenPort int    `yaml:"listen_port"`
 	Host       string `yaml:"host"`
@@ -64,39 +509,76 @@ func LoadAppConfig(configPath string) (*AppConfig, error) {
 		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
 	}
 
+	config, err := parseAppConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s", err, configPath)
+	}
+
+	return config, nil
+}
+
+// parseAppConfig unmarshals, resolves, migrates, and validates one config
+// document's bytes, independent of where they came from (a file, a
+// ConfigSource, ...). The returned error is unqualified by a path/name;
+// callers wrap it with whatever identifies the document in their context.
+func parseAppConfig(data []byte) (*AppConfig, error) {
 	var config AppConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML config %s: %w", configPath, err)
+		return nil, fmt.Errorf("failed to parse YAML config: %w", err)
+	}
+
+	if err := resolveEnvironment(&config, defaultSecretProviders()); err != nil {
+		return nil, fmt.Errorf("failed to resolve environment: %w", err)
 	}
 
+	migrateLegacySlots(&config)
+
 	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("config validation failed for %s: %w", configPath, err)
+		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
 	return &config, nil
 }
 
+// LoadAllConfigs loads every *.yaml/*.yml file under configDir, collecting
+// per-file failures into a ValidationErrors instead of aborting on the
+// first bad file, so a caller sees every broken config in one pass. It is a
+// thin wrapper over LoadAllConfigsFromSource for the common filesystem case;
+// see ConfigSource for pluggable backends (KV stores, HTTP, ...).
 func LoadAllConfigs(configDir string) (map[string]*AppConfig, error) {
+	return LoadAllConfigsFromSource(context.Background(), &FileConfigSource{Dir: configDir})
+}
+
+// LoadAllConfigsFromSource loads every config source.List returns,
+// collecting per-document failures into a ValidationErrors instead of
+// aborting on the first bad one, so a caller sees every broken config in
+// one pass.
+func LoadAllConfigsFromSource(ctx context.Context, source ConfigSource) (map[string]*AppConfig, error) {
 	configs := make(map[string]*AppConfig)
+	var failures ValidationErrors
+
+	names, err := source.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs: %w", err)
+	}
 
-	err := filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+	for _, name := range names {
+		data, err := source.Read(ctx, name)
 		if err != nil {
-			return err
+			failures = append(failures, fmt.Errorf("failed to load config %s: %w", name, err))
+			continue
 		}
 
-		if !info.IsDir() && (filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml") {
-			config, err := LoadAppConfig(path)
-			if err != nil {
-				return fmt.Errorf("failed to load config %s: %w", path, err)
-			}
-			configs[config.Name] = config
+		config, err := parseAppConfig(data)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to load config %s: %w", name, err))
+			continue
 		}
+		configs[config.Name] = config
+	}
 
-		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to load configs from %s: %w", configDir, err)
+	if len(failures) > 0 {
+		return configs, failures
 	}
 
 	return configs, nil
@@ -104,31 +586,439 @@ func LoadAllConfigs(configDir string) (map[string]*AppConfig, error) {
 
 func validateConfig(config *AppConfig) error {
 	if config.Name == "" {
-		return fmt.Errorf("app name is required")
+		return newValidationError("name", config.Name, "app name is required", ErrMissingName)
 	}
 
 	if config.Docker.ExposePort <= 0 {
-		return fmt.Errorf("docker.expose_port must be positive")
+		return newValidationError("docker.expose_port", config.Docker.ExposePort, "docker.expose_port must be positive", ErrInvalidPort)
 	}
 
 	if config.Ports.Blue <= 0 || config.Ports.Green <= 0 {
-		return fmt.Errorf("blue and green ports must be positive")
+		return newValidationError("ports", config.Ports, "blue and green ports must be positive", ErrInvalidPort)
 	}
 
 	if config.Ports.Blue == config.Ports.Green {
-		return fmt.Errorf("blue and green ports must be different")
+		return newValidationError("ports", config.Ports, "blue and green ports must be different", ErrDuplicateColorPort)
+	}
+
+	if err := validateDockerResources(&config.Docker); err != nil {
+		return err
+	}
+
+	if err := validateDockerHost(config.Docker.Host); err != nil {
+		return err
+	}
+
+	for color := range config.Docker.EnvironmentOverrides {
+		if !isDeclaredSlot(config, color) {
+			var reason string
+			if len(config.Slots) > 0 {
+				reason = fmt.Sprintf("environment_overrides: %q is not a declared slot", color)
+			} else {
+				reason = fmt.Sprintf("environment_overrides: only 'blue' and 'green' colors are supported, got '%s'", color)
+			}
+			return newValidationError("docker.environment_overrides", color, reason, ErrInvalidEnvironmentOverride)
+		}
+	}
+
+	if err := validateSlots(config.Slots); err != nil {
+		return err
 	}
 
 	if config.HealthCheck.Retries < 0 {
-		return fmt.Errorf("health_check.retries must be non-negative")
+		return newValidationError("health_check.retries", config.HealthCheck.Retries, "health_check.retries must be non-negative", ErrInvalidHealthCheck)
 	}
 
 	if config.HealthCheck.SuccessThreshold <= 0 {
-		return fmt.Errorf("health_check.success_threshold must be positive")
+		return newValidationError("health_check.success_threshold", config.HealthCheck.SuccessThreshold, "health_check.success_threshold must be positive", ErrInvalidHealthCheck)
 	}
 
 	if config.HealthCheck.ExpectedStatus < 100 || config.HealthCheck.ExpectedStatus >= 600 {
-		return fmt.Errorf("health_check.expected_status must be a valid HTTP status code")
+		return newValidationError("health_check.expected_status", config.HealthCheck.ExpectedStatus, "health_check.expected_status must be a valid HTTP status code", ErrInvalidHealthStatus)
+	}
+
+	if config.HealthCheck.FailureThreshold < 0 {
+		return newValidationError("health_check.failure_threshold", config.HealthCheck.FailureThreshold, "health_check.failure_threshold must be non-negative", ErrInvalidHealthCheck)
+	}
+
+	if config.HealthCheck.InitialDelay < 0 {
+		return newValidationError("health_check.initial_delay", config.HealthCheck.InitialDelay, "health_check.initial_delay must be non-negative", ErrInvalidHealthCheck)
+	}
+
+	if err := validateHealthCheckType(config.HealthCheck); err != nil {
+		return err
+	}
+
+	for i, hc := range config.AdditionalChecks {
+		if err := validateHealthCheckType(hc); err != nil {
+			return fmt.Errorf("additional_checks[%d]: %w", i, err)
+		}
+	}
+
+	if err := validateServices(config.Services); err != nil {
+		return err
+	}
+
+	if config.Watch.Enabled && config.Watch.Interval <= 0 {
+		return newValidationError("watch.interval", config.Watch.Interval, "watch.interval must be positive when watch.enabled is true", ErrInvalidWatchConfig)
+	}
+
+	if err := validatePinnedDigest(config.Deployment.PinnedDigest); err != nil {
+		return err
+	}
+
+	if err := validateDeploymentStrategy(config.Deployment); err != nil {
+		return err
+	}
+
+	if err := validateRestartPolicy(config.Deployment.RestartPolicy); err != nil {
+		return err
+	}
+
+	if err := validateHooks(config.Hooks); err != nil {
+		return err
+	}
+
+	if err := validateHookSteps(config.Hooks); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// hookPhases pairs each HooksConfig field with the dotted path validateHooks
+// reports errors under, so adding a new phase only means adding an entry
+// here.
+func hookPhases(hooks HooksConfig) map[string][]HookCommand {
+	return map[string][]HookCommand{
+		"pre_deploy":  hooks.PreDeploy,
+		"post_health": hooks.PostHealth,
+		"pre_switch":  hooks.PreSwitch,
+		"post_switch": hooks.PostSwitch,
+		"pre_drain":   hooks.PreDrain,
+		"on_failure":  hooks.OnFailure,
+		"on_rollback": hooks.OnRollback,
+	}
+}
+
+// validateHooks checks that every configured HookCommand sets exactly one of
+// command or url, and a recognized mode, across all of HooksConfig's phases.
+func validateHooks(hooks HooksConfig) error {
+	for phase, commands := range hookPhases(hooks) {
+		for i, cmd := range commands {
+			field := fmt.Sprintf("hooks.%s[%d]", phase, i)
+			if (cmd.Command == "") == (cmd.URL == "") {
+				reason := field + " must set exactly one of command or url"
+				return newValidationError(field, cmd, reason, ErrInvalidHook)
+			}
+			switch cmd.Mode {
+			case "", HookModeBlocking, HookModeBackground:
+			default:
+				reason := fmt.Sprintf("%s.mode %q is not one of blocking, background", field, cmd.Mode)
+				return newValidationError(field+".mode", cmd.Mode, reason, ErrInvalidHook)
+			}
+		}
+	}
+	return nil
+}
+
+// hookStepPhases pairs each HookStep-based HooksConfig field with the dotted
+// path validateHookSteps reports errors under, mirroring hookPhases.
+func hookStepPhases(hooks HooksConfig) map[string][]HookStep {
+	return map[string][]HookStep{
+		"pre_start":  hooks.PreStart,
+		"post_start": hooks.PostStart,
+		"pre_stop":   hooks.PreStop,
+		"post_stop":  hooks.PostStop,
+		"pre_swap":   hooks.PreSwap,
+		"post_swap":  hooks.PostSwap,
+	}
+}
+
+// validateHookSteps checks that every configured HookStep sets exactly one
+// of Exec/ContainerExec, and that whichever is set has a non-empty command.
+func validateHookSteps(hooks HooksConfig) error {
+	for phase, steps := range hookStepPhases(hooks) {
+		for i, step := range steps {
+			field := fmt.Sprintf("hooks.%s[%d]", phase, i)
+
+			if (step.Exec == nil) == (step.ContainerExec == nil) {
+				reason := field + " must set exactly one of exec or container_exec"
+				return newValidationError(field, step, reason, ErrInvalidHook)
+			}
+			if step.Exec != nil && step.Exec.Command == "" {
+				return newValidationError(field+".exec.command", step.Exec.Command, field+".exec.command is required", ErrInvalidHook)
+			}
+			if step.ContainerExec != nil && len(step.ContainerExec.Command) == 0 {
+				return newValidationError(field+".container_exec.command", step.ContainerExec.Command, field+".container_exec.command is required", ErrInvalidHook)
+			}
+		}
+	}
+	return nil
+}
+
+// validatePinnedDigest checks digest, if set, looks like "sha256:" followed
+// by 64 lowercase hex characters - the shape handleDeploy's ImageInspect
+// comparison expects, so a typo'd digest fails fast at config load rather
+// than as a mysterious deploy-time mismatch.
+func validatePinnedDigest(digest string) error {
+	if digest == "" {
+		return nil
+	}
+
+	const prefix = "sha256:"
+	hex := strings.TrimPrefix(digest, prefix)
+	if len(digest) == len(hex) || len(hex) != 64 {
+		reason := fmt.Sprintf("deployment.pinned_digest %q must be \"sha256:\" followed by 64 hex characters", digest)
+		return newValidationError("deployment.pinned_digest", digest, reason, ErrInvalidPinnedDigest)
+	}
+	for _, c := range hex {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			reason := fmt.Sprintf("deployment.pinned_digest %q must be \"sha256:\" followed by 64 hex characters", digest)
+			return newValidationError("deployment.pinned_digest", digest, reason, ErrInvalidPinnedDigest)
+		}
+	}
+
+	return nil
+}
+
+// validateDeploymentStrategy checks Deployment.Strategy is one of the known
+// strategies and, for DeploymentStrategyCanary, that Canary describes a
+// usable sequence of weight stages: each step between 1 and 100, strictly
+// increasing, ending at 100 so the rollout always finishes fully cut over.
+func validateDeploymentStrategy(d Deployment) error {
+	switch d.Strategy {
+	case "", DeploymentStrategyBlueGreen, DeploymentStrategyRolling:
+		return nil
+	case DeploymentStrategyCanary:
+		// fall through to Canary validation below
+	default:
+		reason := fmt.Sprintf("deployment.strategy %q is not one of blue_green, canary, rolling", d.Strategy)
+		return newValidationError("deployment.strategy", d.Strategy, reason, ErrInvalidDeploymentStrategy)
+	}
+
+	if len(d.Canary.Steps) == 0 {
+		return newValidationError("deployment.canary.steps", d.Canary.Steps, "deployment.canary.steps is required when deployment.strategy is canary", ErrInvalidCanaryPlan)
+	}
+
+	previous := 0
+	for i, step := range d.Canary.Steps {
+		if step <= 0 || step > 100 {
+			reason := fmt.Sprintf("deployment.canary.steps[%d] must be between 1 and 100, got %d", i, step)
+			return newValidationError("deployment.canary.steps", step, reason, ErrInvalidCanaryPlan)
+		}
+		if step <= previous {
+			reason := fmt.Sprintf("deployment.canary.steps must strictly increase, got %d at index %d after %d", step, i, previous)
+			return newValidationError("deployment.canary.steps", d.Canary.Steps, reason, ErrInvalidCanaryPlan)
+		}
+		previous = step
+	}
+
+	if last := d.Canary.Steps[len(d.Canary.Steps)-1]; last != 100 {
+		reason := fmt.Sprintf("deployment.canary.steps must end at 100, got %d", last)
+		return newValidationError("deployment.canary.steps", d.Canary.Steps, reason, ErrInvalidCanaryPlan)
+	}
+
+	return nil
+}
+
+// validateRestartPolicy checks rp's fields against each other instead of
+// against a fixed range: an empty Policy (the default, meaning retries are
+// disabled) skips the rest entirely, since MaxRetries/backoff settings are
+// meaningless without a policy that uses them.
+func validateRestartPolicy(rp RestartPolicy) error {
+	switch rp.Policy {
+	case "", RestartPolicyNever, RestartPolicyAlways, RestartPolicyOnFailure:
+	default:
+		reason := fmt.Sprintf("deployment.restart_policy.policy %q is not one of always, on_failure, never", rp.Policy)
+		return newValidationError("deployment.restart_policy.policy", rp.Policy, reason, ErrInvalidRestartPolicy)
+	}
+
+	if rp.Policy == "" || rp.Policy == RestartPolicyNever {
+		return nil
+	}
+
+	if rp.MaxRetries <= 0 {
+		reason := fmt.Sprintf("deployment.restart_policy.max_retries must be positive when policy is %q, got %d", rp.Policy, rp.MaxRetries)
+		return newValidationError("deployment.restart_policy.max_retries", rp.MaxRetries, reason, ErrInvalidRestartPolicy)
+	}
+
+	if rp.BackoffInitial <= 0 {
+		reason := fmt.Sprintf("deployment.restart_policy.backoff_initial must be positive when policy is %q", rp.Policy)
+		return newValidationError("deployment.restart_policy.backoff_initial", rp.BackoffInitial, reason, ErrInvalidRestartPolicy)
+	}
+
+	if rp.BackoffMax > 0 && rp.BackoffMax < rp.BackoffInitial {
+		reason := "deployment.restart_policy.backoff_max must be >= backoff_initial"
+		return newValidationError("deployment.restart_policy.backoff_max", rp.BackoffMax, reason, ErrInvalidRestartPolicy)
+	}
+
+	if rp.BackoffMultiplier != 0 && rp.BackoffMultiplier < 1 {
+		reason := fmt.Sprintf("deployment.restart_policy.backoff_multiplier must be >= 1, got %v", rp.BackoffMultiplier)
+		return newValidationError("deployment.restart_policy.backoff_multiplier", rp.BackoffMultiplier, reason, ErrInvalidRestartPolicy)
+	}
+
+	return nil
+}
+
+// validateHealthCheckType checks that the per-type fields a HealthCheck
+// needs are actually set, regardless of whether it's the primary
+// HealthCheck or one of AdditionalChecks.
+func validateHealthCheckType(hc HealthCheck) error {
+	switch hc.Type {
+	case "", HealthCheckTypeHTTP:
+		// Endpoint defaults to "" (no HTTP probe configured), so nothing to
+		// enforce here.
+	case HealthCheckTypeTCP:
+		// Port is resolved from Ports.Blue/Green at check time; nothing
+		// type-specific to validate.
+	case HealthCheckTypeGRPC:
+		// GRPCService may be empty (checks overall server health).
+	case HealthCheckTypeExec:
+		if len(hc.ExecCommand) == 0 {
+			reason := fmt.Sprintf("health_check.exec_command is required when type is %q", HealthCheckTypeExec)
+			return newValidationError("health_check.exec_command", hc.ExecCommand, reason, ErrInvalidHealthCheck)
+		}
+	default:
+		reason := fmt.Sprintf("health_check.type %q is not one of http, tcp, grpc, exec", hc.Type)
+		return newValidationError("health_check.type", hc.Type, reason, ErrInvalidHealthCheck)
+	}
+	return nil
+}
+
+// isDeclaredSlot reports whether color is a valid EnvironmentOverrides key:
+// one of config's declared Slots, or (for configs with no Slots of their
+// own, i.e. not yet migrated) the legacy "blue"/"green" pair.
+func isDeclaredSlot(config *AppConfig, color string) bool {
+	if len(config.Slots) > 0 {
+		_, ok := config.Slots[color]
+		return ok
+	}
+	return color == "blue" || color == "green"
+}
+
+// validateSlots checks that every declared slot has a positive port, a
+// non-negative weight, and doesn't collide with another slot's port.
+func validateSlots(slots map[string]SlotConfig) error {
+	portOwners := make(map[int]string, len(slots))
+
+	for name, slot := range slots {
+		if slot.Port <= 0 {
+			reason := fmt.Sprintf("slots.%s.port must be positive", name)
+			return newValidationError(fmt.Sprintf("slots.%s.port", name), slot.Port, reason, ErrInvalidPort)
+		}
+		if slot.Weight < 0 {
+			reason := fmt.Sprintf("slots.%s.weight must be non-negative", name)
+			return newValidationError(fmt.Sprintf("slots.%s.weight", name), slot.Weight, reason, ErrInvalidSlotConfig)
+		}
+		if owner, taken := portOwners[slot.Port]; taken {
+			reason := fmt.Sprintf("slots.%s and slots.%s both use port %d", owner, name, slot.Port)
+			return newValidationError("slots", slot.Port, reason, ErrInvalidSlotConfig)
+		}
+		portOwners[slot.Port] = name
+	}
+
+	return nil
+}
+
+// minMemoryBytes is Docker's own floor for `--memory` - anything lower and
+// the daemon rejects the container outright.
+const minMemoryBytes = 6 * 1024 * 1024
+
+// validateDockerResources parses docker.MemoryLimit/docker.MemorySwap/
+// docker.MemoryReservation/docker.CPULimit (if set) into their *Bytes/CPUs
+// counterparts and rejects values that would fail at deploy time anyway, so
+// a typo like "512M " (trailing space) is caught by `dockswap config
+// reload` instead of docker run. CPUPeriod, CPUShares, CPUSetCPUs,
+// PidsLimit, BlkioWeight, and Ulimits are passed through to Docker as
+// configured - they're already machine-usable types, so there's nothing to
+// parse.
+func validateDockerResources(docker *Docker) error {
+	if docker.MemoryLimit != "" {
+		memoryBytes, err := ParseMemoryLimit(docker.MemoryLimit)
+		if err != nil {
+			return newValidationError("docker.memory_limit", docker.MemoryLimit, err.Error(), ErrInvalidResourceLimit)
+		}
+		if memoryBytes < minMemoryBytes {
+			return newValidationError("docker.memory_limit", docker.MemoryLimit, "docker.memory_limit must be >= 6m", ErrInvalidResourceLimit)
+		}
+		docker.MemoryBytes = memoryBytes
+	}
+
+	if docker.MemorySwap != "" {
+		memorySwapBytes, err := ParseMemorySwapLimit(docker.MemorySwap)
+		if err != nil {
+			return newValidationError("docker.memory_swap", docker.MemorySwap, err.Error(), ErrInvalidResourceLimit)
+		}
+		docker.MemorySwapBytes = memorySwapBytes
+	}
+
+	if docker.MemoryReservation != "" {
+		memoryReservationBytes, err := ParseMemoryLimit(docker.MemoryReservation)
+		if err != nil {
+			return newValidationError("docker.memory_reservation", docker.MemoryReservation, err.Error(), ErrInvalidResourceLimit)
+		}
+		docker.MemoryReservationBytes = memoryReservationBytes
+	}
+
+	if docker.CPULimit != "" {
+		cpus, err := ParseCPULimit(docker.CPULimit)
+		if err != nil {
+			return newValidationError("docker.cpu_limit", docker.CPULimit, err.Error(), ErrInvalidResourceLimit)
+		}
+		if cpus <= 0 {
+			return newValidationError("docker.cpu_limit", docker.CPULimit, "docker.cpu_limit must be > 0", ErrInvalidResourceLimit)
+		}
+		docker.CPUs = cpus
+	}
+
+	return nil
+}
+
+// dockerHostSchemes are the endpoint schemes runtime.NewClient's backends
+// know how to dial; anything else is almost certainly a typo (a bare host,
+// a missing "tcp://"), so it's rejected here rather than surfacing as an
+// opaque dial error at deploy time.
+var dockerHostSchemes = []string{"unix://", "tcp://", "ssh://"}
+
+// validateDockerHost rejects a docker_host that isn't empty (meaning: use
+// the local default socket) and doesn't start with one of dockerHostSchemes.
+func validateDockerHost(host string) error {
+	if host == "" {
+		return nil
+	}
+	for _, scheme := range dockerHostSchemes {
+		if strings.HasPrefix(host, scheme) {
+			return nil
+		}
+	}
+	return newValidationError("docker.docker_host", host, fmt.Sprintf("docker.docker_host must start with one of %s", strings.Join(dockerHostSchemes, ", ")), ErrInvalidDockerHost)
+}
+
+func validateServices(services []ServiceConfig) error {
+	names := make(map[string]bool, len(services))
+	for _, svc := range services {
+		if svc.Name == "" {
+			return newValidationError("services[].name", svc.Name, "services[].name is required", ErrInvalidServiceConfig)
+		}
+		if svc.Image == "" {
+			reason := fmt.Sprintf("services.%s.image is required", svc.Name)
+			return newValidationError(fmt.Sprintf("services.%s.image", svc.Name), svc.Image, reason, ErrInvalidServiceConfig)
+		}
+		if names[svc.Name] {
+			reason := fmt.Sprintf("duplicate service name: %s", svc.Name)
+			return newValidationError("services[].name", svc.Name, reason, ErrInvalidServiceConfig)
+		}
+		names[svc.Name] = true
+	}
+
+	for _, svc := range services {
+		for _, dep := range svc.DependsOn {
+			if !names[dep] {
+				reason := fmt.Sprintf("service %s depends_on unknown service %s", svc.Name, dep)
+				return newValidationError("services[].depends_on", dep, reason, ErrInvalidServiceConfig)
+			}
+		}
 	}
 
 	return nil
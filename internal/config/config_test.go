@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -786,3 +788,685 @@ health_check:
 		}
 	})
 }
+
+func baseValidConfig() AppConfig {
+	return AppConfig{
+		Name: "test-app",
+		Docker: Docker{
+			ExposePort: 8080,
+		},
+		Ports: Ports{
+			Blue:  8081,
+			Green: 8082,
+		},
+		HealthCheck: HealthCheck{
+			Retries:          3,
+			SuccessThreshold: 2,
+			ExpectedStatus:   200,
+		},
+	}
+}
+
+func TestValidateConfigServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []ServiceConfig
+		wantErr  bool
+		errMsg   string
+	}{
+		{
+			name: "valid services with dependency ordering",
+			services: []ServiceConfig{
+				{Name: "db-proxy", Image: "proxysql:2"},
+				{Name: "cache", Image: "redis:7", DependsOn: []string{"db-proxy"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:     "missing service name",
+			services: []ServiceConfig{{Image: "redis:7"}},
+			wantErr:  true,
+			errMsg:   "services[].name is required",
+		},
+		{
+			name:     "missing service image",
+			services: []ServiceConfig{{Name: "cache"}},
+			wantErr:  true,
+			errMsg:   "services.cache.image is required",
+		},
+		{
+			name: "duplicate service name",
+			services: []ServiceConfig{
+				{Name: "cache", Image: "redis:7"},
+				{Name: "cache", Image: "redis:8"},
+			},
+			wantErr: true,
+			errMsg:  "duplicate service name: cache",
+		},
+		{
+			name: "depends_on unknown service",
+			services: []ServiceConfig{
+				{Name: "cache", Image: "redis:7", DependsOn: []string{"missing"}},
+			},
+			wantErr: true,
+			errMsg:  "service cache depends_on unknown service missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			config.Services = tt.services
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if err.Error() != tt.errMsg {
+					t.Errorf("validateConfig() error = %v, want %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigHealthCheckType(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*AppConfig)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "default type with no endpoint is valid",
+			mutate:  func(c *AppConfig) {},
+			wantErr: false,
+		},
+		{
+			name: "tcp type needs nothing extra",
+			mutate: func(c *AppConfig) {
+				c.HealthCheck.Type = HealthCheckTypeTCP
+			},
+			wantErr: false,
+		},
+		{
+			name: "grpc type with no service checks overall health",
+			mutate: func(c *AppConfig) {
+				c.HealthCheck.Type = HealthCheckTypeGRPC
+			},
+			wantErr: false,
+		},
+		{
+			name: "exec type requires exec_command",
+			mutate: func(c *AppConfig) {
+				c.HealthCheck.Type = HealthCheckTypeExec
+			},
+			wantErr: true,
+			errMsg:  `health_check.exec_command is required when type is "exec"`,
+		},
+		{
+			name: "exec type with exec_command is valid",
+			mutate: func(c *AppConfig) {
+				c.HealthCheck.Type = HealthCheckTypeExec
+				c.HealthCheck.ExecCommand = []string{"pg_isready"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown type is rejected",
+			mutate: func(c *AppConfig) {
+				c.HealthCheck.Type = "carrier-pigeon"
+			},
+			wantErr: true,
+			errMsg:  `health_check.type "carrier-pigeon" is not one of http, tcp, grpc, exec`,
+		},
+		{
+			name: "invalid additional check is rejected with its index",
+			mutate: func(c *AppConfig) {
+				c.AdditionalChecks = []HealthCheck{{Type: HealthCheckTypeExec}}
+			},
+			wantErr: true,
+			errMsg:  `additional_checks[0]: health_check.exec_command is required when type is "exec"`,
+		},
+		{
+			name: "chained additional checks of different types are valid",
+			mutate: func(c *AppConfig) {
+				c.AdditionalChecks = []HealthCheck{
+					{Type: HealthCheckTypeTCP},
+					{Type: HealthCheckTypeGRPC, GRPCService: "myapp.Health"},
+				}
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			tt.mutate(&config)
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if err.Error() != tt.errMsg {
+					t.Errorf("validateConfig() error = %v, want %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigHealthCheckThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*AppConfig)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "zero failure_threshold and initial_delay are valid",
+			mutate:  func(c *AppConfig) {},
+			wantErr: false,
+		},
+		{
+			name: "positive failure_threshold is valid",
+			mutate: func(c *AppConfig) {
+				c.HealthCheck.FailureThreshold = 3
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative failure_threshold is rejected",
+			mutate: func(c *AppConfig) {
+				c.HealthCheck.FailureThreshold = -1
+			},
+			wantErr: true,
+			errMsg:  "health_check.failure_threshold must be non-negative",
+		},
+		{
+			name: "negative initial_delay is rejected",
+			mutate: func(c *AppConfig) {
+				c.HealthCheck.InitialDelay = -1 * time.Second
+			},
+			wantErr: true,
+			errMsg:  "health_check.initial_delay must be non-negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			tt.mutate(&config)
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("validateConfig() error = %v, want containing %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigWatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		watch   Watch
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "disabled watch needs no interval",
+			watch:   Watch{Enabled: false},
+			wantErr: false,
+		},
+		{
+			name:    "enabled watch with positive interval is valid",
+			watch:   Watch{Enabled: true, Interval: time.Minute},
+			wantErr: false,
+		},
+		{
+			name:    "enabled watch with zero interval is rejected",
+			watch:   Watch{Enabled: true},
+			wantErr: true,
+			errMsg:  "watch.interval must be positive when watch.enabled is true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			config.Watch = tt.watch
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if err.Error() != tt.errMsg {
+					t.Errorf("validateConfig() error = %v, want %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigDeploymentStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		deployment Deployment
+		wantErr    bool
+		errMsg     string
+	}{
+		{
+			name:       "empty strategy defaults to blue_green",
+			deployment: Deployment{},
+			wantErr:    false,
+		},
+		{
+			name:       "explicit blue_green is valid",
+			deployment: Deployment{Strategy: DeploymentStrategyBlueGreen},
+			wantErr:    false,
+		},
+		{
+			name:       "rolling is valid",
+			deployment: Deployment{Strategy: DeploymentStrategyRolling},
+			wantErr:    false,
+		},
+		{
+			name:       "unknown strategy is rejected",
+			deployment: Deployment{Strategy: "canaryy"},
+			wantErr:    true,
+			errMsg:     `deployment.strategy "canaryy" is not one of blue_green, canary, rolling`,
+		},
+		{
+			name:       "canary with no steps is rejected",
+			deployment: Deployment{Strategy: DeploymentStrategyCanary},
+			wantErr:    true,
+			errMsg:     "deployment.canary.steps is required when deployment.strategy is canary",
+		},
+		{
+			name: "canary steps not ending at 100 is rejected",
+			deployment: Deployment{
+				Strategy: DeploymentStrategyCanary,
+				Canary:   CanaryPlan{Steps: []int{5, 25, 50}},
+			},
+			wantErr: true,
+			errMsg:  "deployment.canary.steps must end at 100, got 50",
+		},
+		{
+			name: "canary steps not strictly increasing is rejected",
+			deployment: Deployment{
+				Strategy: DeploymentStrategyCanary,
+				Canary:   CanaryPlan{Steps: []int{25, 25, 100}},
+			},
+			wantErr: true,
+			errMsg:  "deployment.canary.steps must strictly increase, got 25 at index 1 after 25",
+		},
+		{
+			name: "canary step over 100 is rejected",
+			deployment: Deployment{
+				Strategy: DeploymentStrategyCanary,
+				Canary:   CanaryPlan{Steps: []int{5, 150}},
+			},
+			wantErr: true,
+			errMsg:  "deployment.canary.steps[1] must be between 1 and 100, got 150",
+		},
+		{
+			name: "valid canary plan",
+			deployment: Deployment{
+				Strategy: DeploymentStrategyCanary,
+				Canary:   CanaryPlan{Steps: []int{5, 25, 50, 100}, StepDuration: time.Minute},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			config.Deployment = tt.deployment
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if err.Error() != tt.errMsg {
+					t.Errorf("validateConfig() error = %v, want %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigPinnedDigest(t *testing.T) {
+	validDigest := "sha256:" + strings.Repeat("a1", 32)
+
+	tests := []struct {
+		name    string
+		digest  string
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "empty digest is valid",
+			digest:  "",
+			wantErr: false,
+		},
+		{
+			name:    "well-formed digest is valid",
+			digest:  validDigest,
+			wantErr: false,
+		},
+		{
+			name:    "missing sha256 prefix is rejected",
+			digest:  strings.Repeat("a1", 32),
+			wantErr: true,
+			errMsg:  fmt.Sprintf("deployment.pinned_digest %q must be \"sha256:\" followed by 64 hex characters", strings.Repeat("a1", 32)),
+		},
+		{
+			name:    "wrong length is rejected",
+			digest:  "sha256:abc123",
+			wantErr: true,
+			errMsg:  `deployment.pinned_digest "sha256:abc123" must be "sha256:" followed by 64 hex characters`,
+		},
+		{
+			name:    "non-hex characters are rejected",
+			digest:  "sha256:" + strings.Repeat("z", 64),
+			wantErr: true,
+			errMsg:  fmt.Sprintf("deployment.pinned_digest %q must be \"sha256:\" followed by 64 hex characters", "sha256:"+strings.Repeat("z", 64)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			config.Deployment.PinnedDigest = tt.digest
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if err.Error() != tt.errMsg {
+					t.Errorf("validateConfig() error = %v, want %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigRestartPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RestartPolicy
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "empty policy is valid (retries disabled)",
+			policy:  RestartPolicy{},
+			wantErr: false,
+		},
+		{
+			name:    "explicit never is valid",
+			policy:  RestartPolicy{Policy: RestartPolicyNever},
+			wantErr: false,
+		},
+		{
+			name:    "unknown policy is rejected",
+			policy:  RestartPolicy{Policy: "sometimes"},
+			wantErr: true,
+			errMsg:  `deployment.restart_policy.policy "sometimes" is not one of always, on_failure, never`,
+		},
+		{
+			name:    "always with no max_retries is rejected",
+			policy:  RestartPolicy{Policy: RestartPolicyAlways, BackoffInitial: time.Second},
+			wantErr: true,
+			errMsg:  `deployment.restart_policy.max_retries must be positive when policy is "always", got 0`,
+		},
+		{
+			name:    "on_failure with no backoff_initial is rejected",
+			policy:  RestartPolicy{Policy: RestartPolicyOnFailure, MaxRetries: 3},
+			wantErr: true,
+			errMsg:  `deployment.restart_policy.backoff_initial must be positive when policy is "on_failure"`,
+		},
+		{
+			name: "backoff_max below backoff_initial is rejected",
+			policy: RestartPolicy{
+				Policy:         RestartPolicyAlways,
+				MaxRetries:     3,
+				BackoffInitial: time.Minute,
+				BackoffMax:     time.Second,
+			},
+			wantErr: true,
+			errMsg:  "deployment.restart_policy.backoff_max must be >= backoff_initial",
+		},
+		{
+			name: "backoff_multiplier below 1 is rejected",
+			policy: RestartPolicy{
+				Policy:            RestartPolicyAlways,
+				MaxRetries:        3,
+				BackoffInitial:    time.Second,
+				BackoffMultiplier: 0.5,
+			},
+			wantErr: true,
+			errMsg:  "deployment.restart_policy.backoff_multiplier must be >= 1, got 0.5",
+		},
+		{
+			name: "fully specified policy is valid",
+			policy: RestartPolicy{
+				Policy:            RestartPolicyAlways,
+				MaxRetries:        3,
+				BackoffInitial:    time.Second,
+				BackoffMax:        time.Minute,
+				BackoffMultiplier: 2,
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			config.Deployment.RestartPolicy = tt.policy
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if err.Error() != tt.errMsg {
+					t.Errorf("validateConfig() error = %v, want %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigHooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   HooksConfig
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "no hooks is valid",
+			hooks:   HooksConfig{},
+			wantErr: false,
+		},
+		{
+			name: "blocking mode is valid",
+			hooks: HooksConfig{
+				PreDeploy: []HookCommand{{Command: "migrate", Mode: HookModeBlocking}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "background mode is valid",
+			hooks: HooksConfig{
+				OnFailure: []HookCommand{{Command: "notify-slack", Mode: HookModeBackground}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty mode defaults to blocking",
+			hooks: HooksConfig{
+				PreSwitch: []HookCommand{{Command: "warm-cache"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing command and url is rejected",
+			hooks: HooksConfig{
+				PostHealth: []HookCommand{{Args: []string{"--check"}}},
+			},
+			wantErr: true,
+			errMsg:  "hooks.post_health[0] must set exactly one of command or url",
+		},
+		{
+			name: "command and url both set is rejected",
+			hooks: HooksConfig{
+				PostHealth: []HookCommand{{Command: "smoke-test", URL: "https://example.com/hook"}},
+			},
+			wantErr: true,
+			errMsg:  "hooks.post_health[0] must set exactly one of command or url",
+		},
+		{
+			name: "url-only hook is accepted",
+			hooks: HooksConfig{
+				PostHealth: []HookCommand{{URL: "https://example.com/hook"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown mode is rejected",
+			hooks: HooksConfig{
+				OnRollback: []HookCommand{{Command: "rollback.sh", Mode: "async"}},
+			},
+			wantErr: true,
+			errMsg:  `hooks.on_rollback[0].mode "async" is not one of blocking, background`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			config.Hooks = tt.hooks
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if err.Error() != tt.errMsg {
+					t.Errorf("validateConfig() error = %v, want %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigHookSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   HooksConfig
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "no hook steps is valid",
+			hooks:   HooksConfig{},
+			wantErr: false,
+		},
+		{
+			name: "exec step is valid",
+			hooks: HooksConfig{
+				PreStart: []HookStep{{Exec: &ExecStep{Command: "migrate"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "container_exec step is valid",
+			hooks: HooksConfig{
+				PostStart: []HookStep{{ContainerExec: &ContainerExecStep{Command: []string{"sh", "-c", "warm-cache"}}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "neither exec nor container_exec is rejected",
+			hooks: HooksConfig{
+				PreStop: []HookStep{{}},
+			},
+			wantErr: true,
+			errMsg:  "hooks.pre_stop[0] must set exactly one of exec or container_exec",
+		},
+		{
+			name: "both exec and container_exec is rejected",
+			hooks: HooksConfig{
+				PostStop: []HookStep{{Exec: &ExecStep{Command: "a"}, ContainerExec: &ContainerExecStep{Command: []string{"b"}}}},
+			},
+			wantErr: true,
+			errMsg:  "hooks.post_stop[0] must set exactly one of exec or container_exec",
+		},
+		{
+			name: "empty exec command is rejected",
+			hooks: HooksConfig{
+				PreSwap: []HookStep{{Exec: &ExecStep{}}},
+			},
+			wantErr: true,
+			errMsg:  "hooks.pre_swap[0].exec.command is required",
+		},
+		{
+			name: "empty container_exec command is rejected",
+			hooks: HooksConfig{
+				PostSwap: []HookStep{{ContainerExec: &ContainerExecStep{}}},
+			},
+			wantErr: true,
+			errMsg:  "hooks.post_swap[0].container_exec.command is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			config.Hooks = tt.hooks
+
+			err := validateConfig(&config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateConfig() expected error but got none")
+				}
+				if err.Error() != tt.errMsg {
+					t.Errorf("validateConfig() error = %v, want %v", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("validateConfig() unexpected error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors identifying the kind of problem a ValidationError wraps.
+// Callers match on these with errors.Is/errors.As instead of string-
+// comparing err.Error(), e.g. errors.Is(err, config.ErrInvalidPort).
+var (
+	ErrMissingName                = errors.New("app name is required")
+	ErrInvalidPort                = errors.New("port configuration is invalid")
+	ErrDuplicateColorPort         = errors.New("blue and green ports must be different")
+	ErrInvalidHealthStatus        = errors.New("health_check.expected_status must be a valid HTTP status code")
+	ErrInvalidHealthCheck         = errors.New("health check configuration is invalid")
+	ErrInvalidServiceConfig       = errors.New("service configuration is invalid")
+	ErrInvalidWatchConfig         = errors.New("watch configuration is invalid")
+	ErrInvalidEnvironmentOverride = errors.New("environment_overrides color is invalid")
+	ErrInvalidSlotConfig          = errors.New("slot configuration is invalid")
+	ErrInvalidResourceLimit       = errors.New("docker resource limit is invalid")
+	ErrInvalidDockerHost          = errors.New("docker.docker_host is invalid")
+	ErrInvalidDeploymentStrategy  = errors.New("deployment.strategy is invalid")
+	ErrInvalidCanaryPlan          = errors.New("deployment.canary configuration is invalid")
+	ErrInvalidPinnedDigest        = errors.New("deployment.pinned_digest is invalid")
+	ErrInvalidHook                = errors.New("hooks configuration is invalid")
+	ErrInvalidRestartPolicy       = errors.New("deployment.restart_policy configuration is invalid")
+)
+
+// ValidationError describes one problem found validating an AppConfig: the
+// dotted field path, the offending value, and why it's invalid. Err is one
+// of the package's sentinel errors and is what Unwrap exposes for
+// errors.Is/errors.As; Error() reproduces the historical plain-English
+// message so existing callers that print or log err.Error() see the same
+// text as before.
+type ValidationError struct {
+	AppName string
+	Field   string
+	Value   interface{}
+	Reason  string
+	Err     error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func newValidationError(field string, value interface{}, reason string, sentinel error) *ValidationError {
+	return &ValidationError{Field: field, Value: value, Reason: reason, Err: sentinel}
+}
+
+// withAppName returns a copy of err tagged with the app name it was found
+// in, for use by callers (like LoadAllConfigs) aggregating errors across
+// several configs.
+func (e *ValidationError) withAppName(appName string) *ValidationError {
+	tagged := *e
+	tagged.AppName = appName
+	return &tagged
+}
+
+// ValidationErrors aggregates every error found validating one or more
+// configs - ValidationError field problems alongside plain load/parse
+// failures - so a caller sees every problem instead of just the first.
+// Unwrap lets errors.Is/errors.As reach into individual members.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d config(s) failed validation: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
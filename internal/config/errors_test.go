@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateConfigErrorsAreTyped(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*AppConfig)
+		wantErr error
+	}{
+		{
+			name:    "missing name",
+			mutate:  func(c *AppConfig) { c.Name = "" },
+			wantErr: ErrMissingName,
+		},
+		{
+			name:    "invalid expose port",
+			mutate:  func(c *AppConfig) { c.Docker.ExposePort = 0 },
+			wantErr: ErrInvalidPort,
+		},
+		{
+			name:    "duplicate color port",
+			mutate:  func(c *AppConfig) { c.Ports.Green = c.Ports.Blue },
+			wantErr: ErrDuplicateColorPort,
+		},
+		{
+			name:    "invalid health status",
+			mutate:  func(c *AppConfig) { c.HealthCheck.ExpectedStatus = 1000 },
+			wantErr: ErrInvalidHealthStatus,
+		},
+		{
+			name:    "invalid health check",
+			mutate:  func(c *AppConfig) { c.HealthCheck.Type = HealthCheckTypeExec },
+			wantErr: ErrInvalidHealthCheck,
+		},
+		{
+			name:    "invalid service config",
+			mutate:  func(c *AppConfig) { c.Services = []ServiceConfig{{Name: "cache"}} },
+			wantErr: ErrInvalidServiceConfig,
+		},
+		{
+			name:    "invalid watch config",
+			mutate:  func(c *AppConfig) { c.Watch = Watch{Enabled: true} },
+			wantErr: ErrInvalidWatchConfig,
+		},
+		{
+			name:    "invalid resource limit",
+			mutate:  func(c *AppConfig) { c.Docker.MemoryLimit = "512M " },
+			wantErr: ErrInvalidResourceLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := baseValidConfig()
+			tt.mutate(&config)
+
+			err := validateConfig(&config)
+			if err == nil {
+				t.Fatalf("validateConfig() expected error but got none")
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("validateConfig() error %v does not wrap %v", err, tt.wantErr)
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("validateConfig() error %v is not a *ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsAggregatesPerFileFailures(t *testing.T) {
+	tempDir := t.TempDir()
+
+	validYAML := `name: good-app
+docker:
+  expose_port: 8080
+ports:
+  blue: 8081
+  green: 8082
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+	invalidYAML := `name: bad-app
+docker:
+  expose_port: 8080
+ports:
+  blue: 8081
+  green: 8081
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+	writeYAML(t, tempDir, "good.yaml", validYAML)
+	writeYAML(t, tempDir, "bad.yaml", invalidYAML)
+
+	configs, err := LoadAllConfigs(tempDir)
+	if err == nil {
+		t.Fatalf("LoadAllConfigs() expected error for a directory with one invalid config")
+	}
+
+	if _, ok := configs["good-app"]; !ok {
+		t.Errorf("LoadAllConfigs() should still return configs that loaded successfully, got %v", configs)
+	}
+
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("LoadAllConfigs() error %v is not a ValidationErrors", err)
+	}
+	if len(validationErrs) != 1 {
+		t.Errorf("LoadAllConfigs() collected %d failures, want 1", len(validationErrs))
+	}
+	if !errors.Is(err, ErrDuplicateColorPort) {
+		t.Errorf("LoadAllConfigs() error %v does not wrap ErrDuplicateColorPort", err)
+	}
+}
+
+func writeYAML(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// memoryUnitBytes maps Docker's single-letter `--memory` suffixes
+// (case-insensitive) to their byte multiplier.
+var memoryUnitBytes = map[byte]int64{
+	'b': 1,
+	'k': 1024,
+	'm': 1024 * 1024,
+	'g': 1024 * 1024 * 1024,
+}
+
+// ParseMemoryLimit parses a Docker-style memory limit ("512m", "1.5g", or a
+// bare integer for raw bytes) into bytes, the way `docker run --memory`
+// parses its own flag. Unlike Docker itself, decimal magnitudes ("1.5g")
+// are accepted and rounded to the nearest byte, since that's a natural way
+// to express e.g. 1.5 gigabytes of memory in a config file.
+func ParseMemoryLimit(limit string) (int64, error) {
+	if limit == "" {
+		return 0, fmt.Errorf("memory limit must not be empty")
+	}
+
+	digits := limit
+	multiplier := int64(1)
+	if suffix, ok := memoryUnitBytes[lowerASCII(limit[len(limit)-1])]; ok {
+		digits = limit[:len(limit)-1]
+		multiplier = suffix
+	}
+
+	value, err := strconv.ParseFloat(digits, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid memory limit %q: must be a non-negative number optionally suffixed with b/k/m/g", limit)
+	}
+
+	bytes := value * float64(multiplier)
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid memory limit %q: overflows a 64-bit byte count", limit)
+	}
+
+	return int64(math.Round(bytes)), nil
+}
+
+// ParseMemorySwapLimit parses a Docker-style `--memory-swap` value: the same
+// syntax as ParseMemoryLimit, plus Docker's own "-1" sentinel meaning
+// unlimited swap.
+func ParseMemorySwapLimit(limit string) (int64, error) {
+	if limit == "-1" {
+		return -1, nil
+	}
+	return ParseMemoryLimit(limit)
+}
+
+// ParseCPULimit parses a CPU limit as either a decimal fraction of CPUs
+// ("0.5", "2.0" of Docker's own `--cpus` flag) or a nano-CPU integer (e.g.
+// "500000000" for half a CPU, the convention Kubernetes resource requests
+// use), returning the number of CPUs as a float64. A value without a "."
+// is always read as nano-CPUs, so "2" is 2 nano-CPUs, not 2 CPUs.
+func ParseCPULimit(limit string) (float64, error) {
+	if limit == "" {
+		return 0, fmt.Errorf("cpu limit must not be empty")
+	}
+
+	if strings.Contains(limit, ".") {
+		cpus, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpu limit %q: must be a decimal fraction of CPUs or an integer nano-CPU count", limit)
+		}
+		return cpus, nil
+	}
+
+	nanoCPUs, err := strconv.ParseInt(limit, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu limit %q: must be a decimal fraction of CPUs or an integer nano-CPU count", limit)
+	}
+
+	return float64(nanoCPUs) / 1e9, nil
+}
+
+func lowerASCII(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + ('a' - 'A')
+	}
+	return b
+}
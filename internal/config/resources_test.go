@@ -0,0 +1,158 @@
+package config
+
+import "testing"
+
+func TestParseMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{name: "megabytes lowercase", input: "512m", expected: 512 * 1024 * 1024},
+		{name: "megabytes uppercase", input: "512M", expected: 512 * 1024 * 1024},
+		{name: "gigabytes", input: "1g", expected: 1024 * 1024 * 1024},
+		{name: "kilobytes", input: "2048k", expected: 2048 * 1024},
+		{name: "raw bytes, no suffix", input: "1048576", expected: 1048576},
+		{name: "explicit bytes suffix", input: "100b", expected: 100},
+		{name: "decimal gigabytes", input: "1.5g", expected: 1610612736},
+		{name: "zero", input: "0", expected: 0},
+		{name: "zero with suffix", input: "0m", expected: 0},
+		{name: "empty", input: "", wantErr: true},
+		{name: "non-numeric", input: "lots", wantErr: true},
+		{name: "malformed suffix", input: "512x", wantErr: true},
+		{name: "trailing space is not a valid suffix", input: "512M ", wantErr: true},
+		{name: "negative", input: "-512m", wantErr: true},
+		{name: "overflows int64", input: "99999999999999999999999g", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMemoryLimit(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMemoryLimit(%q) expected error, got nil", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMemoryLimit(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseMemoryLimit(%q) = %d, want %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCPULimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		wantErr  bool
+	}{
+		{name: "decimal fraction", input: "0.5", expected: 0.5},
+		{name: "decimal whole CPU", input: "2.0", expected: 2.0},
+		{name: "nano-CPU integer", input: "500000000", expected: 0.5},
+		{name: "nano-CPU integer, two CPUs", input: "2000000000", expected: 2.0},
+		{name: "empty", input: "", wantErr: true},
+		{name: "non-numeric", input: "lots", wantErr: true},
+		{name: "malformed decimal", input: "0.5.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCPULimit(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseCPULimit(%q) expected error, got nil", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCPULimit(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseCPULimit(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseMemorySwapLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{name: "plain megabytes", input: "512m", expected: 512 * 1024 * 1024},
+		{name: "unlimited sentinel", input: "-1", expected: -1},
+		{name: "negative but not -1", input: "-2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMemorySwapLimit(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMemorySwapLimit(%q) expected error, got nil", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMemorySwapLimit(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseMemorySwapLimit(%q) = %d, want %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateDockerResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		docker  Docker
+		wantErr bool
+	}{
+		{name: "no limits set", docker: Docker{}},
+		{name: "valid memory and cpu", docker: Docker{MemoryLimit: "512m", CPULimit: "0.5"}},
+		{name: "memory below docker's 6m floor", docker: Docker{MemoryLimit: "4m"}, wantErr: true},
+		{name: "zero cpu", docker: Docker{CPULimit: "0"}, wantErr: true},
+		{name: "malformed memory", docker: Docker{MemoryLimit: "512M "}, wantErr: true},
+		{name: "valid swap and reservation", docker: Docker{MemorySwap: "1g", MemoryReservation: "256m"}},
+		{name: "unlimited swap", docker: Docker{MemorySwap: "-1"}},
+		{name: "malformed swap", docker: Docker{MemorySwap: "1gg"}, wantErr: true},
+		{name: "malformed reservation", docker: Docker{MemoryReservation: "1gg"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docker := tt.docker
+			err := validateDockerResources(&docker)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateDockerResources(%+v) expected error, got nil", tt.docker)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateDockerResources(%+v) unexpected error: %v", tt.docker, err)
+			}
+			if tt.docker.MemoryLimit != "" && docker.MemoryBytes == 0 {
+				t.Errorf("validateDockerResources(%+v) left MemoryBytes unset", tt.docker)
+			}
+			if tt.docker.CPULimit != "" && docker.CPUs == 0 {
+				t.Errorf("validateDockerResources(%+v) left CPUs unset", tt.docker)
+			}
+			if tt.docker.MemorySwap != "" && docker.MemorySwapBytes == 0 && tt.docker.MemorySwap != "-1" {
+				t.Errorf("validateDockerResources(%+v) left MemorySwapBytes unset", tt.docker)
+			}
+			if tt.docker.MemoryReservation != "" && docker.MemoryReservationBytes == 0 {
+				t.Errorf("validateDockerResources(%+v) left MemoryReservationBytes unset", tt.docker)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// secretURIPrefix marks a Docker.Environment/EnvironmentOverrides value as a
+// reference to resolve through a SecretProvider rather than a literal, e.g.
+// "secret://file/run/secrets/db_password" or "secret://env/DB_PASSWORD".
+const secretURIPrefix = "secret://"
+
+// envVarPattern matches ${NAME} and ${NAME:-default} references inside an
+// Environment/EnvironmentOverrides value, interpolated against the
+// dockswap process's own environment.
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// SecretProvider resolves the address portion of a secret://<scheme>/<address>
+// URI to the secret's plaintext value. Implementations should fail loudly
+// rather than return an empty string when a secret can't be found.
+type SecretProvider interface {
+	Resolve(address string) (string, error)
+}
+
+// defaultSecretProviders are the resolvers LoadAppConfig wires secret://
+// URIs through: file, env, and exec are fully implemented; vault and ssm
+// are registered as stubs until a real client is wired in.
+func defaultSecretProviders() map[string]SecretProvider {
+	return map[string]SecretProvider{
+		"file":  fileSecretProvider{},
+		"env":   envSecretProvider{},
+		"exec":  execSecretProvider{},
+		"vault": unimplementedSecretProvider{scheme: "vault"},
+		"ssm":   unimplementedSecretProvider{scheme: "ssm"},
+	}
+}
+
+// fileSecretProvider resolves secret://file/<path> by reading the file at
+// path, trimming a trailing newline the way Docker/Kubernetes secret mounts
+// commonly have one.
+type fileSecretProvider struct{}
+
+func (fileSecretProvider) Resolve(address string) (string, error) {
+	data, err := os.ReadFile(address)
+	if err != nil {
+		return "", fmt.Errorf("read secret file %s: %w", address, err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// envSecretProvider resolves secret://env/<NAME> by looking NAME up in the
+// dockswap process's own environment, for secrets injected by the process
+// supervisor (systemd EnvironmentFile, a CI runner, ...) rather than baked
+// into the YAML.
+type envSecretProvider struct{}
+
+func (envSecretProvider) Resolve(address string) (string, error) {
+	value, ok := os.LookupEnv(address)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", address)
+	}
+	return value, nil
+}
+
+// execSecretProvider resolves secret://exec/<cmd arg1 arg2> by running cmd
+// and using its trimmed stdout, mirroring how `docker-compose` and Vault
+// agent sidecars commonly shell out to a credential helper.
+type execSecretProvider struct{}
+
+func (execSecretProvider) Resolve(address string) (string, error) {
+	fields := strings.Fields(address)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("exec secret command is empty")
+	}
+	out, err := exec.Command(fields[0], fields[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("run exec secret command %q: %w", address, err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// unimplementedSecretProvider backs secret:// schemes that are recognized
+// but not yet wired to a real client, so a config referencing them fails
+// loudly at load time instead of silently resolving to an empty string.
+type unimplementedSecretProvider struct {
+	scheme string
+}
+
+func (p unimplementedSecretProvider) Resolve(address string) (string, error) {
+	return "", fmt.Errorf("secret provider %q is not implemented yet", p.scheme)
+}
+
+// resolveSecretURI resolves value through providers if it's a secret://
+// URI, reporting whether it was one at all so the caller can skip env-var
+// interpolation (and mark the key for redaction) for secret-sourced values.
+func resolveSecretURI(value string, providers map[string]SecretProvider) (resolved string, isSecret bool, err error) {
+	if !strings.HasPrefix(value, secretURIPrefix) {
+		return value, false, nil
+	}
+
+	rest := strings.TrimPrefix(value, secretURIPrefix)
+	scheme, address, ok := strings.Cut(rest, "/")
+	if !ok {
+		return "", true, fmt.Errorf("malformed secret URI %q: expected secret://<scheme>/<address>", value)
+	}
+
+	provider, ok := providers[scheme]
+	if !ok {
+		return "", true, fmt.Errorf("malformed secret URI %q: unknown scheme %q", value, scheme)
+	}
+
+	resolved, err = provider.Resolve(address)
+	if err != nil {
+		return "", true, fmt.Errorf("resolve secret %q: %w", value, err)
+	}
+	return resolved, true, nil
+}
+
+// interpolateEnvVars replaces ${NAME} and ${NAME:-default} references in
+// value with the dockswap process's own environment, erroring on a
+// reference with neither a set variable nor a default.
+func interpolateEnvVars(value string) (string, error) {
+	var firstErr error
+	result := envVarPattern.ReplaceAllStringFunc(value, func(match string) string {
+		groups := envVarPattern.FindStringSubmatch(match)
+		name, hasDefault, defaultValue := groups[1], groups[2] != "", groups[3]
+
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		if hasDefault {
+			return defaultValue
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("environment variable %s is not set and has no default", name)
+		}
+		return match
+	})
+
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return result, nil
+}
+
+// resolveEnvironmentValues resolves every value in env in place: secret://
+// URIs are resolved through providers, everything else has ${VAR}/
+// ${VAR:-default} references interpolated. Keys resolved from a secret are
+// recorded into secretKeys (scoped by the caller, e.g. "environment" or
+// "environment_overrides.blue") so they can be redacted later.
+func resolveEnvironmentValues(scope string, env map[string]string, providers map[string]SecretProvider, secretKeys map[string]bool) error {
+	for key, value := range env {
+		resolved, isSecret, err := resolveSecretURI(value, providers)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", scope, key, err)
+		}
+
+		if !isSecret {
+			resolved, err = interpolateEnvVars(value)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", scope, key, err)
+			}
+		}
+
+		env[key] = resolved
+		if isSecret {
+			secretKeys[scope+"."+key] = true
+		}
+	}
+	return nil
+}
+
+// resolveEnvironment resolves config.Docker.Environment and every color in
+// config.Docker.EnvironmentOverrides through providers, populating
+// config.Docker.secretKeys so RedactedEnvironment can mask secret-sourced
+// values in diagnostics.
+func resolveEnvironment(config *AppConfig, providers map[string]SecretProvider) error {
+	config.Docker.secretKeys = make(map[string]bool)
+
+	if err := resolveEnvironmentValues("environment", config.Docker.Environment, providers, config.Docker.secretKeys); err != nil {
+		return err
+	}
+
+	for color, overrides := range config.Docker.EnvironmentOverrides {
+		scope := fmt.Sprintf("environment_overrides.%s", color)
+		if err := resolveEnvironmentValues(scope, overrides, providers, config.Docker.secretKeys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolateEnvVars(t *testing.T) {
+	t.Setenv("DOCKSWAP_TEST_HOST", "db.internal")
+
+	tests := []struct {
+		name     string
+		value    string
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:     "substitutes a set variable",
+			value:    "postgres://${DOCKSWAP_TEST_HOST}/app",
+			expected: "postgres://db.internal/app",
+		},
+		{
+			name:     "falls back to default when unset",
+			value:    "${DOCKSWAP_TEST_MISSING:-fallback}",
+			expected: "fallback",
+		},
+		{
+			name:    "errors when unset and no default",
+			value:   "${DOCKSWAP_TEST_MISSING}",
+			wantErr: true,
+		},
+		{
+			name:     "leaves plain values untouched",
+			value:    "plain-value",
+			expected: "plain-value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interpolateEnvVars(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("interpolateEnvVars(%q) expected error but got none", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("interpolateEnvVars(%q) unexpected error = %v", tt.value, err)
+			}
+			if result != tt.expected {
+				t.Errorf("interpolateEnvVars(%q) = %q, want %q", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveSecretURI(t *testing.T) {
+	tempDir := t.TempDir()
+	secretFile := filepath.Join(tempDir, "db_password")
+	if err := os.WriteFile(secretFile, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+	t.Setenv("DOCKSWAP_TEST_SECRET", "from-env")
+
+	providers := defaultSecretProviders()
+
+	resolved, isSecret, err := resolveSecretURI("secret://file/"+secretFile, providers)
+	if err != nil {
+		t.Fatalf("resolveSecretURI(file) unexpected error = %v", err)
+	}
+	if !isSecret || resolved != "hunter2" {
+		t.Errorf("resolveSecretURI(file) = (%q, %v), want (\"hunter2\", true)", resolved, isSecret)
+	}
+
+	resolved, isSecret, err = resolveSecretURI("secret://env/DOCKSWAP_TEST_SECRET", providers)
+	if err != nil {
+		t.Fatalf("resolveSecretURI(env) unexpected error = %v", err)
+	}
+	if !isSecret || resolved != "from-env" {
+		t.Errorf("resolveSecretURI(env) = (%q, %v), want (\"from-env\", true)", resolved, isSecret)
+	}
+
+	if _, isSecret, err := resolveSecretURI("plain-value", providers); err != nil || isSecret {
+		t.Errorf("resolveSecretURI(plain) = (isSecret=%v, err=%v), want (false, nil)", isSecret, err)
+	}
+
+	if _, _, err := resolveSecretURI("secret://env/DOCKSWAP_TEST_MISSING", providers); err == nil {
+		t.Errorf("resolveSecretURI(env) expected error for unset variable")
+	}
+
+	if _, _, err := resolveSecretURI("secret://vault/some/path", providers); err == nil {
+		t.Errorf("resolveSecretURI(vault) expected error from unimplemented provider")
+	}
+}
+
+func TestLoadAppConfigWithInterpolationAndSecrets(t *testing.T) {
+	tempDir := t.TempDir()
+
+	secretFile := filepath.Join(tempDir, "db_password")
+	if err := os.WriteFile(secretFile, []byte("hunter2"), 0600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+
+	t.Setenv("DOCKSWAP_TEST_HOST", "db.internal")
+
+	yamlWithSecrets := `name: test-app
+docker:
+  expose_port: 8080
+  environment:
+    DATABASE_HOST: "${DOCKSWAP_TEST_HOST}"
+    DATABASE_PASSWORD: "secret://file/` + secretFile + `"
+    LOG_LEVEL: "${DOCKSWAP_TEST_LOG_LEVEL:-info}"
+  environment_overrides:
+    blue:
+      API_KEY: "secret://env/DOCKSWAP_TEST_API_KEY"
+ports:
+  blue: 8081
+  green: 8082
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+	configFile := filepath.Join(tempDir, "test-secrets.yaml")
+	if err := os.WriteFile(configFile, []byte(yamlWithSecrets), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	t.Setenv("DOCKSWAP_TEST_API_KEY", "super-secret-key")
+
+	config, err := LoadAppConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadAppConfig() unexpected error = %v", err)
+	}
+
+	if got := config.Docker.Environment["DATABASE_HOST"]; got != "db.internal" {
+		t.Errorf("Environment[DATABASE_HOST] = %q, want %q", got, "db.internal")
+	}
+	if got := config.Docker.Environment["DATABASE_PASSWORD"]; got != "hunter2" {
+		t.Errorf("Environment[DATABASE_PASSWORD] = %q, want %q", got, "hunter2")
+	}
+	if got := config.Docker.Environment["LOG_LEVEL"]; got != "info" {
+		t.Errorf("Environment[LOG_LEVEL] = %q, want %q", got, "info")
+	}
+
+	blueEnv := config.Docker.GetEnvironmentForColor("blue")
+	if got := blueEnv["API_KEY"]; got != "super-secret-key" {
+		t.Errorf("blue API_KEY = %q, want %q", got, "super-secret-key")
+	}
+
+	redacted := config.Docker.RedactedEnvironment("", config.Docker.Environment)
+	if redacted["DATABASE_PASSWORD"] != "[REDACTED]" {
+		t.Errorf("RedactedEnvironment() left DATABASE_PASSWORD unredacted: %q", redacted["DATABASE_PASSWORD"])
+	}
+	if redacted["DATABASE_HOST"] != "db.internal" {
+		t.Errorf("RedactedEnvironment() redacted a non-secret value: %q", redacted["DATABASE_HOST"])
+	}
+
+	redactedBlue := config.Docker.RedactedEnvironment("blue", blueEnv)
+	if redactedBlue["API_KEY"] != "[REDACTED]" {
+		t.Errorf("RedactedEnvironment(blue) left API_KEY unredacted: %q", redactedBlue["API_KEY"])
+	}
+}
+
+func TestLoadAppConfigMissingSecretFailsLoudly(t *testing.T) {
+	tempDir := t.TempDir()
+
+	yamlWithMissingSecret := `name: test-app
+docker:
+  expose_port: 8080
+  environment:
+    DATABASE_PASSWORD: "secret://file/` + filepath.Join(tempDir, "does-not-exist") + `"
+ports:
+  blue: 8081
+  green: 8082
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+	configFile := filepath.Join(tempDir, "test-missing-secret.yaml")
+	if err := os.WriteFile(configFile, []byte(yamlWithMissingSecret), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	_, err := LoadAppConfig(configFile)
+	if err == nil {
+		t.Fatal("LoadAppConfig() expected error for missing secret file")
+	}
+	if !strings.Contains(err.Error(), "DATABASE_PASSWORD") {
+		t.Errorf("LoadAppConfig() error = %v, want it to name the offending key", err)
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateLegacySlots(t *testing.T) {
+	config := AppConfig{
+		Ports: Ports{Blue: 8081, Green: 8082},
+	}
+
+	migrateLegacySlots(&config)
+
+	if got := config.Slots["blue"]; got.Port != 8081 || got.Weight != 100 {
+		t.Errorf("Slots[blue] = %+v, want {Port: 8081, Weight: 100}", got)
+	}
+	if got := config.Slots["green"]; got.Port != 8082 || got.Weight != 0 {
+		t.Errorf("Slots[green] = %+v, want {Port: 8082, Weight: 0}", got)
+	}
+}
+
+func TestMigrateLegacySlotsLeavesDeclaredSlotsAlone(t *testing.T) {
+	config := AppConfig{
+		Ports: Ports{Blue: 8081, Green: 8082},
+		Slots: map[string]SlotConfig{
+			"canary": {Port: 8083, Weight: 5},
+		},
+	}
+
+	migrateLegacySlots(&config)
+
+	if len(config.Slots) != 1 {
+		t.Fatalf("migrateLegacySlots() overwrote declared Slots: %+v", config.Slots)
+	}
+	if _, ok := config.Slots["blue"]; ok {
+		t.Errorf("migrateLegacySlots() should not add blue/green when Slots is already declared")
+	}
+}
+
+func TestValidateConfigSlots(t *testing.T) {
+	tests := []struct {
+		name    string
+		slots   map[string]SlotConfig
+		wantErr bool
+	}{
+		{
+			name: "valid slots",
+			slots: map[string]SlotConfig{
+				"blue":   {Port: 8081, Weight: 95},
+				"canary": {Port: 8083, Weight: 5},
+			},
+		},
+		{
+			name: "zero port is invalid",
+			slots: map[string]SlotConfig{
+				"blue": {Port: 0, Weight: 100},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative weight is invalid",
+			slots: map[string]SlotConfig{
+				"blue": {Port: 8081, Weight: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate port is invalid",
+			slots: map[string]SlotConfig{
+				"blue":  {Port: 8081, Weight: 100},
+				"green": {Port: 8081, Weight: 0},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSlots(tt.slots)
+			if tt.wantErr && err == nil {
+				t.Errorf("validateSlots() expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validateSlots() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigEnvironmentOverridesWithCanarySlot(t *testing.T) {
+	config := AppConfig{
+		Name: "test-app",
+		Docker: Docker{
+			ExposePort: 8080,
+		},
+		Ports: Ports{Blue: 8081, Green: 8082},
+		Slots: map[string]SlotConfig{
+			"blue":   {Port: 8081, Weight: 90},
+			"green":  {Port: 8082, Weight: 0},
+			"canary": {Port: 8083, Weight: 10},
+		},
+		HealthCheck: HealthCheck{
+			Retries:          3,
+			SuccessThreshold: 2,
+			ExpectedStatus:   200,
+		},
+	}
+	config.Docker.EnvironmentOverrides = map[string]map[string]string{
+		"canary": {"FEATURE_FLAG": "on"},
+	}
+
+	if err := validateConfig(&config); err != nil {
+		t.Errorf("validateConfig() unexpected error for declared canary slot = %v", err)
+	}
+
+	config.Docker.EnvironmentOverrides = map[string]map[string]string{
+		"purple": {"FEATURE_FLAG": "on"},
+	}
+
+	err := validateConfig(&config)
+	if err == nil {
+		t.Fatal("validateConfig() expected error for undeclared slot")
+	}
+	wantMsg := `environment_overrides: "purple" is not a declared slot`
+	if err.Error() != wantMsg {
+		t.Errorf("validateConfig() error = %q, want %q", err.Error(), wantMsg)
+	}
+}
+
+func TestLoadAppConfigWithCanarySlots(t *testing.T) {
+	tempDir := t.TempDir()
+
+	yamlWithSlots := `name: test-app
+docker:
+  expose_port: 8080
+ports:
+  blue: 8081
+  green: 8082
+slots:
+  blue:
+    port: 8081
+    weight: 90
+  green:
+    port: 8082
+    weight: 0
+  canary:
+    port: 8083
+    weight: 10
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+	configFile := filepath.Join(tempDir, "test-slots.yaml")
+	if err := os.WriteFile(configFile, []byte(yamlWithSlots), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	config, err := LoadAppConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadAppConfig() unexpected error = %v", err)
+	}
+
+	if len(config.Slots) != 3 {
+		t.Fatalf("LoadAppConfig() Slots = %+v, want 3 entries", config.Slots)
+	}
+	if got := config.Slots["canary"]; got.Port != 8083 || got.Weight != 10 {
+		t.Errorf("Slots[canary] = %+v, want {Port: 8083, Weight: 10}", got)
+	}
+}
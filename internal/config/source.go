@@ -0,0 +1,233 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ConfigEvent is one change notification from a ConfigSource's Watch
+// channel: a config named Name was added, updated, or removed. Read(ctx,
+// Name) returns the current bytes for Added/Updated; Removed carries no
+// further data.
+type ConfigEvent struct {
+	Name string
+	Type ConfigEventType
+}
+
+type ConfigEventType string
+
+const (
+	ConfigEventAdded   ConfigEventType = "added"
+	ConfigEventUpdated ConfigEventType = "updated"
+	ConfigEventRemoved ConfigEventType = "removed"
+)
+
+// ConfigSource abstracts where app config YAML documents come from, so
+// LoadAllConfigsFromSource works the same way whether they live on disk, in
+// a KV store, or behind an HTTP endpoint. Name identifies a config within
+// the source (a relative file path for FileConfigSource, a key for a
+// KV-backed one) and is opaque outside the source that produced it.
+type ConfigSource interface {
+	// List returns the name of every config currently available.
+	List(ctx context.Context) ([]string, error)
+
+	// Read returns the raw YAML bytes for name.
+	Read(ctx context.Context, name string) ([]byte, error)
+
+	// Watch streams ConfigEvents for as long as ctx is alive. Sources that
+	// can't watch for changes (most HTTP-backed ones) may return a channel
+	// that's simply never written to.
+	Watch(ctx context.Context) (<-chan ConfigEvent, error)
+}
+
+// configSourceFactory builds a ConfigSource from a parsed DSN. Factories
+// register themselves under their URL scheme via registerConfigSource;
+// FileConfigSource's "file" scheme is always available, other schemes
+// (e.g. "boltdb") only when the build includes their implementation.
+type configSourceFactory func(u *url.URL) (ConfigSource, error)
+
+var configSourceFactories = map[string]configSourceFactory{
+	"file": newFileConfigSourceFromURL,
+}
+
+// registerConfigSource makes NewConfigSource recognize an additional DSN
+// scheme; implementations call this from an init() guarded by their own
+// build tag (see source_boltdb.go).
+func registerConfigSource(scheme string, factory configSourceFactory) {
+	configSourceFactories[scheme] = factory
+}
+
+// NewConfigSource builds the ConfigSource named by dsn, a URL like
+// "file:///etc/dockswap/apps" or "boltdb:///var/lib/dockswap/state.db?prefix=apps/".
+// A bare filesystem path with no scheme is treated as "file://<path>" for
+// convenience.
+func NewConfigSource(dsn string) (ConfigSource, error) {
+	if !strings.Contains(dsn, "://") {
+		dsn = "file://" + dsn
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config source DSN %q: %w", dsn, err)
+	}
+
+	factory, ok := configSourceFactories[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("no config source registered for scheme %q (dsn %q)", u.Scheme, dsn)
+	}
+
+	return factory(u)
+}
+
+// FileConfigSource is the default ConfigSource: every *.yaml/*.yml file
+// under Dir, named by its path relative to Dir. This is the behavior
+// LoadAllConfigs has always had.
+type FileConfigSource struct {
+	Dir string
+}
+
+func newFileConfigSourceFromURL(u *url.URL) (ConfigSource, error) {
+	dir := u.Path
+	if u.Host != "" {
+		// A DSN like "file://./configs" parses with Host="." and Path="/configs";
+		// stitch them back into the relative path the caller intended.
+		dir = filepath.Join(u.Host, dir)
+	}
+	return &FileConfigSource{Dir: dir}, nil
+}
+
+func (s *FileConfigSource) List(ctx context.Context) ([]string, error) {
+	var names []string
+
+	walkErr := filepath.Walk(s.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Dir, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, rel)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, fmt.Errorf("failed to list configs under %s: %w", s.Dir, walkErr)
+	}
+
+	return names, nil
+}
+
+func (s *FileConfigSource) Read(ctx context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// Watch uses fsnotify to report changes to *.yaml/*.yml files directly
+// under Dir - it does not recurse into subdirectories, matching how apps
+// are laid out in practice (one file per app, flat). A rename is reported
+// as Removed rather than Added/Updated, the same way fsnotify itself can't
+// tell a rename-out from a delete without tracking both halves; Watcher's
+// caller re-lists the whole directory on every event anyway, so the new
+// name (if any) is picked up on the next reload regardless.
+func (s *FileConfigSource) Watch(ctx context.Context) (<-chan ConfigEvent, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fsnotify watcher for %s: %w", s.Dir, err)
+	}
+	if err := fsw.Add(s.Dir); err != nil {
+		fsw.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", s.Dir, err)
+	}
+
+	events := make(chan ConfigEvent)
+	go func() {
+		defer close(events)
+		defer fsw.Close()
+
+		// justCreated tracks names whose most recent event was Added, so the
+		// spurious Write fsnotify fires right after a Create for the same
+		// file (confirmed to happen on every os.WriteFile of a new file) can
+		// be swallowed instead of reported as a second, bogus Updated event.
+		justCreated := make(map[string]bool)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case fsEvent, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+				evt, ok := s.classifyEvent(fsEvent)
+				if !ok {
+					continue
+				}
+				if evt.Type == ConfigEventUpdated && justCreated[evt.Name] {
+					delete(justCreated, evt.Name)
+					continue
+				}
+				if evt.Type == ConfigEventAdded {
+					justCreated[evt.Name] = true
+				} else {
+					delete(justCreated, evt.Name)
+				}
+				select {
+				case events <- evt:
+				case <-ctx.Done():
+					return
+				}
+
+			case _, ok := <-fsw.Errors:
+				// An fsnotify error (e.g. the watch being torn down
+				// under us) isn't fatal to the stream - keep watching
+				// rather than silently going deaf on the caller.
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// classifyEvent maps an fsnotify.Event under Dir to a ConfigEvent, filtering
+// out anything that isn't a *.yaml/*.yml file and operations List/Read don't
+// care about (e.g. a chmod).
+func (s *FileConfigSource) classifyEvent(fsEvent fsnotify.Event) (ConfigEvent, bool) {
+	if ext := filepath.Ext(fsEvent.Name); ext != ".yaml" && ext != ".yml" {
+		return ConfigEvent{}, false
+	}
+	rel, err := filepath.Rel(s.Dir, fsEvent.Name)
+	if err != nil {
+		return ConfigEvent{}, false
+	}
+
+	switch {
+	case fsEvent.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		return ConfigEvent{Name: rel, Type: ConfigEventRemoved}, true
+	case fsEvent.Op&fsnotify.Create != 0:
+		return ConfigEvent{Name: rel, Type: ConfigEventAdded}, true
+	case fsEvent.Op&fsnotify.Write != 0:
+		return ConfigEvent{Name: rel, Type: ConfigEventUpdated}, true
+	default:
+		return ConfigEvent{}, false
+	}
+}
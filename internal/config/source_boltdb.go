@@ -0,0 +1,168 @@
+//go:build boltdb
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltConfigBucket is the single bucket app config documents are stored
+// in, keyed by name under Prefix the way Traefik's KV providers namespace
+// keys under a root path.
+const boltConfigBucket = "dockswap_configs"
+
+func init() {
+	registerConfigSource("boltdb", newBoltConfigSourceFromURL)
+}
+
+// BoltConfigSource reads app config YAML documents out of a BoltDB bucket
+// instead of the filesystem, so several dockswap instances can share one
+// config store (e.g. replicated onto shared storage) without a directory of
+// files. Prefix namespaces keys the way a KV-backed Traefik provider would
+// (e.g. "apps/").
+type BoltConfigSource struct {
+	Path   string
+	Prefix string
+}
+
+func newBoltConfigSourceFromURL(u *url.URL) (ConfigSource, error) {
+	return &BoltConfigSource{
+		Path:   u.Path,
+		Prefix: u.Query().Get("prefix"),
+	}, nil
+}
+
+func (s *BoltConfigSource) open() (*bolt.DB, error) {
+	db, err := bolt.Open(s.Path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open boltdb %s: %w", s.Path, err)
+	}
+	return db, nil
+}
+
+func (s *BoltConfigSource) List(ctx context.Context) ([]string, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var names []string
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(boltConfigBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, _ []byte) error {
+			key := string(k)
+			if strings.HasPrefix(key, s.Prefix) {
+				names = append(names, strings.TrimPrefix(key, s.Prefix))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs in %s: %w", s.Path, err)
+	}
+
+	return names, nil
+}
+
+func (s *BoltConfigSource) Read(ctx context.Context, name string) ([]byte, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var data []byte
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(boltConfigBucket))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s does not exist", boltConfigBucket)
+		}
+		value := bucket.Get([]byte(s.Prefix + name))
+		if value == nil {
+			return fmt.Errorf("config %s not found", name)
+		}
+		data = append([]byte(nil), value...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s from %s: %w", name, s.Path, err)
+	}
+
+	return data, nil
+}
+
+// Watch polls BoltDB for added/updated/removed keys every pollInterval,
+// since Bolt has no native change-notification API; this is still a live
+// reload with no file watcher involved, just a cheap periodic diff.
+func (s *BoltConfigSource) Watch(ctx context.Context) (<-chan ConfigEvent, error) {
+	events := make(chan ConfigEvent)
+
+	go func() {
+		defer close(events)
+
+		previous, err := s.snapshot()
+		if err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(boltWatchPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				current, err := s.snapshot()
+				if err != nil {
+					continue
+				}
+				for name, data := range current {
+					old, existed := previous[name]
+					if !existed {
+						events <- ConfigEvent{Name: name, Type: ConfigEventAdded}
+					} else if string(old) != string(data) {
+						events <- ConfigEvent{Name: name, Type: ConfigEventUpdated}
+					}
+				}
+				for name := range previous {
+					if _, stillPresent := current[name]; !stillPresent {
+						events <- ConfigEvent{Name: name, Type: ConfigEventRemoved}
+					}
+				}
+				previous = current
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+const boltWatchPollInterval = 5 * time.Second
+
+func (s *BoltConfigSource) snapshot() (map[string][]byte, error) {
+	names, err := s.List(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string][]byte, len(names))
+	for _, name := range names {
+		data, err := s.Read(context.Background(), name)
+		if err != nil {
+			return nil, err
+		}
+		snapshot[name] = data
+	}
+	return snapshot, nil
+}
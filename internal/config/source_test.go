@@ -0,0 +1,201 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileConfigSourceListAndRead(t *testing.T) {
+	tempDir := t.TempDir()
+
+	yamlContent := `name: app1
+docker:
+  expose_port: 8080
+ports:
+  blue: 8081
+  green: 8082
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+	if err := os.WriteFile(filepath.Join(tempDir, "app1.yaml"), []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("failed to write app1.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("ignore me"), 0644); err != nil {
+		t.Fatalf("failed to write notes.txt: %v", err)
+	}
+
+	source := &FileConfigSource{Dir: tempDir}
+
+	names, err := source.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() unexpected error = %v", err)
+	}
+	if len(names) != 1 || names[0] != "app1.yaml" {
+		t.Errorf("List() = %v, want [app1.yaml]", names)
+	}
+
+	data, err := source.Read(context.Background(), "app1.yaml")
+	if err != nil {
+		t.Fatalf("Read() unexpected error = %v", err)
+	}
+	if string(data) != yamlContent {
+		t.Errorf("Read() = %q, want %q", data, yamlContent)
+	}
+
+	if _, err := source.Read(context.Background(), "missing.yaml"); err == nil {
+		t.Errorf("Read() expected error for missing config")
+	}
+}
+
+func TestFileConfigSourceWatchClosesOnCancel(t *testing.T) {
+	source := &FileConfigSource{Dir: t.TempDir()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := source.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() unexpected error = %v", err)
+	}
+
+	cancel()
+
+	if _, ok := <-events; ok {
+		t.Errorf("Watch() channel should close once ctx is canceled")
+	}
+}
+
+func TestFileConfigSourceWatchReportsChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	source := &FileConfigSource{Dir: tempDir}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := source.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() unexpected error = %v", err)
+	}
+
+	waitFor := func(wantType ConfigEventType) {
+		t.Helper()
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				t.Fatalf("events channel closed before seeing %s", wantType)
+			}
+			if evt.Name != "app1.yaml" {
+				t.Errorf("event name = %q, want app1.yaml", evt.Name)
+			}
+			if evt.Type != wantType {
+				t.Errorf("event type = %q, want %q", evt.Type, wantType)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for a %s event", wantType)
+		}
+	}
+
+	path := filepath.Join(tempDir, "app1.yaml")
+	if err := os.WriteFile(path, []byte("name: app1"), 0644); err != nil {
+		t.Fatalf("failed to write app1.yaml: %v", err)
+	}
+	waitFor(ConfigEventAdded)
+
+	if err := os.WriteFile(path, []byte("name: app1\ndescription: updated"), 0644); err != nil {
+		t.Fatalf("failed to rewrite app1.yaml: %v", err)
+	}
+	waitFor(ConfigEventUpdated)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove app1.yaml: %v", err)
+	}
+	waitFor(ConfigEventRemoved)
+}
+
+func TestFileConfigSourceWatchIgnoresNonYAML(t *testing.T) {
+	tempDir := t.TempDir()
+	source := &FileConfigSource{Dir: tempDir}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := source.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() unexpected error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("ignore me"), 0644); err != nil {
+		t.Fatalf("failed to write notes.txt: %v", err)
+	}
+
+	select {
+	case evt := <-events:
+		t.Errorf("expected no event for a non-YAML file, got %+v", evt)
+	case <-time.After(200 * time.Millisecond):
+		// No event, as expected.
+	}
+}
+
+func TestNewConfigSource(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Run("bare path defaults to file scheme", func(t *testing.T) {
+		source, err := NewConfigSource(tempDir)
+		if err != nil {
+			t.Fatalf("NewConfigSource() unexpected error = %v", err)
+		}
+		if _, ok := source.(*FileConfigSource); !ok {
+			t.Errorf("NewConfigSource(%q) = %T, want *FileConfigSource", tempDir, source)
+		}
+	})
+
+	t.Run("file scheme DSN", func(t *testing.T) {
+		source, err := NewConfigSource("file://" + tempDir)
+		if err != nil {
+			t.Fatalf("NewConfigSource() unexpected error = %v", err)
+		}
+		fileSource, ok := source.(*FileConfigSource)
+		if !ok {
+			t.Fatalf("NewConfigSource() = %T, want *FileConfigSource", source)
+		}
+		if fileSource.Dir != tempDir {
+			t.Errorf("FileConfigSource.Dir = %q, want %q", fileSource.Dir, tempDir)
+		}
+	})
+
+	t.Run("unregistered scheme", func(t *testing.T) {
+		if _, err := NewConfigSource("boltdb:///var/lib/dockswap/state.db"); err == nil {
+			t.Errorf("NewConfigSource() expected error for unregistered scheme in this build")
+		}
+	})
+}
+
+func TestLoadAllConfigsFromSource(t *testing.T) {
+	tempDir := t.TempDir()
+
+	validYAML := `name: good-app
+docker:
+  expose_port: 8080
+ports:
+  blue: 8081
+  green: 8082
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+	if err := os.WriteFile(filepath.Join(tempDir, "good.yaml"), []byte(validYAML), 0644); err != nil {
+		t.Fatalf("failed to write good.yaml: %v", err)
+	}
+
+	configs, err := LoadAllConfigsFromSource(context.Background(), &FileConfigSource{Dir: tempDir})
+	if err != nil {
+		t.Fatalf("LoadAllConfigsFromSource() unexpected error = %v", err)
+	}
+	if _, ok := configs["good-app"]; !ok {
+		t.Errorf("LoadAllConfigsFromSource() missing good-app, got %v", configs)
+	}
+}
@@ -6,9 +6,17 @@ import (
 	"path/filepath"
 )
 
+// TLSDir returns the subdirectory of configDir holding client TLS material
+// (ca.pem, cert.pem, key.pem) for apps whose docker.docker_host points at a
+// "tcp://" daemon - the same three-file convention the Docker CLI itself
+// uses under ~/.docker, just rooted at the dockswap config dir instead.
+func TLSDir(configDir string) string {
+	return filepath.Join(configDir, "tls")
+}
+
 // ValidateAndPrepareConfigDir ensures required folders exist and all app configs are valid.
 func ValidateAndPrepareConfigDir(configDir string) error {
-	required := []string{"apps", "state", "caddy"}
+	required := []string{"apps", "state", "caddy", "tls"}
 	for _, sub := range required {
 		dir := filepath.Join(configDir, sub)
 		if err := os.MkdirAll(dir, 0755); err != nil {
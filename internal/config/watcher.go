@@ -0,0 +1,59 @@
+package config
+
+import (
+	"context"
+	"time"
+)
+
+// Watcher turns a ConfigSource's raw ConfigEvent stream into debounced,
+// fully-loaded-and-validated config snapshots. A burst of events from a
+// single save (e.g. an editor's write-then-rename) collapses into one
+// reload instead of firing once per underlying filesystem event.
+type Watcher struct {
+	source ConfigSource
+	// Debounce is how long Run waits after the last event in a burst before
+	// re-loading. Defaults to 250ms, long enough to absorb a typical
+	// editor's save sequence without making a reload feel sluggish.
+	Debounce time.Duration
+}
+
+// NewWatcher builds a Watcher over source with the default Debounce.
+func NewWatcher(source ConfigSource) *Watcher {
+	return &Watcher{source: source, Debounce: 250 * time.Millisecond}
+}
+
+// Run subscribes to source.Watch and, after each burst of change events
+// settles, re-runs LoadAllConfigsFromSource and passes the result to
+// onReload. It blocks until ctx is canceled or the underlying event channel
+// closes, so callers should run it in its own goroutine. A failed reload
+// (onReload's err) doesn't stop the watch - the next event still triggers
+// another attempt - since a transient bad edit shouldn't end hot-reloading
+// for the rest of the process's life.
+func (w *Watcher) Run(ctx context.Context, onReload func(map[string]*AppConfig, error)) error {
+	events, err := w.source.Watch(ctx)
+	if err != nil {
+		return err
+	}
+
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = 250 * time.Millisecond
+	}
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+			pending = time.After(debounce)
+		case <-pending:
+			pending = nil
+			configs, err := LoadAllConfigsFromSource(ctx, w.source)
+			onReload(configs, err)
+		}
+	}
+}
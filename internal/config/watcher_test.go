@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherDebouncesBurstsIntoOneReload(t *testing.T) {
+	tempDir := t.TempDir()
+	yamlContent := `name: app1
+docker:
+  expose_port: 8080
+ports:
+  blue: 8081
+  green: 8082
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+	path := filepath.Join(tempDir, "app1.yaml")
+	if err := os.WriteFile(path, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("failed to write app1.yaml: %v", err)
+	}
+
+	w := NewWatcher(&FileConfigSource{Dir: tempDir})
+	w.Debounce = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloads := make(chan map[string]*AppConfig, 10)
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx, func(configs map[string]*AppConfig, err error) {
+			reloads <- configs
+			errs <- err
+		})
+	}()
+
+	// Write several times in quick succession; Debounce should collapse
+	// these into a single reload rather than one per write.
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(path, []byte(yamlContent), 0644); err != nil {
+			t.Fatalf("failed to rewrite app1.yaml: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case configs := <-reloads:
+		if err := <-errs; err != nil {
+			t.Fatalf("reload returned error: %v", err)
+		}
+		if _, ok := configs["app1"]; !ok {
+			t.Errorf("reload configs = %v, want app1 present", configs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for debounced reload")
+	}
+
+	select {
+	case <-reloads:
+		t.Error("expected the burst of writes to collapse into a single reload")
+	case <-time.After(300 * time.Millisecond):
+		// No second reload, as expected.
+	}
+
+	cancel()
+	<-done
+}
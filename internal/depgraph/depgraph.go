@@ -0,0 +1,89 @@
+// Package depgraph orders a set of apps by their depends_on declarations
+// (config.AppConfig.DependsOn) into topological levels, so a multi-app
+// rollout (dockswap deploy-group) can deploy independent apps in parallel
+// while still deploying each app only after everything it depends on has
+// already gone out - e.g. an "api" app before the "web" app that depends on
+// it.
+package depgraph
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Sentinel errors identifying why Build rejected a dependency set.
+var (
+	ErrUnknownDependency = errors.New("depends on an app not in the graph")
+	ErrCyclicDependency  = errors.New("dependency cycle")
+)
+
+// Graph is a topologically-ordered dependency graph: Levels[0] has no
+// dependencies, Levels[1] depends only on apps in Levels[0], and so on.
+// Apps within a level have no dependency relationship to each other and can
+// deploy in parallel.
+type Graph struct {
+	Levels [][]string
+}
+
+// Build constructs a Graph from deps, a map of app name to the names of the
+// apps it depends on (config.AppConfig.DependsOn). It returns
+// ErrUnknownDependency if an app names a dependency absent from deps, or
+// ErrCyclicDependency if the dependencies don't form a DAG.
+func Build(deps map[string][]string) (*Graph, error) {
+	for app, dependsOn := range deps {
+		for _, dep := range dependsOn {
+			if _, ok := deps[dep]; !ok {
+				return nil, fmt.Errorf("%s depends on %s: %w", app, dep, ErrUnknownDependency)
+			}
+		}
+	}
+
+	remaining := make(map[string][]string, len(deps))
+	for app, dependsOn := range deps {
+		remaining[app] = dependsOn
+	}
+
+	var levels [][]string
+	for len(remaining) > 0 {
+		var level []string
+		for app, dependsOn := range remaining {
+			if allSatisfied(dependsOn, remaining) {
+				level = append(level, app)
+			}
+		}
+		if len(level) == 0 {
+			return nil, fmt.Errorf("%s: %w", cycleMembers(remaining), ErrCyclicDependency)
+		}
+
+		sort.Strings(level)
+		levels = append(levels, level)
+		for _, app := range level {
+			delete(remaining, app)
+		}
+	}
+
+	return &Graph{Levels: levels}, nil
+}
+
+// allSatisfied reports whether none of dependsOn is still in remaining -
+// i.e. every dependency has already been placed in an earlier level.
+func allSatisfied(dependsOn []string, remaining map[string][]string) bool {
+	for _, dep := range dependsOn {
+		if _, ok := remaining[dep]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// cycleMembers names the apps still stuck in remaining once no further
+// level can be peeled off, for a readable error message.
+func cycleMembers(remaining map[string][]string) string {
+	names := make([]string, 0, len(remaining))
+	for app := range remaining {
+		names = append(names, app)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%v", names)
+}
@@ -0,0 +1,85 @@
+package depgraph
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuild_Levels(t *testing.T) {
+	deps := map[string][]string{
+		"api":  nil,
+		"db":   nil,
+		"web":  {"api"},
+		"jobs": {"api", "db"},
+	}
+
+	graph, err := Build(deps)
+	if err != nil {
+		t.Fatalf("Build() unexpected error = %v", err)
+	}
+
+	if len(graph.Levels) != 2 {
+		t.Fatalf("Levels = %v, want 2 levels", graph.Levels)
+	}
+	if got, want := graph.Levels[0], []string{"api", "db"}; !equal(got, want) {
+		t.Errorf("Levels[0] = %v, want %v", got, want)
+	}
+	if got, want := graph.Levels[1], []string{"jobs", "web"}; !equal(got, want) {
+		t.Errorf("Levels[1] = %v, want %v", got, want)
+	}
+}
+
+func TestBuild_NoDependencies(t *testing.T) {
+	deps := map[string][]string{"a": nil, "b": nil}
+
+	graph, err := Build(deps)
+	if err != nil {
+		t.Fatalf("Build() unexpected error = %v", err)
+	}
+	if len(graph.Levels) != 1 || !equal(graph.Levels[0], []string{"a", "b"}) {
+		t.Errorf("Levels = %v, want single level [a b]", graph.Levels)
+	}
+}
+
+func TestBuild_UnknownDependency(t *testing.T) {
+	deps := map[string][]string{"web": {"api"}}
+
+	_, err := Build(deps)
+	if !errors.Is(err, ErrUnknownDependency) {
+		t.Fatalf("Build() error = %v, want ErrUnknownDependency", err)
+	}
+}
+
+func TestBuild_Cycle(t *testing.T) {
+	deps := map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"a"},
+	}
+
+	_, err := Build(deps)
+	if !errors.Is(err, ErrCyclicDependency) {
+		t.Fatalf("Build() error = %v, want ErrCyclicDependency", err)
+	}
+}
+
+func TestBuild_SelfDependency(t *testing.T) {
+	deps := map[string][]string{"a": {"a"}}
+
+	_, err := Build(deps)
+	if !errors.Is(err, ErrCyclicDependency) {
+		t.Fatalf("Build() error = %v, want ErrCyclicDependency", err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package deployment
+
+import (
+	"testing"
+
+	"dockswap/internal/state"
+)
+
+// fuzzEvents lists every DeploymentEvent ProcessEvent dispatches on, so the
+// fuzzer can reach combinations TestInvalidStateTransitions and friends
+// don't bother enumerating by hand. EventDeploy is deliberately excluded -
+// it's only ever valid coming out of Deploy() (which also wires up
+// targetColor/deploymentID), so it gets its own fuzz action below instead
+// of being fed to ProcessEvent directly.
+var fuzzEvents = []DeploymentEvent{
+	EventContainerStarted, EventContainerFailed,
+	EventHealthCheckPassed, EventHealthCheckFailed,
+	EventCaddyUpdated, EventCaddyFailed,
+	EventDrainComplete, EventContainerStopped, EventStopFailed,
+	EventRollbackComplete, EventRollbackFailed, EventManualRecovery,
+	EventActiveContainerDied, EventInterrupted, EventResumed,
+	EventCanaryStepPassed, EventCanaryStepFailed,
+}
+
+var fuzzKnownStates = map[DeploymentState]bool{
+	StateStable: true, StateStarting: true, StateHealthCheck: true,
+	StateCanary: true, StateCanaryPromoting: true, StateSwitching: true,
+	StateDraining: true, StateStopping: true, StateRollingBack: true,
+	StateFailed: true,
+}
+
+// FuzzDeploymentStateMachine drives a DeploymentStateMachine through random
+// sequences of Deploy()/ProcessEvent() calls, checking invariants that
+// should hold no matter what path through the state graph got there. Go's
+// own fuzzing engine already minimizes any failing input down to the
+// shortest byte sequence that still reproduces the failure (rerun with
+// `go test -run=FuzzDeploymentStateMachine/<testdata-file>` to replay one),
+// so there's no hand-rolled shrinker here - that would just be reimplementing
+// what `go test -fuzz` does for every corpus entry it saves.
+func FuzzDeploymentStateMachine(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6})
+	f.Add([]byte{255, 0, 0, 0, 255})
+	f.Add([]byte{})
+	f.Add([]byte{10, 11, 12, 13, 14, 0, 1, 2})
+	f.Add([]byte{3, 3, 3, 255, 3, 3})
+
+	f.Fuzz(func(t *testing.T, seq []byte) {
+		db := openResumeTestDB(t)
+		actions := &MockActionProvider{}
+		sm := New("fuzz-app", "blue", actions, db)
+
+		originalColor := sm.activeColor
+		inactiveColor := sm.getInactiveColor()
+
+		eventsPersisted := make(map[int64]int)
+
+		for _, b := range seq {
+			_, histBefore := sm.deploymentID, len(sm.stateHistory)
+
+			// 255 is reserved for "try to Deploy()"; every other byte picks
+			// a fuzzEvents entry to feed straight to ProcessEvent.
+			wasStable := sm.state == StateStable
+			if b == 255 {
+				if err := sm.Deploy("nginx:fuzz"); !wasStable && err == nil {
+					t.Fatalf("Deploy() succeeded outside StateStable (state=%s)", sm.state)
+				}
+			} else {
+				event := fuzzEvents[int(b)%len(fuzzEvents)]
+				_ = sm.ProcessEvent(event)
+			}
+
+			if !fuzzKnownStates[sm.state] {
+				t.Fatalf("machine reached undefined state %q", sm.state)
+			}
+
+			inProgress, canDeploy, needsRecovery := sm.IsInProgress(), sm.CanDeploy(), sm.NeedsManualIntervention()
+			trueCount := 0
+			for _, v := range []bool{inProgress, canDeploy, needsRecovery} {
+				if v {
+					trueCount++
+				}
+			}
+			if trueCount != 1 {
+				t.Fatalf("IsInProgress()=%v CanDeploy()=%v NeedsManualIntervention()=%v not mutually exclusive in state %s",
+					inProgress, canDeploy, needsRecovery, sm.state)
+			}
+
+			idAfter, histAfter := sm.deploymentID, len(sm.stateHistory)
+			if n := histAfter - histBefore; n > 0 && idAfter != 0 {
+				eventsPersisted[idAfter] += n
+			}
+		}
+
+		if sm.state == StateStable {
+			if sm.activeColor != originalColor && sm.activeColor != inactiveColor {
+				t.Fatalf("activeColor = %q, want %q or %q", sm.activeColor, originalColor, inactiveColor)
+			}
+		}
+
+		for depID, want := range eventsPersisted {
+			rows, err := state.GetDeploymentEvents(db, depID)
+			if err != nil {
+				t.Fatalf("GetDeploymentEvents(%d): %v", depID, err)
+			}
+			if len(rows) != want {
+				t.Fatalf("deployment %d: %d deployment_events rows, want %d (one per recorded StateTransition)", depID, len(rows), want)
+			}
+		}
+	})
+}
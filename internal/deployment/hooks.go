@@ -0,0 +1,239 @@
+package deployment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dockswap/internal/state"
+)
+
+// HookMode selects whether runHookPhase waits for a Hook to finish before
+// moving on (HookModeBlocking, the default) or fires it and keeps going
+// immediately (HookModeBackground), mirroring OCI runtime hooks' own
+// distinction between a prestart hook that can veto a container start and a
+// poststart hook that merely observes it.
+type HookMode string
+
+const (
+	HookModeBlocking   HookMode = "blocking"
+	HookModeBackground HookMode = "background"
+)
+
+// Hook is one lifecycle command an operator wants run around a deployment -
+// a DB migration before the new container starts, a cache warm after it
+// passes health checks, a Slack notification on rollback. It's deliberately
+// a plain data struct rather than config.HookCommand so this package stays
+// decoupled from config, the same way SetCanarySteps takes a []int instead
+// of a config.CanaryPlan. Exactly one of Command or URL is set: Command runs
+// as a host process (see hooks.ExecRunner), URL instead POSTs a JSON payload
+// to it (see hooks.WebhookRunner) - the same exec-vs-HTTP split
+// HealthChecker draws between its exec and HTTP probes.
+type Hook struct {
+	Command string
+	Args    []string
+	URL     string
+	Timeout time.Duration
+	Env     map[string]string
+	Mode    HookMode
+}
+
+// Hooks groups every lifecycle phase a DeploymentStateMachine invokes hooks
+// at. Each phase's hooks run in order; see runHookPhase for exactly when and
+// how a phase's outcome affects the deployment. PreDrain runs right before
+// DrainConnections, once Caddy has already cut over to the new color -
+// log-and-continue like PostSwitch, since there's no sensible way to veto a
+// drain of traffic that's already been rerouted.
+type Hooks struct {
+	PreDeploy  []Hook
+	PostHealth []Hook
+	PreSwitch  []Hook
+	PostSwitch []Hook
+	PreDrain   []Hook
+	OnFailure  []Hook
+	OnRollback []Hook
+}
+
+// HookRunner executes a single Hook, giving tests a way to stub lifecycle
+// hook execution instead of actually shelling out. Run blocks for at most
+// ctx's deadline and returns the command's captured stdout/stderr alongside
+// an error wrapping a non-zero exit code or a timeout.
+type HookRunner interface {
+	Run(ctx context.Context, hook Hook) (stdout, stderr string, err error)
+}
+
+// HookResult is one Hook's outcome, captured so recordTransition can fold it
+// into the deployment_events payload alongside the transition it gated (for
+// a blocking hook) or insert as its own event row (for a background or
+// on_failure hook, which has no single transition to ride along with).
+type HookResult struct {
+	Phase   string
+	Index   int
+	Command string
+	Args    []string
+	Stdout  string
+	Stderr  string
+	Error   string
+}
+
+// SetHooks registers the lifecycle hooks this deployment's transitions
+// invoke. Pass the zero value (the default) to run no hooks at all.
+func (sm *DeploymentStateMachine) SetHooks(hooks Hooks) {
+	sm.hooks = hooks
+}
+
+// SetHookRunner registers the HookRunner every configured Hook is executed
+// through. A phase with hooks configured but no HookRunner set fails
+// immediately, the same way calling UpdateCaddy with no caddy manager
+// configured does - a pre_deploy/pre_switch/on_rollback hook that never
+// actually ran can't be trusted to have vetoed anything.
+func (sm *DeploymentStateMachine) SetHookRunner(runner HookRunner) {
+	sm.hookRunner = runner
+}
+
+// runHookPhase runs every Hook in list in order, tagging each with its phase
+// label for the HookResult it records. Hooks with HookModeBackground are
+// fired via runBackgroundHook and never block this call or influence its
+// return value. A blocking hook's non-zero exit stops the phase immediately
+// (later hooks in list don't run) and is returned to the caller, which
+// decides what that means for the deployment - see Deploy, CompleteHealthCheck,
+// CompleteCaddyUpdate, and CompleteCanaryStep.
+func (sm *DeploymentStateMachine) runHookPhase(phase string, list []Hook) error {
+	sm.lastHookFailure = ""
+
+	for i, hook := range list {
+		if hook.Mode == HookModeBackground {
+			sm.runBackgroundHook(phase, i, hook)
+			continue
+		}
+
+		stdout, stderr, err := sm.execHook(hook)
+		sm.lastHookOutputs = append(sm.lastHookOutputs, HookResult{
+			Phase: phase, Index: i, Command: hookIdentity(hook), Args: hook.Args,
+			Stdout: stdout, Stderr: stderr, Error: errString(err),
+		})
+		if err != nil {
+			sm.lastHookFailure = fmt.Sprintf("%s hook %q: %v", phase, hookIdentity(hook), err)
+			return fmt.Errorf("%s hook %q: %w", phase, hookIdentity(hook), err)
+		}
+	}
+
+	return nil
+}
+
+// hookIdentity labels hook for logs/HookResult/deployment_events: its
+// Command, or its URL for a webhook hook (Command is empty for those) -
+// otherwise a failing webhook hook's identity is lost since the only other
+// field callers read is the empty Command.
+func hookIdentity(hook Hook) string {
+	if hook.Command != "" {
+		return hook.Command
+	}
+	return hook.URL
+}
+
+// execHook runs hook through sm.hookRunner with a context bounded by
+// hook.Timeout (unbounded when zero).
+func (sm *DeploymentStateMachine) execHook(hook Hook) (stdout, stderr string, err error) {
+	if sm.hookRunner == nil {
+		return "", "", fmt.Errorf("no HookRunner configured to run hook %q", hookIdentity(hook))
+	}
+
+	ctx := context.Background()
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+
+	return sm.hookRunner.Run(ctx, hook)
+}
+
+// runBackgroundHook executes hook without blocking the caller, persisting
+// its outcome as its own "hook:<phase>" deployment_events row (rather than
+// folding it into sm.lastHookOutputs, which only the next synchronous
+// recordTransition drains) since a background hook may well still be
+// running by the time the triggering transition is recorded.
+func (sm *DeploymentStateMachine) runBackgroundHook(phase string, index int, hook Hook) {
+	appName, deploymentID, db, runner := sm.appName, sm.deploymentID, sm.db, sm.hookRunner
+
+	go func() {
+		stdout, stderr, err := runHookWith(runner, hook)
+		result := HookResult{
+			Phase: phase, Index: index, Command: hookIdentity(hook), Args: hook.Args,
+			Stdout: stdout, Stderr: stderr, Error: errString(err),
+		}
+		persistHookResult(db, appName, deploymentID, result)
+	}()
+}
+
+// fireOnFailureHooks runs hooks.OnFailure in the background once a
+// deployment has settled into StateFailed, for an operator's Slack/pager
+// notification hook - it never influences the transition that already
+// landed the machine in StateFailed, only reports it happened.
+func (sm *DeploymentStateMachine) fireOnFailureHooks() {
+	for i, hook := range sm.hooks.OnFailure {
+		sm.runBackgroundHook("on_failure", i, hook)
+	}
+}
+
+// runHookWith is the free-function core of execHook, reusable from
+// runBackgroundHook's goroutine without a *DeploymentStateMachine receiver
+// (the machine itself may have moved on by the time a background hook
+// finishes).
+func runHookWith(runner HookRunner, hook Hook) (stdout, stderr string, err error) {
+	if runner == nil {
+		return "", "", fmt.Errorf("no HookRunner configured to run hook %q", hookIdentity(hook))
+	}
+
+	ctx := context.Background()
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+
+	return runner.Run(ctx, hook)
+}
+
+// persistHookResult inserts result as its own deployment_events row, best
+// effort - there's no caller left to hand a logging failure back to once
+// the triggering goroutine has nothing further to do.
+func persistHookResult(db *sql.DB, appName string, deploymentID int64, result HookResult) {
+	if db == nil || deploymentID == 0 {
+		return
+	}
+	var errMsg *string
+	if result.Error != "" {
+		errMsg = &result.Error
+	}
+	_, _ = state.InsertDeploymentEvent(db, deploymentID, appName, fmt.Sprintf("hook:%s", result.Phase), hookPayload([]HookResult{result}), errMsg)
+}
+
+// errString returns err.Error(), or "" for a nil err, so HookResult.Error
+// stays the empty string (and json-omits cleanly enough) when a hook
+// succeeded.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// hookPayload marshals results for the deployment_events payload column,
+// falling back to "{}" (recordTransition's existing placeholder) on an
+// empty or unmarshalable result set.
+func hookPayload(results []HookResult) string {
+	if len(results) == 0 {
+		return "{}"
+	}
+	data, err := json.Marshal(struct {
+		Hooks []HookResult `json:"hooks"`
+	}{Hooks: results})
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,171 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// StubHookRunner lets tests control a Hook's outcome by its Command,
+// without actually shelling out.
+type StubHookRunner struct {
+	results map[string]error
+	calls   []Hook
+}
+
+func (r *StubHookRunner) Run(ctx context.Context, hook Hook) (string, string, error) {
+	r.calls = append(r.calls, hook)
+	return "stdout-for-" + hook.Command, "", r.results[hook.Command]
+}
+
+func TestPreDeployHookFailureForcesContainerFailed(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetHookRunner(&StubHookRunner{results: map[string]error{"migrate": errors.New("exit 1")}})
+	sm.SetHooks(Hooks{PreDeploy: []Hook{{Command: "migrate"}}})
+
+	err := sm.Deploy("nginx:1.22")
+	if err == nil {
+		t.Fatal("Deploy() expected an error from the failed pre_deploy hook, got nil")
+	}
+	if sm.GetState() != StateFailed {
+		t.Errorf("GetState() = %v, want %v", sm.GetState(), StateFailed)
+	}
+	if len(actions.startContainerCalls) != 0 {
+		t.Errorf("StartContainer called %d times, want 0 (pre_deploy hook should have vetoed it)", len(actions.startContainerCalls))
+	}
+}
+
+func TestPreDeployHookSuccessLetsDeployProceed(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	runner := &StubHookRunner{}
+	sm.SetHookRunner(runner)
+	sm.SetHooks(Hooks{PreDeploy: []Hook{{Command: "migrate"}}})
+
+	if err := sm.Deploy("nginx:1.22"); err != nil {
+		t.Fatalf("Deploy() failed: %v", err)
+	}
+	if sm.GetState() != StateStarting {
+		t.Errorf("GetState() = %v, want %v", sm.GetState(), StateStarting)
+	}
+	if len(actions.startContainerCalls) != 1 {
+		t.Errorf("StartContainer called %d times, want 1", len(actions.startContainerCalls))
+	}
+	if len(runner.calls) != 1 || runner.calls[0].Command != "migrate" {
+		t.Errorf("hook runner calls = %v, want one call to migrate", runner.calls)
+	}
+}
+
+func TestPreSwitchHookFailureForcesCaddyFailed(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetHookRunner(&StubHookRunner{results: map[string]error{"warm-cache": errors.New("exit 1")}})
+	sm.SetHooks(Hooks{PreSwitch: []Hook{{Command: "warm-cache"}}})
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+
+	sm.CompleteHealthCheck(true, "")
+	if sm.GetState() != StateRollingBack {
+		t.Errorf("GetState() = %v, want %v", sm.GetState(), StateRollingBack)
+	}
+	if len(actions.updateCaddyCalls) != 0 {
+		t.Errorf("UpdateCaddy called %d times, want 0 (pre_switch hook should have vetoed it)", len(actions.updateCaddyCalls))
+	}
+	if len(actions.stopContainerCalls) != 1 {
+		t.Errorf("StopContainer called %d times, want 1 (target container torn down same as a real caddy failure)", len(actions.stopContainerCalls))
+	}
+}
+
+func TestPreDrainHookRunsBeforeDrainAndCannotVetoIt(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	runner := &StubHookRunner{results: map[string]error{"notify-drain": errors.New("exit 1")}}
+	sm.SetHookRunner(runner)
+	sm.SetHooks(Hooks{PreDrain: []Hook{{Command: "notify-drain"}}})
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+	sm.CompleteHealthCheck(true, "")
+	if err := sm.CompleteCaddyUpdate(true); err != nil {
+		t.Fatalf("CompleteCaddyUpdate() failed: %v", err)
+	}
+
+	if sm.GetState() != StateDraining {
+		t.Errorf("GetState() = %v, want %v (pre_drain failing shouldn't veto a cutover that's already live)", sm.GetState(), StateDraining)
+	}
+	if len(actions.drainConnectionsCalls) != 1 {
+		t.Errorf("DrainConnections called %d times, want 1", len(actions.drainConnectionsCalls))
+	}
+	if len(runner.calls) != 1 || runner.calls[0].Command != "notify-drain" {
+		t.Errorf("hook runner calls = %v, want one call to notify-drain", runner.calls)
+	}
+}
+
+func TestPostHealthHookFailureFailsHealthCheck(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetHookRunner(&StubHookRunner{results: map[string]error{"smoke-test": errors.New("exit 1")}})
+	sm.SetHooks(Hooks{PostHealth: []Hook{{Command: "smoke-test"}}})
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+
+	sm.CompleteHealthCheck(true, "")
+	if sm.GetState() != StateRollingBack {
+		t.Errorf("GetState() = %v, want %v", sm.GetState(), StateRollingBack)
+	}
+	if len(actions.updateCaddyCalls) != 0 {
+		t.Errorf("UpdateCaddy called %d times, want 0", len(actions.updateCaddyCalls))
+	}
+}
+
+func TestOnRollbackHookFailureForcesRollbackFailed(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetHookRunner(&StubHookRunner{results: map[string]error{"page-oncall": errors.New("exit 1")}})
+	sm.SetHooks(Hooks{OnRollback: []Hook{{Command: "page-oncall"}}})
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+
+	err := sm.CompleteHealthCheck(false, "unhealthy")
+	if err == nil {
+		t.Fatal("CompleteHealthCheck() expected an error from the failed on_rollback hook, got nil")
+	}
+	if sm.GetState() != StateFailed {
+		t.Errorf("GetState() = %v, want %v (on_rollback hook should have vetoed the rollback itself)", sm.GetState(), StateFailed)
+	}
+	if len(actions.stopContainerCalls) != 0 {
+		t.Errorf("StopContainer called %d times, want 0", len(actions.stopContainerCalls))
+	}
+}
+
+func TestHookPhaseWithNoRunnerConfiguredFails(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetHooks(Hooks{PreDeploy: []Hook{{Command: "migrate"}}})
+
+	err := sm.Deploy("nginx:1.22")
+	if err == nil {
+		t.Fatal("Deploy() expected an error when hooks are configured but no HookRunner is set")
+	}
+	if sm.GetState() != StateFailed {
+		t.Errorf("GetState() = %v, want %v", sm.GetState(), StateFailed)
+	}
+}
+
+func TestBackgroundHookDoesNotBlockOrVetoDeploy(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetHookRunner(&StubHookRunner{results: map[string]error{"notify-slack": errors.New("webhook unreachable")}})
+	sm.SetHooks(Hooks{PreDeploy: []Hook{{Command: "notify-slack", Mode: HookModeBackground}}})
+
+	if err := sm.Deploy("nginx:1.22"); err != nil {
+		t.Fatalf("Deploy() failed: %v", err)
+	}
+	if sm.GetState() != StateStarting {
+		t.Errorf("GetState() = %v, want %v (a background hook's failure shouldn't veto the deploy)", sm.GetState(), StateStarting)
+	}
+}
@@ -0,0 +1,219 @@
+package deployment
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"dockswap/internal/state"
+)
+
+func openResumeTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := state.OpenAndMigrate(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// seedDeployment writes an app_configs + deployments + current_state +
+// deployment_events row the way a real deployment in progress would have,
+// landing current_state.status in status and previousColor as the
+// still-active color a Resume call should find.
+func seedDeployment(t *testing.T, db *sql.DB, appName, previousColor, targetColor, image, status string) int64 {
+	t.Helper()
+
+	cfgID, err := state.InsertAppConfig(db, appName, "name: "+appName, "sha-"+appName)
+	if err != nil {
+		t.Fatalf("insert app config: %v", err)
+	}
+
+	depID, err := state.InsertDeployment(db, appName, cfgID, image, "deploying", targetColor, nil)
+	if err != nil {
+		t.Fatalf("insert deployment: %v", err)
+	}
+
+	if err := state.UpsertCurrentState(db, appName, depID, previousColor, image, status); err != nil {
+		t.Fatalf("upsert current state: %v", err)
+	}
+
+	if _, err := state.InsertDeploymentEvent(db, depID, appName, "deploy", "{}", nil); err != nil {
+		t.Fatalf("insert deployment event: %v", err)
+	}
+
+	return depID
+}
+
+func TestResume_NoPriorDeployment(t *testing.T) {
+	db := openResumeTestDB(t)
+
+	sm, err := Resume(db, "never-deployed", &MockActionProvider{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sm != nil {
+		t.Fatalf("expected nil state machine for an app with no deployment history, got %+v", sm)
+	}
+}
+
+func TestResume_StableRequiresNothing(t *testing.T) {
+	db := openResumeTestDB(t)
+	seedDeployment(t, db, "myapp", "blue", "green", "myapp:v2", string(StateStable))
+
+	sm, err := Resume(db, "myapp", &MockActionProvider{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sm != nil {
+		t.Fatalf("expected nil state machine for a settled deployment, got %+v", sm)
+	}
+}
+
+func TestResume_InterruptedForcesFailed(t *testing.T) {
+	db := openResumeTestDB(t)
+	seedDeployment(t, db, "myapp", "blue", "green", "myapp:v2", "interrupted")
+
+	sm, err := Resume(db, "myapp", &MockActionProvider{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sm == nil {
+		t.Fatal("expected a state machine to resume into")
+	}
+	if sm.GetState() != StateFailed {
+		t.Errorf("expected StateFailed, got %s", sm.GetState())
+	}
+}
+
+func TestResume_HealthCheckIsReissuable(t *testing.T) {
+	db := openResumeTestDB(t)
+	seedDeployment(t, db, "myapp", "blue", "green", "myapp:v2", string(StateHealthCheck))
+
+	mock := &MockActionProvider{}
+	sm, err := Resume(db, "myapp", mock)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sm == nil {
+		t.Fatal("expected a state machine to resume into")
+	}
+	if sm.GetState() != StateHealthCheck {
+		t.Errorf("expected StateHealthCheck to survive resume, got %s", sm.GetState())
+	}
+	if sm.GetTargetColor() != "green" {
+		t.Errorf("expected target color green, got %s", sm.GetTargetColor())
+	}
+	if len(mock.checkHealthCalls) != 0 {
+		t.Errorf("expected Resume to leave re-polling to the deployment loop, got %d direct calls", len(mock.checkHealthCalls))
+	}
+}
+
+func TestResume_DrainingReissuesWithReducedTimeout(t *testing.T) {
+	db := openResumeTestDB(t)
+	seedDeployment(t, db, "myapp", "blue", "green", "myapp:v2", string(StateDraining))
+
+	mock := &MockActionProvider{}
+	sm, err := Resume(db, "myapp", mock)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sm == nil {
+		t.Fatal("expected a state machine to resume into")
+	}
+	if sm.GetState() != StateDraining {
+		t.Errorf("expected StateDraining to survive resume, got %s", sm.GetState())
+	}
+	if len(mock.drainConnectionsCalls) != 1 {
+		t.Fatalf("expected DrainConnections to be re-issued once, got %d calls", len(mock.drainConnectionsCalls))
+	}
+	call := mock.drainConnectionsCalls[0]
+	if call.AppName != "myapp" || call.Color != "blue" {
+		t.Errorf("expected drain against the outgoing blue color, got %+v", call)
+	}
+	if call.Timeout <= 0 || call.Timeout > 30*time.Second {
+		t.Errorf("expected a reduced but positive drain timeout, got %s", call.Timeout)
+	}
+}
+
+func TestResume_BackoffReissuesNothing(t *testing.T) {
+	db := openResumeTestDB(t)
+	seedDeployment(t, db, "myapp", "blue", "green", "myapp:v2", string(StateBackoff))
+	if err := state.UpsertDeploymentCheckpoint(db, state.DeploymentCheckpoint{
+		AppName:          "myapp",
+		RetryCount:       1,
+		BackoffStartedAt: time.Now(),
+		BackoffDuration:  time.Second,
+		UpdatedAt:        time.Now(),
+	}); err != nil {
+		t.Fatalf("upsert checkpoint: %v", err)
+	}
+
+	mock := &MockActionProvider{}
+	sm, err := Resume(db, "myapp", mock)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sm == nil {
+		t.Fatal("expected a state machine to resume into")
+	}
+	if sm.GetState() != StateBackoff {
+		t.Errorf("expected StateBackoff to survive resume, got %s", sm.GetState())
+	}
+	if sm.GetRetryCount() != 1 {
+		t.Errorf("expected retry count restored from checkpoint, got %d", sm.GetRetryCount())
+	}
+	if len(mock.startContainerCalls)+len(mock.stopContainerCalls)+len(mock.rollbackCaddyCalls) != 0 {
+		t.Error("expected no actions re-issued for StateBackoff; the deployment loop's own clock check picks it back up")
+	}
+}
+
+func TestResume_RestoresHealthStreakFromCheckpoint(t *testing.T) {
+	db := openResumeTestDB(t)
+	seedDeployment(t, db, "myapp", "blue", "green", "myapp:v2", string(StateHealthCheck))
+	if err := state.UpsertDeploymentCheckpoint(db, state.DeploymentCheckpoint{
+		AppName:             "myapp",
+		HealthSuccessStreak: 2,
+		UpdatedAt:           time.Now(),
+	}); err != nil {
+		t.Fatalf("upsert checkpoint: %v", err)
+	}
+
+	sm, err := Resume(db, "myapp", &MockActionProvider{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sm == nil {
+		t.Fatal("expected a state machine to resume into")
+	}
+	if sm.GetHealthStreak() != 2 {
+		t.Errorf("expected health streak restored from checkpoint, got %d", sm.GetHealthStreak())
+	}
+}
+
+func TestResume_UnresumableStatesFailClosed(t *testing.T) {
+	for _, s := range []DeploymentState{StateStarting, StateSwitching, StateStopping, StateRollingBack} {
+		t.Run(string(s), func(t *testing.T) {
+			db := openResumeTestDB(t)
+			seedDeployment(t, db, "myapp", "blue", "green", "myapp:v2", string(s))
+
+			mock := &MockActionProvider{}
+			sm, err := Resume(db, "myapp", mock)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sm == nil {
+				t.Fatal("expected a state machine to resume into")
+			}
+			if sm.GetState() != StateFailed {
+				t.Errorf("expected StateFailed after resuming from %s, got %s", s, sm.GetState())
+			}
+			if len(mock.startContainerCalls)+len(mock.stopContainerCalls)+len(mock.rollbackCaddyCalls) != 0 {
+				t.Error("expected no actions re-issued for an unresumable state")
+			}
+		})
+	}
+}
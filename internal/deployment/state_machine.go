@@ -1,7 +1,10 @@
 package deployment
 
 import (
+	"context"
 	"database/sql"
+	"dockswap/internal/errs"
+	"dockswap/internal/events"
 	"dockswap/internal/state"
 	"fmt"
 	"time"
@@ -18,6 +21,29 @@ const (
 	StateStopping    DeploymentState = "stopping"
 	StateRollingBack DeploymentState = "rolling_back"
 	StateFailed      DeploymentState = "failed"
+
+	// StateBackoff is entered in place of StateFailed/StateRollingBack when
+	// a container start, health check, or Caddy update failure is still
+	// retryable under the state machine's RestartPolicy. It waits out that
+	// retry's exponential backoff window, gated by a caller (normally
+	// DeploymentOrchestrator.runDeploymentLoop) checking BackoffElapsed and
+	// calling CompleteBackoff, the same shape StateCanary's soak window
+	// uses for CanaryStepStartedAt/CompleteCanaryStep.
+	StateBackoff DeploymentState = "backoff"
+
+	// StateCanary is a canary rollout's stepped-traffic counterpart to
+	// StateSwitching: the target color has passed its health check and is
+	// now taking an increasing weighted share of live traffic, one
+	// config.CanaryPlan.Steps stage at a time.
+	StateCanary DeploymentState = "canary"
+
+	// StateCanaryPromoting is entered once the canary rollout's
+	// second-to-last step has passed and it advances onto the last step
+	// (100% weight); it mirrors StateSwitching's own
+	// EventCaddyUpdated/EventCaddyFailed handling for the routing update
+	// that moves the target color from "highest-weighted upstream" to sole
+	// upstream.
+	StateCanaryPromoting DeploymentState = "canary_promoting"
 )
 
 type DeploymentEvent string
@@ -36,13 +62,215 @@ const (
 	EventRollbackComplete  DeploymentEvent = "rollback_complete"
 	EventRollbackFailed    DeploymentEvent = "rollback_failed"
 	EventManualRecovery    DeploymentEvent = "manual_recovery"
+	// EventActiveContainerDied is raised out-of-band (e.g. by a
+	// docker.EventWatcher) when the container backing the currently-active
+	// color exits unexpectedly, bypassing the normal health-check loop.
+	EventActiveContainerDied DeploymentEvent = "active_container_died"
+	// EventInterrupted marks a deployment force-failed by Interrupt or
+	// ResumeInterrupted - a shutdown.Coordinator cancellation, the
+	// deployment loop's own timeout, or a carried-over interrupted marker
+	// found at startup - rather than by a normal ActionProvider outcome.
+	EventInterrupted DeploymentEvent = "interrupted"
+	// EventResumed marks a deployment rehydrated by Resume after a process
+	// crash left it mid-flight without ever reaching Interrupt's
+	// "interrupted" marker. ToState mirrors FromState when Resume judged the
+	// pending action safe to re-issue (health check, drain); it's
+	// StateFailed when Resume couldn't tell whether the pending action
+	// already completed.
+	EventResumed DeploymentEvent = "resumed"
+
+	// EventCanaryStepPassed advances a canary rollout to its next weight
+	// stage, or (on the last stage) into StateCanaryPromoting, once that
+	// stage's soak/health window passed.
+	EventCanaryStepPassed DeploymentEvent = "canary_step_passed"
+	// EventCanaryStepFailed rolls a canary rollout back to the stable color
+	// because CheckHealth failed during a weight stage's soak window.
+	EventCanaryStepFailed DeploymentEvent = "canary_step_failed"
+
+	// EventCanceled marks a deployment an operator aborted via
+	// SetDesiredTransition's Cancel field while it was still early enough to
+	// back out cleanly (StateStarting/StateHealthCheck), intercepted by
+	// ProcessEvent before the event it was actually called with ever reaches
+	// the normal per-state handler.
+	EventCanceled DeploymentEvent = "canceled"
+	// EventForcedRollback marks a deployment an operator reversed via
+	// SetDesiredTransition's ForceRollback field after its cutover had
+	// already landed (StateDraining), intercepted by ProcessEvent the same
+	// way EventCanceled is.
+	EventForcedRollback DeploymentEvent = "forced_rollback"
+
+	// EventBackoffElapsed advances out of StateBackoff once its wait has
+	// run out, re-issuing whichever step (container start or Caddy update)
+	// originally failed - raised by CompleteBackoff, gated by a caller
+	// checking BackoffElapsed first.
+	EventBackoffElapsed DeploymentEvent = "backoff_elapsed"
 )
 
+// RestartPolicyKind selects whether a failed deployment step is retried
+// before the state machine gives up and lands in StateFailed, mirroring
+// Kubernetes' pod restart policies (Always/OnFailure/Never) applied to a
+// deployment attempt instead of a running container. Every retryable
+// failure here is, by definition, a failure, so RestartPolicyAlways and
+// RestartPolicyOnFailure behave identically - the distinction only matters
+// for Kubernetes' own "container exited 0" case, which this state machine
+// has no equivalent of.
+type RestartPolicyKind string
+
+const (
+	RestartPolicyAlways    RestartPolicyKind = "always"
+	RestartPolicyOnFailure RestartPolicyKind = "on_failure"
+	RestartPolicyNever     RestartPolicyKind = "never"
+)
+
+// RestartPolicy configures retries of a failed container start, health
+// check, or Caddy update. Each retry waits BackoffInitial *
+// BackoffMultiplier^(attempt-1), capped at BackoffMax, before the failed
+// step is re-issued; MaxRetries bounds how many retries happen before the
+// deployment gives up and lands in StateFailed. The zero value behaves like
+// RestartPolicyNever: no retries, failing straight to StateFailed exactly
+// as before this existed.
+type RestartPolicy struct {
+	Policy            RestartPolicyKind
+	MaxRetries        int
+	BackoffInitial    time.Duration
+	BackoffMax        time.Duration
+	BackoffMultiplier float64
+}
+
+// allowsRetry reports whether rp permits another retry given how many have
+// already been attempted for the current deployment.
+func (rp RestartPolicy) allowsRetry(retryCount int) bool {
+	if rp.Policy == "" || rp.Policy == RestartPolicyNever {
+		return false
+	}
+	return retryCount < rp.MaxRetries
+}
+
+// BackoffFor computes the exponential backoff duration before retry number
+// attempt (1-indexed): BackoffInitial * BackoffMultiplier^(attempt-1),
+// capped at BackoffMax. BackoffMultiplier defaults to 2 when unset.
+func (rp RestartPolicy) BackoffFor(attempt int) time.Duration {
+	multiplier := rp.BackoffMultiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	backoff := float64(rp.BackoffInitial)
+	for i := 1; i < attempt; i++ {
+		backoff *= multiplier
+	}
+
+	d := time.Duration(backoff)
+	if rp.BackoffMax > 0 && d > rp.BackoffMax {
+		d = rp.BackoffMax
+	}
+	return d
+}
+
+// retryStep records which failed step enterBackoff should re-issue once
+// StateBackoff's wait elapses.
+type retryStep string
+
+const (
+	retryStepStartContainer retryStep = "start_container"
+	retryStepCaddyUpdate    retryStep = "caddy_update"
+)
+
+// DesiredTransition records an operator's out-of-band intent for an
+// in-progress deployment, consulted by ProcessEvent before every event -
+// borrowed from Nomad's allocation DesiredTransition struct. Cancel aborts a
+// deployment while it's still early enough to back out cleanly without ever
+// having taken traffic (StateStarting/StateHealthCheck); by StateDraining
+// the cutover has already landed and Cancel is a no-op, too late to act on.
+// ForceRollback reverses a deployment whose cutover already landed
+// (StateDraining), restoring traffic to the previous color. Pause is
+// advisory only: ProcessEvent doesn't act on it directly, but
+// DeploymentOrchestrator.runDeploymentLoop checks it before issuing the next
+// health-check/canary-step poll so the deployment holds at its current
+// state instead of advancing.
+type DesiredTransition struct {
+	Cancel        *bool
+	Pause         *bool
+	ForceRollback *bool
+}
+
+// boolPtrValue reports false for a nil pointer instead of panicking, so
+// DesiredTransition's fields can be left unset (the common case) without
+// every caller nil-checking them first.
+func boolPtrValue(b *bool) bool {
+	return b != nil && *b
+}
+
+// HealthChecker is one pluggable readiness probe a DeploymentStateMachine
+// can run directly via CheckHealth, in place of delegating entirely to its
+// ActionProvider's own CheckHealth - e.g. an HTTP/TCP/exec/gRPC probe built
+// from config.HealthCheck the same way docker.HealthProbe is, but without
+// this package depending on the docker package to use one. When several are
+// registered via SetHealthCheckers, all must pass; the first one to fail
+// short-circuits the rest and its Name() is folded into the failed
+// transition's recorded error so operators can see which probe blocked the
+// deploy.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// HealthStatus is one poll's outcome, borrowed from Docker's own
+// types.Health model (starting/healthy/unhealthy) so a HealthResult can
+// distinguish "still warming up" from "actively failing" instead of
+// collapsing both into a single bool.
+type HealthStatus string
+
+const (
+	HealthStatusHealthy   HealthStatus = "healthy"
+	HealthStatusUnhealthy HealthStatus = "unhealthy"
+	HealthStatusStarting  HealthStatus = "starting"
+)
+
+// HealthResult is one poll of an ActionProvider's CheckHealth, richer than a
+// bare bool so CheckHealth (the state-machine method, not the ActionProvider
+// one) can log latency/message alongside the pass/fail streak it's
+// accumulating.
+type HealthResult struct {
+	Status  HealthStatus
+	Latency time.Duration
+	Message string
+}
+
+// HealthPolicy configures how CheckHealth turns a series of HealthResult
+// polls into a StateSwitching/StateRollingBack decision, mirroring Docker's
+// own HEALTHCHECK thresholds: SuccessThreshold/FailureThreshold consecutive
+// results (a streak reset by any result of the other kind) before
+// transitioning, Interval as the minimum gap between polls, and StartPeriod
+// as a grace window during which failures accumulate a streak but don't yet
+// trigger StateRollingBack - giving a slow-starting process a head start,
+// same rationale as config.HealthCheck.InitialDelay. Timeout, if set, bounds
+// how long (after StartPeriod) CheckHealth keeps polling for
+// SuccessThreshold passes before giving up and failing outright, even
+// without FailureThreshold fails. SuccessThreshold and FailureThreshold both
+// default to 1 when unset, preserving the original single-pass-to-switch,
+// single-fail-to-rollback behavior.
+type HealthPolicy struct {
+	SuccessThreshold int
+	FailureThreshold int
+	Interval         time.Duration
+	Timeout          time.Duration
+	StartPeriod      time.Duration
+}
+
 type ActionProvider interface {
 	StartContainer(appName, color, image string) error
-	CheckHealth(appName, color string) (bool, error)
+	CheckHealth(appName, color string) (HealthResult, error)
 	UpdateCaddy(appName, activeColor string) error
+	// UpdateCaddyWeighted splits appName's routing across weights (slot/color
+	// name -> traffic percent, summing to 100) instead of sending it all to
+	// one active color, for a canary rollout's stepped weight stages.
+	UpdateCaddyWeighted(appName string, weights map[string]int) error
 	DrainConnections(appName, color string, timeout time.Duration) error
+	// ConnectionsRemaining reports how many connections are still open
+	// against color, so ConnectionsRemaining can show live drain progress
+	// while DrainConnections is blocked waiting for them to close.
+	ConnectionsRemaining(appName, color string) int
 	StopContainer(appName, color string) error
 	RollbackCaddy(appName, activeColor string) error
 }
@@ -59,11 +287,104 @@ type DeploymentStateMachine struct {
 	healthCheckTimeout time.Duration
 	drainTimeout       time.Duration
 
+	// healthCheckers, if set via SetHealthCheckers, are run directly by
+	// CheckHealth instead of delegating to actions.CheckHealth; all must
+	// pass.
+	healthCheckers []HealthChecker
+
+	// lastHealthCheckReason is set by CompleteHealthCheck just before it
+	// raises EventHealthCheckFailed/EventHealthCheckPassed, so
+	// handleHealthCheckState can fold the specific failure reason (e.g.
+	// "http probe failed: ...") into the error ProcessEvent records against
+	// that transition.
+	lastHealthCheckReason string
+
+	// healthPolicy is registered via SetHealthPolicy and consulted by
+	// CheckHealth to turn a series of polls into successStreak/failStreak
+	// counters and, ultimately, a StateSwitching/StateRollingBack decision.
+	healthPolicy HealthPolicy
+	// successStreak and failStreak count consecutive CheckHealth poll
+	// outcomes of one kind since the last reset (entering StateHealthCheck,
+	// or the other streak incrementing); exactly one of them is non-zero at
+	// a time. Exposed via GetHealthStreak.
+	successStreak int
+	failStreak    int
+	// healthCheckStartedAt is when the current StateHealthCheck was
+	// entered, anchoring healthPolicy.StartPeriod's grace window and
+	// healthPolicy.Timeout's overall give-up deadline.
+	healthCheckStartedAt time.Time
+	// lastHealthPollAt is when CheckHealth last actually polled (as opposed
+	// to being called again before healthPolicy.Interval elapsed and
+	// returning early).
+	lastHealthPollAt time.Time
+
+	// canarySteps is config.CanaryPlan.Steps, set via SetCanarySteps. A
+	// deploy whose HealthCheck passes while this is non-empty steps through
+	// StateCanary's weight stages instead of cutting straight over to
+	// StateSwitching.
+	canarySteps []int
+	// canaryStepIdx indexes the weight stage most recently applied in
+	// canarySteps.
+	canaryStepIdx int
+	// canaryStepStartedAt is when the current weight stage was applied, so
+	// a caller (DeploymentOrchestrator's runDeploymentLoop) can gate
+	// CompleteCanaryStep behind that stage's soak window.
+	canaryStepStartedAt time.Time
+
+	// desired is the operator intent registered via SetDesiredTransition,
+	// consulted by ProcessEvent on every call via desiredTransitionEvent.
+	desired DesiredTransition
+
+	// restartPolicy is registered via SetRestartPolicy and consulted by
+	// enterBackoff on a container start, health check, or Caddy update
+	// failure, in place of failing straight to StateFailed/StateRollingBack.
+	restartPolicy RestartPolicy
+	// retryCount is how many times the current deployment has entered
+	// StateBackoff, exposed via GetRetryCount and checked against
+	// restartPolicy.MaxRetries by enterBackoff.
+	retryCount int
+	// pendingRetry is the step CompleteBackoff re-issues once StateBackoff's
+	// wait elapses, set by enterBackoff.
+	pendingRetry retryStep
+	// backoffStartedAt and backoffDuration bound StateBackoff's wait the
+	// same way canaryStepStartedAt/config.CanaryPlan.StepDuration bound
+	// StateCanary's; BackoffElapsed compares them against now.
+	backoffStartedAt time.Time
+	backoffDuration  time.Duration
+	// now, if set via SetClock, is used in place of time.Now by
+	// enterBackoff/BackoffElapsed, for tests to control backoff timing
+	// without sleeping.
+	now func() time.Time
+
 	stateHistory []StateTransition
 
 	db *sql.DB // NEW: DB handle for persistence
 
 	deploymentID int64 // NEW: Track current deployment row
+
+	// bus, if set via SetEventBus, is published to on every state
+	// transition so external systems can react without polling GetState.
+	bus *events.Bus
+
+	// hooks are the lifecycle commands registered via SetHooks; see
+	// runHookPhase for when each phase runs and how its outcome affects the
+	// deployment.
+	hooks Hooks
+	// hookRunner executes every Hook in hooks, registered via
+	// SetHookRunner. A hook phase with entries but no hookRunner set fails
+	// fast; see SetHookRunner.
+	hookRunner HookRunner
+
+	// lastHookFailure is set by runHookPhase just before a blocking hook's
+	// non-zero exit forces EventContainerFailed/EventCaddyFailed/
+	// EventRollbackFailed, so recordTransition can fold the specific hook
+	// and command into that transition's recorded error the same way
+	// lastHealthCheckReason does for EventHealthCheckFailed.
+	lastHookFailure string
+	// lastHookOutputs accumulates HookResults from blocking hooks run since
+	// the last recordTransition call, which drains and clears it into that
+	// transition's deployment_events payload.
+	lastHookOutputs []HookResult
 }
 
 type StateTransition struct {
@@ -72,6 +393,11 @@ type StateTransition struct {
 	Event     DeploymentEvent
 	Timestamp time.Time
 	Error     error
+	// HealthStreak is GetHealthStreak() at the moment of this transition:
+	// positive for consecutive passes, negative for consecutive fails, zero
+	// before any poll has completed. Streaks reset only on entering
+	// StateHealthCheck, so later transitions carry the last streak.
+	HealthStreak int
 }
 
 // New creates a new state machine with DB persistence.
@@ -88,6 +414,141 @@ func New(appName, activeColor string, actions ActionProvider, db *sql.DB) *Deplo
 	}
 }
 
+// Resume reconstructs appName's DeploymentStateMachine from its last
+// persisted current_state row and deployment_events log, for a caller
+// recovering from a dockswap process crash that left a deployment mid-flight
+// without ever reaching Interrupt's "interrupted" marker - a clean
+// shutdown.Coordinator cancellation already sets that marker, which
+// ResumeInterrupted handles. It returns (nil, nil) when appName has no
+// persisted current_state or its last deployment already reached
+// StateStable, meaning there's nothing to resume and the caller should build
+// a fresh machine via New instead.
+//
+// actions is required even though db/appName alone would mirror a drop-in
+// constructor like New's: a deployment resumed mid StateHealthCheck or
+// StateDraining needs a live ActionProvider to re-poll health or re-drain
+// against, not just replayed bookkeeping. StateBackoff is also resumed in
+// place, with its restored backoff timer. Every other in-flight state
+// (StateStarting, StateSwitching, StateStopping, StateRollingBack,
+// StateCanary, StateCanaryPromoting) has no
+// safe way to tell whether its pending action completed before the crash,
+// so Resume force-fails those straight to StateFailed, the same place
+// Interrupt leaves an uninterruptible deployment - an operator resolves it
+// with RecoverManually.
+func Resume(db *sql.DB, appName string, actions ActionProvider) (*DeploymentStateMachine, error) {
+	cs, err := state.GetCurrentState(db, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current state for %s: %w", appName, err)
+	}
+
+	fromState := DeploymentState(cs.Status)
+	if fromState == "" || fromState == StateStable || cs.Status == "unknown" {
+		// "unknown" is GetCurrentState's own placeholder for an app with no
+		// current_state row and no deployment history - never actually
+		// deployed, so there's nothing to resume.
+		return nil, nil
+	}
+
+	sm := &DeploymentStateMachine{
+		state:              fromState,
+		appName:            appName,
+		activeColor:        cs.ActiveColor,
+		previousColor:      cs.ActiveColor,
+		newImage:           cs.Image,
+		actions:            actions,
+		healthCheckTimeout: 60 * time.Second,
+		drainTimeout:       30 * time.Second,
+		stateHistory:       make([]StateTransition, 0),
+		db:                 db,
+		deploymentID:       cs.DeploymentID,
+	}
+
+	// Restore retry/backoff/health-streak/desired-transition state the
+	// current_state row alone doesn't carry, so a crash mid-backoff or
+	// mid-health-check resumes its streak instead of starting over. Unlike
+	// the best-effort deployment_events lookup below, a failure here is
+	// treated as fatal: silently falling back to zero-value backoff state
+	// would make BackoffElapsed report true immediately and fire a retry
+	// before its backoff window actually elapsed.
+	checkpoint, err := state.GetDeploymentCheckpoint(db, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deployment checkpoint for %s: %w", appName, err)
+	}
+	if checkpoint != nil {
+		sm.retryCount = checkpoint.RetryCount
+		sm.backoffStartedAt = checkpoint.BackoffStartedAt
+		sm.backoffDuration = checkpoint.BackoffDuration
+		sm.desired = DesiredTransition{
+			Cancel:        checkpoint.DesiredCancel,
+			Pause:         checkpoint.DesiredPause,
+			ForceRollback: checkpoint.DesiredForceRollback,
+		}
+		sm.successStreak = checkpoint.HealthSuccessStreak
+		sm.failStreak = checkpoint.HealthFailStreak
+		sm.healthCheckStartedAt = checkpoint.HealthCheckStartedAt
+	}
+
+	// A clean shutdown already marked this "interrupted", and a deployment
+	// that was already StateFailed needs the same manual recovery either
+	// way - neither has a pending action worth re-issuing.
+	if cs.Status == "interrupted" || fromState == StateFailed {
+		sm.state = StateFailed
+		sm.recordTransition(fromState, StateFailed, EventResumed,
+			fmt.Errorf("resuming app %s: deployment left in status %q by a previous process", appName, cs.Status))
+		return sm, nil
+	}
+
+	dep, err := state.GetDeploymentByID(db, cs.DeploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deployment %d for %s: %w", cs.DeploymentID, appName, err)
+	}
+	sm.targetColor = dep.ActiveColor
+
+	var lastEventAt time.Time
+	if events, err := state.GetDeploymentEvents(db, cs.DeploymentID); err == nil && len(events) > 0 {
+		lastEventAt = events[len(events)-1].CreatedAt
+	}
+
+	switch fromState {
+	case StateHealthCheck:
+		// Re-polling health is idempotent; the orchestrator's deployment
+		// loop already does this every tick regardless of how it entered
+		// StateHealthCheck, so there's nothing left to re-issue here beyond
+		// leaving the machine in place for that loop to pick back up.
+		sm.recordTransition(fromState, fromState, EventResumed,
+			fmt.Errorf("resuming health check for %s after process restart", appName))
+
+	case StateDraining:
+		// DrainConnections blocks synchronously inside the original
+		// StateSwitching -> StateDraining transition, so a crash here means
+		// it never actually ran (or was cut off mid-wait) - re-issue it
+		// now, with its timeout reduced by however long has already elapsed
+		// since draining began.
+		remaining := sm.drainTimeout - time.Since(lastEventAt)
+		if lastEventAt.IsZero() || remaining < 0 {
+			remaining = 0
+		}
+		drainErr := actions.DrainConnections(appName, sm.previousColor, remaining)
+		sm.recordTransition(fromState, fromState, EventResumed, drainErr)
+
+	case StateBackoff:
+		// BackoffElapsed/CompleteBackoff are just clock checks against the
+		// restored backoffStartedAt/backoffDuration above - the
+		// orchestrator's deployment loop already polls these every tick
+		// regardless of how StateBackoff was entered, so there's nothing to
+		// re-issue beyond leaving the machine in place for it.
+		sm.recordTransition(fromState, fromState, EventResumed,
+			fmt.Errorf("resuming backoff for %s after process restart", appName))
+
+	default:
+		sm.state = StateFailed
+		sm.recordTransition(fromState, StateFailed, EventResumed,
+			fmt.Errorf("cannot safely resume app %s from state %s after a crash; manual recovery required", appName, fromState))
+	}
+
+	return sm, nil
+}
+
 func (sm *DeploymentStateMachine) GetState() DeploymentState {
 	return sm.state
 }
@@ -104,10 +565,186 @@ func (sm *DeploymentStateMachine) SetDrainTimeout(timeout time.Duration) {
 	sm.drainTimeout = timeout
 }
 
+// SetCanarySteps registers the weight stages (e.g. []int{5, 25, 50, 100}) a
+// deploy steps through after HealthCheck passes, instead of cutting straight
+// over to StateSwitching. Pass nil (the default) to keep the blue_green
+// behavior.
+func (sm *DeploymentStateMachine) SetCanarySteps(steps []int) {
+	sm.canarySteps = steps
+}
+
+// CanaryStepStartedAt returns when the current canary weight stage was
+// applied, for a caller to gate CompleteCanaryStep behind
+// config.CanaryPlan.StepDuration's soak window. Zero outside StateCanary.
+func (sm *DeploymentStateMachine) CanaryStepStartedAt() time.Time {
+	if sm.state != StateCanary {
+		return time.Time{}
+	}
+	return sm.canaryStepStartedAt
+}
+
+// CanaryWeight returns the target color's traffic weight at the current
+// canary stage, for status output. Zero outside StateCanary/
+// StateCanaryPromoting.
+func (sm *DeploymentStateMachine) CanaryWeight() int {
+	if sm.state != StateCanary && sm.state != StateCanaryPromoting {
+		return 0
+	}
+	if len(sm.canarySteps) == 0 || sm.canaryStepIdx >= len(sm.canarySteps) {
+		return 0
+	}
+	return sm.canarySteps[sm.canaryStepIdx]
+}
+
+// SetHealthCheckers registers the probes CheckHealth runs directly against
+// the target color, bypassing actions.CheckHealth entirely while set. Pass
+// nil (the default) to fall back to the ActionProvider's own CheckHealth.
+func (sm *DeploymentStateMachine) SetHealthCheckers(checkers []HealthChecker) {
+	sm.healthCheckers = checkers
+}
+
+// SetHealthPolicy registers policy as the thresholds CheckHealth consults on
+// every subsequent poll. Pass a zero-value HealthPolicy (the default) to
+// keep the original single-pass-to-switch, single-fail-to-rollback
+// behavior.
+func (sm *DeploymentStateMachine) SetHealthPolicy(policy HealthPolicy) {
+	sm.healthPolicy = policy
+}
+
+// GetHealthStreak reports the current run of consecutive CheckHealth poll
+// outcomes: positive for consecutive passes, negative for consecutive
+// fails, zero before any poll has completed in this StateHealthCheck.
+func (sm *DeploymentStateMachine) GetHealthStreak() int {
+	if sm.successStreak > 0 {
+		return sm.successStreak
+	}
+	return -sm.failStreak
+}
+
+// GetLastHealthCheckReason reports the reason string from the most recent
+// CompleteHealthCheck call, empty if none has completed yet in this
+// StateHealthCheck.
+func (sm *DeploymentStateMachine) GetLastHealthCheckReason() string {
+	return sm.lastHealthCheckReason
+}
+
+// SetEventBus registers bus so every subsequent state transition is
+// published as an events.Event.
+func (sm *DeploymentStateMachine) SetEventBus(bus *events.Bus) {
+	sm.bus = bus
+}
+
+// SetRestartPolicy registers rp as the policy enterBackoff consults on a
+// container start, health check, or Caddy update failure. Pass a zero-value
+// RestartPolicy (the default) to keep the original fail-straight-to-
+// StateFailed behavior.
+func (sm *DeploymentStateMachine) SetRestartPolicy(rp RestartPolicy) {
+	sm.restartPolicy = rp
+}
+
+// SetClock overrides the time source enterBackoff/BackoffElapsed use in
+// place of time.Now, for tests to control StateBackoff timing without
+// sleeping.
+func (sm *DeploymentStateMachine) SetClock(now func() time.Time) {
+	sm.now = now
+}
+
+// GetRetryCount returns how many times the current deployment has entered
+// StateBackoff under its RestartPolicy.
+func (sm *DeploymentStateMachine) GetRetryCount() int {
+	return sm.retryCount
+}
+
+// BackoffElapsed reports whether StateBackoff's wait has run out, for a
+// caller (DeploymentOrchestrator's runDeploymentLoop) to gate CompleteBackoff
+// behind it, the same way it gates CompleteCanaryStep behind
+// CanaryStepStartedAt/config.CanaryPlan.StepDuration. Always false outside
+// StateBackoff.
+func (sm *DeploymentStateMachine) BackoffElapsed() bool {
+	if sm.state != StateBackoff {
+		return false
+	}
+	return sm.nowFunc().Sub(sm.backoffStartedAt) >= sm.backoffDuration
+}
+
+// nowFunc returns sm.now if SetClock registered one, else time.Now.
+func (sm *DeploymentStateMachine) nowFunc() time.Time {
+	if sm.now != nil {
+		return sm.now()
+	}
+	return time.Now()
+}
+
+// enterBackoff records another retry and transitions into StateBackoff to
+// wait out restartPolicy's exponential backoff before step is re-issued by
+// CompleteBackoff, instead of the caller failing straight to StateFailed/
+// StateRollingBack. Reports false (leaving sm.state untouched) when
+// restartPolicy doesn't permit another retry, so the caller falls through
+// to its normal failure handling.
+func (sm *DeploymentStateMachine) enterBackoff(step retryStep) bool {
+	if !sm.restartPolicy.allowsRetry(sm.retryCount) {
+		return false
+	}
+
+	sm.retryCount++
+	sm.pendingRetry = step
+	sm.backoffStartedAt = sm.nowFunc()
+	sm.backoffDuration = sm.restartPolicy.BackoffFor(sm.retryCount)
+	sm.state = StateBackoff
+	return true
+}
+
+// SetDesiredTransition registers dt as the operator intent ProcessEvent
+// consults on every subsequent call. Pass a zero-value DesiredTransition to
+// clear it.
+func (sm *DeploymentStateMachine) SetDesiredTransition(dt DesiredTransition) {
+	sm.desired = dt
+	// Pause in particular needs to persist right away: runDeploymentLoop
+	// stops calling ProcessEvent (recordTransition's own checkpoint write)
+	// entirely for as long as IsPaused() is true, so without this a pause
+	// flag set just before a crash would never make it to disk.
+	sm.persistCheckpoint()
+}
+
+// GetDesiredTransition returns the operator intent most recently registered
+// via SetDesiredTransition.
+func (sm *DeploymentStateMachine) GetDesiredTransition() DesiredTransition {
+	return sm.desired
+}
+
+// IsPaused reports whether SetDesiredTransition's Pause field is set, for
+// DeploymentOrchestrator.runDeploymentLoop to hold the deployment at its
+// current state instead of polling the next health-check/canary-step tick.
+func (sm *DeploymentStateMachine) IsPaused() bool {
+	return boolPtrValue(sm.desired.Pause)
+}
+
+// desiredTransitionEvent reports whether the operator intent registered via
+// SetDesiredTransition should intercept the event ProcessEvent was just
+// called with, and if so which synthetic event to process instead. Cancel
+// only applies early enough to back out cleanly, before the new color has
+// taken any traffic (StateStarting/StateHealthCheck); by StateDraining the
+// cutover has already landed and Cancel is a no-op there - ForceRollback is
+// what undoes that instead.
+func (sm *DeploymentStateMachine) desiredTransitionEvent() (DeploymentEvent, bool) {
+	switch {
+	case boolPtrValue(sm.desired.Cancel) && (sm.state == StateStarting || sm.state == StateHealthCheck):
+		return EventCanceled, true
+	case boolPtrValue(sm.desired.ForceRollback) && sm.state == StateDraining:
+		return EventForcedRollback, true
+	default:
+		return "", false
+	}
+}
+
 func (sm *DeploymentStateMachine) ProcessEvent(event DeploymentEvent) error {
 	oldState := sm.state
 	var err error
 
+	if altEvent, intercepted := sm.desiredTransitionEvent(); intercepted {
+		event = altEvent
+	}
+
 	switch sm.state {
 	case StateStable:
 		err = sm.handleStableState(event)
@@ -125,6 +762,12 @@ func (sm *DeploymentStateMachine) ProcessEvent(event DeploymentEvent) error {
 		err = sm.handleRollingBackState(event)
 	case StateFailed:
 		err = sm.handleFailedState(event)
+	case StateBackoff:
+		err = sm.handleBackoffState(event)
+	case StateCanary:
+		err = sm.handleCanaryState(event)
+	case StateCanaryPromoting:
+		err = sm.handleCanaryPromotingState(event)
 	default:
 		return fmt.Errorf("unknown state: %s", sm.state)
 	}
@@ -141,6 +784,11 @@ func (sm *DeploymentStateMachine) Deploy(newImage string) error {
 	sm.newImage = newImage
 	sm.targetColor = sm.getInactiveColor()
 	sm.previousColor = sm.activeColor
+	sm.retryCount = 0
+	// A Cancel/Pause/ForceRollback left over from the previous deployment
+	// (e.g. Cancel, consumed once and never un-set by the operator) must not
+	// silently carry forward and fire again against this new one.
+	sm.desired = DesiredTransition{}
 
 	// --- DB: Insert config if new, then deployment ---
 	if sm.db != nil {
@@ -149,17 +797,25 @@ func (sm *DeploymentStateMachine) Deploy(newImage string) error {
 			// For now, treat newImage as config YAML (stub)
 			_, err := state.InsertAppConfig(sm.db, sm.appName, newImage, "sha-stub")
 			if err != nil {
-				return fmt.Errorf("failed to insert app config: %w", err)
+				return errs.Wrap(errs.ErrDBPersist, err, "failed to insert app config")
 			}
 		}
 		cfg, _ = state.GetLatestAppConfig(sm.db, sm.appName)
 		depID, err := state.InsertDeployment(sm.db, sm.appName, cfg.ID, newImage, "deploying", sm.targetColor, nil)
 		if err != nil {
-			return fmt.Errorf("failed to insert deployment: %w", err)
+			return errs.Wrap(errs.ErrDBPersist, err, "failed to insert deployment")
 		}
 		sm.deploymentID = depID
 	}
 
+	// pre_deploy gates StartContainer: a blocking hook's non-zero exit
+	// force-fails the deployment exactly as if the container itself had
+	// failed to start, without ever calling StartContainer.
+	if err := sm.runHookPhase("pre_deploy", sm.hooks.PreDeploy); err != nil {
+		sm.state = StateStarting
+		return sm.ProcessEvent(EventContainerFailed)
+	}
+
 	return sm.ProcessEvent(EventDeploy)
 }
 
@@ -171,8 +827,11 @@ func (sm *DeploymentStateMachine) handleStableState(event DeploymentEvent) error
 	case EventManualRecovery:
 		// Already stable, no-op
 		return nil
+	case EventActiveContainerDied:
+		sm.state = StateFailed
+		return fmt.Errorf("active container for color %s died unexpectedly", sm.activeColor)
 	default:
-		return fmt.Errorf("invalid event %s for state %s", event, sm.state)
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
 	}
 }
 
@@ -180,25 +839,109 @@ func (sm *DeploymentStateMachine) handleStartingState(event DeploymentEvent) err
 	switch event {
 	case EventContainerStarted:
 		sm.state = StateHealthCheck
+		sm.healthCheckStartedAt = sm.nowFunc()
+		sm.successStreak = 0
+		sm.failStreak = 0
+		sm.lastHealthPollAt = time.Time{}
 		return nil
 	case EventContainerFailed:
+		if sm.enterBackoff(retryStepStartContainer) {
+			return nil
+		}
 		sm.state = StateFailed
-		return fmt.Errorf("container failed to start")
+		return errs.Wrap(errs.ErrContainerStart, nil, "container failed to start")
+	case EventCanceled:
+		sm.state = StateRollingBack
+		return sm.actions.StopContainer(sm.appName, sm.targetColor)
 	default:
-		return fmt.Errorf("invalid event %s for state %s", event, sm.state)
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
 	}
 }
 
 func (sm *DeploymentStateMachine) handleHealthCheckState(event DeploymentEvent) error {
 	switch event {
+	case EventCanceled:
+		sm.state = StateRollingBack
+		return sm.actions.StopContainer(sm.appName, sm.targetColor)
 	case EventHealthCheckPassed:
+		if len(sm.canarySteps) > 0 {
+			sm.state = StateCanary
+			sm.canaryStepIdx = 0
+			sm.canaryStepStartedAt = time.Now()
+			return sm.actions.UpdateCaddyWeighted(sm.appName, sm.canaryWeights())
+		}
 		sm.state = StateSwitching
 		return sm.actions.UpdateCaddy(sm.appName, sm.targetColor)
 	case EventHealthCheckFailed:
+		if sm.enterBackoff(retryStepStartContainer) {
+			return sm.actions.StopContainer(sm.appName, sm.targetColor)
+		}
 		sm.state = StateRollingBack
 		return sm.actions.StopContainer(sm.appName, sm.targetColor)
 	default:
-		return fmt.Errorf("invalid event %s for state %s", event, sm.state)
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
+	}
+}
+
+// canaryWeights returns the current stage's traffic split: the target color
+// at canarySteps[canaryStepIdx] percent, the previous (still-stable) color
+// with the remainder.
+func (sm *DeploymentStateMachine) canaryWeights() map[string]int {
+	weight := sm.canarySteps[sm.canaryStepIdx]
+	return map[string]int{
+		sm.targetColor:   weight,
+		sm.previousColor: 100 - weight,
+	}
+}
+
+// handleCanaryState advances a canary rollout through its weight stages.
+// EventCanaryStepPassed first advances canaryStepIdx, then either re-issues
+// UpdateCaddyWeighted for that next stage, or - once it's advanced onto the
+// last stage (100%) - finishes the cutover to the target color alone and
+// moves to StateCanaryPromoting, since a weighted update to 100% and a plain
+// UpdateCaddy are equivalent. EventCanaryStepFailed restores full traffic to
+// the stable color and tears down the target container, the same shape as a
+// failed health check.
+func (sm *DeploymentStateMachine) handleCanaryState(event DeploymentEvent) error {
+	switch event {
+	case EventCanaryStepPassed:
+		sm.canaryStepIdx++
+		if sm.canaryStepIdx >= len(sm.canarySteps)-1 {
+			sm.state = StateCanaryPromoting
+			return sm.actions.UpdateCaddy(sm.appName, sm.targetColor)
+		}
+		sm.canaryStepStartedAt = time.Now()
+		return sm.actions.UpdateCaddyWeighted(sm.appName, sm.canaryWeights())
+	case EventCanaryStepFailed:
+		sm.state = StateRollingBack
+		if err := sm.actions.RollbackCaddy(sm.appName, sm.previousColor); err != nil {
+			return err
+		}
+		return sm.actions.StopContainer(sm.appName, sm.targetColor)
+	default:
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
+	}
+}
+
+// handleCanaryPromotingState mirrors handleSwitchingState: it's waiting on
+// the EventCaddyUpdated/EventCaddyFailed outcome of the full-cutover
+// UpdateCaddy call handleCanaryState already issued on entry.
+func (sm *DeploymentStateMachine) handleCanaryPromotingState(event DeploymentEvent) error {
+	switch event {
+	case EventCaddyUpdated:
+		sm.state = StateDraining
+		return sm.actions.DrainConnections(sm.appName, sm.previousColor, sm.drainTimeout)
+	case EventCaddyFailed:
+		if sm.enterBackoff(retryStepCaddyUpdate) {
+			return nil
+		}
+		sm.state = StateRollingBack
+		if err := sm.actions.StopContainer(sm.appName, sm.targetColor); err != nil {
+			return errs.Wrap(errs.ErrCaddyUpdate, err, "rolling back app %s after caddy update failed", sm.appName)
+		}
+		return nil
+	default:
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
 	}
 }
 
@@ -208,10 +951,16 @@ func (sm *DeploymentStateMachine) handleSwitchingState(event DeploymentEvent) er
 		sm.state = StateDraining
 		return sm.actions.DrainConnections(sm.appName, sm.previousColor, sm.drainTimeout)
 	case EventCaddyFailed:
+		if sm.enterBackoff(retryStepCaddyUpdate) {
+			return nil
+		}
 		sm.state = StateRollingBack
-		return sm.actions.StopContainer(sm.appName, sm.targetColor)
+		if err := sm.actions.StopContainer(sm.appName, sm.targetColor); err != nil {
+			return errs.Wrap(errs.ErrCaddyUpdate, err, "rolling back app %s after caddy update failed", sm.appName)
+		}
+		return nil
 	default:
-		return fmt.Errorf("invalid event %s for state %s", event, sm.state)
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
 	}
 }
 
@@ -220,8 +969,17 @@ func (sm *DeploymentStateMachine) handleDrainingState(event DeploymentEvent) err
 	case EventDrainComplete:
 		sm.state = StateStopping
 		return sm.actions.StopContainer(sm.appName, sm.previousColor)
+	case EventForcedRollback:
+		sm.state = StateRollingBack
+		if err := sm.actions.RollbackCaddy(sm.appName, sm.previousColor); err != nil {
+			return errs.Wrap(errs.ErrCaddyUpdate, err, "force rollback: failed to restore traffic to %s for app %s", sm.previousColor, sm.appName)
+		}
+		if err := sm.actions.DrainConnections(sm.appName, sm.targetColor, sm.drainTimeout); err != nil {
+			return err
+		}
+		return sm.actions.StopContainer(sm.appName, sm.targetColor)
 	default:
-		return fmt.Errorf("invalid event %s for state %s", event, sm.state)
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
 	}
 }
 
@@ -235,7 +993,7 @@ func (sm *DeploymentStateMachine) handleStoppingState(event DeploymentEvent) err
 		sm.state = StateFailed
 		return fmt.Errorf("failed to stop old container")
 	default:
-		return fmt.Errorf("invalid event %s for state %s", event, sm.state)
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
 	}
 }
 
@@ -248,7 +1006,7 @@ func (sm *DeploymentStateMachine) handleRollingBackState(event DeploymentEvent)
 		sm.state = StateFailed
 		return fmt.Errorf("rollback failed")
 	default:
-		return fmt.Errorf("invalid event %s for state %s", event, sm.state)
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
 	}
 }
 
@@ -258,39 +1016,203 @@ func (sm *DeploymentStateMachine) handleFailedState(event DeploymentEvent) error
 		sm.state = StateStable
 		return nil
 	default:
-		return fmt.Errorf("invalid event %s for state %s", event, sm.state)
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
 	}
 }
 
+// handleBackoffState re-issues whichever step enterBackoff recorded as
+// pendingRetry once EventBackoffElapsed arrives - a fresh StartContainer for
+// a container start or health check failure, a fresh UpdateCaddy for a
+// Caddy update failure. Like handleStartingState's original dispatch, the
+// re-issued action's real outcome is reported back later through
+// CompleteContainerOperation/CompleteCaddyUpdate, not through this call's
+// return value - a failure to even dispatch is discarded here, best effort,
+// so CompleteBackoff() doesn't short-circuit the retry loop before that
+// completion callback gets a chance to run.
+func (sm *DeploymentStateMachine) handleBackoffState(event DeploymentEvent) error {
+	switch event {
+	case EventBackoffElapsed:
+		switch sm.pendingRetry {
+		case retryStepStartContainer:
+			sm.state = StateStarting
+			_ = sm.actions.StartContainer(sm.appName, sm.targetColor, sm.newImage)
+			return nil
+		case retryStepCaddyUpdate:
+			sm.state = StateSwitching
+			_ = sm.actions.UpdateCaddy(sm.appName, sm.targetColor)
+			return nil
+		default:
+			return errs.Wrap(errs.ErrInvalidTransition, nil, "backoff elapsed with no pending retry step")
+		}
+	default:
+		return errs.Wrap(errs.ErrInvalidTransition, nil, "invalid event %s for state %s", event, sm.state)
+	}
+}
+
+// CheckHealth polls pollHealth (sm.healthCheckers if any are registered,
+// otherwise actions.CheckHealth), gated by healthPolicy.Interval so a caller
+// driving this off a faster ticker doesn't over-poll, and folds the result
+// into successStreak/failStreak. It only completes the health check once
+// healthPolicy.SuccessThreshold consecutive passes have accumulated, or once
+// healthPolicy.FailureThreshold consecutive fails have accumulated outside
+// healthPolicy.StartPeriod's grace window, or once healthPolicy.StartPeriod
+// plus healthPolicy.Timeout has elapsed since entering StateHealthCheck
+// without reaching
+// SuccessThreshold - whichever comes first. Both thresholds default to 1
+// when unset, so a zero-value HealthPolicy keeps the original
+// single-pass-to-switch, single-fail-to-rollback behavior. A call that
+// doesn't meet any of those returns nil: still checking.
 func (sm *DeploymentStateMachine) CheckHealth() error {
 	if sm.state != StateHealthCheck {
 		return fmt.Errorf("cannot check health in state %s", sm.state)
 	}
 
-	healthy, err := sm.actions.CheckHealth(sm.appName, sm.targetColor)
+	now := sm.nowFunc()
+	if sm.healthPolicy.Interval > 0 && !sm.lastHealthPollAt.IsZero() && now.Sub(sm.lastHealthPollAt) < sm.healthPolicy.Interval {
+		return nil
+	}
+	sm.lastHealthPollAt = now
+
+	result, err := sm.pollHealth()
 	if err != nil {
-		return sm.ProcessEvent(EventHealthCheckFailed)
+		result = HealthResult{Status: HealthStatusUnhealthy, Message: err.Error()}
+	}
+
+	if result.Status == HealthStatusHealthy {
+		sm.successStreak++
+		sm.failStreak = 0
+	} else {
+		sm.failStreak++
+		sm.successStreak = 0
+	}
+
+	successThreshold := sm.healthPolicy.SuccessThreshold
+	if successThreshold <= 0 {
+		successThreshold = 1
+	}
+	if sm.successStreak >= successThreshold {
+		return sm.CompleteHealthCheck(true, "")
 	}
 
-	if healthy {
-		return sm.ProcessEvent(EventHealthCheckPassed)
+	sinceStart := now.Sub(sm.healthCheckStartedAt)
+	window := sm.healthPolicy.StartPeriod + sm.healthPolicy.Timeout
+	if sm.healthPolicy.Timeout > 0 && sinceStart >= window {
+		return sm.CompleteHealthCheck(false, fmt.Sprintf(
+			"health check did not reach %d consecutive pass(es) within %s", successThreshold, window))
 	}
 
+	failureThreshold := sm.healthPolicy.FailureThreshold
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+	inStartPeriod := sm.healthPolicy.StartPeriod > 0 && sinceStart < sm.healthPolicy.StartPeriod
+	if !inStartPeriod && sm.failStreak >= failureThreshold {
+		return sm.CompleteHealthCheck(false, result.Message)
+	}
+
+	// Neither threshold nor timeout hit yet: persist the streak so a crash
+	// mid-flapping-health-check doesn't lose progress toward it, since
+	// CompleteHealthCheck (and its recordTransition checkpoint write) won't
+	// run until one of the returns above fires.
+	sm.persistCheckpoint()
 	return nil // Still checking
 }
 
-func (sm *DeploymentStateMachine) CompleteHealthCheck(passed bool) error {
-	if passed {
-		return sm.ProcessEvent(EventHealthCheckPassed)
-	} else {
+// pollHealth runs sm.healthCheckers if any are registered (all must pass,
+// first failure short-circuits the rest), falling back to
+// actions.CheckHealth otherwise.
+func (sm *DeploymentStateMachine) pollHealth() (HealthResult, error) {
+	if len(sm.healthCheckers) > 0 {
+		for _, checker := range sm.healthCheckers {
+			if err := checker.Check(context.Background()); err != nil {
+				return HealthResult{Status: HealthStatusUnhealthy, Message: fmt.Sprintf("%s probe failed: %v", checker.Name(), err)}, nil
+			}
+		}
+		return HealthResult{Status: HealthStatusHealthy}, nil
+	}
+
+	return sm.actions.CheckHealth(sm.appName, sm.targetColor)
+}
+
+// CompleteHealthCheck records reason (ignored when passed is true) so
+// handleHealthCheckState can fold it into the failed transition's error,
+// runs the post_health/pre_switch/on_rollback hooks the outcome calls for,
+// then raises the matching event.
+//
+// A failed health check runs on_rollback before handing off to
+// handleHealthCheckState's own StopContainer: a blocking hook's non-zero
+// exit means rollback never even starts, force-failing straight to
+// EventRollbackFailed instead. A passed health check runs post_health as an
+// extra gate on the promotion itself - a failure there is folded into
+// lastHealthCheckReason and raises EventHealthCheckFailed exactly like a
+// failed probe would - and, for a non-canary deploy, pre_switch as a gate on
+// the upcoming UpdateCaddy call, force-failing to EventCaddyFailed without
+// ever calling it.
+func (sm *DeploymentStateMachine) CompleteHealthCheck(passed bool, reason string) error {
+	sm.lastHealthCheckReason = reason
+
+	if !passed {
+		if err := sm.runHookPhase("on_rollback", sm.hooks.OnRollback); err != nil {
+			sm.state = StateRollingBack
+			return sm.ProcessEvent(EventRollbackFailed)
+		}
 		return sm.ProcessEvent(EventHealthCheckFailed)
 	}
+
+	if err := sm.runHookPhase("post_health", sm.hooks.PostHealth); err != nil {
+		sm.lastHealthCheckReason = fmt.Sprintf("post_health hook failed: %v", err)
+		return sm.ProcessEvent(EventHealthCheckFailed)
+	}
+
+	if len(sm.canarySteps) == 0 {
+		if err := sm.runHookPhase("pre_switch", sm.hooks.PreSwitch); err != nil {
+			sm.state = StateSwitching
+			return sm.ProcessEvent(EventCaddyFailed)
+		}
+	}
+
+	return sm.ProcessEvent(EventHealthCheckPassed)
+}
+
+// ConnectionsRemaining reports how many connections are still draining away
+// from the outgoing color, for CLI/status output to show drain progress. It
+// only means anything while draining; outside StateDraining there's nothing
+// being drained, so it reports 0.
+func (sm *DeploymentStateMachine) ConnectionsRemaining() int {
+	if sm.state != StateDraining {
+		return 0
+	}
+	return sm.actions.ConnectionsRemaining(sm.appName, sm.previousColor)
 }
 
 func (sm *DeploymentStateMachine) CompleteDrain() error {
 	return sm.ProcessEvent(EventDrainComplete)
 }
 
+// CompleteBackoff raises EventBackoffElapsed, re-issuing whichever step
+// enterBackoff recorded as pendingRetry. Callers should gate this behind
+// BackoffElapsed the same way CompleteCanaryStep callers gate on
+// CanaryStepStartedAt/config.CanaryPlan.StepDuration.
+func (sm *DeploymentStateMachine) CompleteBackoff() error {
+	return sm.ProcessEvent(EventBackoffElapsed)
+}
+
+// CompleteCanaryStep records whether the current canary weight stage's
+// soak/health window passed, raising EventCanaryStepPassed (advancing to the
+// next stage, or promoting to full traffic on the last one) or
+// EventCanaryStepFailed (triggering an automatic rollback) accordingly.
+func (sm *DeploymentStateMachine) CompleteCanaryStep(success bool) error {
+	if success {
+		return sm.ProcessEvent(EventCanaryStepPassed)
+	}
+
+	if err := sm.runHookPhase("on_rollback", sm.hooks.OnRollback); err != nil {
+		sm.state = StateRollingBack
+		return sm.ProcessEvent(EventRollbackFailed)
+	}
+	return sm.ProcessEvent(EventCanaryStepFailed)
+}
+
 func (sm *DeploymentStateMachine) CompleteContainerOperation(success bool, isRollback bool) error {
 	if isRollback {
 		if success {
@@ -318,18 +1240,84 @@ func (sm *DeploymentStateMachine) CompleteContainerOperation(success bool, isRol
 	}
 }
 
+// CompleteCaddyUpdate runs post_switch and pre_drain (on a successful
+// update, both best-effort: there's no sensible way to unwind a cutover
+// that's already live) or on_rollback (on a failed one, gating the rollback
+// itself - a blocking hook's non-zero exit force-fails straight to
+// EventRollbackFailed without ever calling StopContainer) before raising the
+// matching event.
 func (sm *DeploymentStateMachine) CompleteCaddyUpdate(success bool) error {
 	if success {
+		_ = sm.runHookPhase("post_switch", sm.hooks.PostSwitch)
+		_ = sm.runHookPhase("pre_drain", sm.hooks.PreDrain)
 		return sm.ProcessEvent(EventCaddyUpdated)
-	} else {
-		return sm.ProcessEvent(EventCaddyFailed)
 	}
+
+	if err := sm.runHookPhase("on_rollback", sm.hooks.OnRollback); err != nil {
+		sm.state = StateRollingBack
+		return sm.ProcessEvent(EventRollbackFailed)
+	}
+	return sm.ProcessEvent(EventCaddyFailed)
 }
 
 func (sm *DeploymentStateMachine) RecoverManually() error {
 	return sm.ProcessEvent(EventManualRecovery)
 }
 
+// Interrupt force-transitions an in-progress deployment straight to
+// StateFailed instead of leaving it stuck in whatever in-flight state cause
+// cut it off in - the deployment loop's own timeout, or a
+// shutdown.Coordinator cancellation. When shutdown is true, the persisted
+// current_state.Status is left as "interrupted" rather than the "failed"
+// recordTransition would otherwise set, so a later RecoverApp call (wired
+// up through ResumeInterrupted at startup) can tell a shutdown cut this
+// deployment off mid-flight rather than it failing on its own terms. It's a
+// no-op once the machine has already reached a safe checkpoint
+// (StateStable/StateFailed).
+func (sm *DeploymentStateMachine) Interrupt(cause error, shutdown bool) error {
+	if !sm.IsInProgress() {
+		return nil
+	}
+
+	fromState := sm.state
+	sm.state = StateFailed
+	sm.recordTransition(fromState, StateFailed, EventInterrupted, cause)
+
+	if shutdown && sm.db != nil {
+		_ = state.UpsertCurrentState(sm.db, sm.appName, sm.deploymentID, sm.activeColor, sm.newImage, "interrupted")
+	}
+	return cause
+}
+
+// ResumeInterrupted transitions a freshly constructed (StateStable) machine
+// straight to StateFailed, for a caller that found this app's persisted
+// current_state.Status == "interrupted": a previous process's
+// shutdown.Coordinator cut a deployment off mid-flight, so this process
+// should refuse new deploys until an operator runs RecoverManually rather
+// than silently treating the half-finished rollout as settled. It's a no-op
+// on a machine that isn't freshly stable.
+func (sm *DeploymentStateMachine) ResumeInterrupted() {
+	if sm.state != StateStable {
+		return
+	}
+	sm.recordTransition(sm.state, StateFailed, EventInterrupted, fmt.Errorf("resuming after previous process was interrupted mid-deployment"))
+	sm.state = StateFailed
+}
+
+// NotifyContainerDied is called by an out-of-band signal (e.g. a
+// docker.EventWatcher observing a "die"/"oom" event) reporting that the
+// container for colorThatDied has exited. If that color is the currently
+// active one and the machine is otherwise idle (StateStable), this fails the
+// deployment immediately instead of waiting for the next health-check tick.
+// Events for any other color, or while a deployment is already in progress,
+// are ignored here since the normal state transitions already cover them.
+func (sm *DeploymentStateMachine) NotifyContainerDied(colorThatDied string) error {
+	if sm.state != StateStable || colorThatDied != sm.activeColor {
+		return nil
+	}
+	return sm.ProcessEvent(EventActiveContainerDied)
+}
+
 func (sm *DeploymentStateMachine) getInactiveColor() string {
 	if sm.activeColor == "blue" {
 		return "green"
@@ -337,25 +1325,92 @@ func (sm *DeploymentStateMachine) getInactiveColor() string {
 	return "blue"
 }
 
+// persistCheckpoint writes the retry/backoff/health-streak/desired-transition
+// fields current_state doesn't carry. It is its own method (rather than
+// inline in recordTransition) because SetDesiredTransition also needs to call
+// it directly: runDeploymentLoop stops calling ProcessEvent - and so stops
+// reaching recordTransition - for as long as IsPaused() is true, so a Pause
+// set just before a crash would otherwise never make it to disk.
+func (sm *DeploymentStateMachine) persistCheckpoint() {
+	if sm.db == nil {
+		return
+	}
+	_ = state.UpsertDeploymentCheckpoint(sm.db, state.DeploymentCheckpoint{
+		AppName:              sm.appName,
+		RetryCount:           sm.retryCount,
+		BackoffStartedAt:     sm.backoffStartedAt,
+		BackoffDuration:      sm.backoffDuration,
+		DesiredCancel:        sm.desired.Cancel,
+		DesiredPause:         sm.desired.Pause,
+		DesiredForceRollback: sm.desired.ForceRollback,
+		HealthSuccessStreak:  sm.successStreak,
+		HealthFailStreak:     sm.failStreak,
+		HealthCheckStartedAt: sm.healthCheckStartedAt,
+		UpdatedAt:            time.Now(),
+	})
+}
+
+// recordTransition appends a StateTransition to history (and persists/
+// publishes it) using recordedErr in place of err when err is nil but
+// CompleteHealthCheck left a reason behind for this exact failure event -
+// EventHealthCheckFailed's own handler returns nil whenever rolling back the
+// target container succeeds, which would otherwise bury why the health
+// check itself failed. The caller-visible return value from ProcessEvent is
+// untouched; only what's recorded in history/DB/bus changes.
 func (sm *DeploymentStateMachine) recordTransition(fromState, toState DeploymentState, event DeploymentEvent, err error) {
+	recordedErr := err
+	if event == EventHealthCheckFailed && recordedErr == nil && sm.lastHealthCheckReason != "" {
+		recordedErr = errs.Wrap(errs.ErrHealthCheck, nil, "%s", sm.lastHealthCheckReason)
+	}
+	switch event {
+	case EventContainerFailed, EventCaddyFailed, EventRollbackFailed:
+		if recordedErr == nil && sm.lastHookFailure != "" {
+			recordedErr = errs.Wrap(errs.ErrHookFailed, nil, "%s", sm.lastHookFailure)
+		}
+	}
+
 	transition := StateTransition{
-		FromState: fromState,
-		ToState:   toState,
-		Event:     event,
-		Timestamp: time.Now(),
-		Error:     err,
+		FromState:    fromState,
+		ToState:      toState,
+		Event:        event,
+		Timestamp:    time.Now(),
+		Error:        recordedErr,
+		HealthStreak: sm.GetHealthStreak(),
 	}
 	sm.stateHistory = append(sm.stateHistory, transition)
 
+	// hookOutputs rides along with this transition's own deployment_events
+	// row instead of a separate one, since it's exactly the hooks that ran
+	// (synchronously) to produce this transition.
+	hookOutputs := sm.lastHookOutputs
+	sm.lastHookOutputs = nil
+
 	// --- DB: Persist event and update current state ---
 	if sm.db != nil && sm.deploymentID != 0 {
 		errMsg := ""
-		if err != nil {
-			errMsg = err.Error()
+		if recordedErr != nil {
+			errMsg = recordedErr.Error()
 		}
-		_, _ = state.InsertDeploymentEvent(sm.db, sm.deploymentID, sm.appName, string(event), "{}", &errMsg)
+		_, _ = state.InsertDeploymentEvent(sm.db, sm.deploymentID, sm.appName, string(event), hookPayload(hookOutputs), &errMsg)
 		_ = state.UpsertCurrentState(sm.db, sm.appName, sm.deploymentID, sm.activeColor, sm.newImage, string(toState))
 	}
+	sm.persistCheckpoint()
+
+	if sm.bus != nil {
+		sm.bus.Publish(events.Event{
+			AppName:   sm.appName,
+			Color:     sm.targetColor,
+			FromState: string(fromState),
+			ToState:   string(toState),
+			Image:     sm.newImage,
+			Timestamp: transition.Timestamp,
+			Error:     recordedErr,
+		})
+	}
+
+	if toState == StateFailed && fromState != StateFailed {
+		sm.fireOnFailureHooks()
+	}
 }
 
 func (sm *DeploymentStateMachine) GetActiveColor() string {
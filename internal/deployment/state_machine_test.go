@@ -1,27 +1,36 @@
 package deployment
 
 import (
+	"dockswap/internal/errs"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 )
 
 type MockActionProvider struct {
-	startContainerError   error
-	checkHealthResult     bool
-	checkHealthError      error
-	updateCaddyError      error
-	drainConnectionsError error
-	stopContainerError    error
-	rollbackCaddyError    error
+	startContainerError error
+	// checkHealthResults is popped one HealthResult per CheckHealth call,
+	// repeating the last entry once exhausted, so a test can script a
+	// sequence like [unhealthy, unhealthy, healthy] to exercise flapping.
+	checkHealthResults       []HealthResult
+	checkHealthIdx           int
+	checkHealthError         error
+	updateCaddyError         error
+	updateCaddyWeightedError error
+	drainConnectionsError    error
+	connectionsRemaining     int
+	stopContainerError       error
+	rollbackCaddyError       error
 
 	// Call tracking
-	startContainerCalls   []StartContainerCall
-	checkHealthCalls      []CheckHealthCall
-	updateCaddyCalls      []UpdateCaddyCall
-	drainConnectionsCalls []DrainConnectionsCall
-	stopContainerCalls    []StopContainerCall
-	rollbackCaddyCalls    []RollbackCaddyCall
+	startContainerCalls      []StartContainerCall
+	checkHealthCalls         []CheckHealthCall
+	updateCaddyCalls         []UpdateCaddyCall
+	updateCaddyWeightedCalls []UpdateCaddyWeightedCall
+	drainConnectionsCalls    []DrainConnectionsCall
+	stopContainerCalls       []StopContainerCall
+	rollbackCaddyCalls       []RollbackCaddyCall
 }
 
 type StartContainerCall struct {
@@ -40,6 +49,11 @@ type UpdateCaddyCall struct {
 	ActiveColor string
 }
 
+type UpdateCaddyWeightedCall struct {
+	AppName string
+	Weights map[string]int
+}
+
 type DrainConnectionsCall struct {
 	AppName string
 	Color   string
@@ -65,12 +79,23 @@ func (m *MockActionProvider) StartContainer(appName, color, image string) error
 	return m.startContainerError
 }
 
-func (m *MockActionProvider) CheckHealth(appName, color string) (bool, error) {
+func (m *MockActionProvider) CheckHealth(appName, color string) (HealthResult, error) {
 	m.checkHealthCalls = append(m.checkHealthCalls, CheckHealthCall{
 		AppName: appName,
 		Color:   color,
 	})
-	return m.checkHealthResult, m.checkHealthError
+	if m.checkHealthError != nil {
+		return HealthResult{}, m.checkHealthError
+	}
+	if len(m.checkHealthResults) == 0 {
+		return HealthResult{Status: HealthStatusUnhealthy}, nil
+	}
+	idx := m.checkHealthIdx
+	if idx >= len(m.checkHealthResults) {
+		idx = len(m.checkHealthResults) - 1
+	}
+	m.checkHealthIdx++
+	return m.checkHealthResults[idx], nil
 }
 
 func (m *MockActionProvider) UpdateCaddy(appName, activeColor string) error {
@@ -81,6 +106,14 @@ func (m *MockActionProvider) UpdateCaddy(appName, activeColor string) error {
 	return m.updateCaddyError
 }
 
+func (m *MockActionProvider) UpdateCaddyWeighted(appName string, weights map[string]int) error {
+	m.updateCaddyWeightedCalls = append(m.updateCaddyWeightedCalls, UpdateCaddyWeightedCall{
+		AppName: appName,
+		Weights: weights,
+	})
+	return m.updateCaddyWeightedError
+}
+
 func (m *MockActionProvider) DrainConnections(appName, color string, timeout time.Duration) error {
 	m.drainConnectionsCalls = append(m.drainConnectionsCalls, DrainConnectionsCall{
 		AppName: appName,
@@ -90,6 +123,10 @@ func (m *MockActionProvider) DrainConnections(appName, color string, timeout tim
 	return m.drainConnectionsError
 }
 
+func (m *MockActionProvider) ConnectionsRemaining(appName, color string) int {
+	return m.connectionsRemaining
+}
+
 func (m *MockActionProvider) StopContainer(appName, color string) error {
 	m.stopContainerCalls = append(m.stopContainerCalls, StopContainerCall{
 		AppName: appName,
@@ -152,7 +189,7 @@ func TestSuccessfulDeployment(t *testing.T) {
 	}
 
 	// Health check passed
-	err = sm.CompleteHealthCheck(true)
+	err = sm.CompleteHealthCheck(true, "")
 	if err != nil {
 		t.Fatalf("CompleteHealthCheck() failed: %v", err)
 	}
@@ -203,6 +240,32 @@ func TestSuccessfulDeployment(t *testing.T) {
 	}
 }
 
+func TestConnectionsRemaining(t *testing.T) {
+	actions := &MockActionProvider{connectionsRemaining: 3}
+	sm := New("test-app", "blue", actions, nil)
+
+	if got := sm.ConnectionsRemaining(); got != 0 {
+		t.Errorf("ConnectionsRemaining() outside StateDraining = %d, want 0", got)
+	}
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+	sm.CompleteHealthCheck(true, "")
+	sm.CompleteCaddyUpdate(true)
+
+	if sm.GetState() != StateDraining {
+		t.Fatalf("setup failed: state = %v, want %v", sm.GetState(), StateDraining)
+	}
+	if got := sm.ConnectionsRemaining(); got != 3 {
+		t.Errorf("ConnectionsRemaining() while draining = %d, want 3", got)
+	}
+
+	actions.connectionsRemaining = 0
+	if got := sm.ConnectionsRemaining(); got != 0 {
+		t.Errorf("ConnectionsRemaining() after drain = %d, want 0", got)
+	}
+}
+
 func TestContainerStartFailure(t *testing.T) {
 	actions := &MockActionProvider{
 		startContainerError: fmt.Errorf("container start failed"),
@@ -237,7 +300,7 @@ func TestHealthCheckFailureRollback(t *testing.T) {
 	sm.CompleteContainerOperation(true, false)
 
 	// Health check failed
-	err := sm.CompleteHealthCheck(false)
+	err := sm.CompleteHealthCheck(false, "probe failed")
 	if err != nil {
 		t.Fatalf("CompleteHealthCheck() failed: %v", err)
 	}
@@ -275,7 +338,7 @@ func TestCaddyUpdateFailureRollback(t *testing.T) {
 	// Get to switching state
 	sm.Deploy("nginx:1.22")
 	sm.CompleteContainerOperation(true, false)
-	sm.CompleteHealthCheck(true)
+	sm.CompleteHealthCheck(true, "")
 
 	// Caddy update failed
 	err := sm.CompleteCaddyUpdate(false)
@@ -287,6 +350,358 @@ func TestCaddyUpdateFailureRollback(t *testing.T) {
 	}
 }
 
+func TestDesiredTransitionCancelDuringHealthCheck(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+	if sm.GetState() != StateHealthCheck {
+		t.Fatalf("state before cancel = %v, want %v", sm.GetState(), StateHealthCheck)
+	}
+
+	cancel := true
+	sm.SetDesiredTransition(DesiredTransition{Cancel: &cancel})
+
+	if err := sm.CompleteHealthCheck(true, ""); err != nil {
+		t.Fatalf("CompleteHealthCheck() after cancel = %v, want nil", err)
+	}
+	if sm.GetState() != StateRollingBack {
+		t.Errorf("state after cancel during health check = %v, want %v", sm.GetState(), StateRollingBack)
+	}
+	if len(actions.stopContainerCalls) != 1 || actions.stopContainerCalls[0].Color != "green" {
+		t.Errorf("stopContainerCalls = %v, want one call stopping green", actions.stopContainerCalls)
+	}
+}
+
+func TestDesiredTransitionCancelDuringDrainIsTooLate(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+	sm.CompleteHealthCheck(true, "")
+	sm.CompleteCaddyUpdate(true)
+	if sm.GetState() != StateDraining {
+		t.Fatalf("state before cancel = %v, want %v", sm.GetState(), StateDraining)
+	}
+
+	cancel := true
+	sm.SetDesiredTransition(DesiredTransition{Cancel: &cancel})
+
+	if err := sm.CompleteDrain(); err != nil {
+		t.Fatalf("CompleteDrain() with cancel set = %v, want nil", err)
+	}
+	if sm.GetState() != StateStopping {
+		t.Errorf("cancel during drain state = %v, want %v (cancel should be a no-op this late)", sm.GetState(), StateStopping)
+	}
+	if actions.stopContainerCalls[len(actions.stopContainerCalls)-1].Color != "blue" {
+		t.Errorf("drain should still proceed to stop the old color, stopContainerCalls = %v", actions.stopContainerCalls)
+	}
+}
+
+func TestDesiredTransitionForceRollbackAfterSwitch(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+	sm.CompleteHealthCheck(true, "")
+	sm.CompleteCaddyUpdate(true)
+	if sm.GetState() != StateDraining {
+		t.Fatalf("state before force-rollback = %v, want %v", sm.GetState(), StateDraining)
+	}
+
+	forceRollback := true
+	sm.SetDesiredTransition(DesiredTransition{ForceRollback: &forceRollback})
+
+	if err := sm.CompleteDrain(); err != nil {
+		t.Fatalf("CompleteDrain() with force-rollback set = %v, want nil", err)
+	}
+	if sm.GetState() != StateRollingBack {
+		t.Errorf("state after force-rollback = %v, want %v", sm.GetState(), StateRollingBack)
+	}
+	if len(actions.rollbackCaddyCalls) != 1 || actions.rollbackCaddyCalls[0].ActiveColor != "blue" {
+		t.Errorf("rollbackCaddyCalls = %v, want one call restoring blue", actions.rollbackCaddyCalls)
+	}
+	lastDrain := actions.drainConnectionsCalls[len(actions.drainConnectionsCalls)-1]
+	if lastDrain.Color != "green" {
+		t.Errorf("drainConnectionsCalls = %v, want the last call draining green", actions.drainConnectionsCalls)
+	}
+	if len(actions.stopContainerCalls) != 1 || actions.stopContainerCalls[0].Color != "green" {
+		t.Errorf("stopContainerCalls = %v, want one call stopping green", actions.stopContainerCalls)
+	}
+
+	// Complete the rollback the normal way.
+	if err := sm.CompleteContainerOperation(true, true); err != nil {
+		t.Fatalf("CompleteContainerOperation() rollback = %v, want nil", err)
+	}
+	if sm.GetState() != StateStable {
+		t.Errorf("state after rollback completes = %v, want %v", sm.GetState(), StateStable)
+	}
+}
+
+func TestGetDesiredTransitionRoundTrips(t *testing.T) {
+	sm := New("test-app", "blue", &MockActionProvider{}, nil)
+
+	pause := true
+	sm.SetDesiredTransition(DesiredTransition{Pause: &pause})
+
+	if !sm.IsPaused() {
+		t.Errorf("IsPaused() = false, want true")
+	}
+	got := sm.GetDesiredTransition()
+	if got.Pause == nil || *got.Pause != true {
+		t.Errorf("GetDesiredTransition().Pause = %v, want true", got.Pause)
+	}
+}
+
+func TestRestartPolicyNeverFailsImmediately(t *testing.T) {
+	actions := &MockActionProvider{startContainerError: errors.New("boom")}
+	sm := New("test-app", "blue", actions, nil)
+	// Zero-value RestartPolicy (never set) behaves like RestartPolicyNever.
+
+	sm.Deploy("nginx:1.22")
+	if err := sm.CompleteContainerOperation(false, false); err == nil {
+		t.Fatal("CompleteContainerOperation() = nil, want container start error")
+	}
+	if sm.GetState() != StateFailed {
+		t.Errorf("state = %v, want %v", sm.GetState(), StateFailed)
+	}
+	if sm.GetRetryCount() != 0 {
+		t.Errorf("GetRetryCount() = %d, want 0", sm.GetRetryCount())
+	}
+}
+
+func TestRestartPolicyRetriesContainerStart(t *testing.T) {
+	actions := &MockActionProvider{startContainerError: errors.New("boom")}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetRestartPolicy(RestartPolicy{
+		Policy:            RestartPolicyAlways,
+		MaxRetries:        2,
+		BackoffInitial:    time.Second,
+		BackoffMultiplier: 2,
+	})
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sm.SetClock(func() time.Time { return now })
+
+	sm.Deploy("nginx:1.22")
+	if err := sm.CompleteContainerOperation(false, false); err != nil {
+		t.Fatalf("CompleteContainerOperation() = %v, want nil (retry scheduled)", err)
+	}
+	if sm.GetState() != StateBackoff {
+		t.Fatalf("state after first failure = %v, want %v", sm.GetState(), StateBackoff)
+	}
+	if sm.GetRetryCount() != 1 {
+		t.Errorf("GetRetryCount() = %d, want 1", sm.GetRetryCount())
+	}
+
+	if sm.BackoffElapsed() {
+		t.Error("BackoffElapsed() = true before any time passed, want false")
+	}
+	now = now.Add(999 * time.Millisecond)
+	if sm.BackoffElapsed() {
+		t.Error("BackoffElapsed() = true at 999ms, want false (backoff_initial is 1s)")
+	}
+	now = now.Add(2 * time.Millisecond)
+	if !sm.BackoffElapsed() {
+		t.Error("BackoffElapsed() = false at 1.001s, want true")
+	}
+
+	actions.startContainerError = nil
+	if err := sm.CompleteBackoff(); err != nil {
+		t.Fatalf("CompleteBackoff() = %v, want nil", err)
+	}
+	if sm.GetState() != StateStarting {
+		t.Errorf("state after backoff elapsed = %v, want %v", sm.GetState(), StateStarting)
+	}
+	if len(actions.startContainerCalls) != 2 {
+		t.Errorf("startContainerCalls = %d, want 2 (initial deploy + one retry)", len(actions.startContainerCalls))
+	}
+
+	if err := sm.CompleteContainerOperation(true, false); err != nil {
+		t.Fatalf("CompleteContainerOperation() after retry = %v, want nil", err)
+	}
+	if sm.GetState() != StateHealthCheck {
+		t.Errorf("state after successful retry = %v, want %v", sm.GetState(), StateHealthCheck)
+	}
+}
+
+func TestRestartPolicyOnFailureRetriesCaddyUpdate(t *testing.T) {
+	actions := &MockActionProvider{updateCaddyError: errors.New("caddy unreachable")}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetRestartPolicy(RestartPolicy{
+		Policy:         RestartPolicyOnFailure,
+		MaxRetries:     1,
+		BackoffInitial: time.Millisecond,
+	})
+	sm.SetClock(func() time.Time { return time.Unix(0, 0) })
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+	sm.CompleteHealthCheck(true, "")
+	if sm.GetState() != StateSwitching {
+		t.Fatalf("state before caddy failure = %v, want %v", sm.GetState(), StateSwitching)
+	}
+
+	if err := sm.CompleteCaddyUpdate(false); err != nil {
+		t.Fatalf("CompleteCaddyUpdate() = %v, want nil (retry scheduled)", err)
+	}
+	if sm.GetState() != StateBackoff {
+		t.Fatalf("state after caddy failure = %v, want %v", sm.GetState(), StateBackoff)
+	}
+	if len(actions.stopContainerCalls) != 0 {
+		t.Errorf("stopContainerCalls = %v, want none (container is healthy, only caddy failed)", actions.stopContainerCalls)
+	}
+
+	actions.updateCaddyError = nil
+	if err := sm.CompleteBackoff(); err != nil {
+		t.Fatalf("CompleteBackoff() = %v, want nil", err)
+	}
+	if sm.GetState() != StateSwitching {
+		t.Errorf("state after backoff elapsed = %v, want %v", sm.GetState(), StateSwitching)
+	}
+	if len(actions.updateCaddyCalls) != 2 {
+		t.Errorf("updateCaddyCalls = %d, want 2 (initial switch + one retry)", len(actions.updateCaddyCalls))
+	}
+
+	if err := sm.CompleteCaddyUpdate(true); err != nil {
+		t.Fatalf("CompleteCaddyUpdate() after retry = %v, want nil", err)
+	}
+	if sm.GetState() != StateDraining {
+		t.Errorf("state after successful retry = %v, want %v", sm.GetState(), StateDraining)
+	}
+}
+
+func TestRestartPolicyExhaustsMaxRetries(t *testing.T) {
+	actions := &MockActionProvider{startContainerError: errors.New("boom")}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetRestartPolicy(RestartPolicy{
+		Policy:         RestartPolicyAlways,
+		MaxRetries:     2,
+		BackoffInitial: time.Millisecond,
+	})
+	sm.SetClock(func() time.Time { return time.Unix(0, 0) })
+
+	sm.Deploy("nginx:1.22")
+	for i := 0; i < 2; i++ {
+		if err := sm.CompleteContainerOperation(false, false); err != nil {
+			t.Fatalf("retry %d: CompleteContainerOperation() = %v, want nil", i+1, err)
+		}
+		if sm.GetState() != StateBackoff {
+			t.Fatalf("retry %d: state = %v, want %v", i+1, sm.GetState(), StateBackoff)
+		}
+		if err := sm.CompleteBackoff(); err != nil {
+			t.Fatalf("retry %d: CompleteBackoff() = %v, want nil", i+1, err)
+		}
+	}
+
+	// Third failure exceeds MaxRetries (2), so it should fail for good.
+	if err := sm.CompleteContainerOperation(false, false); err == nil {
+		t.Fatal("CompleteContainerOperation() after exhausting retries = nil, want container start error")
+	}
+	if sm.GetState() != StateFailed {
+		t.Errorf("state after exhausting retries = %v, want %v", sm.GetState(), StateFailed)
+	}
+	if sm.GetRetryCount() != 2 {
+		t.Errorf("GetRetryCount() = %d, want 2 (capped at MaxRetries)", sm.GetRetryCount())
+	}
+}
+
+func TestHealthPolicyStreaksSurviveFlapping(t *testing.T) {
+	actions := &MockActionProvider{
+		checkHealthResults: []HealthResult{
+			{Status: HealthStatusUnhealthy},
+			{Status: HealthStatusHealthy},
+			{Status: HealthStatusUnhealthy},
+			{Status: HealthStatusHealthy},
+			{Status: HealthStatusHealthy},
+		},
+	}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetHealthPolicy(HealthPolicy{SuccessThreshold: 2, FailureThreshold: 3})
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+	if sm.GetState() != StateHealthCheck {
+		t.Fatalf("state = %v, want %v", sm.GetState(), StateHealthCheck)
+	}
+
+	// Neither threshold is reached while the streak keeps resetting, so the
+	// deployment should sit in StateHealthCheck through every flap.
+	for i := 0; i < 4; i++ {
+		if err := sm.CheckHealth(); err != nil {
+			t.Fatalf("poll %d: CheckHealth() = %v, want nil", i+1, err)
+		}
+		if sm.GetState() != StateHealthCheck {
+			t.Fatalf("poll %d: state = %v, want %v (still flapping)", i+1, sm.GetState(), StateHealthCheck)
+		}
+	}
+
+	// Fifth poll is the second consecutive healthy result, reaching
+	// SuccessThreshold.
+	if err := sm.CheckHealth(); err != nil {
+		t.Fatalf("final poll: CheckHealth() = %v, want nil", err)
+	}
+	if sm.GetState() != StateSwitching {
+		t.Errorf("state after reaching SuccessThreshold = %v, want %v", sm.GetState(), StateSwitching)
+	}
+}
+
+func TestHealthPolicyZeroValueFailsImmediately(t *testing.T) {
+	actions := &MockActionProvider{
+		checkHealthResults: []HealthResult{{Status: HealthStatusUnhealthy}},
+	}
+	sm := New("test-app", "blue", actions, nil)
+	// No SetHealthPolicy call: the zero-value HealthPolicy must still roll
+	// back on the very first failing poll, same as before HealthPolicy
+	// existed.
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+
+	if err := sm.CheckHealth(); err != nil {
+		t.Fatalf("CheckHealth() = %v, want nil", err)
+	}
+	if sm.GetState() != StateRollingBack {
+		t.Errorf("state after single failure under zero-value HealthPolicy = %v, want %v", sm.GetState(), StateRollingBack)
+	}
+}
+
+func TestHealthPolicyStartPeriodGracePeriod(t *testing.T) {
+	actions := &MockActionProvider{
+		checkHealthResults: []HealthResult{{Status: HealthStatusUnhealthy}},
+	}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetHealthPolicy(HealthPolicy{FailureThreshold: 1, StartPeriod: 5 * time.Second})
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sm.SetClock(func() time.Time { return now })
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+
+	// A failure inside StartPeriod is a grace-period freebie: it still
+	// counts toward failStreak, but FailureThreshold isn't consulted yet.
+	if err := sm.CheckHealth(); err != nil {
+		t.Fatalf("CheckHealth() during start period = %v, want nil", err)
+	}
+	if sm.GetState() != StateHealthCheck {
+		t.Fatalf("state during start period = %v, want %v", sm.GetState(), StateHealthCheck)
+	}
+
+	// Once StartPeriod has elapsed, the very next failure (failStreak already
+	// at 1, FailureThreshold is 1) rolls the deployment back.
+	now = now.Add(6 * time.Second)
+	if err := sm.CheckHealth(); err != nil {
+		t.Fatalf("CheckHealth() after start period = %v, want nil", err)
+	}
+	if sm.GetState() != StateRollingBack {
+		t.Errorf("state after start period elapsed = %v, want %v", sm.GetState(), StateRollingBack)
+	}
+}
+
 func TestInvalidStateTransitions(t *testing.T) {
 	actions := &MockActionProvider{}
 	sm := New("test-app", "blue", actions, nil)
@@ -312,6 +727,9 @@ func TestInvalidStateTransitions(t *testing.T) {
 			} else if !tt.shouldError && err != nil {
 				t.Errorf("ProcessEvent() should not fail: %v", err)
 			}
+			if tt.shouldError && !errors.Is(err, errs.ErrInvalidTransition) {
+				t.Errorf("ProcessEvent() err = %v, want errors.Is(err, errs.ErrInvalidTransition)", err)
+			}
 		})
 	}
 }
@@ -434,3 +852,142 @@ func TestGetInactiveColor(t *testing.T) {
 		t.Errorf("Inactive color for green active = %v, want blue", greenActiveSm.getInactiveColor())
 	}
 }
+
+func TestNotifyContainerDied_ActiveColorFailsImmediately(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+
+	err := sm.NotifyContainerDied("blue")
+	if err == nil {
+		t.Fatal("expected NotifyContainerDied to return an error for the active color")
+	}
+	if sm.GetState() != StateFailed {
+		t.Errorf("state = %v, want %v", sm.GetState(), StateFailed)
+	}
+}
+
+func TestNotifyContainerDied_InactiveColorIgnored(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+
+	if err := sm.NotifyContainerDied("green"); err != nil {
+		t.Errorf("expected nil error for inactive color, got %v", err)
+	}
+	if sm.GetState() != StateStable {
+		t.Errorf("state = %v, want %v", sm.GetState(), StateStable)
+	}
+}
+
+func TestNotifyContainerDied_IgnoredMidDeployment(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.state = StateHealthCheck
+
+	if err := sm.NotifyContainerDied("blue"); err != nil {
+		t.Errorf("expected nil error while a deployment is in progress, got %v", err)
+	}
+	if sm.GetState() != StateHealthCheck {
+		t.Errorf("state = %v, want unchanged %v", sm.GetState(), StateHealthCheck)
+	}
+}
+
+func TestCanaryStepsThroughStagesToPromotion(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetCanarySteps([]int{5, 25, 100})
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+
+	// Health check passed: first canary stage (5%) applied.
+	if err := sm.CompleteHealthCheck(true, ""); err != nil {
+		t.Fatalf("CompleteHealthCheck() failed: %v", err)
+	}
+	if sm.GetState() != StateCanary {
+		t.Fatalf("state = %v, want %v", sm.GetState(), StateCanary)
+	}
+	if len(actions.updateCaddyWeightedCalls) != 1 {
+		t.Fatalf("UpdateCaddyWeighted called %d times, want 1", len(actions.updateCaddyWeightedCalls))
+	}
+	if w := actions.updateCaddyWeightedCalls[0].Weights; w["green"] != 5 || w["blue"] != 95 {
+		t.Errorf("first stage weights = %+v, want green=5 blue=95", w)
+	}
+	if sm.CanaryWeight() != 5 {
+		t.Errorf("CanaryWeight() = %d, want 5", sm.CanaryWeight())
+	}
+
+	// Stage passed: advances to 25%.
+	if err := sm.CompleteCanaryStep(true); err != nil {
+		t.Fatalf("CompleteCanaryStep() failed: %v", err)
+	}
+	if sm.GetState() != StateCanary {
+		t.Fatalf("state = %v, want %v", sm.GetState(), StateCanary)
+	}
+	if len(actions.updateCaddyWeightedCalls) != 2 {
+		t.Fatalf("UpdateCaddyWeighted called %d times, want 2", len(actions.updateCaddyWeightedCalls))
+	}
+	if w := actions.updateCaddyWeightedCalls[1].Weights; w["green"] != 25 {
+		t.Errorf("second stage weight = %+v, want green=25", w)
+	}
+
+	// Second stage (25%) passed: advancing onto the last stage (100%) is a
+	// full cutover, promotion begins.
+	if err := sm.CompleteCanaryStep(true); err != nil {
+		t.Fatalf("CompleteCanaryStep() failed: %v", err)
+	}
+	if sm.GetState() != StateCanaryPromoting {
+		t.Fatalf("state = %v, want %v", sm.GetState(), StateCanaryPromoting)
+	}
+	if len(actions.updateCaddyCalls) != 1 || actions.updateCaddyCalls[0].ActiveColor != "green" {
+		t.Errorf("updateCaddyCalls = %+v, want one call for green", actions.updateCaddyCalls)
+	}
+
+	// Caddy fully updated: proceeds to draining, same as a blue/green cutover.
+	if err := sm.CompleteCaddyUpdate(true); err != nil {
+		t.Fatalf("CompleteCaddyUpdate() failed: %v", err)
+	}
+	if sm.GetState() != StateDraining {
+		t.Errorf("state = %v, want %v", sm.GetState(), StateDraining)
+	}
+}
+
+func TestCanaryStepFailureRollsBack(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+	sm.SetCanarySteps([]int{5, 25, 100})
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+	sm.CompleteHealthCheck(true, "")
+
+	if err := sm.CompleteCanaryStep(false); err != nil {
+		t.Fatalf("CompleteCanaryStep() failed: %v", err)
+	}
+	if sm.GetState() != StateRollingBack {
+		t.Fatalf("state = %v, want %v", sm.GetState(), StateRollingBack)
+	}
+	if len(actions.rollbackCaddyCalls) != 1 || actions.rollbackCaddyCalls[0].ActiveColor != "blue" {
+		t.Errorf("rollbackCaddyCalls = %+v, want one call restoring blue", actions.rollbackCaddyCalls)
+	}
+	if len(actions.stopContainerCalls) != 1 || actions.stopContainerCalls[0].Color != "green" {
+		t.Errorf("stopContainerCalls = %+v, want one call stopping green", actions.stopContainerCalls)
+	}
+}
+
+func TestHealthCheckPassedWithNoCanarySteps(t *testing.T) {
+	actions := &MockActionProvider{}
+	sm := New("test-app", "blue", actions, nil)
+
+	sm.Deploy("nginx:1.22")
+	sm.CompleteContainerOperation(true, false)
+
+	if err := sm.CompleteHealthCheck(true, ""); err != nil {
+		t.Fatalf("CompleteHealthCheck() failed: %v", err)
+	}
+	if sm.GetState() != StateSwitching {
+		t.Errorf("state = %v, want %v; canary path should not engage without SetCanarySteps", sm.GetState(), StateSwitching)
+	}
+	if len(actions.updateCaddyWeightedCalls) != 0 {
+		t.Errorf("UpdateCaddyWeighted called %d times, want 0", len(actions.updateCaddyWeightedCalls))
+	}
+}
@@ -2,52 +2,188 @@ package docker
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"dockswap/internal/caddy"
 	"dockswap/internal/config"
 	"dockswap/internal/deployment"
+	"dockswap/internal/docker/errdefs"
+	"dockswap/internal/events"
+	"dockswap/internal/hooks"
+	"dockswap/internal/logger"
+	"dockswap/internal/proxy"
+	"dockswap/internal/runtime"
+	"dockswap/internal/state"
 )
 
 // DockerActionProvider implements the deployment.ActionProvider interface
 type DockerActionProvider struct {
-	dockerManager *DockerManager
-	caddyManager  caddy.CaddyManagerInterface
+	dockerManager *RuntimeManager
+	caddyManager  caddy.ProxyManager
 	configs       map[string]*config.AppConfig
 	ctx           context.Context
+
+	// db, if set via SetDB, lets UpdateCaddy/RollbackCaddy look up every
+	// other app's current active color so routing updates cover the whole
+	// fleet, not just the app being deployed. Apps this can't resolve a
+	// state for are left out of the render, same as CLI's own
+	// generateCaddyConfig.
+	db *sql.DB
+
+	// proxies holds an optional in-process proxy.Proxy per app for apps that
+	// opt into the userland reverse proxy instead of (or alongside) Caddy.
+	proxies map[string]*proxy.Proxy
+
+	// connCounters holds an optional ConnectionCounter per app, registered
+	// via SetConnectionCounter, that DrainConnections polls for apps with no
+	// in-process proxy - real Caddy-admin-backed or docker-stats/procfs
+	// observation instead of a blind sleep.
+	connCounters map[string]ConnectionCounter
+
+	// drainMu guards lastDrain, which pollDrain writes from whatever
+	// goroutine is draining and DrainResult reads from any other.
+	drainMu   sync.Mutex
+	lastDrain map[string]DrainResult
+
+	// log is the base logger actions are reported through; SetLogger
+	// replaces it with one already carrying the "app" field (and typically
+	// "color") so every line of a deployment is correlatable.
+	log logger.Logger
+
+	// bus, if set via SetEventBus, is published to around container
+	// start/stop/health actions, alongside the state-transition events
+	// DeploymentStateMachine publishes on the same bus.
+	bus *events.Bus
 }
 
-func NewDockerActionProvider(dockerManager *DockerManager, caddyManager caddy.CaddyManagerInterface, configs map[string]*config.AppConfig) *DockerActionProvider {
+func NewDockerActionProvider(dockerManager *RuntimeManager, caddyManager caddy.ProxyManager, configs map[string]*config.AppConfig) *DockerActionProvider {
 	return &DockerActionProvider{
 		dockerManager: dockerManager,
 		caddyManager:  caddyManager,
 		configs:       configs,
 		ctx:           context.Background(),
+		proxies:       make(map[string]*proxy.Proxy),
+		connCounters:  make(map[string]ConnectionCounter),
+		lastDrain:     make(map[string]DrainResult),
+		log:           logger.New(logger.LevelInfo),
+	}
+}
+
+// SetLogger replaces dap's logger, typically with one from DeploymentOrchestrator
+// already carrying the "app" field via Logger.With, so every action this
+// provider takes logs with that context attached.
+func (dap *DockerActionProvider) SetLogger(log logger.Logger) {
+	dap.log = log
+}
+
+// SetEventBus registers bus so StartContainer/StopContainer/CheckHealth
+// publish an events.Event around the action they take.
+func (dap *DockerActionProvider) SetEventBus(bus *events.Bus) {
+	dap.bus = bus
+}
+
+// publish is a no-op when dap.bus is nil, so callers don't need to guard
+// every publish call.
+func (dap *DockerActionProvider) publish(appName, color, fromState, toState string, err error) {
+	if dap.bus == nil {
+		return
 	}
+	dap.bus.Publish(events.Event{
+		AppName:   appName,
+		Color:     color,
+		FromState: fromState,
+		ToState:   toState,
+		Timestamp: time.Now(),
+		Error:     err,
+	})
+}
+
+// SetProxy registers an in-process proxy.Proxy for appName so UpdateCaddy and
+// RollbackCaddy also flip its target on swap/rollback.
+func (dap *DockerActionProvider) SetProxy(appName string, p *proxy.Proxy) {
+	dap.proxies[appName] = p
 }
 
 func (dap *DockerActionProvider) SetContext(ctx context.Context) {
 	dap.ctx = ctx
 }
 
-func (dap *DockerActionProvider) StartContainer(appName, color, image string) error {
+// SetDB registers db so UpdateCaddy/RollbackCaddy can resolve every app's
+// current active color when rendering routing config, not just the one
+// being deployed.
+func (dap *DockerActionProvider) SetDB(db *sql.DB) {
+	dap.db = db
+}
+
+// routingStates builds the (configs, states) pair UpdateAppRouting and
+// RollbackRouting need to render routing config: appName's own entry uses
+// activeColor directly (the color the caller just switched to or is rolling
+// back to), every other app's entry comes from dap.db if set. Apps with no
+// resolvable state are left out rather than failing the whole render, the
+// same way CLI's generateCaddyConfig skips apps it has no state for.
+func (dap *DockerActionProvider) routingStates(appName, activeColor string) (map[string]*config.AppConfig, map[string]*state.AppState) {
+	configs := map[string]*config.AppConfig{appName: dap.configs[appName]}
+	states := map[string]*state.AppState{
+		appName: {Name: appName, ActiveColor: activeColor},
+	}
+
+	if dap.db == nil {
+		return configs, states
+	}
+
+	for otherApp, otherConfig := range dap.configs {
+		if otherApp == appName {
+			continue
+		}
+
+		cs, err := state.GetCurrentState(dap.db, otherApp)
+		if err != nil || cs == nil {
+			continue
+		}
+
+		configs[otherApp] = otherConfig
+		states[otherApp] = &state.AppState{
+			Name:        cs.AppName,
+			ActiveColor: cs.ActiveColor,
+			Status:      cs.Status,
+			LastUpdated: cs.UpdatedAt,
+		}
+	}
+
+	return configs, states
+}
+
+func (dap *DockerActionProvider) StartContainer(appName, color, image string) (err error) {
+	log := dap.log.With("app", appName, "color", color)
+	log.Info("starting container with image %s", image)
+	defer func() { dap.publish(appName, color, "starting", "started", err) }()
+
 	appConfig, exists := dap.configs[appName]
 	if !exists {
 		return fmt.Errorf("no configuration found for app %s", appName)
 	}
 
+	if err := dap.runHookSteps(dap.ctx, "pre_start", appConfig.Hooks.PreStart, appName, color, image, ""); err != nil {
+		log.Error("pre_start hook failed: %v", err)
+		return err
+	}
+
 	// Ensure network exists if configured
 	if appConfig.Docker.Network != "" {
 		_, err := dap.dockerManager.EnsureNetwork(dap.ctx, appConfig.Docker.Network)
 		if err != nil {
+			log.Error("failed to ensure network %s: %v", appConfig.Docker.Network, err)
 			return fmt.Errorf("failed to ensure network %s: %w", appConfig.Docker.Network, err)
 		}
 	}
 
 	// Check if container already exists
-	exists, err := dap.dockerManager.ContainerExists(dap.ctx, appName, color)
+	exists, err = dap.dockerManager.ContainerExists(dap.ctx, appName, color)
 	if err != nil {
+		log.Error("failed to check container existence: %v", err)
 		return fmt.Errorf("failed to check container existence: %w", err)
 	}
 
@@ -55,59 +191,221 @@ func (dap *DockerActionProvider) StartContainer(appName, color, image string) er
 		// Remove existing container
 		err = dap.dockerManager.RemoveContainer(dap.ctx, appName, color, true)
 		if err != nil {
+			log.Error("failed to remove existing container: %v", err)
 			return fmt.Errorf("failed to remove existing container: %w", err)
 		}
 	}
 
+	// Apps with sidecars deploy the whole group atomically per color;
+	// plain apps keep the single-container path unchanged below.
+	if len(appConfig.Services) > 0 {
+		group, err := dap.dockerManager.CreateContainerGroup(dap.ctx, appName, color, image, appConfig)
+		if err != nil {
+			log.Error("failed to create container group: %v", err)
+			return fmt.Errorf("failed to create container group: %w", errdefs.FromDockerError(err))
+		}
+
+		containerID := mainServiceContainerID(group)
+		if err := dap.runHookSteps(dap.ctx, "post_start", appConfig.Hooks.PostStart, appName, color, image, containerID); err != nil {
+			log.Error("post_start hook failed: %v", err)
+			return err
+		}
+
+		log.Info("container group started")
+		return nil
+	}
+
 	// Create new container
 	containerInfo, err := dap.dockerManager.CreateContainer(dap.ctx, appName, color, image, appConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create container: %w", err)
+		log.Error("failed to create container: %v", err)
+		return fmt.Errorf("failed to create container: %w", errdefs.FromDockerError(err))
 	}
+	log = log.With("container_id", containerInfo.ID)
 
 	// Start container
 	err = dap.dockerManager.StartContainer(dap.ctx, containerInfo.ID)
 	if err != nil {
-		return fmt.Errorf("failed to start container: %w", err)
+		log.Error("failed to start container: %v", err)
+		return fmt.Errorf("failed to start container: %w", errdefs.FromDockerError(err))
 	}
 
 	// Connect to network if configured
 	if appConfig.Docker.Network != "" {
 		err = dap.dockerManager.ConnectContainerToNetwork(dap.ctx, appConfig.Docker.Network, containerInfo.ID)
 		if err != nil {
+			log.Error("failed to connect container to network: %v", err)
 			return fmt.Errorf("failed to connect container to network: %w", err)
 		}
 	}
 
+	if err := dap.runHookSteps(dap.ctx, "post_start", appConfig.Hooks.PostStart, appName, color, image, containerInfo.ID); err != nil {
+		log.Error("post_start hook failed: %v", err)
+		return err
+	}
+
+	log.Info("container started")
 	return nil
 }
 
-func (dap *DockerActionProvider) CheckHealth(appName, color string) (bool, error) {
+// mainServiceContainerID returns the container ID of group's "main" service
+// (appConfig.Name, by CreateContainerGroup's own convention), or "" if not
+// found, so a ContainerExec hook step has a container to target for apps
+// with sidecars.
+func mainServiceContainerID(group []ServiceContainer) string {
+	for _, sc := range group {
+		if sc.Service == mainServiceName {
+			return sc.Info.ID
+		}
+	}
+	return ""
+}
+
+func (dap *DockerActionProvider) CheckHealth(appName, color string) (deployment.HealthResult, error) {
 	appConfig, exists := dap.configs[appName]
 	if !exists {
-		return false, fmt.Errorf("no configuration found for app %s", appName)
+		return deployment.HealthResult{}, fmt.Errorf("no configuration found for app %s", appName)
+	}
+
+	healthy, err := dap.checkHealth(appName, color, appConfig)
+	if err != nil {
+		dap.publish(appName, color, "health_check", "health_check_failed", err)
+		return deployment.HealthResult{Status: deployment.HealthStatusUnhealthy, Message: err.Error()}, err
+	}
+
+	toState := "health_check_failed"
+	result := deployment.HealthResult{Status: deployment.HealthStatusUnhealthy}
+	if healthy {
+		toState = "health_check_passed"
+		result = deployment.HealthResult{Status: deployment.HealthStatusHealthy}
+	}
+	dap.publish(appName, color, "health_check", toState, nil)
+	return result, nil
+}
+
+func (dap *DockerActionProvider) checkHealth(appName, color string, appConfig *config.AppConfig) (bool, error) {
+	if len(appConfig.Services) > 0 {
+		result, err := dap.dockerManager.CheckGroupHealth(dap.ctx, appName, color, appConfig)
+		if err != nil {
+			return false, err
+		}
+		return result.Status == HealthStatusHealthy, nil
 	}
 
 	return dap.dockerManager.IsContainerHealthy(dap.ctx, appName, color, appConfig)
 }
 
 func (dap *DockerActionProvider) UpdateCaddy(appName, activeColor string) error {
+	log := dap.log.With("app", appName, "color", activeColor)
+
+	appConfig, exists := dap.configs[appName]
+	if !exists {
+		return fmt.Errorf("no configuration found for app %s", appName)
+	}
+
+	// A swap is a routing change across the whole app, not an action on one
+	// container, so pre_swap/post_swap run with no container_exec target -
+	// container_exec steps in these phases always fail cleanly, the same as
+	// pre_start's.
+	if err := dap.runHookSteps(dap.ctx, "pre_swap", appConfig.Hooks.PreSwap, appName, activeColor, "", ""); err != nil {
+		log.Error("pre_swap hook failed: %v", err)
+		return err
+	}
+
+	hasProxy := false
+	if p, ok := dap.proxies[appName]; ok {
+		if err := p.SwitchTarget(activeColor); err != nil {
+			log.Error("failed to switch proxy target: %v", err)
+			return fmt.Errorf("failed to switch proxy target for %s: %w", appName, err)
+		}
+		hasProxy = true
+	}
+
+	if dap.caddyManager == nil {
+		if !hasProxy {
+			return fmt.Errorf("caddy manager not available")
+		}
+	} else {
+		configs, states := dap.routingStates(appName, activeColor)
+		if err := dap.caddyManager.UpdateAppRouting(appName, configs, states); err != nil {
+			log.Error("failed to update routing: %v", err)
+			return err
+		}
+		log.Info("routing updated")
+	}
+
+	if err := dap.runHookSteps(dap.ctx, "post_swap", appConfig.Hooks.PostSwap, appName, activeColor, "", ""); err != nil {
+		log.Error("post_swap hook failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateCaddyWeighted splits appName's routing across weights (slot/color
+// name -> traffic percent) instead of sending it all to one active color,
+// for a canary rollout's stepped weight stages. It has no in-process-proxy
+// equivalent: proxy.Proxy only ever points at a single target, so this only
+// does anything when a caddyManager is configured.
+func (dap *DockerActionProvider) UpdateCaddyWeighted(appName string, weights map[string]int) error {
+	log := dap.log.With("app", appName)
+
 	if dap.caddyManager == nil {
 		return fmt.Errorf("caddy manager not available")
 	}
 
-	// Update states to reflect new active color (this would normally be done by the caller)
-	// For now, we'll regenerate config with current states
-	return dap.caddyManager.ReloadCaddy()
+	// The "active" color for routingStates' own app entry doesn't matter
+	// here - UpdateWeightedRouting overrides appName's Slots weights
+	// wholesale - so pass whichever color currently carries the most
+	// traffic, purely for every *other* app's rendering to stay correct.
+	configs, states := dap.routingStates(appName, dap.highestWeightColor(weights))
+	if err := dap.caddyManager.UpdateWeightedRouting(appName, weights, configs, states); err != nil {
+		log.Error("failed to update weighted routing: %v", err)
+		return err
+	}
+	log.Info("weighted routing updated: %v", weights)
+	return nil
+}
+
+// highestWeightColor returns the color weights gives the largest traffic
+// share to, breaking ties arbitrarily; see UpdateCaddyWeighted.
+func (dap *DockerActionProvider) highestWeightColor(weights map[string]int) string {
+	var best string
+	bestWeight := -1
+	for color, weight := range weights {
+		if weight > bestWeight {
+			best, bestWeight = color, weight
+		}
+	}
+	return best
 }
 
+// drainPollInterval is how often DrainConnections rechecks the connection
+// count while waiting for it to reach zero.
+const drainPollInterval = 250 * time.Millisecond
+
+// DrainConnections waits for connections against appName's color container
+// to reach zero, polling every drainPollInterval, and returns as soon as
+// that happens. It prefers appName's in-process proxy (SetProxy) if one is
+// registered, then a registered ConnectionCounter (SetConnectionCounter,
+// typically wired from Caddy's admin API or a docker-stats/procfs fallback).
+// Apps with neither - no way to observe real drain progress - fall back to
+// waiting out the full timeout as before.
 func (dap *DockerActionProvider) DrainConnections(appName, color string, timeout time.Duration) error {
-	// In a real implementation, this would:
-	// 1. Check for active connections to the container
-	// 2. Wait for connections to naturally close
-	// 3. Force close remaining connections after timeout
+	start := time.Now()
+
+	if p, hasProxy := dap.proxies[appName]; hasProxy {
+		return dap.pollDrain(appName, start, timeout, func(context.Context) (int, error) {
+			return p.ActiveConnections(), nil
+		})
+	}
+
+	if cc, hasCounter := dap.connCounters[appName]; hasCounter {
+		return dap.pollDrain(appName, start, timeout, func(ctx context.Context) (int, error) {
+			return cc.Count(ctx, color)
+		})
+	}
 
-	// For now, we'll simulate the drain timeout
 	timer := time.NewTimer(timeout)
 	defer timer.Stop()
 
@@ -115,84 +413,471 @@ func (dap *DockerActionProvider) DrainConnections(appName, color string, timeout
 	case <-dap.ctx.Done():
 		return dap.ctx.Err()
 	case <-timer.C:
-		// Drain timeout reached
+		dap.recordDrain(appName, DrainResult{Duration: time.Since(start)})
 		return nil
 	}
 }
 
-func (dap *DockerActionProvider) StopContainer(appName, color string) error {
+// pollDrain repeatedly calls count every drainPollInterval until it reports
+// zero connections or timeout elapses since start, logging each tick and
+// recording the final DrainResult for DrainResult(appName) to retrieve. A
+// count error is logged and treated as "still draining" rather than
+// aborting the wait, since a single failed poll (a transient Caddy admin
+// API hiccup, a container mid-restart) shouldn't cut a drain short.
+func (dap *DockerActionProvider) pollDrain(appName string, start time.Time, timeout time.Duration, count func(context.Context) (int, error)) error {
+	log := dap.log.With("app", appName)
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	last := 0
+	for {
+		n, err := count(dap.ctx)
+		if err != nil {
+			log.Warn("drain connection count failed: %v", err)
+		} else {
+			last = n
+			log.Debug("drain poll: %d connections remaining", n)
+			if n == 0 {
+				dap.recordDrain(appName, DrainResult{Duration: time.Since(start)})
+				return nil
+			}
+		}
+
+		select {
+		case <-dap.ctx.Done():
+			return dap.ctx.Err()
+		case <-deadline.C:
+			// Drain timeout reached with connections still open; the caller
+			// proceeds to stop the container regardless.
+			dap.recordDrain(appName, DrainResult{Connections: last, Duration: time.Since(start)})
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordDrain stores result as appName's most recent drain outcome, for
+// DrainResult to hand to callers (the deployment state machine, an API
+// handler) that want to persist or report how the last drain actually went.
+func (dap *DockerActionProvider) recordDrain(appName string, result DrainResult) {
+	dap.drainMu.Lock()
+	defer dap.drainMu.Unlock()
+	dap.lastDrain[appName] = result
+}
+
+// DrainResult returns appName's most recently completed drain outcome, and
+// whether one has happened yet.
+func (dap *DockerActionProvider) DrainResult(appName string) (DrainResult, bool) {
+	dap.drainMu.Lock()
+	defer dap.drainMu.Unlock()
+	result, ok := dap.lastDrain[appName]
+	return result, ok
+}
+
+// SetConnectionCounter registers cc so DrainConnections observes appName's
+// real connection count (via Caddy's admin API or a docker-stats/procfs
+// fallback) instead of sleeping out the full drain timeout. Takes
+// precedence below SetProxy's in-process proxy, since a registered proxy
+// already tracks connections directly.
+func (dap *DockerActionProvider) SetConnectionCounter(appName string, cc ConnectionCounter) {
+	dap.connCounters[appName] = cc
+}
+
+// ConnectionsRemaining returns how many connections appName's in-process
+// proxy is still tracking, or 0 if appName has no registered proxy. color is
+// accepted to match the ActionProvider interface but unused: the proxy's
+// connection set isn't partitioned by color, only by whether it was dialed
+// before or after the last SwitchTarget.
+func (dap *DockerActionProvider) ConnectionsRemaining(appName, color string) int {
+	if p, ok := dap.proxies[appName]; ok {
+		return p.ActiveConnections()
+	}
+	return 0
+}
+
+func (dap *DockerActionProvider) StopContainer(appName, color string) (err error) {
+	log := dap.log.With("app", appName, "color", color)
+	log.Info("stopping container")
+	defer func() { dap.publish(appName, color, "stopping", "stopped", err) }()
+
 	appConfig, exists := dap.configs[appName]
 	if !exists {
 		return fmt.Errorf("no configuration found for app %s", appName)
 	}
 
+	// Resolved best-effort for the hook env/container_exec target: the
+	// container may already be gone (e.g. a retry of a stop that partially
+	// succeeded), in which case pre_stop just runs with no container to
+	// reach and any container_exec step in it fails cleanly below.
+	var image, containerID string
+	if info, infoErr := dap.dockerManager.GetContainerInfo(dap.ctx, appName, color); infoErr == nil {
+		image = info.Image
+		containerID = info.ID
+	}
+
+	if err := dap.runHookSteps(dap.ctx, "pre_stop", appConfig.Hooks.PreStop, appName, color, image, containerID); err != nil {
+		log.Error("pre_stop hook failed: %v", err)
+		return err
+	}
+
 	// Stop container with configured timeout
 	stopTimeout := appConfig.Deployment.StopTimeout
 	if stopTimeout == 0 {
 		stopTimeout = 15 * time.Second // Default timeout
 	}
 
-	err := dap.dockerManager.StopContainer(dap.ctx, appName, color, stopTimeout)
+	if len(appConfig.Services) > 0 {
+		if err := dap.dockerManager.StopContainerGroup(dap.ctx, appName, color, appConfig, stopTimeout); err != nil {
+			log.Error("failed to stop container group: %v", err)
+			return fmt.Errorf("failed to stop container group: %w", err)
+		}
+
+		// The group's containers are gone now; post_stop's container_exec
+		// steps (if any) fail cleanly the same way pre_stop's would against
+		// an already-removed container.
+		if err := dap.runHookSteps(dap.ctx, "post_stop", appConfig.Hooks.PostStop, appName, color, image, ""); err != nil {
+			log.Error("post_stop hook failed: %v", err)
+			return err
+		}
+		return nil
+	}
+
+	err = dap.dockerManager.StopContainer(dap.ctx, appName, color, stopTimeout)
 	if err != nil {
+		log.Error("failed to stop container: %v", err)
 		return fmt.Errorf("failed to stop container: %w", err)
 	}
 
 	// Remove stopped container
 	err = dap.dockerManager.RemoveContainer(dap.ctx, appName, color, false)
 	if err != nil {
+		log.Error("failed to remove container: %v", err)
 		return fmt.Errorf("failed to remove container: %w", err)
 	}
 
+	if err := dap.runHookSteps(dap.ctx, "post_stop", appConfig.Hooks.PostStop, appName, color, image, ""); err != nil {
+		log.Error("post_stop hook failed: %v", err)
+		return err
+	}
+
+	log.Info("container stopped")
 	return nil
 }
 
 func (dap *DockerActionProvider) RollbackCaddy(appName, activeColor string) error {
+	log := dap.log.With("app", appName, "color", activeColor)
+	log.Info("rolling back routing")
+
+	hasProxy := false
+	if p, ok := dap.proxies[appName]; ok {
+		if err := p.SwitchTarget(activeColor); err != nil {
+			log.Error("failed to switch proxy target: %v", err)
+			return fmt.Errorf("failed to switch proxy target for %s: %w", appName, err)
+		}
+		hasProxy = true
+	}
+
 	if dap.caddyManager == nil {
+		if hasProxy {
+			return nil
+		}
 		return fmt.Errorf("caddy manager not available")
 	}
 
-	// Rollback caddy config (regenerate with previous state)
-	return dap.caddyManager.ReloadCaddy()
+	configs, states := dap.routingStates(appName, activeColor)
+	if err := dap.caddyManager.RollbackRouting(appName, configs, states); err != nil {
+		log.Error("failed to roll back routing: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Rollback reverts appName to the generation appState.Rollback() just swapped
+// back in. Unlike RollbackCaddy (which only re-points routing at a color
+// whose container is still running, for a deployment that failed mid-flight),
+// Rollback targets a generation whose container may already have been
+// stopped and removed by a prior successful deploy, so it re-pulls the
+// previous image if needed, (re)starts the previous-color container, health
+// checks it, flips routing, and stops the generation being rolled back from.
+// Callers are responsible for loading/persisting appState (e.g. via
+// Workspace); Rollback only mutates it in memory and performs the
+// corresponding container/routing actions.
+func (dap *DockerActionProvider) Rollback(appName string, appState *state.AppState) (err error) {
+	appConfig, exists := dap.configs[appName]
+	if !exists {
+		return fmt.Errorf("no configuration found for app %s", appName)
+	}
+
+	failedColor := appState.ActiveColor
+	if err := appState.Rollback(); err != nil {
+		return fmt.Errorf("cannot roll back app %s: %w", appName, err)
+	}
+	targetColor := appState.ActiveColor
+	image := appState.CurrentImage
+
+	log := dap.log.With("app", appName, "color", targetColor)
+	log.Info("rolling back to previous generation: image=%s", image)
+	defer func() { dap.publish(appName, targetColor, "rolling_back", "rolled_back", err) }()
+
+	running, err := dap.dockerManager.ContainerExists(dap.ctx, appName, targetColor)
+	if err != nil {
+		return fmt.Errorf("failed to check previous container existence: %w", err)
+	}
+
+	if !running {
+		if err := dap.dockerManager.PullImage(dap.ctx, image, appConfig); err != nil {
+			return fmt.Errorf("failed to pull previous image %s for rollback: %w", image, errdefs.FromDockerError(err))
+		}
+		if err := dap.StartContainer(appName, targetColor, image); err != nil {
+			return fmt.Errorf("failed to start previous container for rollback: %w", err)
+		}
+	}
+
+	healthy, err := dap.checkHealth(appName, targetColor, appConfig)
+	if err != nil {
+		return fmt.Errorf("failed to health check previous container during rollback: %w", err)
+	}
+	if !healthy {
+		return fmt.Errorf("previous container for app %s is not healthy, aborting rollback", appName)
+	}
+
+	if err := dap.UpdateCaddy(appName, targetColor); err != nil {
+		return fmt.Errorf("failed to flip routing during rollback: %w", err)
+	}
+
+	if err := dap.StopContainer(appName, failedColor); err != nil {
+		log.Error("failed to stop rolled-back-from container %s: %v", failedColor, err)
+	}
+
+	log.Info("rollback complete")
+	return nil
 }
 
 // DeploymentOrchestrator orchestrates the entire deployment process
 type DeploymentOrchestrator struct {
-	dockerManager *DockerManager
-	caddyManager  *caddy.CaddyManager
+	dockerManager *RuntimeManager
+	caddyManager  caddy.ProxyManager
 	configs       map[string]*config.AppConfig
 	states        map[string]*deployment.DeploymentStateMachine
+	db            *sql.DB
+
+	// log is the base logger each app's DockerActionProvider is handed a
+	// "app"-tagged child of, so every line across a deployment (orchestrator
+	// and action provider alike) is correlatable by app.
+	log logger.Logger
+
+	// bus, if set via SetEventBus, is handed to each app's state machine and
+	// DockerActionProvider so every transition and action they publish lands
+	// on the same stream.
+	bus *events.Bus
+
+	// ctx, if set via SetContext, is handed to each app's DockerActionProvider
+	// and is the parent for runDeploymentLoop's per-deployment timeout, so a
+	// shutdown.Coordinator's cancellation reaches both.
+	ctx context.Context
+
+	// locks guards each app's state machine against a second concurrent
+	// Deploy/DeployAsync call for that same app, the way Docker's contStore
+	// locks one container's state at a time while leaving unrelated
+	// containers free to proceed. Populated by InitializeApp.
+	locks map[string]*sync.Mutex
+
+	// sem bounds how many apps' deployment loops run at once across the
+	// whole orchestrator; DeployAsync/DeployAll acquire a slot before
+	// starting and release it on completion. Sized by
+	// SetMaxConcurrentDeployments, defaulting to
+	// DefaultMaxConcurrentDeployments.
+	sem chan struct{}
+
+	// pool and tlsDir, if set via SetRuntimePool, let InitializeApp resolve
+	// a dedicated RuntimeManager for any app whose config sets
+	// docker.docker_host instead of defaulting every app to dockerManager.
+	pool   *runtime.Pool
+	tlsDir string
+
+	// managers holds the per-app RuntimeManager resolveManager picked for
+	// each app at InitializeApp time, so runDeploymentLoop's health checks
+	// talk to the same daemon its DockerActionProvider does.
+	managers map[string]*RuntimeManager
 }
 
-func NewDeploymentOrchestrator(dockerManager *DockerManager, caddyManager *caddy.CaddyManager, configs map[string]*config.AppConfig) *DeploymentOrchestrator {
+func NewDeploymentOrchestrator(dockerManager *RuntimeManager, caddyManager caddy.ProxyManager, configs map[string]*config.AppConfig) *DeploymentOrchestrator {
 	return &DeploymentOrchestrator{
 		dockerManager: dockerManager,
 		caddyManager:  caddyManager,
 		configs:       configs,
 		states:        make(map[string]*deployment.DeploymentStateMachine),
+		log:           logger.New(logger.LevelInfo),
+		locks:         make(map[string]*sync.Mutex),
+		sem:           make(chan struct{}, DefaultMaxConcurrentDeployments),
+		managers:      make(map[string]*RuntimeManager),
 	}
 }
 
+// SetDB registers db so each app's DockerActionProvider can resolve every
+// other app's current active color when rendering routing config; see
+// DockerActionProvider.SetDB.
+func (do *DeploymentOrchestrator) SetDB(db *sql.DB) {
+	do.db = db
+}
+
+// SetLogger replaces do's base logger, e.g. with one configured for JSON
+// output or a syslog sink; InitializeApp hands each app's action provider a
+// copy tagged with that app's name.
+func (do *DeploymentOrchestrator) SetLogger(log logger.Logger) {
+	do.log = log
+}
+
+// SetEventBus registers bus so InitializeApp wires it into every app's
+// state machine and action provider from this point on.
+func (do *DeploymentOrchestrator) SetEventBus(bus *events.Bus) {
+	do.bus = bus
+}
+
+// SetContext registers ctx so InitializeApp wires it into every app's
+// action provider from this point on, and runDeploymentLoop derives its
+// per-deployment timeout from it instead of context.Background(). Callers
+// running under a shutdown.Coordinator should pass its Context() here so
+// SIGINT/SIGTERM actually interrupts an in-flight deployment rather than
+// leaving it to run to its own timeout.
+func (do *DeploymentOrchestrator) SetContext(ctx context.Context) {
+	do.ctx = ctx
+}
+
+// SetRuntimePool registers pool and tlsDir so InitializeApp can resolve a
+// dedicated RuntimeManager for any app whose config sets docker.docker_host,
+// instead of that app sharing do.dockerManager with every other app. tlsDir
+// is passed through to runtime.Endpoint.TLSDir for "tcp://" hosts; apps that
+// leave docker_host unset keep using do.dockerManager exactly as before.
+func (do *DeploymentOrchestrator) SetRuntimePool(pool *runtime.Pool, tlsDir string) {
+	do.pool = pool
+	do.tlsDir = tlsDir
+}
+
+// resolveManager returns do.dockerManager for appConfig unless a pool is
+// registered and appConfig.Docker.Host is set, in which case it resolves
+// (and caches, via the pool) a RuntimeManager dedicated to that host.
+func (do *DeploymentOrchestrator) resolveManager(appConfig *config.AppConfig) (*RuntimeManager, error) {
+	if do.pool == nil || appConfig.Docker.Host == "" {
+		return do.dockerManager, nil
+	}
+
+	cli, err := do.pool.Get(runtime.Kind(appConfig.Docker.Runtime), runtime.Endpoint{
+		Host:   appConfig.Docker.Host,
+		TLSDir: do.tlsDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to docker_host %s for app %s: %w", appConfig.Docker.Host, appConfig.Name, err)
+	}
+	return NewRuntimeManager(cli), nil
+}
+
 func (do *DeploymentOrchestrator) InitializeApp(appName, activeColor string) error {
 	appConfig, exists := do.configs[appName]
 	if !exists {
 		return fmt.Errorf("no configuration found for app %s", appName)
 	}
 
+	dockerManager, err := do.resolveManager(appConfig)
+	if err != nil {
+		return err
+	}
+	do.managers[appName] = dockerManager
+
 	// Create action provider for this app
-	actionProvider := NewDockerActionProvider(do.dockerManager, do.caddyManager, do.configs)
+	actionProvider := NewDockerActionProvider(dockerManager, do.caddyManager, do.configs)
+	actionProvider.SetLogger(do.log.With("app", appName))
+	if do.db != nil {
+		actionProvider.SetDB(do.db)
+	}
+	if do.bus != nil {
+		actionProvider.SetEventBus(do.bus)
+	}
+	if do.ctx != nil {
+		actionProvider.SetContext(do.ctx)
+	}
 
-	// Create state machine
-	stateMachine := deployment.New(appName, activeColor, actionProvider, nil)
+	// Wire a real ConnectionCounter so DrainConnections observes actual
+	// drain progress instead of sleeping out the full timeout: Caddy's
+	// admin API when that's the configured backend (it already tracks
+	// per-upstream request counts), otherwise the docker-stats/procfs
+	// fallback every backend supports.
+	if cm, ok := do.caddyManager.(*caddy.CaddyManager); ok {
+		actionProvider.SetConnectionCounter(appName, NewCaddyConnectionCounter(cm.AdminURL, appConfig))
+	} else {
+		actionProvider.SetConnectionCounter(appName, NewStatsConnectionCounter(dockerManager, appName, appConfig))
+	}
+
+	// Resume an in-flight deployment a previous process crashed out of -
+	// current_state.status left as some non-terminal DeploymentState rather
+	// than "stable", "failed", or the clean-shutdown "interrupted" marker -
+	// instead of starting fresh from activeColor. Falls back to a plain New
+	// machine when there's nothing to resume.
+	var stateMachine *deployment.DeploymentStateMachine
+	if do.db != nil {
+		resumed, err := deployment.Resume(do.db, appName, actionProvider)
+		if err != nil {
+			do.log.With("app", appName).Error("failed to resume in-flight deployment: %v", err)
+		}
+		stateMachine = resumed
+	}
+	if stateMachine == nil {
+		stateMachine = deployment.New(appName, activeColor, actionProvider, do.db)
+	}
+	if do.bus != nil {
+		stateMachine.SetEventBus(do.bus)
+	}
 
 	// Configure timeouts from app config
 	stateMachine.SetHealthCheckTimeout(time.Duration(appConfig.HealthCheck.Retries) * appConfig.HealthCheck.Interval)
 	stateMachine.SetDrainTimeout(appConfig.Deployment.DrainTimeout)
 
+	if appConfig.Deployment.Strategy == config.DeploymentStrategyCanary {
+		stateMachine.SetCanarySteps(appConfig.Deployment.Canary.Steps)
+	}
+
+	restartPolicy := convertRestartPolicy(appConfig.Deployment.RestartPolicy)
+	stateMachine.SetRestartPolicy(restartPolicy)
+	stateMachine.SetHealthPolicy(convertHealthPolicy(appConfig.HealthCheck))
+	stateMachine.SetHooks(convertHooks(appConfig.Hooks))
+	stateMachine.SetHookRunner(hooks.NewDispatchRunner(hooks.NewExecRunner(), hooks.NewWebhookRunner(restartPolicy)))
+
 	do.states[appName] = stateMachine
+	do.locks[appName] = &sync.Mutex{}
 	return nil
 }
 
+// ctxOrBackground returns do.ctx if SetContext has been called, else
+// context.Background(), so callers don't need to nil-check it themselves.
+func (do *DeploymentOrchestrator) ctxOrBackground() context.Context {
+	if do.ctx != nil {
+		return do.ctx
+	}
+	return context.Background()
+}
+
 func (do *DeploymentOrchestrator) Deploy(appName, newImage string) error {
+	lock, exists := do.locks[appName]
+	if !exists {
+		return fmt.Errorf("app %s not initialized", appName)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	return do.deployLocked(do.ctxOrBackground(), appName, newImage)
+}
+
+// deployLocked runs one full deploy-and-settle cycle for appName against
+// ctx. Callers must already hold do.locks[appName]; Deploy and DeployAsync
+// are the only two.
+func (do *DeploymentOrchestrator) deployLocked(ctx context.Context, appName, newImage string) error {
+	log := do.log.With("app", appName)
+
 	stateMachine, exists := do.states[appName]
 	if !exists {
 		return fmt.Errorf("app %s not initialized", appName)
@@ -202,58 +887,124 @@ func (do *DeploymentOrchestrator) Deploy(appName, newImage string) error {
 		return fmt.Errorf("app %s is not in a deployable state: %s", appName, stateMachine.GetState())
 	}
 
+	log.Info("starting deployment of image %s", newImage)
+
 	// Start deployment
 	err := stateMachine.Deploy(newImage)
 	if err != nil {
+		log.Error("failed to start deployment: %v", err)
 		return fmt.Errorf("failed to start deployment: %w", err)
 	}
 
 	// Run deployment loop
-	return do.runDeploymentLoop(appName)
+	err = do.runDeploymentLoop(ctx, appName)
+	if err != nil {
+		log.Error("deployment failed: %v", err)
+		return err
+	}
+	log.Info("deployment complete")
+	return nil
 }
 
-func (do *DeploymentOrchestrator) runDeploymentLoop(appName string) error {
+// runDeploymentLoop drives appName's state machine through to StateStable or
+// StateFailed, polling every 2 seconds, bounded by a 10-minute deployment
+// timeout layered on top of parentCtx. parentCtx.Err() being non-nil when
+// that timeout fires distinguishes an outside cancellation - the
+// orchestrator's shutdown.Coordinator context for Deploy, or a
+// DeploymentHandle's own cancelable context for DeployAsync/DeployAll -
+// from an ordinary 10-minute deployment timeout.
+func (do *DeploymentOrchestrator) runDeploymentLoop(parentCtx context.Context, appName string) error {
 	stateMachine := do.states[appName]
 	appConfig := do.configs[appName]
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(parentCtx, 10*time.Minute)
 	defer cancel()
 
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
+	// lastHealthMessage holds the most recent HealthCheckResult.Message seen
+	// while polling StateHealthCheck, so a 10-minute deployment timeout that
+	// strikes mid health-check can report why the container never went
+	// healthy instead of just that time ran out.
+	var lastHealthMessage string
+
 	for {
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("deployment timeout for app %s", appName)
+			if interrupted := parentCtx.Err() != nil; interrupted {
+				return stateMachine.Interrupt(fmt.Errorf("deployment for app %s interrupted: %w", appName, parentCtx.Err()), true)
+			}
+			if stateMachine.GetState() == deployment.StateHealthCheck && lastHealthMessage != "" {
+				return stateMachine.Interrupt(fmt.Errorf("deployment timeout for app %s: %s", appName, lastHealthMessage), false)
+			}
+			return stateMachine.Interrupt(fmt.Errorf("deployment timeout for app %s", appName), false)
 		case <-ticker.C:
+			if stateMachine.IsPaused() {
+				continue
+			}
+
 			state := stateMachine.GetState()
 
 			switch state {
 			case deployment.StateHealthCheck:
-				// Check health and complete if ready
-				healthy, err := do.dockerManager.IsContainerHealthy(ctx, appName, stateMachine.GetTargetColor(), appConfig)
-				if err != nil {
-					stateMachine.CompleteHealthCheck(false)
-					continue
+				// CheckHealth polls sm.actions.CheckHealth (DockerActionProvider's
+				// CheckHealth, wrapping CheckContainerHealth) and folds the
+				// result into the policy's success/failure streaks, completing
+				// the health check only once a threshold is reached.
+				if err := stateMachine.CheckHealth(); err != nil {
+					return fmt.Errorf("failed to complete health check: %w", err)
 				}
-
-				if healthy {
-					err = stateMachine.CompleteHealthCheck(true)
-					if err != nil {
-						return fmt.Errorf("failed to complete health check: %w", err)
-					}
+				if reason := stateMachine.GetLastHealthCheckReason(); reason != "" {
+					lastHealthMessage = reason
 				}
 
 			case deployment.StateDraining:
-				// Wait for drain timeout, then complete
-				// In a real implementation, you'd check for active connections
-				time.Sleep(appConfig.Deployment.DrainTimeout)
+				// DrainConnections (called synchronously from the
+				// StateSwitching -> StateDraining transition) has already
+				// blocked until connections reached zero or the drain
+				// timeout elapsed, so there's nothing left to wait for here.
 				err := stateMachine.CompleteDrain()
 				if err != nil {
 					return fmt.Errorf("failed to complete drain: %w", err)
 				}
 
+			case deployment.StateCanary:
+				// Let the current weight stage soak before checking health
+				// again, instead of judging it the instant its traffic
+				// share changed.
+				if time.Since(stateMachine.CanaryStepStartedAt()) < appConfig.Deployment.Canary.StepDuration {
+					continue
+				}
+
+				result, err := do.managers[appName].CheckContainerHealth(ctx, appName, stateMachine.GetTargetColor(), appConfig)
+				if err != nil {
+					stateMachine.CompleteCanaryStep(false)
+					continue
+				}
+				lastHealthMessage = result.Message
+
+				if result.Status == HealthStatusHealthy {
+					if err := stateMachine.CompleteCanaryStep(true); err != nil {
+						return fmt.Errorf("failed to complete canary step: %w", err)
+					}
+				} else {
+					if err := stateMachine.CompleteCanaryStep(false); err != nil {
+						return fmt.Errorf("failed to fail canary step: %w", err)
+					}
+				}
+
+			case deployment.StateBackoff:
+				// Let the current retry's exponential backoff elapse before
+				// re-issuing whichever step failed, instead of judging it
+				// the instant the state machine entered StateBackoff.
+				if !stateMachine.BackoffElapsed() {
+					continue
+				}
+				if err := stateMachine.CompleteBackoff(); err != nil {
+					return fmt.Errorf("failed to complete backoff: %w", err)
+				}
+
 			case deployment.StateStable:
 				// Deployment completed successfully
 				return nil
@@ -266,6 +1017,17 @@ func (do *DeploymentOrchestrator) runDeploymentLoop(appName string) error {
 	}
 }
 
+// InProgress reports whether appName currently has a deployment under way,
+// so callers like a config reload or a shutdown sequence can tell whether
+// it's safe to re-initialize or tear down that app's state machine.
+func (do *DeploymentOrchestrator) InProgress(appName string) bool {
+	stateMachine, exists := do.states[appName]
+	if !exists {
+		return false
+	}
+	return stateMachine.IsInProgress()
+}
+
 func (do *DeploymentOrchestrator) GetAppState(appName string) deployment.DeploymentState {
 	stateMachine, exists := do.states[appName]
 	if !exists {
@@ -290,3 +1052,133 @@ func (do *DeploymentOrchestrator) RecoverApp(appName string) error {
 
 	return stateMachine.RecoverManually()
 }
+
+// SetDesiredTransition registers dt as appName's operator intent, consulted
+// by that app's runDeploymentLoop and its state machine's ProcessEvent on
+// their next tick/event - e.g. from an API handler canceling or pausing a
+// deployment a dashboard operator is watching.
+func (do *DeploymentOrchestrator) SetDesiredTransition(appName string, dt deployment.DesiredTransition) error {
+	stateMachine, exists := do.states[appName]
+	if !exists {
+		return fmt.Errorf("app %s not initialized", appName)
+	}
+
+	stateMachine.SetDesiredTransition(dt)
+	return nil
+}
+
+// GetDesiredTransition returns appName's current operator intent, as last
+// registered via SetDesiredTransition.
+func (do *DeploymentOrchestrator) GetDesiredTransition(appName string) (deployment.DesiredTransition, error) {
+	stateMachine, exists := do.states[appName]
+	if !exists {
+		return deployment.DesiredTransition{}, fmt.Errorf("app %s not initialized", appName)
+	}
+
+	return stateMachine.GetDesiredTransition(), nil
+}
+
+// MarkInterrupted flags appName's just-initialized state machine as failed
+// rather than stable, for an InitializeApp caller that found this app's
+// persisted current_state.Status == "interrupted" - see
+// deployment.DeploymentStateMachine.ResumeInterrupted. An operator then
+// resolves it the same way as any other StateFailed app, via RecoverApp.
+func (do *DeploymentOrchestrator) MarkInterrupted(appName string) {
+	if stateMachine, exists := do.states[appName]; exists {
+		stateMachine.ResumeInterrupted()
+	}
+}
+
+// HandleContainerEvent reacts to one decoded container lifecycle event from
+// an EventWatcher, notifying the active color's unexpected exit without
+// waiting for the next WaitForHealthy poll. Wire it up via DispatchEvents
+// alongside any other per-event consumer (e.g. a StateReconciler) sharing
+// the same EventWatcher.
+func (do *DeploymentOrchestrator) HandleContainerEvent(evt ContainerEvent) {
+	if evt.Action != "die" && evt.Action != "oom" {
+		return
+	}
+
+	stateMachine, exists := do.states[evt.AppName]
+	if !exists {
+		return
+	}
+
+	if err := stateMachine.NotifyContainerDied(evt.Color); err != nil {
+		appConfig, ok := do.configs[evt.AppName]
+		if ok && appConfig.Deployment.AutoRollback {
+			// Best-effort: hand the operator a clean recovery path
+			// rather than leaving the machine wedged in StateFailed.
+			_ = do.RecoverApp(evt.AppName)
+		}
+	}
+}
+
+// convertHookCommands adapts a []config.HookCommand into the []deployment.Hook
+// shape DeploymentStateMachine.SetHooks takes, keeping the deployment package
+// decoupled from config the same way SetCanarySteps's []int already does.
+func convertHookCommands(commands []config.HookCommand) []deployment.Hook {
+	converted := make([]deployment.Hook, len(commands))
+	for i, cmd := range commands {
+		mode := deployment.HookModeBlocking
+		if cmd.Mode == config.HookModeBackground {
+			mode = deployment.HookModeBackground
+		}
+		converted[i] = deployment.Hook{
+			Command: cmd.Command,
+			Args:    cmd.Args,
+			URL:     cmd.URL,
+			Timeout: cmd.Timeout,
+			Env:     cmd.Env,
+			Mode:    mode,
+		}
+	}
+	return converted
+}
+
+// convertRestartPolicy translates config.RestartPolicy's YAML-facing shape
+// into deployment.RestartPolicy, the same way convertHooks decouples
+// deployment from config for Hooks.
+func convertRestartPolicy(cfg config.RestartPolicy) deployment.RestartPolicy {
+	return deployment.RestartPolicy{
+		Policy:            deployment.RestartPolicyKind(cfg.Policy),
+		MaxRetries:        cfg.MaxRetries,
+		BackoffInitial:    cfg.BackoffInitial,
+		BackoffMax:        cfg.BackoffMax,
+		BackoffMultiplier: cfg.BackoffMultiplier,
+	}
+}
+
+// convertHealthPolicy translates config.HealthCheck's YAML-facing shape into
+// deployment.HealthPolicy, reusing its existing FailureThreshold/StartPeriod
+// fields (FailureThreshold already bounds docker.WaitForHealthy's
+// consecutive failures; StartPeriod already feeds the container's
+// Docker-native HEALTHCHECK) rather than introducing a second set of knobs. cfg.Timeout is
+// deliberately not carried over: it's a per-probe dial/request timeout
+// (passed straight to NewHTTPProbe/NewTCPProbe/NewGRPCProbe/NewExecProbe),
+// not an overall give-up deadline, so HealthPolicy.Timeout is derived from
+// Retries*Interval instead - the same formula already used for
+// SetHealthCheckTimeout.
+func convertHealthPolicy(cfg config.HealthCheck) deployment.HealthPolicy {
+	return deployment.HealthPolicy{
+		SuccessThreshold: cfg.SuccessThreshold,
+		FailureThreshold: cfg.FailureThreshold,
+		Interval:         cfg.Interval,
+		Timeout:          time.Duration(cfg.Retries) * cfg.Interval,
+		StartPeriod:      cfg.StartPeriod,
+	}
+}
+
+// convertHooks adapts a config.HooksConfig into the deployment.Hooks shape
+// DeploymentStateMachine.SetHooks takes.
+func convertHooks(cfg config.HooksConfig) deployment.Hooks {
+	return deployment.Hooks{
+		PreDeploy:  convertHookCommands(cfg.PreDeploy),
+		PostHealth: convertHookCommands(cfg.PostHealth),
+		PreSwitch:  convertHookCommands(cfg.PreSwitch),
+		PostSwitch: convertHookCommands(cfg.PostSwitch),
+		PreDrain:   convertHookCommands(cfg.PreDrain),
+		OnFailure:  convertHookCommands(cfg.OnFailure),
+		OnRollback: convertHookCommands(cfg.OnRollback),
+	}
+}
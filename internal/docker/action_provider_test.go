@@ -3,6 +3,8 @@ package docker
 import (
 	"context"
 	"errors"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,20 +15,33 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"dockswap/internal/config"
+	"dockswap/internal/deployment"
+	"dockswap/internal/proxy"
+	"dockswap/internal/state"
 )
 
-// MockCaddyManager for testing
+// MockCaddyManager is a caddy.ProxyManager for testing.
 type MockCaddyManager struct {
 	mock.Mock
 }
 
-func (m *MockCaddyManager) ReloadCaddy() error {
+func (m *MockCaddyManager) ValidateRunning() error {
 	args := m.Called()
 	return args.Error(0)
 }
 
-func (m *MockCaddyManager) GenerateConfig(configs map[string]*config.AppConfig, states interface{}) error {
-	args := m.Called(configs, states)
+func (m *MockCaddyManager) UpdateAppRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	args := m.Called(appName, configs, states)
+	return args.Error(0)
+}
+
+func (m *MockCaddyManager) RollbackRouting(appName string, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	args := m.Called(appName, configs, states)
+	return args.Error(0)
+}
+
+func (m *MockCaddyManager) UpdateWeightedRouting(appName string, weights map[string]int, configs map[string]*config.AppConfig, states map[string]*state.AppState) error {
+	args := m.Called(appName, weights, configs, states)
 	return args.Error(0)
 }
 
@@ -34,7 +49,7 @@ func TestDockerActionProvider_StartContainer(t *testing.T) {
 	t.Run("successful container start", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -61,7 +76,7 @@ func TestDockerActionProvider_StartContainer(t *testing.T) {
 	t.Run("app config not found", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{}
 		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
@@ -75,7 +90,7 @@ func TestDockerActionProvider_StartContainer(t *testing.T) {
 	t.Run("container creation failure", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -98,13 +113,81 @@ func TestDockerActionProvider_StartContainer(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to create container")
 		mockClient.AssertExpectations(t)
 	})
+
+	t.Run("pre_start hook runs before and post_start after container creation", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		cfg := createTestAppConfig()
+		var order []string
+		cfg.Hooks.PreStart = []config.HookStep{{Exec: &config.ExecStep{Command: "true"}}}
+		cfg.Hooks.PostStart = []config.HookStep{{Exec: &config.ExecStep{Command: "true"}}}
+		configs := map[string]*config.AppConfig{"test-app": cfg}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		mockClient.On("NetworkList", mock.Anything, mock.Anything).Return([]network.Inspect{{Name: "test-network", ID: "net123"}}, nil)
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil).
+			Run(func(mock.Arguments) { order = append(order, "container_list") })
+		mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "test-app-blue").
+			Run(func(mock.Arguments) { order = append(order, "container_create") }).
+			Return(container.CreateResponse{ID: "container123"}, nil)
+		mockClient.On("ContainerStart", mock.Anything, "container123", mock.Anything).Return(nil)
+		mockClient.On("NetworkConnect", mock.Anything, "net123", "container123", mock.Anything).Return(nil)
+
+		err := actionProvider.StartContainer("test-app", "blue", "nginx:1.21")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"container_list", "container_create"}, order)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("failing pre_start hook prevents container creation", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		cfg := createTestAppConfig()
+		cfg.Hooks.PreStart = []config.HookStep{{Exec: &config.ExecStep{Command: "false"}}}
+		configs := map[string]*config.AppConfig{"test-app": cfg}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		err := actionProvider.StartContainer("test-app", "blue", "nginx:1.21")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "pre_start hook step 0 failed")
+		mockClient.AssertNotCalled(t, "ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("pre_start hook with continue_on_error does not block container creation", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		cfg := createTestAppConfig()
+		cfg.Hooks.PreStart = []config.HookStep{{Exec: &config.ExecStep{Command: "false"}, ContinueOnError: true}}
+		configs := map[string]*config.AppConfig{"test-app": cfg}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		mockClient.On("NetworkList", mock.Anything, mock.Anything).Return([]network.Inspect{{Name: "test-network", ID: "net123"}}, nil)
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
+		mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "test-app-blue").
+			Return(container.CreateResponse{ID: "container123"}, nil)
+		mockClient.On("ContainerStart", mock.Anything, "container123", mock.Anything).Return(nil)
+		mockClient.On("NetworkConnect", mock.Anything, "net123", "container123", mock.Anything).Return(nil)
+
+		err := actionProvider.StartContainer("test-app", "blue", "nginx:1.21")
+
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
 }
 
 func TestDockerActionProvider_CheckHealth(t *testing.T) {
 	t.Run("healthy container", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -129,17 +212,17 @@ func TestDockerActionProvider_CheckHealth(t *testing.T) {
 		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
 		mockClient.On("ContainerInspect", mock.Anything, "container123").Return(containerJSON, nil)
 
-		healthy, err := actionProvider.CheckHealth("test-app", "blue")
+		result, err := actionProvider.CheckHealth("test-app", "blue")
 
 		assert.NoError(t, err)
-		assert.True(t, healthy)
+		assert.Equal(t, deployment.HealthStatusHealthy, result.Status)
 		mockClient.AssertExpectations(t)
 	})
 
 	t.Run("unhealthy container", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -162,10 +245,10 @@ func TestDockerActionProvider_CheckHealth(t *testing.T) {
 		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
 		mockClient.On("ContainerInspect", mock.Anything, "container123").Return(containerJSON, nil)
 
-		healthy, err := actionProvider.CheckHealth("test-app", "blue")
+		result, err := actionProvider.CheckHealth("test-app", "blue")
 
 		assert.NoError(t, err)
-		assert.False(t, healthy)
+		assert.Equal(t, deployment.HealthStatusUnhealthy, result.Status)
 		mockClient.AssertExpectations(t)
 	})
 }
@@ -174,7 +257,7 @@ func TestDockerActionProvider_StopContainer(t *testing.T) {
 	t.Run("successful container stop", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -183,7 +266,13 @@ func TestDockerActionProvider_StopContainer(t *testing.T) {
 
 		// Mock container operations
 		containers := []types.Container{{ID: "container123"}}
-		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil).Twice()
+		containerJSON := types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "container123", State: &types.ContainerState{}},
+			Config:            &container.Config{Image: "nginx:1.21"},
+			NetworkSettings:   &types.NetworkSettings{},
+		}
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil).Times(3)
+		mockClient.On("ContainerInspect", mock.Anything, "container123").Return(containerJSON, nil)
 		mockClient.On("ContainerStop", mock.Anything, "container123", mock.Anything).Return(nil)
 		mockClient.On("ContainerRemove", mock.Anything, "container123", mock.Anything).Return(nil)
 
@@ -196,7 +285,7 @@ func TestDockerActionProvider_StopContainer(t *testing.T) {
 	t.Run("container not found", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -211,20 +300,77 @@ func TestDockerActionProvider_StopContainer(t *testing.T) {
 		assert.Contains(t, err.Error(), "container test-app-blue not found")
 		mockClient.AssertExpectations(t)
 	})
+
+	t.Run("failing pre_stop hook prevents container stop", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		cfg := createTestAppConfig()
+		cfg.Hooks.PreStop = []config.HookStep{{Exec: &config.ExecStep{Command: "false"}}}
+		configs := map[string]*config.AppConfig{"test-app": cfg}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		containers := []types.Container{{ID: "container123"}}
+		containerJSON := types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "container123", State: &types.ContainerState{}},
+			Config:            &container.Config{Image: "nginx:1.21"},
+			NetworkSettings:   &types.NetworkSettings{},
+		}
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+		mockClient.On("ContainerInspect", mock.Anything, "container123").Return(containerJSON, nil)
+
+		err := actionProvider.StopContainer("test-app", "blue")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "pre_stop hook step 0 failed")
+		mockClient.AssertNotCalled(t, "ContainerStop", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("post_stop hook runs after container removal", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		var order []string
+		cfg := createTestAppConfig()
+		cfg.Hooks.PostStop = []config.HookStep{{Exec: &config.ExecStep{Command: "true"}}}
+		configs := map[string]*config.AppConfig{"test-app": cfg}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		containers := []types.Container{{ID: "container123"}}
+		containerJSON := types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "container123", State: &types.ContainerState{}},
+			Config:            &container.Config{Image: "nginx:1.21"},
+			NetworkSettings:   &types.NetworkSettings{},
+		}
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+		mockClient.On("ContainerInspect", mock.Anything, "container123").Return(containerJSON, nil)
+		mockClient.On("ContainerStop", mock.Anything, "container123", mock.Anything).Return(nil)
+		mockClient.On("ContainerRemove", mock.Anything, "container123", mock.Anything).
+			Run(func(mock.Arguments) { order = append(order, "container_remove") }).
+			Return(nil)
+
+		err := actionProvider.StopContainer("test-app", "blue")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"container_remove"}, order)
+		mockClient.AssertExpectations(t)
+	})
 }
 
 func TestDockerActionProvider_UpdateCaddy(t *testing.T) {
 	t.Run("successful caddy update", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
 		}
 		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
 
-		mockCaddy.On("ReloadCaddy").Return(nil)
+		mockCaddy.On("UpdateAppRouting", "test-app", mock.Anything, mock.Anything).Return(nil)
 
 		err := actionProvider.UpdateCaddy("test-app", "blue")
 
@@ -234,7 +380,7 @@ func TestDockerActionProvider_UpdateCaddy(t *testing.T) {
 
 	t.Run("caddy manager not available", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -250,27 +396,187 @@ func TestDockerActionProvider_UpdateCaddy(t *testing.T) {
 	t.Run("caddy reload failure", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
 		}
 		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
 
-		mockCaddy.On("ReloadCaddy").Return(errors.New("reload failed"))
+		mockCaddy.On("UpdateAppRouting", "test-app", mock.Anything, mock.Anything).Return(errors.New("reload failed"))
 
 		err := actionProvider.UpdateCaddy("test-app", "blue")
 
 		assert.Error(t, err)
 		mockCaddy.AssertExpectations(t)
 	})
+
+	t.Run("failing pre_swap hook prevents routing update", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		cfg := createTestAppConfig()
+		cfg.Hooks.PreSwap = []config.HookStep{{Exec: &config.ExecStep{Command: "false"}}}
+		configs := map[string]*config.AppConfig{"test-app": cfg}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		err := actionProvider.UpdateCaddy("test-app", "blue")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "pre_swap hook step 0 failed")
+		mockCaddy.AssertNotCalled(t, "UpdateAppRouting", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("post_swap hook runs after routing update", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		var order []string
+		cfg := createTestAppConfig()
+		cfg.Hooks.PostSwap = []config.HookStep{{Exec: &config.ExecStep{Command: "true"}}}
+		configs := map[string]*config.AppConfig{"test-app": cfg}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		mockCaddy.On("UpdateAppRouting", "test-app", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { order = append(order, "update_routing") }).
+			Return(nil)
+
+		err := actionProvider.UpdateCaddy("test-app", "blue")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"update_routing"}, order)
+		mockCaddy.AssertExpectations(t)
+	})
+}
+
+func TestDockerActionProvider_RollbackCaddy(t *testing.T) {
+	t.Run("successful caddy rollback", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{
+			"test-app": createTestAppConfig(),
+		}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		mockCaddy.On("RollbackRouting", "test-app", mock.Anything, mock.Anything).Return(nil)
+
+		err := actionProvider.RollbackCaddy("test-app", "blue")
+
+		assert.NoError(t, err)
+		mockCaddy.AssertExpectations(t)
+	})
+
+	t.Run("caddy rollback failure", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{
+			"test-app": createTestAppConfig(),
+		}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		mockCaddy.On("RollbackRouting", "test-app", mock.Anything, mock.Anything).Return(errors.New("rollback failed"))
+
+		err := actionProvider.RollbackCaddy("test-app", "blue")
+
+		assert.Error(t, err)
+		mockCaddy.AssertExpectations(t)
+	})
+}
+
+func rollbackTestState() *state.AppState {
+	now := time.Now().UTC()
+	return &state.AppState{
+		Name:               "test-app",
+		CurrentImage:       "nginx:1.22",
+		DesiredImage:       "nginx:1.22",
+		ActiveColor:        "green",
+		Status:             "stable",
+		LastDeployment:     now,
+		LastUpdated:        now,
+		PreviousImage:      "nginx:1.21",
+		PreviousColor:      "blue",
+		PreviousDeployment: now.Add(-time.Hour),
+	}
+}
+
+func TestDockerActionProvider_Rollback(t *testing.T) {
+	t.Run("no previous generation to roll back to", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{"test-app": createTestAppConfig()}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		appState := state.CreateInitialState("test-app", "nginx:1.21", state.ColorBlue)
+
+		err := actionProvider.Rollback("test-app", appState)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no previous generation")
+	})
+
+	t.Run("fails cleanly when the previous image was garbage collected", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{"test-app": createTestAppConfig()}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		// Previous color's container isn't running, and re-pulling its image fails.
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
+		mockClient.On("ImagePull", mock.Anything, "nginx:1.21", mock.Anything).
+			Return(io.NopCloser(strings.NewReader("")), errors.New("no such image: nginx:1.21"))
+
+		err := actionProvider.Rollback("test-app", rollbackTestState())
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to pull previous image")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("aborts when the previous container fails its health check", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{"test-app": createTestAppConfig()}
+		configs["test-app"].HealthCheck.Endpoint = ""
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		// Previous color's container is already running, so no pull/start is needed.
+		containers := []types.Container{{ID: "container123"}}
+		containerJSON := types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:    "container123",
+				State: &types.ContainerState{Status: "exited"},
+			},
+			Config:          &container.Config{Image: "nginx:1.21"},
+			NetworkSettings: &types.NetworkSettings{},
+		}
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+		mockClient.On("ContainerInspect", mock.Anything, "container123").Return(containerJSON, nil)
+
+		err := actionProvider.Rollback("test-app", rollbackTestState())
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not healthy")
+		mockCaddy.AssertExpectations(t)
+	})
 }
 
 func TestDockerActionProvider_DrainConnections(t *testing.T) {
 	t.Run("successful drain", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -286,7 +592,7 @@ func TestDockerActionProvider_DrainConnections(t *testing.T) {
 	t.Run("context cancelled during drain", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockCaddy := new(MockCaddyManager)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		configs := map[string]*config.AppConfig{
 			"test-app": createTestAppConfig(),
@@ -303,4 +609,44 @@ func TestDockerActionProvider_DrainConnections(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, context.Canceled, err)
 	})
+
+	t.Run("returns early when proxy has no active connections", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{
+			"test-app": createTestAppConfig(),
+		}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		p, err := proxy.New("test-app", proxy.ModeTCP, config.Proxy{ListenPort: 19090}, "127.0.0.1:8081", "127.0.0.1:8082", "blue", time.Second)
+		assert.NoError(t, err)
+		actionProvider.SetProxy("test-app", p)
+
+		start := time.Now()
+		err = actionProvider.DrainConnections("test-app", "blue", 5*time.Second)
+
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 1*time.Second, "should return as soon as ActiveConnections() is 0, not wait out the full timeout")
+	})
+}
+
+func TestDockerActionProvider_ConnectionsRemaining(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	mockCaddy := new(MockCaddyManager)
+	dm := NewRuntimeManager(mockClient)
+
+	configs := map[string]*config.AppConfig{
+		"test-app": createTestAppConfig(),
+	}
+	actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+	assert.Equal(t, 0, actionProvider.ConnectionsRemaining("test-app", "blue"), "no registered proxy should report 0")
+
+	p, err := proxy.New("test-app", proxy.ModeTCP, config.Proxy{ListenPort: 19091}, "127.0.0.1:8081", "127.0.0.1:8082", "blue", time.Second)
+	assert.NoError(t, err)
+	actionProvider.SetProxy("test-app", p)
+
+	assert.Equal(t, p.ActiveConnections(), actionProvider.ConnectionsRemaining("test-app", "blue"))
 }
@@ -3,118 +3,118 @@ package docker
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/network"
-	"github.com/docker/docker/api/types/system"
-	"github.com/docker/docker/client"
-)
+	"github.com/docker/docker/api/types/image"
 
-// DockerClient interface for testability
-type DockerClient interface {
-	// Container operations
-	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, containerName string) (container.CreateResponse, error)
-	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
-	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
-	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
-	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
-	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
-
-	// Network operations
-	NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error)
-	NetworkList(ctx context.Context, options network.ListOptions) ([]network.Inspect, error)
-	NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error
-
-	// System operations
-	Ping(ctx context.Context) (types.Ping, error)
-	Info(ctx context.Context) (system.Info, error)
-
-	// Cleanup
-	Close() error
-}
+	"dockswap/internal/config"
+	"dockswap/internal/runtime"
+)
 
-// RealDockerClient wraps the official Docker client
-type RealDockerClient struct {
-	client *client.Client
+// RuntimeManager provides high-level container operations over a pluggable
+// runtime.Client backend (Docker, Podman, or containerd - see
+// runtime.NewClient), so everything above it (findContainers,
+// ListAppContainers, the state tables) stays oblivious to which daemon is
+// actually running the containers.
+type RuntimeManager struct {
+	client       runtime.Client
+	authResolver RegistryAuthResolver
 }
 
-func NewDockerClient() (*RealDockerClient, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+func NewRuntimeManager(client runtime.Client) *RuntimeManager {
+	return &RuntimeManager{
+		client:       client,
+		authResolver: NewDockerConfigAuthResolver(),
 	}
-
-	return &RealDockerClient{client: cli}, nil
-}
-
-func (r *RealDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, containerName string) (container.CreateResponse, error) {
-	return r.client.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, containerName)
-}
-
-func (r *RealDockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
-	return r.client.ContainerStart(ctx, containerID, options)
-}
-
-func (r *RealDockerClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
-	return r.client.ContainerStop(ctx, containerID, options)
 }
 
-func (r *RealDockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
-	return r.client.ContainerRemove(ctx, containerID, options)
+// SetRegistryAuthResolver overrides the resolver PullImage uses to look up
+// registry credentials; tests use this to inject a fake instead of reading
+// the real ~/.docker/config.json.
+func (dm *RuntimeManager) SetRegistryAuthResolver(resolver RegistryAuthResolver) {
+	dm.authResolver = resolver
 }
 
-func (r *RealDockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
-	return r.client.ContainerList(ctx, options)
-}
-
-func (r *RealDockerClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
-	return r.client.ContainerInspect(ctx, containerID)
-}
-
-func (r *RealDockerClient) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
-	return r.client.NetworkCreate(ctx, name, options)
-}
-
-func (r *RealDockerClient) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Inspect, error) {
-	return r.client.NetworkList(ctx, options)
-}
-
-func (r *RealDockerClient) NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error {
-	return r.client.NetworkConnect(ctx, networkID, containerID, config)
-}
-
-func (r *RealDockerClient) Ping(ctx context.Context) (types.Ping, error) {
-	return r.client.Ping(ctx)
+func (dm *RuntimeManager) ValidateConnection(ctx context.Context) error {
+	_, err := dm.client.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("docker daemon not accessible: %w", err)
+	}
+	return nil
 }
 
-func (r *RealDockerClient) Info(ctx context.Context) (system.Info, error) {
-	return r.client.Info(ctx)
+func (dm *RuntimeManager) Close() error {
+	return dm.client.Close()
 }
 
-func (r *RealDockerClient) Close() error {
-	return r.client.Close()
-}
+// PullImage pulls imageRef and blocks until the pull completes, discarding
+// the daemon's progress stream. CreateContainer assumes the image is
+// already present locally; callers that need a fresh image (e.g. the
+// watcher after detecting a new registry digest) must PullImage first.
+// appConfig may be nil for an anonymous pull; otherwise its Docker.Registry
+// override (if any) and, failing that, the host's Docker config are
+// consulted via authResolver.
+func (dm *RuntimeManager) PullImage(ctx context.Context, imageRef string, appConfig *config.AppConfig) error {
+	return dm.PullImageWithOptions(ctx, imageRef, appConfig, PullOptions{})
+}
+
+// PullOptions extends PullImageWithOptions beyond the bare image reference,
+// mirroring StreamLogsOptions' pattern of bolting options onto an existing
+// call without breaking PullImage's existing callers.
+type PullOptions struct {
+	// Platform pins the pull to a specific os/arch (e.g. "linux/amd64"),
+	// forwarded to image.PullOptions.Platform; empty pulls whatever the
+	// daemon resolves by default.
+	Platform string
+
+	// Progress, if set, receives the daemon's raw JSON progress stream
+	// instead of having it silently discarded.
+	Progress io.Writer
+}
+
+// PullImageWithOptions is PullImage with room for a pinned Platform and/or a
+// progress sink; handleDeploy's pre-flight pull uses this to stream status
+// to the deploy log instead of pulling blind.
+func (dm *RuntimeManager) PullImageWithOptions(ctx context.Context, imageRef string, appConfig *config.AppConfig, opts PullOptions) error {
+	auth, err := dm.authResolver.ResolveAuth(ctx, imageRef, appConfig)
+	if err != nil {
+		return fmt.Errorf("failed to resolve registry auth for %s: %w", imageRef, err)
+	}
 
-// DockerManager provides high-level Docker operations
-type DockerManager struct {
-	client DockerClient
-}
+	reader, err := dm.client.ImagePull(ctx, imageRef, image.PullOptions{RegistryAuth: auth, Platform: opts.Platform})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", imageRef, err)
+	}
+	defer reader.Close()
 
-func NewDockerManager(client DockerClient) *DockerManager {
-	return &DockerManager{
-		client: client,
+	dest := io.Discard
+	if opts.Progress != nil {
+		dest = opts.Progress
 	}
+	if _, err := io.Copy(dest, reader); err != nil {
+		return fmt.Errorf("failed to read pull progress for %s: %w", imageRef, err)
+	}
+
+	return nil
 }
 
-func (dm *DockerManager) ValidateConnection(ctx context.Context) error {
-	_, err := dm.client.Ping(ctx)
+// InspectImageDigest resolves imageRef's locally pulled content digest (its
+// first RepoDigest) - callers that just pulled imageRef use this to find
+// the immutable digest a mutable tag currently refers to, e.g. to compare
+// against Deployment.PinnedDigest before creating a container.
+func (dm *RuntimeManager) InspectImageDigest(ctx context.Context, imageRef string) (string, error) {
+	inspect, err := dm.client.ImageInspect(ctx, imageRef)
 	if err != nil {
-		return fmt.Errorf("docker daemon not accessible: %w", err)
+		return "", fmt.Errorf("failed to inspect image %s: %w", imageRef, err)
+	}
+	if len(inspect.RepoDigests) == 0 {
+		return "", fmt.Errorf("image %s has no resolvable digest", imageRef)
 	}
-	return nil
-}
 
-func (dm *DockerManager) Close() error {
-	return dm.client.Close()
+	digest := inspect.RepoDigests[0]
+	if at := strings.IndexByte(digest, '@'); at != -1 {
+		digest = digest[at+1:]
+	}
+	return digest, nil
 }
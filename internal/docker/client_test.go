@@ -3,17 +3,23 @@ package docker
 import (
 	"context"
 	"errors"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/system"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"dockswap/internal/config"
 )
 
-// MockDockerClient is a mock implementation of DockerClient
+// MockDockerClient is a mock implementation of runtime.Client
 type MockDockerClient struct {
 	mock.Mock
 }
@@ -48,6 +54,47 @@ func (m *MockDockerClient) ContainerInspect(ctx context.Context, containerID str
 	return args.Get(0).(types.ContainerJSON), args.Error(1)
 }
 
+func (m *MockDockerClient) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
+	args := m.Called(ctx, refStr, options)
+	rc, _ := args.Get(0).(io.ReadCloser)
+	return rc, args.Error(1)
+}
+
+func (m *MockDockerClient) ImageInspect(ctx context.Context, refStr string) (types.ImageInspect, error) {
+	args := m.Called(ctx, refStr)
+	return args.Get(0).(types.ImageInspect), args.Error(1)
+}
+
+func (m *MockDockerClient) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (types.IDResponse, error) {
+	args := m.Called(ctx, containerID, options)
+	return args.Get(0).(types.IDResponse), args.Error(1)
+}
+
+func (m *MockDockerClient) ContainerExecStart(ctx context.Context, execID string, options container.ExecStartOptions) error {
+	args := m.Called(ctx, execID, options)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	args := m.Called(ctx, execID)
+	return args.Get(0).(container.ExecInspect), args.Error(1)
+}
+
+func (m *MockDockerClient) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	args := m.Called(ctx, containerID, options)
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockDockerClient) CopyToContainer(ctx context.Context, containerID, path string, content io.Reader, options container.CopyToContainerOptions) error {
+	args := m.Called(ctx, containerID, path, content, options)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	args := m.Called(ctx, containerID, srcPath)
+	return args.Get(0).(io.ReadCloser), args.Get(1).(container.PathStat), args.Error(2)
+}
+
 func (m *MockDockerClient) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
 	args := m.Called(ctx, name, options)
 	return args.Get(0).(network.CreateResponse), args.Error(1)
@@ -73,17 +120,22 @@ func (m *MockDockerClient) Info(ctx context.Context) (system.Info, error) {
 	return args.Get(0).(system.Info), args.Error(1)
 }
 
+func (m *MockDockerClient) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).(<-chan events.Message), args.Get(1).(<-chan error)
+}
+
 func (m *MockDockerClient) Close() error {
 	args := m.Called()
 	return args.Error(0)
 }
 
-func TestDockerManager_ValidateConnection(t *testing.T) {
+func TestRuntimeManager_ValidateConnection(t *testing.T) {
 	t.Run("successful connection", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockClient.On("Ping", mock.Anything).Return(types.Ping{}, nil)
 
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 		err := dm.ValidateConnection(context.Background())
 
 		assert.NoError(t, err)
@@ -94,7 +146,7 @@ func TestDockerManager_ValidateConnection(t *testing.T) {
 		mockClient := new(MockDockerClient)
 		mockClient.On("Ping", mock.Anything).Return(types.Ping{}, errors.New("connection failed"))
 
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 		err := dm.ValidateConnection(context.Background())
 
 		assert.Error(t, err)
@@ -103,21 +155,130 @@ func TestDockerManager_ValidateConnection(t *testing.T) {
 	})
 }
 
-func TestNewDockerManager(t *testing.T) {
+func TestNewRuntimeManager(t *testing.T) {
 	mockClient := new(MockDockerClient)
-	dm := NewDockerManager(mockClient)
+	dm := NewRuntimeManager(mockClient)
 
 	assert.NotNil(t, dm)
 	assert.Equal(t, mockClient, dm.client)
 }
 
-func TestDockerManager_Close(t *testing.T) {
+func TestRuntimeManager_Close(t *testing.T) {
 	mockClient := new(MockDockerClient)
 	mockClient.On("Close").Return(nil)
 
-	dm := NewDockerManager(mockClient)
+	dm := NewRuntimeManager(mockClient)
 	err := dm.Close()
 
 	assert.NoError(t, err)
 	mockClient.AssertExpectations(t)
 }
+
+// fakeAuthResolver is a RegistryAuthResolver test double that hands back a
+// canned auth string (or error) without touching the real Docker config.
+type fakeAuthResolver struct {
+	auth string
+	err  error
+}
+
+func (f *fakeAuthResolver) ResolveAuth(ctx context.Context, imageRef string, appConfig *config.AppConfig) (string, error) {
+	return f.auth, f.err
+}
+
+func TestRuntimeManager_PullImage(t *testing.T) {
+	t.Run("successful pull", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockClient.On("ImagePull", mock.Anything, "myapp:latest", image.PullOptions{RegistryAuth: "creds"}).
+			Return(io.NopCloser(strings.NewReader(`{"status":"Pulling from myapp"}`)), nil)
+
+		dm := NewRuntimeManager(mockClient)
+		dm.SetRegistryAuthResolver(&fakeAuthResolver{auth: "creds"})
+		err := dm.PullImage(context.Background(), "myapp:latest", nil)
+
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("pull failure", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockClient.On("ImagePull", mock.Anything, "myapp:latest", mock.Anything).
+			Return(io.ReadCloser(nil), errors.New("no such image"))
+
+		dm := NewRuntimeManager(mockClient)
+		dm.SetRegistryAuthResolver(&fakeAuthResolver{})
+		err := dm.PullImage(context.Background(), "myapp:latest", nil)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to pull image myapp:latest")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("auth resolution failure", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+
+		dm := NewRuntimeManager(mockClient)
+		dm.SetRegistryAuthResolver(&fakeAuthResolver{err: errors.New("credential helper exited 1")})
+		err := dm.PullImage(context.Background(), "myapp:latest", nil)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to resolve registry auth")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("platform and progress are forwarded", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockClient.On("ImagePull", mock.Anything, "myapp:latest", image.PullOptions{RegistryAuth: "creds", Platform: "linux/arm64"}).
+			Return(io.NopCloser(strings.NewReader(`{"status":"Pulling from myapp"}`)), nil)
+
+		dm := NewRuntimeManager(mockClient)
+		dm.SetRegistryAuthResolver(&fakeAuthResolver{auth: "creds"})
+
+		var progress strings.Builder
+		err := dm.PullImageWithOptions(context.Background(), "myapp:latest", nil, PullOptions{Platform: "linux/arm64", Progress: &progress})
+
+		assert.NoError(t, err)
+		assert.Contains(t, progress.String(), "Pulling from myapp")
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestRuntimeManager_InspectImageDigest(t *testing.T) {
+	t.Run("returns the resolved digest", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockClient.On("ImageInspect", mock.Anything, "myapp:latest").
+			Return(types.ImageInspect{RepoDigests: []string{"myapp@sha256:abc123"}}, nil)
+
+		dm := NewRuntimeManager(mockClient)
+		digest, err := dm.InspectImageDigest(context.Background(), "myapp:latest")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "sha256:abc123", digest)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("no repo digests is an error", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockClient.On("ImageInspect", mock.Anything, "myapp:latest").
+			Return(types.ImageInspect{}, nil)
+
+		dm := NewRuntimeManager(mockClient)
+		_, err := dm.InspectImageDigest(context.Background(), "myapp:latest")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no resolvable digest")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("inspect failure is wrapped", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockClient.On("ImageInspect", mock.Anything, "myapp:latest").
+			Return(types.ImageInspect{}, errors.New("no such image"))
+
+		dm := NewRuntimeManager(mockClient)
+		_, err := dm.InspectImageDigest(context.Background(), "myapp:latest")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to inspect image myapp:latest")
+		mockClient.AssertExpectations(t)
+	})
+}
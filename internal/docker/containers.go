@@ -3,6 +3,8 @@ package docker
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
+	units "github.com/docker/go-units"
 
 	"dockswap/internal/config"
 )
@@ -22,17 +25,26 @@ type ContainerInfo struct {
 	Status  string
 	State   string
 	Health  string
-	Ports   map[int]int // container:host port mapping
-	Created time.Time
+	// FailingStreak is Docker's count of consecutive failing healthcheck
+	// runs (State.Health.FailingStreak), 0 when the container has no
+	// healthcheck or its last run passed.
+	FailingStreak int
+	Ports         map[int]int // container:host port mapping
+	// Networks lists the names of every Docker network this container is
+	// currently attached to, from ContainerList's NetworkSettings summary -
+	// internal/reconciler compares it against docker.network to catch a
+	// container that's fallen off its app network without a full Inspect.
+	Networks []string
+	Created  time.Time
 }
 
-func (dm *DockerManager) CreateContainer(ctx context.Context, appName, color, image string, appConfig *config.AppConfig) (*ContainerInfo, error) {
+func (dm *RuntimeManager) CreateContainer(ctx context.Context, appName, color, image string, appConfig *config.AppConfig) (*ContainerInfo, error) {
 	containerName := fmt.Sprintf("%s-%s", appName, color)
 
 	// Build container configuration
 	containerConfig := &container.Config{
 		Image: image,
-		Env:   buildEnvironmentVars(appConfig.Docker.Environment),
+		Env:   buildEnvironmentVars(appConfig.Docker.GetEnvironmentForColor(color)),
 		Labels: map[string]string{
 			"dockswap.app":     appName,
 			"dockswap.color":   color,
@@ -63,11 +75,18 @@ func (dm *DockerManager) CreateContainer(ctx context.Context, appName, color, im
 		return nil, fmt.Errorf("failed to apply volume mounts: %w", err)
 	}
 
-	// Network configuration
+	// Define the container's own Docker-native HEALTHCHECK, if configured
+	applyHealthcheck(containerConfig, appConfig)
+
+	// Network configuration: ContainerCreate only accepts one network in its
+	// NetworkingConfig, so the first attachment is wired in there and any
+	// remaining ones are joined afterward with NetworkConnect, mirroring how
+	// testcontainers-go configures multi-network endpoints.
+	attachments := networkAttachmentsFor(appConfig)
 	networkingConfig := &network.NetworkingConfig{}
-	if appConfig.Docker.Network != "" {
+	if len(attachments) > 0 {
 		networkingConfig.EndpointsConfig = map[string]*network.EndpointSettings{
-			appConfig.Docker.Network: {},
+			attachments[0].Name: endpointSettingsFor(appName, attachments[0]),
 		}
 	}
 
@@ -77,6 +96,14 @@ func (dm *DockerManager) CreateContainer(ctx context.Context, appName, color, im
 		return nil, fmt.Errorf("failed to create container %s: %w", containerName, err)
 	}
 
+	for i := 1; i < len(attachments); i++ {
+		attachment := attachments[i]
+		settings := endpointSettingsFor(appName, attachment)
+		if err := dm.client.NetworkConnect(ctx, attachment.Name, resp.ID, settings); err != nil {
+			return nil, fmt.Errorf("failed to connect container %s to network %s: %w", containerName, attachment.Name, err)
+		}
+	}
+
 	return &ContainerInfo{
 		ID:      resp.ID,
 		Name:    containerName,
@@ -88,7 +115,7 @@ func (dm *DockerManager) CreateContainer(ctx context.Context, appName, color, im
 	}, nil
 }
 
-func (dm *DockerManager) StartContainer(ctx context.Context, containerID string) error {
+func (dm *RuntimeManager) StartContainer(ctx context.Context, containerID string) error {
 	err := dm.client.ContainerStart(ctx, containerID, container.StartOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to start container %s: %w", containerID, err)
@@ -96,7 +123,7 @@ func (dm *DockerManager) StartContainer(ctx context.Context, containerID string)
 	return nil
 }
 
-func (dm *DockerManager) StopContainer(ctx context.Context, appName, color string, timeout time.Duration) error {
+func (dm *RuntimeManager) StopContainer(ctx context.Context, appName, color string, timeout time.Duration) error {
 	containerName := fmt.Sprintf("%s-%s", appName, color)
 
 	// Find container
@@ -123,7 +150,7 @@ func (dm *DockerManager) StopContainer(ctx context.Context, appName, color strin
 	return nil
 }
 
-func (dm *DockerManager) RemoveContainer(ctx context.Context, appName, color string, force bool) error {
+func (dm *RuntimeManager) RemoveContainer(ctx context.Context, appName, color string, force bool) error {
 	containerName := fmt.Sprintf("%s-%s", appName, color)
 
 	// Find container
@@ -150,7 +177,7 @@ func (dm *DockerManager) RemoveContainer(ctx context.Context, appName, color str
 	return nil
 }
 
-func (dm *DockerManager) GetContainerInfo(ctx context.Context, appName, color string) (*ContainerInfo, error) {
+func (dm *RuntimeManager) GetContainerInfo(ctx context.Context, appName, color string) (*ContainerInfo, error) {
 	containers, err := dm.findContainers(ctx, appName, color)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find container: %w", err)
@@ -182,23 +209,26 @@ func (dm *DockerManager) GetContainerInfo(ctx context.Context, appName, color st
 
 	// Determine health status
 	health := "unknown"
+	failingStreak := 0
 	if containerJSON.State.Health != nil {
 		health = strings.ToLower(containerJSON.State.Health.Status)
+		failingStreak = containerJSON.State.Health.FailingStreak
 	}
 
 	return &ContainerInfo{
-		ID:      containerJSON.ID,
-		Name:    strings.TrimPrefix(containerJSON.Name, "/"),
-		Image:   containerJSON.Config.Image,
-		Status:  containerJSON.State.Status,
-		State:   containerJSON.State.Status,
-		Health:  health,
-		Ports:   ports,
-		Created: parseCreatedTime(containerJSON.Created),
+		ID:            containerJSON.ID,
+		Name:          strings.TrimPrefix(containerJSON.Name, "/"),
+		Image:         containerJSON.Config.Image,
+		Status:        containerJSON.State.Status,
+		State:         containerJSON.State.Status,
+		Health:        health,
+		FailingStreak: failingStreak,
+		Ports:         ports,
+		Created:       parseCreatedTime(containerJSON.Created),
 	}, nil
 }
 
-func (dm *DockerManager) ListAppContainers(ctx context.Context, appName string) ([]*ContainerInfo, error) {
+func (dm *RuntimeManager) ListAppContainers(ctx context.Context, appName string) ([]*ContainerInfo, error) {
 	filterArgs := filters.NewArgs()
 	filterArgs.Add("label", fmt.Sprintf("dockswap.app=%s", appName))
 	filterArgs.Add("label", "dockswap.managed=true")
@@ -225,13 +255,19 @@ func (dm *DockerManager) ListAppContainers(ctx context.Context, appName string)
 			info.Name = strings.TrimPrefix(container.Names[0], "/")
 		}
 
+		if container.NetworkSettings != nil {
+			for netName := range container.NetworkSettings.Networks {
+				info.Networks = append(info.Networks, netName)
+			}
+		}
+
 		result = append(result, info)
 	}
 
 	return result, nil
 }
 
-func (dm *DockerManager) ContainerExists(ctx context.Context, appName, color string) (bool, error) {
+func (dm *RuntimeManager) ContainerExists(ctx context.Context, appName, color string) (bool, error) {
 	containers, err := dm.findContainers(ctx, appName, color)
 	if err != nil {
 		return false, err
@@ -239,7 +275,7 @@ func (dm *DockerManager) ContainerExists(ctx context.Context, appName, color str
 	return len(containers) > 0, nil
 }
 
-func (dm *DockerManager) findContainers(ctx context.Context, appName, color string) ([]types.Container, error) {
+func (dm *RuntimeManager) findContainers(ctx context.Context, appName, color string) ([]types.Container, error) {
 	filterArgs := filters.NewArgs()
 	filterArgs.Add("label", fmt.Sprintf("dockswap.app=%s", appName))
 	filterArgs.Add("label", fmt.Sprintf("dockswap.color=%s", color))
@@ -251,6 +287,52 @@ func (dm *DockerManager) findContainers(ctx context.Context, appName, color stri
 	})
 }
 
+func (dm *RuntimeManager) findServiceContainers(ctx context.Context, appName, color, service string) ([]types.Container, error) {
+	filterArgs := filters.NewArgs()
+	filterArgs.Add("label", fmt.Sprintf("dockswap.app=%s", appName))
+	filterArgs.Add("label", fmt.Sprintf("dockswap.color=%s", color))
+	filterArgs.Add("label", fmt.Sprintf("dockswap.service=%s", service))
+	filterArgs.Add("label", "dockswap.managed=true")
+
+	return dm.client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filterArgs,
+	})
+}
+
+// getServiceContainerInfo inspects the running container for one of
+// appName's sidecars, mirroring GetContainerInfo for the main container.
+func (dm *RuntimeManager) getServiceContainerInfo(ctx context.Context, appName, color, service string) (*ContainerInfo, error) {
+	containers, err := dm.findServiceContainers(ctx, appName, color, service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find container: %w", err)
+	}
+
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("service container %s-%s-%s not found", appName, color, service)
+	}
+
+	containerJSON, err := dm.client.ContainerInspect(ctx, containers[0].ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	health := "unknown"
+	if containerJSON.State.Health != nil {
+		health = strings.ToLower(containerJSON.State.Health.Status)
+	}
+
+	return &ContainerInfo{
+		ID:      containerJSON.ID,
+		Name:    strings.TrimPrefix(containerJSON.Name, "/"),
+		Image:   containerJSON.Config.Image,
+		Status:  containerJSON.State.Status,
+		State:   containerJSON.State.Status,
+		Health:  health,
+		Created: parseCreatedTime(containerJSON.Created),
+	}, nil
+}
+
 func buildEnvironmentVars(envMap map[string]string) []string {
 	var env []string
 	for key, value := range envMap {
@@ -259,31 +341,92 @@ func buildEnvironmentVars(envMap map[string]string) []string {
 	return env
 }
 
+// defaultCPUPeriod is Docker's own scheduler period for --cpus, used
+// whenever Docker.CPUPeriod is left unset (0).
+const defaultCPUPeriod = 100000 // 100ms
+
+// applyResourceLimits translates appConfig.Docker's resource knobs - memory,
+// swap, reservation, CPU quota/shares/pinning, pids limit, blkio weight, and
+// ulimits, already parsed and range-checked by config.validateConfig at load
+// time - into the container.HostConfig fields the Docker API expects.
 func applyResourceLimits(hostConfig *container.HostConfig, appConfig *config.AppConfig) error {
-	// Apply memory limit
-	if appConfig.Docker.MemoryLimit != "" {
-		memoryBytes, err := parseMemoryLimit(appConfig.Docker.MemoryLimit)
-		if err != nil {
-			return fmt.Errorf("invalid memory limit %s: %w", appConfig.Docker.MemoryLimit, err)
+	docker := appConfig.Docker
+
+	if docker.MemoryLimit != "" {
+		hostConfig.Memory = docker.MemoryBytes
+	}
+
+	if docker.MemorySwap != "" {
+		hostConfig.MemorySwap = docker.MemorySwapBytes
+	}
+
+	if docker.MemoryReservation != "" {
+		hostConfig.MemoryReservation = docker.MemoryReservationBytes
+	}
+
+	if docker.CPULimit != "" {
+		period := docker.CPUPeriod
+		if period == 0 {
+			period = defaultCPUPeriod
 		}
-		hostConfig.Memory = memoryBytes
+		hostConfig.CPUPeriod = period
+		hostConfig.CPUQuota = int64(math.Round(docker.CPUs * float64(period)))
 	}
 
-	// Apply CPU limit
-	if appConfig.Docker.CPULimit != "" {
-		cpuQuota, err := parseCPULimit(appConfig.Docker.CPULimit)
-		if err != nil {
-			return fmt.Errorf("invalid CPU limit %s: %w", appConfig.Docker.CPULimit, err)
+	if docker.CPUShares != 0 {
+		hostConfig.CPUShares = docker.CPUShares
+	}
+
+	if docker.CPUSetCPUs != "" {
+		hostConfig.CpusetCpus = docker.CPUSetCPUs
+	}
+
+	if docker.PidsLimit != 0 {
+		pidsLimit := docker.PidsLimit
+		hostConfig.PidsLimit = &pidsLimit
+	}
+
+	if docker.BlkioWeight != 0 {
+		hostConfig.BlkioWeight = docker.BlkioWeight
+	}
+
+	if len(docker.Ulimits) > 0 {
+		ulimits := make([]*units.Ulimit, 0, len(docker.Ulimits))
+		for _, u := range docker.Ulimits {
+			ulimits = append(ulimits, &units.Ulimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard})
 		}
-		hostConfig.CPUQuota = cpuQuota
-		hostConfig.CPUPeriod = 100000 // 100ms period
+		hostConfig.Ulimits = ulimits
 	}
 
 	return nil
 }
 
 func applyPortMappings(hostConfig *container.HostConfig, containerConfig *container.Config, appConfig *config.AppConfig, color string) error {
-	// Determine the host port based on color
+	if len(appConfig.Docker.PortMappings) == 0 {
+		return applyLegacyPortMapping(hostConfig, containerConfig, appConfig, color)
+	}
+
+	mappings, err := resolvePortMappings(appConfig, color)
+	if err != nil {
+		return err
+	}
+
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+	for _, m := range mappings {
+		exposedPorts[m.Port] = struct{}{}
+		portBindings[m.Port] = append(portBindings[m.Port], m.Binding)
+	}
+
+	containerConfig.ExposedPorts = exposedPorts
+	hostConfig.PortBindings = portBindings
+	return nil
+}
+
+// applyLegacyPortMapping wires appConfig.Docker.ExposePort to a single host
+// port derived from color, the original single-port behavior kept for apps
+// that haven't adopted Docker.PortMappings.
+func applyLegacyPortMapping(hostConfig *container.HostConfig, containerConfig *container.Config, appConfig *config.AppConfig, color string) error {
 	var hostPort int
 	if color == "blue" {
 		hostPort = appConfig.Ports.Blue
@@ -291,7 +434,6 @@ func applyPortMappings(hostConfig *container.HostConfig, containerConfig *contai
 		hostPort = appConfig.Ports.Green
 	}
 
-	// Configure port mapping
 	containerConfig.ExposedPorts = nat.PortSet{
 		nat.Port(fmt.Sprintf("%d/tcp", appConfig.Docker.ExposePort)): struct{}{},
 	}
@@ -307,6 +449,72 @@ func applyPortMappings(hostConfig *container.HostConfig, containerConfig *contai
 	return nil
 }
 
+// resolvePortMappings parses appConfig.Docker.PortMappings (Docker's native
+// -p syntax, including ranges) and shifts every resulting host port by
+// colorPortOffset(appConfig.Docker, color), so the same set of specs
+// produces non-colliding host ports per color.
+func resolvePortMappings(appConfig *config.AppConfig, color string) ([]nat.PortMapping, error) {
+	offset := colorPortOffset(appConfig.Docker, color)
+
+	var result []nat.PortMapping
+	for _, spec := range appConfig.Docker.PortMappings {
+		parsed, err := nat.ParsePortSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid docker.port_mappings entry %q: %w", spec, err)
+		}
+
+		for _, m := range parsed {
+			if m.Binding.HostPort != "" {
+				hostPort, err := strconv.Atoi(m.Binding.HostPort)
+				if err != nil {
+					return nil, fmt.Errorf("invalid host port in docker.port_mappings entry %q: %w", spec, err)
+				}
+				m.Binding.HostPort = strconv.Itoa(hostPort + offset)
+			}
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// colorPortOffset returns how much to shift a PortMappings host port by for
+// color: "blue" is always the zero-offset anchor (its host ports are the
+// spec's literal ones), Docker.ColorPortOffsets[color] wins if set, and
+// Docker.ColorPortOffset is the fallback applied to every other color.
+func colorPortOffset(docker config.Docker, color string) int {
+	if color == "blue" || color == "" {
+		return 0
+	}
+	if offset, ok := docker.ColorPortOffsets[color]; ok {
+		return offset
+	}
+	return docker.ColorPortOffset
+}
+
+// applyHealthcheck translates appConfig.HealthCheck into the container's own
+// Docker-native HEALTHCHECK (container.Config.Healthcheck), so dockswap
+// defines the check itself instead of relying on whatever HEALTHCHECK (if
+// any) the image baked in. Only HealthCheckTypeExec has a direct
+// translation - CMD is the only test Docker's native healthcheck supports,
+// and ExecCommand is the one field already expressed as a CMD. HTTP/TCP/gRPC
+// checks stay out-of-band, run by docker.HealthProbe against the host, since
+// there's no command inside the container to point Docker's own HEALTHCHECK
+// at.
+func applyHealthcheck(containerConfig *container.Config, appConfig *config.AppConfig) {
+	hc := appConfig.HealthCheck
+	if hc.Type != config.HealthCheckTypeExec || len(hc.ExecCommand) == 0 {
+		return
+	}
+
+	containerConfig.Healthcheck = &container.HealthConfig{
+		Test:        append([]string{"CMD"}, hc.ExecCommand...),
+		Interval:    hc.Interval,
+		Timeout:     hc.Timeout,
+		Retries:     hc.Retries,
+		StartPeriod: hc.StartPeriod,
+	}
+}
+
 func applyVolumeMounts(hostConfig *container.HostConfig, appConfig *config.AppConfig) error {
 	var binds []string
 	for _, volume := range appConfig.Docker.Volumes {
@@ -316,31 +524,40 @@ func applyVolumeMounts(hostConfig *container.HostConfig, appConfig *config.AppCo
 	return nil
 }
 
-func parseMemoryLimit(limit string) (int64, error) {
-	// Simple memory parsing - in production you'd want more robust parsing
-	// This handles formats like "512m", "1g", etc.
-	if strings.HasSuffix(limit, "m") || strings.HasSuffix(limit, "M") {
-		// Parse megabytes
-		return 512 * 1024 * 1024, nil // Simplified
+// networkAttachmentsFor returns the networks a container should join:
+// appConfig.Docker.Networks if set, else a single-entry list built from the
+// legacy Docker.Network field so configs that haven't adopted multi-network
+// attach keep working unchanged.
+func networkAttachmentsFor(appConfig *config.AppConfig) []config.NetworkAttachment {
+	if len(appConfig.Docker.Networks) > 0 {
+		return appConfig.Docker.Networks
 	}
-	if strings.HasSuffix(limit, "g") || strings.HasSuffix(limit, "G") {
-		// Parse gigabytes
-		return 1024 * 1024 * 1024, nil // Simplified
+	if appConfig.Docker.Network != "" {
+		return []config.NetworkAttachment{{Name: appConfig.Docker.Network}}
 	}
-	return 0, fmt.Errorf("unsupported memory format: %s", limit)
+	return nil
 }
 
-func parseCPULimit(limit string) (int64, error) {
-	// Simple CPU parsing - in production you'd want more robust parsing
-	// This handles formats like "0.5", "1.0", etc.
-	if limit == "0.5" {
-		return 50000, nil // 50% of CPU
+// activeAlias is a stable per-app DNS name attached to every network a
+// container joins, so an upstream (a caddy route, another service) can
+// dial "<app>-active" instead of a host-published port and keep resolving
+// across blue/green cutovers.
+func activeAlias(appName string) string {
+	return appName + "-active"
+}
+
+// endpointSettingsFor builds the EndpointSettings for attachment, always
+// including activeAlias alongside any user-configured aliases.
+func endpointSettingsFor(appName string, attachment config.NetworkAttachment) *network.EndpointSettings {
+	settings := &network.EndpointSettings{
+		Aliases: append([]string{activeAlias(appName)}, attachment.Aliases...),
 	}
-	if limit == "1.0" {
-		return 100000, nil // 100% of CPU
+	if attachment.IPv4Address != "" {
+		settings.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: attachment.IPv4Address}
 	}
-	return 0, fmt.Errorf("unsupported CPU format: %s", limit)
+	return settings
 }
+
 func parseCreatedTime(created string) time.Time {
 	// Docker uses RFC3339Nano format
 	if t, err := time.Parse(time.RFC3339Nano, created); err == nil {
@@ -355,7 +572,7 @@ func parseCreatedTime(created string) time.Time {
 }
 
 // GenerateDockerCommand generates the equivalent docker run command for a container
-func (dm *DockerManager) GenerateDockerCommand(ctx context.Context, appName, color string, appConfig *config.AppConfig) (string, error) {
+func (dm *RuntimeManager) GenerateDockerCommand(ctx context.Context, appName, color string, appConfig *config.AppConfig) (string, error) {
 	containerName := fmt.Sprintf("%s-%s", appName, color)
 
 	// Get container info to find the actual image
@@ -385,18 +602,67 @@ func (dm *DockerManager) GenerateDockerCommand(ctx context.Context, appName, col
 		parts = append(parts, fmt.Sprintf("--memory %s", appConfig.Docker.MemoryLimit))
 	}
 
+	if appConfig.Docker.MemorySwap != "" {
+		parts = append(parts, fmt.Sprintf("--memory-swap %s", appConfig.Docker.MemorySwap))
+	}
+
+	if appConfig.Docker.MemoryReservation != "" {
+		parts = append(parts, fmt.Sprintf("--memory-reservation %s", appConfig.Docker.MemoryReservation))
+	}
+
 	if appConfig.Docker.CPULimit != "" {
 		parts = append(parts, fmt.Sprintf("--cpus %s", appConfig.Docker.CPULimit))
 	}
 
+	if appConfig.Docker.CPUPeriod != 0 {
+		parts = append(parts, fmt.Sprintf("--cpu-period %d", appConfig.Docker.CPUPeriod))
+	}
+
+	if appConfig.Docker.CPUShares != 0 {
+		parts = append(parts, fmt.Sprintf("--cpu-shares %d", appConfig.Docker.CPUShares))
+	}
+
+	if appConfig.Docker.CPUSetCPUs != "" {
+		parts = append(parts, fmt.Sprintf("--cpuset-cpus %s", appConfig.Docker.CPUSetCPUs))
+	}
+
+	if appConfig.Docker.PidsLimit != 0 {
+		parts = append(parts, fmt.Sprintf("--pids-limit %d", appConfig.Docker.PidsLimit))
+	}
+
+	if appConfig.Docker.BlkioWeight != 0 {
+		parts = append(parts, fmt.Sprintf("--blkio-weight %d", appConfig.Docker.BlkioWeight))
+	}
+
+	for _, u := range appConfig.Docker.Ulimits {
+		parts = append(parts, fmt.Sprintf("--ulimit %s=%d:%d", u.Name, u.Soft, u.Hard))
+	}
+
 	// Port mappings
-	var hostPort int
-	if color == "blue" {
-		hostPort = appConfig.Ports.Blue
+	if len(appConfig.Docker.PortMappings) == 0 {
+		var hostPort int
+		if color == "blue" {
+			hostPort = appConfig.Ports.Blue
+		} else {
+			hostPort = appConfig.Ports.Green
+		}
+		parts = append(parts, fmt.Sprintf("-p %d:%d", hostPort, appConfig.Docker.ExposePort))
 	} else {
-		hostPort = appConfig.Ports.Green
+		mappings, err := resolvePortMappings(appConfig, color)
+		if err != nil {
+			return "", err
+		}
+		for _, m := range mappings {
+			flag := fmt.Sprintf("%s:%s", m.Binding.HostPort, m.Port.Port())
+			if m.Binding.HostIP != "" {
+				flag = fmt.Sprintf("%s:%s", m.Binding.HostIP, flag)
+			}
+			if proto := m.Port.Proto(); proto != "" && proto != "tcp" {
+				flag = fmt.Sprintf("%s/%s", flag, proto)
+			}
+			parts = append(parts, fmt.Sprintf("-p %s", flag))
+		}
 	}
-	parts = append(parts, fmt.Sprintf("-p %d:%d", hostPort, appConfig.Docker.ExposePort))
 
 	// Environment variables
 	for key, value := range appConfig.Docker.Environment {
@@ -408,6 +674,24 @@ func (dm *DockerManager) GenerateDockerCommand(ctx context.Context, appName, col
 		parts = append(parts, fmt.Sprintf("-v %s", volume))
 	}
 
+	// Healthcheck (mirrors applyHealthcheck: only "exec" has a direct
+	// translation into Docker's own HEALTHCHECK)
+	if hc := appConfig.HealthCheck; hc.Type == config.HealthCheckTypeExec && len(hc.ExecCommand) > 0 {
+		parts = append(parts, fmt.Sprintf("--health-cmd %q", strings.Join(hc.ExecCommand, " ")))
+		if hc.Interval > 0 {
+			parts = append(parts, fmt.Sprintf("--health-interval %s", hc.Interval))
+		}
+		if hc.Timeout > 0 {
+			parts = append(parts, fmt.Sprintf("--health-timeout %s", hc.Timeout))
+		}
+		if hc.Retries > 0 {
+			parts = append(parts, fmt.Sprintf("--health-retries %d", hc.Retries))
+		}
+		if hc.StartPeriod > 0 {
+			parts = append(parts, fmt.Sprintf("--health-start-period %s", hc.StartPeriod))
+		}
+	}
+
 	// Network
 	if appConfig.Docker.Network != "" {
 		parts = append(parts, fmt.Sprintf("--network %s", appConfig.Docker.Network))
@@ -3,13 +3,18 @@ package docker
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"dockswap/internal/config"
 )
@@ -20,7 +25,9 @@ func createTestAppConfig() *config.AppConfig {
 		Docker: config.Docker{
 			RestartPolicy: "unless-stopped",
 			MemoryLimit:   "512m",
+			MemoryBytes:   512 * 1024 * 1024,
 			CPULimit:      "0.5",
+			CPUs:          0.5,
 			Environment: map[string]string{
 				"ENV": "test",
 			},
@@ -47,10 +54,10 @@ func createTestAppConfig() *config.AppConfig {
 	}
 }
 
-func TestDockerManager_CreateContainer(t *testing.T) {
+func TestRuntimeManager_CreateContainer(t *testing.T) {
 	t.Run("successful creation", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 		appConfig := createTestAppConfig()
 
 		expectedResp := container.CreateResponse{
@@ -85,7 +92,7 @@ func TestDockerManager_CreateContainer(t *testing.T) {
 
 	t.Run("creation failure", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 		appConfig := createTestAppConfig()
 
 		mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
@@ -98,12 +105,47 @@ func TestDockerManager_CreateContainer(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to create container")
 		mockClient.AssertExpectations(t)
 	})
+
+	t.Run("multi-network attach", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		dm := NewRuntimeManager(mockClient)
+		appConfig := createTestAppConfig()
+		appConfig.Docker.Network = ""
+		appConfig.Docker.Networks = []config.NetworkAttachment{
+			{Name: "frontend", Aliases: []string{"test-app-web"}},
+			{Name: "backend", IPv4Address: "10.0.0.5"},
+		}
+
+		mockClient.On("ContainerCreate",
+			mock.Anything, mock.Anything, mock.Anything,
+			mock.MatchedBy(func(networkingConfig *network.NetworkingConfig) bool {
+				settings, ok := networkingConfig.EndpointsConfig["frontend"]
+				return ok &&
+					assert.ObjectsAreEqual([]string{"test-app-active", "test-app-web"}, settings.Aliases)
+			}),
+			"test-app-blue",
+		).Return(container.CreateResponse{ID: "container123"}, nil)
+
+		mockClient.On("NetworkConnect",
+			mock.Anything, "backend", "container123",
+			mock.MatchedBy(func(settings *network.EndpointSettings) bool {
+				return settings.IPAMConfig != nil && settings.IPAMConfig.IPv4Address == "10.0.0.5" &&
+					assert.ObjectsAreEqual([]string{"test-app-active"}, settings.Aliases)
+			}),
+		).Return(nil)
+
+		containerInfo, err := dm.CreateContainer(context.Background(), "test-app", "blue", "nginx:1.21", appConfig)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "container123", containerInfo.ID)
+		mockClient.AssertExpectations(t)
+	})
 }
 
-func TestDockerManager_StartContainer(t *testing.T) {
+func TestRuntimeManager_StartContainer(t *testing.T) {
 	t.Run("successful start", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		mockClient.On("ContainerStart", mock.Anything, "container123", mock.Anything).Return(nil)
 
@@ -115,7 +157,7 @@ func TestDockerManager_StartContainer(t *testing.T) {
 
 	t.Run("start failure", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		mockClient.On("ContainerStart", mock.Anything, "container123", mock.Anything).
 			Return(errors.New("start failed"))
@@ -128,10 +170,10 @@ func TestDockerManager_StartContainer(t *testing.T) {
 	})
 }
 
-func TestDockerManager_StopContainer(t *testing.T) {
+func TestRuntimeManager_StopContainer(t *testing.T) {
 	t.Run("successful stop", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		containers := []types.Container{
 			{
@@ -151,7 +193,7 @@ func TestDockerManager_StopContainer(t *testing.T) {
 
 	t.Run("container not found", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
 
@@ -163,10 +205,10 @@ func TestDockerManager_StopContainer(t *testing.T) {
 	})
 }
 
-func TestDockerManager_GetContainerInfo(t *testing.T) {
+func TestRuntimeManager_GetContainerInfo(t *testing.T) {
 	t.Run("successful inspect", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		containers := []types.Container{
 			{
@@ -182,7 +224,8 @@ func TestDockerManager_GetContainerInfo(t *testing.T) {
 				State: &types.ContainerState{
 					Status: "running",
 					Health: &types.Health{
-						Status: "healthy",
+						Status:        "healthy",
+						FailingStreak: 2,
 					},
 				},
 				Created: time.Now().Format(time.RFC3339Nano),
@@ -205,12 +248,13 @@ func TestDockerManager_GetContainerInfo(t *testing.T) {
 		assert.Equal(t, "nginx:1.21", info.Image)
 		assert.Equal(t, "running", info.Status)
 		assert.Equal(t, "healthy", info.Health)
+		assert.Equal(t, 2, info.FailingStreak)
 		mockClient.AssertExpectations(t)
 	})
 
 	t.Run("container not found", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
 
@@ -223,10 +267,10 @@ func TestDockerManager_GetContainerInfo(t *testing.T) {
 	})
 }
 
-func TestDockerManager_ContainerExists(t *testing.T) {
+func TestRuntimeManager_ContainerExists(t *testing.T) {
 	t.Run("container exists", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		containers := []types.Container{
 			{
@@ -246,7 +290,7 @@ func TestDockerManager_ContainerExists(t *testing.T) {
 
 	t.Run("container does not exist", func(t *testing.T) {
 		mockClient := new(MockDockerClient)
-		dm := NewDockerManager(mockClient)
+		dm := NewRuntimeManager(mockClient)
 
 		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
 
@@ -258,50 +302,136 @@ func TestDockerManager_ContainerExists(t *testing.T) {
 	})
 }
 
-func TestParseMemoryLimit(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-		hasError bool
-	}{
-		{"512m", 512 * 1024 * 1024, false},
-		{"1g", 1024 * 1024 * 1024, false},
-		{"invalid", 0, true},
-	}
+func TestApplyResourceLimits(t *testing.T) {
+	appConfig := createTestAppConfig()
+	hostConfig := &container.HostConfig{}
 
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			result, err := parseMemoryLimit(tt.input)
-			if tt.hasError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expected, result)
-			}
-		})
-	}
+	err := applyResourceLimits(hostConfig, appConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, appConfig.Docker.MemoryBytes, hostConfig.Memory)
+	assert.Equal(t, int64(100000), hostConfig.CPUPeriod)
+	assert.Equal(t, int64(50000), hostConfig.CPUQuota)
 }
 
-func TestParseCPULimit(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-		hasError bool
-	}{
-		{"0.5", 50000, false},
-		{"1.0", 100000, false},
-		{"invalid", 0, true},
-	}
+func TestApplyPortMappings(t *testing.T) {
+	t.Run("legacy single port falls back to Ports.Blue/Green", func(t *testing.T) {
+		appConfig := createTestAppConfig()
+		hostConfig := &container.HostConfig{}
+		containerConfig := &container.Config{}
 
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			result, err := parseCPULimit(tt.input)
-			if tt.hasError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expected, result)
-			}
-		})
-	}
+		err := applyPortMappings(hostConfig, containerConfig, appConfig, "blue")
+
+		assert.NoError(t, err)
+		bindings := hostConfig.PortBindings[nat.Port(fmt.Sprintf("%d/tcp", appConfig.Docker.ExposePort))]
+		require.Len(t, bindings, 1)
+		assert.Equal(t, "8081", bindings[0].HostPort)
+	})
+
+	t.Run("UDP and host-IP binding are preserved", func(t *testing.T) {
+		appConfig := createTestAppConfig()
+		appConfig.Docker.PortMappings = []string{"53:53/udp", "127.0.0.1:9000:9000"}
+		hostConfig := &container.HostConfig{}
+		containerConfig := &container.Config{}
+
+		err := applyPortMappings(hostConfig, containerConfig, appConfig, "blue")
+		require.NoError(t, err)
+
+		udpBindings := hostConfig.PortBindings[nat.Port("53/udp")]
+		require.Len(t, udpBindings, 1)
+		assert.Equal(t, "53", udpBindings[0].HostPort)
+
+		tcpBindings := hostConfig.PortBindings[nat.Port("9000/tcp")]
+		require.Len(t, tcpBindings, 1)
+		assert.Equal(t, "127.0.0.1", tcpBindings[0].HostIP)
+		assert.Equal(t, "9000", tcpBindings[0].HostPort)
+	})
+
+	t.Run("port range expands and each port is offset per color", func(t *testing.T) {
+		appConfig := createTestAppConfig()
+		appConfig.Docker.PortMappings = []string{"3000-3002:3000-3002"}
+		appConfig.Docker.ColorPortOffset = 1000
+		hostConfig := &container.HostConfig{}
+		containerConfig := &container.Config{}
+
+		err := applyPortMappings(hostConfig, containerConfig, appConfig, "green")
+		require.NoError(t, err)
+
+		for _, containerPort := range []string{"3000", "3001", "3002"} {
+			bindings := hostConfig.PortBindings[nat.Port(containerPort+"/tcp")]
+			require.Len(t, bindings, 1)
+			offsetPort, err := strconv.Atoi(containerPort)
+			require.NoError(t, err)
+			assert.Equal(t, strconv.Itoa(offsetPort+1000), bindings[0].HostPort)
+		}
+	})
+
+	t.Run("per-color offset override wins over ColorPortOffset", func(t *testing.T) {
+		appConfig := createTestAppConfig()
+		appConfig.Docker.PortMappings = []string{"8080:80/tcp"}
+		appConfig.Docker.ColorPortOffset = 1000
+		appConfig.Docker.ColorPortOffsets = map[string]int{"green": 5000}
+		hostConfig := &container.HostConfig{}
+		containerConfig := &container.Config{}
+
+		err := applyPortMappings(hostConfig, containerConfig, appConfig, "green")
+		require.NoError(t, err)
+
+		bindings := hostConfig.PortBindings[nat.Port("80/tcp")]
+		require.Len(t, bindings, 1)
+		assert.Equal(t, "13080", bindings[0].HostPort)
+	})
+
+	t.Run("blue is always the zero-offset anchor", func(t *testing.T) {
+		appConfig := createTestAppConfig()
+		appConfig.Docker.PortMappings = []string{"8080:80/tcp"}
+		appConfig.Docker.ColorPortOffset = 1000
+		hostConfig := &container.HostConfig{}
+		containerConfig := &container.Config{}
+
+		err := applyPortMappings(hostConfig, containerConfig, appConfig, "blue")
+		require.NoError(t, err)
+
+		bindings := hostConfig.PortBindings[nat.Port("80/tcp")]
+		require.Len(t, bindings, 1)
+		assert.Equal(t, "8080", bindings[0].HostPort)
+	})
+}
+
+func TestApplyHealthcheck(t *testing.T) {
+	t.Run("exec type translates into container.Config.Healthcheck", func(t *testing.T) {
+		appConfig := createTestAppConfig()
+		appConfig.HealthCheck.Type = config.HealthCheckTypeExec
+		appConfig.HealthCheck.ExecCommand = []string{"pg_isready", "-U", "postgres"}
+		appConfig.HealthCheck.StartPeriod = 5 * time.Second
+		containerConfig := &container.Config{}
+
+		applyHealthcheck(containerConfig, appConfig)
+
+		require.NotNil(t, containerConfig.Healthcheck)
+		assert.Equal(t, []string{"CMD", "pg_isready", "-U", "postgres"}, containerConfig.Healthcheck.Test)
+		assert.Equal(t, appConfig.HealthCheck.Interval, containerConfig.Healthcheck.Interval)
+		assert.Equal(t, appConfig.HealthCheck.Timeout, containerConfig.Healthcheck.Timeout)
+		assert.Equal(t, appConfig.HealthCheck.Retries, containerConfig.Healthcheck.Retries)
+		assert.Equal(t, 5*time.Second, containerConfig.Healthcheck.StartPeriod)
+	})
+
+	t.Run("http type leaves the container's own HEALTHCHECK untouched", func(t *testing.T) {
+		appConfig := createTestAppConfig()
+		containerConfig := &container.Config{}
+
+		applyHealthcheck(containerConfig, appConfig)
+
+		assert.Nil(t, containerConfig.Healthcheck)
+	})
+
+	t.Run("exec type with no command leaves the container's own HEALTHCHECK untouched", func(t *testing.T) {
+		appConfig := createTestAppConfig()
+		appConfig.HealthCheck.Type = config.HealthCheckTypeExec
+		containerConfig := &container.Config{}
+
+		applyHealthcheck(containerConfig, appConfig)
+
+		assert.Nil(t, containerConfig.Healthcheck)
+	})
 }
@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+
+	"dockswap/internal/docker/errdefs"
+)
+
+// StreamLogs resolves appName/color to its container and returns a reader
+// of its stdout/stderr, following the container's output when follow is
+// true, the same way GetContainerInfo resolves a color to a container
+// before inspecting it. Callers own the returned ReadCloser and must Close
+// it to stop following.
+func (dm *RuntimeManager) StreamLogs(ctx context.Context, appName, color string, follow bool) (io.ReadCloser, error) {
+	logs, _, err := dm.StreamContainerLogs(ctx, appName, color, StreamLogsOptions{Follow: follow, Tail: "all"})
+	return logs, err
+}
+
+// StreamLogsOptions controls StreamContainerLogs' window and formatting,
+// mirroring the fields `docker logs` itself accepts.
+type StreamLogsOptions struct {
+	// Follow keeps the stream open and delivers new lines as they're written.
+	Follow bool
+	// Since is passed through to the Docker API verbatim - a Unix timestamp
+	// or a Go duration string such as "10m" - and returns only logs at or
+	// after it. Empty means "since the container started".
+	Since string
+	// Tail limits the stream to the last N lines, or "all" for no limit.
+	Tail string
+	// Timestamps prefixes each line with its RFC3339Nano time, as recorded
+	// by the Docker daemon.
+	Timestamps bool
+}
+
+// StreamContainerLogs resolves appName/color to its container and returns a
+// reader of its stdout/stderr framed per opts, alongside the container's ID
+// so callers can label output (e.g. by short ID) when tailing more than one
+// container at once. Callers own the returned ReadCloser and must Close it
+// to stop following.
+func (dm *RuntimeManager) StreamContainerLogs(ctx context.Context, appName, color string, opts StreamLogsOptions) (io.ReadCloser, string, error) {
+	containers, err := dm.findContainers(ctx, appName, color)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find container: %w", err)
+	}
+	if len(containers) == 0 {
+		return nil, "", fmt.Errorf("container %s-%s not found", appName, color)
+	}
+
+	tail := opts.Tail
+	if tail == "" {
+		tail = "all"
+	}
+
+	logs, err := dm.client.ContainerLogs(ctx, containers[0].ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Since:      opts.Since,
+		Timestamps: opts.Timestamps,
+		Tail:       tail,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to stream logs for %s-%s: %w", appName, color, errdefs.FromDockerError(err))
+	}
+	return logs, containers[0].ID, nil
+}
+
+// CopyToContainer resolves appName/color to its container and extracts the
+// tar stream content at destPath inside it - seeding a config file into a
+// blue/green container without shelling out to `docker cp`.
+func (dm *RuntimeManager) CopyToContainer(ctx context.Context, appName, color, destPath string, content io.Reader) error {
+	containers, err := dm.findContainers(ctx, appName, color)
+	if err != nil {
+		return fmt.Errorf("failed to find container: %w", err)
+	}
+	if len(containers) == 0 {
+		return fmt.Errorf("container %s-%s not found", appName, color)
+	}
+
+	if err := dm.client.CopyToContainer(ctx, containers[0].ID, destPath, content, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy to %s-%s:%s: %w", appName, color, destPath, errdefs.FromDockerError(err))
+	}
+	return nil
+}
+
+// CopyFromContainer resolves appName/color to its container and returns a
+// tar stream of srcPath inside it - pulling a crash dump out of a
+// blue/green container without shelling out to `docker cp`. Callers own the
+// returned ReadCloser.
+func (dm *RuntimeManager) CopyFromContainer(ctx context.Context, appName, color, srcPath string) (io.ReadCloser, error) {
+	containers, err := dm.findContainers(ctx, appName, color)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find container: %w", err)
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("container %s-%s not found", appName, color)
+	}
+
+	reader, _, err := dm.client.CopyFromContainer(ctx, containers[0].ID, srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy from %s-%s:%s: %w", appName, color, srcPath, errdefs.FromDockerError(err))
+	}
+	return reader, nil
+}
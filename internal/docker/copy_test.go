@@ -0,0 +1,115 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeManager_StreamLogs(t *testing.T) {
+	t.Run("container not found", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		dm := NewRuntimeManager(mockClient)
+
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
+
+		_, err := dm.StreamLogs(context.Background(), "test-app", "blue", false)
+
+		require.Error(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("streams logs for resolved container", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		dm := NewRuntimeManager(mockClient)
+
+		containers := []types.Container{{ID: "container123", Names: []string{"/test-app-blue"}}}
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+		mockClient.On("ContainerLogs", mock.Anything, "container123", mock.Anything).
+			Return(io.NopCloser(strings.NewReader("log line")), nil)
+
+		reader, err := dm.StreamLogs(context.Background(), "test-app", "blue", true)
+
+		require.NoError(t, err)
+		defer reader.Close()
+
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "log line", string(body))
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestRuntimeManager_StreamContainerLogs(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	dm := NewRuntimeManager(mockClient)
+
+	containers := []types.Container{{ID: "container123", Names: []string{"/test-app-blue"}}}
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+	mockClient.On("ContainerLogs", mock.Anything, "container123", container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Since:      "10m",
+		Timestamps: true,
+		Tail:       "100",
+	}).Return(io.NopCloser(strings.NewReader("log line")), nil)
+
+	reader, containerID, err := dm.StreamContainerLogs(context.Background(), "test-app", "blue", StreamLogsOptions{
+		Follow:     true,
+		Since:      "10m",
+		Tail:       "100",
+		Timestamps: true,
+	})
+
+	require.NoError(t, err)
+	defer reader.Close()
+	assert.Equal(t, "container123", containerID)
+
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "log line", string(body))
+	mockClient.AssertExpectations(t)
+}
+
+func TestRuntimeManager_CopyToContainer(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	dm := NewRuntimeManager(mockClient)
+
+	containers := []types.Container{{ID: "container123", Names: []string{"/test-app-blue"}}}
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+	mockClient.On("CopyToContainer", mock.Anything, "container123", "/etc/app/config.yml", mock.Anything, mock.Anything).
+		Return(nil)
+
+	err := dm.CopyToContainer(context.Background(), "test-app", "blue", "/etc/app/config.yml", strings.NewReader("tar bytes"))
+
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestRuntimeManager_CopyFromContainer(t *testing.T) {
+	mockClient := new(MockDockerClient)
+	dm := NewRuntimeManager(mockClient)
+
+	containers := []types.Container{{ID: "container123", Names: []string{"/test-app-blue"}}}
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+	mockClient.On("CopyFromContainer", mock.Anything, "container123", "/var/crash").
+		Return(io.NopCloser(strings.NewReader("tar bytes")), container.PathStat{}, nil)
+
+	reader, err := dm.CopyFromContainer(context.Background(), "test-app", "blue", "/var/crash")
+
+	require.NoError(t, err)
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "tar bytes", string(body))
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"dockswap/internal/deployment"
+)
+
+// DefaultMaxConcurrentDeployments bounds how many apps' deployment loops a
+// DeploymentOrchestrator runs at once when fanning out via DeployAsync or
+// DeployAll, unless overridden with SetMaxConcurrentDeployments.
+const DefaultMaxConcurrentDeployments = 4
+
+// DeploymentHandle tracks one app's deployment started by DeployAsync or
+// DeployAll, letting the caller observe and control it independently of
+// however many other apps are deploying alongside it in the worker pool.
+type DeploymentHandle struct {
+	AppName string
+
+	cancel      context.CancelFunc
+	done        chan struct{}
+	err         error
+	transitions chan deployment.StateTransition
+}
+
+// Wait blocks until AppName's deployment settles and returns its error, if any.
+func (h *DeploymentHandle) Wait() error {
+	<-h.done
+	return h.err
+}
+
+// Cancel interrupts the deployment in flight, the same way a
+// shutdown.Coordinator cancellation would; it returns immediately, use Wait
+// to observe the outcome.
+func (h *DeploymentHandle) Cancel() {
+	h.cancel()
+}
+
+// Transitions streams every deployment.StateTransition recorded for this
+// one deploy, in order, and is closed once the deployment settles.
+func (h *DeploymentHandle) Transitions() <-chan deployment.StateTransition {
+	return h.transitions
+}
+
+// SetMaxConcurrentDeployments bounds how many apps may have a deployment
+// loop running at once through DeployAsync/DeployAll; call before either.
+// Deployments already past the old bound's acquire are unaffected.
+func (do *DeploymentOrchestrator) SetMaxConcurrentDeployments(n int) {
+	if n < 1 {
+		n = 1
+	}
+	do.sem = make(chan struct{}, n)
+}
+
+// DeployAsync starts appName's deployment in a goroutine gated by the
+// orchestrator's worker pool (see SetMaxConcurrentDeployments) and returns
+// immediately with a DeploymentHandle, instead of blocking the way Deploy
+// does. Concurrent DeployAsync/Deploy calls against different apps proceed
+// in parallel; calls against the same app still serialize on that app's
+// lock, same as Deploy.
+func (do *DeploymentOrchestrator) DeployAsync(appName, newImage string) *DeploymentHandle {
+	handle := &DeploymentHandle{
+		AppName:     appName,
+		done:        make(chan struct{}),
+		transitions: make(chan deployment.StateTransition, 16),
+	}
+
+	lock, exists := do.locks[appName]
+	if !exists {
+		handle.cancel = func() {}
+		handle.err = fmt.Errorf("app %s not initialized", appName)
+		close(handle.done)
+		close(handle.transitions)
+		return handle
+	}
+
+	ctx, cancel := context.WithCancel(do.ctxOrBackground())
+	handle.cancel = cancel
+
+	go func() {
+		defer cancel()
+		defer close(handle.done)
+		defer close(handle.transitions)
+
+		sem := do.sem
+		sem <- struct{}{}
+		defer func() { <-sem }()
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		stateMachine, ok := do.states[appName]
+		if !ok {
+			handle.err = fmt.Errorf("app %s not initialized", appName)
+			return
+		}
+		startHistory := len(stateMachine.GetStateHistory())
+
+		handle.err = do.deployLocked(ctx, appName, newImage)
+
+		for _, t := range stateMachine.GetStateHistory()[startHistory:] {
+			handle.transitions <- t
+		}
+	}()
+
+	return handle
+}
+
+// DeployAll starts images[appName] for every app in images concurrently via
+// DeployAsync, for fleet-wide rollouts, and returns each app's
+// DeploymentHandle immediately without waiting on any of them; callers that
+// want to block until the whole fleet settles call Wait on each handle.
+func (do *DeploymentOrchestrator) DeployAll(images map[string]string) []*DeploymentHandle {
+	handles := make([]*DeploymentHandle, 0, len(images))
+	for appName, image := range images {
+		handles = append(handles, do.DeployAsync(appName, image))
+	}
+	return handles
+}
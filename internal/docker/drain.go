@@ -0,0 +1,199 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dockswap/internal/config"
+	"dockswap/internal/docker/errdefs"
+)
+
+// ConnectionCounter reports how many connections are currently open against
+// color's container, so DrainConnections can observe real drain progress
+// instead of blindly sleeping out the timeout. Implementations are bound to
+// one app at construction and take color per call, since the same counter
+// drains whichever color DrainConnections is asked to wait on.
+type ConnectionCounter interface {
+	Count(ctx context.Context, color string) (int, error)
+}
+
+// DrainResult is what a completed drain observed: how many connections were
+// still open when DrainConnections gave up waiting (0 if it drained
+// cleanly) and how long it actually took, for the deployment state machine
+// to persist alongside the transition it unblocked.
+type DrainResult struct {
+	Connections int
+	Duration    time.Duration
+}
+
+// portForColor resolves the host port appConfig exposes for color, checking
+// the generalized Slots map before falling back to the legacy Ports.Blue/
+// Green fields - the same precedence caddy.getActivePort uses for routing.
+func portForColor(appConfig *config.AppConfig, color string) (int, error) {
+	if len(appConfig.Slots) > 0 {
+		slot, ok := appConfig.Slots[color]
+		if !ok {
+			return 0, fmt.Errorf("invalid color %q: no slot configured", color)
+		}
+		return slot.Port, nil
+	}
+
+	switch color {
+	case "blue":
+		return appConfig.Ports.Blue, nil
+	case "green":
+		return appConfig.Ports.Green, nil
+	default:
+		return 0, fmt.Errorf("invalid color %q", color)
+	}
+}
+
+// CaddyConnectionCounter counts connections via Caddy's admin API: it polls
+// GET /reverse_proxy/upstreams and sums NumRequests for every upstream whose
+// address is color's port, since Caddy already tracks per-upstream request
+// counts and exposes them without any extra instrumentation in the
+// container itself.
+type CaddyConnectionCounter struct {
+	client    *http.Client
+	adminURL  string
+	appConfig *config.AppConfig
+}
+
+// NewCaddyConnectionCounter builds a counter that queries adminURL (Caddy's
+// admin API, e.g. CaddyManager.AdminURL) for appConfig's upstreams.
+func NewCaddyConnectionCounter(adminURL string, appConfig *config.AppConfig) *CaddyConnectionCounter {
+	return &CaddyConnectionCounter{
+		client:    &http.Client{Timeout: 5 * time.Second},
+		adminURL:  adminURL,
+		appConfig: appConfig,
+	}
+}
+
+type caddyUpstream struct {
+	Address     string `json:"address"`
+	NumRequests int    `json:"num_requests"`
+}
+
+func (c *CaddyConnectionCounter) Count(ctx context.Context, color string) (int, error) {
+	port, err := portForColor(c.appConfig, color)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.adminURL+"/reverse_proxy/upstreams", nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build upstreams request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query caddy upstreams: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("caddy admin API returned status %d", resp.StatusCode)
+	}
+
+	var upstreams []caddyUpstream
+	if err := json.NewDecoder(resp.Body).Decode(&upstreams); err != nil {
+		return 0, fmt.Errorf("failed to decode caddy upstreams response: %w", err)
+	}
+
+	suffix := fmt.Sprintf(":%d", port)
+	total := 0
+	for _, u := range upstreams {
+		if strings.HasSuffix(u.Address, suffix) {
+			total += u.NumRequests
+		}
+	}
+	return total, nil
+}
+
+// tcpStateEstablished is the hex connection-state code /proc/net/tcp uses
+// for an ESTABLISHED socket.
+const tcpStateEstablished = "01"
+
+// StatsConnectionCounter is the fallback used when there's no Caddy admin
+// API to ask - Nginx/Traefik/HAProxy routing, or no proxy configured at
+// all: it counts ESTABLISHED entries in color's container's /proc/net/tcp
+// and /proc/net/tcp6 whose local port matches, the same signal `ss -t state
+// established` reports. runtime.Client has no call that captures exec
+// output (ExecProbe only ever needed the exit code), so this reads the
+// procfs files through the same CopyFromContainer archive endpoint
+// `docker cp` itself uses rather than through ContainerExec.
+type StatsConnectionCounter struct {
+	dm        *RuntimeManager
+	appName   string
+	appConfig *config.AppConfig
+}
+
+// NewStatsConnectionCounter builds a counter that inspects appName's color
+// container directly through dm.
+func NewStatsConnectionCounter(dm *RuntimeManager, appName string, appConfig *config.AppConfig) *StatsConnectionCounter {
+	return &StatsConnectionCounter{dm: dm, appName: appName, appConfig: appConfig}
+}
+
+func (c *StatsConnectionCounter) Count(ctx context.Context, color string) (int, error) {
+	port, err := portForColor(c.appConfig, color)
+	if err != nil {
+		return 0, err
+	}
+
+	containers, err := c.dm.findContainers(ctx, c.appName, color)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find container: %w", err)
+	}
+	if len(containers) == 0 {
+		return 0, fmt.Errorf("container %s-%s not found", c.appName, color)
+	}
+
+	total := 0
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		n, err := c.countEstablished(ctx, containers[0].ID, path, port)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (c *StatsConnectionCounter) countEstablished(ctx context.Context, containerID, path string, port int) (int, error) {
+	reader, _, err := c.dm.client.CopyFromContainer(ctx, containerID, path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, errdefs.FromDockerError(err))
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	if _, err := tr.Next(); err != nil {
+		return 0, fmt.Errorf("failed to read tar entry for %s: %w", path, err)
+	}
+
+	portHex := strings.ToUpper(strconv.FormatInt(int64(port), 16))
+	count := 0
+	scanner := bufio.NewScanner(tr)
+	scanner.Scan() // header line
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		localAddr := strings.Split(fields[1], ":")
+		if len(localAddr) != 2 || localAddr[1] != portHex {
+			continue
+		}
+		if fields[3] == tcpStateEstablished {
+			count++
+		}
+	}
+	return count, nil
+}
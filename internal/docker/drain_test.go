@@ -0,0 +1,213 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"dockswap/internal/config"
+)
+
+func tarOf(t *testing.T, name, content string) io.ReadCloser {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	return io.NopCloser(&buf)
+}
+
+const procNetTCPHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode"
+
+func TestCaddyConnectionCounter_Count(t *testing.T) {
+	t.Run("sums matching upstreams", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/reverse_proxy/upstreams", r.URL.Path)
+			_ = json.NewEncoder(w).Encode([]caddyUpstream{
+				{Address: "localhost:8081", NumRequests: 3},
+				{Address: "localhost:8082", NumRequests: 1},
+			})
+		}))
+		defer server.Close()
+
+		counter := NewCaddyConnectionCounter(server.URL, createTestAppConfig())
+
+		n, err := counter.Count(context.Background(), "blue")
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+	})
+
+	t.Run("zero when no upstream matches", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode([]caddyUpstream{{Address: "localhost:9999", NumRequests: 5}})
+		}))
+		defer server.Close()
+
+		counter := NewCaddyConnectionCounter(server.URL, createTestAppConfig())
+
+		n, err := counter.Count(context.Background(), "blue")
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+	})
+
+	t.Run("admin API error surfaces", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		counter := NewCaddyConnectionCounter(server.URL, createTestAppConfig())
+
+		_, err := counter.Count(context.Background(), "blue")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid color", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		counter := NewCaddyConnectionCounter(server.URL, createTestAppConfig())
+
+		_, err := counter.Count(context.Background(), "purple")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestStatsConnectionCounter_Count(t *testing.T) {
+	t.Run("counts established sockets on the app port", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		dm := NewRuntimeManager(mockClient)
+		counter := NewStatsConnectionCounter(dm, "test-app", createTestAppConfig())
+
+		containers := []types.Container{{ID: "container123", Names: []string{"/test-app-blue"}}}
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return(containers, nil)
+
+		// Port 8081 is 0x1F91. One ESTABLISHED (state 01), one TIME_WAIT
+		// (state 06) that shouldn't be counted.
+		tcp := procNetTCPHeader + "\n" +
+			"0: 00000000:1F91 00000000:0000 01 00000000:00000000 00:00000000 00000000     0        0 12345 1 0000000000000000 100 0 0 10 0\n" +
+			"1: 0100007F:1F91 0100007F:0000 06 00000000:00000000 00:00000000 00000000     0        0 12346 1 0000000000000000 100 0 0 10 0\n"
+		mockClient.On("CopyFromContainer", mock.Anything, "container123", "/proc/net/tcp").
+			Return(tarOf(t, "tcp", tcp), container.PathStat{}, nil)
+		mockClient.On("CopyFromContainer", mock.Anything, "container123", "/proc/net/tcp6").
+			Return(tarOf(t, "tcp6", procNetTCPHeader+"\n"), container.PathStat{}, nil)
+
+		n, err := counter.Count(context.Background(), "blue")
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("container not found", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		dm := NewRuntimeManager(mockClient)
+		counter := NewStatsConnectionCounter(dm, "test-app", createTestAppConfig())
+
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
+
+		_, err := counter.Count(context.Background(), "blue")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestDockerActionProvider_DrainConnections_WithCounter(t *testing.T) {
+	t.Run("returns as soon as the counter reaches zero", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{"test-app": createTestAppConfig()}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		counter := &fakeConnectionCounter{counts: []int{2, 1, 0}}
+		actionProvider.SetConnectionCounter("test-app", counter)
+
+		start := time.Now()
+		err := actionProvider.DrainConnections("test-app", "blue", 5*time.Second)
+
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 1*time.Second)
+		assert.Equal(t, 3, counter.calls)
+
+		result, ok := actionProvider.DrainResult("test-app")
+		require.True(t, ok)
+		assert.Equal(t, 0, result.Connections)
+	})
+
+	t.Run("gives up at the timeout and records connections remaining", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{"test-app": createTestAppConfig()}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		counter := &fakeConnectionCounter{counts: []int{4}}
+		actionProvider.SetConnectionCounter("test-app", counter)
+
+		err := actionProvider.DrainConnections("test-app", "blue", 10*time.Millisecond)
+
+		require.NoError(t, err)
+
+		result, ok := actionProvider.DrainResult("test-app")
+		require.True(t, ok)
+		assert.Equal(t, 4, result.Connections)
+	})
+
+	t.Run("a failing counter is treated as still draining, not fatal", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockCaddy := new(MockCaddyManager)
+		dm := NewRuntimeManager(mockClient)
+
+		configs := map[string]*config.AppConfig{"test-app": createTestAppConfig()}
+		actionProvider := NewDockerActionProvider(dm, mockCaddy, configs)
+
+		counter := &fakeConnectionCounter{err: assert.AnError}
+		actionProvider.SetConnectionCounter("test-app", counter)
+
+		err := actionProvider.DrainConnections("test-app", "blue", 10*time.Millisecond)
+
+		assert.NoError(t, err)
+	})
+}
+
+// fakeConnectionCounter returns counts[i] on its i'th call, repeating the
+// last entry once calls run past the end of counts, so a test can model a
+// count that never reaches zero within the timeout.
+type fakeConnectionCounter struct {
+	counts []int
+	err    error
+	calls  int
+}
+
+func (f *fakeConnectionCounter) Count(ctx context.Context, color string) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	idx := f.calls
+	if idx >= len(f.counts) {
+		idx = len(f.counts) - 1
+	}
+	f.calls++
+	return f.counts[idx], nil
+}
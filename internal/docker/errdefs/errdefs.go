@@ -0,0 +1,144 @@
+// Package errdefs defines a small set of typed error classes for Docker
+// daemon failures, modeled on Moby's docker/errdefs package. Wrapping errors
+// in these types lets callers use errors.As/errors.Is instead of matching on
+// error message substrings, and lets callers decide whether a failure is
+// worth retrying.
+package errdefs
+
+import (
+	"errors"
+	"strings"
+)
+
+// NotFound is returned when a requested resource (container, network,
+// image) does not exist.
+type NotFound struct{ cause error }
+
+func (e *NotFound) Error() string { return e.cause.Error() }
+func (e *NotFound) Unwrap() error { return e.cause }
+
+// Conflict is returned when the request conflicts with the current state of
+// the resource (e.g. starting an already-running container).
+type Conflict struct{ cause error }
+
+func (e *Conflict) Error() string { return e.cause.Error() }
+func (e *Conflict) Unwrap() error { return e.cause }
+
+// Unavailable is returned when the Docker daemon itself could not be
+// reached or is temporarily overloaded. Callers should treat this as
+// retryable.
+type Unavailable struct{ cause error }
+
+func (e *Unavailable) Error() string { return e.cause.Error() }
+func (e *Unavailable) Unwrap() error { return e.cause }
+
+// InvalidParameter is returned when the request was rejected due to bad
+// input (e.g. malformed resource limits).
+type InvalidParameter struct{ cause error }
+
+func (e *InvalidParameter) Error() string { return e.cause.Error() }
+func (e *InvalidParameter) Unwrap() error { return e.cause }
+
+// System is returned for unclassified daemon-side failures.
+type System struct{ cause error }
+
+func (e *System) Error() string { return e.cause.Error() }
+func (e *System) Unwrap() error { return e.cause }
+
+// Forbidden is returned when the daemon rejected the request due to
+// permissions (e.g. AppArmor/SELinux denial).
+type Forbidden struct{ cause error }
+
+func (e *Forbidden) Error() string { return e.cause.Error() }
+func (e *Forbidden) Unwrap() error { return e.cause }
+
+// NewNotFound wraps cause as a NotFound error.
+func NewNotFound(cause error) error { return &NotFound{cause: cause} }
+
+// NewConflict wraps cause as a Conflict error.
+func NewConflict(cause error) error { return &Conflict{cause: cause} }
+
+// NewUnavailable wraps cause as an Unavailable error.
+func NewUnavailable(cause error) error { return &Unavailable{cause: cause} }
+
+// NewInvalidParameter wraps cause as an InvalidParameter error.
+func NewInvalidParameter(cause error) error { return &InvalidParameter{cause: cause} }
+
+// NewSystem wraps cause as a System error.
+func NewSystem(cause error) error { return &System{cause: cause} }
+
+// NewForbidden wraps cause as a Forbidden error.
+func NewForbidden(cause error) error { return &Forbidden{cause: cause} }
+
+// IsNotFound reports whether err is (or wraps) a NotFound error.
+func IsNotFound(err error) bool {
+	var e *NotFound
+	return errors.As(err, &e)
+}
+
+// IsConflict reports whether err is (or wraps) a Conflict error.
+func IsConflict(err error) bool {
+	var e *Conflict
+	return errors.As(err, &e)
+}
+
+// IsUnavailable reports whether err is (or wraps) an Unavailable error.
+func IsUnavailable(err error) bool {
+	var e *Unavailable
+	return errors.As(err, &e)
+}
+
+// IsInvalidParameter reports whether err is (or wraps) an InvalidParameter error.
+func IsInvalidParameter(err error) bool {
+	var e *InvalidParameter
+	return errors.As(err, &e)
+}
+
+// IsForbidden reports whether err is (or wraps) a Forbidden error.
+func IsForbidden(err error) bool {
+	var e *Forbidden
+	return errors.As(err, &e)
+}
+
+// IsRetryable reports whether err represents a transient failure worth
+// retrying (currently just Unavailable). Conflict/InvalidParameter/NotFound
+// are considered permanent for the duration of the current operation.
+func IsRetryable(err error) bool {
+	return IsUnavailable(err)
+}
+
+// FromDockerError classifies a raw error returned by the Docker Engine API
+// client into one of the typed errors above, based on the substrings the
+// moby API is known to return. Errors that don't match a known pattern are
+// wrapped as System so callers still get a consistent type to switch on.
+func FromDockerError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	switch {
+	case contains(msg, "No such container", "No such network", "No such image", "not found"):
+		return NewNotFound(err)
+	case contains(msg, "already in progress", "already exists", "is not running", "is already"):
+		return NewConflict(err)
+	case contains(msg, "connection refused", "cannot connect to the Docker daemon", "i/o timeout", "EOF"):
+		return NewUnavailable(err)
+	case contains(msg, "invalid", "must be", "cannot be empty"):
+		return NewInvalidParameter(err)
+	case contains(msg, "permission denied", "operation not permitted"):
+		return NewForbidden(err)
+	default:
+		return NewSystem(err)
+	}
+}
+
+func contains(haystack string, needles ...string) bool {
+	lower := strings.ToLower(haystack)
+	for _, n := range needles {
+		if strings.Contains(lower, strings.ToLower(n)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package errdefs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFromDockerError_Classification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCheck func(error) bool
+	}{
+		{"not found", errors.New("Error: No such container: app-blue"), IsNotFound},
+		{"conflict", errors.New("container app-blue is already in progress"), IsConflict},
+		{"unavailable", errors.New("Cannot connect to the Docker daemon at unix:///var/run/docker.sock"), IsUnavailable},
+		{"invalid parameter", errors.New("invalid memory limit"), IsInvalidParameter},
+		{"forbidden", errors.New("permission denied"), IsForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classified := FromDockerError(tt.err)
+			if !tt.wantCheck(classified) {
+				t.Fatalf("expected classification predicate to match for %q, got %T", tt.err, classified)
+			}
+			if !errors.Is(classified, classified) {
+				t.Fatalf("expected errors.Is to match itself")
+			}
+		})
+	}
+}
+
+func TestFromDockerError_UnknownIsSystem(t *testing.T) {
+	err := FromDockerError(errors.New("something went sideways"))
+	var sysErr *System
+	if !errors.As(err, &sysErr) {
+		t.Fatalf("expected unknown error to classify as System, got %T", err)
+	}
+}
+
+func TestFromDockerError_Nil(t *testing.T) {
+	if FromDockerError(nil) != nil {
+		t.Fatal("expected nil in, nil out")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !IsRetryable(NewUnavailable(errors.New("timeout"))) {
+		t.Fatal("expected Unavailable to be retryable")
+	}
+	if IsRetryable(NewConflict(errors.New("already running"))) {
+		t.Fatal("expected Conflict to not be retryable")
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	cause := errors.New("boom")
+	wrapped := NewNotFound(cause)
+	if !errors.Is(wrapped, cause) {
+		t.Fatal("expected Unwrap to expose the original cause to errors.Is")
+	}
+}
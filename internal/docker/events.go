@@ -0,0 +1,182 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+
+	"dockswap/internal/runtime"
+)
+
+// ContainerEvent is a decoded container lifecycle event for a dockswap
+// managed container, keyed by the app/color labels used throughout this
+// package.
+type ContainerEvent struct {
+	AppName     string
+	Color       string
+	Action      string // "die", "oom", "destroy", "health_status: healthy", ...
+	ContainerID string
+	Time        time.Time
+}
+
+// EventWatcher consumes the Docker daemon's event stream (filtered to
+// dockswap-managed containers) and republishes decoded ContainerEvents on a
+// buffered channel. It reconnects automatically on stream errors with
+// exponential backoff, so callers only need to range over Events() once.
+type EventWatcher struct {
+	client runtime.Client
+
+	events chan ContainerEvent
+
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	// resync, if set via SetResync, runs at the start of every connection
+	// attempt (the first one and every reconnect after a dropped stream),
+	// before any events are consumed. It gives a caller a chance to heal
+	// state drift accumulated while the stream was down - typically by
+	// listing containers directly and reconciling - since events missed
+	// during a disconnect otherwise vanish silently.
+	resync func(ctx context.Context)
+}
+
+// NewEventWatcher creates an EventWatcher over client. Call Run to start
+// consuming; Events returns the channel new ContainerEvents are published
+// on.
+func NewEventWatcher(client runtime.Client) *EventWatcher {
+	return &EventWatcher{
+		client:         client,
+		events:         make(chan ContainerEvent, 64),
+		initialBackoff: 500 * time.Millisecond,
+		maxBackoff:     30 * time.Second,
+	}
+}
+
+// Events returns the channel decoded container events are published on. It
+// is closed when Run returns (i.e. when ctx is canceled).
+func (w *EventWatcher) Events() <-chan ContainerEvent {
+	return w.events
+}
+
+// SetResync registers fn to run at the start of every connection attempt;
+// see the resync field doc for why. fn should return promptly - it runs
+// inline before consumeOnce starts reading events, so a slow resync delays
+// this connection's first event.
+func (w *EventWatcher) SetResync(fn func(ctx context.Context)) {
+	w.resync = fn
+}
+
+// Run subscribes to the daemon event stream and blocks until ctx is
+// canceled, reconnecting with exponential backoff whenever the stream ends
+// unexpectedly.
+func (w *EventWatcher) Run(ctx context.Context) {
+	defer close(w.events)
+
+	backoff := w.initialBackoff
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		connected := w.consumeOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			// A clean-ish connection that produced events resets backoff.
+			backoff = w.initialBackoff
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > w.maxBackoff {
+			backoff = w.maxBackoff
+		}
+	}
+}
+
+// DispatchEvents ranges over watcher's decoded events until its channel
+// closes (i.e. until the watcher's Run context is canceled), invoking every
+// handler for each one in order. Use this instead of ranging over
+// watcher.Events() directly when more than one consumer (e.g. a
+// DeploymentOrchestrator and a StateReconciler) needs to react to the same
+// EventWatcher - a channel only delivers each event to one reader, so
+// without this they'd silently split the stream between them.
+func DispatchEvents(watcher *EventWatcher, handlers ...func(ContainerEvent)) {
+	for evt := range watcher.Events() {
+		for _, handle := range handlers {
+			handle(evt)
+		}
+	}
+}
+
+// consumeOnce opens a single event stream and forwards messages until the
+// stream ends or errors. It returns true if at least one event was
+// successfully delivered, which the caller uses to decide whether to reset
+// the backoff.
+func (w *EventWatcher) consumeOnce(ctx context.Context) bool {
+	if w.resync != nil {
+		w.resync(ctx)
+	}
+
+	filterArgs := filters.NewArgs()
+	filterArgs.Add("label", "dockswap.managed=true")
+	filterArgs.Add("type", string(events.ContainerEventType))
+
+	msgCh, errCh := w.client.Events(ctx, events.ListOptions{Filters: filterArgs})
+
+	delivered := false
+	for {
+		select {
+		case <-ctx.Done():
+			return delivered
+		case err, ok := <-errCh:
+			if !ok {
+				return delivered
+			}
+			if err != nil {
+				return delivered
+			}
+		case msg, ok := <-msgCh:
+			if !ok {
+				return delivered
+			}
+			if ce, ok := decodeContainerEvent(msg); ok {
+				select {
+				case w.events <- ce:
+					delivered = true
+				case <-ctx.Done():
+					return delivered
+				}
+			}
+		}
+	}
+}
+
+func decodeContainerEvent(msg events.Message) (ContainerEvent, bool) {
+	if msg.Type != events.ContainerEventType {
+		return ContainerEvent{}, false
+	}
+
+	appName := msg.Actor.Attributes["dockswap.app"]
+	color := msg.Actor.Attributes["dockswap.color"]
+	if appName == "" {
+		return ContainerEvent{}, false
+	}
+
+	return ContainerEvent{
+		AppName:     appName,
+		Color:       color,
+		Action:      string(msg.Action),
+		ContainerID: msg.Actor.ID,
+		Time:        time.Unix(msg.Time, 0),
+	}, true
+}
@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEventWatcher_DecodesContainerDieEvent(t *testing.T) {
+	mockClient := new(MockDockerClient)
+
+	msgCh := make(chan events.Message, 1)
+	errCh := make(chan error, 1)
+	msgCh <- events.Message{
+		Type:   events.ContainerEventType,
+		Action: "die",
+		Actor: events.Actor{
+			ID: "container123",
+			Attributes: map[string]string{
+				"dockswap.app":   "myapp",
+				"dockswap.color": "blue",
+			},
+		},
+		Time: time.Now().Unix(),
+	}
+
+	mockClient.On("Events", mock.Anything, mock.Anything).
+		Return((<-chan events.Message)(msgCh), (<-chan error)(errCh)).Once()
+
+	watcher := NewEventWatcher(mockClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case evt := <-watcher.Events():
+		if evt.AppName != "myapp" || evt.Color != "blue" || evt.Action != "die" {
+			t.Fatalf("unexpected decoded event: %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for decoded event")
+	}
+
+	cancel()
+	close(msgCh)
+	close(errCh)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after context cancellation")
+	}
+}
+
+func TestEventWatcher_IgnoresEventsWithoutAppLabel(t *testing.T) {
+	msg := events.Message{
+		Type:   events.ContainerEventType,
+		Action: "die",
+		Actor:  events.Actor{ID: "container123", Attributes: map[string]string{}},
+	}
+
+	if _, ok := decodeContainerEvent(msg); ok {
+		t.Fatal("expected event without dockswap.app label to be ignored")
+	}
+}
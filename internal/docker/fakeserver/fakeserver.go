@@ -0,0 +1,364 @@
+// Package fakeserver implements a small in-process HTTP server that speaks
+// just enough of the Docker Engine API - /containers/create,
+// /containers/{id}/start, /containers/{id}/stop, /containers/json,
+// /containers/{id}/json, /containers/{id} (remove), and /images/create - to
+// be pointed at by a real docker/client.Client via client.WithHost(server.URL).
+// It plays the same role as go-dockerclient's testing.DockerServer: tests
+// get real wire-format request/response handling (label filters, JSON
+// container inspection) without either hand-rolled mocks or a real Docker
+// daemon. The create request's HostConfig (binds, port bindings, limits) is
+// not decoded or stored.
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// Server is the fake daemon. Construct with New, and Close it when done.
+type Server struct {
+	// URL is the base address to pass to client.WithHost.
+	URL string
+
+	mu         sync.Mutex
+	containers map[string]*containerRecord
+	images     map[string]bool
+	nextID     int
+	failures   map[string]string
+	hooks      map[string]func(*http.Request)
+
+	httpServer *httptest.Server
+}
+
+type containerRecord struct {
+	id      string
+	name    string
+	image   string
+	env     []string
+	labels  map[string]string
+	running bool
+	created time.Time
+}
+
+// New starts the fake server and returns it ready to accept requests.
+func New() *Server {
+	s := &Server{
+		containers: make(map[string]*containerRecord),
+		images:     make(map[string]bool),
+		failures:   make(map[string]string),
+		hooks:      make(map[string]func(*http.Request)),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/create", s.handleImageCreate)
+	mux.HandleFunc("/containers/create", s.handleContainerCreate)
+	mux.HandleFunc("/containers/json", s.handleContainerList)
+	mux.HandleFunc("/containers/", s.handleContainerByID)
+
+	s.httpServer = httptest.NewServer(mux)
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close shuts down the underlying httptest.Server.
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// PrepareFailure makes the next call for operation ("pull", "create",
+// "start", "stop", "list", "inspect", "remove") respond with a 500 and
+// message instead of performing the operation. It fires exactly once.
+func (s *Server) PrepareFailure(operation, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[operation] = message
+}
+
+func (s *Server) takeFailure(operation string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.failures[operation]
+	if ok {
+		delete(s.failures, operation)
+	}
+	return msg, ok
+}
+
+// OnRequest registers a callback invoked synchronously on every request for
+// operation, before the canned response is written - e.g. to assert on the
+// container config a test's RuntimeManager call actually sent over the wire.
+func (s *Server) OnRequest(operation string, fn func(*http.Request)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks[operation] = fn
+}
+
+func (s *Server) callHook(operation string, r *http.Request) {
+	s.mu.Lock()
+	fn := s.hooks[operation]
+	s.mu.Unlock()
+	if fn != nil {
+		fn(r)
+	}
+}
+
+func writeDockerError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func (s *Server) handleImageCreate(w http.ResponseWriter, r *http.Request) {
+	s.callHook("pull", r)
+	if msg, failed := s.takeFailure("pull"); failed {
+		writeDockerError(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	image := r.URL.Query().Get("fromImage")
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		image = image + ":" + tag
+	}
+
+	s.mu.Lock()
+	s.images[image] = true
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"status":"Pull complete","id":%q}`+"\n", image)
+}
+
+// createRequest mirrors the subset of docker/client's ContainerCreate wire
+// format (the container.Config fields Image, Env and Labels) this fake cares
+// about; the nested HostConfig is ignored.
+type createRequest struct {
+	Image  string            `json:"Image"`
+	Env    []string          `json:"Env"`
+	Labels map[string]string `json:"Labels"`
+}
+
+func (s *Server) handleContainerCreate(w http.ResponseWriter, r *http.Request) {
+	s.callHook("create", r)
+	if msg, failed := s.takeFailure("create"); failed {
+		writeDockerError(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDockerError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("%064x", s.nextID)
+	s.containers[id] = &containerRecord{
+		id:      id,
+		name:    strings.TrimPrefix(r.URL.Query().Get("name"), "/"),
+		image:   req.Image,
+		env:     req.Env,
+		labels:  req.Labels,
+		created: time.Now(),
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(container.CreateResponse{ID: id})
+}
+
+func (s *Server) handleContainerList(w http.ResponseWriter, r *http.Request) {
+	s.callHook("list", r)
+	if msg, failed := s.takeFailure("list"); failed {
+		writeDockerError(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	wantLabels := parseLabelFilters(r.URL.Query().Get("filters"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]types.Container, 0, len(s.containers))
+	for _, c := range s.containers {
+		if !matchesLabels(c.labels, wantLabels) {
+			continue
+		}
+		status, state := "Exited", "exited"
+		if c.running {
+			status, state = "Up", "running"
+		}
+		result = append(result, types.Container{
+			ID:     c.id,
+			Image:  c.image,
+			Names:  []string{"/" + c.name},
+			Status: status,
+			State:  state,
+			Labels: c.labels,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// parseLabelFilters extracts the "label" entries from Docker's filters
+// query parameter, a JSON object of field name to list of values (e.g.
+// {"label":["dockswap.app=myapp"]}).
+func parseLabelFilters(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+
+	var filters map[string][]string
+	if err := json.Unmarshal([]byte(raw), &filters); err != nil {
+		return nil
+	}
+
+	labels := make(map[string]string)
+	for _, kv := range filters["label"] {
+		key, value, ok := strings.Cut(kv, "=")
+		if ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+func matchesLabels(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// handleContainerByID dispatches everything under /containers/{id}/... that
+// isn't the /containers/create or /containers/json routes registered in New.
+func (s *Server) handleContainerByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/containers/")
+	parts := strings.SplitN(path, "/", 2)
+	id := parts[0]
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "start" && r.Method == http.MethodPost:
+		s.handleContainerStart(w, r, id)
+	case action == "stop" && r.Method == http.MethodPost:
+		s.handleContainerStop(w, r, id)
+	case action == "json" && r.Method == http.MethodGet:
+		s.handleContainerInspect(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		s.handleContainerRemove(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleContainerStart(w http.ResponseWriter, r *http.Request, id string) {
+	s.callHook("start", r)
+	if msg, failed := s.takeFailure("start"); failed {
+		writeDockerError(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	s.mu.Lock()
+	c, ok := s.containers[id]
+	if ok {
+		c.running = true
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeDockerError(w, http.StatusNotFound, fmt.Sprintf("no such container: %s", id))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleContainerStop(w http.ResponseWriter, r *http.Request, id string) {
+	s.callHook("stop", r)
+	if msg, failed := s.takeFailure("stop"); failed {
+		writeDockerError(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	s.mu.Lock()
+	c, ok := s.containers[id]
+	if ok {
+		c.running = false
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeDockerError(w, http.StatusNotFound, fmt.Sprintf("no such container: %s", id))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleContainerInspect(w http.ResponseWriter, r *http.Request, id string) {
+	s.callHook("inspect", r)
+	if msg, failed := s.takeFailure("inspect"); failed {
+		writeDockerError(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	s.mu.Lock()
+	c, ok := s.containers[id]
+	s.mu.Unlock()
+	if !ok {
+		writeDockerError(w, http.StatusNotFound, fmt.Sprintf("no such container: %s", id))
+		return
+	}
+
+	status := "exited"
+	if c.running {
+		status = "running"
+	}
+
+	resp := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:      c.id,
+			Name:    "/" + c.name,
+			Created: c.created.Format(time.RFC3339Nano),
+			State:   &types.ContainerState{Status: status, Running: c.running},
+		},
+		Config:          &container.Config{Image: c.image, Env: c.env, Labels: c.labels},
+		NetworkSettings: &types.NetworkSettings{},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleContainerRemove(w http.ResponseWriter, r *http.Request, id string) {
+	s.callHook("remove", r)
+	if msg, failed := s.takeFailure("remove"); failed {
+		writeDockerError(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	s.mu.Lock()
+	_, ok := s.containers[id]
+	delete(s.containers, id)
+	s.mu.Unlock()
+
+	if !ok {
+		writeDockerError(w, http.StatusNotFound, fmt.Sprintf("no such container: %s", id))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,167 @@
+package docker_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	dockerclient "github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dockswap/internal/caddy"
+	"dockswap/internal/config"
+	"dockswap/internal/docker"
+	"dockswap/internal/docker/fakeserver"
+	"dockswap/internal/runtime"
+	"dockswap/internal/state"
+)
+
+// newFakeServerManager points a real docker/client.Client at server via
+// client.WithHost, the same wiring a fake daemon needs in the absence of a
+// real one: no mocked runtime.Client, just wire-format HTTP against an
+// in-process server.
+func newFakeServerManager(t *testing.T, server *fakeserver.Server) *docker.RuntimeManager {
+	t.Helper()
+
+	host := strings.Replace(server.URL, "http://", "tcp://", 1)
+	cli, err := dockerclient.NewClientWithOpts(
+		dockerclient.WithHost(host),
+		dockerclient.WithVersion("1.43"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cli.Close() })
+
+	return docker.NewRuntimeManager(runtime.NewDockerClientFromRaw(cli))
+}
+
+func fakeServerAppConfig(appName string) *config.AppConfig {
+	return &config.AppConfig{
+		Name: appName,
+		Docker: config.Docker{
+			RestartPolicy: "no",
+		},
+		Ports: config.Ports{Blue: 8081, Green: 8082},
+		Proxy: config.Proxy{ListenPort: 80, Host: appName + ".example.com"},
+		Deployment: config.Deployment{
+			StopTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// TestFakeServer_FullBlueGreenCycle drives RuntimeManager, caddy.CaddyManager's
+// GenerateConfig, and state.AppState transitions through a complete
+// blue/green deployment against fakeserver instead of MockDockerClient or a
+// real daemon, exercising the wiring between all three rather than any one
+// package in isolation.
+func TestFakeServer_FullBlueGreenCycle(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+
+	ctx := context.Background()
+	dm := newFakeServerManager(t, server)
+	appName := "myapp"
+	appConfig := fakeServerAppConfig(appName)
+
+	// Initial deploy: blue is live on v1.
+	appState := state.CreateInitialState(appName, "myapp:v1", state.ColorBlue)
+
+	blueInfo, err := dm.CreateContainer(ctx, appName, "blue", appState.CurrentImage, appConfig)
+	require.NoError(t, err)
+	require.NoError(t, dm.StartContainer(ctx, blueInfo.ID))
+	appState.SetServiceContainerID("blue", "main", blueInfo.ID)
+
+	running, err := dm.GetContainerInfo(ctx, appName, "blue")
+	require.NoError(t, err)
+	assert.Equal(t, "running", running.State)
+
+	tempDir := t.TempDir()
+	caddyManager := caddy.New(filepath.Join(tempDir, "config.json"), filepath.Join(tempDir, "template.json"))
+	require.NoError(t, os.WriteFile(caddyManager.GetTemplatePath(), []byte(blueGreenCaddyTemplate), 0644))
+
+	configs := map[string]*config.AppConfig{appName: appConfig}
+	states := map[string]*state.AppState{appName: appState}
+	require.NoError(t, caddyManager.GenerateConfig(configs, states))
+	assertRoutesToPort(t, caddyManager.GetConfigPath(), appConfig.Ports.Blue)
+
+	// Roll out v2 to green, then cut traffic over.
+	appState.SetDeploying("myapp:v2")
+	require.NoError(t, dm.PullImage(ctx, appState.DesiredImage, appConfig))
+
+	greenInfo, err := dm.CreateContainer(ctx, appName, "green", appState.DesiredImage, appConfig)
+	require.NoError(t, err)
+	require.NoError(t, dm.StartContainer(ctx, greenInfo.ID))
+	appState.SetServiceContainerID("green", "main", greenInfo.ID)
+
+	appState.CompleteDeployment(state.ColorGreen)
+	require.NoError(t, caddyManager.GenerateConfig(configs, states))
+	assertRoutesToPort(t, caddyManager.GetConfigPath(), appConfig.Ports.Green)
+
+	// Drain and remove the now-inactive blue container.
+	require.NoError(t, dm.StopContainer(ctx, appName, "blue", appConfig.Deployment.StopTimeout))
+	require.NoError(t, dm.RemoveContainer(ctx, appName, "blue", false))
+
+	remaining, err := dm.ListAppContainers(ctx, appName)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, appName+"-green", remaining[0].Name)
+}
+
+// TestFakeServer_StartFailurePropagates confirms a PrepareFailure hook
+// surfaces through RuntimeManager's wrapped error instead of being swallowed.
+func TestFakeServer_StartFailurePropagates(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+
+	ctx := context.Background()
+	dm := newFakeServerManager(t, server)
+	appConfig := fakeServerAppConfig("myapp")
+
+	info, err := dm.CreateContainer(ctx, "myapp", "blue", "myapp:v1", appConfig)
+	require.NoError(t, err)
+
+	server.PrepareFailure("start", "cannot start container: no such image")
+	err = dm.StartContainer(ctx, info.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such image")
+}
+
+const blueGreenCaddyTemplate = `{
+  "apps": {
+    "http": {
+      "servers": {
+        {{range .Apps}}
+        "{{.Name}}": {
+          "listen": [":{{.Proxy.ListenPort}}"],
+          "routes": [
+            {
+              "handle": [
+                {
+                  "handler": "reverse_proxy",
+                  "upstreams": [{"dial": "localhost:{{.ActivePort}}"}]
+                }
+              ]
+            }
+          ]
+        }{{if not .IsLast}},{{end}}
+        {{end}}
+      }
+    }
+  }
+}`
+
+func assertRoutesToPort(t *testing.T, configPath string, port int) {
+	t.Helper()
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Contains(t, string(data), "localhost:"+strconv.Itoa(port))
+}
@@ -0,0 +1,215 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+
+	"dockswap/internal/config"
+)
+
+// mainServiceName is the reserved ServiceContainer.Service value for an app's
+// primary container, as opposed to one of its configured sidecars.
+const mainServiceName = "main"
+
+// ServiceContainer pairs a service name with the container created for it.
+// The main container uses mainServiceName.
+type ServiceContainer struct {
+	Service string
+	Info    *ContainerInfo
+}
+
+// orderServices topologically sorts appConfig's services so that every
+// service appears after everything it depends_on, returning an error if
+// depends_on references an unknown service or forms a cycle. Reversing the
+// result gives the correct stop order.
+func orderServices(services []config.ServiceConfig) ([]config.ServiceConfig, error) {
+	byName := make(map[string]config.ServiceConfig, len(services))
+	for _, svc := range services {
+		byName[svc.Name] = svc
+	}
+
+	const (
+		unvisited = 0
+		visiting  = 1
+		done      = 2
+	)
+	state := make(map[string]int, len(services))
+	var ordered []config.ServiceConfig
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular depends_on involving service %s", name)
+		}
+
+		svc, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown service %s in depends_on", name)
+		}
+
+		state[name] = visiting
+		for _, dep := range svc.DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		ordered = append(ordered, svc)
+		return nil
+	}
+
+	for _, svc := range services {
+		if err := visit(svc.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// CreateContainerGroup creates and starts appName's main container plus all
+// configured sidecars for one color: sidecars start first, in depends_on
+// order, and the main container starts last, after which every container in
+// the group joins the shared dockswap network. If any member fails to
+// start, everything started so far for this call is torn down so a
+// half-started group never lingers.
+func (dm *RuntimeManager) CreateContainerGroup(ctx context.Context, appName, color, image string, appConfig *config.AppConfig) ([]ServiceContainer, error) {
+	order, err := orderServices(appConfig.Services)
+	if err != nil {
+		return nil, fmt.Errorf("failed to order services for app %s: %w", appName, err)
+	}
+
+	var started []ServiceContainer
+	rollback := func() {
+		for i := len(started) - 1; i >= 0; i-- {
+			_ = dm.client.ContainerRemove(context.Background(), started[i].Info.ID, container.RemoveOptions{Force: true})
+		}
+	}
+
+	for _, svc := range order {
+		info, err := dm.createServiceContainer(ctx, appName, color, svc, appConfig)
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to create sidecar %s for %s-%s: %w", svc.Name, appName, color, err)
+		}
+		if err := dm.StartContainer(ctx, info.ID); err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to start sidecar %s for %s-%s: %w", svc.Name, appName, color, err)
+		}
+		started = append(started, ServiceContainer{Service: svc.Name, Info: info})
+	}
+
+	mainInfo, err := dm.CreateContainer(ctx, appName, color, image, appConfig)
+	if err != nil {
+		rollback()
+		return nil, fmt.Errorf("failed to create main container for %s-%s: %w", appName, color, err)
+	}
+	if err := dm.StartContainer(ctx, mainInfo.ID); err != nil {
+		rollback()
+		return nil, fmt.Errorf("failed to start main container for %s-%s: %w", appName, color, err)
+	}
+	started = append(started, ServiceContainer{Service: mainServiceName, Info: mainInfo})
+
+	if appConfig.Docker.Network != "" {
+		for _, sc := range started {
+			if err := dm.ConnectContainerToNetwork(ctx, appConfig.Docker.Network, sc.Info.ID); err != nil {
+				rollback()
+				return nil, fmt.Errorf("failed to connect %s to network %s: %w", sc.Info.Name, appConfig.Docker.Network, err)
+			}
+		}
+	}
+
+	return started, nil
+}
+
+// StopContainerGroup stops appName's color group: the main container first,
+// so the group stops taking traffic before its dependencies disappear, then
+// its sidecars in reverse depends_on order.
+func (dm *RuntimeManager) StopContainerGroup(ctx context.Context, appName, color string, appConfig *config.AppConfig, timeout time.Duration) error {
+	if err := dm.StopContainer(ctx, appName, color, timeout); err != nil {
+		return fmt.Errorf("failed to stop main container for %s-%s: %w", appName, color, err)
+	}
+	if err := dm.RemoveContainer(ctx, appName, color, false); err != nil {
+		return fmt.Errorf("failed to remove main container for %s-%s: %w", appName, color, err)
+	}
+
+	order, err := orderServices(appConfig.Services)
+	if err != nil {
+		return fmt.Errorf("failed to order services for app %s: %w", appName, err)
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		svc := order[i]
+		if err := dm.stopServiceContainer(ctx, appName, color, svc.Name, timeout); err != nil {
+			return fmt.Errorf("failed to stop sidecar %s for %s-%s: %w", svc.Name, appName, color, err)
+		}
+	}
+
+	return nil
+}
+
+func (dm *RuntimeManager) createServiceContainer(ctx context.Context, appName, color string, svc config.ServiceConfig, appConfig *config.AppConfig) (*ContainerInfo, error) {
+	containerName := fmt.Sprintf("%s-%s-%s", appName, color, svc.Name)
+
+	containerConfig := &container.Config{
+		Image: svc.Image,
+		Env:   buildEnvironmentVars(svc.Environment),
+		Labels: map[string]string{
+			"dockswap.app":     appName,
+			"dockswap.color":   color,
+			"dockswap.service": svc.Name,
+			"dockswap.managed": "true",
+		},
+	}
+
+	hostConfig := &container.HostConfig{
+		RestartPolicy: container.RestartPolicy{
+			Name: container.RestartPolicyMode(appConfig.Docker.RestartPolicy),
+		},
+		AutoRemove: false,
+		Binds:      svc.Volumes,
+	}
+
+	resp, err := dm.client.ContainerCreate(ctx, containerConfig, hostConfig, &network.NetworkingConfig{}, containerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container %s: %w", containerName, err)
+	}
+
+	return &ContainerInfo{
+		ID:      resp.ID,
+		Name:    containerName,
+		Image:   svc.Image,
+		Status:  "created",
+		State:   "created",
+		Health:  "unknown",
+		Created: time.Now(),
+	}, nil
+}
+
+func (dm *RuntimeManager) stopServiceContainer(ctx context.Context, appName, color, service string, timeout time.Duration) error {
+	containers, err := dm.findServiceContainers(ctx, appName, color, service)
+	if err != nil {
+		return fmt.Errorf("failed to find container %s-%s-%s: %w", appName, color, service, err)
+	}
+	if len(containers) == 0 {
+		return nil
+	}
+
+	timeoutSeconds := int(timeout.Seconds())
+	if err := dm.client.ContainerStop(ctx, containers[0].ID, container.StopOptions{Timeout: &timeoutSeconds}); err != nil {
+		return fmt.Errorf("failed to stop container %s-%s-%s: %w", appName, color, service, err)
+	}
+
+	if err := dm.client.ContainerRemove(ctx, containers[0].ID, container.RemoveOptions{RemoveVolumes: false}); err != nil {
+		return fmt.Errorf("failed to remove container %s-%s-%s: %w", appName, color, service, err)
+	}
+
+	return nil
+}
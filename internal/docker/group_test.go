@@ -0,0 +1,159 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"dockswap/internal/config"
+)
+
+func TestOrderServices(t *testing.T) {
+	t.Run("orders by depends_on", func(t *testing.T) {
+		services := []config.ServiceConfig{
+			{Name: "app", DependsOn: []string{"cache", "log-shipper"}},
+			{Name: "cache", DependsOn: []string{"db-proxy"}},
+			{Name: "db-proxy"},
+			{Name: "log-shipper"},
+		}
+
+		ordered, err := orderServices(services)
+		assert.NoError(t, err)
+
+		pos := make(map[string]int, len(ordered))
+		for i, svc := range ordered {
+			pos[svc.Name] = i
+		}
+
+		assert.Less(t, pos["db-proxy"], pos["cache"])
+		assert.Less(t, pos["cache"], pos["app"])
+		assert.Less(t, pos["log-shipper"], pos["app"])
+	})
+
+	t.Run("detects cycles", func(t *testing.T) {
+		services := []config.ServiceConfig{
+			{Name: "a", DependsOn: []string{"b"}},
+			{Name: "b", DependsOn: []string{"a"}},
+		}
+
+		_, err := orderServices(services)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "circular depends_on")
+	})
+
+	t.Run("errors on unknown dependency", func(t *testing.T) {
+		services := []config.ServiceConfig{
+			{Name: "a", DependsOn: []string{"missing"}},
+		}
+
+		_, err := orderServices(services)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown service")
+	})
+}
+
+func groupTestAppConfig() *config.AppConfig {
+	appConfig := createTestAppConfig()
+	appConfig.Services = []config.ServiceConfig{
+		{Name: "db-proxy", Image: "proxysql:2"},
+	}
+	return appConfig
+}
+
+func TestRuntimeManager_CreateContainerGroup(t *testing.T) {
+	t.Run("starts sidecar before main", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		dm := NewRuntimeManager(mockClient)
+		appConfig := groupTestAppConfig()
+
+		var createOrder []string
+		mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(c *container.Config) bool {
+			return c.Labels["dockswap.service"] == "db-proxy"
+		}), mock.Anything, mock.Anything, "test-app-blue-db-proxy").
+			Run(func(mock.Arguments) { createOrder = append(createOrder, "db-proxy") }).
+			Return(container.CreateResponse{ID: "sidecar123"}, nil)
+		mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(c *container.Config) bool {
+			return c.Image == "nginx:1.21"
+		}), mock.Anything, mock.Anything, "test-app-blue").
+			Run(func(mock.Arguments) { createOrder = append(createOrder, "main") }).
+			Return(container.CreateResponse{ID: "main123"}, nil)
+		mockClient.On("ContainerStart", mock.Anything, "sidecar123", mock.Anything).Return(nil)
+		mockClient.On("ContainerStart", mock.Anything, "main123", mock.Anything).Return(nil)
+		mockClient.On("NetworkList", mock.Anything, mock.Anything).
+			Return([]network.Inspect{{Name: "test-network", ID: "net123"}}, nil)
+		mockClient.On("NetworkConnect", mock.Anything, "net123", mock.Anything, mock.Anything).Return(nil)
+
+		group, err := dm.CreateContainerGroup(context.Background(), "test-app", "blue", "nginx:1.21", appConfig)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"db-proxy", "main"}, createOrder)
+		assert.Len(t, group, 2)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("rolls back sidecars started before a later failure", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		dm := NewRuntimeManager(mockClient)
+		appConfig := groupTestAppConfig()
+
+		mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(c *container.Config) bool {
+			return c.Labels["dockswap.service"] == "db-proxy"
+		}), mock.Anything, mock.Anything, "test-app-blue-db-proxy").
+			Return(container.CreateResponse{ID: "sidecar123"}, nil)
+		mockClient.On("ContainerStart", mock.Anything, "sidecar123", mock.Anything).
+			Return(errors.New("start failed"))
+		mockClient.On("ContainerRemove", mock.Anything, "sidecar123", mock.Anything).Return(nil)
+
+		group, err := dm.CreateContainerGroup(context.Background(), "test-app", "blue", "nginx:1.21", appConfig)
+
+		assert.Error(t, err)
+		assert.Nil(t, group)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestRuntimeManager_CheckGroupHealth(t *testing.T) {
+	t.Run("unhealthy when sidecar is not running", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		dm := NewRuntimeManager(mockClient)
+		appConfig := groupTestAppConfig()
+		appConfig.HealthCheck.Endpoint = ""
+
+		mainContainer := types.Container{ID: "main123", Names: []string{"/test-app-blue"}}
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).
+			Return([]types.Container{mainContainer}, nil).Once()
+		mockClient.On("ContainerInspect", mock.Anything, "main123").Return(types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:    "main123",
+				Name:  "/test-app-blue",
+				State: &types.ContainerState{Status: "running"},
+			},
+			Config:          &container.Config{Image: "nginx:1.21"},
+			NetworkSettings: &types.NetworkSettings{},
+		}, nil)
+
+		sidecarContainer := types.Container{ID: "sidecar123", Names: []string{"/test-app-blue-db-proxy"}}
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).
+			Return([]types.Container{sidecarContainer}, nil).Once()
+		mockClient.On("ContainerInspect", mock.Anything, "sidecar123").Return(types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:    "sidecar123",
+				Name:  "/test-app-blue-db-proxy",
+				State: &types.ContainerState{Status: "exited"},
+			},
+			Config: &container.Config{Image: "proxysql:2"},
+		}, nil)
+
+		result, err := dm.CheckGroupHealth(context.Background(), "test-app", "blue", appConfig)
+
+		assert.NoError(t, err)
+		assert.Equal(t, HealthStatusUnhealthy, result.Status)
+		assert.Contains(t, result.Message, "db-proxy")
+	})
+}
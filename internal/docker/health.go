@@ -3,11 +3,19 @@ package docker
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/docker/docker/api/types/container"
+
 	"dockswap/internal/config"
+	"dockswap/internal/docker/errdefs"
 )
 
 type HealthStatus string
@@ -19,28 +27,253 @@ const (
 	HealthStatusUnknown   HealthStatus = "unknown"
 )
 
+// ProbeResult is the outcome of a single HealthProbe run as part of
+// CheckContainerHealth.
+type ProbeResult struct {
+	Name    string
+	Status  HealthStatus
+	Message string
+}
+
 type HealthCheckResult struct {
 	Status        HealthStatus
 	DockerHealth  HealthStatus
-	HTTPHealth    HealthStatus
+	ProbeResults  []ProbeResult
 	Message       string
 	LastCheck     time.Time
 	CheckDuration time.Duration
 }
 
-type HTTPHealthChecker struct {
-	client *http.Client
+// HealthProbe is one protocol-specific check that CheckContainerHealth runs
+// and folds into the aggregate HealthStatus. A config.HealthCheck (the
+// primary one, plus any config.AppConfig.AdditionalChecks) compiles to
+// exactly one HealthProbe, so e.g. a TCP-readiness probe can be chained with
+// an HTTP-liveness probe and a gRPC-serving probe before traffic is cut
+// over.
+type HealthProbe interface {
+	// Name identifies the probe in ProbeResult, e.g. "http" or "grpc".
+	Name() string
+	Check(ctx context.Context) (HealthStatus, error)
+}
+
+// HTTPProbe is the original checkHTTPHealth behavior: poll an HTTP endpoint
+// until it returns the expected status code or retries are exhausted.
+type HTTPProbe struct {
+	url            string
+	method         string
+	expectedStatus int
+	retries        int
+	interval       time.Duration
+	client         *http.Client
+}
+
+func NewHTTPProbe(url, method string, expectedStatus, retries int, interval, timeout time.Duration) *HTTPProbe {
+	return &HTTPProbe{
+		url:            url,
+		method:         method,
+		expectedStatus: expectedStatus,
+		retries:        retries,
+		interval:       interval,
+		client:         &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPProbe) Name() string { return "http" }
+
+func (p *HTTPProbe) Check(ctx context.Context) (HealthStatus, error) {
+	for attempt := 0; attempt < p.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return HealthStatusUnhealthy, ctx.Err()
+			case <-time.After(p.interval):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, p.method, p.url, nil)
+		if err != nil {
+			continue
+		}
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == p.expectedStatus {
+			return HealthStatusHealthy, nil
+		}
+	}
+
+	return HealthStatusUnhealthy, fmt.Errorf("HTTP health check failed after %d attempts", p.retries)
+}
+
+// TCPProbe is a dial-only readiness check, useful for databases and other
+// TCP services that don't speak HTTP or gRPC.
+type TCPProbe struct {
+	address string
+	timeout time.Duration
+}
+
+func NewTCPProbe(address string, timeout time.Duration) *TCPProbe {
+	return &TCPProbe{address: address, timeout: timeout}
+}
+
+func (p *TCPProbe) Name() string { return "tcp" }
+
+func (p *TCPProbe) Check(ctx context.Context) (HealthStatus, error) {
+	dialer := net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return HealthStatusUnhealthy, fmt.Errorf("TCP dial to %s failed: %w", p.address, err)
+	}
+	conn.Close()
+	return HealthStatusHealthy, nil
+}
+
+// GRPCProbe calls the standard grpc.health.v1.Health/Check RPC against a
+// configurable service name; the empty service name checks overall server
+// health, matching the grpc-health-probe convention.
+type GRPCProbe struct {
+	address string
+	service string
+	timeout time.Duration
+}
+
+func NewGRPCProbe(address, service string, timeout time.Duration) *GRPCProbe {
+	return &GRPCProbe{address: address, service: service, timeout: timeout}
 }
 
-func NewHTTPHealthChecker(timeout time.Duration) *HTTPHealthChecker {
-	return &HTTPHealthChecker{
-		client: &http.Client{
-			Timeout: timeout,
-		},
+func (p *GRPCProbe) Name() string { return "grpc" }
+
+func (p *GRPCProbe) Check(ctx context.Context) (HealthStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	conn, err := grpc.NewClient(p.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return HealthStatusUnhealthy, fmt.Errorf("gRPC dial to %s failed: %w", p.address, err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.service})
+	if err != nil {
+		return HealthStatusUnhealthy, fmt.Errorf("gRPC health check failed: %w", err)
+	}
+
+	switch resp.Status {
+	case healthpb.HealthCheckResponse_SERVING:
+		return HealthStatusHealthy, nil
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		return HealthStatusUnhealthy, fmt.Errorf("gRPC service %q reported NOT_SERVING", p.service)
+	default:
+		return HealthStatusStarting, nil
 	}
 }
 
-func (dm *DockerManager) CheckContainerHealth(ctx context.Context, appName, color string, appConfig *config.AppConfig) (*HealthCheckResult, error) {
+// ExecProbe runs a command inside the target container via
+// ContainerExecCreate/Start/Inspect and checks its exit code, mirroring
+// Docker's own HEALTHCHECK CMD.
+type ExecProbe struct {
+	dm          *RuntimeManager
+	containerID string
+	command     []string
+	timeout     time.Duration
+}
+
+func NewExecProbe(dm *RuntimeManager, containerID string, command []string, timeout time.Duration) *ExecProbe {
+	return &ExecProbe{dm: dm, containerID: containerID, command: command, timeout: timeout}
+}
+
+func (p *ExecProbe) Name() string { return "exec" }
+
+func (p *ExecProbe) Check(ctx context.Context) (HealthStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	created, err := p.dm.client.ContainerExecCreate(ctx, p.containerID, container.ExecOptions{
+		Cmd:          p.command,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return HealthStatusUnhealthy, fmt.Errorf("exec create failed: %w", errdefs.FromDockerError(err))
+	}
+
+	if err := p.dm.client.ContainerExecStart(ctx, created.ID, container.ExecStartOptions{}); err != nil {
+		return HealthStatusUnhealthy, fmt.Errorf("exec start failed: %w", errdefs.FromDockerError(err))
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		inspect, err := p.dm.client.ContainerExecInspect(ctx, created.ID)
+		if err != nil {
+			return HealthStatusUnhealthy, fmt.Errorf("exec inspect failed: %w", errdefs.FromDockerError(err))
+		}
+
+		if !inspect.Running {
+			if inspect.ExitCode == 0 {
+				return HealthStatusHealthy, nil
+			}
+			return HealthStatusUnhealthy, fmt.Errorf("exec command exited with code %d", inspect.ExitCode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return HealthStatusUnhealthy, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// buildProbes compiles appConfig's HealthCheck and AdditionalChecks into one
+// HealthProbe per entry. A HealthCheck with no Type and no Endpoint (the
+// pre-chunk0-6 zero value) contributes no probe, so existing configs that
+// only relied on the container's Docker-native HEALTHCHECK keep working.
+func (dm *RuntimeManager) buildProbes(color, containerID string, appConfig *config.AppConfig) ([]HealthProbe, error) {
+	checks := make([]config.HealthCheck, 0, 1+len(appConfig.AdditionalChecks))
+	if appConfig.HealthCheck.Type != "" || appConfig.HealthCheck.Endpoint != "" {
+		checks = append(checks, appConfig.HealthCheck)
+	}
+	checks = append(checks, appConfig.AdditionalChecks...)
+
+	probes := make([]HealthProbe, 0, len(checks))
+	for _, hc := range checks {
+		probe, err := dm.newProbe(color, containerID, appConfig, hc)
+		if err != nil {
+			return nil, err
+		}
+		probes = append(probes, probe)
+	}
+
+	return probes, nil
+}
+
+func (dm *RuntimeManager) newProbe(color, containerID string, appConfig *config.AppConfig, hc config.HealthCheck) (HealthProbe, error) {
+	port := appConfig.Ports.Green
+	if color == "blue" {
+		port = appConfig.Ports.Blue
+	}
+
+	switch hc.Type {
+	case "", config.HealthCheckTypeHTTP:
+		url := fmt.Sprintf("http://localhost:%d%s", port, hc.Endpoint)
+		return NewHTTPProbe(url, hc.Method, hc.ExpectedStatus, hc.Retries, hc.Interval, hc.Timeout), nil
+	case config.HealthCheckTypeTCP:
+		return NewTCPProbe(fmt.Sprintf("localhost:%d", port), hc.Timeout), nil
+	case config.HealthCheckTypeGRPC:
+		return NewGRPCProbe(fmt.Sprintf("localhost:%d", port), hc.GRPCService, hc.Timeout), nil
+	case config.HealthCheckTypeExec:
+		return NewExecProbe(dm, containerID, hc.ExecCommand, hc.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown health check type %q", hc.Type)
+	}
+}
+
+func (dm *RuntimeManager) CheckContainerHealth(ctx context.Context, appName, color string, appConfig *config.AppConfig) (*HealthCheckResult, error) {
 	startTime := time.Now()
 	result := &HealthCheckResult{
 		Status:    HealthStatusUnknown,
@@ -52,6 +285,14 @@ func (dm *DockerManager) CheckContainerHealth(ctx context.Context, appName, colo
 	if err != nil {
 		result.Message = fmt.Sprintf("Failed to get container info: %v", err)
 		result.CheckDuration = time.Since(startTime)
+
+		classified := errdefs.FromDockerError(err)
+		if !errdefs.IsRetryable(classified) {
+			// A not-found/conflict/invalid-parameter error means the
+			// container is gone or the request was malformed, not that the
+			// daemon is momentarily unreachable; don't keep polling.
+			return result, classified
+		}
 		return result, nil
 	}
 
@@ -67,76 +308,52 @@ func (dm *DockerManager) CheckContainerHealth(ctx context.Context, appName, colo
 		return result, nil
 	}
 
-	// Check HTTP health if configured
-	if appConfig.HealthCheck.Endpoint != "" {
-		httpHealth, httpErr := dm.checkHTTPHealth(appName, color, appConfig)
-		result.HTTPHealth = httpHealth
+	probes, err := dm.buildProbes(color, containerInfo.ID, appConfig)
+	if err != nil {
+		result.Status = HealthStatusUnhealthy
+		result.Message = err.Error()
+		result.CheckDuration = time.Since(startTime)
+		return result, nil
+	}
 
-		if httpErr != nil {
-			result.Status = HealthStatusUnhealthy
-			result.Message = fmt.Sprintf("HTTP health check failed: %v", httpErr)
-			result.CheckDuration = time.Since(startTime)
-			return result, nil
+	probeResults := make([]ProbeResult, 0, len(probes))
+	for _, probe := range probes {
+		status, probeErr := probe.Check(ctx)
+		msg := ""
+		if probeErr != nil {
+			msg = probeErr.Error()
 		}
+		probeResults = append(probeResults, ProbeResult{Name: probe.Name(), Status: status, Message: msg})
 	}
+	result.ProbeResults = probeResults
 
 	// Determine overall health status
-	result.Status = dm.determineOverallHealth(result.DockerHealth, result.HTTPHealth, appConfig)
+	result.Status = determineOverallHealth(dockerHealth, probeResults)
 
-	if result.Status == HealthStatusHealthy {
+	switch result.Status {
+	case HealthStatusHealthy:
 		result.Message = "All health checks passed"
-	} else if result.Status == HealthStatusStarting {
+	case HealthStatusStarting:
 		result.Message = "Container is starting up"
-	} else {
-		result.Message = "Health checks failed"
+	default:
+		result.Message = firstFailureMessage(probeResults)
 	}
 
 	result.CheckDuration = time.Since(startTime)
 	return result, nil
 }
 
-func (dm *DockerManager) checkHTTPHealth(appName, color string, appConfig *config.AppConfig) (HealthStatus, error) {
-	// Determine the port to check
-	var port int
-	if color == "blue" {
-		port = appConfig.Ports.Blue
-	} else {
-		port = appConfig.Ports.Green
-	}
-
-	// Build health check URL
-	url := fmt.Sprintf("http://localhost:%d%s", port, appConfig.HealthCheck.Endpoint)
-
-	// Create HTTP health checker with configured timeout
-	checker := NewHTTPHealthChecker(appConfig.HealthCheck.Timeout)
-
-	// Perform health check with retries
-	for attempt := 0; attempt < appConfig.HealthCheck.Retries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(appConfig.HealthCheck.Interval)
-		}
-
-		req, err := http.NewRequest(appConfig.HealthCheck.Method, url, nil)
-		if err != nil {
-			continue
-		}
-
-		resp, err := checker.client.Do(req)
-		if err != nil {
-			continue
-		}
-		resp.Body.Close()
+func (dm *RuntimeManager) WaitForHealthy(ctx context.Context, appName, color string, appConfig *config.AppConfig, timeout time.Duration) error {
+	startTime := time.Now()
 
-		if resp.StatusCode == appConfig.HealthCheck.ExpectedStatus {
-			return HealthStatusHealthy, nil
+	if appConfig.HealthCheck.InitialDelay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(appConfig.HealthCheck.InitialDelay):
 		}
 	}
 
-	return HealthStatusUnhealthy, fmt.Errorf("HTTP health check failed after %d attempts", appConfig.HealthCheck.Retries)
-}
-
-func (dm *DockerManager) WaitForHealthy(ctx context.Context, appName, color string, appConfig *config.AppConfig, timeout time.Duration) error {
-	startTime := time.Now()
 	ticker := time.NewTicker(appConfig.HealthCheck.Interval)
 	defer ticker.Stop()
 
@@ -144,6 +361,7 @@ func (dm *DockerManager) WaitForHealthy(ctx context.Context, appName, color stri
 	defer timeoutTimer.Stop()
 
 	successCount := 0
+	failureCount := 0
 
 	for {
 		select {
@@ -154,16 +372,24 @@ func (dm *DockerManager) WaitForHealthy(ctx context.Context, appName, color stri
 		case <-ticker.C:
 			result, err := dm.CheckContainerHealth(ctx, appName, color, appConfig)
 			if err != nil {
+				// CheckContainerHealth only returns a non-nil error for
+				// non-retryable failures (container gone, bad request); fail
+				// fast instead of grinding out the rest of the timeout.
 				return fmt.Errorf("health check error: %w", err)
 			}
 
 			if result.Status == HealthStatusHealthy {
 				successCount++
+				failureCount = 0
 				if successCount >= appConfig.HealthCheck.SuccessThreshold {
 					return nil // Health check passed
 				}
 			} else {
 				successCount = 0 // Reset on failure
+				failureCount++
+				if appConfig.HealthCheck.FailureThreshold > 0 && failureCount >= appConfig.HealthCheck.FailureThreshold {
+					return fmt.Errorf("health check failed %d consecutive times: %s", failureCount, result.Message)
+				}
 			}
 
 			// Log progress (in real implementation, you'd use a proper logger)
@@ -187,34 +413,98 @@ func parseDockerHealthStatus(healthStr string) HealthStatus {
 	}
 }
 
-func (dm *DockerManager) determineOverallHealth(dockerHealth, httpHealth HealthStatus, appConfig *config.AppConfig) HealthStatus {
-	// If HTTP health check is not configured, rely on Docker health
-	if appConfig.HealthCheck.Endpoint == "" {
+// determineOverallHealth aggregates the container's Docker-native health
+// (if any) with an arbitrary list of probe results: any single unhealthy
+// probe fails the whole check, any starting probe holds it at "starting",
+// and it's only healthy once Docker health (if configured) and every probe
+// agree.
+func determineOverallHealth(dockerHealth HealthStatus, probeResults []ProbeResult) HealthStatus {
+	if len(probeResults) == 0 {
 		if dockerHealth == HealthStatusUnknown {
-			// If no Docker health check is configured, assume healthy if container is running
+			// If no Docker health check is configured either, assume
+			// healthy if the container is running.
 			return HealthStatusHealthy
 		}
 		return dockerHealth
 	}
 
-	// Both Docker and HTTP health checks are configured
-	if dockerHealth == HealthStatusUnhealthy || httpHealth == HealthStatusUnhealthy {
-		return HealthStatusUnhealthy
+	overall := dockerHealth
+	sawStarting := false
+
+	for _, pr := range probeResults {
+		if pr.Status == HealthStatusUnhealthy {
+			return HealthStatusUnhealthy
+		}
+		if pr.Status == HealthStatusStarting {
+			sawStarting = true
+		}
+		if overall == HealthStatusUnknown {
+			overall = pr.Status
+		}
 	}
 
-	if dockerHealth == HealthStatusStarting || httpHealth == HealthStatusStarting {
+	if overall == HealthStatusUnhealthy {
+		return HealthStatusUnhealthy
+	}
+	if sawStarting || overall == HealthStatusStarting || overall == HealthStatusUnknown {
 		return HealthStatusStarting
 	}
-
-	if dockerHealth == HealthStatusHealthy && httpHealth == HealthStatusHealthy {
+	if overall == HealthStatusHealthy {
 		return HealthStatusHealthy
 	}
 
-	// If we have mixed results or unknown status, consider it as starting
 	return HealthStatusStarting
 }
 
-func (dm *DockerManager) IsContainerHealthy(ctx context.Context, appName, color string, appConfig *config.AppConfig) (bool, error) {
+func firstFailureMessage(probeResults []ProbeResult) string {
+	for _, pr := range probeResults {
+		if pr.Status != HealthStatusHealthy && pr.Message != "" {
+			return fmt.Sprintf("%s health check failed: %s", pr.Name, pr.Message)
+		}
+	}
+	return "Health checks failed"
+}
+
+// CheckGroupHealth extends CheckContainerHealth to a compose-style app
+// group: the group is only HealthStatusHealthy if the main container passes
+// its usual checks AND every configured sidecar is running and, where it has
+// a Docker-native healthcheck, reporting healthy.
+func (dm *RuntimeManager) CheckGroupHealth(ctx context.Context, appName, color string, appConfig *config.AppConfig) (*HealthCheckResult, error) {
+	result, err := dm.CheckContainerHealth(ctx, appName, color, appConfig)
+	if err != nil || result.Status != HealthStatusHealthy {
+		return result, err
+	}
+
+	for _, svc := range appConfig.Services {
+		info, err := dm.getServiceContainerInfo(ctx, appName, color, svc.Name)
+		if err != nil {
+			result.Status = HealthStatusUnhealthy
+			result.Message = fmt.Sprintf("sidecar %s: %v", svc.Name, err)
+			return result, nil
+		}
+
+		if info.State != "running" {
+			result.Status = HealthStatusUnhealthy
+			result.Message = fmt.Sprintf("sidecar %s is not running (state: %s)", svc.Name, info.State)
+			return result, nil
+		}
+
+		switch parseDockerHealthStatus(info.Health) {
+		case HealthStatusUnhealthy:
+			result.Status = HealthStatusUnhealthy
+			result.Message = fmt.Sprintf("sidecar %s reported unhealthy", svc.Name)
+			return result, nil
+		case HealthStatusStarting:
+			result.Status = HealthStatusStarting
+			result.Message = fmt.Sprintf("sidecar %s is starting up", svc.Name)
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+func (dm *RuntimeManager) IsContainerHealthy(ctx context.Context, appName, color string, appConfig *config.AppConfig) (bool, error) {
 	result, err := dm.CheckContainerHealth(ctx, appName, color, appConfig)
 	if err != nil {
 		return false, err
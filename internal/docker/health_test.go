@@ -0,0 +1,232 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"dockswap/internal/config"
+)
+
+func TestParseDockerHealthStatus(t *testing.T) {
+	tests := []struct {
+		in   string
+		want HealthStatus
+	}{
+		{"healthy", HealthStatusHealthy},
+		{"HEALTHY", HealthStatusHealthy},
+		{"unhealthy", HealthStatusUnhealthy},
+		{"starting", HealthStatusStarting},
+		{"", HealthStatusUnknown},
+		{"none", HealthStatusUnknown},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseDockerHealthStatus(tt.in))
+	}
+}
+
+func TestDetermineOverallHealth(t *testing.T) {
+	tests := []struct {
+		name         string
+		dockerHealth HealthStatus
+		probes       []ProbeResult
+		want         HealthStatus
+	}{
+		{
+			name:         "no probes, no docker health, running container is healthy",
+			dockerHealth: HealthStatusUnknown,
+			probes:       nil,
+			want:         HealthStatusHealthy,
+		},
+		{
+			name:         "no probes, defers to docker health",
+			dockerHealth: HealthStatusUnhealthy,
+			probes:       nil,
+			want:         HealthStatusUnhealthy,
+		},
+		{
+			name:         "any unhealthy probe fails the whole check",
+			dockerHealth: HealthStatusHealthy,
+			probes: []ProbeResult{
+				{Name: "tcp", Status: HealthStatusHealthy},
+				{Name: "http", Status: HealthStatusUnhealthy},
+			},
+			want: HealthStatusUnhealthy,
+		},
+		{
+			name:         "a starting probe holds the check at starting",
+			dockerHealth: HealthStatusHealthy,
+			probes: []ProbeResult{
+				{Name: "tcp", Status: HealthStatusHealthy},
+				{Name: "grpc", Status: HealthStatusStarting},
+			},
+			want: HealthStatusStarting,
+		},
+		{
+			name:         "all probes and docker health agree healthy",
+			dockerHealth: HealthStatusHealthy,
+			probes: []ProbeResult{
+				{Name: "tcp", Status: HealthStatusHealthy},
+				{Name: "http", Status: HealthStatusHealthy},
+				{Name: "grpc", Status: HealthStatusHealthy},
+			},
+			want: HealthStatusHealthy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := determineOverallHealth(tt.dockerHealth, tt.probes)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHTTPProbe(t *testing.T) {
+	t.Run("healthy on expected status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		probe := NewHTTPProbe(server.URL, "GET", http.StatusOK, 1, time.Millisecond, time.Second)
+		status, err := probe.Check(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, HealthStatusHealthy, status)
+		assert.Equal(t, "http", probe.Name())
+	})
+
+	t.Run("unhealthy after exhausting retries", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		probe := NewHTTPProbe(server.URL, "GET", http.StatusOK, 2, time.Millisecond, time.Second)
+		status, err := probe.Check(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, HealthStatusUnhealthy, status)
+	})
+}
+
+func TestTCPProbe(t *testing.T) {
+	t.Run("healthy when something is listening", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer ln.Close()
+
+		probe := NewTCPProbe(ln.Addr().String(), time.Second)
+		status, err := probe.Check(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, HealthStatusHealthy, status)
+		assert.Equal(t, "tcp", probe.Name())
+	})
+
+	t.Run("unhealthy when nothing is listening", func(t *testing.T) {
+		probe := NewTCPProbe("127.0.0.1:1", 100*time.Millisecond)
+		status, err := probe.Check(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, HealthStatusUnhealthy, status)
+	})
+}
+
+func TestExecProbe(t *testing.T) {
+	t.Run("healthy on exit code 0", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockClient.On("ContainerExecCreate", mock.Anything, "container123", mock.Anything).
+			Return(types.IDResponse{ID: "exec1"}, nil)
+		mockClient.On("ContainerExecStart", mock.Anything, "exec1", mock.Anything).Return(nil)
+		mockClient.On("ContainerExecInspect", mock.Anything, "exec1").
+			Return(container.ExecInspect{ExecID: "exec1", Running: false, ExitCode: 0}, nil)
+
+		dm := NewRuntimeManager(mockClient)
+		probe := NewExecProbe(dm, "container123", []string{"pg_isready"}, time.Second)
+
+		status, err := probe.Check(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, HealthStatusHealthy, status)
+		assert.Equal(t, "exec", probe.Name())
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("unhealthy on non-zero exit code", func(t *testing.T) {
+		mockClient := new(MockDockerClient)
+		mockClient.On("ContainerExecCreate", mock.Anything, "container123", mock.Anything).
+			Return(types.IDResponse{ID: "exec1"}, nil)
+		mockClient.On("ContainerExecStart", mock.Anything, "exec1", mock.Anything).Return(nil)
+		mockClient.On("ContainerExecInspect", mock.Anything, "exec1").
+			Return(container.ExecInspect{ExecID: "exec1", Running: false, ExitCode: 1}, nil)
+
+		dm := NewRuntimeManager(mockClient)
+		probe := NewExecProbe(dm, "container123", []string{"pg_isready"}, time.Second)
+
+		status, err := probe.Check(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, HealthStatusUnhealthy, status)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestRuntimeManager_BuildProbes(t *testing.T) {
+	appConfig := &config.AppConfig{
+		Ports: config.Ports{Blue: 8081, Green: 8082},
+		HealthCheck: config.HealthCheck{
+			Type:     config.HealthCheckTypeTCP,
+			Timeout:  time.Second,
+			Retries:  1,
+			Interval: time.Millisecond,
+		},
+		AdditionalChecks: []config.HealthCheck{
+			{Type: config.HealthCheckTypeGRPC, GRPCService: "myapp.Health", Timeout: time.Second},
+			{Type: config.HealthCheckTypeExec, ExecCommand: []string{"true"}, Timeout: time.Second},
+		},
+	}
+
+	dm := NewRuntimeManager(new(MockDockerClient))
+	probes, err := dm.buildProbes("blue", "container123", appConfig)
+
+	assert.NoError(t, err)
+	assert.Len(t, probes, 3)
+	assert.Equal(t, "tcp", probes[0].Name())
+	assert.Equal(t, "grpc", probes[1].Name())
+	assert.Equal(t, "exec", probes[2].Name())
+}
+
+func TestRuntimeManager_BuildProbesUnknownType(t *testing.T) {
+	appConfig := &config.AppConfig{
+		Ports:       config.Ports{Blue: 8081, Green: 8082},
+		HealthCheck: config.HealthCheck{Type: "carrier-pigeon"},
+	}
+
+	dm := NewRuntimeManager(new(MockDockerClient))
+	_, err := dm.buildProbes("blue", "container123", appConfig)
+
+	assert.Error(t, err)
+}
+
+func TestRuntimeManager_BuildProbesEmptyHealthCheckContributesNoProbe(t *testing.T) {
+	appConfig := &config.AppConfig{
+		Ports: config.Ports{Blue: 8081, Green: 8082},
+	}
+
+	dm := NewRuntimeManager(new(MockDockerClient))
+	probes, err := dm.buildProbes("blue", "container123", appConfig)
+
+	assert.NoError(t, err)
+	assert.Empty(t, probes)
+}
@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"dockswap/internal/config"
+	"dockswap/internal/docker/errdefs"
+)
+
+// hookEnv builds the DOCKSWAP_* environment every HookStep runs with,
+// whether on the host (appended to the inherited environment) or inside the
+// container (passed as the exec's Env).
+func hookEnv(appName, color, image, containerID string) []string {
+	return []string{
+		"DOCKSWAP_APP=" + appName,
+		"DOCKSWAP_COLOR=" + color,
+		"DOCKSWAP_IMAGE=" + image,
+		"DOCKSWAP_CONTAINER_ID=" + containerID,
+	}
+}
+
+// runHookSteps runs every step in steps in order, tagging failures with
+// phase for the caller's error message. A step's non-zero exit stops the
+// phase immediately (later steps don't run) unless that step sets
+// ContinueOnError, in which case the failure is logged and the phase
+// continues. containerID is required for ContainerExec steps and may be
+// empty otherwise (e.g. PreStart, which runs before a container exists).
+func (dap *DockerActionProvider) runHookSteps(ctx context.Context, phase string, steps []config.HookStep, appName, color, image, containerID string) error {
+	env := hookEnv(appName, color, image, containerID)
+	log := dap.log.With("app", appName, "color", color)
+
+	for i, step := range steps {
+		stepCtx := ctx
+		var cancel context.CancelFunc
+		if step.Timeout > 0 {
+			stepCtx, cancel = context.WithTimeout(ctx, step.Timeout)
+		}
+
+		err := dap.runHookStep(stepCtx, step, env, containerID)
+		if cancel != nil {
+			cancel()
+		}
+
+		if err == nil {
+			continue
+		}
+
+		if step.ContinueOnError {
+			log.Error("%s hook step %d failed, continuing (continue_on_error): %v", phase, i, err)
+			continue
+		}
+
+		return fmt.Errorf("%s hook step %d failed: %w", phase, i, err)
+	}
+
+	return nil
+}
+
+// runHookStep dispatches a single HookStep to either the host (Exec) or the
+// container (ContainerExec), per config.validateHookSteps's guarantee that
+// exactly one of the two is set.
+func (dap *DockerActionProvider) runHookStep(ctx context.Context, step config.HookStep, env []string, containerID string) error {
+	if step.Exec != nil {
+		return runExecStep(ctx, step.Exec, env)
+	}
+	return dap.runContainerExecStep(ctx, step.ContainerExec, env, containerID)
+}
+
+// runExecStep runs an ExecStep as a host child process, capturing
+// stdout/stderr so they can be folded into the returned error on failure.
+func runExecStep(ctx context.Context, step *config.ExecStep, env []string) error {
+	cmd := exec.CommandContext(ctx, step.Command, step.Args...)
+	cmd.Env = append(cmd.Environ(), env...)
+
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("exec %q: %w (stderr: %s)", step.Command, err, errBuf.String())
+	}
+	return nil
+}
+
+// runContainerExecStep runs a ContainerExecStep inside containerID via
+// ContainerExecCreate/Start/Inspect, the same create/start/poll-exit-code
+// pattern ExecProbe uses - runtime.Client has no call that captures exec
+// output, so only the exit code is observed.
+func (dap *DockerActionProvider) runContainerExecStep(ctx context.Context, step *config.ContainerExecStep, env []string, containerID string) error {
+	if containerID == "" {
+		return fmt.Errorf("container_exec hook requires a running container")
+	}
+
+	created, err := dap.dockerManager.client.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          step.Command,
+		Env:          env,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("exec create failed: %w", errdefs.FromDockerError(err))
+	}
+
+	if err := dap.dockerManager.client.ContainerExecStart(ctx, created.ID, container.ExecStartOptions{}); err != nil {
+		return fmt.Errorf("exec start failed: %w", errdefs.FromDockerError(err))
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		inspect, err := dap.dockerManager.client.ContainerExecInspect(ctx, created.ID)
+		if err != nil {
+			return fmt.Errorf("exec inspect failed: %w", errdefs.FromDockerError(err))
+		}
+
+		if !inspect.Running {
+			if inspect.ExitCode == 0 {
+				return nil
+			}
+			return fmt.Errorf("command exited with code %d", inspect.ExitCode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
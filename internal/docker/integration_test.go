@@ -0,0 +1,137 @@
+//go:build integration
+
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"dockswap/internal/config"
+	"dockswap/internal/runtime"
+)
+
+// This suite drives RuntimeManager against a real Docker daemon instead of
+// MockDockerClient, so it catches the container-config translation
+// (labels, port/volume binds, env vars) that a mock can't validate. It's
+// gated behind -tags=integration because it needs Docker available and
+// takes real wall-clock time to pull images and poll health checks.
+
+// terminateContainerOnEnd registers t.Cleanup to remove c even if the test
+// fails before reaching its own cleanup, so a crashed assertion doesn't
+// leak a container on the test host.
+func terminateContainerOnEnd(t *testing.T, ctx context.Context, c testcontainers.Container) {
+	t.Helper()
+	t.Cleanup(func() {
+		if err := c.Terminate(ctx); err != nil {
+			t.Logf("failed to terminate container %s: %v", c.GetContainerID(), err)
+		}
+	})
+}
+
+// newIntegrationManager connects RuntimeManager to the real Docker daemon
+// from the environment (DOCKER_HOST or the default socket), the same way
+// the `dockswap` binary does via runtime.NewDockerClient.
+func newIntegrationManager(t *testing.T) *RuntimeManager {
+	t.Helper()
+	cli, err := runtime.NewDockerClient(runtime.Endpoint{})
+	require.NoError(t, err, "docker daemon must be reachable to run -tags=integration tests")
+	t.Cleanup(func() { _ = cli.Close() })
+	return NewRuntimeManager(cli)
+}
+
+// integrationAppConfig builds a minimal AppConfig for a single-color HTTP
+// smoke test: an exposed port, a fast-polling HTTP health check against
+// "/", and no resource limits or volumes to keep CreateContainer simple.
+func integrationAppConfig(appName string, bluePort, greenPort, exposePort int) *config.AppConfig {
+	return &config.AppConfig{
+		Name: appName,
+		Docker: config.Docker{
+			RestartPolicy: "no",
+			ExposePort:    exposePort,
+		},
+		Ports: config.Ports{Blue: bluePort, Green: greenPort},
+		HealthCheck: config.HealthCheck{
+			Type:             config.HealthCheckTypeHTTP,
+			Endpoint:         "/",
+			Method:           "GET",
+			Timeout:          2 * time.Second,
+			Interval:         500 * time.Millisecond,
+			Retries:          20,
+			SuccessThreshold: 2,
+			ExpectedStatus:   200,
+		},
+		Deployment: config.Deployment{StopTimeout: 5 * time.Second},
+	}
+}
+
+func TestIntegration_CreateStartStopContainer(t *testing.T) {
+	ctx := context.Background()
+
+	// A throwaway nginx started via testcontainers-go confirms the daemon
+	// is reachable and the image is pulled before RuntimeManager takes over.
+	probe, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "nginx:1.25-alpine",
+			ExposedPorts: []string{"80/tcp"},
+			WaitingFor:   wait.ForHTTP("/").WithPort("80/tcp"),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, probe)
+
+	dm := newIntegrationManager(t)
+	appConfig := integrationAppConfig("dockswap-it-nginx", 18080, 18081, 80)
+
+	info, err := dm.CreateContainer(ctx, appConfig.Name, "blue", "nginx:1.25-alpine", appConfig)
+	require.NoError(t, err)
+	defer dm.RemoveContainer(ctx, appConfig.Name, "blue", true)
+
+	require.NoError(t, dm.StartContainer(ctx, info.ID))
+	require.NoError(t, dm.WaitForHealthy(ctx, appConfig.Name, "blue", appConfig, 20*time.Second))
+
+	got, err := dm.GetContainerInfo(ctx, appConfig.Name, "blue")
+	require.NoError(t, err)
+	assert.Equal(t, "running", got.State)
+	assert.Equal(t, "nginx:1.25-alpine", got.Image)
+
+	require.NoError(t, dm.StopContainer(ctx, appConfig.Name, "blue", appConfig.Deployment.StopTimeout))
+}
+
+func TestIntegration_BlueGreenSwap(t *testing.T) {
+	ctx := context.Background()
+	dm := newIntegrationManager(t)
+	appConfig := integrationAppConfig("dockswap-it-swap", 18090, 18091, 80)
+
+	// blue: nginx
+	blueInfo, err := dm.CreateContainer(ctx, appConfig.Name, "blue", "nginx:1.25-alpine", appConfig)
+	require.NoError(t, err)
+	defer dm.RemoveContainer(ctx, appConfig.Name, "blue", true)
+	require.NoError(t, dm.StartContainer(ctx, blueInfo.ID))
+	require.NoError(t, dm.WaitForHealthy(ctx, appConfig.Name, "blue", appConfig, 20*time.Second))
+
+	// green: a tiny health-endpoint image that answers 200 on any path.
+	greenInfo, err := dm.CreateContainer(ctx, appConfig.Name, "green", "traefik/whoami:v1.10", appConfig)
+	require.NoError(t, err)
+	defer dm.RemoveContainer(ctx, appConfig.Name, "green", true)
+	require.NoError(t, dm.StartContainer(ctx, greenInfo.ID))
+	require.NoError(t, dm.WaitForHealthy(ctx, appConfig.Name, "green", appConfig, 20*time.Second))
+
+	// Cut over: stop and remove blue now that green is confirmed healthy.
+	require.NoError(t, dm.StopContainer(ctx, appConfig.Name, "blue", appConfig.Deployment.StopTimeout))
+	require.NoError(t, dm.RemoveContainer(ctx, appConfig.Name, "blue", true))
+
+	exists, err := dm.ContainerExists(ctx, appConfig.Name, "blue")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	greenExists, err := dm.ContainerExists(ctx, appConfig.Name, "green")
+	require.NoError(t, err)
+	assert.True(t, greenExists)
+}
@@ -15,7 +15,7 @@ type NetworkInfo struct {
 	Scope  string
 }
 
-func (dm *DockerManager) CreateNetwork(ctx context.Context, networkName string) (*NetworkInfo, error) {
+func (dm *RuntimeManager) CreateNetwork(ctx context.Context, networkName string) (*NetworkInfo, error) {
 	// Check if network already exists
 	exists, existingNetwork, err := dm.NetworkExists(ctx, networkName)
 	if err != nil {
@@ -50,7 +50,7 @@ func (dm *DockerManager) CreateNetwork(ctx context.Context, networkName string)
 	}, nil
 }
 
-func (dm *DockerManager) NetworkExists(ctx context.Context, networkName string) (bool, *NetworkInfo, error) {
+func (dm *RuntimeManager) NetworkExists(ctx context.Context, networkName string) (bool, *NetworkInfo, error) {
 	filterArgs := filters.NewArgs()
 	filterArgs.Add("name", networkName)
 
@@ -75,7 +75,7 @@ func (dm *DockerManager) NetworkExists(ctx context.Context, networkName string)
 	return false, nil, nil
 }
 
-func (dm *DockerManager) ConnectContainerToNetwork(ctx context.Context, networkName, containerID string) error {
+func (dm *RuntimeManager) ConnectContainerToNetwork(ctx context.Context, networkName, containerID string) error {
 	// Check if network exists
 	exists, networkInfo, err := dm.NetworkExists(ctx, networkName)
 	if err != nil {
@@ -95,7 +95,7 @@ func (dm *DockerManager) ConnectContainerToNetwork(ctx context.Context, networkN
 	return nil
 }
 
-func (dm *DockerManager) EnsureNetwork(ctx context.Context, networkName string) (*NetworkInfo, error) {
+func (dm *RuntimeManager) EnsureNetwork(ctx context.Context, networkName string) (*NetworkInfo, error) {
 	if networkName == "" {
 		return nil, nil // No network configuration
 	}
@@ -104,7 +104,7 @@ func (dm *DockerManager) EnsureNetwork(ctx context.Context, networkName string)
 	return dm.CreateNetwork(ctx, networkName)
 }
 
-func (dm *DockerManager) ListNetworks(ctx context.Context) ([]*NetworkInfo, error) {
+func (dm *RuntimeManager) ListNetworks(ctx context.Context) ([]*NetworkInfo, error) {
 	networks, err := dm.client.NetworkList(ctx, network.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list networks: %w", err)
@@ -123,7 +123,7 @@ func (dm *DockerManager) ListNetworks(ctx context.Context) ([]*NetworkInfo, erro
 	return result, nil
 }
 
-func (dm *DockerManager) ListDockswapNetworks(ctx context.Context) ([]*NetworkInfo, error) {
+func (dm *RuntimeManager) ListDockswapNetworks(ctx context.Context) ([]*NetworkInfo, error) {
 	filterArgs := filters.NewArgs()
 	filterArgs.Add("label", "dockswap.managed=true")
 
@@ -0,0 +1,129 @@
+package docker
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"dockswap/internal/config"
+	"dockswap/internal/logger"
+	"dockswap/internal/state"
+)
+
+// eventStatus maps a decoded ContainerEvent's Action to the current_state
+// status it should record, and whether the event is one StateReconciler
+// cares about at all. health_status events arrive as a single
+// "health_status: healthy"/"health_status: unhealthy" action string rather
+// than a separate field.
+func eventStatus(action string) (status string, ok bool) {
+	switch {
+	case action == "die" || action == "oom":
+		return "crashed", true
+	case action == "start":
+		return "running", true
+	case action == "health_status: unhealthy":
+		return "unhealthy", true
+	case action == "health_status: healthy":
+		return "healthy", true
+	default:
+		return "", false
+	}
+}
+
+// StateReconciler drives internal/state's deployment_events and
+// current_state tables directly off the Docker event stream, so an
+// unexpected container exit or health flip is recorded the moment it
+// happens instead of waiting for the next poll from runDeploymentLoop or a
+// CLI status check.
+type StateReconciler struct {
+	db      *sql.DB
+	manager *RuntimeManager
+	configs map[string]*config.AppConfig
+	log     logger.Logger
+}
+
+// NewStateReconciler builds a StateReconciler over configs, the set of apps
+// Resync walks to heal state missed while the event stream was down.
+func NewStateReconciler(db *sql.DB, manager *RuntimeManager, configs map[string]*config.AppConfig) *StateReconciler {
+	return &StateReconciler{
+		db:      db,
+		manager: manager,
+		configs: configs,
+		log:     logger.New(logger.LevelInfo),
+	}
+}
+
+// SetLogger replaces r's logger, e.g. with one tagged for the watcher
+// goroutine it runs in.
+func (r *StateReconciler) SetLogger(log logger.Logger) {
+	r.log = log
+}
+
+// Resync lists every configured app's containers and reconciles
+// current_state.status against what's actually running, healing drift that
+// accumulated while the event stream was disconnected. It's meant to be
+// wired into an EventWatcher via SetResync so it runs on first connect and
+// every reconnect; errors are logged and otherwise ignored since a failed
+// resync just leaves that app's state as it was until the next successful
+// one.
+func (r *StateReconciler) Resync(ctx context.Context) {
+	for appName := range r.configs {
+		cs, err := state.GetCurrentState(r.db, appName)
+		if err != nil {
+			r.log.Error("reconciler: resync: failed to load current state for %s: %v", appName, err)
+			continue
+		}
+
+		containers, err := r.manager.ListAppContainers(ctx, appName)
+		if err != nil {
+			r.log.Error("reconciler: resync: failed to list containers for %s: %v", appName, err)
+			continue
+		}
+
+		status := "stopped"
+		for _, c := range containers {
+			if strings.HasSuffix(c.Name, "-"+cs.ActiveColor) && c.State == "running" {
+				status = "running"
+				break
+			}
+		}
+
+		if status == cs.Status {
+			continue
+		}
+		if err := state.UpsertCurrentState(r.db, appName, cs.DeploymentID, cs.ActiveColor, cs.Image, status); err != nil {
+			r.log.Error("reconciler: resync: failed to update current state for %s: %v", appName, err)
+		}
+	}
+}
+
+// Handle reacts to one decoded container lifecycle event, recording it as a
+// deployment_events row if it's one StateReconciler cares about and, if
+// it's about the app's currently active color, updating
+// current_state.status to match. Events about the inactive color (e.g. a
+// health check still settling on the about-to-become-active container
+// mid-deployment) are recorded but don't move current_state, which always
+// reflects the active color. Wire it up via DispatchEvents.
+func (r *StateReconciler) Handle(evt ContainerEvent) {
+	status, ok := eventStatus(evt.Action)
+	if !ok {
+		return
+	}
+
+	cs, err := state.GetCurrentState(r.db, evt.AppName)
+	if err != nil {
+		r.log.Error("reconciler: failed to load current state for %s: %v", evt.AppName, err)
+		return
+	}
+
+	if _, err := state.InsertDeploymentEvent(r.db, cs.DeploymentID, evt.AppName, evt.Action, "{}", nil); err != nil {
+		r.log.Error("reconciler: failed to record event for %s: %v", evt.AppName, err)
+	}
+
+	if evt.Color != cs.ActiveColor {
+		return
+	}
+	if err := state.UpsertCurrentState(r.db, evt.AppName, cs.DeploymentID, cs.ActiveColor, cs.Image, status); err != nil {
+		r.log.Error("reconciler: failed to update current state for %s: %v", evt.AppName, err)
+	}
+}
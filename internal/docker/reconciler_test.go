@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"dockswap/internal/config"
+	"dockswap/internal/state"
+)
+
+func newTestReconciler(t *testing.T, configs map[string]*config.AppConfig) (*StateReconciler, *MockDockerClient) {
+	t.Helper()
+	db, err := state.OpenAndMigrate(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mockClient := new(MockDockerClient)
+	manager := NewRuntimeManager(mockClient)
+	return NewStateReconciler(db, manager, configs), mockClient
+}
+
+func TestEventStatus(t *testing.T) {
+	tests := []struct {
+		action     string
+		wantStatus string
+		wantOK     bool
+	}{
+		{"die", "crashed", true},
+		{"oom", "crashed", true},
+		{"start", "running", true},
+		{"health_status: healthy", "healthy", true},
+		{"health_status: unhealthy", "unhealthy", true},
+		{"destroy", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		status, ok := eventStatus(tt.action)
+		assert.Equal(t, tt.wantStatus, status, "action %q", tt.action)
+		assert.Equal(t, tt.wantOK, ok, "action %q", tt.action)
+	}
+}
+
+func TestStateReconciler_Handle(t *testing.T) {
+	t.Run("active color die marks crashed and records event", func(t *testing.T) {
+		r, _ := newTestReconciler(t, nil)
+
+		require.NoError(t, state.UpsertCurrentState(r.db, "test-app", 1, "blue", "nginx:1.21", "running"))
+
+		r.Handle(ContainerEvent{AppName: "test-app", Color: "blue", Action: "die"})
+
+		cs, err := state.GetCurrentState(r.db, "test-app")
+		require.NoError(t, err)
+		assert.Equal(t, "crashed", cs.Status)
+
+		events, err := state.GetDeploymentEvents(r.db, 1)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "die", events[0].EventType)
+	})
+
+	t.Run("inactive color event is recorded but doesn't move current_state", func(t *testing.T) {
+		r, _ := newTestReconciler(t, nil)
+
+		require.NoError(t, state.UpsertCurrentState(r.db, "test-app", 1, "blue", "nginx:1.21", "running"))
+
+		r.Handle(ContainerEvent{AppName: "test-app", Color: "green", Action: "health_status: unhealthy"})
+
+		cs, err := state.GetCurrentState(r.db, "test-app")
+		require.NoError(t, err)
+		assert.Equal(t, "running", cs.Status)
+
+		events, err := state.GetDeploymentEvents(r.db, 1)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "health_status: unhealthy", events[0].EventType)
+	})
+
+	t.Run("unrecognized action is a no-op", func(t *testing.T) {
+		r, _ := newTestReconciler(t, nil)
+
+		require.NoError(t, state.UpsertCurrentState(r.db, "test-app", 1, "blue", "nginx:1.21", "running"))
+
+		r.Handle(ContainerEvent{AppName: "test-app", Color: "blue", Action: "destroy"})
+
+		cs, err := state.GetCurrentState(r.db, "test-app")
+		require.NoError(t, err)
+		assert.Equal(t, "running", cs.Status)
+
+		events, err := state.GetDeploymentEvents(r.db, 1)
+		require.NoError(t, err)
+		assert.Len(t, events, 0)
+	})
+}
+
+func TestStateReconciler_Resync(t *testing.T) {
+	t.Run("heals status when active color container is running", func(t *testing.T) {
+		r, mockClient := newTestReconciler(t, map[string]*config.AppConfig{
+			"test-app": createTestAppConfig(),
+		})
+
+		require.NoError(t, state.UpsertCurrentState(r.db, "test-app", 1, "blue", "nginx:1.21", "crashed"))
+
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+			{ID: "container123", Names: []string{"/test-app-blue"}, State: "running"},
+		}, nil)
+
+		r.Resync(context.Background())
+
+		cs, err := state.GetCurrentState(r.db, "test-app")
+		require.NoError(t, err)
+		assert.Equal(t, "running", cs.Status)
+	})
+
+	t.Run("marks stopped when no container matches the active color", func(t *testing.T) {
+		r, mockClient := newTestReconciler(t, map[string]*config.AppConfig{
+			"test-app": createTestAppConfig(),
+		})
+
+		require.NoError(t, state.UpsertCurrentState(r.db, "test-app", 1, "blue", "nginx:1.21", "running"))
+
+		mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{}, nil)
+
+		r.Resync(context.Background())
+
+		cs, err := state.GetCurrentState(r.db, "test-app")
+		require.NoError(t, err)
+		assert.Equal(t, "stopped", cs.Status)
+	})
+}
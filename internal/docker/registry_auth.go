@@ -0,0 +1,227 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/registry"
+
+	"dockswap/internal/config"
+)
+
+// RegistryAuthResolver resolves the credentials RuntimeManager.PullImage
+// should send for imageRef, so PullImage itself doesn't need to know
+// whether they come from config.AppConfig.Docker.Registry, a user's
+// ~/.docker/config.json, or a credential helper. Tests inject a fake to
+// avoid touching the real Docker config file.
+type RegistryAuthResolver interface {
+	// ResolveAuth returns the base64-encoded AuthConfig JSON to send as
+	// image.PullOptions.RegistryAuth for imageRef, or "" for an anonymous
+	// pull.
+	ResolveAuth(ctx context.Context, imageRef string, appConfig *config.AppConfig) (string, error)
+}
+
+// DockerConfigAuthResolver resolves registry credentials the way the Docker
+// CLI does: appConfig.Docker.Registry (username/password, a credential
+// helper, or a pre-fetched identity token) takes precedence over anything
+// on disk; failing that, ~/.docker/config.json's per-registry credHelpers,
+// its top-level credsStore default, and finally its static "auths" entries
+// are tried in that order, keyed by imageRef's registry host.
+type DockerConfigAuthResolver struct {
+	// ConfigPath overrides the location of the Docker config file; empty
+	// defaults to ~/.docker/config.json.
+	ConfigPath string
+}
+
+func NewDockerConfigAuthResolver() *DockerConfigAuthResolver {
+	return &DockerConfigAuthResolver{}
+}
+
+func (r *DockerConfigAuthResolver) ResolveAuth(ctx context.Context, imageRef string, appConfig *config.AppConfig) (string, error) {
+	host := registryHost(imageRef)
+
+	if appConfig != nil {
+		override := appConfig.Docker.Registry
+		switch {
+		case override.CredentialHelper != "":
+			auth, err := runCredentialHelper(ctx, override.CredentialHelper, host)
+			if err != nil {
+				return "", fmt.Errorf("registry.credential_helper %s failed for %s: %w", override.CredentialHelper, host, err)
+			}
+			return encodeAuthConfig(auth)
+		case override.Username != "" || override.Password != "" || override.IdentityToken != "":
+			return encodeAuthConfig(registry.AuthConfig{
+				Username:      override.Username,
+				Password:      override.Password,
+				IdentityToken: override.IdentityToken,
+				ServerAddress: host,
+			})
+		}
+	}
+
+	dockerConfig, err := r.loadDockerConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load docker config: %w", err)
+	}
+	if dockerConfig == nil {
+		return "", nil
+	}
+
+	helper := dockerConfig.CredHelpers[host]
+	if helper == "" {
+		helper = dockerConfig.CredsStore
+	}
+	if helper != "" {
+		auth, err := runCredentialHelper(ctx, helper, host)
+		if err != nil {
+			return "", fmt.Errorf("credential helper %s failed for %s: %w", helper, host, err)
+		}
+		return encodeAuthConfig(auth)
+	}
+
+	if entry, ok := dockerConfig.Auths[host]; ok {
+		auth, err := decodeAuthEntry(entry)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode auth for %s: %w", host, err)
+		}
+		auth.ServerAddress = host
+		return encodeAuthConfig(auth)
+	}
+
+	return "", nil
+}
+
+// dockerConfigFile is the handful of ~/.docker/config.json fields relevant
+// to resolving pull credentials; it ignores everything else (aliases,
+// plugins, UI settings, ...) in the real file.
+type dockerConfigFile struct {
+	Auths       map[string]dockerConfigAuthEntry `json:"auths"`
+	CredsStore  string                           `json:"credsStore"`
+	CredHelpers map[string]string                `json:"credHelpers"`
+}
+
+type dockerConfigAuthEntry struct {
+	Auth          string `json:"auth"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+func (r *DockerConfigAuthResolver) loadDockerConfig() (*dockerConfigFile, error) {
+	path := r.ConfigPath
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine home directory: %w", err)
+		}
+		path = filepath.Join(home, ".docker", "config.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg dockerConfigFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// decodeAuthEntry decodes a static "auths" entry, whose Auth field is a
+// base64 "username:password" pair per the Docker config.json format.
+func decodeAuthEntry(entry dockerConfigAuthEntry) (registry.AuthConfig, error) {
+	if entry.Auth == "" {
+		return registry.AuthConfig{IdentityToken: entry.IdentityToken}, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if err != nil {
+		return registry.AuthConfig{}, fmt.Errorf("malformed auth: %w", err)
+	}
+
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return registry.AuthConfig{}, fmt.Errorf("malformed auth: expected \"username:password\"")
+	}
+
+	return registry.AuthConfig{
+		Username:      username,
+		Password:      password,
+		IdentityToken: entry.IdentityToken,
+	}, nil
+}
+
+// credentialHelperOutput is the JSON a docker-credential-<helper> binary
+// writes to stdout in response to a "get" request.
+type credentialHelperOutput struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// runCredentialHelper shells out to docker-credential-<helper>, following
+// Docker's credential helper protocol: the registry host is written to the
+// process's stdin, and the credentials come back as JSON on stdout.
+func runCredentialHelper(ctx context.Context, helper, host string) (registry.AuthConfig, error) {
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(host)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return registry.AuthConfig{}, fmt.Errorf("%w: %s", err, stdout.String())
+	}
+
+	var out credentialHelperOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return registry.AuthConfig{}, fmt.Errorf("invalid response from docker-credential-%s: %w", helper, err)
+	}
+
+	if out.Username == "<token>" {
+		return registry.AuthConfig{IdentityToken: out.Secret, ServerAddress: host}, nil
+	}
+	return registry.AuthConfig{Username: out.Username, Password: out.Secret, ServerAddress: host}, nil
+}
+
+// encodeAuthConfig base64-encodes auth the way the Docker API expects for
+// the X-Registry-Auth header / image.PullOptions.RegistryAuth.
+func encodeAuthConfig(auth registry.AuthConfig) (string, error) {
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode registry auth: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// registryHost extracts the registry host image.PullOptions.RegistryAuth
+// should be keyed by, mirroring how the Docker CLI splits a reference's
+// registry from its repository path. Docker Hub images (no host segment)
+// use its well-known config.json key.
+func registryHost(imageRef string) string {
+	ref := imageRef
+	if at := strings.IndexByte(ref, '@'); at != -1 {
+		ref = ref[:at]
+	}
+
+	if slash := strings.IndexByte(ref, '/'); slash != -1 {
+		first := ref[:slash]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			return first
+		}
+	}
+
+	return "https://index.docker.io/v1/"
+}
@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/registry"
+	"github.com/stretchr/testify/assert"
+
+	"dockswap/internal/config"
+)
+
+func TestRegistryHost(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"myapp:latest", "https://index.docker.io/v1/"},
+		{"library/myapp:latest", "https://index.docker.io/v1/"},
+		{"ghcr.io/acme/myapp:latest", "ghcr.io"},
+		{"localhost:5000/myapp:latest", "localhost:5000"},
+		{"registry.internal/acme/myapp@sha256:abc123", "registry.internal"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, registryHost(tt.ref), tt.ref)
+	}
+}
+
+func writeDockerConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write test docker config: %v", err)
+	}
+	return path
+}
+
+func decodeResolvedAuth(t *testing.T, encoded string) registry.AuthConfig {
+	t.Helper()
+	if encoded == "" {
+		t.Fatal("expected a non-empty resolved auth")
+	}
+	data, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("resolved auth is not valid base64: %v", err)
+	}
+
+	var auth registry.AuthConfig
+	if err := json.Unmarshal(data, &auth); err != nil {
+		t.Fatalf("resolved auth is not valid JSON: %v", err)
+	}
+	return auth
+}
+
+func TestDockerConfigAuthResolver_AppOverride(t *testing.T) {
+	resolver := &DockerConfigAuthResolver{ConfigPath: writeDockerConfig(t, `{}`)}
+	appConfig := &config.AppConfig{
+		Docker: config.Docker{
+			Registry: config.RegistryAuth{
+				Username: "alice",
+				Password: "s3cret",
+			},
+		},
+	}
+
+	encoded, err := resolver.ResolveAuth(context.Background(), "ghcr.io/acme/myapp:latest", appConfig)
+	if err != nil {
+		t.Fatalf("ResolveAuth() unexpected error = %v", err)
+	}
+
+	auth := decodeResolvedAuth(t, encoded)
+	assert.Equal(t, "alice", auth.Username)
+	assert.Equal(t, "s3cret", auth.Password)
+	assert.Equal(t, "ghcr.io", auth.ServerAddress)
+}
+
+func TestDockerConfigAuthResolver_StaticAuths(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("bob:hunter2"))
+	path := writeDockerConfig(t, `{"auths":{"ghcr.io":{"auth":"`+auth+`"}}}`)
+	resolver := &DockerConfigAuthResolver{ConfigPath: path}
+
+	encoded, err := resolver.ResolveAuth(context.Background(), "ghcr.io/acme/myapp:latest", nil)
+	if err != nil {
+		t.Fatalf("ResolveAuth() unexpected error = %v", err)
+	}
+
+	resolved := decodeResolvedAuth(t, encoded)
+	assert.Equal(t, "bob", resolved.Username)
+	assert.Equal(t, "hunter2", resolved.Password)
+}
+
+func TestDockerConfigAuthResolver_NoMatch(t *testing.T) {
+	path := writeDockerConfig(t, `{"auths":{"other.example.com":{"auth":"x"}}}`)
+	resolver := &DockerConfigAuthResolver{ConfigPath: path}
+
+	encoded, err := resolver.ResolveAuth(context.Background(), "ghcr.io/acme/myapp:latest", nil)
+	if err != nil {
+		t.Fatalf("ResolveAuth() unexpected error = %v", err)
+	}
+	assert.Empty(t, encoded)
+}
+
+func TestDockerConfigAuthResolver_MissingConfigFile(t *testing.T) {
+	resolver := &DockerConfigAuthResolver{ConfigPath: filepath.Join(t.TempDir(), "missing.json")}
+
+	encoded, err := resolver.ResolveAuth(context.Background(), "ghcr.io/acme/myapp:latest", nil)
+	if err != nil {
+		t.Fatalf("ResolveAuth() unexpected error = %v", err)
+	}
+	assert.Empty(t, encoded)
+}
+
+func TestDecodeAuthEntry_Malformed(t *testing.T) {
+	_, err := decodeAuthEntry(dockerConfigAuthEntry{Auth: "not-valid-base64!!"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+// Package errs collects the sentinel errors shared across the deployment
+// and workspace packages, so callers can match on errors.Is(err,
+// errs.ErrSomething) instead of string-comparing err.Error() - the same
+// idea as config.ValidationError, one level up from a single package.
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors identifying the kind of failure an *Error wraps.
+var (
+	ErrInvalidTransition = errors.New("invalid state transition")
+	ErrContainerStart    = errors.New("container failed to start")
+	ErrHealthCheck       = errors.New("health check failed")
+	ErrCaddyUpdate       = errors.New("caddy update failed")
+	ErrDrainTimeout      = errors.New("drain timed out")
+	ErrDBPersist         = errors.New("database persistence failed")
+	ErrConfigConflict    = errors.New("configuration conflict")
+	ErrHookFailed        = errors.New("lifecycle hook failed")
+)
+
+// Error is the common shape for every error ProcessEvent and the workspace
+// validators return: Sentinel is one of this package's vars (what Is
+// matches against), Message is the operation-specific detail, and Err is
+// the underlying error it wraps, if any. Error() deliberately omits the
+// sentinel's own text and any "error " prefix so wrapping it again at the
+// CLI layer doesn't stutter as "Error: error ...".
+type Error struct {
+	Sentinel error
+	Message  string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+// Unwrap exposes the underlying error (if any) so errors.Is/As can keep
+// walking past this one to whatever it wrapped.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is this error's sentinel, letting
+// errors.Is(err, errs.ErrInvalidTransition) succeed without Sentinel being
+// part of the Unwrap chain.
+func (e *Error) Is(target error) bool {
+	return target == e.Sentinel
+}
+
+// Wrap builds an *Error tagged with sentinel, formatting message the same
+// way fmt.Errorf would. err may be nil when there's no underlying error to
+// carry, only the sentinel and a message.
+func Wrap(sentinel error, err error, format string, args ...interface{}) *Error {
+	return &Error{Sentinel: sentinel, Message: fmt.Sprintf(format, args...), Err: err}
+}
@@ -0,0 +1,41 @@
+package errs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorIs(t *testing.T) {
+	err := Wrap(ErrInvalidTransition, nil, "invalid event %s for state %s", "deploy", "starting")
+
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("errors.Is(err, ErrInvalidTransition) = false, want true")
+	}
+	if errors.Is(err, ErrContainerStart) {
+		t.Errorf("errors.Is(err, ErrContainerStart) = true, want false")
+	}
+}
+
+func TestErrorUnwrap(t *testing.T) {
+	underlying := errors.New("connection refused")
+	err := Wrap(ErrDBPersist, underlying, "failed to insert deployment")
+
+	if !errors.Is(err, ErrDBPersist) {
+		t.Errorf("errors.Is(err, ErrDBPersist) = false, want true")
+	}
+	if !errors.Is(err, underlying) {
+		t.Errorf("errors.Is(err, underlying) = false, want true")
+	}
+	if errors.Unwrap(err) != underlying {
+		t.Errorf("errors.Unwrap(err) = %v, want %v", errors.Unwrap(err), underlying)
+	}
+}
+
+func TestErrorMessageHasNoStutterPrefix(t *testing.T) {
+	err := Wrap(ErrContainerStart, nil, "container failed to start")
+
+	want := "container failed to start"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
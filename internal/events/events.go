@@ -0,0 +1,87 @@
+// Package events models the deployment lifecycle as a stream of typed
+// Events, modeled on how Docker/Podman's daemon publishes container
+// lifecycle events to subscribers (the CLI's `events` command, webhooks,
+// journald). DeploymentStateMachine publishes one Event per state
+// transition; DockerActionProvider publishes around the container actions
+// it takes (start/stop/health), so external systems can react to a
+// deployment without polling GetAppState.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a single deployment lifecycle occurrence. FromState/ToState are
+// plain strings rather than deployment.DeploymentState so this package has
+// no dependency on the deployment package, which is itself a publisher.
+type Event struct {
+	// Kind classifies what happened, for subscribers that sort/filter
+	// rather than inspect FromState/ToState themselves. Empty for events
+	// that only describe a raw state transition without a specific Kind
+	// assigned - not every publisher sets one.
+	Kind      EventKind
+	AppName   string
+	Color     string
+	FromState string
+	ToState   string
+	Image     string
+	Timestamp time.Time
+	Error     error
+}
+
+// EventKind classifies an Event's cause.
+type EventKind string
+
+const (
+	KindDeploymentStarted    EventKind = "deployment_started"
+	KindDeploymentSucceeded  EventKind = "deployment_succeeded"
+	KindDeploymentFailed     EventKind = "deployment_failed"
+	KindDeploymentRolledBack EventKind = "deployment_rolled_back"
+	KindCaddyReloaded        EventKind = "caddy_reloaded"
+	KindPortConflictDetected EventKind = "port_conflict_detected"
+	// KindTrafficSwitched marks a completed blue/green cutover - handleSwitch
+	// publishes it once the new color is live, distinct from
+	// KindDeploymentSucceeded/RolledBack, which concern a deployment's own
+	// container lifecycle rather than which one traffic is routed to.
+	KindTrafficSwitched EventKind = "traffic_switched"
+)
+
+// Subscriber receives every Event a Bus publishes. Notify runs synchronously
+// on the publisher's goroutine, so implementations that do I/O (Webhook,
+// File) must hand off or keep it fast rather than block the deployment that
+// triggered the event.
+type Subscriber interface {
+	Notify(Event)
+}
+
+// Bus fans a published Event out to every subscribed Subscriber, in
+// subscription order.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []Subscriber
+}
+
+// NewBus creates an empty Bus ready to Subscribe and Publish on.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers s to receive every future Published Event.
+func (b *Bus) Subscribe(s Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, s)
+}
+
+// Publish notifies every current subscriber of e.
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	subs := make([]Subscriber, len(b.subscribers))
+	copy(subs, b.subscribers)
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		s.Notify(e)
+	}
+}
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingSubscriber struct {
+	received []Event
+}
+
+func (r *recordingSubscriber) Notify(e Event) {
+	r.received = append(r.received, e)
+}
+
+func TestBusPublishNotifiesAllSubscribers(t *testing.T) {
+	bus := NewBus()
+	sub1 := &recordingSubscriber{}
+	sub2 := &recordingSubscriber{}
+	bus.Subscribe(sub1)
+	bus.Subscribe(sub2)
+
+	bus.Publish(Event{AppName: "web", ToState: "stable"})
+
+	if len(sub1.received) != 1 || len(sub2.received) != 1 {
+		t.Fatalf("expected both subscribers notified, got %d and %d", len(sub1.received), len(sub2.received))
+	}
+	if sub1.received[0].AppName != "web" {
+		t.Errorf("expected event to carry AppName, got %+v", sub1.received[0])
+	}
+}
+
+func TestRingBufferWrapsAndReturnsChronologicalOrder(t *testing.T) {
+	ring := NewRingBuffer(3)
+	for i := 0; i < 5; i++ {
+		ring.Notify(Event{AppName: string(rune('a' + i))})
+	}
+
+	all := ring.All()
+	if len(all) != 3 {
+		t.Fatalf("expected ring buffer capped at 3, got %d", len(all))
+	}
+
+	expected := []string{"c", "d", "e"}
+	for i, e := range all {
+		if e.AppName != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], e.AppName)
+		}
+	}
+}
+
+func TestRingBufferSinceAndFilter(t *testing.T) {
+	ring := NewRingBuffer(10)
+	old := time.Now().Add(-time.Hour)
+	ring.Notify(Event{AppName: "web", Timestamp: old})
+	ring.Notify(Event{AppName: "api", Timestamp: time.Now()})
+
+	recent := ring.Since(old.Add(time.Minute))
+	if len(recent) != 1 || recent[0].AppName != "api" {
+		t.Errorf("expected only the recent event, got %+v", recent)
+	}
+
+	filtered := ring.Filter(func(e Event) bool { return e.AppName == "web" })
+	if len(filtered) != 1 || filtered[0].AppName != "web" {
+		t.Errorf("expected only web event, got %+v", filtered)
+	}
+}
+
+func TestFileSubscriberWritesOneLinePerEvent(t *testing.T) {
+	var buf bytes.Buffer
+	sub := NewFileSubscriber(&buf)
+
+	sub.Notify(Event{AppName: "web", Color: "blue", FromState: "stable", ToState: "starting"})
+	sub.Notify(Event{AppName: "web", Color: "blue", ToState: "failed", Error: errors.New("boom")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %v", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "state=stable->starting") {
+		t.Errorf("expected state transition in line, got: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "error=boom") {
+		t.Errorf("expected error in line, got: %s", lines[1])
+	}
+}
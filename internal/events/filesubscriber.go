@@ -0,0 +1,30 @@
+package events
+
+import (
+	"fmt"
+	"io"
+)
+
+// FileSubscriber writes one line per Event to output — a plain file, or a
+// journald/syslog connection from logger.NewFileSink/NewSyslogSink, the
+// same sinks the logger package exposes for log output.
+type FileSubscriber struct {
+	output io.Writer
+}
+
+// NewFileSubscriber creates a FileSubscriber writing to output.
+func NewFileSubscriber(output io.Writer) *FileSubscriber {
+	return &FileSubscriber{output: output}
+}
+
+func (f *FileSubscriber) Notify(e Event) {
+	line := fmt.Sprintf("%s app=%s color=%s state=%s->%s image=%s",
+		e.Timestamp.Format("2006-01-02T15:04:05Z07:00"), e.AppName, e.Color, e.FromState, e.ToState, e.Image)
+	if e.Kind != "" {
+		line += " kind=" + string(e.Kind)
+	}
+	if e.Error != nil {
+		line += " error=" + e.Error.Error()
+	}
+	fmt.Fprintln(f.output, line)
+}
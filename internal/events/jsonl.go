@@ -0,0 +1,58 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// jsonEvent is the on-disk/wire shape Event marshals to for JSONLSubscriber
+// and WebhookSubscriber: Error is flattened to a string since an error
+// value's unexported fields don't marshal through encoding/json.
+type jsonEvent struct {
+	Kind      EventKind `json:"kind,omitempty"`
+	AppName   string    `json:"app_name"`
+	Color     string    `json:"color,omitempty"`
+	FromState string    `json:"from_state,omitempty"`
+	ToState   string    `json:"to_state,omitempty"`
+	Image     string    `json:"image,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func toJSONEvent(e Event) jsonEvent {
+	je := jsonEvent{
+		Kind:      e.Kind,
+		AppName:   e.AppName,
+		Color:     e.Color,
+		FromState: e.FromState,
+		ToState:   e.ToState,
+		Image:     e.Image,
+		Timestamp: e.Timestamp,
+	}
+	if e.Error != nil {
+		je.Error = e.Error.Error()
+	}
+	return je
+}
+
+// JSONLSubscriber appends one JSON object per line (the "JSON Lines"
+// format) to output - e.g. a workspace's state/events.log - for machine
+// consumption, as opposed to FileSubscriber's human-readable line format.
+type JSONLSubscriber struct {
+	output io.Writer
+}
+
+// NewJSONLSubscriber creates a JSONLSubscriber writing to output.
+func NewJSONLSubscriber(output io.Writer) *JSONLSubscriber {
+	return &JSONLSubscriber{output: output}
+}
+
+func (j *JSONLSubscriber) Notify(e Event) {
+	data, err := json.Marshal(toJSONEvent(e))
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(j.output, string(data))
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLSubscriberWritesOneLinePerEvent(t *testing.T) {
+	var buf bytes.Buffer
+	sub := NewJSONLSubscriber(&buf)
+
+	ts := time.Now()
+	sub.Notify(Event{Kind: KindDeploymentSucceeded, AppName: "web", Color: "blue", ToState: "stable", Timestamp: ts})
+	sub.Notify(Event{Kind: KindDeploymentFailed, AppName: "web", ToState: "failed", Error: errors.New("boom"), Timestamp: ts})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %v", len(lines), lines)
+	}
+
+	var first jsonEvent
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("expected valid JSON, got error: %v, line: %s", err, lines[0])
+	}
+	if first.Kind != KindDeploymentSucceeded || first.AppName != "web" || first.ToState != "stable" {
+		t.Errorf("unexpected decoded event: %+v", first)
+	}
+
+	var second jsonEvent
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("expected valid JSON, got error: %v, line: %s", err, lines[1])
+	}
+	if second.Error != "boom" {
+		t.Errorf("expected error flattened to string, got %+v", second)
+	}
+}
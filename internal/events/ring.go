@@ -0,0 +1,73 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultRingSize is used by NewRingBuffer when size <= 0.
+const defaultRingSize = 256
+
+// RingBuffer is an in-memory Subscriber that retains the last size published
+// Events, queryable by the `dockswap events` CLI command via Since/Filter.
+// It is process-local: events published before the current process started
+// (or by another process) aren't visible here.
+type RingBuffer struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+// NewRingBuffer creates a RingBuffer retaining the last size Events. size <=
+// 0 falls back to defaultRingSize.
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = defaultRingSize
+	}
+	return &RingBuffer{events: make([]Event, size)}
+}
+
+func (r *RingBuffer) Notify(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// All returns every retained Event in chronological order.
+func (r *RingBuffer) All() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		out := make([]Event, r.next)
+		copy(out, r.events[:r.next])
+		return out
+	}
+
+	out := make([]Event, len(r.events))
+	copy(out, r.events[r.next:])
+	copy(out[len(r.events)-r.next:], r.events[:r.next])
+	return out
+}
+
+// Since returns every retained Event whose Timestamp is after t.
+func (r *RingBuffer) Since(t time.Time) []Event {
+	return r.Filter(func(e Event) bool { return e.Timestamp.After(t) })
+}
+
+// Filter returns every retained Event for which match returns true, e.g.
+// filtering by AppName.
+func (r *RingBuffer) Filter(match func(Event) bool) []Event {
+	var out []Event
+	for _, e := range r.All() {
+		if match(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// maxWebhookRetries bounds how many times WebhookSubscriber retries a
+// delivery that failed to reach the endpoint or got a 5xx back, mirroring
+// watcher.RegistryClient's backoff/jitter retry for registry polls.
+const maxWebhookRetries = 3
+
+// webhookRetryBase is the backoff before the first retry; each subsequent
+// retry doubles it, with up to 50% random jitter so many deployments firing
+// webhooks around the same time don't all retry a flaky endpoint in
+// lockstep. A var, not a const, so tests can shrink it.
+var webhookRetryBase = 250 * time.Millisecond
+
+// WebhookSubscriber POSTs a JSON-encoded Event to URL for every
+// notification, for CI/Slack/PagerDuty-style integrations. If Secret is
+// set, every request carries an X-Dockswap-Signature header
+// ("sha256=<hex hmac>") over the raw body, the same scheme GitHub/Stripe
+// webhooks use, so the receiver can verify the payload came from this
+// workspace.
+type WebhookSubscriber struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// NewWebhookSubscriber creates a WebhookSubscriber posting to url with a
+// 5-second request timeout and no HMAC signing. Set Secret afterward to
+// enable signing.
+func NewWebhookSubscriber(url string) *WebhookSubscriber {
+	return &WebhookSubscriber{
+		URL:    url,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Notify delivers e in its own goroutine so a slow or unreachable endpoint
+// never blocks the deployment that triggered it; delivery is retried with
+// backoff and ultimately swallowed since Notify has no error return to
+// report failures through.
+func (w *WebhookSubscriber) Notify(e Event) {
+	go w.deliver(e)
+}
+
+func (w *WebhookSubscriber) deliver(e Event) {
+	body, err := json.Marshal(toJSONEvent(e))
+	if err != nil {
+		return
+	}
+
+	for attempt := 0; attempt <= maxWebhookRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(webhookBackoff(attempt))
+		}
+
+		if retryable := w.deliverOnce(body); !retryable {
+			return
+		}
+	}
+}
+
+// deliverOnce issues a single delivery attempt, returning whether the
+// failure is worth a backed-off retry (a transport-level failure or a 5xx
+// response) as opposed to the endpoint having rejected the payload outright.
+func (w *WebhookSubscriber) deliverOnce(body []byte) (retryable bool) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		req.Header.Set("X-Dockswap-Signature", signPayload(w.Secret, body))
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return true
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// signPayload returns the "sha256=<hex>" signature a webhook receiver can
+// recompute from the raw body and secret to verify the request's origin.
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns webhookRetryBase*2^(attempt-1), plus up to 50%
+// random jitter.
+func webhookBackoff(attempt int) time.Duration {
+	backoff := webhookRetryBase * time.Duration(1<<uint(attempt-1))
+	return backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+}
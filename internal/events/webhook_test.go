@@ -0,0 +1,75 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSubscriberSignsPayload(t *testing.T) {
+	var gotBody []byte
+	var gotSig string
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get("X-Dockswap-Signature")
+		w.WriteHeader(http.StatusOK)
+		close(done)
+	}))
+	defer server.Close()
+
+	sub := NewWebhookSubscriber(server.URL)
+	sub.Secret = "s3cret"
+	sub.Notify(Event{AppName: "web", ToState: "stable"})
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for webhook delivery")
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(gotBody)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if gotSig != expected {
+		t.Errorf("expected signature %s, got %s", expected, gotSig)
+	}
+}
+
+func TestWebhookSubscriberRetriesOn5xx(t *testing.T) {
+	oldBase := webhookRetryBase
+	webhookRetryBase = time.Millisecond
+	defer func() { webhookRetryBase = oldBase }()
+
+	var attempts int32
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		if n < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		close(done)
+	}))
+	defer server.Close()
+
+	sub := NewWebhookSubscriber(server.URL)
+	sub.Notify(Event{AppName: "web", ToState: "stable"})
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for webhook to succeed after retries")
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
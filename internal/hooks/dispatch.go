@@ -0,0 +1,30 @@
+package hooks
+
+import (
+	"context"
+
+	"dockswap/internal/deployment"
+)
+
+// DispatchRunner routes a Hook to exec or webhook execution depending on
+// whether hook.URL is set, so a single Hooks phase can mix a migration
+// script with a notification webhook without dockswap choosing a runner per
+// app - the same single-registration-point SetHookRunner already has.
+type DispatchRunner struct {
+	exec    deployment.HookRunner
+	webhook deployment.HookRunner
+}
+
+// NewDispatchRunner builds a DispatchRunner backed by exec and webhook.
+func NewDispatchRunner(exec, webhook deployment.HookRunner) *DispatchRunner {
+	return &DispatchRunner{exec: exec, webhook: webhook}
+}
+
+// Run delegates to the webhook runner when hook.URL is set, else the exec
+// runner - the same XOR config.HookCommand validation already enforces.
+func (r *DispatchRunner) Run(ctx context.Context, hook deployment.Hook) (stdout, stderr string, err error) {
+	if hook.URL != "" {
+		return r.webhook.Run(ctx, hook)
+	}
+	return r.exec.Run(ctx, hook)
+}
@@ -0,0 +1,55 @@
+// Package hooks implements deployment.HookRunner by actually shelling out to
+// the configured command, the same relationship docker.HealthProbe has to
+// deployment.HealthChecker: the deployment package defines the pluggable
+// interface and plain data shape, this package supplies the real execution
+// behind it.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+
+	"dockswap/internal/deployment"
+)
+
+// ExecRunner runs a deployment.Hook as a host-level child process via
+// os/exec - not inside the app's container, since lifecycle hooks (DB
+// migrations, cache warms, Slack notifications) typically need to reach
+// things the container itself doesn't: the deploy host's own tooling, a
+// shared database, the network path to a notification webhook.
+type ExecRunner struct{}
+
+// NewExecRunner builds an ExecRunner ready to pass to
+// DeploymentStateMachine.SetHookRunner.
+func NewExecRunner() *ExecRunner {
+	return &ExecRunner{}
+}
+
+// Run executes hook.Command with hook.Args, bounded by ctx, with hook.Env
+// appended to the child's inherited environment. Stdout/stderr are captured
+// (not streamed) and returned in full regardless of the exit outcome; a
+// non-zero exit or ctx deadline is returned as err.
+func (r *ExecRunner) Run(ctx context.Context, hook deployment.Hook) (stdout, stderr string, err error) {
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	if len(hook.Env) > 0 {
+		cmd.Env = append(cmd.Environ(), envPairs(hook.Env)...)
+	}
+
+	err = cmd.Run()
+	return outBuf.String(), errBuf.String(), err
+}
+
+// envPairs renders env as "KEY=VALUE" pairs for exec.Cmd.Env.
+func envPairs(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for k, v := range env {
+		pairs = append(pairs, k+"="+v)
+	}
+	return pairs
+}
@@ -0,0 +1,103 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"dockswap/internal/deployment"
+)
+
+// WebhookRunner runs a deployment.Hook by POSTing a JSON payload to hook.URL,
+// the HTTP counterpart to ExecRunner's host process - for a hook an operator
+// wants to reach a notification service (Slack, PagerDuty) rather than run
+// locally. A failed request (non-2xx status, or a transport error) is
+// retried using retry's backoff/MaxRetries, the same RestartPolicy already
+// configured for this app's container-start/health-check/Caddy retries, so
+// a flaky webhook endpoint doesn't need its own separate retry knobs.
+type WebhookRunner struct {
+	client *http.Client
+	retry  deployment.RestartPolicy
+}
+
+// NewWebhookRunner builds a WebhookRunner that retries a failed POST
+// according to retry, ready to pass to DeploymentStateMachine.SetHookRunner
+// (typically wrapped in a DispatchRunner alongside an ExecRunner).
+func NewWebhookRunner(retry deployment.RestartPolicy) *WebhookRunner {
+	return &WebhookRunner{client: &http.Client{}, retry: retry}
+}
+
+// webhookPayload is the JSON body POSTed for a hook - just the static shape
+// already known at config time (command name doubles as an identifier,
+// args, env), since Hook carries nothing about the deployment that
+// triggered it.
+type webhookPayload struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// Run POSTs hook to hook.URL, retrying on failure up to retry.MaxRetries
+// additional times - unless retry.Policy is "" or RestartPolicyNever, which
+// (mirroring RestartPolicy.allowsRetry) means a single attempt regardless of
+// MaxRetries - waiting retry.BackoffFor(attempt) between tries. ctx is used
+// as-is rather than re-wrapped per attempt: execHook/runHookWith already
+// bound it to hook.Timeout before calling Run, so that deadline (like an
+// exec hook's) covers the whole retry sequence, not each individual POST.
+func (r *WebhookRunner) Run(ctx context.Context, hook deployment.Hook) (stdout, stderr string, err error) {
+	body, marshalErr := json.Marshal(webhookPayload{Command: hook.Command, Args: hook.Args, Env: hook.Env})
+	if marshalErr != nil {
+		return "", "", fmt.Errorf("marshal webhook payload for %s: %w", hook.URL, marshalErr)
+	}
+
+	attempts := 1
+	if r.retry.Policy != "" && r.retry.Policy != deployment.RestartPolicyNever {
+		attempts = r.retry.MaxRetries + 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		respBody, postErr := r.post(ctx, hook, body)
+		if postErr == nil {
+			return respBody, "", nil
+		}
+		lastErr = postErr
+
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-time.After(r.retry.BackoffFor(attempt)):
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		}
+	}
+
+	return "", "", fmt.Errorf("webhook %s: %w", hook.URL, lastErr)
+}
+
+// post issues a single POST attempt and treats any non-2xx response as a
+// failed attempt alongside a transport error.
+func (r *WebhookRunner) post(ctx context.Context, hook deployment.Hook, body []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return string(respBody), nil
+}
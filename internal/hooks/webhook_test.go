@@ -0,0 +1,143 @@
+package hooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dockswap/internal/deployment"
+)
+
+func TestWebhookRunnerSucceedsOnFirstAttempt(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	runner := NewWebhookRunner(deployment.RestartPolicy{})
+	_, _, err := runner.Run(context.Background(), deployment.Hook{URL: server.URL, Command: "notify"})
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("server received %d requests, want 1", calls)
+	}
+}
+
+func TestWebhookRunnerRetriesUsingRestartPolicyBackoff(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	retry := deployment.RestartPolicy{
+		Policy:         deployment.RestartPolicyAlways,
+		MaxRetries:     3,
+		BackoffInitial: time.Millisecond,
+		BackoffMax:     5 * time.Millisecond,
+	}
+	runner := NewWebhookRunner(retry)
+
+	start := time.Now()
+	_, _, err := runner.Run(context.Background(), deployment.Hook{URL: server.URL, Command: "notify"})
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("server received %d requests, want 3 (2 failures + 1 success)", calls)
+	}
+	// retry.BackoffFor(1) + retry.BackoffFor(2) = 1ms + 2ms, so this should
+	// take at least that long but stay well under a flake-prone ceiling.
+	if elapsed < retry.BackoffFor(1)+retry.BackoffFor(2) {
+		t.Errorf("elapsed %s is shorter than the expected backoff wait", elapsed)
+	}
+}
+
+func TestWebhookRunnerGivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	retry := deployment.RestartPolicy{
+		Policy:         deployment.RestartPolicyAlways,
+		MaxRetries:     2,
+		BackoffInitial: time.Millisecond,
+	}
+	runner := NewWebhookRunner(retry)
+
+	_, _, err := runner.Run(context.Background(), deployment.Hook{URL: server.URL, Command: "notify"})
+	if err == nil {
+		t.Fatal("Run() expected an error after exhausting retries, got nil")
+	}
+	if calls != 3 {
+		t.Errorf("server received %d requests, want 3 (1 initial + 2 retries)", calls)
+	}
+}
+
+func TestWebhookRunnerZeroPolicyMakesNoRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	// MaxRetries is non-zero but Policy is the zero value - mirroring
+	// RestartPolicy.allowsRetry, that should still mean no retries at all.
+	retry := deployment.RestartPolicy{MaxRetries: 5, BackoffInitial: time.Millisecond}
+	runner := NewWebhookRunner(retry)
+
+	_, _, err := runner.Run(context.Background(), deployment.Hook{URL: server.URL, Command: "notify"})
+	if err == nil {
+		t.Fatal("Run() expected an error, got nil")
+	}
+	if calls != 1 {
+		t.Errorf("server received %d requests, want 1 (Policy zero-value disables retries regardless of MaxRetries)", calls)
+	}
+}
+
+// stubRunner records whether it was invoked, for TestDispatchRunnerRoutesByURL
+// to check which of exec/webhook a hook got routed to.
+type stubRunner struct {
+	called bool
+}
+
+func (s *stubRunner) Run(ctx context.Context, hook deployment.Hook) (string, string, error) {
+	s.called = true
+	return "", "", nil
+}
+
+func TestDispatchRunnerRoutesByURL(t *testing.T) {
+	webhook := &stubRunner{}
+	exec := &stubRunner{}
+	runner := NewDispatchRunner(exec, webhook)
+
+	if _, _, err := runner.Run(context.Background(), deployment.Hook{Command: "migrate"}); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if !exec.called || webhook.called {
+		t.Errorf("exec-only hook routed wrong: execCalled=%v webhookCalled=%v", exec.called, webhook.called)
+	}
+
+	exec.called, webhook.called = false, false
+	if _, _, err := runner.Run(context.Background(), deployment.Hook{URL: "https://example.com/hook"}); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if exec.called || !webhook.called {
+		t.Errorf("url hook routed wrong: execCalled=%v webhookCalled=%v", exec.called, webhook.called)
+	}
+}
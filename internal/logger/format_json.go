@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// levelNames maps the numeric level constants to the JSON "level" string, so
+// JSONFormatter output uses the same names as TextFormatter.
+var levelNames = map[int]string{
+	LevelError: "error",
+	LevelWarn:  "warn",
+	LevelInfo:  "info",
+	LevelDebug: "debug",
+	LevelTrace: "trace",
+}
+
+// JSONFormatter renders one JSON object per line: time, level and msg plus
+// every Field flattened in as its own key, e.g. {"time":"...","level":"info",
+// "msg":"...","app":"web","color":"blue"}.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(e Entry) []byte {
+	doc := make(map[string]interface{}, len(e.Fields)+3)
+	doc["time"] = e.Time.Format(time.RFC3339)
+	doc["level"] = levelNames[e.Level]
+	doc["msg"] = e.Message
+	for _, f := range e.Fields {
+		doc[f.Key] = f.Value
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		// Fields that don't marshal (e.g. a channel) shouldn't take down
+		// logging; fall back to a minimal record that at least records why.
+		data, _ = json.Marshal(map[string]string{
+			"time":  e.Time.Format(time.RFC3339),
+			"level": "error",
+			"msg":   "failed to marshal log entry: " + err.Error(),
+		})
+	}
+	return append(data, '\n')
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWithAddsFields(t *testing.T) {
+	var buf bytes.Buffer
+	base := NewWithOutput(LevelInfo, &buf)
+	child := base.With("app", "web", "color", "blue")
+
+	child.Info("deployed")
+	output := buf.String()
+
+	if !strings.Contains(output, "app=web") || !strings.Contains(output, "color=blue") {
+		t.Errorf("expected fields in output, got: %s", output)
+	}
+}
+
+func TestWithIsCumulativeAndIsolated(t *testing.T) {
+	var buf bytes.Buffer
+	base := NewWithOutput(LevelInfo, &buf)
+	appLogger := base.With("app", "web")
+	deployLogger := appLogger.With("deployment_id", 42)
+
+	buf.Reset()
+	appLogger.Info("base event")
+	if strings.Contains(buf.String(), "deployment_id") {
+		t.Errorf("parent logger should not pick up child's fields, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	deployLogger.Info("child event")
+	out := buf.String()
+	if !strings.Contains(out, "app=web") || !strings.Contains(out, "deployment_id=42") {
+		t.Errorf("expected both parent and child fields, got: %s", out)
+	}
+}
+
+func TestWithSharesLevelWithParent(t *testing.T) {
+	var buf bytes.Buffer
+	base := NewWithOutput(LevelInfo, &buf)
+	child := base.With("app", "web")
+
+	child.Debug("should be filtered")
+	if buf.Len() != 0 {
+		t.Errorf("expected debug message filtered out, got: %s", buf.String())
+	}
+
+	base.SetLevel(LevelDebug)
+	child.Debug("should now appear")
+	if buf.Len() == 0 {
+		t.Errorf("expected child to observe parent's level change")
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewWithFormatter(LevelInfo, &buf, JSONFormatter{})
+	log.With("app", "web", "color", "green").Info("container started")
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &doc); err != nil {
+		t.Fatalf("expected valid JSON line, got %q: %v", buf.String(), err)
+	}
+
+	if doc["level"] != "info" {
+		t.Errorf("expected level=info, got %v", doc["level"])
+	}
+	if doc["msg"] != "container started" {
+		t.Errorf("expected msg, got %v", doc["msg"])
+	}
+	if doc["app"] != "web" || doc["color"] != "green" {
+		t.Errorf("expected fields flattened into document, got %v", doc)
+	}
+}
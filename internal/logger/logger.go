@@ -10,83 +10,168 @@ import (
 
 const (
 	LevelError = 1
-	LevelInfo  = 2
-	LevelDebug = 3
+	LevelWarn  = 2
+	LevelInfo  = 3
+	LevelDebug = 4
+	LevelTrace = 5
 )
 
 type Logger interface {
 	Error(format string, args ...interface{})
+	Warn(format string, args ...interface{})
 	Info(format string, args ...interface{})
 	Debug(format string, args ...interface{})
+	Trace(format string, args ...interface{})
 	SetLevel(level int)
 	GetLevel() int
+
+	// With returns a child logger that appends keyvals (alternating key,
+	// value pairs, e.g. "app", appName, "color", color) to every entry it
+	// logs, on top of any fields the parent already carries. The child
+	// shares the parent's level, formatter and output.
+	With(keyvals ...interface{}) Logger
 }
 
-type StandardLogger struct {
-	level  int
-	output io.Writer
-	mutex  sync.Mutex
+// Field is a single piece of structured context attached to a log entry,
+// such as app, color, deployment_id or container_id.
+type Field struct {
+	Key   string
+	Value interface{}
 }
 
-func New(level int) Logger {
-	if level < LevelError || level > LevelDebug {
+// Entry is the fully-resolved record handed to a Formatter.
+type Entry struct {
+	Time    time.Time
+	Level   int
+	Name    string
+	Fields  []Field
+	Message string
+}
+
+// Formatter renders an Entry to bytes ready to write to a sink. TextFormatter
+// reproduces the original "[timestamp] LEVEL: message" layout with
+// space-separated key=value pairs; JSONFormatter emits one JSON object per
+// line for collectors that expect structured input.
+type Formatter interface {
+	Format(entry Entry) []byte
+}
+
+type TextFormatter struct{}
+
+func (TextFormatter) Format(e Entry) []byte {
+	out := fmt.Sprintf("[%s] %s: %s", e.Time.Format("2006-01-02 15:04:05"), e.Name, e.Message)
+	for _, f := range e.Fields {
+		out += fmt.Sprintf(" %s=%v", f.Key, f.Value)
+	}
+	return append([]byte(out), '\n')
+}
+
+// core holds the state shared by a logger and every child produced via With:
+// the level, the formatter and the sink all apply tree-wide, only the field
+// set varies per logger.
+type core struct {
+	level     int
+	output    io.Writer
+	formatter Formatter
+	mutex     sync.Mutex
+}
+
+func newCore(level int, output io.Writer, formatter Formatter) *core {
+	if level < LevelError || level > LevelTrace {
 		level = LevelInfo
 	}
-	return &StandardLogger{
-		level:  level,
-		output: os.Stderr,
+	return &core{level: level, output: output, formatter: formatter}
+}
+
+func (c *core) setLevel(level int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if level >= LevelError && level <= LevelTrace {
+		c.level = level
 	}
 }
 
-func NewWithOutput(level int, output io.Writer) Logger {
-	if level < LevelError || level > LevelDebug {
-		level = LevelInfo
+func (c *core) getLevel() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.level
+}
+
+func (c *core) log(level int, name string, fields []Field, message string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.level < level {
+		return
 	}
-	return &StandardLogger{
-		level:  level,
-		output: output,
+
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Name:    name,
+		Fields:  fields,
+		Message: message,
 	}
+	c.output.Write(c.formatter.Format(entry))
 }
 
-func (l *StandardLogger) SetLevel(level int) {
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
-	if level >= LevelError && level <= LevelDebug {
-		l.level = level
+type StandardLogger struct {
+	core   *core
+	fields []Field
+}
+
+func New(level int) Logger {
+	return NewWithFormatter(level, os.Stderr, TextFormatter{})
+}
+
+func NewWithOutput(level int, output io.Writer) Logger {
+	return NewWithFormatter(level, output, TextFormatter{})
+}
+
+// NewWithFormatter builds a Logger that renders entries through formatter
+// before writing them to output, e.g. JSONFormatter{} for shipping structured
+// logs to journald or a central collector.
+func NewWithFormatter(level int, output io.Writer, formatter Formatter) Logger {
+	return &StandardLogger{core: newCore(level, output, formatter)}
+}
+
+func (l *StandardLogger) With(keyvals ...interface{}) Logger {
+	fields := make([]Field, 0, len(l.fields)+len(keyvals)/2)
+	fields = append(fields, l.fields...)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", keyvals[i])
+		}
+		fields = append(fields, Field{Key: key, Value: keyvals[i+1]})
 	}
+	return &StandardLogger{core: l.core, fields: fields}
+}
+
+func (l *StandardLogger) SetLevel(level int) {
+	l.core.setLevel(level)
 }
 
 func (l *StandardLogger) GetLevel() int {
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
-	return l.level
+	return l.core.getLevel()
 }
 
 func (l *StandardLogger) Error(format string, args ...interface{}) {
-	l.log(LevelError, "ERROR", format, args...)
+	l.core.log(LevelError, "ERROR", l.fields, fmt.Sprintf(format, args...))
+}
+
+func (l *StandardLogger) Warn(format string, args ...interface{}) {
+	l.core.log(LevelWarn, "WARN", l.fields, fmt.Sprintf(format, args...))
 }
 
 func (l *StandardLogger) Info(format string, args ...interface{}) {
-	if l.level >= LevelInfo {
-		l.log(LevelInfo, "INFO", format, args...)
-	}
+	l.core.log(LevelInfo, "INFO", l.fields, fmt.Sprintf(format, args...))
 }
 
 func (l *StandardLogger) Debug(format string, args ...interface{}) {
-	if l.level >= LevelDebug {
-		l.log(LevelDebug, "DEBUG", format, args...)
-	}
+	l.core.log(LevelDebug, "DEBUG", l.fields, fmt.Sprintf(format, args...))
 }
 
-func (l *StandardLogger) log(messageLevel int, levelName, format string, args ...interface{}) {
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
-
-	if l.level < messageLevel {
-		return
-	}
-
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	message := fmt.Sprintf(format, args...)
-	fmt.Fprintf(l.output, "[%s] %s: %s\n", timestamp, levelName, message)
+func (l *StandardLogger) Trace(format string, args ...interface{}) {
+	l.core.log(LevelTrace, "TRACE", l.fields, fmt.Sprintf(format, args...))
 }
@@ -13,10 +13,12 @@ func TestNew(t *testing.T) {
 		expected int
 	}{
 		{"Valid Error Level", LevelError, LevelError},
+		{"Valid Warn Level", LevelWarn, LevelWarn},
 		{"Valid Info Level", LevelInfo, LevelInfo},
 		{"Valid Debug Level", LevelDebug, LevelDebug},
+		{"Valid Trace Level", LevelTrace, LevelTrace},
 		{"Invalid Low Level", 0, LevelInfo},
-		{"Invalid High Level", 4, LevelInfo},
+		{"Invalid High Level", 6, LevelInfo},
 	}
 
 	for _, tt := range tests {
@@ -51,9 +53,9 @@ func TestSetLevel(t *testing.T) {
 		t.Errorf("SetLevel(0) should be ignored, but level changed")
 	}
 
-	logger.SetLevel(4)
+	logger.SetLevel(6)
 	if logger.GetLevel() != originalLevel {
-		t.Errorf("SetLevel(4) should be ignored, but level changed")
+		t.Errorf("SetLevel(6) should be ignored, but level changed")
 	}
 }
 
@@ -65,14 +67,21 @@ func TestLogLevelFiltering(t *testing.T) {
 		shouldLog    bool
 	}{
 		{"Error level logs error", LevelError, "Error", true},
+		{"Error level skips warn", LevelError, "Warn", false},
 		{"Error level skips info", LevelError, "Info", false},
 		{"Error level skips debug", LevelError, "Debug", false},
+		{"Error level skips trace", LevelError, "Trace", false},
+		{"Warn level logs warn", LevelWarn, "Warn", true},
+		{"Warn level skips info", LevelWarn, "Info", false},
 		{"Info level logs error", LevelInfo, "Error", true},
+		{"Info level logs warn", LevelInfo, "Warn", true},
 		{"Info level logs info", LevelInfo, "Info", true},
 		{"Info level skips debug", LevelInfo, "Debug", false},
 		{"Debug level logs error", LevelDebug, "Error", true},
 		{"Debug level logs info", LevelDebug, "Info", true},
 		{"Debug level logs debug", LevelDebug, "Debug", true},
+		{"Debug level skips trace", LevelDebug, "Trace", false},
+		{"Trace level logs trace", LevelTrace, "Trace", true},
 	}
 
 	for _, tt := range tests {
@@ -84,10 +93,14 @@ func TestLogLevelFiltering(t *testing.T) {
 			switch tt.messageLevel {
 			case "Error":
 				logger.Error("test error message")
+			case "Warn":
+				logger.Warn("test warn message")
 			case "Info":
 				logger.Info("test info message")
 			case "Debug":
 				logger.Debug("test debug message")
+			case "Trace":
+				logger.Trace("test trace message")
 			}
 
 			output := buf.String()
@@ -183,11 +196,17 @@ func TestLogLevels(t *testing.T) {
 	if LevelError != 1 {
 		t.Errorf("LevelError should be 1, got %d", LevelError)
 	}
-	if LevelInfo != 2 {
-		t.Errorf("LevelInfo should be 2, got %d", LevelInfo)
+	if LevelWarn != 2 {
+		t.Errorf("LevelWarn should be 2, got %d", LevelWarn)
 	}
-	if LevelDebug != 3 {
-		t.Errorf("LevelDebug should be 3, got %d", LevelDebug)
+	if LevelInfo != 3 {
+		t.Errorf("LevelInfo should be 3, got %d", LevelInfo)
+	}
+	if LevelDebug != 4 {
+		t.Errorf("LevelDebug should be 4, got %d", LevelDebug)
+	}
+	if LevelTrace != 5 {
+		t.Errorf("LevelTrace should be 5, got %d", LevelTrace)
 	}
 }
 
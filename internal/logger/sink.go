@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+)
+
+// NewFileSink opens path for appending, creating it if it doesn't exist, and
+// returns it as an io.Writer suitable for NewWithOutput/NewWithFormatter, so
+// dockswap can ship its logs to a fixed location instead of stderr.
+func NewFileSink(path string) (io.Writer, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// NewSyslogSink dials the local syslog daemon and returns a sink tagged with
+// tag, mirroring Docker's daemon/logger syslog driver: operators can point
+// dockswap at journald or a central syslog collector without wrapping
+// stderr themselves.
+func NewSyslogSink(tag string) (io.Writer, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return w, nil
+}
@@ -0,0 +1,287 @@
+// Package proxy implements an in-process blue/green reverse proxy so that
+// dockswap can swap traffic between colors without depending on an external
+// load balancer. It supports a raw TCP mode and an HTTP mode (which honors
+// config.Proxy.PathPrefix and lets health probes be routed through it).
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"dockswap/internal/config"
+)
+
+// Mode selects how the proxy forwards traffic.
+type Mode string
+
+const (
+	ModeTCP  Mode = "tcp"
+	ModeHTTP Mode = "http"
+)
+
+// Proxy binds config.Proxy.ListenPort and forwards connections/requests to
+// whichever color is currently active. SwitchTarget performs an atomic
+// cutover: new connections are dialed against the new target while existing
+// connections are tracked and given until drainTimeout to finish on their
+// own.
+type Proxy struct {
+	appName      string
+	mode         Mode
+	cfg          config.Proxy
+	drainTimeout time.Duration
+
+	mu         sync.RWMutex
+	activeAddr string
+	blueAddr   string
+	greenAddr  string
+
+	listener net.Listener
+	server   *http.Server
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+}
+
+// New creates a Proxy for appName. blueAddr/greenAddr are "host:port"
+// dial targets for the two colors; activeColor selects which one starts
+// active.
+func New(appName string, mode Mode, cfg config.Proxy, blueAddr, greenAddr, activeColor string, drainTimeout time.Duration) (*Proxy, error) {
+	if cfg.ListenPort <= 0 {
+		return nil, fmt.Errorf("proxy.listen_port must be positive for app %s", appName)
+	}
+
+	p := &Proxy{
+		appName:      appName,
+		mode:         mode,
+		cfg:          cfg,
+		drainTimeout: drainTimeout,
+		blueAddr:     blueAddr,
+		greenAddr:    greenAddr,
+		conns:        make(map[net.Conn]struct{}),
+	}
+
+	switch activeColor {
+	case "blue":
+		p.activeAddr = blueAddr
+	case "green":
+		p.activeAddr = greenAddr
+	default:
+		return nil, fmt.Errorf("invalid active color %q for app %s", activeColor, appName)
+	}
+
+	return p, nil
+}
+
+// Start binds the listen port and begins forwarding traffic. It returns
+// once the listener is bound; serving happens in background goroutines.
+func (p *Proxy) Start() error {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", p.cfg.ListenPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", p.cfg.ListenPort, err)
+	}
+	p.listener = ln
+
+	switch p.mode {
+	case ModeHTTP:
+		p.server = &http.Server{Handler: p.buildHTTPHandler()}
+		go func() {
+			_ = p.server.Serve(ln)
+		}()
+	default:
+		go p.serveTCP(ln)
+	}
+
+	return nil
+}
+
+// SwitchTarget atomically flips the dial target to the given color. Any
+// connections already established against the old target keep running and
+// are left to Stop/the caller's drain timeout; only new connections
+// and requests go to the new color.
+func (p *Proxy) SwitchTarget(color string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch color {
+	case "blue":
+		p.activeAddr = p.blueAddr
+	case "green":
+		p.activeAddr = p.greenAddr
+	default:
+		return fmt.Errorf("invalid color %q", color)
+	}
+	return nil
+}
+
+func (p *Proxy) currentTarget() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.activeAddr
+}
+
+// ActiveConnections returns the number of connections currently tracked as
+// in-flight against whatever color they were dialed under.
+func (p *Proxy) ActiveConnections() int {
+	p.connsMu.Lock()
+	defer p.connsMu.Unlock()
+	return len(p.conns)
+}
+
+// Stop closes the listener and waits up to drainTimeout for in-flight
+// connections to close on their own before forcing them closed.
+func (p *Proxy) Stop(ctx context.Context) error {
+	if p.listener != nil {
+		_ = p.listener.Close()
+	}
+	if p.server != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, p.drainTimeout)
+		defer cancel()
+		_ = p.server.Shutdown(shutdownCtx)
+	}
+
+	deadline := time.After(p.drainTimeout)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if p.ActiveConnections() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			p.forceCloseAll()
+			return ctx.Err()
+		case <-deadline:
+			p.forceCloseAll()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Proxy) forceCloseAll() {
+	p.connsMu.Lock()
+	defer p.connsMu.Unlock()
+	for c := range p.conns {
+		_ = c.Close()
+	}
+}
+
+func (p *Proxy) trackConn(c net.Conn) {
+	p.connsMu.Lock()
+	p.conns[c] = struct{}{}
+	p.connsMu.Unlock()
+}
+
+func (p *Proxy) untrackConn(c net.Conn) {
+	p.connsMu.Lock()
+	delete(p.conns, c)
+	p.connsMu.Unlock()
+}
+
+func (p *Proxy) serveTCP(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go p.handleTCPConn(conn)
+	}
+}
+
+func (p *Proxy) handleTCPConn(client net.Conn) {
+	defer client.Close()
+
+	target := p.currentTarget()
+	upstream, err := net.DialTimeout("tcp", target, 5*time.Second)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+
+	p.trackConn(client)
+	defer p.untrackConn(client)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(upstream, client)
+		if tcpConn, ok := upstream.(*net.TCPConn); ok {
+			_ = tcpConn.CloseWrite()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(client, upstream)
+		if tcpConn, ok := client.(*net.TCPConn); ok {
+			_ = tcpConn.CloseWrite()
+		}
+	}()
+	wg.Wait()
+}
+
+func (p *Proxy) buildHTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	proxyHandler := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			target := p.currentTarget()
+			req.URL.Scheme = "http"
+			req.URL.Host = target
+			if p.cfg.Host != "" {
+				req.Host = p.cfg.Host
+			}
+			if p.cfg.PathPrefix != "" {
+				req.URL.Path = strings.TrimPrefix(req.URL.Path, p.cfg.PathPrefix)
+				if req.URL.Path == "" {
+					req.URL.Path = "/"
+				}
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	pattern := "/"
+	if p.cfg.PathPrefix != "" {
+		pattern = p.cfg.PathPrefix + "/"
+	}
+
+	mux.Handle(pattern, p.trackingHandler(proxyHandler))
+	return mux
+}
+
+// trackingHandler wraps an HTTP handler so in-flight requests count toward
+// ActiveConnections for drain purposes.
+func (p *Proxy) trackingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		marker := &httpConnMarker{}
+		p.trackConn(marker)
+		defer p.untrackConn(marker)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpConnMarker satisfies net.Conn just enough to be used as a map key in
+// the shared connection tracker for in-flight HTTP requests.
+type httpConnMarker struct{}
+
+func (httpConnMarker) Read(b []byte) (int, error)       { return 0, io.EOF }
+func (httpConnMarker) Write(b []byte) (int, error)      { return len(b), nil }
+func (httpConnMarker) Close() error                     { return nil }
+func (httpConnMarker) LocalAddr() net.Addr              { return nil }
+func (httpConnMarker) RemoteAddr() net.Addr             { return nil }
+func (httpConnMarker) SetDeadline(t time.Time) error      { return nil }
+func (httpConnMarker) SetReadDeadline(t time.Time) error  { return nil }
+func (httpConnMarker) SetWriteDeadline(t time.Time) error { return nil }
+
+var _ net.Conn = httpConnMarker{}
@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dockswap/internal/config"
+)
+
+func startEchoServer(t *testing.T, reply string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start echo server: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				buf := make([]byte, 1024)
+				if _, err := c.Read(buf); err != nil {
+					return
+				}
+				_, _ = c.Write([]byte(reply))
+			}(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return ln.Addr().String()
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestProxy_TCPSwitchTarget(t *testing.T) {
+	blue := startEchoServer(t, "blue-reply")
+	green := startEchoServer(t, "green-reply")
+
+	cfg := config.Proxy{ListenPort: freePort(t)}
+	p, err := New("test-app", ModeTCP, cfg, blue, green, "blue", time.Second)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if err := p.Start(); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer p.Stop(context.Background())
+
+	time.Sleep(50 * time.Millisecond) // let listener come up
+
+	dial := func() string {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", cfg.ListenPort))
+		if err != nil {
+			t.Fatalf("failed to dial proxy: %v", err)
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("hi"))
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		return string(buf[:n])
+	}
+
+	if got := dial(); got != "blue-reply" {
+		t.Fatalf("expected blue-reply, got %s", got)
+	}
+
+	if err := p.SwitchTarget("green"); err != nil {
+		t.Fatalf("SwitchTarget returned error: %v", err)
+	}
+
+	if got := dial(); got != "green-reply" {
+		t.Fatalf("expected green-reply after switch, got %s", got)
+	}
+}
+
+func TestProxy_HTTPSwitchTarget(t *testing.T) {
+	blueSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("blue"))
+	}))
+	defer blueSrv.Close()
+	greenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("green"))
+	}))
+	defer greenSrv.Close()
+
+	cfg := config.Proxy{ListenPort: freePort(t)}
+	p, err := New("test-app", ModeHTTP, cfg, blueSrv.Listener.Addr().String(), greenSrv.Listener.Addr().String(), "blue", time.Second)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if err := p.Start(); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer p.Stop(context.Background())
+
+	time.Sleep(50 * time.Millisecond)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/", cfg.ListenPort)
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET returned error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "blue" {
+		t.Fatalf("expected blue, got %s", string(body))
+	}
+
+	if err := p.SwitchTarget("green"); err != nil {
+		t.Fatalf("SwitchTarget returned error: %v", err)
+	}
+
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatalf("GET returned error: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "green" {
+		t.Fatalf("expected green after switch, got %s", string(body))
+	}
+}
+
+func TestNew_InvalidActiveColor(t *testing.T) {
+	cfg := config.Proxy{ListenPort: freePort(t)}
+	if _, err := New("test-app", ModeTCP, cfg, "127.0.0.1:1", "127.0.0.1:2", "yellow", time.Second); err == nil {
+		t.Fatal("expected error for invalid active color")
+	}
+}
+
+func TestNew_MissingListenPort(t *testing.T) {
+	cfg := config.Proxy{}
+	if _, err := New("test-app", ModeTCP, cfg, "127.0.0.1:1", "127.0.0.1:2", "blue", time.Second); err == nil {
+		t.Fatal("expected error for missing listen port")
+	}
+}
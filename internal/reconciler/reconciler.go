@@ -0,0 +1,178 @@
+// Package reconciler periodically compares the durable current_state table
+// against what the container runtime actually reports for each app's active
+// color, and repairs the drift it finds: a managed container that's stopped
+// gets restarted, one that's fallen off its app network gets reconnected.
+// Every cycle records a "drift_detected" or "reconciled" deployment_events
+// row, so the event history doubles as a reconciliation audit log the same
+// way StateReconciler's event-driven updates already do for the live
+// container stream. Only containers carrying the dockswap.managed=true
+// label are ever touched - RuntimeManager.ListAppContainers already filters
+// on it, so Reconciler inherits that scoping for free.
+package reconciler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"dockswap/internal/config"
+	"dockswap/internal/docker"
+	"dockswap/internal/logger"
+	"dockswap/internal/state"
+)
+
+// RuntimeManager is the subset of *docker.RuntimeManager Reconciler drives:
+// enough to detect drift (ListAppContainers) and repair it (StartContainer,
+// ConnectContainerToNetwork).
+type RuntimeManager interface {
+	ListAppContainers(ctx context.Context, appName string) ([]*docker.ContainerInfo, error)
+	StartContainer(ctx context.Context, containerID string) error
+	ConnectContainerToNetwork(ctx context.Context, networkName, containerID string) error
+}
+
+// Reconciler runs ReconcileOnce on a timer, the way watcher.Watcher polls
+// each app's registry - except here every app is checked together each
+// cycle instead of on its own independent interval, since drift detection
+// is cheap (one ContainerList per app) compared to a registry HEAD request.
+type Reconciler struct {
+	db       *sql.DB
+	manager  RuntimeManager
+	configs  map[string]*config.AppConfig
+	interval time.Duration
+	log      logger.Logger
+}
+
+// New creates a Reconciler that checks every app in configs against
+// RuntimeManager every interval once Run is called.
+func New(db *sql.DB, manager RuntimeManager, configs map[string]*config.AppConfig, interval time.Duration, log logger.Logger) *Reconciler {
+	return &Reconciler{
+		db:       db,
+		manager:  manager,
+		configs:  configs,
+		interval: interval,
+		log:      log,
+	}
+}
+
+// Run blocks, calling ReconcileOnce every r.interval until ctx is canceled.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.ReconcileOnce(ctx); err != nil {
+				r.log.Error("reconciler: cycle failed: %v", err)
+			}
+		}
+	}
+}
+
+// ReconcileOnce compares current_state against actual runtime state for
+// every app dockswap knows about, repairing and recording drift for each.
+// It keeps going past a single app's error so one unreachable app doesn't
+// stop the rest of the fleet from being reconciled this cycle.
+func (r *Reconciler) ReconcileOnce(ctx context.Context) error {
+	states, err := state.GetAllCurrentStates(r.db)
+	if err != nil {
+		return fmt.Errorf("reconciler: failed to load current states: %w", err)
+	}
+
+	for _, cs := range states {
+		appConfig, exists := r.configs[cs.AppName]
+		if !exists {
+			continue
+		}
+
+		if err := r.reconcileApp(ctx, cs, appConfig); err != nil {
+			r.log.Error("reconciler: %s: %v", cs.AppName, err)
+		}
+	}
+
+	return nil
+}
+
+// reconcileApp repairs drift for one app's active color and records the
+// outcome as a deployment_events row: "drift_detected" (with what was
+// wrong) if it found and fixed anything, "reconciled" on a quiet cycle.
+func (r *Reconciler) reconcileApp(ctx context.Context, cs state.CurrentState, appConfig *config.AppConfig) error {
+	containers, err := r.manager.ListAppContainers(ctx, cs.AppName)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var active *docker.ContainerInfo
+	suffix := "-" + cs.ActiveColor
+	for _, c := range containers {
+		if len(c.Name) >= len(suffix) && c.Name[len(c.Name)-len(suffix):] == suffix {
+			active = c
+			break
+		}
+	}
+
+	if active == nil {
+		return r.recordDrift(cs, fmt.Sprintf("no container found for active color %s", cs.ActiveColor))
+	}
+
+	var repairs []string
+
+	if active.State != "running" {
+		if err := r.manager.StartContainer(ctx, active.ID); err != nil {
+			return fmt.Errorf("failed to restart stopped container %s: %w", active.ID, err)
+		}
+		repairs = append(repairs, "restarted stopped container")
+	}
+
+	if appConfig.Docker.Network != "" && !containsNetwork(active.Networks, appConfig.Docker.Network) {
+		if err := r.manager.ConnectContainerToNetwork(ctx, appConfig.Docker.Network, active.ID); err != nil {
+			return fmt.Errorf("failed to reconnect container %s to network %s: %w", active.ID, appConfig.Docker.Network, err)
+		}
+		repairs = append(repairs, "reconnected to "+appConfig.Docker.Network)
+	}
+
+	if len(repairs) == 0 {
+		return r.recordReconciled(cs)
+	}
+
+	status := "running"
+	if err := state.UpsertCurrentState(r.db, cs.AppName, cs.DeploymentID, cs.ActiveColor, cs.Image, status); err != nil {
+		r.log.Error("reconciler: %s: failed to update current state after repair: %v", cs.AppName, err)
+	}
+
+	return r.recordDriftRepaired(cs, repairs)
+}
+
+func containsNetwork(networks []string, name string) bool {
+	for _, n := range networks {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reconciler) recordReconciled(cs state.CurrentState) error {
+	_, err := state.InsertDeploymentEvent(r.db, cs.DeploymentID, cs.AppName, "reconciled", "{}", nil)
+	return err
+}
+
+func (r *Reconciler) recordDrift(cs state.CurrentState, reason string) error {
+	payload := fmt.Sprintf(`{"reason":%q}`, reason)
+	_, err := state.InsertDeploymentEvent(r.db, cs.DeploymentID, cs.AppName, "drift_detected", payload, &reason)
+	return err
+}
+
+func (r *Reconciler) recordDriftRepaired(cs state.CurrentState, repairs []string) error {
+	quoted := make([]string, len(repairs))
+	for i, repair := range repairs {
+		quoted[i] = fmt.Sprintf("%q", repair)
+	}
+	payload := fmt.Sprintf(`{"repairs":[%s]}`, strings.Join(quoted, ","))
+	_, err := state.InsertDeploymentEvent(r.db, cs.DeploymentID, cs.AppName, "drift_detected", payload, nil)
+	return err
+}
@@ -0,0 +1,176 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"dockswap/internal/config"
+	"dockswap/internal/docker"
+	"dockswap/internal/logger"
+	"dockswap/internal/state"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeManager struct {
+	containers map[string][]*docker.ContainerInfo
+
+	startCalls   []string
+	connectCalls []string
+	startErr     error
+	connectErr   error
+}
+
+func (f *fakeManager) ListAppContainers(ctx context.Context, appName string) ([]*docker.ContainerInfo, error) {
+	return f.containers[appName], nil
+}
+
+func (f *fakeManager) StartContainer(ctx context.Context, containerID string) error {
+	f.startCalls = append(f.startCalls, containerID)
+	return f.startErr
+}
+
+func (f *fakeManager) ConnectContainerToNetwork(ctx context.Context, networkName, containerID string) error {
+	f.connectCalls = append(f.connectCalls, containerID)
+	return f.connectErr
+}
+
+func newTestReconciler(t *testing.T, manager RuntimeManager, configs map[string]*config.AppConfig) (*Reconciler, func()) {
+	t.Helper()
+	db, err := state.OpenAndMigrate(":memory:")
+	require.NoError(t, err)
+	r := New(db, manager, configs, time.Second, logger.New(logger.LevelError))
+	return r, func() { db.Close() }
+}
+
+func appConfig(network string) *config.AppConfig {
+	return &config.AppConfig{
+		Name:   "myapp",
+		Docker: config.Docker{Network: network},
+	}
+}
+
+func TestReconcileOnce_NoCurrentState(t *testing.T) {
+	manager := &fakeManager{}
+	r, closeDB := newTestReconciler(t, manager, map[string]*config.AppConfig{"myapp": appConfig("")})
+	defer closeDB()
+
+	err := r.ReconcileOnce(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestReconcileOnce_MissingContainerRecordsDrift(t *testing.T) {
+	manager := &fakeManager{containers: map[string][]*docker.ContainerInfo{}}
+	r, closeDB := newTestReconciler(t, manager, map[string]*config.AppConfig{"myapp": appConfig("")})
+	defer closeDB()
+
+	require.NoError(t, state.UpsertCurrentState(r.db, "myapp", 1, "blue", "myapp:v1", "running"))
+
+	require.NoError(t, r.ReconcileOnce(context.Background()))
+
+	events, err := state.GetDeploymentEvents(r.db, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "drift_detected", events[0].EventType)
+}
+
+func TestReconcileOnce_StoppedContainerIsRestarted(t *testing.T) {
+	manager := &fakeManager{containers: map[string][]*docker.ContainerInfo{
+		"myapp": {{ID: "c1", Name: "myapp-blue", State: "exited", Networks: []string{"myapp-net"}}},
+	}}
+	r, closeDB := newTestReconciler(t, manager, map[string]*config.AppConfig{"myapp": appConfig("myapp-net")})
+	defer closeDB()
+
+	require.NoError(t, state.UpsertCurrentState(r.db, "myapp", 1, "blue", "myapp:v1", "running"))
+
+	require.NoError(t, r.ReconcileOnce(context.Background()))
+
+	assert.Equal(t, []string{"c1"}, manager.startCalls)
+	assert.Empty(t, manager.connectCalls)
+
+	events, err := state.GetDeploymentEvents(r.db, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "drift_detected", events[0].EventType)
+}
+
+func TestReconcileOnce_DisconnectedNetworkIsReconnected(t *testing.T) {
+	manager := &fakeManager{containers: map[string][]*docker.ContainerInfo{
+		"myapp": {{ID: "c1", Name: "myapp-blue", State: "running", Networks: []string{"other-net"}}},
+	}}
+	r, closeDB := newTestReconciler(t, manager, map[string]*config.AppConfig{"myapp": appConfig("myapp-net")})
+	defer closeDB()
+
+	require.NoError(t, state.UpsertCurrentState(r.db, "myapp", 1, "blue", "myapp:v1", "running"))
+
+	require.NoError(t, r.ReconcileOnce(context.Background()))
+
+	assert.Empty(t, manager.startCalls)
+	assert.Equal(t, []string{"c1"}, manager.connectCalls)
+}
+
+func TestReconcileOnce_HealthyContainerRecordsReconciled(t *testing.T) {
+	manager := &fakeManager{containers: map[string][]*docker.ContainerInfo{
+		"myapp": {{ID: "c1", Name: "myapp-blue", State: "running", Networks: []string{"myapp-net"}}},
+	}}
+	r, closeDB := newTestReconciler(t, manager, map[string]*config.AppConfig{"myapp": appConfig("myapp-net")})
+	defer closeDB()
+
+	require.NoError(t, state.UpsertCurrentState(r.db, "myapp", 1, "blue", "myapp:v1", "running"))
+
+	require.NoError(t, r.ReconcileOnce(context.Background()))
+
+	assert.Empty(t, manager.startCalls)
+	assert.Empty(t, manager.connectCalls)
+
+	events, err := state.GetDeploymentEvents(r.db, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "reconciled", events[0].EventType)
+}
+
+func TestReconcileOnce_IgnoresSidecarContainers(t *testing.T) {
+	manager := &fakeManager{containers: map[string][]*docker.ContainerInfo{
+		"myapp": {
+			{ID: "sidecar", Name: "myapp-blue-redis", State: "exited"},
+			{ID: "c1", Name: "myapp-blue", State: "running", Networks: []string{"myapp-net"}},
+		},
+	}}
+	r, closeDB := newTestReconciler(t, manager, map[string]*config.AppConfig{"myapp": appConfig("myapp-net")})
+	defer closeDB()
+
+	require.NoError(t, state.UpsertCurrentState(r.db, "myapp", 1, "blue", "myapp:v1", "running"))
+
+	require.NoError(t, r.ReconcileOnce(context.Background()))
+
+	assert.Empty(t, manager.startCalls)
+}
+
+func TestReconcileOnce_ContinuesPastOneAppsError(t *testing.T) {
+	manager := &fakeManager{
+		containers: map[string][]*docker.ContainerInfo{
+			"broken": {{ID: "c1", Name: "broken-blue", State: "exited"}},
+			"myapp":  {{ID: "c2", Name: "myapp-blue", State: "running", Networks: []string{"myapp-net"}}},
+		},
+		startErr: errors.New("daemon unreachable"),
+	}
+	r, closeDB := newTestReconciler(t, manager, map[string]*config.AppConfig{
+		"broken": appConfig(""),
+		"myapp":  appConfig("myapp-net"),
+	})
+	defer closeDB()
+
+	require.NoError(t, state.UpsertCurrentState(r.db, "broken", 1, "blue", "broken:v1", "running"))
+	require.NoError(t, state.UpsertCurrentState(r.db, "myapp", 2, "blue", "myapp:v1", "running"))
+
+	require.NoError(t, r.ReconcileOnce(context.Background()))
+
+	events, err := state.GetDeploymentEvents(r.db, 2)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "reconciled", events[0].EventType)
+}
@@ -0,0 +1,121 @@
+// Package runtime abstracts the container engine dockswap drives behind a
+// single Client interface, so the rest of dockswap (RuntimeManager and
+// everything built on it - findContainers, ListAppContainers, the state
+// tables) stays oblivious to whether containers actually live on Docker,
+// Podman, or containerd. Every backend honors the same
+// dockswap.app/color/managed label contract CreateContainer sets, so
+// container discovery doesn't change across backends.
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+)
+
+// Client is the container-runtime-agnostic interface dockswap drives.
+// Request/response shapes are borrowed from the Docker Engine API - Podman's
+// compat API speaks the same wire format, and ContainerdClient translates to
+// and from it - so this interface doesn't grow a fourth vocabulary just to
+// stay "neutral" on paper.
+type Client interface {
+	// Container operations
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, containerName string) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+
+	// ImagePull pulls refStr (e.g. "myapp:latest") and streams progress
+	// events until the pull completes; callers that don't care about
+	// progress can just drain and discard the returned reader.
+	ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error)
+
+	// ImageInspect returns refStr's local metadata, notably RepoDigests, so
+	// a caller that just pulled refStr can resolve the immutable digest a
+	// mutable tag refers to right now.
+	ImageInspect(ctx context.Context, refStr string) (types.ImageInspect, error)
+
+	// ContainerExecCreate, ContainerExecStart and ContainerExecInspect back
+	// ExecProbe: create the exec, start it detached, then poll inspect for
+	// the exit code once it's no longer running.
+	ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (types.IDResponse, error)
+	ContainerExecStart(ctx context.Context, execID string, options container.ExecStartOptions) error
+	ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error)
+
+	// ContainerLogs streams stdout/stderr captured from containerID since it
+	// started, per options (Follow, Tail, Since, Timestamps).
+	ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error)
+
+	// CopyToContainer and CopyFromContainer move a tar stream in or out of
+	// containerID's filesystem over the same bidirectional archive endpoint
+	// every Docker-API-compatible engine exposes, so operators can seed
+	// config files or pull crash dumps without shelling out to `docker cp`.
+	CopyToContainer(ctx context.Context, containerID, path string, content io.Reader, options container.CopyToContainerOptions) error
+	CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error)
+
+	// Network operations
+	NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error)
+	NetworkList(ctx context.Context, options network.ListOptions) ([]network.Inspect, error)
+	NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error
+
+	// System operations
+	Ping(ctx context.Context) (types.Ping, error)
+	Info(ctx context.Context) (system.Info, error)
+
+	// Events subscribes to the runtime's event stream, filtered by options.
+	// The returned channel is closed (and the error channel receives the
+	// terminal error) when the stream ends or ctx is canceled.
+	Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error)
+
+	// Cleanup
+	Close() error
+}
+
+// Kind selects which backend NewClient constructs.
+type Kind string
+
+const (
+	Docker     Kind = "docker"
+	Podman     Kind = "podman"
+	Containerd Kind = "containerd"
+)
+
+// Endpoint identifies the daemon a Client talks to, beyond the backend Kind
+// itself. The zero Endpoint means "local default socket, no TLS" - exactly
+// what every call site got before per-app docker_host existed, so configs
+// that don't set one see no behavior change.
+type Endpoint struct {
+	// Host, if set, overrides the backend's default socket/address, e.g.
+	// "unix:///var/run/docker.sock", "tcp://host:2376", "ssh://user@host".
+	Host string
+
+	// TLSDir, if set, is a directory containing ca.pem, cert.pem, and
+	// key.pem used to authenticate a "tcp://" Host. Ignored by Podman and
+	// containerd backends, which have no equivalent client-TLS option.
+	TLSDir string
+}
+
+// NewClient constructs the Client backend named by kind against endpoint,
+// defaulting to Docker when kind is empty so existing configs (which
+// predate the runtime knob) keep talking to the Docker daemon unchanged.
+func NewClient(kind Kind, endpoint Endpoint) (Client, error) {
+	switch kind {
+	case "", Docker:
+		return NewDockerClient(endpoint)
+	case Podman:
+		return NewPodmanClient(endpoint.Host)
+	case Containerd:
+		return NewContainerdClient(endpoint.Host, "")
+	default:
+		return nil, fmt.Errorf("unknown runtime %q: must be one of docker, podman, containerd", kind)
+	}
+}
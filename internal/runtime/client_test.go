@@ -0,0 +1,49 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient_UnknownKind(t *testing.T) {
+	_, err := NewClient(Kind("lxc"), Endpoint{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown runtime")
+}
+
+func TestNewClient_DefaultsToDocker(t *testing.T) {
+	cli, err := NewClient("", Endpoint{})
+	require.NoError(t, err)
+	defer cli.Close()
+
+	_, ok := cli.(*DockerClient)
+	assert.True(t, ok, "empty Kind should construct a *DockerClient")
+}
+
+func TestDockerHostFromUnixURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid unix socket", url: "unix:///run/podman/podman.sock", want: "/run/podman/podman.sock"},
+		{name: "missing scheme", url: "/run/podman/podman.sock", wantErr: true},
+		{name: "tcp scheme rejected", url: "tcp://127.0.0.1:2375", wantErr: true},
+		{name: "empty", url: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerHostFromUnixURL(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	apievents "github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/oci"
+	"github.com/containerd/typeurl/v2"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+)
+
+// defaultContainerdSocket is containerd's own default, both for a
+// system-wide install and for rootless containerd (run via containerd-rootless.sh).
+const defaultContainerdSocket = "/run/containerd/containerd.sock"
+
+// defaultContainerdNamespace keeps dockswap-managed containers out of the
+// "default" namespace other tools (ctr, nerdctl) use, the same way Docker
+// keeps its containers in its own daemon rather than sharing a namespace.
+const defaultContainerdNamespace = "dockswap"
+
+// ContainerdClient drives containerd directly via its native gRPC API
+// instead of the CRI shim, translating the Docker-shaped container.Config/
+// HostConfig dockswap builds into an OCI runtime spec and a containerd
+// task. containerd has no built-in network management (Docker's bridge
+// networking is itself a plugin on top of libnetwork) - NetworkCreate/
+// NetworkList/NetworkConnect return errdefs.ErrNotImplemented here;
+// multi-network apps need an external CNI setup.
+type ContainerdClient struct {
+	client    *containerd.Client
+	namespace string
+}
+
+// NewContainerdClient dials socketPath (or defaultContainerdSocket when
+// empty) and scopes all operations to namespace (or defaultContainerdNamespace
+// when empty).
+func NewContainerdClient(socketPath, namespace string) (*ContainerdClient, error) {
+	if socketPath == "" {
+		socketPath = defaultContainerdSocket
+	}
+	if namespace == "" {
+		namespace = defaultContainerdNamespace
+	}
+
+	cli, err := containerd.New(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create containerd client: %w", err)
+	}
+
+	return &ContainerdClient{client: cli, namespace: namespace}, nil
+}
+
+func (c *ContainerdClient) ctx(ctx context.Context) context.Context {
+	return namespaces.WithNamespace(ctx, c.namespace)
+}
+
+func (c *ContainerdClient) ContainerCreate(ctx context.Context, cfg *container.Config, hostConfig *container.HostConfig, _ *network.NetworkingConfig, containerName string) (container.CreateResponse, error) {
+	ctx = c.ctx(ctx)
+
+	img, err := c.client.GetImage(ctx, cfg.Image)
+	if err != nil {
+		return container.CreateResponse{}, fmt.Errorf("image %s not present locally (pull it first): %w", cfg.Image, err)
+	}
+
+	labels := make(map[string]string, len(cfg.Labels))
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+
+	specOpts := []oci.SpecOpts{
+		oci.WithImageConfig(img),
+		oci.WithEnv(cfg.Env),
+	}
+	if hostConfig.Memory != 0 {
+		specOpts = append(specOpts, oci.WithMemoryLimit(uint64(hostConfig.Memory)))
+	}
+	if hostConfig.CPUQuota != 0 && hostConfig.CPUPeriod != 0 {
+		specOpts = append(specOpts, oci.WithCPUCFS(hostConfig.CPUQuota, uint64(hostConfig.CPUPeriod)))
+	}
+
+	ctrd, err := c.client.NewContainer(ctx, containerName,
+		containerd.WithImage(img),
+		containerd.WithNewSnapshot(containerName+"-snapshot", img),
+		containerd.WithNewSpec(specOpts...),
+		containerd.WithContainerLabels(labels),
+	)
+	if err != nil {
+		return container.CreateResponse{}, fmt.Errorf("failed to create containerd container %s: %w", containerName, err)
+	}
+
+	return container.CreateResponse{ID: ctrd.ID()}, nil
+}
+
+func (c *ContainerdClient) ContainerStart(ctx context.Context, containerID string, _ container.StartOptions) error {
+	ctx = c.ctx(ctx)
+
+	ctrd, err := c.client.LoadContainer(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to load container %s: %w", containerID, err)
+	}
+
+	task, err := ctrd.NewTask(ctx, cio.NewCreator(cio.WithStdio))
+	if err != nil {
+		return fmt.Errorf("failed to create task for %s: %w", containerID, err)
+	}
+
+	return task.Start(ctx)
+}
+
+func (c *ContainerdClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	ctx = c.ctx(ctx)
+
+	ctrd, err := c.client.LoadContainer(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to load container %s: %w", containerID, err)
+	}
+
+	task, err := ctrd.Task(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load task for %s: %w", containerID, err)
+	}
+
+	timeout := 10 * time.Second
+	if options.Timeout != nil {
+		timeout = time.Duration(*options.Timeout) * time.Second
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	exitCh, err := task.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait on task for %s: %w", containerID, err)
+	}
+
+	if err := task.Kill(ctx, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to signal task for %s: %w", containerID, err)
+	}
+
+	select {
+	case <-exitCh:
+	case <-waitCtx.Done():
+		if err := task.Kill(ctx, syscall.SIGKILL); err != nil {
+			return fmt.Errorf("failed to force-kill task for %s: %w", containerID, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerdClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	ctx = c.ctx(ctx)
+
+	ctrd, err := c.client.LoadContainer(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to load container %s: %w", containerID, err)
+	}
+
+	if task, err := ctrd.Task(ctx, nil); err == nil {
+		if options.Force {
+			_, _ = task.Delete(ctx, containerd.WithProcessKill)
+		} else {
+			_, _ = task.Delete(ctx)
+		}
+	}
+
+	return ctrd.Delete(ctx, containerd.WithSnapshotCleanup)
+}
+
+func (c *ContainerdClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	ctx = c.ctx(ctx)
+
+	filter := containerdLabelFilter(options.Filters)
+	ctrs, err := c.client.Containers(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	result := make([]types.Container, 0, len(ctrs))
+	for _, ctrd := range ctrs {
+		info, err := ctrd.Info(ctx)
+		if err != nil {
+			continue
+		}
+		result = append(result, types.Container{
+			ID:     ctrd.ID(),
+			Names:  []string{"/" + ctrd.ID()},
+			Image:  info.Image,
+			Labels: info.Labels,
+			State:  containerdState(ctx, ctrd),
+		})
+	}
+
+	return result, nil
+}
+
+func (c *ContainerdClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	ctx = c.ctx(ctx)
+
+	ctrd, err := c.client.LoadContainer(ctx, containerID)
+	if err != nil {
+		return types.ContainerJSON{}, fmt.Errorf("failed to load container %s: %w", containerID, err)
+	}
+
+	info, err := ctrd.Info(ctx)
+	if err != nil {
+		return types.ContainerJSON{}, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:      ctrd.ID(),
+			Name:    "/" + ctrd.ID(),
+			Created: info.CreatedAt.Format(time.RFC3339Nano),
+			State: &types.ContainerState{
+				Status: containerdState(ctx, ctrd),
+			},
+		},
+		Config: &container.Config{Image: info.Image, Labels: info.Labels},
+	}, nil
+}
+
+// ImagePull pulls refStr via containerd's resolver/unpacker, which is
+// synchronous rather than a streamed progress feed like Docker's - callers
+// get an already-closed reader since there's nothing left to drain.
+func (c *ContainerdClient) ImagePull(ctx context.Context, refStr string, _ image.PullOptions) (io.ReadCloser, error) {
+	ctx = c.ctx(ctx)
+
+	if _, err := c.client.Pull(ctx, refStr, containerd.WithPullUnpack); err != nil {
+		return nil, fmt.Errorf("failed to pull image %s: %w", refStr, err)
+	}
+
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+// ImageInspect returns refStr's locally-resolved digest as its sole
+// RepoDigest - containerd images are already content-addressed by the
+// descriptor ImagePull resolved, so there's no separate inspect round trip
+// the way Docker's daemon does one.
+func (c *ContainerdClient) ImageInspect(ctx context.Context, refStr string) (types.ImageInspect, error) {
+	ctx = c.ctx(ctx)
+
+	img, err := c.client.GetImage(ctx, refStr)
+	if err != nil {
+		return types.ImageInspect{}, fmt.Errorf("failed to inspect image %s: %w", refStr, err)
+	}
+
+	digest := img.Target().Digest.String()
+	repo := refStr
+	if at := strings.IndexByte(repo, '@'); at != -1 {
+		repo = repo[:at]
+	} else if colon := strings.LastIndexByte(repo, ':'); colon != -1 && !strings.ContainsRune(repo[colon:], '/') {
+		repo = repo[:colon]
+	}
+
+	return types.ImageInspect{
+		ID:          digest,
+		RepoDigests: []string{repo + "@" + digest},
+	}, nil
+}
+
+func (c *ContainerdClient) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (types.IDResponse, error) {
+	ctx = c.ctx(ctx)
+
+	ctrd, err := c.client.LoadContainer(ctx, containerID)
+	if err != nil {
+		return types.IDResponse{}, fmt.Errorf("failed to load container %s: %w", containerID, err)
+	}
+
+	task, err := ctrd.Task(ctx, nil)
+	if err != nil {
+		return types.IDResponse{}, fmt.Errorf("failed to load task for %s: %w", containerID, err)
+	}
+
+	spec, err := ctrd.Spec(ctx)
+	if err != nil {
+		return types.IDResponse{}, fmt.Errorf("failed to load spec for %s: %w", containerID, err)
+	}
+
+	execID := containerID + "-exec-" + time.Now().UTC().Format("150405.000000000")
+	processSpec := spec.Process
+	processSpec.Args = options.Cmd
+
+	if _, err := task.Exec(ctx, execID, processSpec, cio.NewCreator(cio.WithStdio)); err != nil {
+		return types.IDResponse{}, fmt.Errorf("failed to create exec %s on %s: %w", execID, containerID, err)
+	}
+
+	return types.IDResponse{ID: execID}, nil
+}
+
+func (c *ContainerdClient) ContainerExecStart(ctx context.Context, execID string, _ container.ExecStartOptions) error {
+	return fmt.Errorf("containerd exec %s: start is folded into ContainerExecCreate; nothing left to start", execID)
+}
+
+func (c *ContainerdClient) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	return container.ExecInspect{}, fmt.Errorf("containerd exec inspect for %s: not supported, %w", execID, errdefs.ErrNotImplemented)
+}
+
+// ContainerLogs has no containerd equivalent here: ContainerStart wires the
+// task's IO straight to this process's own stdio via cio.WithStdio rather
+// than a readable, re-attachable stream, so there's nothing to tail after
+// the fact.
+func (c *ContainerdClient) ContainerLogs(ctx context.Context, containerID string, _ container.LogsOptions) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("containerd backend does not support log retrieval (container %s): %w", containerID, errdefs.ErrNotImplemented)
+}
+
+// CopyToContainer and CopyFromContainer have no containerd equivalent here:
+// unlike Docker's archive endpoint, copying into or out of a containerd
+// container's rootfs means mounting its snapshot directly, which this
+// client doesn't do.
+func (c *ContainerdClient) CopyToContainer(ctx context.Context, containerID, path string, _ io.Reader, _ container.CopyToContainerOptions) error {
+	return fmt.Errorf("containerd backend does not support CopyToContainer (container %s, path %s): %w", containerID, path, errdefs.ErrNotImplemented)
+}
+
+func (c *ContainerdClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	return nil, container.PathStat{}, fmt.Errorf("containerd backend does not support CopyFromContainer (container %s, path %s): %w", containerID, srcPath, errdefs.ErrNotImplemented)
+}
+
+func (c *ContainerdClient) NetworkCreate(ctx context.Context, name string, _ network.CreateOptions) (network.CreateResponse, error) {
+	return network.CreateResponse{}, fmt.Errorf("containerd has no built-in network management (network %s): %w", name, errdefs.ErrNotImplemented)
+}
+
+func (c *ContainerdClient) NetworkList(ctx context.Context, _ network.ListOptions) ([]network.Inspect, error) {
+	return nil, fmt.Errorf("containerd has no built-in network management: %w", errdefs.ErrNotImplemented)
+}
+
+func (c *ContainerdClient) NetworkConnect(ctx context.Context, networkID, containerID string, _ *network.EndpointSettings) error {
+	return fmt.Errorf("containerd has no built-in network management (network %s, container %s): %w", networkID, containerID, errdefs.ErrNotImplemented)
+}
+
+func (c *ContainerdClient) Ping(ctx context.Context) (types.Ping, error) {
+	if _, err := c.client.Version(ctx); err != nil {
+		return types.Ping{}, fmt.Errorf("containerd not accessible: %w", err)
+	}
+	return types.Ping{APIVersion: "containerd"}, nil
+}
+
+func (c *ContainerdClient) Info(ctx context.Context) (system.Info, error) {
+	v, err := c.client.Version(ctx)
+	if err != nil {
+		return system.Info{}, fmt.Errorf("failed to get containerd version: %w", err)
+	}
+	return system.Info{ServerVersion: v.Version}, nil
+}
+
+// Events translates containerd's topic-based event stream into Docker-
+// shaped events.Message values on a best-effort basis: container/task
+// lifecycle topics map to "container" events with the matching Action;
+// anything else is dropped rather than guessed at.
+func (c *ContainerdClient) Events(ctx context.Context, _ events.ListOptions) (<-chan events.Message, <-chan error) {
+	ctx = c.ctx(ctx)
+
+	msgCh := make(chan events.Message)
+	errCh := make(chan error, 1)
+
+	envelopeCh, containerdErrCh := c.client.EventService().Subscribe(ctx)
+
+	go func() {
+		defer close(msgCh)
+		for {
+			select {
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			case err := <-containerdErrCh:
+				errCh <- err
+				return
+			case envelope := <-envelopeCh:
+				action, id, ok := decodeContainerdEvent(envelope.Event)
+				if !ok {
+					continue
+				}
+				msgCh <- events.Message{
+					Type:   events.ContainerEventType,
+					Action: events.Action(action),
+					Actor:  events.Actor{ID: id},
+					Time:   envelope.Timestamp.Unix(),
+				}
+			}
+		}
+	}()
+
+	return msgCh, errCh
+}
+
+func (c *ContainerdClient) Close() error {
+	return c.client.Close()
+}
+
+// containerdState best-effort maps a containerd task's process status onto
+// the handful of Docker container.State.Status values dockswap's state
+// machine understands ("running", "exited", "created").
+func containerdState(ctx context.Context, ctrd containerd.Container) string {
+	task, err := ctrd.Task(ctx, nil)
+	if err != nil {
+		return "created"
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return "created"
+	}
+	if status.Status == containerd.Stopped {
+		return "exited"
+	}
+	return string(status.Status)
+}
+
+// containerdLabelFilter translates the "label=k=v" filter strings dockswap
+// builds with filters.NewArgs into containerd's own filter expression
+// syntax (`labels."k"==v`).
+func containerdLabelFilter(f filters.Args) string {
+	var parts []string
+	for _, kv := range f.Get("label") {
+		k, v, found := strings.Cut(kv, "=")
+		if !found {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf(`labels."%s"==%q`, k, v))
+	}
+	return strings.Join(parts, ",")
+}
+
+// decodeContainerdEvent unmarshals a containerd event envelope's typed
+// payload and maps it to the Docker action string it corresponds to, plus
+// the container ID the event is about. Event types dockswap doesn't care
+// about (snapshotter GC, image events, ...) return ok=false and are
+// dropped by Events rather than surfaced as a guess.
+func decodeContainerdEvent(any typeurl.Any) (action, id string, ok bool) {
+	evt, err := typeurl.UnmarshalAny(any)
+	if err != nil {
+		return "", "", false
+	}
+
+	switch e := evt.(type) {
+	case *apievents.TaskStart:
+		return "start", e.ContainerID, true
+	case *apievents.TaskExit:
+		return "die", e.ContainerID, true
+	case *apievents.TaskDelete:
+		return "destroy", e.ContainerID, true
+	case *apievents.ContainerCreate:
+		return "create", e.ID, true
+	case *apievents.ContainerDelete:
+		return "destroy", e.ID, true
+	default:
+		return "", "", false
+	}
+}
+
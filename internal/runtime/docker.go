@@ -0,0 +1,139 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/client"
+)
+
+// DockerClient talks to a real Docker (or Docker-API-compatible) daemon
+// over the official SDK. It's the default Client backend.
+type DockerClient struct {
+	client *client.Client
+}
+
+// NewDockerClient connects to endpoint.Host (or the local default socket,
+// via client.FromEnv, when empty), authenticating with the ca.pem/cert.pem/
+// key.pem under endpoint.TLSDir if set.
+func NewDockerClient(endpoint Endpoint) (*DockerClient, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if endpoint.Host != "" {
+		opts = append(opts, client.WithHost(endpoint.Host))
+	}
+	if endpoint.TLSDir != "" {
+		opts = append(opts, client.WithTLSClientConfig(
+			filepath.Join(endpoint.TLSDir, "ca.pem"),
+			filepath.Join(endpoint.TLSDir, "cert.pem"),
+			filepath.Join(endpoint.TLSDir, "key.pem"),
+		))
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+
+	return &DockerClient{client: cli}, nil
+}
+
+// NewDockerClientFromRaw wraps an already-configured docker/client.Client,
+// bypassing NewDockerClient's client.FromEnv defaults. Tests use this to
+// point a real client at a fake daemon (e.g. internal/docker/fakeserver)
+// via client.WithHost instead of the host's actual Docker socket.
+func NewDockerClientFromRaw(cli *client.Client) *DockerClient {
+	return &DockerClient{client: cli}
+}
+
+func (r *DockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, containerName string) (container.CreateResponse, error) {
+	return r.client.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, containerName)
+}
+
+func (r *DockerClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	return r.client.ContainerStart(ctx, containerID, options)
+}
+
+func (r *DockerClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	return r.client.ContainerStop(ctx, containerID, options)
+}
+
+func (r *DockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	return r.client.ContainerRemove(ctx, containerID, options)
+}
+
+func (r *DockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	return r.client.ContainerList(ctx, options)
+}
+
+func (r *DockerClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return r.client.ContainerInspect(ctx, containerID)
+}
+
+func (r *DockerClient) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
+	return r.client.ImagePull(ctx, refStr, options)
+}
+
+func (r *DockerClient) ImageInspect(ctx context.Context, refStr string) (types.ImageInspect, error) {
+	inspect, _, err := r.client.ImageInspectWithRaw(ctx, refStr)
+	return inspect, err
+}
+
+func (r *DockerClient) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (types.IDResponse, error) {
+	return r.client.ContainerExecCreate(ctx, containerID, options)
+}
+
+func (r *DockerClient) ContainerExecStart(ctx context.Context, execID string, options container.ExecStartOptions) error {
+	return r.client.ContainerExecStart(ctx, execID, options)
+}
+
+func (r *DockerClient) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	return r.client.ContainerExecInspect(ctx, execID)
+}
+
+func (r *DockerClient) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	return r.client.ContainerLogs(ctx, containerID, options)
+}
+
+func (r *DockerClient) CopyToContainer(ctx context.Context, containerID, path string, content io.Reader, options container.CopyToContainerOptions) error {
+	return r.client.CopyToContainer(ctx, containerID, path, content, options)
+}
+
+func (r *DockerClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	return r.client.CopyFromContainer(ctx, containerID, srcPath)
+}
+
+func (r *DockerClient) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
+	return r.client.NetworkCreate(ctx, name, options)
+}
+
+func (r *DockerClient) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Inspect, error) {
+	return r.client.NetworkList(ctx, options)
+}
+
+func (r *DockerClient) NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error {
+	return r.client.NetworkConnect(ctx, networkID, containerID, config)
+}
+
+func (r *DockerClient) Ping(ctx context.Context) (types.Ping, error) {
+	return r.client.Ping(ctx)
+}
+
+func (r *DockerClient) Info(ctx context.Context) (system.Info, error) {
+	return r.client.Info(ctx)
+}
+
+func (r *DockerClient) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	return r.client.Events(ctx, options)
+}
+
+func (r *DockerClient) Close() error {
+	return r.client.Close()
+}
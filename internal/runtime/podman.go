@@ -0,0 +1,70 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/docker/docker/client"
+)
+
+// defaultPodmanSocket locates the rootless Podman socket Podman's systemd
+// user unit (podman.socket) activates by default. System-mode Podman
+// listens on /run/podman/podman.sock instead; pass an explicit socketPath
+// to NewPodmanClient for that case.
+func defaultPodmanSocket() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return fmt.Sprintf("unix://%s/podman/podman.sock", runtimeDir)
+	}
+	return fmt.Sprintf("unix:///run/user/%d/podman/podman.sock", os.Getuid())
+}
+
+// NewPodmanClient dials socketPath (or the default rootless Podman socket
+// when empty) and returns a Client backed by it. Podman's "compat" REST API
+// - the one its systemd socket activates by default - mirrors the Docker
+// Engine API wire format closely enough that the same Docker SDK client
+// works against it unmodified; this is the same trick tools like the
+// Testcontainers Podman provider and Docker's own "podman tunnel" use
+// instead of speaking Podman's libpod-native API.
+func NewPodmanClient(socketPath string) (*DockerClient, error) {
+	if socketPath == "" {
+		socketPath = defaultPodmanSocket()
+	}
+
+	host, err := dockerHostFromUnixURL(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid podman socket %q: %w", socketPath, err)
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", host)
+			},
+		},
+	}
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHTTPClient(httpClient),
+		client.WithHost(socketPath),
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Podman client: %w", err)
+	}
+
+	return &DockerClient{client: cli}, nil
+}
+
+// dockerHostFromUnixURL extracts the filesystem path from a "unix://..."
+// socket URL, the only scheme NewPodmanClient accepts.
+func dockerHostFromUnixURL(socketURL string) (string, error) {
+	const prefix = "unix://"
+	if len(socketURL) <= len(prefix) || socketURL[:len(prefix)] != prefix {
+		return "", fmt.Errorf("expected a unix:// socket path, got %q", socketURL)
+	}
+	return socketURL[len(prefix):], nil
+}
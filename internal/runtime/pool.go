@@ -0,0 +1,66 @@
+package runtime
+
+import "sync"
+
+// poolKey identifies one (Kind, Endpoint) pair a Pool can cache a Client
+// for. Two apps with the same docker_host (including both leaving it unset)
+// share a connection instead of dialing the daemon once per app.
+type poolKey struct {
+	kind   Kind
+	host   string
+	tlsDir string
+}
+
+// Pool caches one Client per (Kind, Endpoint) pair, so callers that resolve
+// a Client per-app (e.g. DeploymentOrchestrator, once apps can each set
+// docker_host) don't open a new connection for every app that happens to
+// share an endpoint with another.
+type Pool struct {
+	mu      sync.Mutex
+	clients map[poolKey]Client
+}
+
+// NewPool returns an empty Pool.
+func NewPool() *Pool {
+	return &Pool{clients: make(map[poolKey]Client)}
+}
+
+// Get returns the cached Client for (kind, endpoint), constructing and
+// caching one via NewClient on first request for that pair.
+func (p *Pool) Get(kind Kind, endpoint Endpoint) (Client, error) {
+	key := poolKey{kind: kind, host: endpoint.Host, tlsDir: endpoint.TLSDir}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cli, ok := p.clients[key]; ok {
+		return cli, nil
+	}
+
+	cli, err := NewClient(kind, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[key] = cli
+	return cli, nil
+}
+
+// Close closes every Client the pool has constructed so far, returning the
+// first error encountered (if any) after attempting all of them. A nil Pool
+// (e.g. a CLI built without New) closes nothing.
+func (p *Pool) Close() error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var firstErr error
+	for _, cli := range p.clients {
+		if err := cli.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,32 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolGetCachesByEndpoint(t *testing.T) {
+	p := NewPool()
+
+	a, err := p.Get(Docker, Endpoint{})
+	require.NoError(t, err)
+
+	b, err := p.Get(Docker, Endpoint{})
+	require.NoError(t, err)
+
+	assert.Same(t, a, b, "same (kind, endpoint) should return the cached Client")
+}
+
+func TestPoolGetDistinguishesHosts(t *testing.T) {
+	p := NewPool()
+
+	a, err := p.Get(Docker, Endpoint{Host: "tcp://host-a:2376"})
+	require.NoError(t, err)
+
+	b, err := p.Get(Docker, Endpoint{Host: "tcp://host-b:2376"})
+	require.NoError(t, err)
+
+	assert.NotSame(t, a, b, "different Host should construct distinct Clients")
+}
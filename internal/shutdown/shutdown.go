@@ -0,0 +1,95 @@
+// Package shutdown provides a top-level signal trap modeled on Docker's
+// pkg/signal.Trap: the first SIGINT/SIGTERM cancels a shared context so any
+// cancelable operation in flight (DeploymentOrchestrator.runDeploymentLoop,
+// a DockerActionProvider action) unwinds toward a safe checkpoint instead of
+// running to its own timeout, and a second signal arriving before the
+// in-flight work has had a chance to settle forces an immediate exit.
+package shutdown
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultForceExitWindow bounds how soon a second interrupt must follow the
+// first before Coordinator gives up waiting and exits immediately - the
+// same "ctrl-C twice to force it" behavior as Docker's own pkg/signal.Trap.
+const DefaultForceExitWindow = 5 * time.Second
+
+// Coordinator traps SIGINT/SIGTERM and cancels Context() on the first one,
+// so callers can derive every cancelable operation from it. ForceExitWindow
+// defaults to DefaultForceExitWindow; set it before calling Trap to change
+// it.
+type Coordinator struct {
+	ctx             context.Context
+	cancel          context.CancelFunc
+	ForceExitWindow time.Duration
+
+	mu        sync.Mutex
+	triggered time.Time
+}
+
+// New creates a Coordinator with a fresh cancelable context derived from
+// context.Background().
+func New() *Coordinator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Coordinator{ctx: ctx, cancel: cancel, ForceExitWindow: DefaultForceExitWindow}
+}
+
+// Context is canceled the first time Trap observes SIGINT/SIGTERM.
+func (c *Coordinator) Context() context.Context {
+	return c.ctx
+}
+
+// Trap starts handling SIGINT/SIGTERM in a goroutine and returns a stop func
+// that undoes the signal.Notify registration. The first signal cancels
+// Context() and invokes onSignal (if non-nil) with the signal received; a
+// second one arriving within ForceExitWindow of the first calls os.Exit(1)
+// instead, skipping onSignal entirely, since the point of a second signal is
+// that the caller gave up waiting on whatever onSignal would otherwise do.
+func (c *Coordinator) Trap(onSignal func(os.Signal)) func() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		for sig := range sigCh {
+			c.mu.Lock()
+			forced := !c.triggered.IsZero() && time.Since(c.triggered) < c.ForceExitWindow
+			c.triggered = time.Now()
+			c.mu.Unlock()
+
+			if forced {
+				os.Exit(1)
+			}
+
+			c.cancel()
+			if onSignal != nil {
+				onSignal(sig)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(sigCh)
+	}
+}
+
+// WaitSafe polls isSafe every 200ms until it reports true or timeout
+// elapses, returning whether it became safe in time. Callers use this after
+// Trap fires to give an in-flight deployment its configured budget (e.g.
+// DrainTimeout+StopTimeout) to reach a safe checkpoint before exiting.
+func WaitSafe(isSafe func() bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !isSafe() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package shutdown
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTrapCancelsContextOnFirstSignal(t *testing.T) {
+	c := New()
+	received := make(chan os.Signal, 1)
+	stop := c.Trap(func(sig os.Signal) { received <- sig })
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatalf("failed to send SIGINT: %v", err)
+	}
+
+	select {
+	case <-c.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected Context() to be canceled after SIGINT")
+	}
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("expected onSignal to be invoked after SIGINT")
+	}
+}
+
+func TestWaitSafeReturnsTrueAssoonAsSafe(t *testing.T) {
+	var safe atomic.Bool
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		safe.Store(true)
+	}()
+
+	if !WaitSafe(safe.Load, time.Second) {
+		t.Fatal("expected WaitSafe to report safe before the timeout")
+	}
+}
+
+func TestWaitSafeTimesOutWhenNeverSafe(t *testing.T) {
+	if WaitSafe(func() bool { return false }, 100*time.Millisecond) {
+		t.Fatal("expected WaitSafe to time out when isSafe never returns true")
+	}
+}
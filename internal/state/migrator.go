@@ -0,0 +1,477 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const initialSchemaVersion int64 = 202507101010
+
+// Migration represents a single reversible DB schema change. Checksum is a
+// hex sha256 of the Up/Down SQL text and is recorded alongside the applied
+// version so Migrator can detect a migration's source having changed out
+// from under an already-migrated DB.
+type Migration struct {
+	Version  int64
+	Name     string
+	Checksum string
+	Up       func(tx *sql.Tx) error
+	Down     func(tx *sql.Tx) error
+}
+
+// sqlMigration builds a Migration whose Up/Down are plain SQL scripts - the
+// common case. Checksum is derived from upSQL+downSQL, so editing either
+// after the migration has been applied anywhere is caught by Migrator.Up's
+// drift check. Migrations with logic that can't be expressed as a single
+// exec (e.g. data backfills) should build a Migration literal directly.
+func sqlMigration(version int64, name, upSQL, downSQL string) Migration {
+	sum := sha256.Sum256([]byte(upSQL + "\x00" + downSQL))
+	return Migration{
+		Version:  version,
+		Name:     name,
+		Checksum: hex.EncodeToString(sum[:]),
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(upSQL)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(downSQL)
+			return err
+		},
+	}
+}
+
+// migrations is the ordered list of schema migrations. Append new ones to
+// the end; never edit or remove an entry that has already shipped, since
+// Migrator.Up refuses to open a DB whose recorded checksum no longer
+// matches.
+var migrations = []Migration{
+	sqlMigration(initialSchemaVersion, "initial schema", `
+		CREATE TABLE IF NOT EXISTS app_configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			app_name TEXT NOT NULL,
+			config_yaml TEXT NOT NULL,
+			config_sha TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_app_configs_app_name ON app_configs(app_name);
+		CREATE INDEX IF NOT EXISTS idx_app_configs_config_sha ON app_configs(config_sha);
+
+		CREATE TABLE IF NOT EXISTS deployments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			app_name TEXT NOT NULL,
+			config_id INTEGER NOT NULL,
+			image TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			ended_at DATETIME,
+			status TEXT NOT NULL,
+			active_color TEXT NOT NULL,
+			rollback_of INTEGER,
+			FOREIGN KEY(config_id) REFERENCES app_configs(id),
+			FOREIGN KEY(rollback_of) REFERENCES deployments(id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_deployments_app_name ON deployments(app_name);
+
+		CREATE TABLE IF NOT EXISTS deployment_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			deployment_id INTEGER NOT NULL,
+			app_name TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload TEXT,
+			error TEXT,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY(deployment_id) REFERENCES deployments(id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_deployment_events_deployment_id ON deployment_events(deployment_id);
+		CREATE INDEX IF NOT EXISTS idx_deployment_events_app_name ON deployment_events(app_name);
+
+		CREATE TABLE IF NOT EXISTS current_state (
+			app_name TEXT PRIMARY KEY,
+			deployment_id INTEGER NOT NULL,
+			active_color TEXT NOT NULL,
+			image TEXT NOT NULL,
+			status TEXT NOT NULL,
+			updated_at DATETIME NOT NULL,
+			FOREIGN KEY(deployment_id) REFERENCES deployments(id)
+		);
+	`, `
+		DROP TABLE IF EXISTS current_state;
+		DROP TABLE IF EXISTS deployment_events;
+		DROP TABLE IF EXISTS deployments;
+		DROP TABLE IF EXISTS app_configs;
+	`),
+	sqlMigration(202507281200, "content-address app_configs on (app_name, config_sha)", `
+		CREATE TABLE app_configs_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			app_name TEXT NOT NULL,
+			config_yaml TEXT NOT NULL,
+			config_sha TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			UNIQUE(app_name, config_sha)
+		);
+		INSERT INTO app_configs_new (id, app_name, config_yaml, config_sha, created_at)
+			SELECT MIN(id), app_name, config_yaml, config_sha, MIN(created_at)
+			FROM app_configs
+			GROUP BY app_name, config_sha;
+
+		UPDATE deployments
+		SET config_id = (
+			SELECT new.id FROM app_configs_new new
+			JOIN app_configs old ON old.app_name = new.app_name AND old.config_sha = new.config_sha
+			WHERE old.id = deployments.config_id
+		)
+		WHERE config_id IN (SELECT id FROM app_configs);
+
+		DROP TABLE app_configs;
+		ALTER TABLE app_configs_new RENAME TO app_configs;
+		CREATE INDEX IF NOT EXISTS idx_app_configs_app_name ON app_configs(app_name);
+		CREATE INDEX IF NOT EXISTS idx_app_configs_config_sha ON app_configs(config_sha);
+	`, `
+		CREATE TABLE app_configs_old (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			app_name TEXT NOT NULL,
+			config_yaml TEXT NOT NULL,
+			config_sha TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		);
+		INSERT INTO app_configs_old (id, app_name, config_yaml, config_sha, created_at)
+			SELECT id, app_name, config_yaml, config_sha, created_at FROM app_configs;
+		DROP TABLE app_configs;
+		ALTER TABLE app_configs_old RENAME TO app_configs;
+		CREATE INDEX IF NOT EXISTS idx_app_configs_app_name ON app_configs(app_name);
+		CREATE INDEX IF NOT EXISTS idx_app_configs_config_sha ON app_configs(config_sha);
+	`),
+	sqlMigration(202507282300, "track last-seen registry digest per app for watcher", `
+		CREATE TABLE IF NOT EXISTS image_digests (
+			app_name TEXT PRIMARY KEY,
+			digest TEXT NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+	`, `
+		DROP TABLE IF EXISTS image_digests;
+	`),
+	sqlMigration(202507291400, "persist in-progress canary switch rollouts", `
+		CREATE TABLE IF NOT EXISTS canary_rollouts (
+			app_name TEXT PRIMARY KEY,
+			from_color TEXT NOT NULL,
+			to_color TEXT NOT NULL,
+			steps TEXT NOT NULL,
+			step_idx INTEGER NOT NULL,
+			step_interval_seconds INTEGER NOT NULL,
+			abort_on_5xx_rate REAL NOT NULL,
+			started_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+	`, `
+		DROP TABLE IF EXISTS canary_rollouts;
+	`),
+	sqlMigration(202607301000, "snapshot pre-rollout state for deploy-group rollback", `
+		CREATE TABLE IF NOT EXISTS release_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			release_id TEXT NOT NULL,
+			app_name TEXT NOT NULL,
+			previous_color TEXT NOT NULL,
+			previous_image TEXT NOT NULL,
+			status TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_release_runs_release_id ON release_runs(release_id);
+	`, `
+		DROP TABLE IF EXISTS release_runs;
+	`),
+	sqlMigration(202607301800, "persist retry/backoff/health-streak/desired-transition state for resume", `
+		CREATE TABLE IF NOT EXISTS deployment_checkpoints (
+			app_name TEXT PRIMARY KEY,
+			retry_count INTEGER NOT NULL DEFAULT 0,
+			backoff_started_at DATETIME,
+			backoff_duration_ms INTEGER NOT NULL DEFAULT 0,
+			desired_cancel INTEGER,
+			desired_pause INTEGER,
+			desired_force_rollback INTEGER,
+			health_success_streak INTEGER NOT NULL DEFAULT 0,
+			health_fail_streak INTEGER NOT NULL DEFAULT 0,
+			health_check_started_at DATETIME,
+			updated_at DATETIME NOT NULL
+		);
+	`, `
+		DROP TABLE IF EXISTS deployment_checkpoints;
+	`),
+}
+
+// AppliedMigration is one row of the schema_version ledger.
+type AppliedMigration struct {
+	Version    int64
+	Name       string
+	Checksum   string
+	AppliedAt  time.Time
+	DurationMS int64
+}
+
+// MigrationStatus reports one in-code migration's applied state, for the
+// `migrate status` CLI command.
+type MigrationStatus struct {
+	Migration
+	Applied    bool
+	AppliedAt  time.Time
+	DurationMS int64
+}
+
+// Migrator applies and rolls back the ordered migrations list against db,
+// recording progress in the schema_version table and guarding against
+// concurrent dockswap processes migrating the same DB at once.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator builds a Migrator over db for the given ordered migrations.
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// NewDefaultMigrator builds a Migrator over db using this package's own
+// migrations list - what OpenAndMigrate runs at startup, and what the
+// `migrate` CLI subcommands operate on.
+func NewDefaultMigrator(db *sql.DB) *Migrator {
+	return NewMigrator(db, migrations)
+}
+
+// OpenAndMigrate opens the SQLite DB at path and runs any pending
+// migrations, kept as a thin wrapper over Migrator.Up for callers (main.go,
+// tests) that just want a ready-to-use DB without thinking about the
+// migration runner.
+func OpenAndMigrate(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite db: %w", err)
+	}
+
+	if err := NewMigrator(db, migrations).Up(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// ensureLedger creates the schema_version table (if absent) and the
+// migration_lock sentinel table used by withLock.
+func (m *Migrator) ensureLedger() error {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			checksum TEXT NOT NULL DEFAULT '',
+			applied_at DATETIME NOT NULL,
+			duration_ms INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE IF NOT EXISTS migration_lock (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			locked_at DATETIME NOT NULL
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to ensure schema_version/migration_lock tables: %w", err)
+	}
+	return nil
+}
+
+// withLock runs fn while holding the migration_lock sentinel row, so a
+// second dockswap process running migrate concurrently fails fast instead
+// of racing this one statement-for-statement.
+func (m *Migrator) withLock(fn func() error) error {
+	if _, err := m.db.Exec(`INSERT OR FAIL INTO migration_lock (id, locked_at) VALUES (1, ?)`, time.Now().UTC()); err != nil {
+		return fmt.Errorf("another dockswap process appears to be migrating this database (migration_lock held): %w", err)
+	}
+	defer m.db.Exec(`DELETE FROM migration_lock WHERE id = 1`)
+
+	return fn()
+}
+
+// applied returns the schema_version ledger, keyed by version.
+func (m *Migrator) applied() (map[int64]AppliedMigration, error) {
+	rows, err := m.db.Query(`SELECT version, name, checksum, applied_at, duration_ms FROM schema_version ORDER BY version ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_version: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int64]AppliedMigration)
+	for rows.Next() {
+		var am AppliedMigration
+		if err := rows.Scan(&am.Version, &am.Name, &am.Checksum, &am.AppliedAt, &am.DurationMS); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_version row: %w", err)
+		}
+		result[am.Version] = am
+	}
+	return result, rows.Err()
+}
+
+// checkDrift fails if any already-applied migration's recorded checksum no
+// longer matches the one its in-code definition computes today, which means
+// the migration's source was edited after shipping - exactly the situation
+// that makes silently continuing unsafe.
+func (m *Migrator) checkDrift(applied map[int64]AppliedMigration) error {
+	for _, mig := range m.migrations {
+		am, ok := applied[mig.Version]
+		if !ok {
+			continue
+		}
+		if am.Checksum != "" && am.Checksum != mig.Checksum {
+			return fmt.Errorf("migration %d (%s) has changed since it was applied on %s: recorded checksum %s, current %s",
+				mig.Version, mig.Name, am.AppliedAt.Format(time.RFC3339), am.Checksum, mig.Checksum)
+		}
+	}
+	return nil
+}
+
+// Up applies every migration not yet recorded in schema_version, in order,
+// each in its own transaction. It refuses to run at
+// all if an already-applied migration's checksum has drifted from its
+// in-code definition - see checkDrift.
+func (m *Migrator) Up(ctx context.Context) error {
+	if err := m.ensureLedger(); err != nil {
+		return err
+	}
+
+	return m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		if err := m.checkDrift(applied); err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.applyOne(ctx, mig); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) applyOne(ctx context.Context, mig Migration) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin tx for migration %d: %w", mig.Version, err)
+	}
+
+	start := time.Now()
+	if err := mig.Up(tx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d (%s) failed: %w", mig.Version, mig.Name, err)
+	}
+	duration := time.Since(start)
+
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO schema_version (version, name, checksum, applied_at, duration_ms)
+		VALUES (?, ?, ?, ?, ?)
+	`, mig.Version, mig.Name, mig.Checksum, time.Now().UTC(), duration.Milliseconds())
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %d: %w", mig.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", mig.Version, err)
+	}
+	return nil
+}
+
+// Down rolls back the steps most-recently-applied migrations, newest
+// first, running each migration's Down in its own transaction and removing
+// its schema_version row on success.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+	if err := m.ensureLedger(); err != nil {
+		return err
+	}
+
+	return m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+
+		var toRevert []Migration
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				toRevert = append(toRevert, mig)
+			}
+		}
+		sort.Slice(toRevert, func(i, j int) bool { return toRevert[i].Version > toRevert[j].Version })
+		if len(toRevert) > steps {
+			toRevert = toRevert[:steps]
+		}
+
+		for _, mig := range toRevert {
+			if mig.Down == nil {
+				return fmt.Errorf("migration %d (%s) has no Down, cannot roll back", mig.Version, mig.Name)
+			}
+			tx, err := m.db.BeginTx(ctx, nil)
+			if err != nil {
+				return fmt.Errorf("failed to begin tx for rollback of migration %d: %w", mig.Version, err)
+			}
+			if err := mig.Down(tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("rollback of migration %d (%s) failed: %w", mig.Version, mig.Name, err)
+			}
+			if _, err := tx.Exec(`DELETE FROM schema_version WHERE version = ?`, mig.Version); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to remove schema_version row for migration %d: %w", mig.Version, err)
+			}
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("failed to commit rollback of migration %d: %w", mig.Version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Redo rolls back and re-applies the single most-recently-applied
+// migration - a quick way to iterate on a migration that hasn't shipped
+// beyond your own machine yet.
+func (m *Migrator) Redo(ctx context.Context) error {
+	if err := m.Down(ctx, 1); err != nil {
+		return err
+	}
+	return m.Up(ctx)
+}
+
+// Status reports every in-code migration alongside whether and when it's
+// been applied, in version order, for the `migrate status` CLI command.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureLedger(); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		ms := MigrationStatus{Migration: mig}
+		if am, ok := applied[mig.Version]; ok {
+			ms.Applied = true
+			ms.AppliedAt = am.AppliedAt
+			ms.DurationMS = am.DurationMS
+		}
+		result = append(result, ms)
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMigrator_UpIsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	migrator := NewDefaultMigrator(db)
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("second Up: %v", err)
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			t.Fatalf("migration %d (%s) expected applied, got pending", s.Version, s.Name)
+		}
+	}
+}
+
+func TestMigrator_DownThenUp(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	migrator := NewDefaultMigrator(db)
+
+	if err := migrator.Down(context.Background(), len(migrations)); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			t.Fatalf("migration %d (%s) expected rolled back, still applied", s.Version, s.Name)
+		}
+	}
+
+	if _, err := InsertAppConfig(db, "web-api", "foo: bar", "sha1"); err == nil {
+		t.Fatal("expected app_configs table to be dropped by Down")
+	}
+
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("re-up: %v", err)
+	}
+	if _, err := InsertAppConfig(db, "web-api", "foo: bar", "sha1"); err != nil {
+		t.Fatalf("expected app_configs table restored by Up, got: %v", err)
+	}
+}
+
+func TestMigrator_Redo(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	migrator := NewDefaultMigrator(db)
+	if err := migrator.Redo(context.Background()); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !statuses[len(statuses)-1].Applied {
+		t.Fatal("expected last migration re-applied after redo")
+	}
+}
+
+func TestMigrator_ChecksumDriftRefusesToOpen(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	drifted := []Migration{migrations[0]}
+	drifted[0].Checksum = "not-the-real-checksum"
+
+	if err := NewMigrator(db, drifted).Up(context.Background()); err == nil {
+		t.Fatal("expected Up to refuse a DB whose applied checksum no longer matches")
+	}
+}
+
+func TestMigrator_WithLockRejectsConcurrentMigration(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	migrator := NewDefaultMigrator(db)
+	if err := migrator.ensureLedger(); err != nil {
+		t.Fatalf("ensure ledger: %v", err)
+	}
+
+	err := migrator.withLock(func() error {
+		return migrator.withLock(func() error { return nil })
+	})
+	if err == nil {
+		t.Fatal("expected nested withLock to fail against the held migration_lock row")
+	}
+}
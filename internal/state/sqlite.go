@@ -3,137 +3,15 @@ package state
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/pmezard/go-difflib/difflib"
+
 	_ "github.com/mattn/go-sqlite3"
 )
 
-const initialSchemaVersion int64 = 202507101010
-
-// Migration represents a DB schema migration.
-type Migration struct {
-	Version int64
-	Up      func(tx *sql.Tx) error
-}
-
-// migrations is the ordered list of schema migrations.
-var migrations = []Migration{
-	{
-		Version: initialSchemaVersion,
-		Up: func(tx *sql.Tx) error {
-			_, err := tx.Exec(`
-			CREATE TABLE IF NOT EXISTS app_configs (
-				id INTEGER PRIMARY KEY AUTOINCREMENT,
-				app_name TEXT NOT NULL,
-				config_yaml TEXT NOT NULL,
-				config_sha TEXT NOT NULL,
-				created_at DATETIME NOT NULL
-			);
-			CREATE INDEX IF NOT EXISTS idx_app_configs_app_name ON app_configs(app_name);
-			CREATE INDEX IF NOT EXISTS idx_app_configs_config_sha ON app_configs(config_sha);
-
-			CREATE TABLE IF NOT EXISTS deployments (
-				id INTEGER PRIMARY KEY AUTOINCREMENT,
-				app_name TEXT NOT NULL,
-				config_id INTEGER NOT NULL,
-				image TEXT NOT NULL,
-				started_at DATETIME NOT NULL,
-				ended_at DATETIME,
-				status TEXT NOT NULL,
-				active_color TEXT NOT NULL,
-				rollback_of INTEGER,
-				FOREIGN KEY(config_id) REFERENCES app_configs(id),
-				FOREIGN KEY(rollback_of) REFERENCES deployments(id)
-			);
-			CREATE INDEX IF NOT EXISTS idx_deployments_app_name ON deployments(app_name);
-
-			CREATE TABLE IF NOT EXISTS deployment_events (
-				id INTEGER PRIMARY KEY AUTOINCREMENT,
-				deployment_id INTEGER NOT NULL,
-				app_name TEXT NOT NULL,
-				event_type TEXT NOT NULL,
-				payload TEXT,
-				error TEXT,
-				created_at DATETIME NOT NULL,
-				FOREIGN KEY(deployment_id) REFERENCES deployments(id)
-			);
-			CREATE INDEX IF NOT EXISTS idx_deployment_events_deployment_id ON deployment_events(deployment_id);
-			CREATE INDEX IF NOT EXISTS idx_deployment_events_app_name ON deployment_events(app_name);
-
-			CREATE TABLE IF NOT EXISTS current_state (
-				app_name TEXT PRIMARY KEY,
-				deployment_id INTEGER NOT NULL,
-				active_color TEXT NOT NULL,
-				image TEXT NOT NULL,
-				status TEXT NOT NULL,
-				updated_at DATETIME NOT NULL,
-				FOREIGN KEY(deployment_id) REFERENCES deployments(id)
-			);
-
-			CREATE TABLE IF NOT EXISTS schema_version (
-				version INTEGER PRIMARY KEY,
-				applied_at DATETIME NOT NULL
-			);
-			INSERT OR REPLACE INTO schema_version (version, applied_at) VALUES (?, ?);
-			`, initialSchemaVersion, time.Now().UTC())
-			return err
-		},
-	},
-}
-
-// OpenAndMigrate opens the SQLite DB at path and runs migrations as needed.
-func OpenAndMigrate(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open sqlite db: %w", err)
-	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to begin migration tx: %w", err)
-	}
-
-	// Ensure schema_version table exists
-	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER PRIMARY KEY, applied_at DATETIME NOT NULL);`)
-	if err != nil {
-		tx.Rollback()
-		db.Close()
-		return nil, fmt.Errorf("failed to ensure schema_version table: %w", err)
-	}
-
-	var currentVersion int64
-	row := tx.QueryRow(`SELECT version FROM schema_version ORDER BY version DESC LIMIT 1;`)
-	switch err := row.Scan(&currentVersion); err {
-	case sql.ErrNoRows:
-		currentVersion = 0
-	case nil:
-		// ok
-	default:
-		tx.Rollback()
-		db.Close()
-		return nil, fmt.Errorf("failed to query schema_version: %w", err)
-	}
-
-	for _, m := range migrations {
-		if m.Version > currentVersion {
-			if err := m.Up(tx); err != nil {
-				tx.Rollback()
-				db.Close()
-				return nil, fmt.Errorf("migration to version %d failed: %w", m.Version, err)
-			}
-			currentVersion = m.Version
-		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to commit migrations: %w", err)
-	}
-
-	return db, nil
-}
-
 // --- Entity Structs ---
 
 type AppConfig struct {
@@ -177,15 +55,91 @@ type CurrentState struct {
 
 // --- AppConfig Methods ---
 
+// InsertAppConfig content-addresses app_configs on (app_name, config_sha): a
+// byte-identical config for the same app is a no-op that returns the
+// existing row's ID instead of writing a duplicate, the same way a
+// container image registry dedupes identical layers.
 func InsertAppConfig(db *sql.DB, appName, configYAML, configSHA string) (int64, error) {
-	res, err := db.Exec(`
+	_, err := db.Exec(`
 		INSERT INTO app_configs (app_name, config_yaml, config_sha, created_at)
 		VALUES (?, ?, ?, ?)
+		ON CONFLICT(app_name, config_sha) DO NOTHING
 	`, appName, configYAML, configSHA, time.Now().UTC())
 	if err != nil {
 		return 0, err
 	}
-	return res.LastInsertId()
+
+	existing, err := GetAppConfigBySHA(db, appName, configSHA)
+	if err != nil {
+		return 0, err
+	}
+	return existing.ID, nil
+}
+
+// GetAppConfigBySHA looks up the app_configs row for appName's exact
+// configSHA, the read side of InsertAppConfig's dedup.
+func GetAppConfigBySHA(db *sql.DB, appName, configSHA string) (*AppConfig, error) {
+	row := db.QueryRow(`
+		SELECT id, app_name, config_yaml, config_sha, created_at
+		FROM app_configs
+		WHERE app_name = ? AND config_sha = ?
+	`, appName, configSHA)
+	var ac AppConfig
+	if err := row.Scan(&ac.ID, &ac.AppName, &ac.ConfigYAML, &ac.ConfigSHA, &ac.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &ac, nil
+}
+
+func getAppConfigByID(db *sql.DB, id int64) (*AppConfig, error) {
+	row := db.QueryRow(`
+		SELECT id, app_name, config_yaml, config_sha, created_at
+		FROM app_configs
+		WHERE id = ?
+	`, id)
+	var ac AppConfig
+	if err := row.Scan(&ac.ID, &ac.AppName, &ac.ConfigYAML, &ac.ConfigSHA, &ac.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &ac, nil
+}
+
+// DiffAppConfigs returns a unified diff of the two app_configs rows' raw
+// YAML, oldID first, for the `dockswap config diff` CLI command's audit
+// trail.
+func DiffAppConfigs(db *sql.DB, oldID, newID int64) (string, error) {
+	oldCfg, err := getAppConfigByID(db, oldID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load config %d: %w", oldID, err)
+	}
+	newCfg, err := getAppConfigByID(db, newID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load config %d: %w", newID, err)
+	}
+
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(oldCfg.ConfigYAML),
+		B:        difflib.SplitLines(newCfg.ConfigYAML),
+		FromFile: fmt.Sprintf("config#%d (%s)", oldCfg.ID, oldCfg.CreatedAt.Format(time.RFC3339)),
+		ToFile:   fmt.Sprintf("config#%d (%s)", newCfg.ID, newCfg.CreatedAt.Format(time.RFC3339)),
+		Context:  3,
+	}
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// PruneAppConfigs deletes every app_configs row for appName except the keep
+// most recent, bounding how much history InsertAppConfig's dedup retains.
+func PruneAppConfigs(db *sql.DB, appName string, keep int) error {
+	if keep < 0 {
+		return fmt.Errorf("keep must be non-negative, got %d", keep)
+	}
+	_, err := db.Exec(`
+		DELETE FROM app_configs
+		WHERE app_name = ? AND id NOT IN (
+			SELECT id FROM app_configs WHERE app_name = ? ORDER BY created_at DESC LIMIT ?
+		)
+	`, appName, appName, keep)
+	return err
 }
 
 func GetLatestAppConfig(db *sql.DB, appName string) (*AppConfig, error) {
@@ -242,6 +196,21 @@ func InsertDeployment(db *sql.DB, appName string, configID int64, image, status,
 	return res.LastInsertId()
 }
 
+// GetDeploymentByID looks up a single deployments row by its primary key,
+// for deployment.Resume to pair with current_state's deployment_id.
+func GetDeploymentByID(db *sql.DB, id int64) (*Deployment, error) {
+	row := db.QueryRow(`
+		SELECT id, app_name, config_id, image, started_at, ended_at, status, active_color, rollback_of
+		FROM deployments
+		WHERE id = ?
+	`, id)
+	var d Deployment
+	if err := row.Scan(&d.ID, &d.AppName, &d.ConfigID, &d.Image, &d.StartedAt, &d.EndedAt, &d.Status, &d.ActiveColor, &d.RollbackOf); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
 func GetDeploymentHistory(db *sql.DB, appName string) ([]Deployment, error) {
 	rows, err := db.Query(`
 		SELECT id, app_name, config_id, image, started_at, ended_at, status, active_color, rollback_of
@@ -303,6 +272,58 @@ func GetDeploymentEvents(db *sql.DB, deploymentID int64) ([]DeploymentEvent, err
 	return events, nil
 }
 
+// GetEventsSince returns every persisted deployment event across all
+// deployments whose created_at is at or after since, ordered by id - the
+// cross-deployment query `dockswap events` (given no deployment-id) uses to
+// show a live-ish tail without the caller needing to know a deployment_id.
+// A zero since returns the full retained history.
+func GetEventsSince(db *sql.DB, since time.Time) ([]DeploymentEvent, error) {
+	rows, err := db.Query(`
+		SELECT id, deployment_id, app_name, event_type, payload, error, created_at
+		FROM deployment_events
+		WHERE created_at >= ?
+		ORDER BY id ASC
+	`, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []DeploymentEvent
+	for rows.Next() {
+		var e DeploymentEvent
+		if err := rows.Scan(&e.ID, &e.DeploymentID, &e.AppName, &e.EventType, &e.Payload, &e.Error, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// GetEventsAfter returns every persisted deployment event with id greater
+// than afterID, ordered by id - the poll query `dockswap events --follow`
+// runs to pick up rows inserted since its last poll.
+func GetEventsAfter(db *sql.DB, afterID int64) ([]DeploymentEvent, error) {
+	rows, err := db.Query(`
+		SELECT id, deployment_id, app_name, event_type, payload, error, created_at
+		FROM deployment_events
+		WHERE id > ?
+		ORDER BY id ASC
+	`, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []DeploymentEvent
+	for rows.Next() {
+		var e DeploymentEvent
+		if err := rows.Scan(&e.ID, &e.DeploymentID, &e.AppName, &e.EventType, &e.Payload, &e.Error, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
 // --- CurrentState Methods ---
 
 func UpsertCurrentState(db *sql.DB, appName string, deploymentID int64, activeColor, image, status string) error {
@@ -372,3 +393,286 @@ func GetAllCurrentStates(db *sql.DB) ([]CurrentState, error) {
 	}
 	return states, nil
 }
+
+// --- ImageDigest Methods ---
+
+// UpsertImageDigest records digest as the last registry digest a
+// watcher.Watcher resolved appName's watched tag to, so the comparison
+// survives a dockswap restart instead of living only in the Watcher's
+// in-memory cache.
+func UpsertImageDigest(db *sql.DB, appName, digest string) error {
+	_, err := db.Exec(`
+		INSERT INTO image_digests (app_name, digest, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(app_name) DO UPDATE SET
+			digest=excluded.digest,
+			updated_at=excluded.updated_at
+	`, appName, digest, time.Now().UTC())
+	return err
+}
+
+// GetImageDigest returns the digest last recorded for appName by
+// UpsertImageDigest, or "" if none has been recorded yet.
+func GetImageDigest(db *sql.DB, appName string) (string, error) {
+	var digest string
+	err := db.QueryRow(`SELECT digest FROM image_digests WHERE app_name = ?`, appName).Scan(&digest)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// --- CanaryRollout Methods ---
+
+// CanaryRollout is a `dockswap switch --strategy=canary` run's persisted
+// progress: the weight stage it last applied, so a crash mid-rollout can be
+// resumed or aborted from the next CLI invocation instead of leaving Caddy
+// stuck on a partial traffic split with nothing recorded.
+type CanaryRollout struct {
+	AppName        string
+	FromColor      string
+	ToColor        string
+	Steps          []int
+	StepIdx        int
+	StepInterval   time.Duration
+	AbortOn5xxRate float64
+	StartedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// UpsertCanaryRollout records rollout's current step, so a crash mid-rollout
+// leaves enough state behind for the next `dockswap switch` invocation for
+// appName to resume from StepIdx rather than from scratch.
+func UpsertCanaryRollout(db *sql.DB, r CanaryRollout) error {
+	steps := make([]string, len(r.Steps))
+	for i, s := range r.Steps {
+		steps[i] = strconv.Itoa(s)
+	}
+	_, err := db.Exec(`
+		INSERT INTO canary_rollouts (app_name, from_color, to_color, steps, step_idx, step_interval_seconds, abort_on_5xx_rate, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(app_name) DO UPDATE SET
+			from_color=excluded.from_color,
+			to_color=excluded.to_color,
+			steps=excluded.steps,
+			step_idx=excluded.step_idx,
+			step_interval_seconds=excluded.step_interval_seconds,
+			abort_on_5xx_rate=excluded.abort_on_5xx_rate,
+			started_at=excluded.started_at,
+			updated_at=excluded.updated_at
+	`, r.AppName, r.FromColor, r.ToColor, strings.Join(steps, ","), r.StepIdx,
+		int64(r.StepInterval/time.Second), r.AbortOn5xxRate, r.StartedAt.UTC(), r.UpdatedAt.UTC())
+	return err
+}
+
+// GetCanaryRollout returns appName's in-progress canary rollout, or nil if
+// none is recorded (no rollout ever started, or the last one finished and
+// called DeleteCanaryRollout).
+func GetCanaryRollout(db *sql.DB, appName string) (*CanaryRollout, error) {
+	var r CanaryRollout
+	var stepsRaw string
+	var intervalSeconds int64
+	err := db.QueryRow(`
+		SELECT app_name, from_color, to_color, steps, step_idx, step_interval_seconds, abort_on_5xx_rate, started_at, updated_at
+		FROM canary_rollouts WHERE app_name = ?
+	`, appName).Scan(&r.AppName, &r.FromColor, &r.ToColor, &stepsRaw, &r.StepIdx, &intervalSeconds, &r.AbortOn5xxRate, &r.StartedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.StepInterval = time.Duration(intervalSeconds) * time.Second
+	for _, part := range strings.Split(stepsRaw, ",") {
+		step, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt canary_rollouts.steps for %s: %w", appName, err)
+		}
+		r.Steps = append(r.Steps, step)
+	}
+	return &r, nil
+}
+
+// DeleteCanaryRollout clears appName's in-progress rollout record, once it
+// has completed, aborted, or been explicitly given up on.
+func DeleteCanaryRollout(db *sql.DB, appName string) error {
+	_, err := db.Exec(`DELETE FROM canary_rollouts WHERE app_name = ?`, appName)
+	return err
+}
+
+// DeploymentCheckpoint is the deployment-package state that lives alongside
+// (rather than inside) current_state: restart policy progress, the
+// health-check streak, and any operator-set DesiredTransition - the fields
+// Resume can't reconstruct from current_state/deployment_events alone.
+// DesiredCancel/DesiredPause/DesiredForceRollback are nil when unset,
+// mirroring deployment.DesiredTransition's tri-state *bool fields.
+type DeploymentCheckpoint struct {
+	AppName              string
+	RetryCount           int
+	BackoffStartedAt     time.Time
+	BackoffDuration      time.Duration
+	DesiredCancel        *bool
+	DesiredPause         *bool
+	DesiredForceRollback *bool
+	HealthSuccessStreak  int
+	HealthFailStreak     int
+	HealthCheckStartedAt time.Time
+	UpdatedAt            time.Time
+}
+
+// UpsertDeploymentCheckpoint records appName's current retry/backoff/health
+// streak/desired-transition state, called after every ProcessEvent so a
+// crash mid-backoff or mid-health-check has enough left behind for Resume
+// to pick the streak back up instead of restarting it from zero.
+func UpsertDeploymentCheckpoint(db *sql.DB, c DeploymentCheckpoint) error {
+	var backoffStartedAt *time.Time
+	if !c.BackoffStartedAt.IsZero() {
+		t := c.BackoffStartedAt.UTC()
+		backoffStartedAt = &t
+	}
+	var healthCheckStartedAt *time.Time
+	if !c.HealthCheckStartedAt.IsZero() {
+		t := c.HealthCheckStartedAt.UTC()
+		healthCheckStartedAt = &t
+	}
+	_, err := db.Exec(`
+		INSERT INTO deployment_checkpoints (
+			app_name, retry_count, backoff_started_at, backoff_duration_ms,
+			desired_cancel, desired_pause, desired_force_rollback,
+			health_success_streak, health_fail_streak, health_check_started_at, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(app_name) DO UPDATE SET
+			retry_count=excluded.retry_count,
+			backoff_started_at=excluded.backoff_started_at,
+			backoff_duration_ms=excluded.backoff_duration_ms,
+			desired_cancel=excluded.desired_cancel,
+			desired_pause=excluded.desired_pause,
+			desired_force_rollback=excluded.desired_force_rollback,
+			health_success_streak=excluded.health_success_streak,
+			health_fail_streak=excluded.health_fail_streak,
+			health_check_started_at=excluded.health_check_started_at,
+			updated_at=excluded.updated_at
+	`, c.AppName, c.RetryCount, backoffStartedAt, int64(c.BackoffDuration/time.Millisecond),
+		c.DesiredCancel, c.DesiredPause, c.DesiredForceRollback,
+		c.HealthSuccessStreak, c.HealthFailStreak, healthCheckStartedAt, c.UpdatedAt.UTC())
+	return err
+}
+
+// GetDeploymentCheckpoint returns appName's checkpoint, or nil if none has
+// ever been recorded (no deployment has reached a ProcessEvent call since
+// this migration shipped).
+func GetDeploymentCheckpoint(db *sql.DB, appName string) (*DeploymentCheckpoint, error) {
+	var c DeploymentCheckpoint
+	var backoffStartedAt, healthCheckStartedAt sql.NullTime
+	var backoffDurationMS int64
+	var desiredCancel, desiredPause, desiredForceRollback sql.NullBool
+	err := db.QueryRow(`
+		SELECT app_name, retry_count, backoff_started_at, backoff_duration_ms,
+			desired_cancel, desired_pause, desired_force_rollback,
+			health_success_streak, health_fail_streak, health_check_started_at, updated_at
+		FROM deployment_checkpoints WHERE app_name = ?
+	`, appName).Scan(&c.AppName, &c.RetryCount, &backoffStartedAt, &backoffDurationMS,
+		&desiredCancel, &desiredPause, &desiredForceRollback,
+		&c.HealthSuccessStreak, &c.HealthFailStreak, &healthCheckStartedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if backoffStartedAt.Valid {
+		c.BackoffStartedAt = backoffStartedAt.Time
+	}
+	if healthCheckStartedAt.Valid {
+		c.HealthCheckStartedAt = healthCheckStartedAt.Time
+	}
+	c.BackoffDuration = time.Duration(backoffDurationMS) * time.Millisecond
+	if desiredCancel.Valid {
+		c.DesiredCancel = &desiredCancel.Bool
+	}
+	if desiredPause.Valid {
+		c.DesiredPause = &desiredPause.Bool
+	}
+	if desiredForceRollback.Valid {
+		c.DesiredForceRollback = &desiredForceRollback.Bool
+	}
+	return &c, nil
+}
+
+// ReleaseRun is one app's pre-rollout CurrentState snapshot within a
+// deploy-group release, identified by ReleaseID (shared across every app in
+// that release). If the release fails partway through, RollbackReleaseRun
+// uses it to put the app back exactly where it was: PreviousColor active,
+// PreviousImage deployed.
+type ReleaseRun struct {
+	ID            int64
+	ReleaseID     string
+	AppName       string
+	PreviousColor string
+	PreviousImage string
+	Status        string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Release run statuses: Pending until an app's switch has gone through,
+// Switched once it has (so a later failure knows to roll it back),
+// RolledBack once a rollback has put it back on PreviousColor.
+const (
+	ReleaseRunPending    = "pending"
+	ReleaseRunSwitched   = "switched"
+	ReleaseRunRolledBack = "rolled_back"
+)
+
+// InsertReleaseRun records appName's pre-rollout snapshot for releaseID with
+// status ReleaseRunPending, before handleDeployGroup attempts to deploy and
+// switch it.
+func InsertReleaseRun(db *sql.DB, releaseID, appName, previousColor, previousImage string) (int64, error) {
+	now := time.Now().UTC()
+	result, err := db.Exec(`
+		INSERT INTO release_runs (release_id, app_name, previous_color, previous_image, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, releaseID, appName, previousColor, previousImage, ReleaseRunPending, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateReleaseRunStatus moves id's release_runs row to status (one of the
+// ReleaseRun* constants), e.g. to ReleaseRunSwitched once that app's switch
+// succeeds, or ReleaseRunRolledBack once a failed release has reverted it.
+func UpdateReleaseRunStatus(db *sql.DB, id int64, status string) error {
+	_, err := db.Exec(`
+		UPDATE release_runs SET status = ?, updated_at = ? WHERE id = ?
+	`, status, time.Now().UTC(), id)
+	return err
+}
+
+// GetReleaseRuns returns every app's snapshot recorded for releaseID, in the
+// order they were inserted (topological deploy order), for
+// handleDeployGroup to walk in reverse when rolling a failed release back.
+func GetReleaseRuns(db *sql.DB, releaseID string) ([]ReleaseRun, error) {
+	rows, err := db.Query(`
+		SELECT id, release_id, app_name, previous_color, previous_image, status, created_at, updated_at
+		FROM release_runs WHERE release_id = ? ORDER BY id ASC
+	`, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []ReleaseRun
+	for rows.Next() {
+		var r ReleaseRun
+		if err := rows.Scan(&r.ID, &r.ReleaseID, &r.AppName, &r.PreviousColor, &r.PreviousImage, &r.Status, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
@@ -2,7 +2,10 @@ package state
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -60,6 +63,88 @@ func TestAppConfig_InsertAndQuery(t *testing.T) {
 	}
 }
 
+func TestAppConfig_InsertIsDedupedBySHA(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	id1, err := InsertAppConfig(db, "web-api", "foo: bar", "sha1")
+	if err != nil {
+		t.Fatalf("insert app config: %v", err)
+	}
+
+	id2, err := InsertAppConfig(db, "web-api", "foo: bar", "sha1")
+	if err != nil {
+		t.Fatalf("insert duplicate app config: %v", err)
+	}
+	if id1 != id2 {
+		t.Errorf("expected duplicate insert to return the same id, got %d and %d", id1, id2)
+	}
+
+	history, err := GetAppConfigHistory(db, "web-api")
+	if err != nil {
+		t.Fatalf("get config history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("expected dedup to leave exactly 1 config, got %d", len(history))
+	}
+
+	cfg, err := GetAppConfigBySHA(db, "web-api", "sha1")
+	if err != nil {
+		t.Fatalf("get app config by sha: %v", err)
+	}
+	if cfg.ID != id1 {
+		t.Errorf("expected GetAppConfigBySHA to return id %d, got %d", id1, cfg.ID)
+	}
+}
+
+func TestDiffAppConfigs(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	oldID, err := InsertAppConfig(db, "web-api", "image: v1\n", "sha1")
+	if err != nil {
+		t.Fatalf("insert old config: %v", err)
+	}
+	newID, err := InsertAppConfig(db, "web-api", "image: v2\n", "sha2")
+	if err != nil {
+		t.Fatalf("insert new config: %v", err)
+	}
+
+	diff, err := DiffAppConfigs(db, oldID, newID)
+	if err != nil {
+		t.Fatalf("diff app configs: %v", err)
+	}
+	if !strings.Contains(diff, "-image: v1") || !strings.Contains(diff, "+image: v2") {
+		t.Errorf("expected unified diff of the two YAMLs, got: %s", diff)
+	}
+}
+
+func TestPruneAppConfigs(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := InsertAppConfig(db, "web-api", fmt.Sprintf("image: v%d\n", i), fmt.Sprintf("sha%d", i)); err != nil {
+			t.Fatalf("insert config %d: %v", i, err)
+		}
+	}
+
+	if err := PruneAppConfigs(db, "web-api", 2); err != nil {
+		t.Fatalf("prune app configs: %v", err)
+	}
+
+	history, err := GetAppConfigHistory(db, "web-api")
+	if err != nil {
+		t.Fatalf("get config history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 configs retained, got %d", len(history))
+	}
+	if history[0].ConfigSHA != "sha4" || history[1].ConfigSHA != "sha3" {
+		t.Errorf("expected the 2 most recent configs retained, got: %+v", history)
+	}
+}
+
 func TestDeployment_InsertAndHistory(t *testing.T) {
 	db := openTestDB(t)
 	defer db.Close()
@@ -121,6 +206,58 @@ func TestDeploymentEvent_InsertAndQuery(t *testing.T) {
 	}
 }
 
+func TestGetEventsSinceAndAfter(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	cfgID, _ := InsertAppConfig(db, "web-api", "foo: bar", "sha1")
+	depID, _ := InsertDeployment(db, "web-api", cfgID, "img1", "success", "blue", nil)
+
+	id1, err := InsertDeploymentEvent(db, depID, "web-api", "deployment_started", "img1", nil)
+	if err != nil {
+		t.Fatalf("insert event 1: %v", err)
+	}
+	id2, err := InsertDeploymentEvent(db, depID, "web-api", "deployment_succeeded", "img1", nil)
+	if err != nil {
+		t.Fatalf("insert event 2: %v", err)
+	}
+
+	all, err := GetEventsSince(db, time.Time{})
+	if err != nil {
+		t.Fatalf("get events since zero: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(all))
+	}
+	if all[0].ID != id1 || all[1].ID != id2 {
+		t.Errorf("unexpected event order: %+v", all)
+	}
+
+	future, err := GetEventsSince(db, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("get events since future: %v", err)
+	}
+	if len(future) != 0 {
+		t.Errorf("expected no events after a future cutoff, got %d", len(future))
+	}
+
+	after, err := GetEventsAfter(db, id1)
+	if err != nil {
+		t.Fatalf("get events after: %v", err)
+	}
+	if len(after) != 1 || after[0].ID != id2 {
+		t.Errorf("expected just event 2 after id1, got %+v", after)
+	}
+
+	none, err := GetEventsAfter(db, id2)
+	if err != nil {
+		t.Fatalf("get events after last: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("expected no events after the last id, got %d", len(none))
+	}
+}
+
 func TestCurrentState_UpsertAndQuery(t *testing.T) {
 	db := openTestDB(t)
 	defer db.Close()
@@ -166,3 +303,109 @@ func TestCurrentState_UpsertAndQuery(t *testing.T) {
 		t.Errorf("expected 2 current states, got %d", len(all))
 	}
 }
+
+func TestCanaryRollout_UpsertGetDelete(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	if existing, err := GetCanaryRollout(db, "web-api"); err != nil || existing != nil {
+		t.Fatalf("expected no rollout before any Upsert, got %+v, err %v", existing, err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	rollout := CanaryRollout{
+		AppName:        "web-api",
+		FromColor:      "blue",
+		ToColor:        "green",
+		Steps:          []int{10, 25, 50, 100},
+		StepIdx:        1,
+		StepInterval:   30 * time.Second,
+		AbortOn5xxRate: 0.02,
+		StartedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := UpsertCanaryRollout(db, rollout); err != nil {
+		t.Fatalf("upsert canary rollout: %v", err)
+	}
+
+	got, err := GetCanaryRollout(db, "web-api")
+	if err != nil {
+		t.Fatalf("get canary rollout: %v", err)
+	}
+	if got == nil {
+		t.Fatal("expected a rollout, got nil")
+	}
+	if got.FromColor != "blue" || got.ToColor != "green" || got.StepIdx != 1 || got.StepInterval != 30*time.Second || got.AbortOn5xxRate != 0.02 {
+		t.Errorf("unexpected rollout: %+v", got)
+	}
+	if len(got.Steps) != 4 || got.Steps[0] != 10 || got.Steps[3] != 100 {
+		t.Errorf("unexpected steps: %v", got.Steps)
+	}
+
+	// Upsert again (advance step) should update in place, not duplicate.
+	rollout.StepIdx = 2
+	if err := UpsertCanaryRollout(db, rollout); err != nil {
+		t.Fatalf("upsert canary rollout step 2: %v", err)
+	}
+	got, err = GetCanaryRollout(db, "web-api")
+	if err != nil {
+		t.Fatalf("get canary rollout after advance: %v", err)
+	}
+	if got.StepIdx != 2 {
+		t.Errorf("StepIdx = %d, want 2", got.StepIdx)
+	}
+
+	if err := DeleteCanaryRollout(db, "web-api"); err != nil {
+		t.Fatalf("delete canary rollout: %v", err)
+	}
+	if gone, err := GetCanaryRollout(db, "web-api"); err != nil || gone != nil {
+		t.Fatalf("expected no rollout after delete, got %+v, err %v", gone, err)
+	}
+}
+
+func TestReleaseRuns_InsertUpdateGet(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	if runs, err := GetReleaseRuns(db, "release-1"); err != nil || len(runs) != 0 {
+		t.Fatalf("expected no runs before any Insert, got %+v, err %v", runs, err)
+	}
+
+	apiID, err := InsertReleaseRun(db, "release-1", "api", "blue", "api:v1")
+	if err != nil {
+		t.Fatalf("insert release run: %v", err)
+	}
+	webID, err := InsertReleaseRun(db, "release-1", "web", "green", "web:v1")
+	if err != nil {
+		t.Fatalf("insert release run: %v", err)
+	}
+
+	runs, err := GetReleaseRuns(db, "release-1")
+	if err != nil {
+		t.Fatalf("get release runs: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(runs))
+	}
+	if runs[0].AppName != "api" || runs[0].PreviousColor != "blue" || runs[0].PreviousImage != "api:v1" || runs[0].Status != ReleaseRunPending {
+		t.Errorf("unexpected first run: %+v", runs[0])
+	}
+	if runs[1].AppName != "web" || runs[1].ID != webID {
+		t.Errorf("unexpected second run: %+v", runs[1])
+	}
+
+	if err := UpdateReleaseRunStatus(db, apiID, ReleaseRunSwitched); err != nil {
+		t.Fatalf("update release run status: %v", err)
+	}
+	runs, err = GetReleaseRuns(db, "release-1")
+	if err != nil {
+		t.Fatalf("get release runs after update: %v", err)
+	}
+	if runs[0].Status != ReleaseRunSwitched {
+		t.Errorf("Status = %q, want %q", runs[0].Status, ReleaseRunSwitched)
+	}
+
+	if runs, err := GetReleaseRuns(db, "nonexistent-release"); err != nil || len(runs) != 0 {
+		t.Fatalf("expected no runs for unknown release, got %+v, err %v", runs, err)
+	}
+}
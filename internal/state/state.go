@@ -1,15 +1,30 @@
 package state
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// CurrentSchemaVersion is the AppState schema version LoadAppState migrates
+// every state file up to before unmarshalling it into the struct below, and
+// the version SaveAppState always persists. Bump it and register a migration
+// in stateMigrations whenever AppState gains a field that existing on-disk
+// files need a sensible default for.
+const CurrentSchemaVersion = 1
+
 type AppState struct {
+	// SchemaVersion records which version of this struct produced the file.
+	// Absent (zero) on every file written before schema versioning existed;
+	// LoadAppState always migrates it up to CurrentSchemaVersion before this
+	// struct ever sees a stale value.
+	SchemaVersion int `yaml:"schema_version"`
+
 	Name           string    `yaml:"name"`
 	CurrentImage   string    `yaml:"current_image"`
 	DesiredImage   string    `yaml:"desired_image"`
@@ -17,6 +32,27 @@ type AppState struct {
 	Status         string    `yaml:"status"`
 	LastDeployment time.Time `yaml:"last_deployment"`
 	LastUpdated    time.Time `yaml:"last_updated"`
+
+	// ServiceContainers tracks container IDs per service per color, keyed
+	// first by color ("blue"/"green") then by service name ("main" for the
+	// app's primary container, otherwise a docker.ServiceConfig.Name). Apps
+	// with no sidecars only ever populate the "main" entry.
+	ServiceContainers map[string]map[string]string `yaml:"service_containers,omitempty"`
+
+	// CurrentDigest holds a registry digest for CurrentImage's tag. Nothing in
+	// dockswap writes it: watcher.Watcher keeps the last-seen digest in the
+	// image_digests table (state.UpsertImageDigest) instead, so this field
+	// stays empty unless a state file sets it.
+	CurrentDigest string `yaml:"current_digest,omitempty"`
+
+	// PreviousImage, PreviousColor, and PreviousDeployment record the
+	// generation CompleteDeployment just superseded, so Rollback can swap
+	// back to it without consulting deployment history elsewhere. All three
+	// are empty on an app's first-ever deployment, since there is no prior
+	// generation yet.
+	PreviousImage      string    `yaml:"previous_image,omitempty"`
+	PreviousColor      string    `yaml:"previous_color,omitempty"`
+	PreviousDeployment time.Time `yaml:"previous_deployment,omitempty"`
 }
 
 type DeploymentStatus string
@@ -43,8 +79,13 @@ func LoadAppState(statePath string) (*AppState, error) {
 		return nil, fmt.Errorf("failed to read state file %s: %w", statePath, err)
 	}
 
+	migrated, err := migrateStateYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate state %s: %w", statePath, err)
+	}
+
 	var state AppState
-	if err := yaml.Unmarshal(data, &state); err != nil {
+	if err := yaml.Unmarshal(migrated, &state); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML state %s: %w", statePath, err)
 	}
 
@@ -55,6 +96,81 @@ func LoadAppState(statePath string) (*AppState, error) {
 	return &state, nil
 }
 
+// stateMigrations maps a target schema version to the function that
+// transforms the raw YAML tree of the version just below it into that
+// version. migrateStateYAML runs these in sequence, so migration N only ever
+// needs to assume its input is at version N-1.
+var stateMigrations = map[int]func(map[string]any) (map[string]any, error){
+	1: migrateToV1,
+}
+
+// migrateToV1 introduces CurrentDigest and the Previous* rollback-tracking
+// fields. All four default to their Go zero value (empty string, zero time),
+// which is exactly what a pre-v1 file's absence of these keys already means,
+// so the only real work is stamping the version.
+func migrateToV1(raw map[string]any) (map[string]any, error) {
+	for _, key := range []string{"current_digest", "previous_image", "previous_color"} {
+		if _, ok := raw[key]; !ok {
+			raw[key] = ""
+		}
+	}
+	raw["schema_version"] = 1
+	return raw, nil
+}
+
+// migrateStateYAML parses data's raw YAML tree, runs every migration needed
+// to bring it from whatever schema_version it declares (0 if absent, for
+// files written before this field existed) up to CurrentSchemaVersion, and
+// re-marshals the result for LoadAppState to unmarshal into the current
+// AppState struct. A file already at CurrentSchemaVersion round-trips through
+// unchanged. A file from a newer dockswap version than this build knows about
+// (schema_version > CurrentSchemaVersion) is rejected outright rather than
+// silently truncated to whatever this build understands.
+func migrateStateYAML(data []byte) ([]byte, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	version := 0
+	if v, ok := raw["schema_version"]; ok {
+		n, ok := v.(int)
+		if !ok {
+			return nil, fmt.Errorf("schema_version must be an integer, got %v", v)
+		}
+		version = n
+	}
+
+	if version > CurrentSchemaVersion {
+		return nil, fmt.Errorf("schema_version %d is newer than this build supports (max %d)", version, CurrentSchemaVersion)
+	}
+
+	for v := version + 1; v <= CurrentSchemaVersion; v++ {
+		migrate, ok := stateMigrations[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration registered to reach schema version %d", v)
+		}
+
+		var err error
+		raw, err = migrate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("migration to schema version %d failed: %w", v, err)
+		}
+	}
+
+	out, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-marshal migrated state: %w", err)
+	}
+	return out, nil
+}
+
+// SaveAppState persists state atomically: it first appends a record of the
+// transition to the per-app journal, then writes the state to a temp file,
+// fsyncs it, renames it over statePath, and fsyncs the parent directory. A
+// crash at any point leaves either the old state file or the new one intact
+// (never a half-written one), and the journal retains a record of the
+// transition even if the crash happens before the rename completes.
 func SaveAppState(statePath string, state *AppState) error {
 	if err := validateState(state); err != nil {
 		return fmt.Errorf("state validation failed: %w", err)
@@ -62,23 +178,236 @@ func SaveAppState(statePath string, state *AppState) error {
 
 	state.LastUpdated = time.Now().UTC()
 
+	dir := filepath.Dir(statePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	}
+
+	if err := appendJournal(statePath, state); err != nil {
+		return fmt.Errorf("failed to append journal for %s: %w", statePath, err)
+	}
+
+	if err := atomicWriteState(statePath, state); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", statePath, err)
+	}
+
+	return nil
+}
+
+// atomicWriteState writes state to <statePath>.tmp, fsyncs it, renames it
+// over statePath, then fsyncs the parent directory so the rename itself is
+// durable. This is the standard write-tmp/fsync/rename/fsync-dir sequence for
+// crash-safe file replacement on POSIX filesystems.
+func atomicWriteState(statePath string, state *AppState) error {
 	data, err := yaml.Marshal(state)
 	if err != nil {
 		return fmt.Errorf("failed to marshal state to YAML: %w", err)
 	}
 
-	dir := filepath.Dir(statePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	tmp := tmpStatePath(statePath)
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file %s: %w", tmp, err)
 	}
 
-	if err := os.WriteFile(statePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write state file %s: %w", statePath, err)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write temp file %s: %w", tmp, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to fsync temp file %s: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file %s: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, statePath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", tmp, statePath, err)
+	}
+
+	if err := fsyncDir(filepath.Dir(statePath)); err != nil {
+		return fmt.Errorf("failed to fsync state directory: %w", err)
 	}
 
 	return nil
 }
 
+func fsyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory %s: %w", dir, err)
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+func tmpStatePath(statePath string) string {
+	return statePath + ".tmp"
+}
+
+// JournalEntry is one append-only record of a state transition, written
+// alongside the state file so a crash mid-save can be diagnosed and, where
+// possible, rolled forward on the next load.
+type JournalEntry struct {
+	Seq       uint64    `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+	Status    string    `json:"status"`
+	State     AppState  `json:"state"`
+}
+
+func journalPath(statePath string) string {
+	ext := filepath.Ext(statePath)
+	return strings.TrimSuffix(statePath, ext) + ".journal"
+}
+
+// appendJournal records state as the next entry in statePath's journal,
+// assigning it the next monotonic sequence number and fsyncing the append so
+// it survives a crash that happens before the subsequent rename in
+// atomicWriteState. The entry's Timestamp is state.LastUpdated rather than a
+// fresh time.Now() - SaveAppState stamps LastUpdated before calling here, so
+// reusing it keeps the journal and the state file in lockstep; a second,
+// later timestamp would make LoadAppStateWithRecovery's "journal is ahead of
+// the state" check fire on every normal save, not just a crash-torn one.
+func appendJournal(statePath string, state *AppState) error {
+	path := journalPath(statePath)
+
+	seq, err := nextJournalSeq(path)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(JournalEntry{
+		Seq:       seq,
+		Timestamp: state.LastUpdated,
+		Status:    state.Status,
+		State:     *state,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append journal entry to %s: %w", path, err)
+	}
+
+	return f.Sync()
+}
+
+// readJournal returns the journal entries for statePath in order. A missing
+// journal is not an error (returns nil); a trailing line that fails to parse
+// is assumed to be a torn write from a crash mid-append and is dropped, since
+// every entry before it is still fsynced and trustworthy.
+func readJournal(statePath string) ([]JournalEntry, error) {
+	data, err := os.ReadFile(journalPath(statePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []JournalEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			break
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func nextJournalSeq(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 1, nil
+		}
+		return 0, fmt.Errorf("failed to read journal file %s: %w", path, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i] == "" {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			continue
+		}
+		return entry.Seq + 1, nil
+	}
+
+	return 1, nil
+}
+
+// LoadAppStateWithRecovery loads statePath, repairing after a crash that
+// interrupted a previous SaveAppState. It first checks for a leftover
+// <statePath>.tmp: since atomicWriteState only renames it into place after a
+// successful fsync, a tmp file left behind means the rename itself never
+// happened, and the tmp content is the most recently durable write. It then
+// compares the journal tail against the loaded state: if the journal recorded
+// a transition with no matching save (the crash landed between the journal
+// append and the rename), the on-disk state cannot be trusted to reflect
+// reality and is marked StatusUnknown for operator review rather than being
+// silently resumed.
+func LoadAppStateWithRecovery(statePath string) (*AppState, error) {
+	state, loadErr := LoadAppState(statePath)
+	recovered := false
+
+	tmp := tmpStatePath(statePath)
+	if tmpData, err := os.ReadFile(tmp); err == nil {
+		var tmpState AppState
+		if yaml.Unmarshal(tmpData, &tmpState) == nil && validateState(&tmpState) == nil {
+			if loadErr != nil || tmpState.LastUpdated.After(state.LastUpdated) {
+				state, loadErr = &tmpState, nil
+				recovered = true
+			}
+		}
+		if rmErr := os.Remove(tmp); rmErr != nil && !os.IsNotExist(rmErr) {
+			return nil, fmt.Errorf("failed to remove stale tmp file %s: %w", tmp, rmErr)
+		}
+	}
+
+	entries, err := readJournal(statePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal for %s: %w", statePath, err)
+	}
+
+	switch {
+	case loadErr != nil && len(entries) > 0:
+		unknown := entries[len(entries)-1].State
+		unknown.Status = string(StatusUnknown)
+		state, loadErr = &unknown, nil
+		recovered = true
+	case loadErr != nil:
+		return nil, loadErr
+	case len(entries) > 0 && entries[len(entries)-1].Timestamp.After(state.LastUpdated):
+		state.Status = string(StatusUnknown)
+		recovered = true
+	}
+
+	if recovered {
+		if err := SaveAppState(statePath, state); err != nil {
+			return nil, fmt.Errorf("failed to persist recovered state for %s: %w", statePath, err)
+		}
+	}
+
+	return state, nil
+}
+
 func LoadAllStates(stateDir string) (map[string]*AppState, error) {
 	states := make(map[string]*AppState)
 
@@ -88,7 +417,7 @@ func LoadAllStates(stateDir string) (map[string]*AppState, error) {
 		}
 
 		if !info.IsDir() && (filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml") {
-			state, err := LoadAppState(path)
+			state, err := LoadAppStateWithRecovery(path)
 			if err != nil {
 				return fmt.Errorf("failed to load state %s: %w", path, err)
 			}
@@ -108,6 +437,7 @@ func LoadAllStates(stateDir string) (map[string]*AppState, error) {
 func CreateInitialState(name, image string, activeColor Color) *AppState {
 	now := time.Now().UTC()
 	return &AppState{
+		SchemaVersion:  CurrentSchemaVersion,
 		Name:           name,
 		CurrentImage:   image,
 		DesiredImage:   image,
@@ -130,6 +460,10 @@ func (s *AppState) SetDraining() {
 }
 
 func (s *AppState) CompleteDeployment(newActiveColor Color) {
+	s.PreviousImage = s.CurrentImage
+	s.PreviousColor = s.ActiveColor
+	s.PreviousDeployment = s.LastDeployment
+
 	s.CurrentImage = s.DesiredImage
 	s.ActiveColor = string(newActiveColor)
 	s.Status = string(StatusStable)
@@ -147,6 +481,25 @@ func (s *AppState) StartRollback() {
 	s.LastUpdated = time.Now().UTC()
 }
 
+// Rollback swaps the current generation back to the previous one CompleteDeployment
+// recorded and marks the app as rolling back. It fails if there is no previous
+// generation to roll back to (the app's first-ever deployment). The swap makes
+// the superseded generation the new previous one, so a second Rollback swaps
+// back again.
+func (s *AppState) Rollback() error {
+	if s.PreviousImage == "" || s.PreviousColor == "" {
+		return fmt.Errorf("app %s has no previous generation to roll back to", s.Name)
+	}
+
+	s.PreviousImage, s.CurrentImage = s.CurrentImage, s.PreviousImage
+	s.PreviousColor, s.ActiveColor = s.ActiveColor, s.PreviousColor
+	s.DesiredImage = s.CurrentImage
+	s.Status = string(StatusRollingBack)
+	s.LastUpdated = time.Now().UTC()
+
+	return nil
+}
+
 func (s *AppState) IsDeploymentInProgress() bool {
 	status := DeploymentStatus(s.Status)
 	return status == StatusDeploying || status == StatusDraining || status == StatusRollingBack
@@ -156,6 +509,29 @@ func (s *AppState) NeedsDeployment() bool {
 	return s.CurrentImage != s.DesiredImage && !s.IsDeploymentInProgress()
 }
 
+// SetServiceContainerID records the container ID backing service (or "main"
+// for the app's primary container) on the given color.
+func (s *AppState) SetServiceContainerID(color, service, containerID string) {
+	if s.ServiceContainers == nil {
+		s.ServiceContainers = make(map[string]map[string]string)
+	}
+	if s.ServiceContainers[color] == nil {
+		s.ServiceContainers[color] = make(map[string]string)
+	}
+	s.ServiceContainers[color][service] = containerID
+}
+
+// GetServiceContainerID returns the container ID previously recorded for
+// service on the given color, if any.
+func (s *AppState) GetServiceContainerID(color, service string) (string, bool) {
+	colorContainers, ok := s.ServiceContainers[color]
+	if !ok {
+		return "", false
+	}
+	id, ok := colorContainers[service]
+	return id, ok
+}
+
 func (s *AppState) GetInactiveColor() Color {
 	if s.ActiveColor == string(ColorBlue) {
 		return ColorGreen
@@ -180,6 +556,12 @@ func validateState(state *AppState) error {
 		return fmt.Errorf("active_color must be 'blue' or 'green', got: %s", state.ActiveColor)
 	}
 
+	// PreviousColor is empty on a first-ever deployment; once set (by
+	// CompleteDeployment) it's held to the same constraint as ActiveColor.
+	if state.PreviousColor != "" && state.PreviousColor != string(ColorBlue) && state.PreviousColor != string(ColorGreen) {
+		return fmt.Errorf("previous_color must be 'blue' or 'green', got: %s", state.PreviousColor)
+	}
+
 	validStatuses := map[string]bool{
 		string(StatusStable):      true,
 		string(StatusDeploying):   true,
@@ -205,5 +587,9 @@ func validateState(state *AppState) error {
 		return fmt.Errorf("last_updated cannot be before last_deployment")
 	}
 
+	if state.SchemaVersion != CurrentSchemaVersion {
+		return fmt.Errorf("unsupported schema_version %d, expected %d", state.SchemaVersion, CurrentSchemaVersion)
+	}
+
 	return nil
 }
@@ -3,8 +3,11 @@ package state
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestValidateState(t *testing.T) {
@@ -19,6 +22,7 @@ func TestValidateState(t *testing.T) {
 		{
 			name: "valid state",
 			state: AppState{
+				SchemaVersion:  CurrentSchemaVersion,
 				Name:           "test-app",
 				CurrentImage:   "nginx:1.21",
 				DesiredImage:   "nginx:1.21",
@@ -96,6 +100,21 @@ func TestValidateState(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid status: invalid",
 		},
+		{
+			name: "invalid previous color",
+			state: AppState{
+				Name:           "test-app",
+				CurrentImage:   "nginx:1.21",
+				DesiredImage:   "nginx:1.21",
+				ActiveColor:    "blue",
+				PreviousColor:  "red",
+				Status:         "stable",
+				LastDeployment: now,
+				LastUpdated:    now,
+			},
+			wantErr: true,
+			errMsg:  "previous_color must be 'blue' or 'green', got: red",
+		},
 		{
 			name: "zero last deployment",
 			state: AppState{
@@ -136,6 +155,21 @@ func TestValidateState(t *testing.T) {
 			wantErr: true,
 			errMsg:  "last_updated cannot be before last_deployment",
 		},
+		{
+			name: "unsupported schema version",
+			state: AppState{
+				SchemaVersion:  99,
+				Name:           "test-app",
+				CurrentImage:   "nginx:1.21",
+				DesiredImage:   "nginx:1.21",
+				ActiveColor:    "blue",
+				Status:         "stable",
+				LastDeployment: now,
+				LastUpdated:    now,
+			},
+			wantErr: true,
+			errMsg:  "unsupported schema_version 99, expected 1",
+		},
 	}
 
 	for _, tt := range tests {
@@ -239,11 +273,88 @@ invalid: yaml: structure`
 	})
 }
 
+func TestMigrateStateYAML(t *testing.T) {
+	t.Run("unversioned legacy file migrates to v1", func(t *testing.T) {
+		legacyYAML := `name: "test-app"
+current_image: "nginx:1.21"
+desired_image: "nginx:1.21"
+active_color: "blue"
+status: "stable"
+last_deployment: "2025-07-23T10:30:00Z"
+last_updated: "2025-07-23T10:35:00Z"`
+
+		tempDir := t.TempDir()
+		stateFile := filepath.Join(tempDir, "legacy.yaml")
+		if err := os.WriteFile(stateFile, []byte(legacyYAML), 0644); err != nil {
+			t.Fatalf("WriteFile() unexpected error = %v", err)
+		}
+
+		state, err := LoadAppState(stateFile)
+		if err != nil {
+			t.Fatalf("LoadAppState() unexpected error = %v", err)
+		}
+		if state.SchemaVersion != CurrentSchemaVersion {
+			t.Errorf("SchemaVersion = %d, want %d", state.SchemaVersion, CurrentSchemaVersion)
+		}
+		if state.CurrentDigest != "" || state.PreviousImage != "" || state.PreviousColor != "" {
+			t.Errorf("migrated legacy state should default new fields to empty, got digest=%q previous_image=%q previous_color=%q",
+				state.CurrentDigest, state.PreviousImage, state.PreviousColor)
+		}
+	})
+
+	t.Run("v1 file round trips unchanged", func(t *testing.T) {
+		tempDir := t.TempDir()
+		stateFile := filepath.Join(tempDir, "v1.yaml")
+
+		state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+		if err := SaveAppState(stateFile, state); err != nil {
+			t.Fatalf("SaveAppState() unexpected error = %v", err)
+		}
+
+		loaded, err := LoadAppState(stateFile)
+		if err != nil {
+			t.Fatalf("LoadAppState() unexpected error = %v", err)
+		}
+		if loaded.SchemaVersion != CurrentSchemaVersion {
+			t.Errorf("SchemaVersion = %d, want %d", loaded.SchemaVersion, CurrentSchemaVersion)
+		}
+		if loaded.Name != state.Name || loaded.CurrentImage != state.CurrentImage {
+			t.Errorf("loaded state = %+v, want it to match saved state %+v", loaded, state)
+		}
+	})
+
+	t.Run("file from a future schema version is rejected", func(t *testing.T) {
+		futureYAML := `schema_version: 99
+name: "test-app"
+current_image: "nginx:1.21"
+desired_image: "nginx:1.21"
+active_color: "blue"
+status: "stable"
+last_deployment: "2025-07-23T10:30:00Z"
+last_updated: "2025-07-23T10:35:00Z"`
+
+		tempDir := t.TempDir()
+		stateFile := filepath.Join(tempDir, "future.yaml")
+		if err := os.WriteFile(stateFile, []byte(futureYAML), 0644); err != nil {
+			t.Fatalf("WriteFile() unexpected error = %v", err)
+		}
+
+		_, err := LoadAppState(stateFile)
+		if err == nil {
+			t.Fatal("LoadAppState() expected error for a file from a newer schema version")
+		}
+		if !strings.Contains(err.Error(), "schema_version 99 is newer than this build supports") {
+			t.Errorf("LoadAppState() error = %v, want it to mention the unsupported schema version", err)
+		}
+	})
+}
+
 func TestSaveAppState(t *testing.T) {
 	tempDir := t.TempDir()
 
 	now := time.Now().UTC()
 	state := &AppState{
+		SchemaVersion:  CurrentSchemaVersion,
 		Name:           "test-app",
 		CurrentImage:   "nginx:1.21",
 		DesiredImage:   "nginx:1.22",
@@ -439,6 +550,12 @@ func TestAppStateStateMethods(t *testing.T) {
 		if !state.LastDeployment.After(beforeComplete) {
 			t.Errorf("CompleteDeployment() should update last_deployment timestamp")
 		}
+		if state.PreviousImage != "nginx:1.21" {
+			t.Errorf("CompleteDeployment() previous_image = %v, want %v", state.PreviousImage, "nginx:1.21")
+		}
+		if state.PreviousColor != string(ColorBlue) {
+			t.Errorf("CompleteDeployment() previous_color = %v, want %v", state.PreviousColor, ColorBlue)
+		}
 	})
 
 	t.Run("IsDeploymentInProgress", func(t *testing.T) {
@@ -484,3 +601,214 @@ func TestAppStateStateMethods(t *testing.T) {
 		}
 	})
 }
+
+func TestAppState_ServiceContainers(t *testing.T) {
+	state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+
+	if _, ok := state.GetServiceContainerID("blue", "main"); ok {
+		t.Fatal("expected no container ID before SetServiceContainerID")
+	}
+
+	state.SetServiceContainerID("blue", "main", "container123")
+	state.SetServiceContainerID("blue", "db-proxy", "container456")
+	state.SetServiceContainerID("green", "main", "container789")
+
+	id, ok := state.GetServiceContainerID("blue", "main")
+	if !ok || id != "container123" {
+		t.Errorf("GetServiceContainerID(blue, main) = %v, %v, want container123, true", id, ok)
+	}
+
+	id, ok = state.GetServiceContainerID("blue", "db-proxy")
+	if !ok || id != "container456" {
+		t.Errorf("GetServiceContainerID(blue, db-proxy) = %v, %v, want container456, true", id, ok)
+	}
+
+	id, ok = state.GetServiceContainerID("green", "main")
+	if !ok || id != "container789" {
+		t.Errorf("GetServiceContainerID(green, main) = %v, %v, want container789, true", id, ok)
+	}
+}
+
+func TestSaveAppState_WritesJournal(t *testing.T) {
+	tempDir := t.TempDir()
+	stateFile := filepath.Join(tempDir, "journal-test.yaml")
+
+	state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+	if err := SaveAppState(stateFile, state); err != nil {
+		t.Fatalf("SaveAppState() unexpected error = %v", err)
+	}
+
+	state.SetDeploying("nginx:1.22")
+	if err := SaveAppState(stateFile, state); err != nil {
+		t.Fatalf("SaveAppState() unexpected error = %v", err)
+	}
+
+	entries, err := readJournal(stateFile)
+	if err != nil {
+		t.Fatalf("readJournal() unexpected error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("readJournal() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Seq != 1 || entries[1].Seq != 2 {
+		t.Errorf("journal sequence numbers = %d, %d, want 1, 2", entries[0].Seq, entries[1].Seq)
+	}
+	if entries[1].Status != string(StatusDeploying) {
+		t.Errorf("journal entry status = %v, want %v", entries[1].Status, StatusDeploying)
+	}
+
+	if _, err := os.Stat(tmpStatePath(stateFile)); !os.IsNotExist(err) {
+		t.Errorf("tmp file %s should not remain after a successful save", tmpStatePath(stateFile))
+	}
+}
+
+func TestLoadAppStateWithRecovery_PromotesStaleTmp(t *testing.T) {
+	tempDir := t.TempDir()
+	stateFile := filepath.Join(tempDir, "recover-test.yaml")
+
+	state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+	if err := SaveAppState(stateFile, state); err != nil {
+		t.Fatalf("SaveAppState() unexpected error = %v", err)
+	}
+
+	// Simulate a crash between writing the tmp file and the rename: a newer
+	// tmp sits alongside the older, already-renamed final file.
+	state.CompleteDeployment(ColorGreen)
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() unexpected error = %v", err)
+	}
+	if err := os.WriteFile(tmpStatePath(stateFile), data, 0644); err != nil {
+		t.Fatalf("WriteFile() unexpected error = %v", err)
+	}
+
+	recovered, err := LoadAppStateWithRecovery(stateFile)
+	if err != nil {
+		t.Fatalf("LoadAppStateWithRecovery() unexpected error = %v", err)
+	}
+	if recovered.ActiveColor != string(ColorGreen) {
+		t.Errorf("recovered.ActiveColor = %v, want %v", recovered.ActiveColor, ColorGreen)
+	}
+	if _, err := os.Stat(tmpStatePath(stateFile)); !os.IsNotExist(err) {
+		t.Errorf("tmp file should be cleaned up after recovery")
+	}
+}
+
+func TestLoadAppStateWithRecovery_MarksUnknownOnStaleJournal(t *testing.T) {
+	tempDir := t.TempDir()
+	stateFile := filepath.Join(tempDir, "unknown-test.yaml")
+
+	state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+	if err := SaveAppState(stateFile, state); err != nil {
+		t.Fatalf("SaveAppState() unexpected error = %v", err)
+	}
+
+	// Simulate a crash right after a journal append but before the save that
+	// should have followed it: the journal moves ahead of the state file.
+	state.SetDeploying("nginx:1.22")
+	if err := appendJournal(stateFile, state); err != nil {
+		t.Fatalf("appendJournal() unexpected error = %v", err)
+	}
+
+	recovered, err := LoadAppStateWithRecovery(stateFile)
+	if err != nil {
+		t.Fatalf("LoadAppStateWithRecovery() unexpected error = %v", err)
+	}
+	if recovered.Status != string(StatusUnknown) {
+		t.Errorf("recovered.Status = %v, want %v", recovered.Status, StatusUnknown)
+	}
+}
+
+func TestLoadAppStateWithRecovery_NoOpWhenConsistent(t *testing.T) {
+	tempDir := t.TempDir()
+	stateFile := filepath.Join(tempDir, "consistent-test.yaml")
+
+	state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+	if err := SaveAppState(stateFile, state); err != nil {
+		t.Fatalf("SaveAppState() unexpected error = %v", err)
+	}
+
+	recovered, err := LoadAppStateWithRecovery(stateFile)
+	if err != nil {
+		t.Fatalf("LoadAppStateWithRecovery() unexpected error = %v", err)
+	}
+	if recovered.Status != string(StatusStable) {
+		t.Errorf("recovered.Status = %v, want %v", recovered.Status, StatusStable)
+	}
+}
+
+func TestAppState_Rollback(t *testing.T) {
+	t.Run("no previous generation", func(t *testing.T) {
+		state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+
+		if err := state.Rollback(); err == nil {
+			t.Errorf("Rollback() expected error when there is no previous generation")
+		}
+	})
+
+	t.Run("swaps current and previous generation", func(t *testing.T) {
+		state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+		state.SetDeploying("nginx:1.22")
+		state.CompleteDeployment(ColorGreen)
+
+		if err := state.Rollback(); err != nil {
+			t.Fatalf("Rollback() unexpected error = %v", err)
+		}
+
+		if state.CurrentImage != "nginx:1.21" {
+			t.Errorf("Rollback() current_image = %v, want %v", state.CurrentImage, "nginx:1.21")
+		}
+		if state.DesiredImage != "nginx:1.21" {
+			t.Errorf("Rollback() desired_image = %v, want %v", state.DesiredImage, "nginx:1.21")
+		}
+		if state.ActiveColor != string(ColorBlue) {
+			t.Errorf("Rollback() active_color = %v, want %v", state.ActiveColor, ColorBlue)
+		}
+		if state.PreviousImage != "nginx:1.22" {
+			t.Errorf("Rollback() previous_image = %v, want %v", state.PreviousImage, "nginx:1.22")
+		}
+		if state.PreviousColor != string(ColorGreen) {
+			t.Errorf("Rollback() previous_color = %v, want %v", state.PreviousColor, ColorGreen)
+		}
+		if state.Status != string(StatusRollingBack) {
+			t.Errorf("Rollback() status = %v, want %v", state.Status, StatusRollingBack)
+		}
+	})
+
+	t.Run("round trips through SaveAppState/LoadAppState", func(t *testing.T) {
+		tempDir := t.TempDir()
+		stateFile := filepath.Join(tempDir, "rollback-test.yaml")
+
+		state := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+		state.SetDeploying("nginx:1.22")
+		state.CompleteDeployment(ColorGreen)
+		if err := state.Rollback(); err != nil {
+			t.Fatalf("Rollback() unexpected error = %v", err)
+		}
+
+		if err := SaveAppState(stateFile, state); err != nil {
+			t.Fatalf("SaveAppState() unexpected error = %v", err)
+		}
+
+		loaded, err := LoadAppState(stateFile)
+		if err != nil {
+			t.Fatalf("LoadAppState() unexpected error = %v", err)
+		}
+
+		if loaded.CurrentImage != state.CurrentImage {
+			t.Errorf("loaded.CurrentImage = %v, want %v", loaded.CurrentImage, state.CurrentImage)
+		}
+		if loaded.ActiveColor != state.ActiveColor {
+			t.Errorf("loaded.ActiveColor = %v, want %v", loaded.ActiveColor, state.ActiveColor)
+		}
+		if loaded.PreviousImage != state.PreviousImage {
+			t.Errorf("loaded.PreviousImage = %v, want %v", loaded.PreviousImage, state.PreviousImage)
+		}
+		if loaded.PreviousColor != state.PreviousColor {
+			t.Errorf("loaded.PreviousColor = %v, want %v", loaded.PreviousColor, state.PreviousColor)
+		}
+		if loaded.Status != state.Status {
+			t.Errorf("loaded.Status = %v, want %v", loaded.Status, state.Status)
+		}
+	})
+}
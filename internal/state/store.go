@@ -0,0 +1,62 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AppStateStore abstracts where an app's AppState is persisted, so a
+// caller like workspace.Workspace depends on this interface instead of
+// assuming YAML-on-disk. FileAppStateStore (the default, and the only
+// implementation today) is exactly LoadAppState/SaveAppState/LoadAllStates'
+// existing on-disk layout; a future store backed by one of
+// workspace.DeploymentStore's SQL drivers could satisfy the same interface.
+type AppStateStore interface {
+	// Load returns appName's state, or ok=false if no state file exists
+	// for it yet.
+	Load(appName string) (state *AppState, ok bool, err error)
+	// LoadAll returns every app's state, keyed by AppState.Name.
+	LoadAll() (map[string]*AppState, error)
+	// Save persists state for appName, creating or overwriting its file.
+	Save(appName string, state *AppState) error
+}
+
+// FileAppStateStore is the default AppStateStore: one "<appName>.yaml" file
+// per app under Dir.
+type FileAppStateStore struct {
+	Dir string
+}
+
+// NewFileAppStateStore builds a FileAppStateStore rooted at dir.
+func NewFileAppStateStore(dir string) *FileAppStateStore {
+	return &FileAppStateStore{Dir: dir}
+}
+
+func (s *FileAppStateStore) path(appName string) string {
+	return filepath.Join(s.Dir, appName+".yaml")
+}
+
+func (s *FileAppStateStore) Load(appName string) (*AppState, bool, error) {
+	path := s.path(appName)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("failed to access state file %s: %w", path, err)
+	}
+
+	st, err := LoadAppStateWithRecovery(path)
+	if err != nil {
+		return nil, false, err
+	}
+	return st, true, nil
+}
+
+func (s *FileAppStateStore) LoadAll() (map[string]*AppState, error) {
+	return LoadAllStates(s.Dir)
+}
+
+func (s *FileAppStateStore) Save(appName string, state *AppState) error {
+	return SaveAppState(s.path(appName), state)
+}
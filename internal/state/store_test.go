@@ -0,0 +1,58 @@
+package state
+
+import (
+	"testing"
+)
+
+func TestFileAppStateStore(t *testing.T) {
+	store := NewFileAppStateStore(t.TempDir())
+
+	t.Run("load missing app returns ok=false", func(t *testing.T) {
+		st, ok, err := store.Load("missing-app")
+		if err != nil {
+			t.Fatalf("Load() unexpected error: %v", err)
+		}
+		if ok {
+			t.Errorf("Load() ok = true, want false")
+		}
+		if st != nil {
+			t.Errorf("Load() state = %v, want nil", st)
+		}
+	})
+
+	t.Run("save then load round-trips", func(t *testing.T) {
+		want := CreateInitialState("test-app", "nginx:1.21", ColorBlue)
+
+		if err := store.Save("test-app", want); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		got, ok, err := store.Load("test-app")
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if !ok {
+			t.Fatalf("Load() ok = false, want true")
+		}
+		if got.Name != want.Name || got.CurrentImage != want.CurrentImage {
+			t.Errorf("Load() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("load all returns every saved app", func(t *testing.T) {
+		other := CreateInitialState("other-app", "redis:7", ColorGreen)
+		if err := store.Save("other-app", other); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		all, err := store.LoadAll()
+		if err != nil {
+			t.Fatalf("LoadAll() failed: %v", err)
+		}
+		for _, name := range []string{"test-app", "other-app"} {
+			if _, ok := all[name]; !ok {
+				t.Errorf("LoadAll() missing app %q", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,182 @@
+// Package watcher polls an image registry for digest changes, Watchtower
+// style, and triggers an automatic blue/green rollout through a Deployer
+// (normally a docker.DeploymentOrchestrator) when the currently deployed
+// tag starts pointing at a new digest.
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ImageRef is a parsed "[registry/]repository[:tag]" image reference.
+type ImageRef struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// ParseImageRef splits image into registry host, repository and tag using
+// the same heuristics as the Docker CLI: a tag is the text after the last
+// ":" as long as it doesn't contain a "/" (which would mean it's a port
+// number in the registry host), and a leading path segment is treated as
+// the registry host if it looks like one (contains "." or ":", or is
+// "localhost"). Bare names default to registry-1.docker.io/library/<name>;
+// the library/ default namespace is a Docker Hub convention, so it's only
+// applied when Registry is registry-1.docker.io, not a custom registry.
+func ParseImageRef(image string) ImageRef {
+	ref := ImageRef{Registry: "registry-1.docker.io", Tag: "latest"}
+
+	repository := image
+	if idx := strings.LastIndex(repository, ":"); idx != -1 && !strings.Contains(repository[idx:], "/") {
+		ref.Tag = repository[idx+1:]
+		repository = repository[:idx]
+	}
+
+	if slash := strings.Index(repository, "/"); slash != -1 {
+		host := repository[:slash]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			repository = repository[slash+1:]
+		}
+	}
+
+	if ref.Registry == "registry-1.docker.io" && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	ref.Repository = repository
+
+	return ref
+}
+
+// RegistryClient checks a v2 registry for the current digest of a tag via a
+// manifest HEAD request, mirroring how Watchtower detects updated images
+// without pulling them first.
+type RegistryClient struct {
+	client *http.Client
+	scheme string
+}
+
+// NewRegistryClient creates a RegistryClient that talks to registries over
+// HTTPS. Tests point it at a plain-HTTP httptest.Server via SetScheme.
+func NewRegistryClient(timeout time.Duration) *RegistryClient {
+	return &RegistryClient{
+		client: &http.Client{Timeout: timeout},
+		scheme: "https",
+	}
+}
+
+// SetScheme overrides the URL scheme used to reach the registry; tests use
+// this to point at a plain-HTTP httptest.Server.
+func (rc *RegistryClient) SetScheme(scheme string) {
+	rc.scheme = scheme
+}
+
+// manifestAccept lists the manifest media types dockswap is willing to
+// read the digest of, newest-first, matching what `docker pull` sends.
+var manifestAccept = strings.Join([]string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}, ", ")
+
+// maxHeadRetries bounds how many times HeadManifestDigest retries a
+// throttled or unhealthy registry before giving up and returning its last
+// error to the caller (which, absent Watch.WarnOnHeadFailure, skips this
+// poll rather than blocking the next one).
+const maxHeadRetries = 3
+
+// headRetryBase is the backoff before the first retry; each subsequent
+// retry doubles it, with up to 50% random jitter added so a fleet of
+// dockswap instances watching the same tag don't all hammer the registry
+// again in lockstep after a 429. A var, not a const, so tests can shrink it.
+var headRetryBase = 250 * time.Millisecond
+
+// HeadManifestDigest issues a HEAD /v2/<repository>/manifests/<tag> request
+// and returns the registry's Docker-Content-Digest response header. auth,
+// if non-empty, is sent as a "Basic <auth>" Authorization header (a
+// base64-encoded "user:password" pair, as in config.Watch.PollRegistryAuth).
+// A 429 or 5xx response is retried with exponential backoff and jitter, up
+// to maxHeadRetries times, since those mean "try again later" rather than
+// "this tag doesn't exist".
+func (rc *RegistryClient) HeadManifestDigest(ctx context.Context, ref ImageRef, auth string) (string, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= maxHeadRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepWithJitter(ctx, attempt); err != nil {
+				return "", err
+			}
+		}
+
+		digest, retryable, err := rc.headOnce(ctx, ref, auth)
+		if err == nil {
+			return digest, nil
+		}
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+
+	return "", lastErr
+}
+
+// headOnce issues a single manifest HEAD request. retryable reports whether
+// err is worth a backed-off retry (a 429/5xx response, or a transport-level
+// failure reaching the registry at all) as opposed to a permanent problem
+// with the request itself.
+func (rc *RegistryClient) headOnce(ctx context.Context, ref ImageRef, auth string) (digest string, retryable bool, err error) {
+	url := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", rc.scheme, ref.Registry, ref.Repository, ref.Tag)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to build manifest request for %s: %w", url, err)
+	}
+	req.Header.Set("Accept", manifestAccept)
+	if auth != "" {
+		req.Header.Set("Authorization", "Basic "+auth)
+	}
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to reach registry at %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return "", true, fmt.Errorf("registry returned status %d for %s", resp.StatusCode, url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("registry returned status %d for %s", resp.StatusCode, url)
+	}
+
+	digest = resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", false, fmt.Errorf("registry response for %s is missing Docker-Content-Digest", url)
+	}
+
+	return digest, false, nil
+}
+
+// sleepWithJitter waits headRetryBase*2^(attempt-1), plus up to 50% random
+// jitter, or returns ctx's error if it's canceled first.
+func sleepWithJitter(ctx context.Context, attempt int) error {
+	backoff := headRetryBase * time.Duration(1<<uint(attempt-1))
+	backoff += time.Duration(rand.Int63n(int64(backoff) / 2))
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
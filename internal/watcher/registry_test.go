@@ -0,0 +1,185 @@
+package watcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image string
+		want  ImageRef
+	}{
+		{
+			image: "nginx",
+			want:  ImageRef{Registry: "registry-1.docker.io", Repository: "library/nginx", Tag: "latest"},
+		},
+		{
+			image: "nginx:1.21",
+			want:  ImageRef{Registry: "registry-1.docker.io", Repository: "library/nginx", Tag: "1.21"},
+		},
+		{
+			image: "myorg/myapp:v2",
+			want:  ImageRef{Registry: "registry-1.docker.io", Repository: "myorg/myapp", Tag: "v2"},
+		},
+		{
+			image: "registry.example.com/myorg/myapp:v2",
+			want:  ImageRef{Registry: "registry.example.com", Repository: "myorg/myapp", Tag: "v2"},
+		},
+		{
+			image: "localhost:5000/myapp:v2",
+			want:  ImageRef{Registry: "localhost:5000", Repository: "myapp", Tag: "v2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseImageRef(tt.image))
+		})
+	}
+}
+
+func TestRegistryClient_HeadManifestDigest(t *testing.T) {
+	t.Run("returns the digest header on success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodHead, r.Method)
+			assert.Equal(t, "/v2/myorg/myapp/manifests/v2", r.URL.Path)
+			w.Header().Set("Docker-Content-Digest", "sha256:abc123")
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		rc := NewRegistryClient(time.Second)
+		rc.SetScheme("http")
+
+		ref := ImageRef{Registry: strings.TrimPrefix(server.URL, "http://"), Repository: "myorg/myapp", Tag: "v2"}
+		digest, err := rc.HeadManifestDigest(context.Background(), ref, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "sha256:abc123", digest)
+	})
+
+	t.Run("sends basic auth when configured", func(t *testing.T) {
+		var gotAuth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = r.Header.Get("Authorization")
+			w.Header().Set("Docker-Content-Digest", "sha256:def456")
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		rc := NewRegistryClient(time.Second)
+		rc.SetScheme("http")
+
+		ref := ImageRef{Registry: strings.TrimPrefix(server.URL, "http://"), Repository: "myorg/myapp", Tag: "v2"}
+		_, err := rc.HeadManifestDigest(context.Background(), ref, "dXNlcjpwYXNz")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Basic dXNlcjpwYXNz", gotAuth)
+	})
+
+	t.Run("errors on non-200 status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		rc := NewRegistryClient(time.Second)
+		rc.SetScheme("http")
+
+		ref := ImageRef{Registry: strings.TrimPrefix(server.URL, "http://"), Repository: "myorg/myapp", Tag: "v2"}
+		_, err := rc.HeadManifestDigest(context.Background(), ref, "")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("errors when digest header is missing", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		rc := NewRegistryClient(time.Second)
+		rc.SetScheme("http")
+
+		ref := ImageRef{Registry: strings.TrimPrefix(server.URL, "http://"), Repository: "myorg/myapp", Tag: "v2"}
+		_, err := rc.HeadManifestDigest(context.Background(), ref, "")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Docker-Content-Digest")
+	})
+
+	t.Run("retries a 429 and succeeds once the registry recovers", func(t *testing.T) {
+		oldBase := headRetryBase
+		headRetryBase = time.Millisecond
+		defer func() { headRetryBase = oldBase }()
+
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) <= 2 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:abc123")
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		rc := NewRegistryClient(time.Second)
+		rc.SetScheme("http")
+
+		ref := ImageRef{Registry: strings.TrimPrefix(server.URL, "http://"), Repository: "myorg/myapp", Tag: "v2"}
+		digest, err := rc.HeadManifestDigest(context.Background(), ref, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "sha256:abc123", digest)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("gives up after maxHeadRetries consecutive 503s", func(t *testing.T) {
+		oldBase := headRetryBase
+		headRetryBase = time.Millisecond
+		defer func() { headRetryBase = oldBase }()
+
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		rc := NewRegistryClient(time.Second)
+		rc.SetScheme("http")
+
+		ref := ImageRef{Registry: strings.TrimPrefix(server.URL, "http://"), Repository: "myorg/myapp", Tag: "v2"}
+		_, err := rc.HeadManifestDigest(context.Background(), ref, "")
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(maxHeadRetries+1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("does not retry a non-200, non-429/5xx status", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		rc := NewRegistryClient(time.Second)
+		rc.SetScheme("http")
+
+		ref := ImageRef{Registry: strings.TrimPrefix(server.URL, "http://"), Repository: "myorg/myapp", Tag: "v2"}
+		_, err := rc.HeadManifestDigest(context.Background(), ref, "")
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
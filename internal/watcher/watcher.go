@@ -0,0 +1,214 @@
+package watcher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"dockswap/internal/config"
+	"dockswap/internal/logger"
+	"dockswap/internal/state"
+)
+
+// Deployer triggers a blue/green rollout of a new image for an already
+// initialized app. Satisfied by *docker.DeploymentOrchestrator.
+type Deployer interface {
+	Deploy(appName, newImage string) error
+}
+
+// ImagePuller pulls an image so it's present locally before a Deployer
+// creates a container from it. Satisfied by *docker.RuntimeManager.
+type ImagePuller interface {
+	PullImage(ctx context.Context, imageRef string, appConfig *config.AppConfig) error
+}
+
+// StateStore reports the image currently deployed for an app, so Watcher
+// knows which tag's digest to poll, and persists the last digest seen for
+// that tag so a dockswap restart doesn't forget it and re-trigger a deploy
+// the first time a reused tag (e.g. ":latest") is polled again.
+type StateStore interface {
+	CurrentImage(appName string) (string, error)
+	Digest(appName string) (string, error)
+	SetDigest(appName, digest string) error
+}
+
+type dbStateStore struct {
+	db *sql.DB
+}
+
+// NewDBStateStore adapts a *sql.DB into a StateStore backed by the state
+// package's current_state and image_digests tables.
+func NewDBStateStore(db *sql.DB) StateStore {
+	return &dbStateStore{db: db}
+}
+
+func (s *dbStateStore) CurrentImage(appName string) (string, error) {
+	cs, err := state.GetCurrentState(s.db, appName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current state for %s: %w", appName, err)
+	}
+	if cs == nil {
+		return "", fmt.Errorf("no current state for %s", appName)
+	}
+	return cs.Image, nil
+}
+
+func (s *dbStateStore) Digest(appName string) (string, error) {
+	digest, err := state.GetImageDigest(s.db, appName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get last-seen digest for %s: %w", appName, err)
+	}
+	return digest, nil
+}
+
+func (s *dbStateStore) SetDigest(appName, digest string) error {
+	if err := state.UpsertImageDigest(s.db, appName, digest); err != nil {
+		return fmt.Errorf("failed to record last-seen digest for %s: %w", appName, err)
+	}
+	return nil
+}
+
+// Watcher polls each Watch-enabled app's registry on its own interval and
+// triggers Deployer.Deploy when the deployed tag's digest changes, the way
+// Watchtower's Client does for plain `docker run` containers.
+type Watcher struct {
+	configs  map[string]*config.AppConfig
+	deployer Deployer
+	puller   ImagePuller
+	states   StateStore
+	registry *RegistryClient
+	logger   logger.Logger
+
+	mu          sync.Mutex
+	lastDigests map[string]string
+}
+
+// New creates a Watcher. registryTimeout bounds each manifest HEAD request.
+func New(configs map[string]*config.AppConfig, deployer Deployer, puller ImagePuller, states StateStore, registryTimeout time.Duration, log logger.Logger) *Watcher {
+	return &Watcher{
+		configs:     configs,
+		deployer:    deployer,
+		puller:      puller,
+		states:      states,
+		registry:    NewRegistryClient(registryTimeout),
+		logger:      log,
+		lastDigests: make(map[string]string),
+	}
+}
+
+// Run starts one polling goroutine per app with Watch.Enabled set and
+// blocks until ctx is canceled.
+func (w *Watcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for appName, appConfig := range w.configs {
+		if !appConfig.Watch.Enabled {
+			continue
+		}
+
+		wg.Add(1)
+		go func(appName string, interval time.Duration) {
+			defer wg.Done()
+			w.watchApp(ctx, appName, interval)
+		}(appName, appConfig.Watch.Interval)
+	}
+
+	wg.Wait()
+}
+
+func (w *Watcher) watchApp(ctx context.Context, appName string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.PollApp(ctx, appName); err != nil {
+				w.logger.Error("watch: %s: %v", appName, err)
+			}
+		}
+	}
+}
+
+// previousDigest returns the last digest observed for appName, preferring
+// this process's in-memory cache (populated by an earlier PollApp call) and
+// falling back to the StateStore's persisted value - set by a previous
+// dockswap process's PollApp call, before this one restarted - so a reused
+// tag doesn't look unseen, and thus trigger a spurious deploy, just because
+// the watcher itself was restarted.
+func (w *Watcher) previousDigest(appName string) (digest string, seen bool, err error) {
+	w.mu.Lock()
+	digest, seen = w.lastDigests[appName]
+	w.mu.Unlock()
+	if seen {
+		return digest, true, nil
+	}
+
+	digest, err = w.states.Digest(appName)
+	if err != nil {
+		return "", false, err
+	}
+	return digest, digest != "", nil
+}
+
+// PollApp checks appName's currently deployed tag for a new digest and, if
+// one is found, pulls the image and triggers a rollout. Run calls it on
+// every tick; it's exported so tests can drive a single poll without
+// waiting for the ticker.
+func (w *Watcher) PollApp(ctx context.Context, appName string) error {
+	appConfig, exists := w.configs[appName]
+	if !exists {
+		return fmt.Errorf("no configuration found for app %s", appName)
+	}
+
+	image, err := w.states.CurrentImage(appName)
+	if err != nil {
+		return fmt.Errorf("failed to determine current image: %w", err)
+	}
+
+	ref := ParseImageRef(image)
+	digest, err := w.registry.HeadManifestDigest(ctx, ref, appConfig.Watch.PollRegistryAuth)
+	if err != nil {
+		if appConfig.Watch.WarnOnHeadFailure {
+			w.logger.Error("watch: %s: %v", appName, err)
+			return nil
+		}
+		return err
+	}
+
+	previous, seen, err := w.previousDigest(appName)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.lastDigests[appName] = digest
+	w.mu.Unlock()
+	if err := w.states.SetDigest(appName, digest); err != nil {
+		w.logger.Error("watch: %s: %v", appName, err)
+	}
+
+	if !seen {
+		w.logger.Info("watch: %s: recorded baseline digest %s for %s", appName, digest, image)
+		return nil
+	}
+	if previous == digest {
+		return nil
+	}
+
+	w.logger.Info("watch: %s: digest changed (%s -> %s), rolling out %s", appName, previous, digest, image)
+
+	if err := w.puller.PullImage(ctx, image, appConfig); err != nil {
+		return fmt.Errorf("failed to pull updated image %s: %w", image, err)
+	}
+
+	if err := w.deployer.Deploy(appName, image); err != nil {
+		return fmt.Errorf("failed to deploy updated image %s: %w", image, err)
+	}
+
+	return nil
+}
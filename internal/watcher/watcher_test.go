@@ -0,0 +1,226 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dockswap/internal/config"
+	"dockswap/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRegistry is a minimal v2 registry that serves a mutable digest for a
+// single image, so tests can simulate a new image being pushed mid-test.
+type testRegistry struct {
+	*httptest.Server
+	image string
+
+	mu     sync.Mutex
+	digest string
+}
+
+func newTestRegistry(t *testing.T, digest string) *testRegistry {
+	t.Helper()
+	tr := &testRegistry{digest: digest}
+	tr.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+		w.Header().Set("Docker-Content-Digest", tr.digest)
+		w.WriteHeader(http.StatusOK)
+	}))
+	tr.image = strings.TrimPrefix(tr.Server.URL, "http://") + "/myapp:v1"
+	return tr
+}
+
+func (tr *testRegistry) setDigest(digest string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.digest = digest
+}
+
+type fakeDeployer struct {
+	appName  string
+	newImage string
+	err      error
+	calls    int
+}
+
+func (f *fakeDeployer) Deploy(appName, newImage string) error {
+	f.calls++
+	f.appName = appName
+	f.newImage = newImage
+	return f.err
+}
+
+type fakePuller struct {
+	err   error
+	calls int
+}
+
+func (f *fakePuller) PullImage(ctx context.Context, imageRef string, appConfig *config.AppConfig) error {
+	f.calls++
+	return f.err
+}
+
+type fakeStateStore struct {
+	image  string
+	err    error
+	digest string
+}
+
+func (f *fakeStateStore) CurrentImage(appName string) (string, error) {
+	return f.image, f.err
+}
+
+func (f *fakeStateStore) Digest(appName string) (string, error) {
+	return f.digest, nil
+}
+
+func (f *fakeStateStore) SetDigest(appName, digest string) error {
+	f.digest = digest
+	return nil
+}
+
+func newTestWatcher(t *testing.T, appName string, appConfig *config.AppConfig, deployer Deployer, puller ImagePuller, states StateStore) *Watcher {
+	t.Helper()
+	configs := map[string]*config.AppConfig{appName: appConfig}
+	return New(configs, deployer, puller, states, time.Second, logger.New(logger.LevelError))
+}
+
+func TestWatcher_PollApp(t *testing.T) {
+	t.Run("unknown app returns an error", func(t *testing.T) {
+		w := newTestWatcher(t, "myapp", &config.AppConfig{}, &fakeDeployer{}, &fakePuller{}, &fakeStateStore{})
+
+		err := w.PollApp(context.Background(), "otherapp")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no configuration found")
+	})
+
+	t.Run("first poll records the baseline digest without deploying", func(t *testing.T) {
+		registry := newTestRegistry(t, "sha256:aaa")
+		defer registry.Close()
+
+		deployer := &fakeDeployer{}
+		puller := &fakePuller{}
+		states := &fakeStateStore{image: registry.image}
+		w := newTestWatcher(t, "myapp", &config.AppConfig{}, deployer, puller, states)
+		w.registry.SetScheme("http")
+
+		err := w.PollApp(context.Background(), "myapp")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, deployer.calls)
+		assert.Equal(t, 0, puller.calls)
+	})
+
+	t.Run("unchanged digest does not trigger a deploy", func(t *testing.T) {
+		registry := newTestRegistry(t, "sha256:aaa")
+		defer registry.Close()
+
+		deployer := &fakeDeployer{}
+		puller := &fakePuller{}
+		states := &fakeStateStore{image: registry.image}
+		w := newTestWatcher(t, "myapp", &config.AppConfig{}, deployer, puller, states)
+		w.registry.SetScheme("http")
+
+		assert.NoError(t, w.PollApp(context.Background(), "myapp"))
+		assert.NoError(t, w.PollApp(context.Background(), "myapp"))
+
+		assert.Equal(t, 0, deployer.calls)
+		assert.Equal(t, 0, puller.calls)
+	})
+
+	t.Run("changed digest pulls the image and deploys", func(t *testing.T) {
+		registry := newTestRegistry(t, "sha256:aaa")
+		defer registry.Close()
+
+		deployer := &fakeDeployer{}
+		puller := &fakePuller{}
+		states := &fakeStateStore{image: registry.image}
+		w := newTestWatcher(t, "myapp", &config.AppConfig{}, deployer, puller, states)
+		w.registry.SetScheme("http")
+
+		assert.NoError(t, w.PollApp(context.Background(), "myapp"))
+
+		registry.setDigest("sha256:bbb")
+		err := w.PollApp(context.Background(), "myapp")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, puller.calls)
+		assert.Equal(t, 1, deployer.calls)
+		assert.Equal(t, "myapp", deployer.appName)
+		assert.Equal(t, registry.image, deployer.newImage)
+	})
+
+	t.Run("pull failure is returned and does not deploy", func(t *testing.T) {
+		registry := newTestRegistry(t, "sha256:aaa")
+		defer registry.Close()
+
+		deployer := &fakeDeployer{}
+		puller := &fakePuller{err: errors.New("no space left on device")}
+		states := &fakeStateStore{image: registry.image}
+		w := newTestWatcher(t, "myapp", &config.AppConfig{}, deployer, puller, states)
+		w.registry.SetScheme("http")
+
+		assert.NoError(t, w.PollApp(context.Background(), "myapp"))
+		registry.setDigest("sha256:bbb")
+
+		err := w.PollApp(context.Background(), "myapp")
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, deployer.calls)
+	})
+
+	t.Run("registry failure is swallowed when WarnOnHeadFailure is set", func(t *testing.T) {
+		states := &fakeStateStore{image: "127.0.0.1:1/myapp:v1"}
+		w := newTestWatcher(t, "myapp", &config.AppConfig{Watch: config.Watch{WarnOnHeadFailure: true}}, &fakeDeployer{}, &fakePuller{}, states)
+		w.registry.SetScheme("http")
+
+		err := w.PollApp(context.Background(), "myapp")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("registry failure is returned when WarnOnHeadFailure is unset", func(t *testing.T) {
+		states := &fakeStateStore{image: "127.0.0.1:1/myapp:v1"}
+		w := newTestWatcher(t, "myapp", &config.AppConfig{}, &fakeDeployer{}, &fakePuller{}, states)
+		w.registry.SetScheme("http")
+
+		err := w.PollApp(context.Background(), "myapp")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("a restarted watcher trusts the persisted digest instead of re-baselining", func(t *testing.T) {
+		registry := newTestRegistry(t, "sha256:aaa")
+		defer registry.Close()
+
+		states := &fakeStateStore{image: registry.image}
+		first := newTestWatcher(t, "myapp", &config.AppConfig{}, &fakeDeployer{}, &fakePuller{}, states)
+		first.registry.SetScheme("http")
+		assert.NoError(t, first.PollApp(context.Background(), "myapp"))
+
+		registry.setDigest("sha256:bbb")
+
+		// A fresh Watcher (as if dockswap had just restarted) shares the
+		// same StateStore, so it should see "sha256:aaa" as already known
+		// and deploy on this very first poll, rather than treating it as
+		// an unseen baseline.
+		deployer := &fakeDeployer{}
+		puller := &fakePuller{}
+		second := newTestWatcher(t, "myapp", &config.AppConfig{}, deployer, puller, states)
+		second.registry.SetScheme("http")
+
+		assert.NoError(t, second.PollApp(context.Background(), "myapp"))
+		assert.Equal(t, 1, deployer.calls)
+		assert.Equal(t, 1, puller.calls)
+	})
+}
@@ -0,0 +1,39 @@
+package workspace
+
+import (
+	"database/sql"
+
+	"dockswap/internal/events"
+)
+
+// SQLEventSink is an events.Subscriber that appends every Event to the
+// events table migrations.Migrate creates, the SQL-backed counterpart to
+// events.FileSubscriber/events.JSONLSubscriber - a workspace wired with
+// NewSQLEventSink(w.Store.DB()) can answer "what happened to app X" with a
+// query instead of tailing a log file.
+type SQLEventSink struct {
+	db *sql.DB
+}
+
+// NewSQLEventSink creates a SQLEventSink writing to db's events table. db is
+// expected to already have migrations.Migrate applied against it, as every
+// Workspace's Store does.
+func NewSQLEventSink(db *sql.DB) *SQLEventSink {
+	return &SQLEventSink{db: db}
+}
+
+// Notify inserts e as a new events row. Like events.WebhookSubscriber,
+// Notify has no error return to report a failed insert through, so write
+// failures are logged nowhere and simply dropped - an events table outage
+// shouldn't be able to fail the deployment that triggered the event.
+func (s *SQLEventSink) Notify(e events.Event) {
+	errMsg := ""
+	if e.Error != nil {
+		errMsg = e.Error.Error()
+	}
+
+	_, _ = s.db.Exec(
+		`INSERT INTO events (kind, app_name, color, from_state, to_state, image, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		string(e.Kind), e.AppName, e.Color, e.FromState, e.ToState, e.Image, errMsg, e.Timestamp,
+	)
+}
@@ -0,0 +1,41 @@
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dockswap/internal/events"
+)
+
+func TestSQLEventSinkInsertsAndCanBeQueried(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	store, err := NewDeploymentStore("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("NewDeploymentStore() failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	sink := NewSQLEventSink(store.DB())
+	sink.Notify(events.Event{
+		Kind:      events.KindDeploymentSucceeded,
+		AppName:   "web",
+		Color:     "blue",
+		ToState:   "stable",
+		Timestamp: time.Now(),
+	})
+
+	var kind, appName, toState string
+	row := store.DB().QueryRow("SELECT kind, app_name, to_state FROM events WHERE app_name = ?", "web")
+	if err := row.Scan(&kind, &appName, &toState); err != nil {
+		t.Fatalf("failed to query inserted event: %v", err)
+	}
+	if kind != string(events.KindDeploymentSucceeded) || appName != "web" || toState != "stable" {
+		t.Errorf("unexpected row: kind=%s app_name=%s to_state=%s", kind, appName, toState)
+	}
+}
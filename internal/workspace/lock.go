@@ -0,0 +1,58 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// WorkspaceLockFilename is the advisory lock file at a workspace's root.
+// InitializeWorkspace and LoadWorkspace hold it for as long as the
+// Workspace stays open, so a CI job's `dockswap deploy` and an interactive
+// one against the same workspace can never race on the same config/state
+// files underneath it.
+const WorkspaceLockFilename = "workspace.lock"
+
+// workspaceLock wraps the open *os.File backing an acquired flock, so
+// Workspace.Close can release it.
+type workspaceLock struct {
+	f *os.File
+}
+
+// acquireWorkspaceLock takes an exclusive flock on <rootPath>/workspace.lock.
+// If wait is true it blocks until the lock is available; if false (the
+// --no-wait mode) it fails immediately when another process already holds
+// it instead of queuing behind it.
+func acquireWorkspaceLock(rootPath string, wait bool) (*workspaceLock, error) {
+	path := filepath.Join(rootPath, WorkspaceLockFilename)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to acquire workspace lock %s (is another dockswap process using this workspace?): %w", path, err)
+	}
+
+	return &workspaceLock{f: f}, nil
+}
+
+// Close releases the flock and closes its backing file descriptor.
+func (l *workspaceLock) Close() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	if err := syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN); err != nil {
+		l.f.Close()
+		return fmt.Errorf("failed to release workspace lock: %w", err)
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,34 @@
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspaceLocking(t *testing.T) {
+	tempDir := t.TempDir()
+	workspaceRoot := filepath.Join(tempDir, "locked")
+
+	first, err := InitializeWorkspace(workspaceRoot)
+	if err != nil {
+		t.Fatalf("InitializeWorkspace() failed: %v", err)
+	}
+
+	t.Run("no-wait load fails while another process holds the lock", func(t *testing.T) {
+		if _, err := LoadWorkspaceNoWait(workspaceRoot); err == nil {
+			t.Errorf("LoadWorkspaceNoWait() should fail while the workspace is already locked")
+		}
+	})
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	t.Run("no-wait load succeeds once the lock is released", func(t *testing.T) {
+		second, err := LoadWorkspaceNoWait(workspaceRoot)
+		if err != nil {
+			t.Fatalf("LoadWorkspaceNoWait() failed after lock release: %v", err)
+		}
+		defer second.Close()
+	})
+}
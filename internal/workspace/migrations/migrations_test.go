@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openMemDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateCreatesSchema(t *testing.T) {
+	db := openMemDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	for _, table := range []string{"deployments", "app_configs", "events", "schema_migrations"} {
+		var name string
+		err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type='table' AND name = ?`, table).Scan(&name)
+		if err != nil {
+			t.Errorf("expected table %q to exist after Migrate(): %v", table, err)
+		}
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		t.Fatalf("failed to read schema_migrations: %v", err)
+	}
+	if version != CurrentVersion() {
+		t.Errorf("schema_migrations version = %d, want %d", version, CurrentVersion())
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := openMemDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("first Migrate() failed: %v", err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate() failed: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatalf("failed to count schema_migrations rows: %v", err)
+	}
+	if count != CurrentVersion() {
+		t.Errorf("schema_migrations row count = %d, want %d (no re-apply on a second Migrate())", count, CurrentVersion())
+	}
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	db := openMemDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	futureVersion := CurrentVersion() + 1
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, datetime('now'))`, futureVersion); err != nil {
+		t.Fatalf("failed to seed future schema_migrations row: %v", err)
+	}
+
+	err := Migrate(db)
+	if err == nil {
+		t.Fatal("Migrate() should fail when schema_migrations records a version newer than this build supports")
+	}
+}
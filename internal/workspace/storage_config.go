@@ -0,0 +1,51 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceConfigFilename is the optional file at a workspace's root that
+// selects its DeploymentStore driver and DSN. Its absence is the common
+// case: every workspace defaults to the bundled sqlite file at DBPath.
+const WorkspaceConfigFilename = "workspace.yaml"
+
+// StorageConfig selects the driver and DSN Workspace's DeploymentStore
+// connects with.
+type StorageConfig struct {
+	// Driver is "sqlite" (the default), "postgres", or "mysql" - see
+	// NewDeploymentStore and registerStoreDriver.
+	Driver string `yaml:"driver"`
+	// DSN is the driver-specific connection string. Empty means "use this
+	// workspace's own DBPath", sqlite's existing behavior.
+	DSN string `yaml:"dsn"`
+}
+
+// loadStorageConfig reads workspace.yaml from rootPath, defaulting to the
+// sqlite driver with no DSN override when the file is absent so every
+// existing workspace keeps behaving exactly as it did before workspace.yaml
+// existed.
+func loadStorageConfig(rootPath string) (StorageConfig, error) {
+	path := filepath.Join(rootPath, WorkspaceConfigFilename)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return StorageConfig{Driver: "sqlite"}, nil
+	}
+	if err != nil {
+		return StorageConfig{}, fmt.Errorf("failed to read %s: %w", WorkspaceConfigFilename, err)
+	}
+
+	var cfg StorageConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return StorageConfig{}, fmt.Errorf("failed to parse %s: %w", WorkspaceConfigFilename, err)
+	}
+	if cfg.Driver == "" {
+		cfg.Driver = "sqlite"
+	}
+
+	return cfg, nil
+}
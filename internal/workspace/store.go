@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"database/sql"
+	"fmt"
+
+	"dockswap/internal/workspace/migrations"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DeploymentStore abstracts the SQL database backing a Workspace behind
+// whichever driver workspace.yaml selects, so pointing several dockswap
+// instances at one shared postgres/mysql database instead of each one's own
+// sqlite file is a config change, not a code change.
+type DeploymentStore interface {
+	// DB returns the underlying *sql.DB, for callers (internal/state's
+	// deployment-history functions, internal/cli) that need direct SQL
+	// access this interface doesn't wrap.
+	DB() *sql.DB
+	// Migrate brings the store's schema up to date for this driver.
+	Migrate() error
+	// Ping verifies the store is reachable.
+	Ping() error
+	// Close releases the store's connection.
+	Close() error
+}
+
+// storeFactory builds a DeploymentStore from a driver-specific DSN.
+type storeFactory func(dsn string) (DeploymentStore, error)
+
+var storeFactories = map[string]storeFactory{
+	"sqlite": newSQLiteStore,
+}
+
+// registerStoreDriver makes NewDeploymentStore recognize an additional
+// driver name; implementations call this from an init() guarded by their
+// own build tag (see store_postgres.go, store_mysql.go), the same pattern
+// config.registerConfigSource uses for pluggable config backends.
+func registerStoreDriver(driver string, factory storeFactory) {
+	storeFactories[driver] = factory
+}
+
+// NewDeploymentStore builds the DeploymentStore named by driver ("sqlite",
+// "postgres", "mysql", ...), connecting to dsn. dsn is a sqlite file path
+// for the "sqlite" driver, or a driver-native connection string otherwise.
+func NewDeploymentStore(driver, dsn string) (DeploymentStore, error) {
+	factory, ok := storeFactories[driver]
+	if !ok {
+		return nil, fmt.Errorf("no storage driver registered for %q (is dockswap built with the matching build tag?)", driver)
+	}
+	return factory(dsn)
+}
+
+// sqlStore is the DeploymentStore every driver shares: database/sql already
+// abstracts the wire protocol, so only the driver name and migrations.Migrate's
+// SQLite-flavored schema (AUTOINCREMENT, etc.) would need a driver-specific
+// variant were a non-sqlite backend to ship.
+type sqlStore struct {
+	db *sql.DB
+}
+
+func (s *sqlStore) DB() *sql.DB  { return s.db }
+func (s *sqlStore) Ping() error  { return s.db.Ping() }
+func (s *sqlStore) Close() error { return s.db.Close() }
+
+func (s *sqlStore) Migrate() error {
+	return migrations.Migrate(s.db)
+}
+
+func newSQLiteStore(dsn string) (DeploymentStore, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", dsn, err)
+	}
+	return &sqlStore{db: db}, nil
+}
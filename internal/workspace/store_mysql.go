@@ -0,0 +1,27 @@
+//go:build mysql
+
+package workspace
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registerStoreDriver("mysql", newMySQLStore)
+}
+
+// newMySQLStore opens dsn (a "user:pass@tcp(host:3306)/db" connection
+// string) against the mysql driver. migrations.Migrate's schema is written
+// in SQLite's dialect today, so a mysql-backed Workspace needs that schema
+// ported before Migrate will succeed here - this file establishes the
+// driver plumbing DeploymentStore needs, not a ported schema.
+func newMySQLStore(dsn string) (DeploymentStore, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mysql database: %w", err)
+	}
+	return &sqlStore{db: db}, nil
+}
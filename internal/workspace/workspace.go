@@ -5,24 +5,88 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"dockswap/internal/caddy"
 	"dockswap/internal/config"
+	"dockswap/internal/errs"
+	"dockswap/internal/events"
 	"dockswap/internal/state"
 
-	_ "github.com/mattn/go-sqlite3"
+	"gopkg.in/yaml.v3"
 )
 
 type Workspace struct {
-	Root     string                       `json:"root"`
-	AppsDir  string                       `json:"apps_dir"`
-	StateDir string                       `json:"state_dir"`
-	CaddyDir string                       `json:"caddy_dir"`
-	DBPath   string                       `json:"db_path"`
-	DB       *sql.DB                      `json:"-"`
-	Configs  map[string]*config.AppConfig `json:"-"`
-	States   map[string]*state.AppState   `json:"-"`
-	CaddyMgr *caddy.CaddyManager          `json:"-"`
+	Root       string                       `json:"root"`
+	AppsDir    string                       `json:"apps_dir"`
+	StateDir   string                       `json:"state_dir"`
+	CaddyDir   string                       `json:"caddy_dir"`
+	DBPath     string                       `json:"db_path"`
+	DB         *sql.DB                      `json:"-"`
+	Store      DeploymentStore              `json:"-"`
+	StateStore state.AppStateStore          `json:"-"`
+	Configs    map[string]*config.AppConfig `json:"-"`
+	States     map[string]*state.AppState   `json:"-"`
+	CaddyMgr   *caddy.CaddyManager          `json:"-"`
+	PortRanges PortRanges                   `json:"-"`
+	// Bus, if set via SetEventBus, is published to from SaveState,
+	// UpdateCaddyConfig, and validatePortConflicts.
+	Bus *events.Bus `json:"-"`
+
+	// lock is the flock held on WorkspaceLockFilename for as long as this
+	// Workspace is open; acquired by InitializeWorkspace/LoadWorkspace and
+	// released by Close.
+	lock *workspaceLock
+	// configsSnapshot and statesSnapshot are what RefreshWorkspace observed
+	// of AppsDir and StateDir on its last reload, so a caller polling
+	// RefreshWorkspace repeatedly only pays for the (cheap) directory stat
+	// when nothing has actually changed.
+	configsSnapshot dirSnapshot
+	statesSnapshot  dirSnapshot
+}
+
+// PortRange is an inclusive [Start, End] span of TCP ports AllocatePorts may
+// hand out.
+type PortRange struct {
+	Start int
+	End   int
+}
+
+// PortRanges groups the reserved ranges AllocatePorts draws from for each
+// port role. Use DefaultPortRanges unless the deployment needs different
+// reservations (e.g. to stay clear of ports already used by other
+// software on the host), and override via Workspace.SetPortRanges.
+type PortRanges struct {
+	// BlueGreen backs docker.expose_port, ports.blue, and ports.green - all
+	// three are host/container ports sized the same as a container's own
+	// listen port.
+	BlueGreen PortRange
+	// Proxy backs proxy.listen_port, the in-process proxy's public port.
+	Proxy PortRange
+}
+
+// DefaultPortRanges are the reserved ranges used unless a Workspace
+// overrides them via SetPortRanges: 20000-29999 for blue/green container
+// ports, and 8000-8999 for the in-process proxy's listen port.
+func DefaultPortRanges() PortRanges {
+	return PortRanges{
+		BlueGreen: PortRange{Start: 20000, End: 29999},
+		Proxy:     PortRange{Start: 8000, End: 8999},
+	}
+}
+
+// SetPortRanges overrides the reserved ranges w.AllocatePorts draws from.
+func (w *Workspace) SetPortRanges(ranges PortRanges) {
+	w.PortRanges = ranges
+}
+
+// SetEventBus registers bus so SaveState, UpdateCaddyConfig, and
+// validatePortConflicts publish an events.Event around the workspace-level
+// occurrences they cover, the same pattern DeploymentStateMachine and
+// DockerActionProvider use for the deployment flow itself.
+func (w *Workspace) SetEventBus(bus *events.Bus) {
+	w.Bus = bus
 }
 
 const (
@@ -50,21 +114,47 @@ func DiscoverWorkspace() (*Workspace, error) {
 	return nil, fmt.Errorf("no valid dockswap workspace found in search paths: %v", searchPaths)
 }
 
+// InitializeWorkspace creates a new workspace at rootPath, blocking until it
+// can acquire rootPath's workspace lock. Use InitializeWorkspaceNoWait for
+// the --no-wait mode instead.
 func InitializeWorkspace(rootPath string) (*Workspace, error) {
+	return initializeWorkspace(rootPath, true)
+}
+
+// InitializeWorkspaceNoWait behaves like InitializeWorkspace but fails
+// immediately, instead of blocking, if another process already holds
+// rootPath's workspace lock.
+func InitializeWorkspaceNoWait(rootPath string) (*Workspace, error) {
+	return initializeWorkspace(rootPath, false)
+}
+
+func initializeWorkspace(rootPath string, wait bool) (*Workspace, error) {
 	workspace := &Workspace{
-		Root:     rootPath,
-		AppsDir:  filepath.Join(rootPath, AppsSubdir),
-		StateDir: filepath.Join(rootPath, StateSubdir),
-		CaddyDir: filepath.Join(rootPath, CaddySubdir),
-		DBPath:   filepath.Join(rootPath, DBFilename),
-		Configs:  make(map[string]*config.AppConfig),
-		States:   make(map[string]*state.AppState),
+		Root:       rootPath,
+		AppsDir:    filepath.Join(rootPath, AppsSubdir),
+		StateDir:   filepath.Join(rootPath, StateSubdir),
+		CaddyDir:   filepath.Join(rootPath, CaddySubdir),
+		DBPath:     filepath.Join(rootPath, DBFilename),
+		StateStore: state.NewFileAppStateStore(filepath.Join(rootPath, StateSubdir)),
+		Configs:    make(map[string]*config.AppConfig),
+		States:     make(map[string]*state.AppState),
+		PortRanges: DefaultPortRanges(),
 	}
 
 	if err := workspace.createDirectoryStructure(); err != nil {
 		return nil, fmt.Errorf("failed to create directory structure: %w", err)
 	}
 
+	lock, err := acquireWorkspaceLock(rootPath, wait)
+	if err != nil {
+		return nil, err
+	}
+	workspace.lock = lock
+
+	if err := workspace.AllocatePorts(); err != nil {
+		return nil, fmt.Errorf("failed to allocate ports: %w", err)
+	}
+
 	if err := workspace.initializeDatabase(); err != nil {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -76,21 +166,44 @@ func InitializeWorkspace(rootPath string) (*Workspace, error) {
 	return workspace, nil
 }
 
+// LoadWorkspace opens the existing workspace at rootPath, blocking until it
+// can acquire rootPath's workspace lock. Use LoadWorkspaceNoWait for the
+// --no-wait mode instead.
 func LoadWorkspace(rootPath string) (*Workspace, error) {
+	return loadWorkspace(rootPath, true)
+}
+
+// LoadWorkspaceNoWait behaves like LoadWorkspace but fails immediately,
+// instead of blocking, if another process already holds rootPath's
+// workspace lock - the mode a CI job wants instead of queuing behind an
+// interactive `dockswap deploy` against the same workspace.
+func LoadWorkspaceNoWait(rootPath string) (*Workspace, error) {
+	return loadWorkspace(rootPath, false)
+}
+
+func loadWorkspace(rootPath string, wait bool) (*Workspace, error) {
 	workspace := &Workspace{
-		Root:     rootPath,
-		AppsDir:  filepath.Join(rootPath, AppsSubdir),
-		StateDir: filepath.Join(rootPath, StateSubdir),
-		CaddyDir: filepath.Join(rootPath, CaddySubdir),
-		DBPath:   filepath.Join(rootPath, DBFilename),
-		Configs:  make(map[string]*config.AppConfig),
-		States:   make(map[string]*state.AppState),
+		Root:       rootPath,
+		AppsDir:    filepath.Join(rootPath, AppsSubdir),
+		StateDir:   filepath.Join(rootPath, StateSubdir),
+		CaddyDir:   filepath.Join(rootPath, CaddySubdir),
+		DBPath:     filepath.Join(rootPath, DBFilename),
+		StateStore: state.NewFileAppStateStore(filepath.Join(rootPath, StateSubdir)),
+		Configs:    make(map[string]*config.AppConfig),
+		States:     make(map[string]*state.AppState),
+		PortRanges: DefaultPortRanges(),
 	}
 
 	if err := workspace.ValidateStructure(); err != nil {
 		return nil, fmt.Errorf("workspace structure validation failed: %w", err)
 	}
 
+	lock, err := acquireWorkspaceLock(rootPath, wait)
+	if err != nil {
+		return nil, err
+	}
+	workspace.lock = lock
+
 	if err := workspace.openDatabase(); err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -134,18 +247,43 @@ func (w *Workspace) ValidateStructure() error {
 	return nil
 }
 
+// RefreshWorkspace reloads app configs and states from disk, but only the
+// ones that actually changed since the last call: it stats AppsDir and
+// StateDir first and compares against the mtimes it saw last time, so
+// polling RefreshWorkspace on a timer (e.g. to pick up an edit from another
+// process) skips the config/state reload on every call where nothing moved.
+// AllocatePorts still runs first on every call, and it reads and parses
+// every app file in AppsDir.
 func (w *Workspace) RefreshWorkspace() error {
-	configs, err := config.LoadAllConfigs(w.AppsDir)
+	if err := w.AllocatePorts(); err != nil {
+		return fmt.Errorf("failed to allocate ports: %w", err)
+	}
+
+	configsSnapshot, err := snapshotDir(w.AppsDir)
 	if err != nil {
-		return fmt.Errorf("failed to load app configs: %w", err)
+		return fmt.Errorf("failed to stat apps directory: %w", err)
+	}
+	if configsSnapshot.changedSince(w.configsSnapshot) {
+		configs, err := config.LoadAllConfigs(w.AppsDir)
+		if err != nil {
+			return fmt.Errorf("failed to load app configs: %w", err)
+		}
+		w.Configs = configs
+		w.configsSnapshot = configsSnapshot
 	}
-	w.Configs = configs
 
-	states, err := state.LoadAllStates(w.StateDir)
+	statesSnapshot, err := snapshotDir(w.StateDir)
 	if err != nil {
-		return fmt.Errorf("failed to load app states: %w", err)
+		return fmt.Errorf("failed to stat state directory: %w", err)
+	}
+	if statesSnapshot.changedSince(w.statesSnapshot) {
+		states, err := w.StateStore.LoadAll()
+		if err != nil {
+			return fmt.Errorf("failed to load app states: %w", err)
+		}
+		w.States = states
+		w.statesSnapshot = statesSnapshot
 	}
-	w.States = states
 
 	if err := w.ValidateConfigs(); err != nil {
 		return fmt.Errorf("config validation failed: %w", err)
@@ -154,15 +292,53 @@ func (w *Workspace) RefreshWorkspace() error {
 	return nil
 }
 
+// dirSnapshot is the cheap signal RefreshWorkspace uses to decide whether a
+// directory changed since its last reload. mtime alone can under-detect: a
+// file added or removed within the same filesystem timestamp tick as a
+// prior write can land on an identical ModTime, so entryCount is tracked
+// alongside it to catch adds/removes even when mtimes collide.
+type dirSnapshot struct {
+	mtime      time.Time
+	entryCount int
+}
+
+// changedSince reports whether s reflects a different directory state than
+// prev. Against the zero dirSnapshot, before any snapshot has been taken,
+// any non-empty directory counts as changed; an empty one does not.
+func (s dirSnapshot) changedSince(prev dirSnapshot) bool {
+	return s.entryCount != prev.entryCount || s.mtime.After(prev.mtime)
+}
+
+// snapshotDir returns dir's dirSnapshot: its entry count and the latest
+// modification time across its direct entries (not recursive).
+func snapshotDir(dir string) (dirSnapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return dirSnapshot{}, fmt.Errorf("failed to list directory %s: %w", dir, err)
+	}
+
+	var latest time.Time
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return dirSnapshot{}, fmt.Errorf("failed to stat %s: %w", entry.Name(), err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return dirSnapshot{mtime: latest, entryCount: len(entries)}, nil
+}
+
 func (w *Workspace) ValidateConfigs() error {
 	for appName, appConfig := range w.Configs {
 		if appName != appConfig.Name {
-			return fmt.Errorf("config file name mismatch: file suggests '%s' but config.name is '%s'", appName, appConfig.Name)
+			return errs.Wrap(errs.ErrConfigConflict, nil, "config file name mismatch: file suggests '%s' but config.name is '%s'", appName, appConfig.Name)
 		}
 
 		if appState, exists := w.States[appName]; exists {
 			if appState.Name != appConfig.Name {
-				return fmt.Errorf("state/config name mismatch for app '%s'", appName)
+				return errs.Wrap(errs.ErrConfigConflict, nil, "state/config name mismatch for app '%s'", appName)
 			}
 		}
 
@@ -185,15 +361,43 @@ func (w *Workspace) GetState(appName string) (*state.AppState, bool) {
 }
 
 func (w *Workspace) SaveState(appName string, appState *state.AppState) error {
-	statePath := filepath.Join(w.StateDir, appName+".yaml")
-	if err := state.SaveAppState(statePath, appState); err != nil {
+	if err := w.StateStore.Save(appName, appState); err != nil {
 		return fmt.Errorf("failed to save state for app '%s': %w", appName, err)
 	}
 
 	w.States[appName] = appState
+
+	if w.Bus != nil {
+		w.Bus.Publish(events.Event{
+			Kind:      stateStatusEventKind(appState.Status),
+			AppName:   appName,
+			Color:     appState.ActiveColor,
+			ToState:   appState.Status,
+			Image:     appState.CurrentImage,
+			Timestamp: appState.LastUpdated,
+		})
+	}
+
 	return nil
 }
 
+// stateStatusEventKind maps an AppState.Status to the EventKind SaveState
+// publishes for it. Everything other than the terminal stable/failed/
+// rolling_back statuses (deploying, draining, ...) is a deployment in
+// progress, so it's reported as started.
+func stateStatusEventKind(status string) events.EventKind {
+	switch state.DeploymentStatus(status) {
+	case state.StatusStable:
+		return events.KindDeploymentSucceeded
+	case state.StatusFailed:
+		return events.KindDeploymentFailed
+	case state.StatusRollingBack:
+		return events.KindDeploymentRolledBack
+	default:
+		return events.KindDeploymentStarted
+	}
+}
+
 func (w *Workspace) ListApps() []string {
 	var apps []string
 	for appName := range w.Configs {
@@ -202,11 +406,24 @@ func (w *Workspace) ListApps() []string {
 	return apps
 }
 
+// Close releases everything InitializeWorkspace/LoadWorkspace acquired: the
+// store connection and the workspace lock, so another process's
+// InitializeWorkspace/LoadWorkspace against the same root can proceed. It
+// attempts both even if one fails, returning the first error encountered.
 func (w *Workspace) Close() error {
-	if w.DB != nil {
-		return w.DB.Close()
+	var storeErr error
+	if w.Store != nil {
+		storeErr = w.Store.Close()
+	} else if w.DB != nil {
+		storeErr = w.DB.Close()
 	}
-	return nil
+
+	lockErr := w.lock.Close()
+
+	if storeErr != nil {
+		return storeErr
+	}
+	return lockErr
 }
 
 func (w *Workspace) createDirectoryStructure() error {
@@ -221,60 +438,54 @@ func (w *Workspace) createDirectoryStructure() error {
 	return nil
 }
 
-func (w *Workspace) initializeDatabase() error {
-	db, err := sql.Open("sqlite3", w.DBPath)
+// openStore opens this workspace's DeploymentStore per its workspace.yaml
+// (or the sqlite default against DBPath if absent - see loadStorageConfig),
+// and populates both w.Store and w.DB (kept for existing callers that still
+// expect the raw *sql.DB).
+func (w *Workspace) openStore() error {
+	storageCfg, err := loadStorageConfig(w.Root)
 	if err != nil {
-		return fmt.Errorf("failed to open database connection: %w", err)
+		return err
 	}
-	w.DB = db
 
-	if err := w.createDatabaseSchema(); err != nil {
-		return fmt.Errorf("failed to create database schema: %w", err)
+	dsn := storageCfg.DSN
+	if dsn == "" {
+		dsn = w.DBPath
 	}
 
+	store, err := NewDeploymentStore(storageCfg.Driver, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	w.Store = store
+	w.DB = store.DB()
 	return nil
 }
 
-func (w *Workspace) openDatabase() error {
-	db, err := sql.Open("sqlite3", w.DBPath)
-	if err != nil {
-		return fmt.Errorf("failed to open database connection: %w", err)
+func (w *Workspace) initializeDatabase() error {
+	if err := w.openStore(); err != nil {
+		return err
 	}
-	w.DB = db
 
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("database connection failed: %w", err)
+	if err := w.Store.Migrate(); err != nil {
+		return errs.Wrap(errs.ErrDBPersist, err, "failed to migrate database schema")
 	}
 
 	return nil
 }
 
-func (w *Workspace) createDatabaseSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS deployments (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		app_name TEXT NOT NULL,
-		image TEXT NOT NULL,
-		color TEXT NOT NULL,
-		status TEXT NOT NULL,
-		started_at DATETIME NOT NULL,
-		completed_at DATETIME,
-		error_message TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_deployments_app_name ON deployments(app_name);
-	CREATE INDEX IF NOT EXISTS idx_deployments_started_at ON deployments(started_at);
+func (w *Workspace) openDatabase() error {
+	if err := w.openStore(); err != nil {
+		return err
+	}
 
-	CREATE TABLE IF NOT EXISTS app_configs (
-		app_name TEXT PRIMARY KEY,
-		config_hash TEXT NOT NULL,
-		last_updated DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
+	if err := w.Store.Ping(); err != nil {
+		return fmt.Errorf("database connection failed: %w", err)
+	}
 
-	if _, err := w.DB.Exec(schema); err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
+	if err := w.Store.Migrate(); err != nil {
+		return errs.Wrap(errs.ErrDBPersist, err, "failed to migrate database schema")
 	}
 
 	return nil
@@ -284,8 +495,21 @@ func (w *Workspace) validatePortConflicts(appName string, appConfig *config.AppC
 	usedPorts := make(map[int]string)
 
 	checkPort := func(port int, portType string) error {
+		// A port of 0 or less means the feature is unset/disabled (e.g. an
+		// app that never enables the in-process proxy) rather than an
+		// actual port assignment, so it never conflicts with anything.
+		if port <= 0 {
+			return nil
+		}
 		if existingApp, exists := usedPorts[port]; exists {
-			return fmt.Errorf("port conflict: app '%s' %s port %d conflicts with app '%s'", appName, portType, port, existingApp)
+			if w.Bus != nil {
+				w.Bus.Publish(events.Event{
+					Kind:      events.KindPortConflictDetected,
+					AppName:   appName,
+					Timestamp: time.Now(),
+				})
+			}
+			return errs.Wrap(errs.ErrConfigConflict, nil, "port conflict: app '%s' %s port %d conflicts with app '%s'", appName, portType, port, existingApp)
 		}
 		usedPorts[port] = appName
 		return nil
@@ -329,6 +553,202 @@ func (w *Workspace) validatePortConflicts(appName string, appConfig *config.AppC
 	return nil
 }
 
+// AllocatePorts fills in any unset docker.expose_port, ports.blue,
+// ports.green, or proxy.listen_port across every app config under AppsDir,
+// drawing from w.PortRanges, and rewrites whichever files it changed so the
+// choice is stable on every later load. It runs as the first step of
+// RefreshWorkspace, before config.LoadAllConfigs, so by the time a config is
+// parsed and validated its ports are already positive - AllocatePorts never
+// loosens validateConfig's "ports must be positive" checks, it just makes
+// sure they pass.
+//
+// Allocation is deterministic: app files are processed in filename order,
+// and already-assigned ports (across every app, not just the one being
+// filled in) are reserved up front so two apps loaded in the same pass
+// never race for the same port.
+func (w *Workspace) AllocatePorts() error {
+	entries, err := os.ReadDir(w.AppsDir)
+	if err != nil {
+		return fmt.Errorf("failed to list apps directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(entry.Name()); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	docs := make(map[string]map[string]any, len(names))
+	used := make(map[int]bool)
+
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(w.AppsDir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read app config %s: %w", name, err)
+		}
+
+		var raw map[string]any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			// Malformed YAML is reported with better context by
+			// config.LoadAllConfigs right after this runs; skip it here
+			// rather than failing allocation for every other app too.
+			continue
+		}
+		if raw == nil {
+			raw = map[string]any{}
+		}
+		docs[name] = raw
+
+		for _, port := range assignedPorts(raw) {
+			used[port] = true
+		}
+	}
+
+	for _, name := range names {
+		raw, ok := docs[name]
+		if !ok {
+			continue
+		}
+
+		changed, err := w.fillUnsetPorts(raw, used)
+		if err != nil {
+			return fmt.Errorf("failed to allocate ports for %s: %w", name, err)
+		}
+		if !changed {
+			continue
+		}
+
+		out, err := yaml.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("failed to re-marshal app config %s: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(w.AppsDir, name), out, 0644); err != nil {
+			return fmt.Errorf("failed to persist allocated ports for %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// assignedPorts returns every positive docker.expose_port/ports.blue/
+// ports.green/proxy.listen_port value already present in raw, so
+// AllocatePorts never hands the same port out twice.
+func assignedPorts(raw map[string]any) []int {
+	var ports []int
+
+	if docker, ok := raw["docker"].(map[string]any); ok {
+		if port, ok := asPort(docker["expose_port"]); ok {
+			ports = append(ports, port)
+		}
+	}
+	if p, ok := raw["ports"].(map[string]any); ok {
+		if port, ok := asPort(p["blue"]); ok {
+			ports = append(ports, port)
+		}
+		if port, ok := asPort(p["green"]); ok {
+			ports = append(ports, port)
+		}
+	}
+	if proxy, ok := raw["proxy"].(map[string]any); ok {
+		if port, ok := asPort(proxy["listen_port"]); ok {
+			ports = append(ports, port)
+		}
+	}
+
+	return ports
+}
+
+// fillUnsetPorts assigns a free port from the appropriate w.PortRanges span
+// to every missing or non-positive docker.expose_port/ports.blue/
+// ports.green, reserving it in used as it goes. proxy.listen_port is
+// treated differently: an explicit 0 there means the app's in-process
+// proxy is deliberately disabled (see proxy.New), so only a fully absent
+// listen_port key gets one assigned, and only if the app declares a proxy
+// section at all.
+func (w *Workspace) fillUnsetPorts(raw map[string]any, used map[int]bool) (bool, error) {
+	changed := false
+
+	docker, _ := raw["docker"].(map[string]any)
+	if docker == nil {
+		docker = map[string]any{}
+	}
+	if _, ok := asPort(docker["expose_port"]); !ok {
+		port, err := allocatePort(w.PortRanges.BlueGreen, used)
+		if err != nil {
+			return false, fmt.Errorf("docker.expose_port: %w", err)
+		}
+		docker["expose_port"] = port
+		raw["docker"] = docker
+		changed = true
+	}
+
+	ports, _ := raw["ports"].(map[string]any)
+	if ports == nil {
+		ports = map[string]any{}
+	}
+	if _, ok := asPort(ports["blue"]); !ok {
+		port, err := allocatePort(w.PortRanges.BlueGreen, used)
+		if err != nil {
+			return false, fmt.Errorf("ports.blue: %w", err)
+		}
+		ports["blue"] = port
+		raw["ports"] = ports
+		changed = true
+	}
+	if _, ok := asPort(ports["green"]); !ok {
+		port, err := allocatePort(w.PortRanges.BlueGreen, used)
+		if err != nil {
+			return false, fmt.Errorf("ports.green: %w", err)
+		}
+		ports["green"] = port
+		raw["ports"] = ports
+		changed = true
+	}
+
+	if proxy, ok := raw["proxy"].(map[string]any); ok {
+		if _, present := proxy["listen_port"]; !present {
+			port, err := allocatePort(w.PortRanges.Proxy, used)
+			if err != nil {
+				return false, fmt.Errorf("proxy.listen_port: %w", err)
+			}
+			proxy["listen_port"] = port
+			raw["proxy"] = proxy
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// asPort reports the positive int value of v, the way a YAML integer
+// decodes under gopkg.in/yaml.v3. A missing key (nil), a non-positive
+// value, or any other type is treated as unset.
+func asPort(v any) (int, bool) {
+	n, ok := v.(int)
+	if !ok || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// allocatePort returns the lowest free port in r not already in used,
+// reserving it before returning.
+func allocatePort(r PortRange, used map[int]bool) (int, error) {
+	for port := r.Start; port <= r.End; port++ {
+		if !used[port] {
+			used[port] = true
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port in range %d-%d", r.Start, r.End)
+}
+
 func getSearchPaths() []string {
 	var paths []string
 
@@ -373,6 +793,14 @@ func (w *Workspace) initializeCaddy() error {
 
 	w.CaddyMgr = caddy.New(configPath, templatePath)
 
+	adminCfg, err := caddy.LoadAdminConfig(w.CaddyDir)
+	if err != nil {
+		return fmt.Errorf("failed to load caddy admin config: %w", err)
+	}
+	if adminCfg.Socket != "" {
+		w.CaddyMgr.SetAdminSocket(adminCfg.Socket)
+	}
+
 	if !w.CaddyMgr.HasTemplate() {
 		if err := w.CaddyMgr.CreateDefaultTemplate(); err != nil {
 			return fmt.Errorf("failed to create default caddy template: %w", err)
@@ -395,6 +823,13 @@ func (w *Workspace) UpdateCaddyConfig() error {
 		return fmt.Errorf("failed to reload caddy: %w", err)
 	}
 
+	if w.Bus != nil {
+		w.Bus.Publish(events.Event{
+			Kind:      events.KindCaddyReloaded,
+			Timestamp: time.Now(),
+		})
+	}
+
 	return nil
 }
 
@@ -403,7 +838,7 @@ func (w *Workspace) ValidateCaddy() error {
 		return fmt.Errorf("caddy manager not initialized")
 	}
 
-	return w.CaddyMgr.ValidateCaddyRunning()
+	return w.CaddyMgr.ValidateRunning()
 }
 
 func (w *Workspace) GetCaddyManager() *caddy.CaddyManager {
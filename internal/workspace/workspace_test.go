@@ -1,14 +1,26 @@
 package workspace
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"dockswap/internal/config"
+	"dockswap/internal/errs"
+	"dockswap/internal/events"
 	"dockswap/internal/state"
 )
 
+type recordingSubscriber struct {
+	received []events.Event
+}
+
+func (r *recordingSubscriber) Notify(e events.Event) {
+	r.received = append(r.received, e)
+}
+
 func TestGetSearchPaths(t *testing.T) {
 	paths := getSearchPaths()
 
@@ -332,6 +344,7 @@ last_updated: "2025-07-23T10:35:00Z"`
 	t.Run("save state", func(t *testing.T) {
 		now := time.Now().UTC()
 		newState := &state.AppState{
+			SchemaVersion:  state.CurrentSchemaVersion,
 			Name:           "test-app",
 			CurrentImage:   "nginx:1.22",
 			DesiredImage:   "nginx:1.22",
@@ -354,6 +367,38 @@ last_updated: "2025-07-23T10:35:00Z"`
 			t.Errorf("SaveState() current_image = %v, want %v", savedState.CurrentImage, "nginx:1.22")
 		}
 	})
+
+	t.Run("save state publishes event", func(t *testing.T) {
+		sub := &recordingSubscriber{}
+		bus := events.NewBus()
+		bus.Subscribe(sub)
+		workspace.SetEventBus(bus)
+
+		now := time.Now().UTC()
+		err := workspace.SaveState("test-app", &state.AppState{
+			SchemaVersion:  state.CurrentSchemaVersion,
+			Name:           "test-app",
+			CurrentImage:   "nginx:1.23",
+			DesiredImage:   "nginx:1.23",
+			ActiveColor:    "green",
+			Status:         "failed",
+			LastDeployment: now,
+			LastUpdated:    now,
+		})
+		if err != nil {
+			t.Fatalf("SaveState() failed: %v", err)
+		}
+
+		if len(sub.received) != 1 {
+			t.Fatalf("expected 1 published event, got %d", len(sub.received))
+		}
+		if sub.received[0].Kind != events.KindDeploymentFailed {
+			t.Errorf("Kind = %v, want %v", sub.received[0].Kind, events.KindDeploymentFailed)
+		}
+		if sub.received[0].AppName != "test-app" {
+			t.Errorf("AppName = %v, want test-app", sub.received[0].AppName)
+		}
+	})
 }
 
 func TestValidateConfigs(t *testing.T) {
@@ -407,6 +452,169 @@ proxy:
 		if err == nil {
 			t.Errorf("RefreshWorkspace() should fail due to port conflicts")
 		}
+		if !errors.Is(err, errs.ErrConfigConflict) {
+			t.Errorf("RefreshWorkspace() err = %v, want errors.Is(err, errs.ErrConfigConflict)", err)
+		}
+	})
+
+	t.Run("port conflict publishes event", func(t *testing.T) {
+		sub := &recordingSubscriber{}
+		bus := events.NewBus()
+		bus.Subscribe(sub)
+		workspace.SetEventBus(bus)
+
+		err := workspace.validatePortConflicts("app2", &config.AppConfig{
+			Name:   "app2",
+			Docker: config.Docker{ExposePort: 8080},
+		})
+		if !errors.Is(err, errs.ErrConfigConflict) {
+			t.Fatalf("validatePortConflicts() err = %v, want errors.Is(err, errs.ErrConfigConflict)", err)
+		}
+
+		if len(sub.received) != 1 {
+			t.Fatalf("expected 1 published event, got %d", len(sub.received))
+		}
+		if sub.received[0].Kind != events.KindPortConflictDetected {
+			t.Errorf("Kind = %v, want %v", sub.received[0].Kind, events.KindPortConflictDetected)
+		}
+	})
+}
+
+func TestLoadWorkspacePicksUpCaddyAdminSocket(t *testing.T) {
+	tempDir := t.TempDir()
+	workspaceRoot := filepath.Join(tempDir, "caddy-admin-test")
+
+	setup, err := InitializeWorkspace(workspaceRoot)
+	if err != nil {
+		t.Fatalf("InitializeWorkspace() failed: %v", err)
+	}
+	caddyDir := filepath.Join(workspaceRoot, CaddySubdir)
+	setup.Close()
+
+	adminYAML := "socket: /var/run/caddy-admin.sock\n"
+	if err := os.WriteFile(filepath.Join(caddyDir, "admin.yaml"), []byte(adminYAML), 0644); err != nil {
+		t.Fatalf("failed to write admin.yaml: %v", err)
+	}
+
+	workspace, err := LoadWorkspace(workspaceRoot)
+	if err != nil {
+		t.Fatalf("LoadWorkspace() failed: %v", err)
+	}
+	defer workspace.Close()
+
+	if workspace.GetCaddyManager().AdminSocket != "/var/run/caddy-admin.sock" {
+		t.Errorf("AdminSocket = %q, want /var/run/caddy-admin.sock", workspace.GetCaddyManager().AdminSocket)
+	}
+}
+
+func TestAllocatePorts(t *testing.T) {
+	tempDir := t.TempDir()
+	workspaceRoot := filepath.Join(tempDir, "allocate-test")
+
+	workspace, err := InitializeWorkspace(workspaceRoot)
+	if err != nil {
+		t.Fatalf("InitializeWorkspace() failed: %v", err)
+	}
+	defer workspace.Close()
+
+	t.Run("fills unset ports and persists them", func(t *testing.T) {
+		unsetYAML := `name: unset-app
+docker: {}
+ports: {}
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200`
+
+		pinnedYAML := `name: pinned-app
+docker:
+  expose_port: 20000
+ports:
+  blue: 20001
+  green: 20002
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200
+proxy:
+  listen_port: 8000`
+
+		unsetPath := filepath.Join(workspace.AppsDir, "unset-app.yaml")
+		if err := os.WriteFile(unsetPath, []byte(unsetYAML), 0644); err != nil {
+			t.Fatalf("Failed to write unset-app config: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(workspace.AppsDir, "pinned-app.yaml"), []byte(pinnedYAML), 0644); err != nil {
+			t.Fatalf("Failed to write pinned-app config: %v", err)
+		}
+
+		if err := workspace.RefreshWorkspace(); err != nil {
+			t.Fatalf("RefreshWorkspace() failed: %v", err)
+		}
+
+		unsetCfg, exists := workspace.GetConfig("unset-app")
+		if !exists {
+			t.Fatalf("unset-app config not loaded")
+		}
+
+		if unsetCfg.Docker.ExposePort != 20003 {
+			t.Errorf("unset-app docker.expose_port = %d, want 20003 (next free slot after the pinned app's range)", unsetCfg.Docker.ExposePort)
+		}
+		if unsetCfg.Ports.Blue != 20004 {
+			t.Errorf("unset-app ports.blue = %d, want 20004", unsetCfg.Ports.Blue)
+		}
+		if unsetCfg.Ports.Green != 20005 {
+			t.Errorf("unset-app ports.green = %d, want 20005", unsetCfg.Ports.Green)
+		}
+
+		// No proxy section was declared, so AllocatePorts must not invent one.
+		if unsetCfg.Proxy.ListenPort != 0 {
+			t.Errorf("unset-app proxy.listen_port = %d, want 0 (no proxy section declared)", unsetCfg.Proxy.ListenPort)
+		}
+
+		persisted, err := os.ReadFile(unsetPath)
+		if err != nil {
+			t.Fatalf("Failed to read back unset-app config: %v", err)
+		}
+
+		reRead, err := config.LoadAppConfig(unsetPath)
+		if err != nil {
+			t.Fatalf("LoadAppConfig() on persisted file failed: %v (raw: %s)", err, persisted)
+		}
+		if reRead.Docker.ExposePort != unsetCfg.Docker.ExposePort {
+			t.Errorf("persisted docker.expose_port = %d, want %d (stable across reloads)", reRead.Docker.ExposePort, unsetCfg.Docker.ExposePort)
+		}
+	})
+
+	t.Run("explicit proxy.listen_port of 0 is left disabled", func(t *testing.T) {
+		disabledYAML := `name: disabled-proxy-app
+docker:
+  expose_port: 21000
+ports:
+  blue: 21001
+  green: 21002
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200
+proxy:
+  listen_port: 0
+  host: example.com`
+
+		if err := os.WriteFile(filepath.Join(workspace.AppsDir, "disabled-proxy-app.yaml"), []byte(disabledYAML), 0644); err != nil {
+			t.Fatalf("Failed to write disabled-proxy-app config: %v", err)
+		}
+
+		if err := workspace.RefreshWorkspace(); err != nil {
+			t.Fatalf("RefreshWorkspace() failed: %v", err)
+		}
+
+		cfg, exists := workspace.GetConfig("disabled-proxy-app")
+		if !exists {
+			t.Fatalf("disabled-proxy-app config not loaded")
+		}
+		if cfg.Proxy.ListenPort != 0 {
+			t.Errorf("disabled-proxy-app proxy.listen_port = %d, want 0 (explicitly disabled)", cfg.Proxy.ListenPort)
+		}
 	})
 }
 
@@ -433,10 +641,11 @@ func TestDiscoverWorkspace(t *testing.T) {
 
 	t.Run("workspace found in current directory", func(t *testing.T) {
 		workspaceRoot := filepath.Join(tempDir, "dockswap-cfg")
-		_, err := InitializeWorkspace(workspaceRoot)
+		setup, err := InitializeWorkspace(workspaceRoot)
 		if err != nil {
 			t.Fatalf("InitializeWorkspace() failed: %v", err)
 		}
+		setup.Close()
 
 		workspace, err := DiscoverWorkspace()
 		if err != nil {
@@ -0,0 +1,220 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"dockswap/internal/errs"
+)
+
+// WorkspacesEnvVar, if set, lists named workspaces as comma-separated
+// "name=root" pairs (e.g. "staging=/srv/dockswap-staging,prod=/srv/dockswap-prod"),
+// taking precedence over WorkspacesConfigFilename so a single host can be
+// pointed at a different registry per systemd unit/container without a file
+// on disk.
+const WorkspacesEnvVar = "DOCKSWAP_WORKSPACES"
+
+// WorkspacesConfigFilename is the registry of named workspace roots read
+// from "$HOME/.dockswap-cfg/workspaces.yaml" when WorkspacesEnvVar is unset,
+// letting an operator running e.g. staging and prod on the same host give
+// each a name to target with --workspace instead of remembering paths.
+const WorkspacesConfigFilename = "workspaces.yaml"
+
+// NamedWorkspace is one entry in the workspaces registry: a human-facing
+// Name (used for --workspace=Name and the "Name/app" merged ListApps
+// naming) and the filesystem Root LoadWorkspace opens it from.
+type NamedWorkspace struct {
+	Name string `yaml:"name"`
+	Root string `yaml:"root"`
+}
+
+// workspacesConfigFile is the shape of workspaces.yaml: a plain list under
+// a top-level "workspaces" key.
+type workspacesConfigFile struct {
+	Workspaces []NamedWorkspace `yaml:"workspaces"`
+}
+
+// loadNamedWorkspaces returns the registry of named workspace roots from
+// WorkspacesEnvVar if set, else WorkspacesConfigFilename under
+// os.UserHomeDir, else an empty (not error) list - a host with neither
+// configured simply has no named workspaces to target.
+func loadNamedWorkspaces() ([]NamedWorkspace, error) {
+	if env := os.Getenv(WorkspacesEnvVar); env != "" {
+		return parseWorkspacesEnv(env)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil
+	}
+
+	path := filepath.Join(home, ".dockswap-cfg", WorkspacesConfigFilename)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg workspacesConfigFile
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg.Workspaces, nil
+}
+
+// parseWorkspacesEnv parses WorkspacesEnvVar's "name=root,name2=root2" form.
+func parseWorkspacesEnv(env string) ([]NamedWorkspace, error) {
+	var named []NamedWorkspace
+	for _, entry := range strings.Split(env, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, root, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s entry %q: want name=root", WorkspacesEnvVar, entry)
+		}
+		named = append(named, NamedWorkspace{Name: name, Root: root})
+	}
+	return named, nil
+}
+
+// ResolveWorkspaceRoot looks up name in the workspaces registry
+// (WorkspacesEnvVar or WorkspacesConfigFilename) and returns its root, for
+// callers (cli.FindConfigDir's --workspace counterpart) that just need a
+// path and don't want a full Workspaces aggregator opened.
+func ResolveWorkspaceRoot(name string) (string, error) {
+	named, err := loadNamedWorkspaces()
+	if err != nil {
+		return "", err
+	}
+	for _, nw := range named {
+		if nw.Name == name {
+			return nw.Root, nil
+		}
+	}
+	return "", fmt.Errorf("no workspace named %q in the workspaces registry", name)
+}
+
+// Workspaces aggregates every workspace in the registry behind merged
+// ListApps and cross-workspace port-conflict validation, so two
+// independently-initialized workspaces on the same host (e.g. staging and
+// prod) can't silently allocate the same host port.
+type Workspaces struct {
+	byName map[string]*Workspace
+	// names preserves registry order so ListApps/Close iterate
+	// deterministically instead of at map-iteration's mercy.
+	names []string
+}
+
+// OpenWorkspaces loads every workspace in the registry (WorkspacesEnvVar or
+// WorkspacesConfigFilename) with LoadWorkspaceNoWait - opening several
+// workspaces must not block on a lock one of them can't currently get - and
+// validates that none of them allocate a conflicting host port. Any
+// workspace that fails to load or conflicts causes the ones already opened
+// to be closed before returning the error.
+func OpenWorkspaces() (*Workspaces, error) {
+	named, err := loadNamedWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &Workspaces{byName: make(map[string]*Workspace, len(named))}
+	for _, nw := range named {
+		w, err := LoadWorkspaceNoWait(nw.Root)
+		if err != nil {
+			ws.Close()
+			return nil, fmt.Errorf("failed to load workspace %q at %s: %w", nw.Name, nw.Root, err)
+		}
+		if err := w.RefreshWorkspace(); err != nil {
+			ws.byName[nw.Name] = w
+			ws.names = append(ws.names, nw.Name)
+			ws.Close()
+			return nil, fmt.Errorf("failed to load workspace %q at %s: %w", nw.Name, nw.Root, err)
+		}
+		ws.byName[nw.Name] = w
+		ws.names = append(ws.names, nw.Name)
+	}
+
+	if err := ws.validateGlobalPortConflicts(); err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// Get returns the named workspace, if the registry has one by that name.
+func (ws *Workspaces) Get(name string) (*Workspace, bool) {
+	w, exists := ws.byName[name]
+	return w, exists
+}
+
+// ListApps returns every app across every workspace, named "workspace/app"
+// so two workspaces with an identically-named app (e.g. both staging and
+// prod deploying "web") remain distinguishable.
+func (ws *Workspaces) ListApps() []string {
+	var apps []string
+	for _, name := range ws.names {
+		for _, appName := range ws.byName[name].ListApps() {
+			apps = append(apps, name+"/"+appName)
+		}
+	}
+	return apps
+}
+
+// Close closes every opened workspace, attempting all of them even if one
+// fails, and returns the first error encountered.
+func (ws *Workspaces) Close() error {
+	var firstErr error
+	for _, name := range ws.names {
+		if err := ws.byName[name].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// validateGlobalPortConflicts extends Workspace.validatePortConflicts
+// across workspace boundaries: the same host only has one of each port
+// regardless of which workspace's app claims it.
+func (ws *Workspaces) validateGlobalPortConflicts() error {
+	usedPorts := make(map[int]string)
+
+	checkPort := func(label string, port int, portType string) error {
+		if port <= 0 {
+			return nil
+		}
+		if existingLabel, exists := usedPorts[port]; exists {
+			return errs.Wrap(errs.ErrConfigConflict, nil, "port conflict: %s %s port %d conflicts with %s", label, portType, port, existingLabel)
+		}
+		usedPorts[port] = label
+		return nil
+	}
+
+	for _, name := range ws.names {
+		for appName, appConfig := range ws.byName[name].Configs {
+			label := name + "/" + appName
+			if err := checkPort(label, appConfig.Docker.ExposePort, "expose"); err != nil {
+				return err
+			}
+			if err := checkPort(label, appConfig.Ports.Blue, "blue"); err != nil {
+				return err
+			}
+			if err := checkPort(label, appConfig.Ports.Green, "green"); err != nil {
+				return err
+			}
+			if err := checkPort(label, appConfig.Proxy.ListenPort, "proxy"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dockswap/internal/errs"
+)
+
+func TestParseWorkspacesEnv(t *testing.T) {
+	t.Run("parses name=root pairs", func(t *testing.T) {
+		named, err := parseWorkspacesEnv("staging=/srv/staging, prod=/srv/prod")
+		if err != nil {
+			t.Fatalf("parseWorkspacesEnv() failed: %v", err)
+		}
+		want := []NamedWorkspace{{Name: "staging", Root: "/srv/staging"}, {Name: "prod", Root: "/srv/prod"}}
+		if len(named) != len(want) || named[0] != want[0] || named[1] != want[1] {
+			t.Errorf("parseWorkspacesEnv() = %+v, want %+v", named, want)
+		}
+	})
+
+	t.Run("rejects entry with no =", func(t *testing.T) {
+		if _, err := parseWorkspacesEnv("staging"); err == nil {
+			t.Errorf("expected error for entry missing '=', got nil")
+		}
+	})
+}
+
+const appConfigWithPorts = `name: %s
+docker:
+  expose_port: %d
+ports:
+  blue: %d
+  green: %d
+health_check:
+  retries: 3
+  success_threshold: 2
+  expected_status: 200
+`
+
+// writeAppConfig writes an app config with explicit (non-conflicting)
+// blue/green ports so the test controls every port this app claims,
+// instead of depending on AllocatePorts' starting point - which, left
+// unset, is identical for the first app in any workspace and would make
+// every two-single-app-workspace pair conflict regardless of exposePort.
+func writeAppConfig(t *testing.T, w *Workspace, appName string, exposePort, bluePort, greenPort int) {
+	t.Helper()
+	content := fmt.Sprintf(appConfigWithPorts, appName, exposePort, bluePort, greenPort)
+	if err := os.WriteFile(filepath.Join(w.AppsDir, appName+".yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s config: %v", appName, err)
+	}
+}
+
+func TestOpenWorkspaces(t *testing.T) {
+	t.Run("merges ListApps with workspace/app naming", func(t *testing.T) {
+		tempDir := t.TempDir()
+		stagingRoot := filepath.Join(tempDir, "staging")
+		prodRoot := filepath.Join(tempDir, "prod")
+
+		staging, err := InitializeWorkspace(stagingRoot)
+		if err != nil {
+			t.Fatalf("InitializeWorkspace(staging) failed: %v", err)
+		}
+		writeAppConfig(t, staging, "web", 8080, 8081, 8082)
+		staging.Close()
+
+		prod, err := InitializeWorkspace(prodRoot)
+		if err != nil {
+			t.Fatalf("InitializeWorkspace(prod) failed: %v", err)
+		}
+		writeAppConfig(t, prod, "web", 8090, 8091, 8092)
+		prod.Close()
+
+		t.Setenv(WorkspacesEnvVar, "staging="+stagingRoot+",prod="+prodRoot)
+
+		ws, err := OpenWorkspaces()
+		if err != nil {
+			t.Fatalf("OpenWorkspaces() failed: %v", err)
+		}
+		defer ws.Close()
+
+		apps := ws.ListApps()
+		if len(apps) != 2 {
+			t.Fatalf("ListApps() = %v, want 2 entries", apps)
+		}
+		want := map[string]bool{"staging/web": true, "prod/web": true}
+		for _, a := range apps {
+			if !want[a] {
+				t.Errorf("unexpected app name %q", a)
+			}
+		}
+
+		if _, exists := ws.Get("staging"); !exists {
+			t.Errorf("Get(staging) not found")
+		}
+		if _, exists := ws.Get("nonexistent"); exists {
+			t.Errorf("Get(nonexistent) unexpectedly found")
+		}
+	})
+
+	t.Run("detects port conflict across workspaces", func(t *testing.T) {
+		tempDir := t.TempDir()
+		stagingRoot := filepath.Join(tempDir, "staging")
+		prodRoot := filepath.Join(tempDir, "prod")
+
+		staging, err := InitializeWorkspace(stagingRoot)
+		if err != nil {
+			t.Fatalf("InitializeWorkspace(staging) failed: %v", err)
+		}
+		writeAppConfig(t, staging, "web", 9090, 9091, 9092)
+		staging.Close()
+
+		prod, err := InitializeWorkspace(prodRoot)
+		if err != nil {
+			t.Fatalf("InitializeWorkspace(prod) failed: %v", err)
+		}
+		writeAppConfig(t, prod, "api", 9090, 9191, 9192)
+		prod.Close()
+
+		t.Setenv(WorkspacesEnvVar, "staging="+stagingRoot+",prod="+prodRoot)
+
+		_, err = OpenWorkspaces()
+		if !errors.Is(err, errs.ErrConfigConflict) {
+			t.Fatalf("OpenWorkspaces() err = %v, want errors.Is(err, errs.ErrConfigConflict)", err)
+		}
+	})
+}
+
+func TestResolveWorkspaceRoot(t *testing.T) {
+	t.Run("resolves from env var", func(t *testing.T) {
+		t.Setenv(WorkspacesEnvVar, "staging=/srv/staging")
+
+		root, err := ResolveWorkspaceRoot("staging")
+		if err != nil {
+			t.Fatalf("ResolveWorkspaceRoot() failed: %v", err)
+		}
+		if root != "/srv/staging" {
+			t.Errorf("ResolveWorkspaceRoot() = %q, want /srv/staging", root)
+		}
+	})
+
+	t.Run("unknown name errors", func(t *testing.T) {
+		t.Setenv(WorkspacesEnvVar, "staging=/srv/staging")
+
+		if _, err := ResolveWorkspaceRoot("prod"); err == nil {
+			t.Errorf("expected error for unknown workspace name, got nil")
+		}
+	})
+}
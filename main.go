@@ -1,41 +1,88 @@
 package main
 
 import (
+	"context"
 	"dockswap/internal/cli"
 	"dockswap/internal/config"
 	"dockswap/internal/logger"
 	"dockswap/internal/state"
+	"io"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 )
 
 func main() {
-	// Parse --config and --log-level from os.Args
+	// Parse --config, --log-level, --log-format and --log-file from os.Args
 	flags := cli.GlobalFlags{
 		LogLevel: logger.LevelInfo, // Default to info level
 	}
+	var logFormat, logFile string
 	for i := 1; i < len(os.Args); i++ {
 		if os.Args[i] == "--config" && i+1 < len(os.Args) {
 			flags.Config = os.Args[i+1]
 			i++
 		} else if len(os.Args[i]) > 9 && os.Args[i][:9] == "--config=" {
 			flags.Config = os.Args[i][9:]
+		} else if os.Args[i] == "--workspace" && i+1 < len(os.Args) {
+			flags.Workspace = os.Args[i+1]
+			i++
+		} else if strings.HasPrefix(os.Args[i], "--workspace=") {
+			flags.Workspace = strings.TrimPrefix(os.Args[i], "--workspace=")
 		} else if os.Args[i] == "--log-level" && i+1 < len(os.Args) {
-			if level, err := strconv.Atoi(os.Args[i+1]); err == nil && level >= 1 && level <= 3 {
+			if level, err := strconv.Atoi(os.Args[i+1]); err == nil && level >= 1 && level <= 5 {
 				flags.LogLevel = level
 			}
 			i++
 		} else if strings.HasPrefix(os.Args[i], "--log-level=") {
 			levelStr := strings.TrimPrefix(os.Args[i], "--log-level=")
-			if level, err := strconv.Atoi(levelStr); err == nil && level >= 1 && level <= 3 {
+			if level, err := strconv.Atoi(levelStr); err == nil && level >= 1 && level <= 5 {
 				flags.LogLevel = level
 			}
+		} else if os.Args[i] == "--log-format" && i+1 < len(os.Args) {
+			logFormat = os.Args[i+1]
+			i++
+		} else if strings.HasPrefix(os.Args[i], "--log-format=") {
+			logFormat = strings.TrimPrefix(os.Args[i], "--log-format=")
+		} else if os.Args[i] == "--log-file" && i+1 < len(os.Args) {
+			logFile = os.Args[i+1]
+			i++
+		} else if strings.HasPrefix(os.Args[i], "--log-file=") {
+			logFile = strings.TrimPrefix(os.Args[i], "--log-file=")
+		}
+	}
+
+	// Initialize logger with the parsed level, formatter and sink. --log-file
+	// accepts the special value "syslog" to ship logs to the local syslog
+	// daemon (journald on most distros) instead of a path, the same way
+	// Docker's daemon/logger drivers are picked by name.
+	var sink io.Writer = os.Stderr
+	if logFile == "syslog" {
+		syslogSink, err := logger.NewSyslogSink("dockswap")
+		if err != nil {
+			// stderr is still usable even if syslog isn't; report and fall
+			// back rather than failing startup over a logging backend.
+			os.Stderr.WriteString("Failed to connect to syslog, falling back to stderr: " + err.Error() + "\n")
+		} else {
+			sink = syslogSink
+		}
+	} else if logFile != "" {
+		fileSink, err := logger.NewFileSink(logFile)
+		if err != nil {
+			os.Stderr.WriteString("Failed to open log file, falling back to stderr: " + err.Error() + "\n")
+		} else {
+			sink = fileSink
 		}
 	}
 
-	// Initialize logger with the parsed log level
-	log := logger.New(flags.LogLevel)
+	var formatter logger.Formatter = logger.TextFormatter{}
+	if logFormat == "json" {
+		formatter = logger.JSONFormatter{}
+	}
+
+	log := logger.NewWithFormatter(flags.LogLevel, sink, formatter)
 
 	configDir, err := cli.FindConfigDir(flags, nil, nil, nil)
 	if err != nil {
@@ -57,7 +104,13 @@ func main() {
 	}
 	defer db.Close()
 
-	c := cli.New(db, log)
+	// ctx is canceled on the first SIGINT/SIGTERM, so a Ctrl+C during a
+	// one-shot command's blocking Docker calls (health check polling, a
+	// blue/green cutover) aborts it cleanly instead of orphaning containers.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := cli.New(ctx, db, log)
 
 	// Load app configurations
 	if err := c.LoadConfigs(configDir); err != nil {
@@ -65,7 +118,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := c.Run(os.Args[1:]); err != nil {
+	if err := c.Run(ctx, os.Args[1:]); err != nil {
 		log.Error("Error: %v", err)
 		os.Exit(1)
 	}